@@ -30,18 +30,23 @@ const (
 	READ      Permission_Type = 0
 	WRITE     Permission_Type = 1
 	READWRITE Permission_Type = 2
+	// ADMIN allows managing the users and roles named within the range and
+	// granting permissions on keys within it, without the root role.
+	ADMIN Permission_Type = 3
 )
 
 var Permission_Type_name = map[int32]string{
 	0: "READ",
 	1: "WRITE",
 	2: "READWRITE",
+	3: "ADMIN",
 }
 
 var Permission_Type_value = map[string]int32{
 	"READ":      0,
 	"WRITE":     1,
 	"READWRITE": 2,
+	"ADMIN":     3,
 }
 
 func (x Permission_Type) String() string {
@@ -232,29 +237,29 @@ func init() {
 func init() { proto.RegisterFile("auth.proto", fileDescriptor_8bbd6f3875b0e874) }
 
 var fileDescriptor_8bbd6f3875b0e874 = []byte{
-	// 338 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xcf, 0x4e, 0xea, 0x40,
-	0x14, 0xc6, 0x3b, 0xb4, 0x70, 0xdb, 0xc3, 0x85, 0x90, 0x13, 0x72, 0x6f, 0x83, 0x49, 0x6d, 0xba,
-	0x6a, 0x5c, 0x54, 0x85, 0x8d, 0x5b, 0x8c, 0x2c, 0x5c, 0x49, 0x26, 0x18, 0x97, 0xa4, 0xa4, 0x13,
-	0x24, 0xc0, 0x4c, 0x33, 0x83, 0x31, 0x6c, 0x7c, 0x0e, 0x17, 0x3e, 0x10, 0x4b, 0x1e, 0x41, 0xf0,
-	0x45, 0x4c, 0x67, 0xf8, 0x13, 0xa2, 0xbb, 0xef, 0x7c, 0xe7, 0xfb, 0x66, 0x7e, 0x99, 0x01, 0x48,
-	0x5f, 0x16, 0xcf, 0x49, 0x2e, 0xc5, 0x42, 0x60, 0xa5, 0xd0, 0xf9, 0xa8, 0xd5, 0x1c, 0x8b, 0xb1,
-	0xd0, 0xd6, 0x65, 0xa1, 0xcc, 0x36, 0xba, 0x86, 0xfa, 0xa3, 0x62, 0xb2, 0x9b, 0x65, 0x0f, 0xf9,
-	0x62, 0x22, 0xb8, 0xc2, 0x73, 0xa8, 0x72, 0x31, 0xcc, 0x53, 0xa5, 0x5e, 0x85, 0xcc, 0x7c, 0x12,
-	0x92, 0xd8, 0xa5, 0xc0, 0x45, 0x7f, 0xe7, 0x44, 0x6f, 0xe0, 0x14, 0x15, 0x44, 0x70, 0x78, 0x3a,
-	0x67, 0x3a, 0xf1, 0x97, 0x6a, 0x8d, 0x2d, 0x70, 0x0f, 0xcd, 0x92, 0xf6, 0x0f, 0x33, 0x36, 0xa1,
-	0x2c, 0xc5, 0x8c, 0x29, 0xdf, 0x0e, 0xed, 0xd8, 0xa3, 0x66, 0xc0, 0x2b, 0xf8, 0x23, 0xcc, 0xcd,
-	0xbe, 0x13, 0x92, 0xb8, 0xda, 0xfe, 0x97, 0x18, 0xe0, 0xe4, 0x94, 0x8b, 0xee, 0x63, 0xd1, 0x07,
-	0x01, 0xe8, 0x33, 0x39, 0x9f, 0x28, 0x35, 0x11, 0x1c, 0x3b, 0xe0, 0xe6, 0x4c, 0xce, 0x07, 0xcb,
-	0xdc, 0xa0, 0xd4, 0xdb, 0xff, 0xf7, 0x27, 0x1c, 0x53, 0x49, 0xb1, 0xa6, 0x87, 0x20, 0x36, 0xc0,
-	0x9e, 0xb2, 0xe5, 0x0e, 0xb1, 0x90, 0x78, 0x06, 0x9e, 0x4c, 0xf9, 0x98, 0x0d, 0x19, 0xcf, 0x7c,
-	0xdb, 0xa0, 0x6b, 0xa3, 0xc7, 0xb3, 0xe8, 0x02, 0x1c, 0x5d, 0x73, 0xc1, 0xa1, 0xbd, 0xee, 0x5d,
-	0xc3, 0x42, 0x0f, 0xca, 0x4f, 0xf4, 0x7e, 0xd0, 0x6b, 0x10, 0xac, 0x81, 0x57, 0x98, 0x66, 0x2c,
-	0x45, 0x03, 0x70, 0xa8, 0x98, 0xb1, 0x5f, 0x9f, 0xe7, 0x06, 0x6a, 0x53, 0xb6, 0x3c, 0x62, 0xf9,
-	0xa5, 0xd0, 0x8e, 0xab, 0x6d, 0xfc, 0x09, 0x4c, 0x4f, 0x83, 0xb7, 0xfe, 0x6a, 0x13, 0x58, 0xeb,
-	0x4d, 0x60, 0xad, 0xb6, 0x01, 0x59, 0x6f, 0x03, 0xf2, 0xb9, 0x0d, 0xc8, 0xfb, 0x57, 0x60, 0x8d,
-	0x2a, 0xfa, 0x23, 0x3b, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x61, 0x66, 0xc6, 0x9d, 0xf4, 0x01,
-	0x00, 0x00,
+	// 342 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x4f, 0x4f, 0xc2, 0x30,
+	0x18, 0xc6, 0x57, 0x36, 0x70, 0x7b, 0x11, 0xb2, 0x34, 0x44, 0x17, 0x4c, 0xe6, 0xb2, 0xd3, 0x4e,
+	0x53, 0x21, 0x26, 0x5e, 0x31, 0x70, 0xe0, 0xa0, 0x92, 0x06, 0xe3, 0x91, 0x8c, 0xac, 0x41, 0x02,
+	0xb4, 0xcb, 0x8a, 0x31, 0x5c, 0xfc, 0x1c, 0x7e, 0x03, 0xbf, 0x0a, 0x47, 0x3e, 0x82, 0xe0, 0x17,
+	0x31, 0x6d, 0xf9, 0x13, 0xa2, 0xb7, 0xe7, 0x7d, 0xde, 0xe7, 0x69, 0x7f, 0x69, 0x01, 0x92, 0xb7,
+	0xf9, 0x6b, 0x9c, 0xe5, 0x7c, 0xce, 0x71, 0x49, 0xea, 0x6c, 0x58, 0xaf, 0x8d, 0xf8, 0x88, 0x2b,
+	0xeb, 0x4a, 0x2a, 0xbd, 0x0d, 0x6f, 0xa0, 0xfa, 0x2c, 0x68, 0xde, 0x4a, 0xd3, 0xa7, 0x6c, 0x3e,
+	0xe6, 0x4c, 0xe0, 0x4b, 0x28, 0x33, 0x3e, 0xc8, 0x12, 0x21, 0xde, 0x79, 0x9e, 0x7a, 0x28, 0x40,
+	0x91, 0x4d, 0x80, 0xf1, 0xde, 0xd6, 0x09, 0x3f, 0xc0, 0x92, 0x15, 0x8c, 0xc1, 0x62, 0xc9, 0x8c,
+	0xaa, 0xc4, 0x29, 0x51, 0x1a, 0xd7, 0xc1, 0xde, 0x37, 0x0b, 0xca, 0xdf, 0xcf, 0xb8, 0x06, 0xc5,
+	0x9c, 0x4f, 0xa9, 0xf0, 0xcc, 0xc0, 0x8c, 0x1c, 0xa2, 0x07, 0x7c, 0x0d, 0x27, 0x5c, 0xdf, 0xec,
+	0x59, 0x01, 0x8a, 0xca, 0x8d, 0xb3, 0x58, 0x03, 0xc7, 0xc7, 0x5c, 0x64, 0x17, 0x0b, 0xbf, 0x10,
+	0x40, 0x8f, 0xe6, 0xb3, 0xb1, 0x10, 0x63, 0xce, 0x70, 0x13, 0xec, 0x8c, 0xe6, 0xb3, 0xfe, 0x22,
+	0xd3, 0x28, 0xd5, 0xc6, 0xf9, 0xee, 0x84, 0x43, 0x2a, 0x96, 0x6b, 0xb2, 0x0f, 0x62, 0x17, 0xcc,
+	0x09, 0x5d, 0x6c, 0x11, 0xa5, 0xc4, 0x17, 0xe0, 0xe4, 0x09, 0x1b, 0xd1, 0x01, 0x65, 0xa9, 0x67,
+	0x6a, 0x74, 0x65, 0x74, 0x58, 0x1a, 0xde, 0x82, 0xa5, 0x6a, 0x36, 0x58, 0xa4, 0xd3, 0x6a, 0xbb,
+	0x06, 0x76, 0xa0, 0xf8, 0x42, 0xba, 0xfd, 0x8e, 0x8b, 0x70, 0x05, 0x1c, 0x69, 0xea, 0xb1, 0x20,
+	0x37, 0xad, 0xf6, 0x43, 0xf7, 0xd1, 0x35, 0xc3, 0x3e, 0x58, 0x84, 0x4f, 0xe9, 0xbf, 0x2f, 0x75,
+	0x07, 0x95, 0x09, 0x5d, 0x1c, 0x08, 0xbd, 0x42, 0x60, 0x46, 0xe5, 0x06, 0xfe, 0xcb, 0x4e, 0x8e,
+	0x83, 0xf7, 0xde, 0x72, 0xed, 0x1b, 0xab, 0xb5, 0x6f, 0x2c, 0x37, 0x3e, 0x5a, 0x6d, 0x7c, 0xf4,
+	0xbd, 0xf1, 0xd1, 0xe7, 0x8f, 0x6f, 0x0c, 0x4b, 0xea, 0x4f, 0x9b, 0xbf, 0x03, 0x00, 0x16, 0x8d,
+	0xa9, 0x80, 0xff, 0x01, 0x00, 0x00,
 }
 
 func (m *UserAddOptions) Marshal() (dAtA []byte, err error) {
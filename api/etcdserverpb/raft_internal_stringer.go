@@ -40,6 +40,12 @@ func (as *InternalRaftStringer) String() string {
 			as.Request.Header.String(),
 			as.Request.LeaseRevoke.ID,
 		)
+	case as.Request.LeaseExpire != nil:
+		return fmt.Sprintf("header:<%s> lease_expire:<ids_count:%d max_keys:%d>",
+			as.Request.Header.String(),
+			len(as.Request.LeaseExpire.IDs),
+			as.Request.LeaseExpire.MaxKeys,
+		)
 	case as.Request.Authenticate != nil:
 		return fmt.Sprintf("header:<%s> authenticate:<name:%s simple_token:%s>",
 			as.Request.Header.String(),
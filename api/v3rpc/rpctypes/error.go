@@ -32,10 +32,25 @@ var (
 	ErrGRPCCompacted               = status.New(codes.OutOfRange, "etcdserver: mvcc: required revision has been compacted").Err()
 	ErrGRPCFutureRev               = status.New(codes.OutOfRange, "etcdserver: mvcc: required revision is a future revision").Err()
 	ErrGRPCNoSpace                 = status.New(codes.ResourceExhausted, "etcdserver: mvcc: database space exceeded").Err()
+	ErrGRPCReadOnly                = status.New(codes.FailedPrecondition, "etcdserver: cluster is in read-only mode").Err()
 
-	ErrGRPCLeaseNotFound    = status.New(codes.NotFound, "etcdserver: requested lease not found").Err()
-	ErrGRPCLeaseExist       = status.New(codes.FailedPrecondition, "etcdserver: lease already exists").Err()
-	ErrGRPCLeaseTTLTooLarge = status.New(codes.OutOfRange, "etcdserver: too large lease TTL").Err()
+	ErrGRPCLeaseNotFound       = status.New(codes.NotFound, "etcdserver: requested lease not found").Err()
+	ErrGRPCLeaseExist          = status.New(codes.FailedPrecondition, "etcdserver: lease already exists").Err()
+	ErrGRPCLeaseTTLTooLarge    = status.New(codes.OutOfRange, "etcdserver: too large lease TTL").Err()
+	ErrGRPCLeaseTTLNegative    = status.New(codes.InvalidArgument, "etcdserver: negative lease TTL").Err()
+	ErrGRPCLeaseLabelsTooLarge = status.New(codes.InvalidArgument, "etcdserver: too large lease labels").Err()
+
+	ErrGRPCNamespaceEmpty            = status.New(codes.InvalidArgument, "etcdserver: namespace prefix is empty").Err()
+	ErrGRPCNamespaceNotFound         = status.New(codes.NotFound, "etcdserver: namespace not found").Err()
+	ErrGRPCNamespaceQuotaExceeded    = status.New(codes.ResourceExhausted, "etcdserver: namespace quota exceeded").Err()
+	ErrGRPCNamespaceLeaseTTLTooLarge = status.New(codes.OutOfRange, "etcdserver: lease TTL exceeds the namespace limit").Err()
+
+	ErrGRPCIndexNameEmpty    = status.New(codes.InvalidArgument, "etcdserver: index name is empty").Err()
+	ErrGRPCIndexFieldInvalid = status.New(codes.InvalidArgument, "etcdserver: invalid index field path").Err()
+	ErrGRPCIndexNotFound     = status.New(codes.NotFound, "etcdserver: index not found").Err()
+
+	ErrGRPCRequestIDTooLong  = status.New(codes.InvalidArgument, "etcdserver: request id is too long").Err()
+	ErrGRPCRequestIDConflict = status.New(codes.FailedPrecondition, "etcdserver: request id is already used by a different request").Err()
 
 	ErrGRPCWatchCanceled = status.New(codes.Canceled, "etcdserver: watch canceled").Err()
 
@@ -47,9 +62,11 @@ var (
 	ErrGRPCMemberNotLearner       = status.New(codes.FailedPrecondition, "etcdserver: can only promote a learner member").Err()
 	ErrGRPCLearnerNotReady        = status.New(codes.FailedPrecondition, "etcdserver: can only promote a learner member which is in sync with leader").Err()
 	ErrGRPCTooManyLearners        = status.New(codes.FailedPrecondition, "etcdserver: too many learner members in cluster").Err()
+	ErrGRPCWitnessLearner         = status.New(codes.InvalidArgument, "etcdserver: a witness member cannot be a learner").Err()
 
 	ErrGRPCRequestTooLarge        = status.New(codes.InvalidArgument, "etcdserver: request is too large").Err()
 	ErrGRPCRequestTooManyRequests = status.New(codes.ResourceExhausted, "etcdserver: too many requests").Err()
+	ErrGRPCTooManyConnections     = status.New(codes.Unavailable, "etcdserver: too many client connections").Err()
 
 	ErrGRPCRootUserNotExist     = status.New(codes.FailedPrecondition, "etcdserver: root user does not exist").Err()
 	ErrGRPCRootRoleNotExist     = status.New(codes.FailedPrecondition, "etcdserver: root user does not have root role").Err()
@@ -81,6 +98,7 @@ var (
 	ErrGRPCUnhealthy                  = status.New(codes.Unavailable, "etcdserver: unhealthy cluster").Err()
 	ErrGRPCCorrupt                    = status.New(codes.DataLoss, "etcdserver: corrupt cluster").Err()
 	ErrGRPCNotSupportedForLearner     = status.New(codes.FailedPrecondition, "etcdserver: rpc not supported for learner").Err()
+	ErrGRPCNotSupportedForWitness     = status.New(codes.FailedPrecondition, "etcdserver: rpc not supported for witness").Err()
 	ErrGRPCBadLeaderTransferee        = status.New(codes.FailedPrecondition, "etcdserver: bad leader transferee").Err()
 
 	ErrGRPCWrongDowngradeVersionFormat   = status.New(codes.InvalidArgument, "etcdserver: wrong downgrade target version format").Err()
@@ -89,6 +107,20 @@ var (
 	ErrGRPCDowngradeInProcess            = status.New(codes.FailedPrecondition, "etcdserver: cluster has a downgrade job in progress").Err()
 	ErrGRPCNoInflightDowngrade           = status.New(codes.FailedPrecondition, "etcdserver: no inflight downgrade job").Err()
 
+	ErrGRPCImportRevision        = status.New(codes.InvalidArgument, "etcdserver: mvcc: import revision must be greater than the current revision").Err()
+	ErrGRPCImportKeyOrder        = status.New(codes.InvalidArgument, "etcdserver: mvcc: imported keys must be sorted and unique").Err()
+	ErrGRPCImportNotSingleMember = status.New(codes.FailedPrecondition, "etcdserver: bulk import requires a single member cluster").Err()
+	ErrGRPCImportInProgress      = status.New(codes.Unavailable, "etcdserver: bulk import in progress").Err()
+
+	ErrGRPCRangeRateLimitExceeded = status.New(codes.ResourceExhausted, "etcdserver: range rate limit exceeded").Err()
+
+	ErrGRPCSnapshotNotFound      = status.New(codes.NotFound, "etcdserver: snapshot to resume not found").Err()
+	ErrGRPCInvalidSnapshotOffset = status.New(codes.InvalidArgument, "etcdserver: snapshot offset beyond the snapshot size").Err()
+
+	ErrGRPCUnknownLogModule      = status.New(codes.InvalidArgument, "etcdserver: unknown log module").Err()
+	ErrGRPCInvalidLogLevel       = status.New(codes.InvalidArgument, "etcdserver: invalid log level").Err()
+	ErrGRPCLogLevelsUnconfigured = status.New(codes.FailedPrecondition, "etcdserver: log levels are not configurable").Err()
+
 	ErrGRPCCanceled         = status.New(codes.Canceled, "etcdserver: request canceled").Err()
 	ErrGRPCDeadlineExceeded = status.New(codes.DeadlineExceeded, "etcdserver: context deadline exceeded").Err()
 
@@ -104,10 +136,25 @@ var (
 		ErrorDesc(ErrGRPCCompacted):         ErrGRPCCompacted,
 		ErrorDesc(ErrGRPCFutureRev):         ErrGRPCFutureRev,
 		ErrorDesc(ErrGRPCNoSpace):           ErrGRPCNoSpace,
+		ErrorDesc(ErrGRPCReadOnly):          ErrGRPCReadOnly,
+
+		ErrorDesc(ErrGRPCLeaseNotFound):       ErrGRPCLeaseNotFound,
+		ErrorDesc(ErrGRPCLeaseExist):          ErrGRPCLeaseExist,
+		ErrorDesc(ErrGRPCLeaseTTLTooLarge):    ErrGRPCLeaseTTLTooLarge,
+		ErrorDesc(ErrGRPCLeaseTTLNegative):    ErrGRPCLeaseTTLNegative,
+		ErrorDesc(ErrGRPCLeaseLabelsTooLarge): ErrGRPCLeaseLabelsTooLarge,
+
+		ErrorDesc(ErrGRPCNamespaceEmpty):            ErrGRPCNamespaceEmpty,
+		ErrorDesc(ErrGRPCNamespaceNotFound):         ErrGRPCNamespaceNotFound,
+		ErrorDesc(ErrGRPCNamespaceQuotaExceeded):    ErrGRPCNamespaceQuotaExceeded,
+		ErrorDesc(ErrGRPCNamespaceLeaseTTLTooLarge): ErrGRPCNamespaceLeaseTTLTooLarge,
 
-		ErrorDesc(ErrGRPCLeaseNotFound):    ErrGRPCLeaseNotFound,
-		ErrorDesc(ErrGRPCLeaseExist):       ErrGRPCLeaseExist,
-		ErrorDesc(ErrGRPCLeaseTTLTooLarge): ErrGRPCLeaseTTLTooLarge,
+		ErrorDesc(ErrGRPCIndexNameEmpty):    ErrGRPCIndexNameEmpty,
+		ErrorDesc(ErrGRPCIndexFieldInvalid): ErrGRPCIndexFieldInvalid,
+		ErrorDesc(ErrGRPCIndexNotFound):     ErrGRPCIndexNotFound,
+
+		ErrorDesc(ErrGRPCRequestIDTooLong):  ErrGRPCRequestIDTooLong,
+		ErrorDesc(ErrGRPCRequestIDConflict): ErrGRPCRequestIDConflict,
 
 		ErrorDesc(ErrGRPCMemberExist):            ErrGRPCMemberExist,
 		ErrorDesc(ErrGRPCPeerURLExist):           ErrGRPCPeerURLExist,
@@ -117,9 +164,11 @@ var (
 		ErrorDesc(ErrGRPCMemberNotLearner):       ErrGRPCMemberNotLearner,
 		ErrorDesc(ErrGRPCLearnerNotReady):        ErrGRPCLearnerNotReady,
 		ErrorDesc(ErrGRPCTooManyLearners):        ErrGRPCTooManyLearners,
+		ErrorDesc(ErrGRPCWitnessLearner):         ErrGRPCWitnessLearner,
 
 		ErrorDesc(ErrGRPCRequestTooLarge):        ErrGRPCRequestTooLarge,
 		ErrorDesc(ErrGRPCRequestTooManyRequests): ErrGRPCRequestTooManyRequests,
+		ErrorDesc(ErrGRPCTooManyConnections):     ErrGRPCTooManyConnections,
 
 		ErrorDesc(ErrGRPCRootUserNotExist):     ErrGRPCRootUserNotExist,
 		ErrorDesc(ErrGRPCRootRoleNotExist):     ErrGRPCRootRoleNotExist,
@@ -149,6 +198,7 @@ var (
 		ErrorDesc(ErrGRPCUnhealthy):                  ErrGRPCUnhealthy,
 		ErrorDesc(ErrGRPCCorrupt):                    ErrGRPCCorrupt,
 		ErrorDesc(ErrGRPCNotSupportedForLearner):     ErrGRPCNotSupportedForLearner,
+		ErrorDesc(ErrGRPCNotSupportedForWitness):     ErrGRPCNotSupportedForWitness,
 		ErrorDesc(ErrGRPCBadLeaderTransferee):        ErrGRPCBadLeaderTransferee,
 
 		ErrorDesc(ErrGRPCClusterVersionUnavailable):     ErrGRPCClusterVersionUnavailable,
@@ -156,6 +206,20 @@ var (
 		ErrorDesc(ErrGRPCInvalidDowngradeTargetVersion): ErrGRPCInvalidDowngradeTargetVersion,
 		ErrorDesc(ErrGRPCDowngradeInProcess):            ErrGRPCDowngradeInProcess,
 		ErrorDesc(ErrGRPCNoInflightDowngrade):           ErrGRPCNoInflightDowngrade,
+
+		ErrorDesc(ErrGRPCImportRevision):        ErrGRPCImportRevision,
+		ErrorDesc(ErrGRPCImportKeyOrder):        ErrGRPCImportKeyOrder,
+		ErrorDesc(ErrGRPCImportNotSingleMember): ErrGRPCImportNotSingleMember,
+		ErrorDesc(ErrGRPCImportInProgress):      ErrGRPCImportInProgress,
+
+		ErrorDesc(ErrGRPCRangeRateLimitExceeded): ErrGRPCRangeRateLimitExceeded,
+
+		ErrorDesc(ErrGRPCSnapshotNotFound):      ErrGRPCSnapshotNotFound,
+		ErrorDesc(ErrGRPCInvalidSnapshotOffset): ErrGRPCInvalidSnapshotOffset,
+
+		ErrorDesc(ErrGRPCUnknownLogModule):      ErrGRPCUnknownLogModule,
+		ErrorDesc(ErrGRPCInvalidLogLevel):       ErrGRPCInvalidLogLevel,
+		ErrorDesc(ErrGRPCLogLevelsUnconfigured): ErrGRPCLogLevelsUnconfigured,
 	}
 )
 
@@ -171,10 +235,25 @@ var (
 	ErrCompacted         = Error(ErrGRPCCompacted)
 	ErrFutureRev         = Error(ErrGRPCFutureRev)
 	ErrNoSpace           = Error(ErrGRPCNoSpace)
+	ErrReadOnly          = Error(ErrGRPCReadOnly)
+
+	ErrLeaseNotFound       = Error(ErrGRPCLeaseNotFound)
+	ErrLeaseExist          = Error(ErrGRPCLeaseExist)
+	ErrLeaseTTLTooLarge    = Error(ErrGRPCLeaseTTLTooLarge)
+	ErrLeaseTTLNegative    = Error(ErrGRPCLeaseTTLNegative)
+	ErrLeaseLabelsTooLarge = Error(ErrGRPCLeaseLabelsTooLarge)
+
+	ErrNamespaceEmpty            = Error(ErrGRPCNamespaceEmpty)
+	ErrNamespaceNotFound         = Error(ErrGRPCNamespaceNotFound)
+	ErrNamespaceQuotaExceeded    = Error(ErrGRPCNamespaceQuotaExceeded)
+	ErrNamespaceLeaseTTLTooLarge = Error(ErrGRPCNamespaceLeaseTTLTooLarge)
 
-	ErrLeaseNotFound    = Error(ErrGRPCLeaseNotFound)
-	ErrLeaseExist       = Error(ErrGRPCLeaseExist)
-	ErrLeaseTTLTooLarge = Error(ErrGRPCLeaseTTLTooLarge)
+	ErrIndexNameEmpty    = Error(ErrGRPCIndexNameEmpty)
+	ErrIndexFieldInvalid = Error(ErrGRPCIndexFieldInvalid)
+	ErrIndexNotFound     = Error(ErrGRPCIndexNotFound)
+
+	ErrRequestIDTooLong  = Error(ErrGRPCRequestIDTooLong)
+	ErrRequestIDConflict = Error(ErrGRPCRequestIDConflict)
 
 	ErrMemberExist            = Error(ErrGRPCMemberExist)
 	ErrPeerURLExist           = Error(ErrGRPCPeerURLExist)
@@ -184,9 +263,11 @@ var (
 	ErrMemberNotLearner       = Error(ErrGRPCMemberNotLearner)
 	ErrMemberLearnerNotReady  = Error(ErrGRPCLearnerNotReady)
 	ErrTooManyLearners        = Error(ErrGRPCTooManyLearners)
+	ErrWitnessLearner         = Error(ErrGRPCWitnessLearner)
 
-	ErrRequestTooLarge = Error(ErrGRPCRequestTooLarge)
-	ErrTooManyRequests = Error(ErrGRPCRequestTooManyRequests)
+	ErrRequestTooLarge    = Error(ErrGRPCRequestTooLarge)
+	ErrTooManyRequests    = Error(ErrGRPCRequestTooManyRequests)
+	ErrTooManyConnections = Error(ErrGRPCTooManyConnections)
 
 	ErrRootUserNotExist     = Error(ErrGRPCRootUserNotExist)
 	ErrRootRoleNotExist     = Error(ErrGRPCRootRoleNotExist)
@@ -217,12 +298,27 @@ var (
 	ErrUnhealthy                  = Error(ErrGRPCUnhealthy)
 	ErrCorrupt                    = Error(ErrGRPCCorrupt)
 	ErrBadLeaderTransferee        = Error(ErrGRPCBadLeaderTransferee)
+	ErrNotSupportedForWitness     = Error(ErrGRPCNotSupportedForWitness)
 
 	ErrClusterVersionUnavailable     = Error(ErrGRPCClusterVersionUnavailable)
 	ErrWrongDowngradeVersionFormat   = Error(ErrGRPCWrongDowngradeVersionFormat)
 	ErrInvalidDowngradeTargetVersion = Error(ErrGRPCInvalidDowngradeTargetVersion)
 	ErrDowngradeInProcess            = Error(ErrGRPCDowngradeInProcess)
 	ErrNoInflightDowngrade           = Error(ErrGRPCNoInflightDowngrade)
+
+	ErrImportRevision        = Error(ErrGRPCImportRevision)
+	ErrImportKeyOrder        = Error(ErrGRPCImportKeyOrder)
+	ErrImportNotSingleMember = Error(ErrGRPCImportNotSingleMember)
+	ErrImportInProgress      = Error(ErrGRPCImportInProgress)
+
+	ErrRangeRateLimitExceeded = Error(ErrGRPCRangeRateLimitExceeded)
+
+	ErrSnapshotNotFound      = Error(ErrGRPCSnapshotNotFound)
+	ErrInvalidSnapshotOffset = Error(ErrGRPCInvalidSnapshotOffset)
+
+	ErrUnknownLogModule      = Error(ErrGRPCUnknownLogModule)
+	ErrInvalidLogLevel       = Error(ErrGRPCInvalidLogLevel)
+	ErrLogLevelsUnconfigured = Error(ErrGRPCLogLevelsUnconfigured)
 )
 
 // EtcdError defines gRPC server errors.
@@ -19,4 +19,8 @@ var (
 	MetadataHasLeader        = "true"
 
 	MetadataClientAPIVersionKey = "client-api-version"
+
+	// MetadataRequestIDKey carries the client chosen ID of a mutation,
+	// used by the server to apply retried mutations only once.
+	MetadataRequestIDKey = "request-id"
 )
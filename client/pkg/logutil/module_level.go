@@ -0,0 +1,153 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// inheritLevel is the level of the modules which log at the default level.
+const inheritLevel = math.MinInt32
+
+// ModuleLevels holds the log levels of the modules of a process, which may be
+// changed at runtime. A module logs at the default level unless its level is
+// set.
+//
+// The loggers of the modules are derived from a logger with Logger, which
+// filters the entries by the level of the module. The logger they are derived
+// from must be enabled at the lowest level the modules may log at, e.g. built
+// at zap.DebugLevel, for the modules to log more than the default level.
+type ModuleLevels struct {
+	level   zap.AtomicLevel
+	modules map[string]*int32
+}
+
+// NewModuleLevels returns the log levels of modules, which log at level by
+// default.
+func NewModuleLevels(level zap.AtomicLevel, modules ...string) *ModuleLevels {
+	ml := &ModuleLevels{level: level, modules: make(map[string]*int32, len(modules))}
+	for _, m := range modules {
+		l := int32(inheritLevel)
+		ml.modules[m] = &l
+	}
+	return ml
+}
+
+// DefaultLevel returns the level the modules log at unless their level is set.
+func (ml *ModuleLevels) DefaultLevel() zap.AtomicLevel {
+	return ml.level
+}
+
+// Modules returns the sorted names of the modules.
+func (ml *ModuleLevels) Modules() []string {
+	ms := make([]string, 0, len(ml.modules))
+	for m := range ml.modules {
+		ms = append(ms, m)
+	}
+	sort.Strings(ms)
+	return ms
+}
+
+// Level returns the level module logs at, and whether it is set rather than
+// the default level.
+func (ml *ModuleLevels) Level(module string) (zapcore.Level, bool, error) {
+	l, ok := ml.modules[module]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown log module %q", module)
+	}
+	if lvl := atomic.LoadInt32(l); lvl != inheritLevel {
+		return zapcore.Level(lvl), true, nil
+	}
+	return ml.level.Level(), false, nil
+}
+
+// SetLevel makes module log at level rather than the default level.
+func (ml *ModuleLevels) SetLevel(module string, level zapcore.Level) error {
+	l, ok := ml.modules[module]
+	if !ok {
+		return fmt.Errorf("unknown log module %q", module)
+	}
+	atomic.StoreInt32(l, int32(level))
+	return nil
+}
+
+// ResetLevel makes module log at the default level again.
+func (ml *ModuleLevels) ResetLevel(module string) error {
+	l, ok := ml.modules[module]
+	if !ok {
+		return fmt.Errorf("unknown log module %q", module)
+	}
+	atomic.StoreInt32(l, inheritLevel)
+	return nil
+}
+
+// Logger returns the logger of module derived from lg, which logs at the
+// level of module. An unknown module, e.g. "", logs at the default level. It
+// returns lg as is if ml is nil.
+func (ml *ModuleLevels) Logger(lg *zap.Logger, module string) *zap.Logger {
+	if ml == nil {
+		return lg
+	}
+	var enab zapcore.LevelEnabler = ml.level
+	if l, ok := ml.modules[module]; ok {
+		enab = &moduleLevel{level: l, defaultLevel: ml.level}
+	}
+	return lg.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if lc, ok := c.(*levelCore); ok {
+			// derived from the logger of another module
+			c = lc.Core
+		}
+		return &levelCore{Core: c, enab: enab}
+	}))
+}
+
+type moduleLevel struct {
+	level        *int32
+	defaultLevel zap.AtomicLevel
+}
+
+func (m *moduleLevel) Enabled(l zapcore.Level) bool {
+	if lvl := atomic.LoadInt32(m.level); lvl != inheritLevel {
+		return zapcore.Level(lvl).Enabled(l)
+	}
+	return m.defaultLevel.Enabled(l)
+}
+
+// levelCore filters the entries of Core by enab.
+type levelCore struct {
+	zapcore.Core
+	enab zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.enab.Enabled(l) && c.Core.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), enab: c.enab}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enab.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
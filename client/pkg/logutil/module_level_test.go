@@ -0,0 +1,80 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestModuleLevels(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	ml := NewModuleLevels(zap.NewAtomicLevelAt(zap.InfoLevel), "raft", "mvcc")
+	lg := ml.Logger(zap.New(core), "")
+	raft := ml.Logger(lg, "raft")
+	mvcc := ml.Logger(lg, "mvcc").With(zap.String("module", "mvcc"))
+
+	logAll := func() []string {
+		for _, l := range []*zap.Logger{lg, raft, mvcc} {
+			l.Debug("debug")
+			l.Info("info")
+			l.Warn("warn")
+		}
+		var got []string
+		for _, e := range logs.TakeAll() {
+			got = append(got, e.Level.String())
+		}
+		return got
+	}
+
+	if got, want := logAll(), []string{"info", "warn", "info", "warn", "info", "warn"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v at the default level, got %v", want, got)
+	}
+
+	if err := ml.SetLevel("raft", zap.DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	if err := ml.SetLevel("mvcc", zap.WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := logAll(), []string{"info", "warn", "debug", "info", "warn", "warn"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v with the levels of the modules set, got %v", want, got)
+	}
+	if l, set, err := ml.Level("mvcc"); err != nil || !set || l != zap.WarnLevel {
+		t.Errorf("expected mvcc level warn set, got %v, %v, %v", l, set, err)
+	}
+
+	ml.DefaultLevel().SetLevel(zap.ErrorLevel)
+	if err := ml.ResetLevel("mvcc"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := logAll(), []string{"debug", "info", "warn"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v with the default level changed, got %v", want, got)
+	}
+	if l, set, err := ml.Level("mvcc"); err != nil || set || l != zap.ErrorLevel {
+		t.Errorf("expected mvcc level error not set, got %v, %v, %v", l, set, err)
+	}
+
+	if err := ml.SetLevel("auth", zapcore.DebugLevel); err == nil {
+		t.Errorf("expected an error setting the level of an unknown module")
+	}
+	if got, want := ml.Modules(), []string{"mvcc", "raft"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected modules %v, got %v", want, got)
+	}
+}
@@ -49,13 +49,20 @@ func NewListenerWithOpts(addr, scheme string, opts ...ListenerOption) (net.Liste
 }
 
 func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, error) {
+	lnOpts := newListenOpts(opts...)
+
 	if scheme == "unix" || scheme == "unixs" {
 		// unix sockets via unix://laddr
-		return NewUnixListener(addr)
+		ln, err := NewUnixListener(addr)
+		if err != nil {
+			return nil, err
+		}
+		if lnOpts.skipTLSInfoCheck && !lnOpts.IsTLS() {
+			return ln, nil
+		}
+		return wrapTLS(scheme, lnOpts.tlsInfo, ln)
 	}
 
-	lnOpts := newListenOpts(opts...)
-
 	switch {
 	case lnOpts.IsSocketOpts():
 		// new ListenConfig with socket options.
@@ -106,7 +113,9 @@ func wrapTLS(scheme string, tlsinfo *TLSInfo, l net.Listener) (net.Listener, err
 	if scheme != "https" && scheme != "unixs" {
 		return l, nil
 	}
-	if tlsinfo != nil && tlsinfo.SkipClientSANVerify {
+	// the remote address of a unix socket connection has no host to
+	// verify the client certificate SAN against
+	if scheme == "unixs" || (tlsinfo != nil && tlsinfo.SkipClientSANVerify) {
 		return NewTLSListener(l, tlsinfo)
 	}
 	return newTLSListener(l, tlsinfo, checkSAN)
@@ -21,6 +21,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -516,6 +517,70 @@ func TestNewListenerUnixSocket(t *testing.T) {
 	l.Close()
 }
 
+// TestNewListenerUnixSocketTLS tests that a unixs listener serves TLS and
+// accepts client certificates, which have no address to verify the SAN against.
+func TestNewListenerUnixSocketTLS(t *testing.T) {
+	tlsInfo, err := createSelfCert(t)
+	if err != nil {
+		t.Fatalf("unable to create cert: %v", err)
+	}
+	clientTLSInfo, err := createSelfCertEx(t, "127.0.0.1", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		t.Fatalf("unable to create cert: %v", err)
+	}
+	tlsInfo.TrustedCAFile = clientTLSInfo.CertFile
+
+	clientCert, err := tls.LoadX509KeyPair(clientTLSInfo.CertFile, clientTLSInfo.KeyFile)
+	if err != nil {
+		t.Fatalf("unable to create peer cert: %v", err)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}}
+
+	addr := filepath.Join(t.TempDir(), "testsocket")
+	ln, err := NewListener(addr, "unixs", tlsInfo)
+	if err != nil {
+		t.Fatalf("unexpected NewListener error: %v", err)
+	}
+	defer ln.Close()
+
+	chClientErr := make(chan error, 1)
+	go func() {
+		conn, err := tls.Dial("unix", addr, tlsConfig)
+		if err == nil {
+			err = conn.Handshake()
+			defer conn.Close()
+		}
+		chClientErr <- err
+	}()
+
+	chAcceptConn := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			chAcceptConn <- conn
+		}
+	}()
+
+	select {
+	case conn := <-chAcceptConn:
+		defer conn.Close()
+		if _, ok := conn.(*tls.Conn); !ok {
+			t.Errorf("failed to accept *tls.Conn")
+		}
+	case err := <-chClientErr:
+		if err != nil {
+			t.Fatalf("unexpected client error: %v", err)
+		}
+		select {
+		case conn := <-chAcceptConn:
+			conn.Close()
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the connection to be accepted")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the connection to be accepted")
+	}
+}
+
 // TestNewListenerTLSInfoSelfCert tests that a new certificate accepts connections.
 func TestNewListenerTLSInfoSelfCert(t *testing.T) {
 	tmpdir := t.TempDir()
@@ -51,6 +51,7 @@ const (
 	PermRead      = authpb.READ
 	PermWrite     = authpb.WRITE
 	PermReadWrite = authpb.READWRITE
+	PermAdmin     = authpb.ADMIN
 )
 
 type UserAddOptions authpb.UserAddOptions
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,6 +27,7 @@ import (
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	"go.etcd.io/etcd/client/v3/credentials"
+	"go.etcd.io/etcd/client/v3/internal/balancer"
 	"go.etcd.io/etcd/client/v3/internal/endpoint"
 	"go.etcd.io/etcd/client/v3/internal/resolver"
 	"go.uber.org/zap"
@@ -66,6 +68,7 @@ type Client struct {
 	Username string
 	// Password is a password for authentication.
 	Password        string
+	credentials     func(ctx context.Context) (username, password string, err error)
 	authTokenBundle credentials.Bundle
 
 	callOpts []grpc.CallOption
@@ -143,6 +146,9 @@ func (c *Client) GetLogger() *zap.Logger {
 // Close shuts down the client's etcd connections.
 func (c *Client) Close() error {
 	c.cancel()
+	if kv, ok := c.KV.(io.Closer); ok {
+		kv.Close()
+	}
 	if c.Watcher != nil {
 		c.Watcher.Close()
 	}
@@ -233,9 +239,8 @@ func (c *Client) dialSetupOpts(creds grpccredentials.TransportCredentials, dopts
 		opts = append(opts, grpc.WithInsecure())
 	}
 
-	// Interceptor retry and backoff.
-	// TODO: Replace all of clientv3/retry.go with RetryPolicy:
-	// https://github.com/grpc/grpc-proto/blob/cdd9ed5c3d3f87aef62f373b93361cf7bddc620d/grpc/service_config/service_config.proto#L130
+	// Interceptor retry and backoff, overridden by the RetryPolicy of the
+	// config, if any.
 	rrBackoff := withBackoff(c.roundRobinQuorumBackoff(defaultBackoffWaitBetween, defaultBackoffJitterFraction))
 	opts = append(opts,
 		// Disable stream retry by default since go-grpc-middleware/retry does not support client streams.
@@ -259,11 +264,17 @@ func (c *Client) Dial(ep string) (*grpc.ClientConn, error) {
 func (c *Client) getToken(ctx context.Context) error {
 	var err error // return last error in a case of fail
 
-	if c.Username == "" || c.Password == "" {
+	username, password := c.Username, c.Password
+	if c.credentials != nil {
+		if username, password, err = c.credentials(ctx); err != nil {
+			return err
+		}
+	}
+	if username == "" || password == "" {
 		return nil
 	}
 
-	resp, err := c.Auth.Authenticate(ctx, c.Username, c.Password)
+	resp, err := c.Auth.Authenticate(ctx, username, password)
 	if err != nil {
 		if err == rpctypes.ErrAuthNotEnabled {
 			c.authTokenBundle.UpdateAuthToken("")
@@ -275,6 +286,15 @@ func (c *Client) getToken(ctx context.Context) error {
 	return nil
 }
 
+// refreshToken clears the auth token, then authenticates again.
+// call c.Auth.Authenticate with an invalid token will always fail the auth check on the server-side,
+// if the server has not apply the patch of pr #12165 (https://github.com/etcd-io/etcd/pull/12165)
+// and a rpctypes.ErrInvalidAuthToken will recursively call c.getToken until system run out of resource.
+func (c *Client) refreshToken(ctx context.Context) error {
+	c.authTokenBundle.UpdateAuthToken("")
+	return c.getToken(ctx)
+}
+
 // dialWithBalancer dials the client's current load balanced resolver group.  The scheme of the host
 // of the provided endpoint determines the scheme used for all endpoints of the client connection.
 func (c *Client) dialWithBalancer(dopts ...grpc.DialOption) (*grpc.ClientConn, error) {
@@ -387,6 +407,10 @@ func newClient(cfg *Config) (*Client, error) {
 		client.Password = cfg.Password
 		client.authTokenBundle = credentials.NewBundle(credentials.Config{})
 	}
+	if cfg.Credentials != nil {
+		client.credentials = cfg.Credentials
+		client.authTokenBundle = credentials.NewBundle(credentials.Config{})
+	}
 	if cfg.MaxCallSendMsgSize > 0 || cfg.MaxCallRecvMsgSize > 0 {
 		if cfg.MaxCallRecvMsgSize > 0 && cfg.MaxCallSendMsgSize > cfg.MaxCallRecvMsgSize {
 			return nil, fmt.Errorf("gRPC message recv limit (%d bytes) must be greater than send limit (%d bytes)", cfg.MaxCallRecvMsgSize, cfg.MaxCallSendMsgSize)
@@ -405,7 +429,15 @@ func newClient(cfg *Config) (*Client, error) {
 		client.callOpts = callOpts
 	}
 
-	client.resolver = resolver.New(cfg.Endpoints...)
+	if cb := cfg.CircuitBreaker; cb != nil {
+		client.resolver = resolver.NewWithServiceConfig(balancer.ServiceConfig(balancer.Config{
+			FailureThreshold: cb.FailureThreshold,
+			LatencyThreshold: cb.LatencyThreshold,
+			CoolDown:         cb.CoolDown,
+		}), cfg.Endpoints...)
+	} else {
+		client.resolver = resolver.New(cfg.Endpoints...)
+	}
 
 	if len(cfg.Endpoints) < 1 {
 		client.cancel()
@@ -428,6 +460,9 @@ func newClient(cfg *Config) (*Client, error) {
 	client.KV = NewKV(client)
 	client.Lease = NewLease(client)
 	client.Watcher = NewWatcher(client)
+	if cfg.WatchCacheSize > 0 {
+		client.Watcher = NewWatchCache(client.Watcher, cfg.WatchCacheSize)
+	}
 	client.Auth = NewAuth(client)
 	client.Maintenance = NewMaintenance(client)
 
@@ -0,0 +1,127 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// EndpointStatus is the result of querying the status of one endpoint.
+type EndpointStatus struct {
+	Endpoint string
+	Status   *StatusResponse
+	Err      error
+}
+
+// ClusterStatusReport consolidates the status of all endpoints of a client.
+// The aggregated fields only account for the endpoints that responded.
+type ClusterStatusReport struct {
+	// Endpoints holds the status of each endpoint, in the order of the
+	// endpoints of the client.
+	Endpoints []EndpointStatus
+
+	// Leader is the member ID of the leader all responding endpoints agree
+	// on, or 0 if they do not agree.
+	Leader uint64
+
+	MinRevision int64
+	MaxRevision int64
+
+	MinDbSize int64
+	MaxDbSize int64
+
+	// Versions lists the distinct server versions, sorted.
+	Versions []string
+}
+
+// LeaderAgreed reports whether all responding endpoints agree on a leader.
+func (r *ClusterStatusReport) LeaderAgreed() bool { return r.Leader != 0 }
+
+// RevisionSpread is the difference between the highest and the lowest
+// revision reported, which is how far the slowest member lags behind.
+func (r *ClusterStatusReport) RevisionSpread() int64 { return r.MaxRevision - r.MinRevision }
+
+// ClusterStatus queries the status of all endpoints of the client
+// concurrently and consolidates the responses. Errors of individual
+// endpoints are recorded in the report, an error is returned only if none
+// of the endpoints responded.
+func ClusterStatus(ctx context.Context, c *Client) (*ClusterStatusReport, error) {
+	eps := c.Endpoints()
+	if len(eps) == 0 {
+		return nil, ErrNoAvailableEndpoints
+	}
+	r := &ClusterStatusReport{Endpoints: make([]EndpointStatus, len(eps))}
+	var wg sync.WaitGroup
+	for i, ep := range eps {
+		wg.Add(1)
+		go func(i int, ep string) {
+			defer wg.Done()
+			resp, err := c.Status(ctx, ep)
+			r.Endpoints[i] = EndpointStatus{Endpoint: ep, Status: resp, Err: err}
+		}(i, ep)
+	}
+	wg.Wait()
+
+	var (
+		responded int
+		lastErr   error
+		versions  = make(map[string]struct{})
+	)
+	leaderAgreed := true
+	for _, es := range r.Endpoints {
+		if es.Err != nil {
+			lastErr = es.Err
+			continue
+		}
+		s := es.Status
+		rev := s.Header.Revision
+		if responded == 0 {
+			r.Leader = s.Leader
+			r.MinRevision, r.MaxRevision = rev, rev
+			r.MinDbSize, r.MaxDbSize = s.DbSize, s.DbSize
+		}
+		responded++
+		if s.Leader != r.Leader {
+			leaderAgreed = false
+		}
+		if rev < r.MinRevision {
+			r.MinRevision = rev
+		}
+		if rev > r.MaxRevision {
+			r.MaxRevision = rev
+		}
+		if s.DbSize < r.MinDbSize {
+			r.MinDbSize = s.DbSize
+		}
+		if s.DbSize > r.MaxDbSize {
+			r.MaxDbSize = s.DbSize
+		}
+		versions[s.Version] = struct{}{}
+	}
+	if responded == 0 {
+		return r, fmt.Errorf("no endpoint responded to status: %w", lastErr)
+	}
+	if !leaderAgreed {
+		r.Leader = 0
+	}
+	for v := range versions {
+		r.Versions = append(r.Versions, v)
+	}
+	sort.Strings(r.Versions)
+	return r, nil
+}
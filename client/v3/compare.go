@@ -31,22 +31,7 @@ const (
 type Cmp pb.Compare
 
 func Compare(cmp Cmp, result string, v interface{}) Cmp {
-	var r pb.Compare_CompareResult
-
-	switch result {
-	case "=":
-		r = pb.Compare_EQUAL
-	case "!=":
-		r = pb.Compare_NOT_EQUAL
-	case ">":
-		r = pb.Compare_GREATER
-	case "<":
-		r = pb.Compare_LESS
-	default:
-		panic("Unknown result op")
-	}
-
-	cmp.Result = r
+	cmp.Result = compareResult(result)
 	switch cmp.Target {
 	case pb.Compare_VALUE:
 		val, ok := v.(string)
@@ -138,3 +123,18 @@ func mustInt64orLeaseID(val interface{}) int64 {
 	}
 	return mustInt64(val)
 }
+
+// compareResult returns the compare result of one of "=", "!=", ">" and "<".
+func compareResult(result string) pb.Compare_CompareResult {
+	switch result {
+	case "=":
+		return pb.Compare_EQUAL
+	case "!=":
+		return pb.Compare_NOT_EQUAL
+	case ">":
+		return pb.Compare_GREATER
+	case "<":
+		return pb.Compare_LESS
+	}
+	panic("Unknown result op")
+}
@@ -146,8 +146,11 @@ func (e *Election) Resign(ctx context.Context) (err error) {
 
 // Leader returns the leader value for the current election.
 func (e *Election) Leader(ctx context.Context) (*v3.GetResponse, error) {
-	client := e.session.Client()
-	resp, err := client.Get(ctx, e.keyPrefix, v3.WithFirstCreate()...)
+	return electionLeader(ctx, e.session.Client(), e.keyPrefix)
+}
+
+func electionLeader(ctx context.Context, client *v3.Client, keyPrefix string) (*v3.GetResponse, error) {
+	resp, err := client.Get(ctx, keyPrefix, v3.WithFirstCreate()...)
 	if err != nil {
 		return nil, err
 	} else if len(resp.Kvs) == 0 {
@@ -166,16 +169,14 @@ func (e *Election) Leader(ctx context.Context) (*v3.GetResponse, error) {
 // is otherwise disrupted.
 func (e *Election) Observe(ctx context.Context) <-chan v3.GetResponse {
 	retc := make(chan v3.GetResponse)
-	go e.observe(ctx, retc)
+	go observeElection(ctx, e.session.Client(), e.keyPrefix, retc)
 	return retc
 }
 
-func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
-	client := e.session.Client()
-
+func observeElection(ctx context.Context, client *v3.Client, keyPrefix string, ch chan<- v3.GetResponse) {
 	defer close(ch)
 	for {
-		resp, err := client.Get(ctx, e.keyPrefix, v3.WithFirstCreate()...)
+		resp, err := client.Get(ctx, keyPrefix, v3.WithFirstCreate()...)
 		if err != nil {
 			return
 		}
@@ -187,7 +188,7 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 			cctx, cancel := context.WithCancel(ctx)
 			// wait for first key put on prefix
 			opts := []v3.OpOption{v3.WithRev(resp.Header.Revision), v3.WithPrefix()}
-			wch := client.Watch(cctx, e.keyPrefix, opts...)
+			wch := client.Watch(cctx, keyPrefix, opts...)
 			for kv == nil {
 				wr, ok := <-wch
 				if !ok || wr.Err() != nil {
@@ -244,6 +245,34 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 	}
 }
 
+// ElectionObserver observes the leader of an election without campaigning,
+// so that it needs no Session keeping a lease alive. The leadership changes
+// are observed through the watch events on the election keys, the key of a
+// leader being deleted on resignation or on the expiry of its lease.
+type ElectionObserver struct {
+	client    *v3.Client
+	keyPrefix string
+}
+
+// NewElectionObserver returns an observer of the election on a given key
+// prefix.
+func NewElectionObserver(client *v3.Client, pfx string) *ElectionObserver {
+	return &ElectionObserver{client: client, keyPrefix: pfx + "/"}
+}
+
+// Leader returns the leader value for the current election.
+func (o *ElectionObserver) Leader(ctx context.Context) (*v3.GetResponse, error) {
+	return electionLeader(ctx, o.client, o.keyPrefix)
+}
+
+// Observe returns a channel that reliably observes ordered leader proposals,
+// as Election.Observe does.
+func (o *ElectionObserver) Observe(ctx context.Context) <-chan v3.GetResponse {
+	retc := make(chan v3.GetResponse)
+	go observeElection(ctx, o.client, o.keyPrefix, retc)
+	return retc
+}
+
 // Key returns the leader key if elected, empty string otherwise.
 func (e *Election) Key() string { return e.leaderKey }
 
@@ -252,3 +281,6 @@ func (e *Election) Rev() int64 { return e.leaderRev }
 
 // Header is the response header from the last successful election proposal.
 func (e *Election) Header() *pb.ResponseHeader { return e.hdr }
+
+// FencingToken returns the fencing token of the leadership, if elected.
+func (e *Election) FencingToken() FencingToken { return FencingToken(e.leaderRev) }
@@ -0,0 +1,76 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+// ErrFenced is returned by FencedTxn when a newer fencing token was written
+// to the fence key.
+var ErrFenced = errors.New("fencing: superseded by a newer token")
+
+// FencingToken is a number increasing with each acquisition of a Mutex or
+// election leadership: the creation revision of the key of the holder. A
+// resource protected by the lock keeps the latest token it has seen, so
+// that it can reject the writes of a stale holder, which lost the lock
+// without noticing, e.g. as it was paused past the expiry of its session.
+type FencingToken int64
+
+// String encodes the token such that the encodings of the tokens compare
+// in the order of the tokens.
+func (t FencingToken) String() string { return fmt.Sprintf("%016x", int64(t)) }
+
+// Guard compares true if no newer token was written to the fence key. It
+// compares false if the fence key does not exist.
+func (t FencingToken) Guard(fenceKey string) v3.Cmp {
+	return v3.Compare(v3.Value(fenceKey), "<", (t + 1).String())
+}
+
+// Fence writes the token to the fence key.
+func (t FencingToken) Fence(fenceKey string) v3.Op {
+	return v3.OpPut(fenceKey, t.String())
+}
+
+// FencedTxn applies ops along with writing the token to the fence key,
+// unless a newer token was written to the fence key, in which case it
+// returns ErrFenced.
+func FencedTxn(ctx context.Context, client *v3.Client, fenceKey string, t FencingToken, ops ...v3.Op) (*v3.TxnResponse, error) {
+	then := append([]v3.Op{t.Fence(fenceKey)}, ops...)
+	for {
+		resp, err := client.Txn(ctx).If(t.Guard(fenceKey)).Then(then...).Else(v3.OpGet(fenceKey)).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Succeeded {
+			return resp, nil
+		}
+		if len(resp.Responses[0].GetResponseRange().Kvs) != 0 {
+			return nil, ErrFenced
+		}
+		// first write to the fence key
+		resp, err = client.Txn(ctx).If(v3.Compare(v3.CreateRevision(fenceKey), "=", 0)).Then(then...).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Succeeded {
+			return resp, nil
+		}
+	}
+}
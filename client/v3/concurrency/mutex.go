@@ -146,6 +146,9 @@ func (m *Mutex) Key() string { return m.myKey }
 // Header is the response header received from etcd on acquiring the lock.
 func (m *Mutex) Header() *pb.ResponseHeader { return m.hdr }
 
+// FencingToken returns the fencing token of the lock, if held.
+func (m *Mutex) FencingToken() FencingToken { return FencingToken(m.myRev) }
+
 type lockerMutex struct{ *Mutex }
 
 func (lm *lockerMutex) Lock() {
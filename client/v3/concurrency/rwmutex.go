@@ -0,0 +1,249 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+// RWMutexFairness decides the order in which an RWMutex is granted to the
+// readers and writers waiting for it.
+type RWMutexFairness int
+
+const (
+	// RWMutexFIFO grants the lock in the order it is requested, so that
+	// neither the readers nor the writers starve. A reader waits for the
+	// writers that requested the lock before it.
+	RWMutexFIFO RWMutexFairness = iota
+	// RWMutexReaderPreference lets readers acquire the lock while it is held
+	// by other readers. A writer only queues up once there are no readers,
+	// so writers may starve under a steady flow of readers.
+	RWMutexReaderPreference
+	// RWMutexWriterPreference keeps new readers out while any writer holds or
+	// waits for the lock, so readers may starve under a steady flow of writers.
+	RWMutexWriterPreference
+)
+
+type rwMutexOptions struct {
+	fairness RWMutexFairness
+}
+
+// RWMutexOption configures RWMutex.
+type RWMutexOption func(*rwMutexOptions)
+
+// WithFairness configures the order in which the lock is granted to the
+// readers and writers. It defaults to RWMutexFIFO.
+func WithFairness(fairness RWMutexFairness) RWMutexOption {
+	return func(o *rwMutexOptions) {
+		o.fairness = fairness
+	}
+}
+
+// RWMutex is a reader/writer mutual exclusion lock held by any number of
+// readers or a single writer. Every reader and writer is a key bound to the
+// lease of its session, so that the lock held by a crashed client is released
+// once its session expires. An RWMutex holds a single lock at a time, either
+// for reading or for writing.
+type RWMutex struct {
+	s    *Session
+	opts rwMutexOptions
+
+	pfx   string
+	myKey string
+	myRev int64
+	hdr   *pb.ResponseHeader
+}
+
+func NewRWMutex(s *Session, pfx string, opts ...RWMutexOption) *RWMutex {
+	rwm := &RWMutex{s: s, pfx: pfx + "/", myKey: "\x00", myRev: -1}
+	for _, opt := range opts {
+		opt(&rwm.opts)
+	}
+	return rwm
+}
+
+func (rwm *RWMutex) readers() string { return rwm.pfx + "read/" }
+func (rwm *RWMutex) writers() string { return rwm.pfx + "write/" }
+
+// RLock locks the mutex for reading with a cancelable context. If the context
+// is canceled while waiting for the lock, the mutex tries to clean its stale
+// lock entry.
+func (rwm *RWMutex) RLock(ctx context.Context) error {
+	var barrier string
+	if rwm.opts.fairness == RWMutexWriterPreference {
+		barrier = rwm.writers()
+	}
+	return rwm.lock(ctx, rwm.readers(), barrier, rwm.writers(), false)
+}
+
+// TryRLock locks the mutex for reading if not already locked for writing by
+// another session, or waited for by writers served first. Otherwise it
+// returns ErrLocked.
+func (rwm *RWMutex) TryRLock(ctx context.Context) error {
+	var barrier string
+	if rwm.opts.fairness == RWMutexWriterPreference {
+		barrier = rwm.writers()
+	}
+	return rwm.lock(ctx, rwm.readers(), barrier, rwm.writers(), true)
+}
+
+// Lock locks the mutex for writing with a cancelable context. If the context
+// is canceled while waiting for the lock, the mutex tries to clean its stale
+// lock entry.
+func (rwm *RWMutex) Lock(ctx context.Context) error {
+	var barrier string
+	if rwm.opts.fairness == RWMutexReaderPreference {
+		barrier = rwm.readers()
+	}
+	return rwm.lock(ctx, rwm.writers(), barrier, rwm.pfx, false)
+}
+
+// TryLock locks the mutex for writing if not already locked by another
+// session, or waited for by readers or writers served first. Otherwise it
+// returns ErrLocked.
+func (rwm *RWMutex) TryLock(ctx context.Context) error {
+	var barrier string
+	if rwm.opts.fairness == RWMutexReaderPreference {
+		barrier = rwm.readers()
+	}
+	return rwm.lock(ctx, rwm.writers(), barrier, rwm.pfx, true)
+}
+
+// lock puts the key of the session under pfx once there are no keys under
+// barrier, then waits for the keys under wait created before it to be deleted.
+func (rwm *RWMutex) lock(ctx context.Context, pfx, barrier, wait string, try bool) error {
+	client := rwm.s.Client()
+	if err := rwm.enqueue(ctx, pfx, barrier, try); err != nil {
+		return err
+	}
+	if try {
+		resp, err := client.Get(ctx, wait, append(v3.WithLastCreate(), v3.WithMaxCreateRev(rwm.myRev-1))...)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			rwm.hdr = resp.Header
+			return nil
+		}
+		// Cannot lock, so delete the key
+		if err = rwm.Unlock(ctx); err != nil {
+			return err
+		}
+		return ErrLocked
+	}
+
+	// wait for deletion revisions prior to myKey
+	if _, werr := waitDeletes(ctx, client, wait, rwm.myRev-1); werr != nil {
+		// release lock key if wait failed
+		rwm.Unlock(client.Ctx())
+		return werr
+	}
+	// make sure the session is not expired, and the owner key still exists.
+	gresp, werr := client.Get(ctx, rwm.myKey)
+	if werr != nil {
+		rwm.Unlock(client.Ctx())
+		return werr
+	}
+	if len(gresp.Kvs) == 0 { // is the session key lost?
+		return ErrSessionExpired
+	}
+	rwm.hdr = gresp.Header
+	return nil
+}
+
+// enqueue puts the key of the session under pfx, reusing the key in case this
+// session already waits for or holds the lock. If barrier is set, it first
+// waits for the keys under barrier to be deleted.
+func (rwm *RWMutex) enqueue(ctx context.Context, pfx, barrier string, try bool) error {
+	client := rwm.s.Client()
+	rwm.myKey = fmt.Sprintf("%s%x", pfx, rwm.s.Lease())
+	for {
+		cmps := []v3.Cmp{v3.Compare(v3.CreateRevision(rwm.myKey), "=", 0)}
+		if barrier != "" {
+			cmps = append(cmps, v3.Compare(v3.CreateRevision(barrier), "=", 0).WithPrefix())
+		}
+		put := v3.OpPut(rwm.myKey, "", v3.WithLease(rwm.s.Lease()))
+		get := v3.OpGet(rwm.myKey)
+		resp, err := client.Txn(ctx).If(cmps...).Then(put).Else(get).Commit()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			rwm.myRev = resp.Header.Revision
+			return nil
+		}
+		if kvs := resp.Responses[0].GetResponseRange().Kvs; len(kvs) != 0 {
+			rwm.myRev = kvs[0].CreateRevision
+			return nil
+		}
+		if try {
+			rwm.myKey = "\x00"
+			return ErrLocked
+		}
+		if _, err = waitDeletes(ctx, client, barrier, resp.Header.Revision); err != nil {
+			rwm.myKey = "\x00"
+			return err
+		}
+	}
+}
+
+// RUnlock releases the lock held for reading.
+func (rwm *RWMutex) RUnlock(ctx context.Context) error { return rwm.Unlock(ctx) }
+
+// Unlock releases the lock held for writing.
+func (rwm *RWMutex) Unlock(ctx context.Context) error {
+	client := rwm.s.Client()
+	if _, err := client.Delete(ctx, rwm.myKey); err != nil {
+		return err
+	}
+	rwm.myKey = "\x00"
+	rwm.myRev = -1
+	return nil
+}
+
+// IsOwner compares true as long as the session holds the lock.
+func (rwm *RWMutex) IsOwner() v3.Cmp {
+	return v3.Compare(v3.CreateRevision(rwm.myKey), "=", rwm.myRev)
+}
+
+func (rwm *RWMutex) Key() string { return rwm.myKey }
+
+// Header is the response header received from etcd on acquiring the lock.
+func (rwm *RWMutex) Header() *pb.ResponseHeader { return rwm.hdr }
+
+type lockerRWMutex struct{ *RWMutex }
+
+func (lm *lockerRWMutex) Lock() {
+	client := lm.s.Client()
+	if err := lm.RWMutex.RLock(client.Ctx()); err != nil {
+		panic(err)
+	}
+}
+func (lm *lockerRWMutex) Unlock() {
+	client := lm.s.Client()
+	if err := lm.RWMutex.RUnlock(client.Ctx()); err != nil {
+		panic(err)
+	}
+}
+
+// RLocker returns a sync.Locker locking the mutex for reading.
+func (rwm *RWMutex) RLocker() sync.Locker {
+	return &lockerRWMutex{rwm}
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+// ErrNoPermit is returned by TryAcquire when all the permits of the
+// Semaphore are held by other sessions.
+var ErrNoPermit = errors.New("semaphore: no permit available")
+
+// Semaphore is a counting semaphore limiting the number of sessions holding
+// one of its permits at a time. The sessions waiting for a permit are served
+// in order. Every holder and waiter is a key bound to the lease of its
+// session, so that the permit held by a crashed client is reclaimed once its
+// session expires. All the sessions using a semaphore must agree on its
+// number of permits.
+type Semaphore struct {
+	s       *Session
+	permits int
+
+	pfx   string
+	myKey string
+	myRev int64
+	hdr   *pb.ResponseHeader
+}
+
+// NewSemaphore returns a semaphore with the given number of permits on a
+// given key prefix.
+func NewSemaphore(s *Session, pfx string, permits int) *Semaphore {
+	return &Semaphore{s: s, permits: permits, pfx: pfx + "/", myKey: "\x00", myRev: -1}
+}
+
+// TryAcquire acquires a permit if one is available. Otherwise it returns
+// ErrNoPermit.
+func (sem *Semaphore) TryAcquire(ctx context.Context) error {
+	if err := sem.enqueue(ctx); err != nil {
+		return err
+	}
+	resp, err := sem.holdersAhead(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) < sem.permits {
+		sem.hdr = resp.Header
+		return nil
+	}
+	// Cannot acquire, so delete the key
+	if err = sem.Release(ctx); err != nil {
+		return err
+	}
+	return ErrNoPermit
+}
+
+// Acquire acquires a permit with a cancelable context, waiting for one to be
+// released if none is available. If the context is canceled while waiting,
+// the semaphore tries to clean its stale waiter entry.
+func (sem *Semaphore) Acquire(ctx context.Context) error {
+	if err := sem.enqueue(ctx); err != nil {
+		return err
+	}
+	client := sem.s.Client()
+	if err := sem.waitPermit(ctx); err != nil {
+		// release waiter key if wait failed
+		sem.Release(client.Ctx())
+		return err
+	}
+	// make sure the session is not expired, and the waiter key still exists.
+	gresp, err := client.Get(ctx, sem.myKey)
+	if err != nil {
+		sem.Release(client.Ctx())
+		return err
+	}
+	if len(gresp.Kvs) == 0 { // is the session key lost?
+		return ErrSessionExpired
+	}
+	sem.hdr = gresp.Header
+	return nil
+}
+
+// enqueue puts the key of the session in the waiter queue, reusing the key in
+// case this session already waits for or holds a permit.
+func (sem *Semaphore) enqueue(ctx context.Context) error {
+	s := sem.s
+	client := s.Client()
+
+	sem.myKey = fmt.Sprintf("%s%x", sem.pfx, s.Lease())
+	cmp := v3.Compare(v3.CreateRevision(sem.myKey), "=", 0)
+	put := v3.OpPut(sem.myKey, "", v3.WithLease(s.Lease()))
+	get := v3.OpGet(sem.myKey)
+	resp, err := client.Txn(ctx).If(cmp).Then(put).Else(get).Commit()
+	if err != nil {
+		return err
+	}
+	sem.myRev = resp.Header.Revision
+	if !resp.Succeeded {
+		sem.myRev = resp.Responses[0].GetResponseRange().Kvs[0].CreateRevision
+	}
+	return nil
+}
+
+// holdersAhead gets the keys of the waiters and holders queued before the
+// session. The count of the range ignores the revision filters, so the keys
+// are counted instead.
+func (sem *Semaphore) holdersAhead(ctx context.Context) (*v3.GetResponse, error) {
+	return sem.s.Client().Get(ctx, sem.pfx, v3.WithPrefix(), v3.WithMaxCreateRev(sem.myRev-1), v3.WithKeysOnly())
+}
+
+// waitPermit waits until fewer sessions than permits are queued before the
+// session.
+func (sem *Semaphore) waitPermit(ctx context.Context) error {
+	client := sem.s.Client()
+	for {
+		resp, err := sem.holdersAhead(ctx)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) < sem.permits {
+			return nil
+		}
+		// wait for a release, or the expiry of a session, since the count
+		if err = waitPrefixDelete(ctx, client, sem.pfx, resp.Header.Revision+1); err != nil {
+			return err
+		}
+	}
+}
+
+// Release releases the permit held, or stops waiting for one.
+func (sem *Semaphore) Release(ctx context.Context) error {
+	client := sem.s.Client()
+	if _, err := client.Delete(ctx, sem.myKey); err != nil {
+		return err
+	}
+	sem.myKey = "\x00"
+	sem.myRev = -1
+	return nil
+}
+
+// IsOwner compares true as long as the session holds the key of the permit.
+func (sem *Semaphore) IsOwner() v3.Cmp {
+	return v3.Compare(v3.CreateRevision(sem.myKey), "=", sem.myRev)
+}
+
+func (sem *Semaphore) Key() string { return sem.myKey }
+
+// Header is the response header received from etcd on acquiring the permit.
+func (sem *Semaphore) Header() *pb.ResponseHeader { return sem.hdr }
@@ -17,6 +17,7 @@ package concurrency
 import (
 	"context"
 	"math"
+	"sync/atomic"
 
 	v3 "go.etcd.io/etcd/client/v3"
 )
@@ -54,8 +55,56 @@ const (
 	RepeatableReads
 	// ReadCommitted reads keys from any committed revision.
 	ReadCommitted
+	// Snapshot reads within the same transaction attempt return data from
+	// the revision of the first read, or from the writes of the attempt. The
+	// transaction only conflicts on the keys written since the first read,
+	// so that unlike SerializableSnapshot it allows write skew.
+	Snapshot
 )
 
+// STMStats observes the attempts of STM transactions, to monitor their
+// contention.
+type STMStats interface {
+	// Conflict is called when an attempt fails to commit for conflicting
+	// with another transaction, before the transaction is retried.
+	Conflict(iso Isolation)
+	// Commit is called when a transaction commits after the given number
+	// of attempts.
+	Commit(iso Isolation, attempts int)
+	// Abort is called when a transaction is aborted with an error.
+	Abort(iso Isolation, err error)
+}
+
+// STMCounters is an STMStats counting the outcomes of the attempts of all
+// the STM transactions it observes.
+type STMCounters struct {
+	commits   uint64
+	retries   uint64
+	conflicts uint64
+	aborts    uint64
+}
+
+func (c *STMCounters) Conflict(iso Isolation) { atomic.AddUint64(&c.conflicts, 1) }
+
+func (c *STMCounters) Commit(iso Isolation, attempts int) {
+	atomic.AddUint64(&c.commits, 1)
+	atomic.AddUint64(&c.retries, uint64(attempts-1))
+}
+
+func (c *STMCounters) Abort(iso Isolation, err error) { atomic.AddUint64(&c.aborts, 1) }
+
+// Commits returns the number of committed transactions.
+func (c *STMCounters) Commits() uint64 { return atomic.LoadUint64(&c.commits) }
+
+// Retries returns the number of attempts retried by committed transactions.
+func (c *STMCounters) Retries() uint64 { return atomic.LoadUint64(&c.retries) }
+
+// Conflicts returns the number of attempts which conflicted.
+func (c *STMCounters) Conflicts() uint64 { return atomic.LoadUint64(&c.conflicts) }
+
+// Aborts returns the number of aborted transactions.
+func (c *STMCounters) Aborts() uint64 { return atomic.LoadUint64(&c.aborts) }
+
 // stmError safely passes STM errors through panic to the STM error channel.
 type stmError struct{ err error }
 
@@ -63,6 +112,7 @@ type stmOptions struct {
 	iso      Isolation
 	ctx      context.Context
 	prefetch []string
+	stats    STMStats
 }
 
 type stmOption func(*stmOptions)
@@ -85,6 +135,11 @@ func WithPrefetch(keys ...string) stmOption {
 	return func(so *stmOptions) { so.prefetch = append(so.prefetch, keys...) }
 }
 
+// WithStats specifies the observer of the attempts of the transaction.
+func WithStats(stats STMStats) stmOption {
+	return func(so *stmOptions) { so.stats = stats }
+}
+
 // NewSTM initiates a new STM instance, using serializable snapshot isolation by default.
 func NewSTM(c *v3.Client, apply func(STM) error, so ...stmOption) (*v3.TxnResponse, error) {
 	opts := &stmOptions{ctx: c.Ctx()}
@@ -98,7 +153,7 @@ func NewSTM(c *v3.Client, apply func(STM) error, so ...stmOption) (*v3.TxnRespon
 			return f(s)
 		}
 	}
-	return runSTM(mkSTM(c, opts), apply)
+	return runSTM(mkSTM(c, opts), apply, opts)
 }
 
 func mkSTM(c *v3.Client, opts *stmOptions) STM {
@@ -127,6 +182,13 @@ func mkSTM(c *v3.Client, opts *stmOptions) STM {
 		s := &stm{client: c, ctx: opts.ctx, getOpts: []v3.OpOption{v3.WithSerializable()}}
 		s.conflicts = func() []v3.Cmp { return nil }
 		return s
+	case Snapshot:
+		s := &stmSerializable{
+			stm:      stm{client: c, ctx: opts.ctx},
+			prefetch: make(map[string]*v3.GetResponse),
+		}
+		s.conflicts = func() []v3.Cmp { return s.wset.cmps(s.rset.first() + 1) }
+		return s
 	default:
 		panic("unsupported stm")
 	}
@@ -137,7 +199,7 @@ type stmResponse struct {
 	err  error
 }
 
-func runSTM(s STM, apply func(STM) error) (*v3.TxnResponse, error) {
+func runSTM(s STM, apply func(STM) error, opts *stmOptions) (*v3.TxnResponse, error) {
 	outc := make(chan stmResponse, 1)
 	go func() {
 		defer func() {
@@ -151,18 +213,27 @@ func runSTM(s STM, apply func(STM) error) (*v3.TxnResponse, error) {
 			}
 		}()
 		var out stmResponse
-		for {
+		for attempt := 1; ; attempt++ {
 			s.reset()
 			if out.err = apply(s); out.err != nil {
 				break
 			}
 			if out.resp = s.commit(); out.resp != nil {
+				if opts.stats != nil {
+					opts.stats.Commit(opts.iso, attempt)
+				}
 				break
 			}
+			if opts.stats != nil {
+				opts.stats.Conflict(opts.iso)
+			}
 		}
 		outc <- out
 	}()
 	r := <-outc
+	if r.err != nil && opts.stats != nil {
+		opts.stats.Abort(opts.iso, r.err)
+	}
 	return r.resp, r.err
 }
 
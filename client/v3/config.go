@@ -65,6 +65,13 @@ type Config struct {
 	// Password is a password for authentication.
 	Password string `json:"password"`
 
+	// Credentials, if set, returns the user name and password each time the
+	// client authenticates, in place of Username and Password, so that the
+	// credentials can be rotated without creating a new client. The client
+	// authenticates again, and retries once, when a request is refused for
+	// an invalid or outdated auth token.
+	Credentials func(ctx context.Context) (username, password string, err error) `json:"-"`
+
 	// RejectOldCluster when set will refuse to create a client against an outdated cluster.
 	RejectOldCluster bool `json:"reject-old-cluster"`
 
@@ -89,9 +96,58 @@ type Config struct {
 	// PermitWithoutStream when set will allow client to send keepalive pings to server without any active streams(RPCs).
 	PermitWithoutStream bool `json:"permit-without-stream"`
 
+	// CircuitBreaker, if set, ejects the endpoints that keep failing or
+	// responding slowly from the balancer for a cool-down period, as long as
+	// other endpoints are available.
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit-breaker"`
+
+	// RetryPolicy decides how the failed calls are retried, if set. By
+	// default, the calls are retried up to 100 times, backing off after
+	// every attempt against a quorum of the endpoints.
+	RetryPolicy RetryPolicy
+
+	// HedgeDelay, when positive, hedges the linearizable Range requests: a
+	// request that gets no response within the delay is issued again to
+	// another endpoint, and the first response wins while the other requests
+	// are canceled. The requests that may mutate the store, transactions
+	// included, are never hedged.
+	HedgeDelay time.Duration `json:"hedge-delay"`
+
+	// HedgeMaxRequests is the maximum number of requests issued for a hedged
+	// Range request, the first one included. If 0, it defaults to 2.
+	HedgeMaxRequests int `json:"hedge-max-requests"`
+
+	// WatchCacheSize, when positive, buffers up to that many events of every
+	// watch locally and resumes the watches from the buffered revision when
+	// their stream is lost, e.g. on a leader change. See NewWatchCache.
+	WatchCacheSize int `json:"watch-cache-size"`
+
+	// IdempotentWrites attaches a random request ID to the Put, Delete and Txn
+	// requests that have none, see WithRequestID, so that they are retried
+	// like the reads. It requires the servers to deduplicate the requests,
+	// which they do unless started with --experimental-request-id-window=0.
+	IdempotentWrites bool `json:"idempotent-writes"`
+
 	// TODO: support custom balancer picker
 }
 
+// CircuitBreakerConfig configures the ejection of the unhealthy endpoints.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive requests failing, the
+	// endpoint being unavailable or the request timing out, that ejects an
+	// endpoint. If 0, it defaults to 5.
+	FailureThreshold int `json:"failure-threshold"`
+
+	// LatencyThreshold ejects an endpoint once the moving average of the
+	// latency of its key-value requests exceeds it, if positive.
+	LatencyThreshold time.Duration `json:"latency-threshold"`
+
+	// CoolDown is how long an endpoint stays ejected. Once it is over, the
+	// endpoint is ejected again on its first failure. If 0, it defaults to
+	// 10 seconds.
+	CoolDown time.Duration `json:"cool-down"`
+}
+
 // ConfigSpec is the configuration from users, which comes from command-line flags,
 // environment variables or config file. It is a fully declarative configuration,
 // and can be serialized & deserialized to/from JSON.
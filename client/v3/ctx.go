@@ -16,6 +16,8 @@ package clientv3
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
@@ -36,6 +38,46 @@ func WithRequireLeader(ctx context.Context) context.Context {
 	return metadata.NewOutgoingContext(ctx, copied)
 }
 
+// WithRequestID attaches an ID to the mutation requested with the context,
+// so that the server applies a Put, Delete or Txn once however many times it
+// is retried, and answers the retries with the response of the first one. The
+// ID must be unique among the recent mutations of the user, and must not be
+// reused for another mutation. Unlike the other mutations, the mutations with
+// a request ID are retried by the client after a failure.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok { // no outgoing metadata ctx key, create one
+		md = metadata.Pairs(rpctypes.MetadataRequestIDKey, id)
+		return metadata.NewOutgoingContext(ctx, md)
+	}
+	copied := md.Copy() // avoid racey updates
+	// overwrite/add request id key/value
+	copied.Set(rpctypes.MetadataRequestIDKey, id)
+	return metadata.NewOutgoingContext(ctx, copied)
+}
+
+// requestIDFromContext returns the request ID attached to the context with
+// WithRequestID, if any.
+func requestIDFromContext(ctx context.Context) string {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if ids := md.Get(rpctypes.MetadataRequestIDKey); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
 // embeds client version
 func withVersion(ctx context.Context) context.Context {
 	md, ok := metadata.FromOutgoingContext(ctx)
@@ -65,3 +65,48 @@ func TestMetadataWithClientAPIVersion(t *testing.T) {
 		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataClientAPIVersionKey, ss)
 	}
 }
+
+func TestMetadataWithRequestID(t *testing.T) {
+	if id := requestIDFromContext(context.TODO()); id != "" {
+		t.Fatalf("unexpected request id %q", id)
+	}
+
+	ctx := WithRequestID(WithRequireLeader(context.TODO()), "first")
+	ctx = WithRequestID(ctx, "second")
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		t.Fatal("expected outgoing metadata ctx key")
+	}
+	if ss := md.Get(rpctypes.MetadataRequestIDKey); !reflect.DeepEqual(ss, []string{"second"}) {
+		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataRequestIDKey, ss)
+	}
+	if ss := md.Get(rpctypes.MetadataRequireLeaderKey); !reflect.DeepEqual(ss, []string{rpctypes.MetadataHasLeader}) {
+		t.Fatalf("unexpected metadata for %q %v", rpctypes.MetadataRequireLeaderKey, ss)
+	}
+	if id := requestIDFromContext(ctx); id != "second" {
+		t.Fatalf("request id = %q, want %q", id, "second")
+	}
+}
+
+func TestRetryKVClientMutationOpts(t *testing.T) {
+	rkv := &retryKVClient{}
+	ctx, opts := rkv.mutationOpts(context.TODO(), nil)
+	if len(opts) != 0 || requestIDFromContext(ctx) != "" {
+		t.Fatalf("expected a mutation without request id not to be retried")
+	}
+
+	ctx, opts = rkv.mutationOpts(WithRequestID(context.TODO(), "id"), nil)
+	if len(opts) != 1 || requestIDFromContext(ctx) != "id" {
+		t.Fatalf("expected a mutation with request id to be retried, got %d options", len(opts))
+	}
+
+	rkv.idempotentWrites = true
+	ctx, opts = rkv.mutationOpts(context.TODO(), nil)
+	id := requestIDFromContext(ctx)
+	if len(opts) != 1 || len(id) != 32 {
+		t.Fatalf("expected a random request id to be attached, got %q", id)
+	}
+	if ctx, _ = rkv.mutationOpts(context.TODO(), nil); requestIDFromContext(ctx) == id {
+		t.Fatalf("expected a new request id for every mutation")
+	}
+}
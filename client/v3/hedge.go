@@ -0,0 +1,207 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+
+	"google.golang.org/grpc"
+)
+
+const defaultHedgeMaxRequests = 2
+
+// hedgeKVClient hedges the linearizable Range requests of a KVClient across
+// the endpoints of a client: when a request gets no response within the
+// delay, it is issued again to the next endpoint, up to max requests, and
+// the first response wins while the other requests are canceled. Only the
+// Range requests, which never mutate the store, are hedged; the other
+// requests, transactions included, are passed through once.
+type hedgeKVClient struct {
+	pb.KVClient
+	delay time.Duration
+	max   int
+
+	endpoints func() []string
+	dial      func(ep string) (pb.KVClient, io.Closer, error)
+
+	mu   sync.Mutex
+	kvcs map[string]hedgeConn
+	// next is the endpoint the next hedged request is first issued to.
+	next int
+	// closed is set once the client is closed, no endpoint is dialed then.
+	closed bool
+}
+
+// hedgeConn is the KVClient pinned to an endpoint and its connection.
+type hedgeConn struct {
+	pb.KVClient
+	conn io.Closer
+}
+
+func newHedgeKVClient(c *Client, kvc pb.KVClient) pb.KVClient {
+	max := c.cfg.HedgeMaxRequests
+	if max <= 0 {
+		max = defaultHedgeMaxRequests
+	}
+	return &hedgeKVClient{
+		KVClient:  kvc,
+		delay:     c.cfg.HedgeDelay,
+		max:       max,
+		endpoints: c.Endpoints,
+		dial: func(ep string) (pb.KVClient, io.Closer, error) {
+			conn, err := c.Dial(ep)
+			if err != nil {
+				return nil, nil, err
+			}
+			return pb.NewKVClient(conn), conn, nil
+		},
+		kvcs: make(map[string]hedgeConn),
+	}
+}
+
+// kvClient returns the KVClient pinned to the endpoint ep.
+func (h *hedgeKVClient) kvClient(ep string) (pb.KVClient, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, context.Canceled
+	}
+	if hc, ok := h.kvcs[ep]; ok {
+		return hc.KVClient, nil
+	}
+	kvc, conn, err := h.dial(ep)
+	if err != nil {
+		return nil, err
+	}
+	h.kvcs[ep] = hedgeConn{KVClient: kvc, conn: conn}
+	return kvc, nil
+}
+
+// closeRemoved closes the connections to the endpoints not in eps, which
+// were removed from the client.
+func (h *hedgeKVClient) closeRemoved(eps []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ep, hc := range h.kvcs {
+		if !containsString(eps, ep) {
+			delete(h.kvcs, ep)
+			hc.close()
+		}
+	}
+}
+
+// Close closes the connections to all the endpoints.
+func (h *hedgeKVClient) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ep, hc := range h.kvcs {
+		delete(h.kvcs, ep)
+		hc.close()
+	}
+	return nil
+}
+
+func (hc hedgeConn) close() {
+	if hc.conn != nil {
+		// the requests still in flight on the connection fail with it
+		hc.conn.Close()
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *hedgeKVClient) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (*pb.RangeResponse, error) {
+	eps := h.endpoints()
+	h.closeRemoved(eps)
+	if in.Serializable || len(eps) < 2 {
+		return h.KVClient.Range(ctx, in, opts...)
+	}
+	max := h.max
+	if max > len(eps) {
+		max = len(eps)
+	}
+	h.mu.Lock()
+	start := h.next
+	h.next = (h.next + 1) % len(eps)
+	h.mu.Unlock()
+
+	// canceling the context cancels the requests that lost
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		resp *pb.RangeResponse
+		err  error
+	}
+	resc := make(chan result, max)
+	issued := 0
+	issue := func() {
+		ep := eps[(start+issued)%len(eps)]
+		issued++
+		go func() {
+			kvc, err := h.kvClient(ep)
+			if err != nil {
+				resc <- result{err: err}
+				return
+			}
+			resp, err := kvc.Range(ctx, in, opts...)
+			resc <- result{resp: resp, err: err}
+		}()
+	}
+
+	issue()
+	hedgec := time.After(h.delay)
+	var err error
+	for failed := 0; ; {
+		if issued == max {
+			hedgec = nil
+		}
+		select {
+		case r := <-resc:
+			if r.err == nil {
+				return r.resp, nil
+			}
+			err = r.err
+			// the request would fail on any endpoint
+			if isHaltErr(ctx, err) {
+				return nil, err
+			}
+			if failed++; failed < issued {
+				continue
+			}
+			if issued == max {
+				return nil, err
+			}
+			// all the requests failed, hedge without waiting
+			issue()
+			hedgec = time.After(h.delay)
+		case <-hedgec:
+			issue()
+			hedgec = time.After(h.delay)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"google.golang.org/grpc"
+)
+
+// fakeRangeKVClient serves the Range requests of an endpoint.
+type fakeRangeKVClient struct {
+	pb.KVClient
+	member uint64
+	// delay is how long the requests take, unless canceled
+	delay time.Duration
+	err   error
+
+	canceled chan struct{}
+}
+
+func (f *fakeRangeKVClient) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (*pb.RangeResponse, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		if f.canceled != nil {
+			close(f.canceled)
+		}
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pb.RangeResponse{Header: &pb.ResponseHeader{MemberId: f.member}}, nil
+}
+
+func newTestHedgeKVClient(max int, kvcs ...*fakeRangeKVClient) *hedgeKVClient {
+	var eps []string
+	byEp := make(map[string]pb.KVClient)
+	for _, kvc := range kvcs {
+		ep := string(rune('a' + kvc.member))
+		eps = append(eps, ep)
+		byEp[ep] = kvc
+	}
+	return &hedgeKVClient{
+		KVClient:  &fakeRangeKVClient{member: 0},
+		delay:     50 * time.Millisecond,
+		max:       max,
+		endpoints: func() []string { return eps },
+		dial:      func(ep string) (pb.KVClient, io.Closer, error) { return byEp[ep], nil, nil },
+		kvcs:      make(map[string]hedgeConn),
+	}
+}
+
+func TestHedgeKVClientRange(t *testing.T) {
+	slow := &fakeRangeKVClient{member: 1, delay: time.Hour, canceled: make(chan struct{})}
+	fast := &fakeRangeKVClient{member: 2}
+	h := newTestHedgeKVClient(2, slow, fast)
+
+	start := time.Now()
+	resp, err := h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != 2 {
+		t.Fatalf("expected the response of member 2, got member %d", resp.Header.MemberId)
+	}
+	if took := time.Since(start); took < h.delay {
+		t.Fatalf("expected the request to be hedged after %v, took %v", h.delay, took)
+	}
+	select {
+	case <-slow.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the slow request to be canceled")
+	}
+
+	// the next request is first issued to the next endpoint
+	if resp, err = h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != 2 {
+		t.Fatalf("expected the response of member 2, got member %d", resp.Header.MemberId)
+	}
+
+	// serializable requests are not hedged
+	if resp, err = h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo"), Serializable: true}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != 0 {
+		t.Fatalf("expected the response of the unhedged client, got member %d", resp.Header.MemberId)
+	}
+}
+
+func TestHedgeKVClientRangeError(t *testing.T) {
+	// a failed request is hedged without waiting
+	unavailable := &fakeRangeKVClient{member: 1, err: rpctypes.ErrGRPCNoLeader}
+	ok := &fakeRangeKVClient{member: 2}
+	h := newTestHedgeKVClient(2, unavailable, ok)
+	h.delay = time.Hour
+	resp, err := h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != 2 {
+		t.Fatalf("expected the response of member 2, got member %d", resp.Header.MemberId)
+	}
+
+	// an error that would be returned by any endpoint is not hedged
+	denied := &fakeRangeKVClient{member: 1, err: rpctypes.ErrGRPCPermissionDenied}
+	h = newTestHedgeKVClient(2, denied, ok)
+	h.delay = time.Hour
+	if _, err = h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}); err != rpctypes.ErrGRPCPermissionDenied {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrGRPCPermissionDenied, err)
+	}
+
+	// the last error is returned once all the requests failed
+	h = newTestHedgeKVClient(2, unavailable, &fakeRangeKVClient{member: 2, err: rpctypes.ErrGRPCNoLeader})
+	if _, err = h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}); err != rpctypes.ErrGRPCNoLeader {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrGRPCNoLeader, err)
+	}
+}
+
+type fakeConn struct{ closed bool }
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestHedgeKVClientCloseConns(t *testing.T) {
+	eps := []string{"a", "b", "c"}
+	conns := make(map[string]*fakeConn)
+	h := newTestHedgeKVClient(3)
+	h.endpoints = func() []string { return eps }
+	h.dial = func(ep string) (pb.KVClient, io.Closer, error) {
+		conns[ep] = &fakeConn{}
+		return &fakeRangeKVClient{}, conns[ep], nil
+	}
+	for _, ep := range eps {
+		if _, err := h.kvClient(ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the connections to the removed endpoints are closed
+	eps = []string{"a"}
+	if _, err := h.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo"), Serializable: true}); err != nil {
+		t.Fatal(err)
+	}
+	if conns["a"].closed || !conns["b"].closed || !conns["c"].closed {
+		t.Errorf("expected only the connections to b and c to be closed, got a: %v, b: %v, c: %v", conns["a"].closed, conns["b"].closed, conns["c"].closed)
+	}
+
+	// and the others once the client is closed
+	h.Close()
+	if !conns["a"].closed {
+		t.Error("expected the connection to a to be closed")
+	}
+	if _, err := h.kvClient("a"); err != context.Canceled {
+		t.Errorf("expected %v from a closed client, got %v", context.Canceled, err)
+	}
+}
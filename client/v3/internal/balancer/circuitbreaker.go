@@ -0,0 +1,267 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package balancer implements a round robin gRPC balancer with a circuit
+// breaker, ejecting the endpoints that keep failing or responding slowly
+// for a cool-down period.
+package balancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/serviceconfig"
+	"google.golang.org/grpc/status"
+)
+
+// Name is the name of the circuit breaker balancer in the service configs.
+const Name = "etcd_circuit_breaker"
+
+const (
+	defaultFailureThreshold = 5
+	defaultCoolDown         = 10 * time.Second
+
+	// latencySamples is the number of requests an endpoint has to serve before
+	// its latency is compared to the threshold.
+	latencySamples = 5
+	// latencyWeight is the weight of the latest request in the moving average
+	// of the latency.
+	latencyWeight = 0.3
+)
+
+func init() {
+	balancer.Register(builder{})
+}
+
+// Config is the load balancing config of the circuit breaker.
+type Config struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// FailureThreshold is the number of consecutive failed requests ejecting
+	// an endpoint. If 0, it defaults to 5.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// LatencyThreshold ejects an endpoint once the moving average of the
+	// latency of its key-value requests exceeds it, if positive.
+	LatencyThreshold time.Duration `json:"latencyThreshold,omitempty"`
+	// CoolDown is how long an endpoint stays ejected. If 0, it defaults to
+	// 10 seconds.
+	CoolDown time.Duration `json:"coolDown,omitempty"`
+}
+
+// ServiceConfig returns the gRPC service config selecting the circuit breaker
+// configured with cfg.
+func ServiceConfig(cfg Config) string {
+	b, err := json.Marshal(map[string]interface{}{
+		"loadBalancingConfig": []map[string]Config{{Name: cfg}},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal the circuit breaker config: %v", err))
+	}
+	return string(b)
+}
+
+type builder struct{}
+
+func (builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	t := newTracker(Config{}, time.Now)
+	return &circuitBreaker{
+		Balancer: base.NewBalancerBuilder(Name, &pickerBuilder{t: t}, base.Config{HealthCheck: true}).Build(cc, opts),
+		t:        t,
+	}
+}
+
+func (builder) Name() string { return Name }
+
+func (builder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	var cfg Config
+	if err := json.Unmarshal(js, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid circuit breaker config: %v", err)
+	}
+	return &cfg, nil
+}
+
+// circuitBreaker is a base round robin balancer whose pickers skip the
+// ejected endpoints.
+type circuitBreaker struct {
+	balancer.Balancer
+	t *tracker
+}
+
+func (b *circuitBreaker) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*Config); ok {
+		b.t.setConfig(*cfg)
+	}
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+type pickerBuilder struct {
+	t *tracker
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &picker{t: pb.t, next: uint32(rand.Intn(len(info.ReadySCs)))}
+	for sc, sci := range info.ReadySCs {
+		p.scs = append(p.scs, sc)
+		p.addrs = append(p.addrs, sci.Address.Addr)
+	}
+	return p
+}
+
+// picker picks the ready endpoints in turn, skipping the ejected ones unless
+// all of them are.
+type picker struct {
+	t     *tracker
+	scs   []balancer.SubConn
+	addrs []string
+	next  uint32
+}
+
+func (p *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	n := uint32(len(p.scs))
+	start := atomic.AddUint32(&p.next, 1)
+	i := start % n
+	for j := uint32(0); j < n; j++ {
+		if k := (start + j) % n; !p.t.ejected(p.addrs[k]) {
+			i = k
+			break
+		}
+	}
+	addr := p.addrs[i]
+	measured := strings.HasPrefix(info.FullMethodName, "/etcdserverpb.KV/") && info.FullMethodName != "/etcdserverpb.KV/RangeStream"
+	picked := time.Now()
+	return balancer.PickResult{
+		SubConn: p.scs[i],
+		Done: func(di balancer.DoneInfo) {
+			var latency time.Duration
+			if measured {
+				latency = time.Since(picked)
+			}
+			p.t.observe(addr, di.Err, latency)
+		},
+	}, nil
+}
+
+// tracker scores the health of the endpoints from the outcome of their
+// requests, and ejects the unhealthy ones.
+type tracker struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	cfg       Config
+	endpoints map[string]*endpointHealth
+}
+
+type endpointHealth struct {
+	// failures is the number of consecutive failed requests.
+	failures int
+	// latency is the moving average of the latency of the requests.
+	latency time.Duration
+	samples int
+	// ejectedUntil is when the cool-down of the endpoint ends.
+	ejectedUntil time.Time
+}
+
+func newTracker(cfg Config, now func() time.Time) *tracker {
+	t := &tracker{now: now, endpoints: make(map[string]*endpointHealth)}
+	t.setConfig(cfg)
+	return t
+}
+
+func (t *tracker) setConfig(cfg Config) {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = defaultCoolDown
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+}
+
+func (t *tracker) ejected(addr string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.endpoints[addr]
+	return ok && t.now().Before(h.ejectedUntil)
+}
+
+// observe records the outcome of a request to addr, and latency if it is
+// measured.
+func (t *tracker) observe(addr string, err error, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.endpoints[addr]
+	if !ok {
+		h = &endpointHealth{}
+		t.endpoints[addr] = h
+	}
+	now := t.now()
+	if now.Before(h.ejectedUntil) {
+		// a request picked before the ejection
+		return
+	}
+
+	if err != nil {
+		if !isFailure(err) {
+			return
+		}
+		if h.failures++; h.failures >= t.cfg.FailureThreshold {
+			t.eject(h, now)
+		}
+		return
+	}
+	h.failures = 0
+	if latency <= 0 || t.cfg.LatencyThreshold <= 0 {
+		return
+	}
+	if h.samples == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(h.latency))
+	}
+	if h.samples++; h.samples >= latencySamples && h.latency > t.cfg.LatencyThreshold {
+		t.eject(h, now)
+	}
+}
+
+// eject ejects the endpoint for the cool-down period; once it is over, the
+// endpoint is ejected again on its first failure.
+func (t *tracker) eject(h *endpointHealth, now time.Time) {
+	h.ejectedUntil = now.Add(t.cfg.CoolDown)
+	h.failures = t.cfg.FailureThreshold - 1
+	h.latency, h.samples = 0, 0
+}
+
+// isFailure returns true if err tells that the endpoint is unhealthy, rather
+// than the request invalid.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
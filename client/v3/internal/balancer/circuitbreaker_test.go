@@ -0,0 +1,153 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
+)
+
+var errUnavailable = status.Error(codes.Unavailable, "etcdserver: no leader")
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestTrackerFailures(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	tr := newTracker(Config{FailureThreshold: 3, CoolDown: time.Minute}, clock.now)
+
+	tr.observe("a", errUnavailable, 0)
+	tr.observe("a", errUnavailable, 0)
+	tr.observe("a", nil, 0)
+	tr.observe("a", errUnavailable, 0)
+	tr.observe("a", errUnavailable, 0)
+	// the errors telling nothing about the health of the endpoint are ignored
+	tr.observe("a", status.Error(codes.InvalidArgument, "etcdserver: request is too large"), 0)
+	tr.observe("a", errors.New("canceled"), 0)
+	if tr.ejected("a") {
+		t.Fatal("expected the endpoint not to be ejected before 3 consecutive failures")
+	}
+	tr.observe("a", errUnavailable, 0)
+	if !tr.ejected("a") {
+		t.Fatal("expected the endpoint to be ejected after 3 consecutive failures")
+	}
+	if tr.ejected("b") {
+		t.Fatal("expected the other endpoints not to be ejected")
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+	if tr.ejected("a") {
+		t.Fatal("expected the endpoint to be back after the cool-down")
+	}
+	tr.observe("a", errUnavailable, 0)
+	if !tr.ejected("a") {
+		t.Fatal("expected the endpoint to be ejected again on its first failure")
+	}
+}
+
+func TestTrackerLatency(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	tr := newTracker(Config{LatencyThreshold: 100 * time.Millisecond}, clock.now)
+
+	for i := 0; i < 10; i++ {
+		tr.observe("a", nil, 10*time.Millisecond)
+		// the requests not measured are ignored
+		tr.observe("a", nil, 0)
+	}
+	if tr.ejected("a") {
+		t.Fatal("expected the fast endpoint not to be ejected")
+	}
+	for i := 0; i < 10 && !tr.ejected("a"); i++ {
+		tr.observe("a", nil, time.Second)
+	}
+	if !tr.ejected("a") {
+		t.Fatal("expected the slow endpoint to be ejected")
+	}
+	clock.t = clock.t.Add(defaultCoolDown)
+	if tr.ejected("a") {
+		t.Fatal("expected the endpoint to be back after the default cool-down")
+	}
+}
+
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+func TestPickerSkipsEjected(t *testing.T) {
+	tr := newTracker(Config{FailureThreshold: 1}, time.Now)
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for _, addr := range []string{"a", "b", "c"} {
+		info.ReadySCs[&fakeSubConn{addr: addr}] = base.SubConnInfo{Address: resolver.Address{Addr: addr}}
+	}
+	p := (&pickerBuilder{t: tr}).Build(info)
+
+	pick := func() string {
+		res, err := p.Pick(balancer.PickInfo{FullMethodName: "/etcdserverpb.KV/Range"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.SubConn.(*fakeSubConn).addr
+	}
+	res, err := p.Pick(balancer.PickInfo{FullMethodName: "/etcdserverpb.KV/Range"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Done(balancer.DoneInfo{Err: errUnavailable})
+	ejected := res.SubConn.(*fakeSubConn).addr
+	for i := 0; i < 10; i++ {
+		if addr := pick(); addr == ejected {
+			t.Fatalf("expected the ejected endpoint %q not to be picked", ejected)
+		}
+	}
+
+	// the endpoints are still picked once all of them are ejected
+	for _, addr := range []string{"a", "b", "c"} {
+		tr.observe(addr, errUnavailable, 0)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		seen[pick()] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected all the ejected endpoints to be picked in turn, got %v", seen)
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	cfg := Config{FailureThreshold: 2, LatencyThreshold: time.Second, CoolDown: time.Minute}
+	var sc struct {
+		LoadBalancingConfig []map[string]json.RawMessage `json:"loadBalancingConfig"`
+	}
+	if err := json.Unmarshal([]byte(ServiceConfig(cfg)), &sc); err != nil {
+		t.Fatal(err)
+	}
+	lbc, err := builder{}.ParseConfig(sc.LoadBalancingConfig[0][Name])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *lbc.(*Config); got != cfg {
+		t.Fatalf("expected config %+v, got %+v", cfg, got)
+	}
+}
@@ -23,24 +23,33 @@ import (
 
 const (
 	Schema = "etcd-endpoints"
+
+	defaultServiceConfig = `{"loadBalancingPolicy": "round_robin"}`
 )
 
 // EtcdManualResolver is a Resolver (and resolver.Builder) that can be updated
 // using SetEndpoints.
 type EtcdManualResolver struct {
 	*manual.Resolver
-	endpoints     []string
-	serviceConfig *serviceconfig.ParseResult
+	endpoints         []string
+	serviceConfigJSON string
+	serviceConfig     *serviceconfig.ParseResult
 }
 
 func New(endpoints ...string) *EtcdManualResolver {
+	return NewWithServiceConfig(defaultServiceConfig, endpoints...)
+}
+
+// NewWithServiceConfig returns a resolver passing the given gRPC service
+// config, e.g. to select another balancer than round robin.
+func NewWithServiceConfig(serviceConfig string, endpoints ...string) *EtcdManualResolver {
 	r := manual.NewBuilderWithScheme(Schema)
-	return &EtcdManualResolver{Resolver: r, endpoints: endpoints, serviceConfig: nil}
+	return &EtcdManualResolver{Resolver: r, endpoints: endpoints, serviceConfigJSON: serviceConfig}
 }
 
 // Build returns itself for Resolver, because it's both a builder and a resolver.
 func (r *EtcdManualResolver) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
-	r.serviceConfig = cc.ParseServiceConfig(`{"loadBalancingPolicy": "round_robin"}`)
+	r.serviceConfig = cc.ParseServiceConfig(r.serviceConfigJSON)
 	if r.serviceConfig.Err != nil {
 		return nil, r.serviceConfig.Err
 	}
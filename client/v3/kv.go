@@ -16,6 +16,9 @@ package clientv3
 
 import (
 	"context"
+	"io"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -48,6 +51,12 @@ type KV interface {
 	// When passed WithSort(), the keys will be sorted.
 	Get(ctx context.Context, key string, opts ...OpOption) (*GetResponse, error)
 
+	// GetStream retrieves keys like Get, but the server streams them in several
+	// responses at the same revision instead of a single one, so that large
+	// ranges neither exceed the maximum message size nor have to be buffered.
+	// The returned iterator must be closed once done with.
+	GetStream(ctx context.Context, key string, opts ...OpOption) (*GetIterator, error)
+
 	// Delete deletes a key, or optionally using WithRange(end), [key, end).
 	Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error)
 
@@ -90,6 +99,95 @@ func (resp *TxnResponse) OpResponse() OpResponse {
 	return OpResponse{txn: resp}
 }
 
+// GetIterator iterates over the keys retrieved by GetStream.
+// Next and Recv should not both be used on the same iterator.
+type GetIterator struct {
+	recv  func() (*GetResponse, error)
+	close func()
+
+	resp *GetResponse
+	kvs  []*mvccpb.KeyValue
+	kv   *mvccpb.KeyValue
+	err  error
+}
+
+// NewGetIterator returns an iterator over the responses returned by recv
+// until it fails, io.EOF marking the end of the range. close is called by
+// Close. It is useful to wrap the iterators returned by a KV.
+func NewGetIterator(recv func() (*GetResponse, error), close func()) *GetIterator {
+	return &GetIterator{recv: recv, close: close}
+}
+
+// Next advances to the next key and reports whether there is one. Once it
+// returns false, Err returns the error that ended the iteration, if any.
+func (it *GetIterator) Next() bool {
+	for len(it.kvs) == 0 {
+		resp, err := it.Recv()
+		if err != nil {
+			it.kv = nil
+			return false
+		}
+		it.kvs = resp.Kvs
+	}
+	it.kv, it.kvs = it.kvs[0], it.kvs[1:]
+	return true
+}
+
+// KV returns the key Next advanced to.
+func (it *GetIterator) KV() *mvccpb.KeyValue { return it.kv }
+
+// Recv returns the next response of the range, or io.EOF after the last one.
+func (it *GetIterator) Recv() (*GetResponse, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	resp, err := it.recv()
+	if err != nil {
+		it.err = err
+		return nil, err
+	}
+	it.resp = resp
+	return resp, nil
+}
+
+// Header returns the header of the range, nil until a response is received.
+func (it *GetIterator) Header() *pb.ResponseHeader {
+	if it.resp == nil {
+		return nil
+	}
+	return it.resp.Header
+}
+
+// Count returns the number of keys in the range, once a response is received.
+func (it *GetIterator) Count() int64 {
+	if it.resp == nil {
+		return 0
+	}
+	return it.resp.Count
+}
+
+// More reports, once the iteration is over, whether the range has more keys
+// than the limit of the request.
+func (it *GetIterator) More() bool {
+	return it.err == io.EOF && it.resp != nil && it.resp.More
+}
+
+// Err returns the error that ended the iteration, nil if the whole range was
+// received.
+func (it *GetIterator) Err() error {
+	if it.err == io.EOF {
+		return nil
+	}
+	return it.err
+}
+
+// Close stops receiving the range.
+func (it *GetIterator) Close() {
+	if it.close != nil {
+		it.close()
+	}
+}
+
 type kv struct {
 	remote   pb.KVClient
 	callOpts []grpc.CallOption
@@ -99,10 +197,21 @@ func NewKV(c *Client) KV {
 	api := &kv{remote: RetryKVClient(c)}
 	if c != nil {
 		api.callOpts = c.callOpts
+		if c.cfg.HedgeDelay > 0 {
+			api.remote = newHedgeKVClient(c, api.remote)
+		}
 	}
 	return api
 }
 
+// Close closes the connections of the KV that are not shared with the client.
+func (kv *kv) Close() error {
+	if c, ok := kv.remote.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 func NewKVFromKVClient(remote pb.KVClient, c *Client) KV {
 	api := &kv{remote: remote}
 	if c != nil {
@@ -121,6 +230,29 @@ func (kv *kv) Get(ctx context.Context, key string, opts ...OpOption) (*GetRespon
 	return r.get, toErr(ctx, err)
 }
 
+func (kv *kv) GetStream(ctx context.Context, key string, opts ...OpOption) (*GetIterator, error) {
+	op := OpGet(key, opts...)
+	if !op.IsSortOptionValid() {
+		return nil, rpctypes.ErrInvalidSortOption
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := kv.remote.RangeStream(ctx, op.toRangeRequest(), kv.callOpts...)
+	if err != nil {
+		cancel()
+		return nil, toErr(ctx, err)
+	}
+	return NewGetIterator(func() (*GetResponse, error) {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, toErr(ctx, err)
+		}
+		return (*GetResponse)(resp.RangeResponse), nil
+	}, cancel), nil
+}
+
 func (kv *kv) Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error) {
 	r, err := kv.Do(ctx, OpDelete(key, opts...))
 	return r.del, toErr(ctx, err)
@@ -157,7 +289,7 @@ func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
 		}
 	case tPut:
 		var resp *pb.PutResponse
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease}
+		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease, Ttl: op.ttl}
 		resp, err = kv.remote.Put(ctx, r, kv.callOpts...)
 		if err == nil {
 			return OpResponse{put: (*PutResponse)(resp)}, nil
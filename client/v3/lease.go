@@ -60,12 +60,18 @@ type LeaseTimeToLiveResponse struct {
 
 	// Keys is the list of keys attached to this lease.
 	Keys [][]byte `json:"keys"`
+
+	// Labels are the metadata attached to the lease when granted.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // LeaseStatus represents a lease status.
 type LeaseStatus struct {
 	ID LeaseID `json:"id"`
 	// TODO: TTL int64
+
+	// Labels are the metadata attached to the lease when granted.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // LeaseLeasesResponse wraps the protobuf message LeaseLeasesResponse.
@@ -106,8 +112,9 @@ func (e ErrKeepAliveHalted) Error() string {
 }
 
 type Lease interface {
-	// Grant creates a new lease.
-	Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error)
+	// Grant creates a new lease. Labels can be attached to the lease with
+	// WithLabels.
+	Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error)
 
 	// Revoke revokes the given lease.
 	Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse, error)
@@ -211,8 +218,8 @@ func NewLeaseFromLeaseClient(remote pb.LeaseClient, c *Client, keepAliveTimeout
 	return l
 }
 
-func (l *lessor) Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error) {
-	r := &pb.LeaseGrantRequest{TTL: ttl}
+func (l *lessor) Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error) {
+	r := toLeaseGrantRequest(ttl, opts...)
 	resp, err := l.remote.LeaseGrant(ctx, r, l.callOpts...)
 	if err == nil {
 		gresp := &LeaseGrantResponse{
@@ -247,6 +254,7 @@ func (l *lessor) TimeToLive(ctx context.Context, id LeaseID, opts ...LeaseOption
 		TTL:            resp.TTL,
 		GrantedTTL:     resp.GrantedTTL,
 		Keys:           resp.Keys,
+		Labels:         resp.Labels,
 	}
 	return gresp, nil
 }
@@ -256,7 +264,7 @@ func (l *lessor) Leases(ctx context.Context) (*LeaseLeasesResponse, error) {
 	if err == nil {
 		leases := make([]LeaseStatus, len(resp.Leases))
 		for i := range resp.Leases {
-			leases[i] = LeaseStatus{ID: LeaseID(resp.Leases[i].ID)}
+			leases[i] = LeaseStatus{ID: LeaseID(resp.Leases[i].ID), Labels: resp.Leases[i].Labels}
 		}
 		return &LeaseLeasesResponse{ResponseHeader: resp.GetHeader(), Leases: leases}, nil
 	}
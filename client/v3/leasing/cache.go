@@ -31,7 +31,16 @@ type leaseCache struct {
 	mu      sync.RWMutex
 	entries map[string]*leaseKey
 	revokes map[string]time.Time
-	header  *v3pb.ResponseHeader
+	// generations holds the last generation of the invalidated prefixes.
+	generations map[string]generation
+	header      *v3pb.ResponseHeader
+}
+
+// generation is a bulk invalidation of the leases of a prefix.
+type generation struct {
+	// rev is the revision of the generation key.
+	rev int64
+	at  time.Time
 }
 
 type leaseKey struct {
@@ -119,21 +128,59 @@ func (lc *leaseCache) NotifyOps(ops []v3.Op) (wcs []<-chan struct{}) {
 
 func (lc *leaseCache) MayAcquire(key string) bool {
 	lc.mu.RLock()
-	lr, ok := lc.revokes[key]
-	lc.mu.RUnlock()
-	return !ok || time.Since(lr) > revokeBackoff
+	defer lc.mu.RUnlock()
+	if lr, ok := lc.revokes[key]; ok && time.Since(lr) <= revokeBackoff {
+		return false
+	}
+	for pfx, g := range lc.generations {
+		if strings.HasPrefix(key, pfx) && time.Since(g.at) <= revokeBackoff {
+			return false
+		}
+	}
+	return true
 }
 
-func (lc *leaseCache) Add(key string, resp *v3.GetResponse, op v3.Op) *v3.GetResponse {
+// Add caches the response of a lease acquired at its revision, unless the
+// prefix of the key was invalidated since. It returns the response to op and
+// whether it is cached.
+func (lc *leaseCache) Add(key string, resp *v3.GetResponse, op v3.Op) (*v3.GetResponse, bool) {
 	lk := &leaseKey{resp, resp.Header.Revision, closedCh}
 	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	for pfx, g := range lc.generations {
+		if strings.HasPrefix(key, pfx) && g.rev > lk.rev {
+			return lk.get(op), false
+		}
+	}
 	if lc.header == nil || lc.header.Revision < resp.Header.Revision {
 		lc.header = resp.Header
 	}
 	lc.entries[key] = lk
-	ret := lk.get(op)
-	lc.mu.Unlock()
-	return ret
+	return lk.get(op), true
+}
+
+// EvictGeneration evicts the keys with the prefix whose leases were acquired
+// before the generation at rev, but the ones being written, and returns them.
+func (lc *leaseCache) EvictGeneration(pfx string, rev int64) (keys []string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if g, ok := lc.generations[pfx]; !ok || g.rev < rev {
+		lc.generations[pfx] = generation{rev: rev, at: time.Now()}
+	}
+	for k, li := range lc.entries {
+		if !strings.HasPrefix(k, pfx) || li.rev >= rev {
+			continue
+		}
+		select {
+		case <-li.waitc:
+		default:
+			// the write completes under the lease
+			continue
+		}
+		delete(lc.entries, k)
+		keys = append(keys, k)
+	}
+	return keys
 }
 
 func (lc *leaseCache) Update(key, val []byte, respHeader *v3pb.ResponseHeader) {
@@ -260,6 +307,11 @@ func (lc *leaseCache) clearOldRevokes(ctx context.Context) {
 					delete(lc.revokes, k)
 				}
 			}
+			for pfx, g := range lc.generations {
+				if time.Since(g.at.Add(revokeBackoff)) > 0 {
+					delete(lc.generations, pfx)
+				}
+			}
 			lc.mu.Unlock()
 		}
 	}
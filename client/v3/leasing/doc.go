@@ -43,4 +43,15 @@
 //     lkv2.Put(context.TODO(), "abc", "456")
 //     resp, err = lkv.Get("abc")
 //
+// Writes contending for many leased keys are better served by the server than
+// by revoking each lease. Invalidating a prefix releases the leases of its keys
+// held by all the leasing clients at once, and keeps them from acquiring the
+// leases again for a while:
+//
+//     err = leasing.InvalidatePrefix(context.TODO(), lkv, "ab")
+//
+// The keys beginning with "\x00generation/" are reserved for the generations
+// of the invalidated prefixes, and are not cached. The cache hits and misses and
+// the invalidations are counted by the metrics under "etcd_client_leasing".
+//
 package leasing
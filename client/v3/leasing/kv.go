@@ -40,11 +40,19 @@ type leasingKV struct {
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
+	// genMu serializes the invalidations of the generations.
+	genMu sync.Mutex
+
 	sessionOpts []concurrency.SessionOption
 	session     *concurrency.Session
 	sessionc    chan struct{}
 }
 
+// generationPrefix is the prefix, under the leasing prefix, of the keys whose
+// writes invalidate the leases of their prefix. The keys with this prefix are
+// not cached.
+const generationPrefix = "\x00generation/"
+
 var closedCh chan struct{}
 
 func init() {
@@ -59,17 +67,21 @@ func NewKV(cl *v3.Client, pfx string, opts ...concurrency.SessionOption) (v3.KV,
 		cl:          cl,
 		kv:          cl.KV,
 		pfx:         pfx,
-		leases:      leaseCache{revokes: make(map[string]time.Time)},
+		leases:      leaseCache{revokes: make(map[string]time.Time), generations: make(map[string]generation)},
 		ctx:         cctx,
 		cancel:      cancel,
 		sessionOpts: opts,
 		sessionc:    make(chan struct{}),
 	}
-	lkv.wg.Add(2)
+	lkv.wg.Add(3)
 	go func() {
 		defer lkv.wg.Done()
 		lkv.monitorSession()
 	}()
+	go func() {
+		defer lkv.wg.Done()
+		lkv.monitorGenerations()
+	}()
 	go func() {
 		defer lkv.wg.Done()
 		lkv.leases.clearOldRevokes(cctx)
@@ -77,6 +89,21 @@ func NewKV(cl *v3.Client, pfx string, opts ...concurrency.SessionOption) (v3.KV,
 	return lkv, lkv.Close, lkv.waitSession(cctx)
 }
 
+// Invalidator is implemented by the KVs whose cached keys can be invalidated
+// by prefix, such as the leasing KVs and the namespace KVs wrapping them.
+type Invalidator interface {
+	InvalidatePrefix(ctx context.Context, prefix string) error
+}
+
+// InvalidatePrefix invalidates the cached keys of kv with the given prefix, if
+// kv is an Invalidator.
+func InvalidatePrefix(ctx context.Context, kv v3.KV, prefix string) error {
+	if inv, ok := kv.(Invalidator); ok {
+		return inv.InvalidatePrefix(ctx, prefix)
+	}
+	return nil
+}
+
 func (lkv *leasingKV) Close() {
 	lkv.cancel()
 	lkv.wg.Wait()
@@ -86,6 +113,12 @@ func (lkv *leasingKV) Get(ctx context.Context, key string, opts ...v3.OpOption)
 	return lkv.get(ctx, v3.OpGet(key, opts...))
 }
 
+// GetStream bypasses the leasing cache, ranges large enough to be streamed
+// are better not cached.
+func (lkv *leasingKV) GetStream(ctx context.Context, key string, opts ...v3.OpOption) (*v3.GetIterator, error) {
+	return lkv.kv.GetStream(ctx, key, opts...)
+}
+
 func (lkv *leasingKV) Put(ctx context.Context, key, val string, opts ...v3.OpOption) (*v3.PutResponse, error) {
 	return lkv.put(ctx, v3.OpPut(key, val, opts...))
 }
@@ -121,6 +154,56 @@ func (lkv *leasingKV) Txn(ctx context.Context) v3.Txn {
 	return &txnLeasing{Txn: lkv.kv.Txn(ctx), lkv: lkv, ctx: ctx}
 }
 
+// InvalidatePrefix invalidates the leases of the keys with the prefix held by
+// all the leasing clients sharing the leasing prefix, which stop acquiring them
+// for a while.
+func (lkv *leasingKV) InvalidatePrefix(ctx context.Context, prefix string) error {
+	resp, err := lkv.kv.Put(ctx, lkv.pfx+generationPrefix+prefix, "")
+	if err != nil {
+		return err
+	}
+	lkv.invalidate(prefix, resp.Header.Revision)
+	return nil
+}
+
+// monitorGenerations invalidates the leases of the prefixes whose generation
+// keys are written.
+func (lkv *leasingKV) monitorGenerations() {
+	gpfx := lkv.pfx + generationPrefix
+	rev := int64(0)
+	for lkv.ctx.Err() == nil {
+		opts := []v3.OpOption{v3.WithPrefix()}
+		if rev != 0 {
+			opts = append(opts, v3.WithRev(rev+1))
+		}
+		for resp := range lkv.cl.Watch(lkv.ctx, gpfx, opts...) {
+			if resp.CompactRevision != 0 {
+				rev = 0
+				continue
+			}
+			for _, ev := range resp.Events {
+				rev = ev.Kv.ModRevision
+				if ev.Type == v3.EventTypePut {
+					lkv.invalidate(strings.TrimPrefix(string(ev.Kv.Key), gpfx), rev)
+				}
+			}
+		}
+	}
+}
+
+// invalidate evicts the keys with the prefix whose leases were acquired before
+// rev, and releases their leases.
+func (lkv *leasingKV) invalidate(prefix string, rev int64) {
+	// InvalidatePrefix returns once released, by it or by monitorGenerations
+	lkv.genMu.Lock()
+	defer lkv.genMu.Unlock()
+	keys := lkv.leases.EvictGeneration(prefix, rev)
+	invalidationsTotal.WithLabelValues("generation").Add(float64(len(keys)))
+	for _, key := range keys {
+		lkv.rescind(lkv.ctx, key, rev)
+	}
+}
+
 func (lkv *leasingKV) monitorSession() {
 	for lkv.ctx.Err() == nil {
 		if lkv.session != nil {
@@ -169,10 +252,15 @@ func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64) {
 		wch := lkv.cl.Watch(cctx, lkv.pfx+key, v3.WithRev(rev+1))
 		for resp := range wch {
 			for _, ev := range resp.Events {
+				if ev.Type == v3.EventTypeDelete {
+					// released, such as by a generation of its prefix
+					return
+				}
 				if string(ev.Kv.Value) != "REVOKE" {
 					continue
 				}
 				if v3.LeaseID(ev.Kv.Lease) == lkv.leaseID() {
+					invalidationsTotal.WithLabelValues("revoke").Inc()
 					lkv.rescind(cctx, key, ev.Kv.ModRevision)
 				}
 				return
@@ -229,6 +317,10 @@ func (lkv *leasingKV) tryModifyOp(ctx context.Context, op v3.Op) (*v3.TxnRespons
 }
 
 func (lkv *leasingKV) put(ctx context.Context, op v3.Op) (pr *v3.PutResponse, err error) {
+	if reserved(op.KeyBytes()) {
+		r, err := lkv.kv.Do(ctx, op)
+		return r.Put(), err
+	}
 	if err := lkv.waitSession(ctx); err != nil {
 		return nil, err
 	}
@@ -297,16 +389,18 @@ func (lkv *leasingKV) get(ctx context.Context, op v3.Op) (*v3.GetResponse, error
 		r, err := lkv.kv.Do(ctx, op)
 		return r.Get(), err
 	}
-	if !lkv.readySession() {
+	if !lkv.readySession() || reserved(op.KeyBytes()) {
 		return do()
 	}
 
 	if resp, ok := lkv.leases.Get(ctx, op); resp != nil {
+		cacheHitsTotal.Inc()
 		return resp, nil
 	} else if !ok || op.IsSerializable() {
 		// must be handled by server or can skip linearization
 		return do()
 	}
+	cacheMissesTotal.Inc()
 
 	key := string(op.KeyBytes())
 	if !lkv.leases.MayAcquire(key) {
@@ -321,10 +415,16 @@ func (lkv *leasingKV) get(ctx context.Context, op v3.Op) (*v3.GetResponse, error
 	getResp := (*v3.GetResponse)(resp.Responses[0].GetResponseRange())
 	getResp.Header = resp.Header
 	if resp.Succeeded {
-		getResp = lkv.leases.Add(key, getResp, op)
+		var cached bool
+		getResp, cached = lkv.leases.Add(key, getResp, op)
 		lkv.wg.Add(1)
 		go func() {
 			defer lkv.wg.Done()
+			if !cached {
+				// invalidated while acquired
+				lkv.rescind(lkv.ctx, key, resp.Header.Revision+1)
+				return
+			}
 			lkv.monitorLease(ctx, key, resp.Header.Revision)
 		}()
 	}
@@ -378,6 +478,10 @@ func (lkv *leasingKV) delete(ctx context.Context, op v3.Op) (dr *v3.DeleteRespon
 	if len(op.RangeBytes()) > 0 {
 		return lkv.deleteRange(ctx, op)
 	}
+	if reserved(op.KeyBytes()) {
+		r, err := lkv.kv.Do(ctx, op)
+		return r.Del(), err
+	}
 	key := string(op.KeyBytes())
 	for ctx.Err() == nil {
 		resp, wc, err := lkv.tryModifyOp(ctx, op)
@@ -432,11 +536,11 @@ func (lkv *leasingKV) revokeLeaseKvs(ctx context.Context, kvs []*mvccpb.KeyValue
 		if rev := kv.CreateRevision; rev > maxLeaseRev {
 			maxLeaseRev = rev
 		}
-		if v3.LeaseID(kv.Lease) == lkv.leaseID() {
-			// don't revoke own keys
+		key := strings.TrimPrefix(string(kv.Key), lkv.pfx)
+		if v3.LeaseID(kv.Lease) == lkv.leaseID() || reserved([]byte(key)) {
+			// don't revoke own keys, nor generation keys
 			continue
 		}
-		key := strings.TrimPrefix(string(kv.Key), lkv.pfx)
 		if _, err := lkv.revoke(ctx, key, v3.OpGet(key)); err != nil {
 			return 0, err
 		}
@@ -477,3 +581,9 @@ func (lkv *leasingKV) leaseID() v3.LeaseID {
 	defer lkv.leases.mu.RUnlock()
 	return lkv.session.Lease()
 }
+
+// reserved returns whether the key maps to a generation key under the leasing
+// prefix, and so is not cached.
+func reserved(key []byte) bool {
+	return strings.HasPrefix(string(key), generationPrefix)
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasing
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	cacheHitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_leasing",
+		Name:      "cache_hits_total",
+		Help:      "The total number of linearizable reads served from the leasing cache.",
+	})
+
+	cacheMissesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_leasing",
+		Name:      "cache_misses_total",
+		Help:      "The total number of cacheable linearizable reads sent to the server.",
+	})
+
+	invalidationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_leasing",
+		Name:      "invalidations_total",
+		Help:      "The total number of cached keys invalidated, by a revoke of their lease or by a generation of their prefix.",
+	}, []string{"type"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHitsTotal)
+	prometheus.MustRegister(cacheMissesTotal)
+	prometheus.MustRegister(invalidationsTotal)
+}
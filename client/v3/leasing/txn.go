@@ -108,7 +108,7 @@ func (txn *txnLeasing) guardKeys(ops []v3.Op) (cmps []v3.Cmp) {
 	seen := make(map[string]bool)
 	for _, op := range ops {
 		key := string(op.KeyBytes())
-		if op.IsGet() || len(op.RangeBytes()) != 0 || seen[key] {
+		if op.IsGet() || len(op.RangeBytes()) != 0 || seen[key] || reserved(op.KeyBytes()) {
 			continue
 		}
 		rev := txn.lkv.leases.Rev(key)
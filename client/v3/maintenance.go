@@ -15,26 +15,56 @@
 package clientv3
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 
+	"github.com/klauspost/compress/zstd"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
 
 type (
-	DefragmentResponse pb.DefragmentResponse
-	AlarmResponse      pb.AlarmResponse
-	AlarmMember        pb.AlarmMember
-	StatusResponse     pb.StatusResponse
-	HashKVResponse     pb.HashKVResponse
-	MoveLeaderResponse pb.MoveLeaderResponse
-	DowngradeResponse  pb.DowngradeResponse
+	DefragmentResponse       pb.DefragmentResponse
+	DefragmentStreamResponse pb.DefragmentStreamResponse
+	AlarmResponse            pb.AlarmResponse
+	AlarmMember              pb.AlarmMember
+	StatusResponse           pb.StatusResponse
+	HashKVResponse           pb.HashKVResponse
+	MoveLeaderResponse       pb.MoveLeaderResponse
+	DowngradeResponse        pb.DowngradeResponse
+
+	WatchConsumersResponse pb.WatchConsumersResponse
+	ConfigAdviceResponse   pb.ConfigAdviceResponse
+	BulkImportResponse     pb.BulkImportResponse
+	KeyHistogramResponse   pb.KeyHistogramResponse
+	BackupResponse         pb.BackupResponse
+	ReadOnlyResponse       pb.ReadOnlyResponse
+
+	Namespace               pb.Namespace
+	NamespacePutResponse    pb.NamespacePutResponse
+	NamespaceDeleteResponse pb.NamespaceDeleteResponse
+	NamespaceListResponse   pb.NamespaceListResponse
+
+	Index               pb.Index
+	IndexPutResponse    pb.IndexPutResponse
+	IndexDeleteResponse pb.IndexDeleteResponse
+	IndexListResponse   pb.IndexListResponse
+	IndexRangeResponse  pb.IndexRangeResponse
+
+	LogLevelResponse      pb.LogLevelResponse
+	SlowLogResponse       pb.SlowLogResponse
+	TopKeysResponse       pb.TopKeysResponse
+	FragmentationResponse pb.FragmentationResponse
 
 	DowngradeAction pb.DowngradeRequest_DowngradeAction
+	ReadOnlyAction  pb.ReadOnlyRequest_ReadOnlyAction
 )
 
 const (
@@ -43,6 +73,19 @@ const (
 	DowngradeCancel   = DowngradeAction(pb.DowngradeRequest_CANCEL)
 )
 
+const (
+	ReadOnlyGet     = ReadOnlyAction(pb.ReadOnlyRequest_GET)
+	ReadOnlyEnable  = ReadOnlyAction(pb.ReadOnlyRequest_ENABLE)
+	ReadOnlyDisable = ReadOnlyAction(pb.ReadOnlyRequest_DISABLE)
+)
+
+const (
+	// SnapshotCompressionZstd compresses the snapshot stream with zstd.
+	SnapshotCompressionZstd = "zstd"
+	// SnapshotCompressionGzip compresses the snapshot stream with gzip.
+	SnapshotCompressionGzip = "gzip"
+)
+
 type Maintenance interface {
 	// AlarmList gets all active alarms.
 	AlarmList(ctx context.Context) (*AlarmResponse, error)
@@ -59,6 +102,12 @@ type Maintenance interface {
 	// times with different endpoints.
 	Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error)
 
+	// DefragmentWithProgress defragments a given etcd member like Defragment,
+	// calling progress as the defragmentation goes, and returns the last
+	// response once it is done. The member keeps serving requests while its
+	// backend is copied. Supported since etcd 3.6.
+	DefragmentWithProgress(ctx context.Context, endpoint string, progress func(*DefragmentStreamResponse)) (*DefragmentStreamResponse, error)
+
 	// Status gets the status of the endpoint.
 	Status(ctx context.Context, endpoint string) (*StatusResponse, error)
 
@@ -72,6 +121,20 @@ type Maintenance interface {
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
 	SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error)
 
+	// SnapshotWithCompression is like SnapshotWithVersion, but asks the server to compress
+	// the snapshot stream with the given algorithm, e.g. SnapshotCompressionZstd. The
+	// returned reader decompresses the stream. Servers that do not support compression,
+	// e.g. etcd < v3.6, send the snapshot uncompressed.
+	SnapshotWithCompression(ctx context.Context, compression string) (*SnapshotResponse, error)
+
+	// SnapshotResumable is like SnapshotWithCompression, but asks the server to keep the
+	// snapshot for a while if the stream breaks. SnapshotResponse.ID is the id the
+	// snapshot can be resumed with, zero if the server does not keep it, e.g. etcd < v3.6.
+	// A non-zero id resumes the kept snapshot instead, from offset bytes on: the returned
+	// reader skips the first offset bytes of the snapshot, but the sha256 digest read
+	// last still covers the whole snapshot.
+	SnapshotResumable(ctx context.Context, compression string, id uint64, offset int64) (*SnapshotResponse, error)
+
 	// Snapshot provides a reader for a point-in-time snapshot of etcd.
 	// If the context "ctx" is canceled or timed out, reading from returned
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
@@ -86,6 +149,119 @@ type Maintenance interface {
 	// on the cluster version.
 	// Supported since etcd 3.5.
 	Downgrade(ctx context.Context, action DowngradeAction, version string) (*DowngradeResponse, error)
+
+	// WatchConsumers lists the at most limit watches of the endpoint that
+	// receive the most events per second, or 10 if limit is zero.
+	// Supported since etcd 3.6.
+	WatchConsumers(ctx context.Context, endpoint string, limit int64) (*WatchConsumersResponse, error)
+
+	// ConfigAdvice analyzes the runtime stats of the endpoint against its
+	// configuration and recommends configuration changes.
+	// Supported since etcd 3.6.
+	ConfigAdvice(ctx context.Context, endpoint string) (*ConfigAdviceResponse, error)
+
+	// BulkImport writes the key-value pairs returned by next, sorted by key,
+	// straight into the backend at revision rev, or at the revision following
+	// the current one if rev is zero, until next returns io.EOF. The cluster
+	// must have a single member, which rejects other writes until the import
+	// is done. Only the key and value of the key-value pairs are imported.
+	// Supported since etcd 3.6.
+	BulkImport(ctx context.Context, rev int64, next func() ([]*mvccpb.KeyValue, error)) (*BulkImportResponse, error)
+
+	// KeyHistogram counts the keys of the endpoint and their value bytes grouped
+	// by their prefix ending with the depth-th separator, or "/" if separator is
+	// empty. The keys counted are given by key and the WithRange, WithPrefix or
+	// WithFromKey options, or all the keys if key is empty. WithRev counts the
+	// keys of a past revision, and WithLimit only returns the buckets with the
+	// most keys.
+	// Supported since etcd 3.6.
+	KeyHistogram(ctx context.Context, endpoint, key, separator string, depth int64, opts ...OpOption) (*KeyHistogramResponse, error)
+
+	// Backup calls f with the changes of the key-value store after sinceRev, up to
+	// the revision of the header of the responses, in revision order, as an
+	// incremental backup which can be layered onto a snapshot taken at or after
+	// sinceRev. The changes of a revision may be split over several responses.
+	// Make sure to specify only one endpoint in the client configuration, as for
+	// snapshots.
+	// Supported since etcd 3.6.
+	Backup(ctx context.Context, sinceRev int64, f func(*BackupResponse) error) error
+
+	// ReadOnly enables, disables or gets the read-only mode of the cluster.
+	// While the cluster is read-only, the requests mutating keys, leases or
+	// auth fail with rpctypes.ErrReadOnly, while reads and watches are served
+	// as usual.
+	// Supported since etcd 3.6.
+	ReadOnly(ctx context.Context, action ReadOnlyAction) (*ReadOnlyResponse, error)
+
+	// NamespacePut registers a namespace, or updates the limits of the one with
+	// the same prefix. The writes to the keys of the namespace fail with
+	// rpctypes.ErrNamespaceQuotaExceeded once they exceed its quotas, and the
+	// writes attaching leases with a larger TTL than its limit fail with
+	// rpctypes.ErrNamespaceLeaseTTLTooLarge. The keys of nested namespaces count
+	// against the quotas of all of them.
+	// Supported since etcd 3.6.
+	NamespacePut(ctx context.Context, ns Namespace) (*NamespacePutResponse, error)
+
+	// NamespaceDelete unregisters the namespace of prefix, leaving its keys in
+	// place.
+	// Supported since etcd 3.6.
+	NamespaceDelete(ctx context.Context, prefix string) (*NamespaceDeleteResponse, error)
+
+	// NamespaceList lists the namespaces with their usage of the quotas.
+	// Supported since etcd 3.6.
+	NamespaceList(ctx context.Context) (*NamespaceListResponse, error)
+
+	// IndexPut registers a secondary index of the values of a field of the
+	// JSON values of the keys with a prefix, or replaces the index with the
+	// same name. The index is built from the keys in place, then kept up to date
+	// by the writes to the keys.
+	// Supported since etcd 3.6.
+	IndexPut(ctx context.Context, idx Index) (*IndexPutResponse, error)
+
+	// IndexDelete unregisters the index name.
+	// Supported since etcd 3.6.
+	IndexDelete(ctx context.Context, name string) (*IndexDeleteResponse, error)
+
+	// IndexList lists the indexes with the number of keys they index.
+	// Supported since etcd 3.6.
+	IndexList(ctx context.Context) (*IndexListResponse, error)
+
+	// IndexRange gets the keys of the index name whose value of the indexed
+	// field is value, sorted by key. The options are those of Get: WithRange,
+	// WithPrefix and WithFromKey get the keys with the indexed values in a
+	// range instead, sorted by value then by key, and WithLimit,
+	// WithSerializable, WithKeysOnly and WithCountOnly apply alike. The values
+	// of the numbers are as written in the JSON values, and those of the
+	// booleans are "true" and "false".
+	// Supported since etcd 3.6.
+	IndexRange(ctx context.Context, name, value string, opts ...OpOption) (*IndexRangeResponse, error)
+
+	// LogLevel sets the log levels of the member of the endpoint at runtime,
+	// and returns them. A non-empty defaultLevel sets the level of the modules
+	// without their own level, and levels sets the levels of the modules by
+	// name, an empty level making the module log at the default level again.
+	// Supported since etcd 3.6.
+	LogLevel(ctx context.Context, endpoint, defaultLevel string, levels map[string]string) (*LogLevelResponse, error)
+
+	// SlowLog lists the last requests served by the member of the endpoint
+	// slower than its slow request threshold, most recent first, at most limit
+	// of them if limit is positive.
+	// Supported since etcd 3.6.
+	SlowLog(ctx context.Context, endpoint string, limit int64) (*SlowLogResponse, error)
+
+	// TopKeys reports the largest keys of the member of the endpoint, its
+	// prefixes ending with the depth-th separator, or "/" if separator is
+	// empty, with the most revisions, and its watch ranges with the most
+	// watchers or the largest backlog, limit of each or 10 if limit is not
+	// positive.
+	// Supported since etcd 3.6.
+	TopKeys(ctx context.Context, endpoint, separator string, depth, limit int64) (*TopKeysResponse, error)
+
+	// Fragmentation reports how the pages of the backend of the member of the
+	// endpoint are used, and whether its defragmentation would reclaim at
+	// least minReclaimableRatio of its size, or 0.25 if not positive.
+	// Supported since etcd 3.6.
+	Fragmentation(ctx context.Context, endpoint string, minReclaimableRatio float64) (*FragmentationResponse, error)
 }
 
 // SnapshotResponse is aggregated response from the snapshot stream.
@@ -101,6 +277,9 @@ type SnapshotResponse struct {
 	// Informs which etcd server version should be used when restoring the snapshot.
 	// Supported on etcd >= v3.6.
 	Version string
+	// ID is the id the snapshot can be resumed with, zero if it is not resumable.
+	// Supported on etcd >= v3.6.
+	ID uint64
 }
 
 type maintenance struct {
@@ -211,6 +390,33 @@ func (m *maintenance) Defragment(ctx context.Context, endpoint string) (*Defragm
 	return (*DefragmentResponse)(resp), nil
 }
 
+func (m *maintenance) DefragmentWithProgress(ctx context.Context, endpoint string, progress func(*DefragmentStreamResponse)) (*DefragmentStreamResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	stream, err := remote.DefragmentStream(ctx, &pb.DefragmentRequest{}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, toErr(ctx, err)
+		}
+		if resp.Done {
+			return (*DefragmentStreamResponse)(resp), nil
+		}
+		if progress != nil {
+			progress((*DefragmentStreamResponse)(resp))
+		}
+	}
+}
+
 func (m *maintenance) Status(ctx context.Context, endpoint string) (*StatusResponse, error) {
 	remote, cancel, err := m.dial(endpoint)
 	if err != nil {
@@ -239,21 +445,48 @@ func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*
 }
 
 func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error) {
-	ss, err := m.remote.Snapshot(ctx, &pb.SnapshotRequest{}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
+	return m.SnapshotWithCompression(ctx, "")
+}
+
+func (m *maintenance) SnapshotWithCompression(ctx context.Context, compression string) (*SnapshotResponse, error) {
+	return m.snapshot(ctx, &pb.SnapshotRequest{Compression: compression})
+}
+
+func (m *maintenance) SnapshotResumable(ctx context.Context, compression string, id uint64, offset int64) (*SnapshotResponse, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid snapshot offset %d", offset)
+	}
+	return m.snapshot(ctx, &pb.SnapshotRequest{Compression: compression, Resumable: true, SnapshotId: id, Offset: uint64(offset)})
+}
+
+func (m *maintenance) snapshot(ctx context.Context, req *pb.SnapshotRequest) (*SnapshotResponse, error) {
+	// a resumed snapshot is not retried, the server gives up keeping it once resumed
+	opts := m.callOpts
+	if req.SnapshotId == 0 {
+		opts = append(opts, withMax(defaultStreamMaxRetries))
+	}
+	ss, err := m.remote.Snapshot(ctx, req, opts...)
 	if err != nil {
 		return nil, toErr(ctx, err)
 	}
 
-	m.lg.Info("opened snapshot stream; downloading")
+	m.lg.Info("opened snapshot stream; downloading",
+		zap.String("compression", req.Compression),
+		zap.Uint64("snapshot-id", req.SnapshotId),
+		zap.Uint64("offset", req.Offset),
+	)
 	pr, pw := io.Pipe()
 
 	resp, err := ss.Recv()
 	if err != nil {
 		m.logAndCloseWithError(err, pw)
+		return nil, toErr(ctx, err)
 	}
 	go func() {
+		var dec snapshotDecoder
+		defer dec.close()
 		// Saving response is blocking
-		err = m.save(resp, pw)
+		err = m.saveCompressed(resp, pw, &dec)
 		if err != nil {
 			m.logAndCloseWithError(err, pw)
 			return
@@ -264,7 +497,7 @@ func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotRespons
 				m.logAndCloseWithError(err, pw)
 				return
 			}
-			err = m.save(resp, pw)
+			err = m.saveCompressed(resp, pw, &dec)
 			if err != nil {
 				m.logAndCloseWithError(err, pw)
 				return
@@ -275,7 +508,8 @@ func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotRespons
 		Header:   resp.Header,
 		Snapshot: &snapshotReadCloser{ctx: ctx, ReadCloser: pr},
 		Version:  resp.Version,
-	}, err
+		ID:       resp.SnapshotId,
+	}, nil
 }
 
 func (m *maintenance) Snapshot(ctx context.Context) (io.ReadCloser, error) {
@@ -326,6 +560,59 @@ func (m *maintenance) save(resp *pb.SnapshotResponse, pw *io.PipeWriter) error {
 	return nil
 }
 
+// snapshotDecoder decompresses the snapshot blobs, the decoders are created on
+// the first compressed blob and reused afterwards.
+type snapshotDecoder struct {
+	zstd *zstd.Decoder
+	gzip *gzip.Reader
+}
+
+func (d *snapshotDecoder) decode(compression string, blob []byte) ([]byte, error) {
+	switch compression {
+	case SnapshotCompressionZstd:
+		if d.zstd == nil {
+			dec, err := zstd.NewReader(nil)
+			if err != nil {
+				return nil, err
+			}
+			d.zstd = dec
+		}
+		return d.zstd.DecodeAll(blob, nil)
+	case SnapshotCompressionGzip:
+		var err error
+		if d.gzip == nil {
+			d.gzip, err = gzip.NewReader(bytes.NewReader(blob))
+		} else {
+			err = d.gzip.Reset(bytes.NewReader(blob))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(d.gzip)
+	default:
+		return nil, fmt.Errorf("unsupported snapshot compression %q", compression)
+	}
+}
+
+func (d *snapshotDecoder) close() {
+	if d.zstd != nil {
+		d.zstd.Close()
+	}
+}
+
+// saveCompressed is like save for blobs which may be compressed.
+func (m *maintenance) saveCompressed(resp *pb.SnapshotResponse, pw *io.PipeWriter, dec *snapshotDecoder) error {
+	if resp.Compression == "" {
+		return m.save(resp, pw)
+	}
+	blob, err := dec.decode(resp.Compression, resp.Blob)
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(blob)
+	return err
+}
+
 type snapshotReadCloser struct {
 	ctx context.Context
 	io.ReadCloser
@@ -356,3 +643,220 @@ func (m *maintenance) Downgrade(ctx context.Context, action DowngradeAction, ver
 	resp, err := m.remote.Downgrade(ctx, &pb.DowngradeRequest{Action: actionType, Version: version}, m.callOpts...)
 	return (*DowngradeResponse)(resp), toErr(ctx, err)
 }
+
+func (m *maintenance) ReadOnly(ctx context.Context, action ReadOnlyAction) (*ReadOnlyResponse, error) {
+	var actionType pb.ReadOnlyRequest_ReadOnlyAction
+	switch action {
+	case ReadOnlyGet:
+		actionType = pb.ReadOnlyRequest_GET
+	case ReadOnlyEnable:
+		actionType = pb.ReadOnlyRequest_ENABLE
+	case ReadOnlyDisable:
+		actionType = pb.ReadOnlyRequest_DISABLE
+	default:
+		return nil, errors.New("etcdclient: unknown read-only action")
+	}
+	resp, err := m.remote.ReadOnly(ctx, &pb.ReadOnlyRequest{Action: actionType}, m.callOpts...)
+	return (*ReadOnlyResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) WatchConsumers(ctx context.Context, endpoint string, limit int64) (*WatchConsumersResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.WatchConsumers(ctx, &pb.WatchConsumersRequest{Limit: limit}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*WatchConsumersResponse)(resp), nil
+}
+
+func (m *maintenance) ConfigAdvice(ctx context.Context, endpoint string) (*ConfigAdviceResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.ConfigAdvice(ctx, &pb.ConfigAdviceRequest{}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*ConfigAdviceResponse)(resp), nil
+}
+
+func (m *maintenance) BulkImport(ctx context.Context, rev int64, next func() ([]*mvccpb.KeyValue, error)) (*BulkImportResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := m.remote.BulkImport(ctx, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	for {
+		kvs, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = stream.Send(&pb.BulkImportRequest{Revision: rev, Kvs: kvs}); err != nil {
+			if err == io.EOF {
+				// the server failed, CloseAndRecv returns its error
+				break
+			}
+			return nil, toErr(ctx, err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*BulkImportResponse)(resp), nil
+}
+
+func (m *maintenance) Backup(ctx context.Context, sinceRev int64, f func(*BackupResponse) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := m.remote.Backup(ctx, &pb.BackupRequest{SinceRevision: sinceRev}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
+	if err != nil {
+		return toErr(ctx, err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return toErr(ctx, err)
+		}
+		if err = f((*BackupResponse)(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *maintenance) KeyHistogram(ctx context.Context, endpoint, key, separator string, depth int64, opts ...OpOption) (*KeyHistogramResponse, error) {
+	op := OpGet(key, opts...)
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	req := &pb.KeyHistogramRequest{
+		Key:       op.key,
+		RangeEnd:  op.end,
+		Separator: []byte(separator),
+		Depth:     depth,
+		Revision:  op.rev,
+		Limit:     op.limit,
+	}
+	resp, err := remote.KeyHistogram(ctx, req, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*KeyHistogramResponse)(resp), nil
+}
+
+func (m *maintenance) NamespacePut(ctx context.Context, ns Namespace) (*NamespacePutResponse, error) {
+	resp, err := m.remote.NamespacePut(ctx, &pb.NamespacePutRequest{Namespace: (*pb.Namespace)(&ns)}, m.callOpts...)
+	return (*NamespacePutResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) NamespaceDelete(ctx context.Context, prefix string) (*NamespaceDeleteResponse, error) {
+	resp, err := m.remote.NamespaceDelete(ctx, &pb.NamespaceDeleteRequest{Prefix: []byte(prefix)}, m.callOpts...)
+	return (*NamespaceDeleteResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) NamespaceList(ctx context.Context) (*NamespaceListResponse, error) {
+	resp, err := m.remote.NamespaceList(ctx, &pb.NamespaceListRequest{}, m.callOpts...)
+	return (*NamespaceListResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) IndexPut(ctx context.Context, idx Index) (*IndexPutResponse, error) {
+	resp, err := m.remote.IndexPut(ctx, &pb.IndexPutRequest{Index: (*pb.Index)(&idx)}, m.callOpts...)
+	return (*IndexPutResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) IndexDelete(ctx context.Context, name string) (*IndexDeleteResponse, error) {
+	resp, err := m.remote.IndexDelete(ctx, &pb.IndexDeleteRequest{Name: name}, m.callOpts...)
+	return (*IndexDeleteResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) IndexList(ctx context.Context) (*IndexListResponse, error) {
+	resp, err := m.remote.IndexList(ctx, &pb.IndexListRequest{}, m.callOpts...)
+	return (*IndexListResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) IndexRange(ctx context.Context, name, value string, opts ...OpOption) (*IndexRangeResponse, error) {
+	op := OpGet(value, opts...)
+	resp, err := m.remote.IndexRange(ctx, &pb.IndexRangeRequest{
+		Name:         name,
+		Value:        op.key,
+		RangeEnd:     op.end,
+		Limit:        op.limit,
+		Serializable: op.serializable,
+		KeysOnly:     op.keysOnly,
+		CountOnly:    op.countOnly,
+	}, m.callOpts...)
+	return (*IndexRangeResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) LogLevel(ctx context.Context, endpoint, defaultLevel string, levels map[string]string) (*LogLevelResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	req := &pb.LogLevelRequest{DefaultLevel: defaultLevel}
+	for module, level := range levels {
+		req.Modules = append(req.Modules, &pb.ModuleLogLevel{Module: module, Level: level})
+	}
+	sort.Slice(req.Modules, func(i, j int) bool { return req.Modules[i].Module < req.Modules[j].Module })
+	resp, err := remote.LogLevel(ctx, req, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*LogLevelResponse)(resp), nil
+}
+
+func (m *maintenance) SlowLog(ctx context.Context, endpoint string, limit int64) (*SlowLogResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.SlowLog(ctx, &pb.SlowLogRequest{Limit: limit}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*SlowLogResponse)(resp), nil
+}
+
+func (m *maintenance) TopKeys(ctx context.Context, endpoint, separator string, depth, limit int64) (*TopKeysResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	req := &pb.TopKeysRequest{Separator: []byte(separator), Depth: depth, Limit: limit}
+	resp, err := remote.TopKeys(ctx, req, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*TopKeysResponse)(resp), nil
+}
+
+func (m *maintenance) Fragmentation(ctx context.Context, endpoint string, minReclaimableRatio float64) (*FragmentationResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.Fragmentation(ctx, &pb.FragmentationRequest{MinReclaimableRatio: minReclaimableRatio}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*FragmentationResponse)(resp), nil
+}
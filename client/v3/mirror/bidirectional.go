@@ -0,0 +1,462 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// chunkSize is the number of keys read or written per transaction by a
+// bidirectional mirror, keeping the transactions under the default maximum
+// number of operations.
+const chunkSize = 64
+
+// Side is one of the two clusters of a mirror.
+type Side int
+
+const (
+	Source Side = iota
+	Destination
+)
+
+func (s Side) String() string {
+	if s == Destination {
+		return "destination"
+	}
+	return "source"
+}
+
+func (s Side) other() Side { return 1 - s }
+
+// ConflictPolicy returns the side whose change of a key wins, when the key is
+// changed on both sides of a bidirectional mirror before either change is
+// mirrored, or has different values on both sides when they are first
+// merged. The key is relative to the mirrored prefixes, and the revisions are
+// the mod revisions of the key on each side.
+type ConflictPolicy func(key string, sourceRev, destRev int64) Side
+
+// LastWriterWins is the ConflictPolicy keeping the change of the higher mod
+// revision, the source winning ties. The revisions of the two clusters being
+// independent, it favors the cluster whose revision grows faster rather than
+// the later change in time, but both sides agree on the winner.
+func LastWriterWins(_ string, sourceRev, destRev int64) Side {
+	if destRev > sourceRev {
+		return Destination
+	}
+	return Source
+}
+
+// PrefixOwnership returns a ConflictPolicy keeping the change of the side
+// owning the longest prefix of the key among owners. The conflicts on the
+// keys without owner are resolved by fallback.
+func PrefixOwnership(owners map[string]Side, fallback ConflictPolicy) ConflictPolicy {
+	return func(key string, sourceRev, destRev int64) Side {
+		owned, owner := -1, Source
+		for prefix, side := range owners {
+			if strings.HasPrefix(key, prefix) && len(prefix) > owned {
+				owned, owner = len(prefix), side
+			}
+		}
+		if owned < 0 {
+			return fallback(key, sourceRev, destRev)
+		}
+		return owner
+	}
+}
+
+// BidirectionalConfig configures a bidirectional mirror.
+type BidirectionalConfig struct {
+	// Prefix is the prefix of the keys mirrored on the source, which are
+	// mirrored under DestPrefix on the destination.
+	Prefix, DestPrefix string
+	// ExcludePrefixes are the prefixes, relative to Prefix and DestPrefix, of
+	// the keys not mirrored.
+	ExcludePrefixes []string
+	// Policy resolves the conflicts. Defaults to LastWriterWins.
+	Policy ConflictPolicy
+	// Checkpoint is the progress to resume the mirror from. If zero, the keys
+	// of both sides are merged first, the keys with different values being
+	// resolved as conflicts.
+	Checkpoint Checkpoint
+	// OnCheckpoint, if set, is called with the progress of the mirror after
+	// the changes of each watch response are mirrored. The mirror stops on
+	// its error.
+	OnCheckpoint func(Checkpoint) error
+}
+
+// SyncBidirectional mirrors the changes of the keys of src to dst, and the
+// changes of dst to src, until ctx is canceled or an error occurs.
+//
+// The changes written by the mirror are not mirrored back, nor are the
+// changes superseded by a later change of the key, or leaving the key as it
+// is on the other side. A key changed on both sides before either change is
+// mirrored is a conflict, resolved by the policy of cfg. The changes of a
+// transaction are mirrored by chunks, and the leases are not mirrored.
+func SyncBidirectional(ctx context.Context, src, dst *clientv3.Client, cfg BidirectionalConfig) error {
+	if cfg.Policy == nil {
+		cfg.Policy = LastWriterWins
+	}
+	b := &bidirectional{
+		cfg:    cfg,
+		c:      [2]*clientv3.Client{src, dst},
+		prefix: [2]string{cfg.Prefix, cfg.DestPrefix},
+		seen:   [2]int64{cfg.Checkpoint.SourceRev, cfg.Checkpoint.DestRev},
+		own:    [2]map[int64]bool{{}, {}},
+	}
+	if b.seen == [2]int64{} {
+		if err := b.merge(ctx); err != nil {
+			return err
+		}
+		if err := b.checkpoint(); err != nil {
+			return err
+		}
+	}
+
+	wctx := clientv3.WithRequireLeader(ctx)
+	wc := [2]clientv3.WatchChan{b.watch(wctx, Source), b.watch(wctx, Destination)}
+	for {
+		var (
+			side Side
+			wr   clientv3.WatchResponse
+			ok   bool
+		)
+		select {
+		case wr, ok = <-wc[Source]:
+			side = Source
+		case wr, ok = <-wc[Destination]:
+			side = Destination
+		}
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return errors.New("mirror: watch closed")
+		}
+		if wr.CompactRevision != 0 {
+			return rpctypes.ErrCompacted
+		}
+		if err := wr.Err(); err != nil {
+			return err
+		}
+		if err := b.mirrorEvents(ctx, side, wr.Events); err != nil {
+			return err
+		}
+		if err := b.checkpoint(); err != nil {
+			return err
+		}
+	}
+}
+
+// change is a change of a key on a side of a bidirectional mirror.
+type change struct {
+	// key is relative to the prefix of the side
+	key   string
+	value []byte
+	del   bool
+	// rev is the mod revision of the key on its side
+	rev int64
+}
+
+type bidirectional struct {
+	cfg    BidirectionalConfig
+	c      [2]*clientv3.Client
+	prefix [2]string
+	// seen holds the revisions up to which the changes of each side are
+	// mirrored
+	seen [2]int64
+	// own holds the revisions of each side written by the mirror, whose
+	// changes are not mirrored back
+	own [2]map[int64]bool
+}
+
+func (b *bidirectional) checkpoint() error {
+	if b.cfg.OnCheckpoint == nil {
+		return nil
+	}
+	return b.cfg.OnCheckpoint(Checkpoint{SourceRev: b.seen[Source], DestRev: b.seen[Destination]})
+}
+
+func (b *bidirectional) watch(ctx context.Context, side Side) clientv3.WatchChan {
+	return b.c[side].Watch(ctx, b.prefix[side], clientv3.WithPrefix(), clientv3.WithRev(b.seen[side]+1))
+}
+
+// relative returns the key of the given side relative to its prefix, and
+// whether it is mirrored.
+func (b *bidirectional) relative(side Side, key []byte) (string, bool) {
+	rel := strings.TrimPrefix(string(key), b.prefix[side])
+	for _, prefix := range b.cfg.ExcludePrefixes {
+		if strings.HasPrefix(rel, prefix) {
+			return rel, false
+		}
+	}
+	return rel, true
+}
+
+// merge mirrors the current keys of each side missing on the other side, and
+// resolves the keys with different values with the conflict policy.
+func (b *bidirectional) merge(ctx context.Context) error {
+	var it [2]*pageIterator
+	for _, side := range []Side{Source, Destination} {
+		resp, err := b.c[side].Get(ctx, b.prefix[side], clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			return err
+		}
+		b.seen[side] = resp.Header.Revision
+		rc, errc := NewSyncer(b.c[side], b.prefix[side], b.seen[side]).SyncBase(ctx)
+		it[side] = &pageIterator{rc: rc, errc: errc}
+	}
+
+	var changes [2][]change
+	add := func(side Side, kv *mvccpb.KeyValue) error {
+		rel, ok := b.relative(side, kv.Key)
+		if !ok {
+			return nil
+		}
+		changes[side] = append(changes[side], change{key: rel, value: kv.Value, rev: kv.ModRevision})
+		if len(changes[side]) < chunkSize {
+			return nil
+		}
+		err := b.mirror(ctx, side, changes[side])
+		changes[side] = nil
+		return err
+	}
+
+	kvs := [2]*mvccpb.KeyValue{}
+	for _, side := range []Side{Source, Destination} {
+		var err error
+		if kvs[side], err = it[side].next(); err != nil {
+			return err
+		}
+	}
+	for kvs[Source] != nil || kvs[Destination] != nil {
+		var cmp int
+		switch {
+		case kvs[Source] == nil:
+			cmp = 1
+		case kvs[Destination] == nil:
+			cmp = -1
+		default:
+			cmp = strings.Compare(strings.TrimPrefix(string(kvs[Source].Key), b.prefix[Source]),
+				strings.TrimPrefix(string(kvs[Destination].Key), b.prefix[Destination]))
+		}
+
+		var err error
+		switch {
+		case cmp < 0:
+			err = add(Source, kvs[Source])
+		case cmp > 0:
+			err = add(Destination, kvs[Destination])
+		case !bytes.Equal(kvs[Source].Value, kvs[Destination].Value):
+			rel, _ := b.relative(Source, kvs[Source].Key)
+			winner := b.cfg.Policy(rel, kvs[Source].ModRevision, kvs[Destination].ModRevision)
+			err = add(winner, kvs[winner])
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, side := range []Side{Source, Destination} {
+			if (side == Source && cmp <= 0) || (side == Destination && cmp >= 0) {
+				if kvs[side], err = it[side].next(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, side := range []Side{Source, Destination} {
+		if err := b.mirror(ctx, side, changes[side]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mirrorEvents mirrors the events of the given side to the other side.
+func (b *bidirectional) mirrorEvents(ctx context.Context, from Side, evs []*clientv3.Event) error {
+	for len(evs) > 0 {
+		rev := evs[0].Kv.ModRevision
+		n := 1
+		for n < len(evs) && evs[n].Kv.ModRevision == rev {
+			n++
+		}
+
+		if b.own[from][rev] {
+			delete(b.own[from], rev)
+		} else {
+			var changes []change
+			for _, ev := range evs[:n] {
+				rel, ok := b.relative(from, ev.Kv.Key)
+				if !ok {
+					continue
+				}
+				changes = append(changes, change{key: rel, value: ev.Kv.Value, del: ev.Type == mvccpb.DELETE, rev: rev})
+			}
+			if err := b.mirror(ctx, from, changes); err != nil {
+				return err
+			}
+		}
+
+		b.seen[from] = rev
+		for r := range b.own[from] {
+			if r < rev {
+				delete(b.own[from], r)
+			}
+		}
+		evs = evs[n:]
+	}
+	return nil
+}
+
+// mirror mirrors the changes of the given side to the other side, by chunks.
+func (b *bidirectional) mirror(ctx context.Context, from Side, changes []change) error {
+	for len(changes) > 0 {
+		n := chunkSize
+		if n > len(changes) {
+			n = len(changes)
+		}
+		for {
+			done, err := b.mirrorChunk(ctx, from, changes[:n])
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+		}
+		changes = changes[n:]
+	}
+	return nil
+}
+
+// mirrorChunk mirrors the changes of the given side to the other side in a
+// transaction. It returns false if the keys changed on the other side
+// meanwhile, the changes having to be mirrored again.
+func (b *bidirectional) mirrorChunk(ctx context.Context, from Side, changes []change) (bool, error) {
+	to := from.other()
+	keys := make([]string, len(changes))
+	for i, ch := range changes {
+		keys[i] = ch.key
+	}
+	cur, err := b.get(ctx, from, keys)
+	if err != nil {
+		return false, err
+	}
+	other, err := b.get(ctx, to, keys)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		cmps []clientv3.Cmp
+		ops  []clientv3.Op
+	)
+	for i, ch := range changes {
+		// a superseded change is mirrored with the later one
+		if kv := cur[i]; (ch.del && kv != nil) || (!ch.del && (kv == nil || kv.ModRevision != ch.rev)) {
+			continue
+		}
+		kv := other[i]
+		if (ch.del && kv == nil) || (!ch.del && kv != nil && bytes.Equal(kv.Value, ch.value)) {
+			continue
+		}
+		var rev int64
+		if kv != nil {
+			rev = kv.ModRevision
+		}
+		// the key changed on the other side since the last mirrored change,
+		// other than by the mirror, is a conflict
+		if rev > b.seen[to] && !b.own[to][rev] && b.winner(from, ch.key, ch.rev, rev) != from {
+			continue
+		}
+
+		key := b.prefix[to] + ch.key
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(key), "=", rev))
+		if ch.del {
+			ops = append(ops, clientv3.OpDelete(key))
+		} else {
+			ops = append(ops, clientv3.OpPut(key, string(ch.value)))
+		}
+	}
+	if len(ops) == 0 {
+		return true, nil
+	}
+
+	resp, err := b.c[to].Txn(ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return false, err
+	}
+	if resp.Succeeded {
+		b.own[to][resp.Header.Revision] = true
+	}
+	return resp.Succeeded, nil
+}
+
+// winner returns the side whose change of the key wins a conflict, given the
+// mod revisions of the key on the side it is mirrored from and on the other
+// side.
+func (b *bidirectional) winner(from Side, key string, fromRev, toRev int64) Side {
+	if from == Source {
+		return b.cfg.Policy(key, fromRev, toRev)
+	}
+	return b.cfg.Policy(key, toRev, fromRev)
+}
+
+// get returns the current key-values of the given keys, relative to the
+// prefix of the side, nil for the missing keys.
+func (b *bidirectional) get(ctx context.Context, side Side, keys []string) ([]*mvccpb.KeyValue, error) {
+	ops := make([]clientv3.Op, len(keys))
+	for i, key := range keys {
+		ops[i] = clientv3.OpGet(b.prefix[side] + key)
+	}
+	resp, err := b.c[side].Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]*mvccpb.KeyValue, len(keys))
+	for i, r := range resp.Responses {
+		if rr := r.GetResponseRange(); len(rr.Kvs) != 0 {
+			kvs[i] = rr.Kvs[0]
+		}
+	}
+	return kvs, nil
+}
+
+// pageIterator iterates over the key-values of the pages of SyncBase.
+type pageIterator struct {
+	rc   <-chan clientv3.GetResponse
+	errc chan error
+	kvs  []*mvccpb.KeyValue
+}
+
+// next returns the next key-value, or nil after the last one.
+func (it *pageIterator) next() (*mvccpb.KeyValue, error) {
+	for len(it.kvs) == 0 {
+		resp, ok := <-it.rc
+		if !ok {
+			return nil, <-it.errc
+		}
+		it.kvs = resp.Kvs
+	}
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLastWriterWins(t *testing.T) {
+	tests := []struct {
+		sourceRev, destRev int64
+		want               Side
+	}{
+		{sourceRev: 5, destRev: 3, want: Source},
+		{sourceRev: 3, destRev: 5, want: Destination},
+		{sourceRev: 4, destRev: 4, want: Source},
+		{sourceRev: 0, destRev: 1, want: Destination},
+	}
+	for _, tt := range tests {
+		if got := LastWriterWins("k", tt.sourceRev, tt.destRev); got != tt.want {
+			t.Errorf("LastWriterWins(%d, %d) = %v, want %v", tt.sourceRev, tt.destRev, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixOwnership(t *testing.T) {
+	policy := PrefixOwnership(map[string]Side{
+		"edge/":       Destination,
+		"edge/global": Source,
+		"config/":     Source,
+	}, LastWriterWins)
+	tests := []struct {
+		key                string
+		sourceRev, destRev int64
+		want               Side
+	}{
+		{key: "edge/a", sourceRev: 10, destRev: 1, want: Destination},
+		{key: "edge/global/a", sourceRev: 1, destRev: 10, want: Source},
+		{key: "config/a", sourceRev: 1, destRev: 10, want: Source},
+		// keys without owner fall back to last writer wins
+		{key: "other", sourceRev: 1, destRev: 10, want: Destination},
+		{key: "other", sourceRev: 10, destRev: 1, want: Source},
+	}
+	for _, tt := range tests {
+		if got := policy(tt.key, tt.sourceRev, tt.destRev); got != tt.want {
+			t.Errorf("policy(%q, %d, %d) = %v, want %v", tt.key, tt.sourceRev, tt.destRev, got, tt.want)
+		}
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := ReadCheckpoint(path)
+	if err != nil || cp != (Checkpoint{}) {
+		t.Fatalf("ReadCheckpoint of a missing file = %+v, %v, want a zero checkpoint", cp, err)
+	}
+
+	want := Checkpoint{SourceRev: 12, DestRev: 7}
+	if err = WriteCheckpoint(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteCheckpoint(path, Checkpoint{SourceRev: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteCheckpoint(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if cp, err = ReadCheckpoint(path); err != nil || cp != want {
+		t.Fatalf("ReadCheckpoint = %+v, %v, want %+v", cp, err, want)
+	}
+	if matches, _ := filepath.Glob(path + ".tmp*"); len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
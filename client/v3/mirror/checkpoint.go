@@ -0,0 +1,68 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the progress of a mirror, to resume it from: the changes of
+// the source up to SourceRev are mirrored, and for a bidirectional mirror the
+// changes of the destination up to DestRev.
+type Checkpoint struct {
+	SourceRev int64 `json:"source_rev"`
+	DestRev   int64 `json:"dest_rev,omitempty"`
+}
+
+// ReadCheckpoint reads the checkpoint saved at path. It returns a zero
+// checkpoint if there is none.
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	var cp Checkpoint
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, err
+	}
+	err = json.Unmarshal(b, &cp)
+	return cp, err
+}
+
+// WriteCheckpoint saves cp at path, replacing the previous checkpoint
+// atomically.
+func WriteCheckpoint(path string, cp Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(append(b, '\n')); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
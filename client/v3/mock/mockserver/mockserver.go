@@ -170,6 +170,10 @@ func (m *mockKVServer) Range(context.Context, *pb.RangeRequest) (*pb.RangeRespon
 	return &pb.RangeResponse{}, nil
 }
 
+func (m *mockKVServer) RangeStream(_ *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	return stream.Send(&pb.RangeStreamResponse{RangeResponse: &pb.RangeResponse{}})
+}
+
 func (m *mockKVServer) Put(context.Context, *pb.PutRequest) (*pb.PutResponse, error) {
 	return &pb.PutResponse{}, nil
 }
@@ -33,6 +33,17 @@ func NewKV(kv clientv3.KV, prefix string) clientv3.KV {
 	return &kvPrefix{kv, prefix}
 }
 
+// InvalidatePrefix invalidates the cached keys with the prefix of the wrapped
+// KV, if it caches keys, such as a leasing KV.
+func (kv *kvPrefix) InvalidatePrefix(ctx context.Context, prefix string) error {
+	if inv, ok := kv.KV.(interface {
+		InvalidatePrefix(ctx context.Context, prefix string) error
+	}); ok {
+		return inv.InvalidatePrefix(ctx, kv.pfx+prefix)
+	}
+	return nil
+}
+
 func (kv *kvPrefix) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
 	if len(key) == 0 {
 		return nil, rpctypes.ErrEmptyKey
@@ -64,6 +75,30 @@ func (kv *kvPrefix) Get(ctx context.Context, key string, opts ...clientv3.OpOpti
 	return get, nil
 }
 
+func (kv *kvPrefix) GetStream(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetIterator, error) {
+	if len(key) == 0 && !(clientv3.IsOptsWithFromKey(opts) || clientv3.IsOptsWithPrefix(opts)) {
+		return nil, rpctypes.ErrEmptyKey
+	}
+	getOp := clientv3.OpGet(key, opts...)
+	if !getOp.IsSortOptionValid() {
+		return nil, rpctypes.ErrInvalidSortOption
+	}
+	// the prefixed range overrides the range options
+	op := kv.prefixOp(getOp)
+	it, err := kv.KV.GetStream(ctx, string(op.KeyBytes()), append(opts, clientv3.WithRange(string(op.RangeBytes())))...)
+	if err != nil {
+		return nil, err
+	}
+	return clientv3.NewGetIterator(func() (*clientv3.GetResponse, error) {
+		get, err := it.Recv()
+		if err != nil {
+			return nil, err
+		}
+		kv.unprefixGetResponse(get)
+		return get, nil
+	}, it.Close), nil
+}
+
 func (kv *kvPrefix) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
 	if len(key) == 0 && !(clientv3.IsOptsWithFromKey(opts) || clientv3.IsOptsWithPrefix(opts)) {
 		return nil, rpctypes.ErrEmptyKey
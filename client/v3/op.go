@@ -68,10 +68,12 @@ type Op struct {
 	// filters for watchers
 	filterPut    bool
 	filterDelete bool
+	valueFilters []*pb.WatchValueFilter
 
 	// for put
 	val     []byte
 	leaseID LeaseID
+	ttl     int64
 
 	// txn
 	cmps    []Cmp
@@ -190,7 +192,7 @@ func (op Op) toRequestOp() *pb.RequestOp {
 	case tRange:
 		return &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: op.toRangeRequest()}}
 	case tPut:
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease}
+		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease, Ttl: op.ttl}
 		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: r}}
 	case tDeleteRange:
 		r := &pb.DeleteRangeRequest{Key: op.key, RangeEnd: op.end, PrevKv: op.prevKV}
@@ -245,6 +247,8 @@ func OpDelete(key string, opts ...OpOption) Op {
 	switch {
 	case ret.leaseID != 0:
 		panic("unexpected lease in delete")
+	case ret.ttl != 0:
+		panic("unexpected ttl in delete")
 	case ret.limit != 0:
 		panic("unexpected limit in delete")
 	case ret.rev != 0:
@@ -259,7 +263,7 @@ func OpDelete(key string, opts ...OpOption) Op {
 		panic("unexpected mod revision filter in delete")
 	case ret.minCreateRev != 0, ret.maxCreateRev != 0:
 		panic("unexpected create revision filter in delete")
-	case ret.filterDelete, ret.filterPut:
+	case ret.filterDelete, ret.filterPut, len(ret.valueFilters) > 0:
 		panic("unexpected filter in delete")
 	case ret.createdNotify:
 		panic("unexpected createdNotify in delete")
@@ -288,10 +292,12 @@ func OpPut(key, val string, opts ...OpOption) Op {
 		panic("unexpected mod revision filter in put")
 	case ret.minCreateRev != 0, ret.maxCreateRev != 0:
 		panic("unexpected create revision filter in put")
-	case ret.filterDelete, ret.filterPut:
+	case ret.filterDelete, ret.filterPut, len(ret.valueFilters) > 0:
 		panic("unexpected filter in put")
 	case ret.createdNotify:
 		panic("unexpected createdNotify in put")
+	case ret.ttl != 0 && (ret.leaseID != 0 || ret.ignoreLease):
+		panic("unexpected lease with ttl in put")
 	}
 	return ret
 }
@@ -307,6 +313,8 @@ func opWatch(key string, opts ...OpOption) Op {
 	switch {
 	case ret.leaseID != 0:
 		panic("unexpected lease in watch")
+	case ret.ttl != 0:
+		panic("unexpected ttl in watch")
 	case ret.limit != 0:
 		panic("unexpected limit in watch")
 	case ret.sort != nil:
@@ -337,6 +345,15 @@ func WithLease(leaseID LeaseID) OpOption {
 	return func(op *Op) { op.leaseID = leaseID }
 }
 
+// WithTTL makes the key of a 'Put' request expire ttl seconds after the put,
+// without having to grant and keep alive a lease. The server attaches the key
+// to a lease it manages, which expires no earlier than ttl seconds, or the
+// minimum lease TTL of the server if it is longer. It cannot be combined with
+// WithLease or WithIgnoreLease.
+func WithTTL(ttl int64) OpOption {
+	return func(op *Op) { op.ttl = ttl }
+}
+
 // WithLimit limits the number of results to return from 'Get' request.
 // If WithLimit is given a 0 limit, it is treated as no limit.
 func WithLimit(n int64) OpOption { return func(op *Op) { op.limit = n } }
@@ -497,6 +514,31 @@ func WithFilterDelete() OpOption {
 	return func(op *Op) { op.filterDelete = true }
 }
 
+// WithValuePrefix discards PUT events whose values do not start with prefix
+// from the watcher. Like the other value filters, it is evaluated by the
+// server and does not discard DELETE events.
+func WithValuePrefix(prefix string) OpOption {
+	return func(op *Op) {
+		op.valueFilters = append(op.valueFilters, &pb.WatchValueFilter{Type: pb.WatchValueFilter_PREFIX, Value: []byte(prefix)})
+	}
+}
+
+// WithValueContains discards PUT events whose values do not contain substr
+// from the watcher.
+func WithValueContains(substr string) OpOption {
+	return func(op *Op) {
+		op.valueFilters = append(op.valueFilters, &pb.WatchValueFilter{Type: pb.WatchValueFilter_CONTAINS, Value: []byte(substr)})
+	}
+}
+
+// WithValueSize discards PUT events whose value sizes do not compare to size
+// as given by result, one of "=", "!=", ">" and "<", from the watcher.
+func WithValueSize(result string, size int64) OpOption {
+	return func(op *Op) {
+		op.valueFilters = append(op.valueFilters, &pb.WatchValueFilter{Type: pb.WatchValueFilter_SIZE, Result: compareResult(result), SizeBytes: size})
+	}
+}
+
 // WithPrevKV gets the previous key-value pair before the event happens. If the previous KV is already compacted,
 // nothing will be returned.
 func WithPrevKV() OpOption {
@@ -540,6 +582,9 @@ type LeaseOp struct {
 
 	// for TimeToLive
 	attachedKeys bool
+
+	// for Grant
+	labels map[string]string
 }
 
 // LeaseOption configures lease operations.
@@ -556,6 +601,18 @@ func WithAttachedKeys() LeaseOption {
 	return func(op *LeaseOp) { op.attachedKeys = true }
 }
 
+// WithLabels makes Grant attach the given labels to the lease, identifying the
+// component owning it for instance.
+func WithLabels(labels map[string]string) LeaseOption {
+	return func(op *LeaseOp) { op.labels = labels }
+}
+
+func toLeaseGrantRequest(ttl int64, opts ...LeaseOption) *pb.LeaseGrantRequest {
+	ret := &LeaseOp{}
+	ret.applyOpts(opts)
+	return &pb.LeaseGrantRequest{TTL: ttl, Labels: ret.labels}
+}
+
 func toLeaseTimeToLiveRequest(id LeaseID, opts ...LeaseOption) *pb.LeaseTimeToLiveRequest {
 	ret := &LeaseOp{id: id}
 	ret.applyOpts(opts)
@@ -39,4 +39,23 @@
 //
 // Now calls using 'cli' will reject order violations with an error.
 //
+// The wrapper records the highest revision served by every member. With
+// WithReroute, a stale response is reissued to the endpoints of the other
+// members, freshest first, and the order violation function is only called
+// when none of them has caught up:
+//
+//	cli.KV = ordering.NewKV(cli.KV, vf, ordering.WithReroute(cli))
+//
+// A request may handle ordering violations with its own policy, passed in its
+// context, instead of the order violation function: failing right away,
+// rerouting to the other endpoints, or waiting for the cluster to catch up:
+//
+//	ctx := ordering.WithPolicy(context.TODO(), ordering.WaitForCatchUp(time.Second))
+//	resp, err := cli.Get(ctx, "foo", clientv3.WithSerializable())
+//
+// WithDefaultPolicy sets the policy of the requests without one.
+//
+// Violations, reroutes, catch-ups and per-member revisions are exported as prometheus
+// metrics under "etcd_client_ordering".
+//
 package ordering
@@ -16,8 +16,10 @@ package ordering
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/v3"
 )
 
@@ -29,10 +31,26 @@ type kvOrdering struct {
 	orderViolationFunc OrderViolationFunc
 	prevRev            int64
 	revMu              sync.RWMutex
+
+	// memberRevs is the highest revision observed from each member.
+	memberRevs map[uint64]int64
+	// router reissues requests that violate the ordering to other
+	// endpoints, nil if violations are only handled by orderViolationFunc.
+	router *endpointRouter
+	// defaultPolicy handles the violations of the requests without policy.
+	defaultPolicy Policy
 }
 
-func NewKV(kv clientv3.KV, orderViolationFunc OrderViolationFunc) *kvOrdering {
-	return &kvOrdering{kv, orderViolationFunc, 0, sync.RWMutex{}}
+// NewKV wraps kv so that Get and Txn never return a response with a revision
+// older than a previously returned one. The orderViolationFunc is called on
+// every stale response that could not be rerouted, the request is retried if
+// it returns nil, unless the request has a Policy.
+func NewKV(kv clientv3.KV, orderViolationFunc OrderViolationFunc, opts ...Option) *kvOrdering {
+	kvo := &kvOrdering{KV: kv, orderViolationFunc: orderViolationFunc}
+	for _, opt := range opts {
+		opt(kvo)
+	}
+	return kvo
 }
 
 func (kv *kvOrdering) getPrevRev() int64 {
@@ -49,28 +67,74 @@ func (kv *kvOrdering) setPrevRev(currRev int64) {
 	}
 }
 
+// observe records the revision of a response served by the given member.
+func (kv *kvOrdering) observe(memberID uint64, rev int64) {
+	kv.revMu.Lock()
+	defer kv.revMu.Unlock()
+	if kv.memberRevs == nil {
+		kv.memberRevs = make(map[uint64]int64)
+	}
+	if rev > kv.memberRevs[memberID] {
+		kv.memberRevs[memberID] = rev
+		memberRevision.WithLabelValues(fmt.Sprintf("%x", memberID)).Set(float64(rev))
+	}
+}
+
+// memberRev returns the highest revision observed from the member.
+func (kv *kvOrdering) memberRev(memberID uint64) (int64, bool) {
+	kv.revMu.RLock()
+	defer kv.revMu.RUnlock()
+	rev, ok := kv.memberRevs[memberID]
+	return rev, ok
+}
+
 func (kv *kvOrdering) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
 	// prevRev is stored in a local variable in order to record the prevRev
 	// at the beginning of the Get operation, because concurrent
 	// access to kvOrdering could change the prevRev field in the
 	// middle of the Get operation.
 	prevRev := kv.getPrevRev()
-	op := clientv3.OpGet(key, opts...)
+	r, err := kv.do(ctx, clientv3.OpGet(key, opts...), prevRev)
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(), nil
+}
+
+// do issues op until it gets a response with a revision of at least prevRev.
+func (kv *kvOrdering) do(ctx context.Context, op clientv3.Op, prevRev int64) (clientv3.OpResponse, error) {
 	for {
 		r, err := kv.KV.Do(ctx, op)
 		if err != nil {
-			return nil, err
+			return r, err
 		}
-		resp := r.Get()
-		if resp.Header.Revision == prevRev {
-			return resp, nil
-		} else if resp.Header.Revision > prevRev {
-			kv.setPrevRev(resp.Header.Revision)
-			return resp, nil
+		hdr := responseHeader(r)
+		kv.observe(hdr.MemberId, hdr.Revision)
+		if hdr.Revision >= prevRev {
+			kv.setPrevRev(hdr.Revision)
+			return r, nil
 		}
-		err = kv.orderViolationFunc(op, r, prevRev)
-		if err != nil {
-			return nil, err
+		violationsTotal.WithLabelValues(opType(op)).Inc()
+		switch p := kv.policy(ctx); p.kind {
+		case policyFail:
+			return r, ErrStaleRevision
+		case policyReroute:
+			if kv.router != nil {
+				if rr, ok := kv.reroute(ctx, op, prevRev, hdr.MemberId); ok {
+					return rr, nil
+				}
+			}
+			return r, ErrNoGreaterRev
+		case policyWait:
+			return kv.waitCatchUp(ctx, op, prevRev, p.timeout)
+		}
+		if kv.router != nil {
+			if rr, ok := kv.reroute(ctx, op, prevRev, hdr.MemberId); ok {
+				return rr, nil
+			}
+		}
+		if err = kv.orderViolationFunc(op, r, prevRev); err != nil {
+			return r, err
 		}
 	}
 }
@@ -131,19 +195,30 @@ func (txn *txnOrdering) Commit() (*clientv3.TxnResponse, error) {
 	// middle of the Commit operation.
 	prevRev := txn.getPrevRev()
 	opTxn := clientv3.OpTxn(txn.cmps, txn.thenOps, txn.elseOps)
-	for {
-		opResp, err := txn.KV.Do(txn.ctx, opTxn)
-		if err != nil {
-			return nil, err
-		}
-		txnResp := opResp.Txn()
-		if txnResp.Header.Revision >= prevRev {
-			txn.setPrevRev(txnResp.Header.Revision)
-			return txnResp, nil
-		}
-		err = txn.orderViolationFunc(opTxn, opResp, prevRev)
-		if err != nil {
-			return nil, err
-		}
+	r, err := txn.do(txn.ctx, opTxn, prevRev)
+	if err != nil {
+		return nil, err
+	}
+	return r.Txn(), nil
+}
+
+func responseHeader(r clientv3.OpResponse) *pb.ResponseHeader {
+	switch {
+	case r.Get() != nil:
+		return r.Get().Header
+	case r.Txn() != nil:
+		return r.Txn().Header
+	case r.Put() != nil:
+		return r.Put().Header
+	case r.Del() != nil:
+		return r.Del().Header
+	}
+	return &pb.ResponseHeader{}
+}
+
+func opType(op clientv3.Op) string {
+	if op.IsTxn() {
+		return "txn"
 	}
+	return "get"
 }
@@ -19,6 +19,7 @@ import (
 	gContext "context"
 	"sync"
 	"testing"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/v3"
@@ -67,15 +68,14 @@ func TestKvOrdering(t *testing.T) {
 	for i, tt := range rangeTests {
 		mKV := &mockKV{clientv3.NewKVFromKVClient(nil, nil), tt.response.OpResponse()}
 		kv := &kvOrdering{
-			mKV,
-			func(r *clientv3.GetResponse) OrderViolationFunc {
+			KV: mKV,
+			orderViolationFunc: func(r *clientv3.GetResponse) OrderViolationFunc {
 				return func(op clientv3.Op, resp clientv3.OpResponse, prevRev int64) error {
 					r.Header.Revision++
 					return nil
 				}
 			}(tt.response),
-			tt.prevRev,
-			sync.RWMutex{},
+			prevRev: tt.prevRev,
 		}
 		res, err := kv.Get(context.TODO(), "mockKey")
 		if err != nil {
@@ -121,15 +121,14 @@ func TestTxnOrdering(t *testing.T) {
 	for i, tt := range txnTests {
 		mKV := &mockKV{clientv3.NewKVFromKVClient(nil, nil), tt.response.OpResponse()}
 		kv := &kvOrdering{
-			mKV,
-			func(r *clientv3.TxnResponse) OrderViolationFunc {
+			KV: mKV,
+			orderViolationFunc: func(r *clientv3.TxnResponse) OrderViolationFunc {
 				return func(op clientv3.Op, resp clientv3.OpResponse, prevRev int64) error {
 					r.Header.Revision++
 					return nil
 				}
 			}(tt.response),
-			tt.prevRev,
-			sync.RWMutex{},
+			prevRev: tt.prevRev,
 		}
 		txn := &txnOrdering{
 			kv.Txn(context.Background()),
@@ -149,3 +148,129 @@ func TestTxnOrdering(t *testing.T) {
 		}
 	}
 }
+
+type endpointKV struct {
+	clientv3.KV
+	memberID uint64
+	rev      int64
+	calls    int
+}
+
+func (kv *endpointKV) Do(ctx gContext.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	kv.calls++
+	hdr := &pb.ResponseHeader{MemberId: kv.memberID, Revision: kv.rev}
+	if op.IsTxn() {
+		return (&clientv3.TxnResponse{Header: hdr}).OpResponse(), nil
+	}
+	return (&clientv3.GetResponse{Header: hdr}).OpResponse(), nil
+}
+
+func TestKvOrderingReroute(t *testing.T) {
+	eps := map[string]*endpointKV{
+		"a": {KV: clientv3.NewKVFromKVClient(nil, nil), memberID: 1, rev: 10},
+		"b": {KV: clientv3.NewKVFromKVClient(nil, nil), memberID: 2, rev: 4},
+		"c": {KV: clientv3.NewKVFromKVClient(nil, nil), memberID: 3, rev: 12},
+	}
+	violations := 0
+	kv := NewKV(eps["b"], func(op clientv3.Op, resp clientv3.OpResponse, prevRev int64) error {
+		violations++
+		return ErrNoGreaterRev
+	})
+	kv.router = newEndpointRouter(
+		func() []string { return []string{"a", "b", "c"} },
+		func(ep string) (clientv3.KV, error) { return eps[ep], nil },
+	)
+	// "b" is known to serve the stale member, "c" has the highest revision.
+	kv.router.setMember("b", 2)
+	kv.router.setMember("c", 3)
+	kv.observe(3, 12)
+	kv.setPrevRev(8)
+
+	resp, err := kv.Get(context.TODO(), "foo", clientv3.WithSerializable())
+	if err != nil {
+		t.Fatalf("expected rerouted response, got error %v", err)
+	}
+	if resp.Header.MemberId != 3 || resp.Header.Revision != 12 {
+		t.Fatalf("expected response from member 3 at revision 12, got %+v", resp.Header)
+	}
+	if eps["b"].calls != 1 || eps["a"].calls != 0 {
+		t.Errorf("expected only the stale and freshest endpoints to be used, got a=%d b=%d", eps["a"].calls, eps["b"].calls)
+	}
+	if violations != 0 {
+		t.Errorf("expected no order violation callback, got %d", violations)
+	}
+	if rev := kv.getPrevRev(); rev != 12 {
+		t.Errorf("expected previous revision 12, got %d", rev)
+	}
+
+	// No endpoint has caught up, the violation callback decides.
+	eps["a"].rev, eps["c"].rev = 5, 6
+	if _, err = kv.Txn(context.TODO()).Commit(); err != ErrNoGreaterRev {
+		t.Fatalf("expected %v, got %v", ErrNoGreaterRev, err)
+	}
+	if violations != 1 {
+		t.Errorf("expected one order violation callback, got %d", violations)
+	}
+	if rev, _ := kv.memberRev(1); rev != 5 {
+		t.Errorf("expected revision 5 recorded for member 1, got %d", rev)
+	}
+}
+
+// catchUpKV is a KV whose revision grows by one on every request.
+type catchUpKV struct {
+	endpointKV
+}
+
+func (kv *catchUpKV) Do(ctx gContext.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	kv.rev++
+	return kv.endpointKV.Do(ctx, op)
+}
+
+func TestKvOrderingPolicy(t *testing.T) {
+	newKV := func(rev int64, opts ...Option) (*kvOrdering, *catchUpKV, *int) {
+		ekv := &catchUpKV{endpointKV{KV: clientv3.NewKVFromKVClient(nil, nil), memberID: 1, rev: rev}}
+		violations := 0
+		kv := NewKV(ekv, func(op clientv3.Op, resp clientv3.OpResponse, prevRev int64) error {
+			violations++
+			return nil
+		}, opts...)
+		kv.setPrevRev(10)
+		return kv, ekv, &violations
+	}
+
+	kv, _, violations := newKV(4)
+	if _, err := kv.Get(WithPolicy(context.TODO(), Fail()), "foo"); err != ErrStaleRevision {
+		t.Errorf("Fail: expected %v, got %v", ErrStaleRevision, err)
+	}
+	if _, err := kv.Get(WithPolicy(context.TODO(), Reroute()), "foo"); err != ErrNoGreaterRev {
+		t.Errorf("Reroute without endpoints: expected %v, got %v", ErrNoGreaterRev, err)
+	}
+	if *violations != 0 {
+		t.Errorf("expected no order violation callback, got %d", *violations)
+	}
+
+	// the revision reaches 10 on the 6th request
+	kv, ekv, _ := newKV(4)
+	resp, err := kv.Get(WithPolicy(context.TODO(), WaitForCatchUp(5*time.Second)), "foo")
+	if err != nil {
+		t.Fatalf("WaitForCatchUp: expected response, got error %v", err)
+	}
+	if resp.Header.Revision != 10 || ekv.calls != 6 {
+		t.Errorf("WaitForCatchUp: expected revision 10 after 6 requests, got %d after %d", resp.Header.Revision, ekv.calls)
+	}
+
+	kv, _, _ = newKV(-100)
+	if _, err = kv.Txn(WithPolicy(context.TODO(), WaitForCatchUp(3*catchUpInterval))).Commit(); err != ErrNoGreaterRev {
+		t.Errorf("WaitForCatchUp timeout: expected %v, got %v", ErrNoGreaterRev, err)
+	}
+
+	// the default policy applies to the requests without policy, the zero
+	// Policy retries until the revision reaches 10 from 6
+	kv, _, violations = newKV(4, WithDefaultPolicy(Fail()))
+	if _, err = kv.Get(context.TODO(), "foo"); err != ErrStaleRevision {
+		t.Errorf("default Fail: expected %v, got %v", ErrStaleRevision, err)
+	}
+	if _, err = kv.Get(WithPolicy(context.TODO(), Policy{}), "foo"); err != nil || *violations != 4 {
+		t.Errorf("zero Policy: expected the order violation callback to retry, got %v after %d violations", err, *violations)
+	}
+}
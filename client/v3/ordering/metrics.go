@@ -0,0 +1,54 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ordering
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	violationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_ordering",
+		Name:      "violations_total",
+		Help:      "The total number of responses with a revision older than a previously observed revision.",
+	}, []string{"type"})
+
+	reroutesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_ordering",
+		Name:      "reroutes_total",
+		Help:      "The total number of ordering violations reissued to other endpoints.",
+	}, []string{"result"})
+
+	catchUpsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "client_ordering",
+		Name:      "catch_ups_total",
+		Help:      "The total number of ordering violations waiting for the cluster to catch up.",
+	}, []string{"result"})
+
+	memberRevision = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "client_ordering",
+		Name:      "member_revision",
+		Help:      "The highest revision observed from each member.",
+	}, []string{"member_id"})
+)
+
+func init() {
+	prometheus.MustRegister(violationsTotal)
+	prometheus.MustRegister(reroutesTotal)
+	prometheus.MustRegister(catchUpsTotal)
+	prometheus.MustRegister(memberRevision)
+}
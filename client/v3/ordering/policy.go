@@ -0,0 +1,112 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ordering
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+// catchUpInterval is the interval between the reissues of a request waiting
+// for the cluster to catch up.
+const catchUpInterval = 50 * time.Millisecond
+
+type policyKind int
+
+const (
+	policyViolationFunc policyKind = iota
+	policyFail
+	policyReroute
+	policyWait
+)
+
+// Policy is how a request handles a response with a revision older than a
+// previously returned one. The zero Policy reroutes the request if the KV was
+// created WithReroute, then calls the OrderViolationFunc.
+type Policy struct {
+	kind    policyKind
+	timeout time.Duration
+}
+
+// Fail returns the Policy failing the request with ErrStaleRevision.
+func Fail() Policy { return Policy{kind: policyFail} }
+
+// Reroute returns the Policy reissuing the request to the other endpoints,
+// which needs the KV to be created WithReroute, and failing it with
+// ErrNoGreaterRev if none of them has caught up.
+func Reroute() Policy { return Policy{kind: policyReroute} }
+
+// WaitForCatchUp returns the Policy reissuing the request until it gets a
+// response with a recent enough revision, and failing it with ErrNoGreaterRev
+// after the timeout.
+func WaitForCatchUp(timeout time.Duration) Policy {
+	return Policy{kind: policyWait, timeout: timeout}
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context making the requests of the ordering KVs issued
+// with it handle stale responses with the policy p.
+func WithPolicy(ctx context.Context, p Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// WithDefaultPolicy makes the ordering KV handle stale responses with the
+// policy p, unless overridden by WithPolicy.
+func WithDefaultPolicy(p Policy) Option {
+	return func(kv *kvOrdering) {
+		kv.defaultPolicy = p
+	}
+}
+
+// policy returns the policy of a request issued with ctx.
+func (kv *kvOrdering) policy(ctx context.Context) Policy {
+	if p, ok := ctx.Value(policyKey{}).(Policy); ok {
+		return p
+	}
+	return kv.defaultPolicy
+}
+
+// waitCatchUp reissues op until it gets a response with a revision of at
+// least prevRev, or the timeout expires.
+func (kv *kvOrdering) waitCatchUp(ctx context.Context, op clientv3.Op, prevRev int64, timeout time.Duration) (clientv3.OpResponse, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(catchUpInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+		case <-deadline.C:
+			catchUpsTotal.WithLabelValues("timeout").Inc()
+			return clientv3.OpResponse{}, ErrNoGreaterRev
+		case <-ctx.Done():
+			return clientv3.OpResponse{}, ctx.Err()
+		}
+		r, err := kv.KV.Do(ctx, op)
+		if err != nil {
+			return r, err
+		}
+		hdr := responseHeader(r)
+		kv.observe(hdr.MemberId, hdr.Revision)
+		if hdr.Revision >= prevRev {
+			kv.setPrevRev(hdr.Revision)
+			catchUpsTotal.WithLabelValues("success").Inc()
+			return r, nil
+		}
+	}
+}
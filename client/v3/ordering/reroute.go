@@ -0,0 +1,142 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ordering
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// Option configures the ordering KV returned by NewKV.
+type Option func(*kvOrdering)
+
+// WithReroute makes the ordering KV reissue requests that returned a stale
+// revision directly to the other endpoints of c, preferring the endpoints
+// of the members with the highest observed revision, before falling back
+// to the OrderViolationFunc.
+func WithReroute(c *clientv3.Client) Option {
+	return func(kv *kvOrdering) {
+		kv.router = newEndpointRouter(c.Endpoints, func(ep string) (clientv3.KV, error) {
+			conn, err := c.Dial(ep)
+			if err != nil {
+				return nil, err
+			}
+			go func() {
+				<-c.Ctx().Done()
+				conn.Close()
+			}()
+			return clientv3.NewKVFromKVClient(pb.NewKVClient(conn), c), nil
+		})
+	}
+}
+
+// endpointRouter keeps a KV pinned to every endpoint of a client and
+// remembers which member serves each endpoint.
+type endpointRouter struct {
+	endpoints func() []string
+	dial      func(ep string) (clientv3.KV, error)
+
+	mu      sync.Mutex
+	kvs     map[string]clientv3.KV
+	members map[string]uint64
+}
+
+func newEndpointRouter(endpoints func() []string, dial func(ep string) (clientv3.KV, error)) *endpointRouter {
+	return &endpointRouter{
+		endpoints: endpoints,
+		dial:      dial,
+		kvs:       make(map[string]clientv3.KV),
+		members:   make(map[string]uint64),
+	}
+}
+
+func (r *endpointRouter) kv(ep string) (clientv3.KV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if kv, ok := r.kvs[ep]; ok {
+		return kv, nil
+	}
+	kv, err := r.dial(ep)
+	if err != nil {
+		return nil, err
+	}
+	r.kvs[ep] = kv
+	return kv, nil
+}
+
+func (r *endpointRouter) member(ep string) (uint64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.members[ep]
+	return id, ok
+}
+
+func (r *endpointRouter) setMember(ep string, id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.members[ep] = id
+}
+
+// reroute reissues op to the endpoints not served by the stale member, in
+// decreasing order of the revision last observed from their members, and
+// returns the first response with a revision of at least prevRev.
+func (kv *kvOrdering) reroute(ctx context.Context, op clientv3.Op, prevRev int64, stale uint64) (clientv3.OpResponse, bool) {
+	type candidate struct {
+		ep  string
+		rev int64
+	}
+	var cands []candidate
+	for _, ep := range kv.router.endpoints() {
+		c := candidate{ep: ep, rev: -1}
+		if id, ok := kv.router.member(ep); ok {
+			if id == stale {
+				continue
+			}
+			if rev, ok := kv.memberRev(id); ok {
+				c.rev = rev
+			}
+		}
+		cands = append(cands, c)
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].rev > cands[j].rev })
+
+	for _, c := range cands {
+		if ctx.Err() != nil {
+			break
+		}
+		ekv, err := kv.router.kv(c.ep)
+		if err != nil {
+			continue
+		}
+		r, err := ekv.Do(ctx, op)
+		if err != nil {
+			continue
+		}
+		hdr := responseHeader(r)
+		kv.router.setMember(c.ep, hdr.MemberId)
+		kv.observe(hdr.MemberId, hdr.Revision)
+		if hdr.Revision >= prevRev {
+			kv.setPrevRev(hdr.Revision)
+			reroutesTotal.WithLabelValues("success").Inc()
+			return r, true
+		}
+	}
+	reroutesTotal.WithLabelValues("failure").Inc()
+	return clientv3.OpResponse{}, false
+}
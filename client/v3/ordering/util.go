@@ -25,6 +25,8 @@ type OrderViolationFunc func(op clientv3.Op, resp clientv3.OpResponse, prevRev i
 
 var ErrNoGreaterRev = errors.New("etcdclient: no cluster members have a revision higher than the previously received revision")
 
+var ErrStaleRevision = errors.New("etcdclient: response revision is older than the previously received revision")
+
 func NewOrderViolationSwitchEndpointClosure(c *clientv3.Client) OrderViolationFunc {
 	violationCount := int32(0)
 	return func(_ clientv3.Op, _ clientv3.OpResponse, _ int64) error {
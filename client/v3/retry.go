@@ -93,27 +93,51 @@ func isSafeRetryMutableRPC(err error) bool {
 
 type retryKVClient struct {
 	kc pb.KVClient
+	// idempotentWrites attaches a request ID to the mutations without one.
+	idempotentWrites bool
 }
 
 // RetryKVClient implements a KVClient.
 func RetryKVClient(c *Client) pb.KVClient {
 	return &retryKVClient{
-		kc: pb.NewKVClient(c.conn),
+		kc:               pb.NewKVClient(c.conn),
+		idempotentWrites: c.cfg.IdempotentWrites,
 	}
 }
+
+// mutationOpts returns the call options of a mutation. The mutations with a
+// request ID are applied once by the server, so they are retried like the
+// reads.
+func (rkv *retryKVClient) mutationOpts(ctx context.Context, opts []grpc.CallOption) (context.Context, []grpc.CallOption) {
+	if requestIDFromContext(ctx) == "" {
+		if !rkv.idempotentWrites {
+			return ctx, opts
+		}
+		ctx = WithRequestID(ctx, newRequestID())
+	}
+	return ctx, append(opts, withRetryPolicy(repeatable))
+}
+
 func (rkv *retryKVClient) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (resp *pb.RangeResponse, err error) {
 	return rkv.kc.Range(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rkv *retryKVClient) RangeStream(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (stream pb.KV_RangeStreamClient, err error) {
+	return rkv.kc.RangeStream(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rkv *retryKVClient) Put(ctx context.Context, in *pb.PutRequest, opts ...grpc.CallOption) (resp *pb.PutResponse, err error) {
+	ctx, opts = rkv.mutationOpts(ctx, opts)
 	return rkv.kc.Put(ctx, in, opts...)
 }
 
 func (rkv *retryKVClient) DeleteRange(ctx context.Context, in *pb.DeleteRangeRequest, opts ...grpc.CallOption) (resp *pb.DeleteRangeResponse, err error) {
+	ctx, opts = rkv.mutationOpts(ctx, opts)
 	return rkv.kc.DeleteRange(ctx, in, opts...)
 }
 
 func (rkv *retryKVClient) Txn(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallOption) (resp *pb.TxnResponse, err error) {
+	ctx, opts = rkv.mutationOpts(ctx, opts)
 	return rkv.kc.Txn(ctx, in, opts...)
 }
 
@@ -214,6 +238,10 @@ func (rmc *retryMaintenanceClient) Snapshot(ctx context.Context, in *pb.Snapshot
 	return rmc.mc.Snapshot(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rmc *retryMaintenanceClient) BulkImport(ctx context.Context, opts ...grpc.CallOption) (stream pb.Maintenance_BulkImportClient, err error) {
+	return rmc.mc.BulkImport(ctx, opts...)
+}
+
 func (rmc *retryMaintenanceClient) MoveLeader(ctx context.Context, in *pb.MoveLeaderRequest, opts ...grpc.CallOption) (resp *pb.MoveLeaderResponse, err error) {
 	return rmc.mc.MoveLeader(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
@@ -222,10 +250,78 @@ func (rmc *retryMaintenanceClient) Defragment(ctx context.Context, in *pb.Defrag
 	return rmc.mc.Defragment(ctx, in, opts...)
 }
 
+func (rmc *retryMaintenanceClient) DefragmentStream(ctx context.Context, in *pb.DefragmentRequest, opts ...grpc.CallOption) (stream pb.Maintenance_DefragmentStreamClient, err error) {
+	return rmc.mc.DefragmentStream(ctx, in, opts...)
+}
+
 func (rmc *retryMaintenanceClient) Downgrade(ctx context.Context, in *pb.DowngradeRequest, opts ...grpc.CallOption) (resp *pb.DowngradeResponse, err error) {
 	return rmc.mc.Downgrade(ctx, in, opts...)
 }
 
+func (rmc *retryMaintenanceClient) WatchConsumers(ctx context.Context, in *pb.WatchConsumersRequest, opts ...grpc.CallOption) (resp *pb.WatchConsumersResponse, err error) {
+	return rmc.mc.WatchConsumers(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) ConfigAdvice(ctx context.Context, in *pb.ConfigAdviceRequest, opts ...grpc.CallOption) (resp *pb.ConfigAdviceResponse, err error) {
+	return rmc.mc.ConfigAdvice(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) KeyHistogram(ctx context.Context, in *pb.KeyHistogramRequest, opts ...grpc.CallOption) (resp *pb.KeyHistogramResponse, err error) {
+	return rmc.mc.KeyHistogram(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) ReadOnly(ctx context.Context, in *pb.ReadOnlyRequest, opts ...grpc.CallOption) (resp *pb.ReadOnlyResponse, err error) {
+	return rmc.mc.ReadOnly(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) NamespacePut(ctx context.Context, in *pb.NamespacePutRequest, opts ...grpc.CallOption) (resp *pb.NamespacePutResponse, err error) {
+	return rmc.mc.NamespacePut(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) NamespaceDelete(ctx context.Context, in *pb.NamespaceDeleteRequest, opts ...grpc.CallOption) (resp *pb.NamespaceDeleteResponse, err error) {
+	return rmc.mc.NamespaceDelete(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) NamespaceList(ctx context.Context, in *pb.NamespaceListRequest, opts ...grpc.CallOption) (resp *pb.NamespaceListResponse, err error) {
+	return rmc.mc.NamespaceList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) IndexPut(ctx context.Context, in *pb.IndexPutRequest, opts ...grpc.CallOption) (resp *pb.IndexPutResponse, err error) {
+	return rmc.mc.IndexPut(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) IndexDelete(ctx context.Context, in *pb.IndexDeleteRequest, opts ...grpc.CallOption) (resp *pb.IndexDeleteResponse, err error) {
+	return rmc.mc.IndexDelete(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) IndexList(ctx context.Context, in *pb.IndexListRequest, opts ...grpc.CallOption) (resp *pb.IndexListResponse, err error) {
+	return rmc.mc.IndexList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) IndexRange(ctx context.Context, in *pb.IndexRangeRequest, opts ...grpc.CallOption) (resp *pb.IndexRangeResponse, err error) {
+	return rmc.mc.IndexRange(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) LogLevel(ctx context.Context, in *pb.LogLevelRequest, opts ...grpc.CallOption) (resp *pb.LogLevelResponse, err error) {
+	return rmc.mc.LogLevel(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) SlowLog(ctx context.Context, in *pb.SlowLogRequest, opts ...grpc.CallOption) (resp *pb.SlowLogResponse, err error) {
+	return rmc.mc.SlowLog(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) TopKeys(ctx context.Context, in *pb.TopKeysRequest, opts ...grpc.CallOption) (resp *pb.TopKeysResponse, err error) {
+	return rmc.mc.TopKeys(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) Fragmentation(ctx context.Context, in *pb.FragmentationRequest, opts ...grpc.CallOption) (resp *pb.FragmentationResponse, err error) {
+	return rmc.mc.Fragmentation(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rmc *retryMaintenanceClient) Backup(ctx context.Context, in *pb.BackupRequest, opts ...grpc.CallOption) (stream pb.Maintenance_BackupClient, err error) {
+	return rmc.mc.Backup(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 type retryAuthClient struct {
 	ac pb.AuthClient
 }
@@ -19,7 +19,6 @@ package clientv3
 
 import (
 	"context"
-	"errors"
 	"io"
 	"sync"
 	"time"
@@ -41,12 +40,27 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		ctx = withVersion(ctx)
 		grpcOpts, retryOpts := filterCallOptions(opts)
-		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+		callOpts := c.applyRetryPolicy(reuseOrNewWithCallOptions(intOpts, retryOpts), method)
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
+			err := invoker(ctx, method, req, reply, cc, grpcOpts...)
+			if err == nil || c.authTokenBundle == nil || !c.shouldRefreshToken(err, callOpts) {
+				return err
+			}
+			// the request was refused before being served, retry it once
+			// with a new auth token.
+			if gterr := c.refreshToken(ctx); gterr != nil {
+				c.GetLogger().Warn(
+					"unary invoker failed to fetch new auth token",
+					zap.String("target", cc.Target()),
+					zap.Error(gterr),
+				)
+				return gterr
+			}
 			return invoker(ctx, method, req, reply, cc, grpcOpts...)
 		}
 		var lastErr error
+		refreshed := false
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
 			if err := waitRetryBackoff(ctx, attempt, callOpts); err != nil {
 				return err
@@ -77,13 +91,12 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 				continue
 			}
 			if c.shouldRefreshToken(lastErr, callOpts) {
-				// clear auth token before refreshing it.
-				// call c.Auth.Authenticate with an invalid token will always fail the auth check on the server-side,
-				// if the server has not apply the patch of pr #12165 (https://github.com/etcd-io/etcd/pull/12165)
-				// and a rpctypes.ErrInvalidAuthToken will recursively call c.getToken until system run out of resource.
-				c.authTokenBundle.UpdateAuthToken("")
-
-				gterr := c.getToken(ctx)
+				if refreshed {
+					// a new auth token was refused as well
+					return lastErr
+				}
+				refreshed = true
+				gterr := c.refreshToken(ctx)
 				if gterr != nil {
 					c.GetLogger().Warn(
 						"retrying of unary invoker failed to fetch new auth token",
@@ -125,7 +138,7 @@ func (c *Client) streamClientInterceptor(optFuncs ...retryOption) grpc.StreamCli
 			}
 		}
 		grpcOpts, retryOpts := filterCallOptions(opts)
-		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+		callOpts := c.applyRetryPolicy(reuseOrNewWithCallOptions(intOpts, retryOpts), method)
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
 			return streamer(ctx, desc, cc, method, grpcOpts...)
@@ -262,10 +275,7 @@ func (s *serverStreamingRetryingStream) receiveMsgAndIndicateRetry(m interface{}
 		return true, err
 	}
 	if s.client.shouldRefreshToken(err, s.callOpts) {
-		// clear auth token to avoid failure when call getToken
-		s.client.authTokenBundle.UpdateAuthToken("")
-
-		gterr := s.client.getToken(s.ctx)
+		gterr := s.client.refreshToken(s.ctx)
 		if gterr != nil {
 			s.client.lg.Warn("retry failed to fetch new auth token", zap.Error(gterr))
 			return false, err // return the original error for simplicity
@@ -318,13 +328,21 @@ func isSafeRetry(c *Client, err error, callOpts *options) bool {
 		return false
 	}
 
-	// Situation when learner refuses RPC it is supposed to not serve is from the server
+	// Situation when learner or witness refuses RPC it is supposed to not serve is from the server
 	// perspective not retryable.
 	// But for backward-compatibility reasons we need  to support situation that
 	// customer provides mix of learners (not yet voters) and voters with an
 	// expectation to pick voter in the next attempt.
 	// TODO: Ideally client should be 'aware' which endpoint represents: leader/voter/learner with high probability.
-	if errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) && len(c.Endpoints()) > 1 {
+	notSupported := isNotSupportedForMember(err)
+	if notSupported && len(c.Endpoints()) <= 1 {
+		// there is no other member to pick, whatever the retry policy.
+		return false
+	}
+	if callOpts.retryOn != nil {
+		return callOpts.retryOn(err)
+	}
+	if notSupported {
 		return true
 	}
 
@@ -397,6 +415,9 @@ type options struct {
 	max         uint
 	backoffFunc backoffFunc
 	retryAuth   bool
+	// retryOn, when set, classifies the errors safe for retry instead of
+	// isSafeRetry.
+	retryOn func(err error) bool
 }
 
 // retryOption is a grpc.CallOption that is local to clientv3's retry interceptor.
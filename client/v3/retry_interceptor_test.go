@@ -1,10 +1,18 @@
 package clientv3
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3/credentials"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	grpccredentials "google.golang.org/grpc/credentials"
-	"testing"
 )
 
 type dummyAuthTokenBundle struct{}
@@ -122,3 +130,62 @@ func TestClientShouldRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+type fakeAuthenticator struct {
+	Auth
+	creds []string
+}
+
+func (a *fakeAuthenticator) Authenticate(ctx context.Context, name string, password string) (*AuthenticateResponse, error) {
+	a.creds = append(a.creds, name+":"+password)
+	return &AuthenticateResponse{Token: "token"}, nil
+}
+
+func TestUnaryClientInterceptorRefreshToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       uint
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"retries disabled", 0, []error{rpctypes.ErrGRPCInvalidAuthToken, nil}, nil, 2},
+		{"retries disabled and refused twice", 0, []error{rpctypes.ErrGRPCAuthOldRevision, rpctypes.ErrGRPCAuthOldRevision}, rpctypes.ErrGRPCAuthOldRevision, 2},
+		{"retries enabled", defaultUnaryMaxRetries, []error{rpctypes.ErrGRPCInvalidAuthToken, nil}, nil, 2},
+		// the request is retried once with a new token
+		{"retries enabled and refused twice", defaultUnaryMaxRetries, []error{rpctypes.ErrGRPCInvalidAuthToken, rpctypes.ErrGRPCInvalidAuthToken, nil}, rpctypes.ErrGRPCInvalidAuthToken, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &fakeAuthenticator{}
+			rotated := 0
+			c := &Client{
+				Auth: auth,
+				credentials: func(ctx context.Context) (string, string, error) {
+					rotated++
+					return "user", fmt.Sprintf("pass%d", rotated), nil
+				},
+				authTokenBundle: credentials.NewBundle(credentials.Config{}),
+				lg:              zap.NewNop(),
+				lgMu:            new(sync.RWMutex),
+			}
+			interceptor := c.unaryClientInterceptor(withMax(tt.max), withBackoff(func(uint) time.Duration { return 0 }))
+			calls := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			}
+			err := interceptor(context.TODO(), "/etcdserverpb.KV/Range", nil, nil, &grpc.ClientConn{}, invoker)
+			if err != tt.wantErr {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if !reflect.DeepEqual(auth.creds, []string{"user:pass1"}) {
+				t.Errorf("expected to authenticate once with the rotated credentials, got %v", auth.creds)
+			}
+		})
+	}
+}
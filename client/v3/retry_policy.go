@@ -0,0 +1,196 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// RetryPolicy decides how the client retries the gRPC calls that failed.
+// The calls that cannot be retried safely, e.g. the watch and lease keep
+// alive streams, are never retried, whatever the policy. Neither are the
+// calls refused by a learner or a witness when the client has a single
+// endpoint. The auth tokens are refreshed on their own, without counting as
+// retries.
+type RetryPolicy interface {
+	// MaxAttempts returns the maximum number of attempts of a call to the
+	// gRPC method (e.g. "/etcdserverpb.KV/Range"), the first one included.
+	MaxAttempts(method string) uint
+	// Backoff returns how long to wait before the given attempt of a call to
+	// method, the first retry being the attempt 1.
+	Backoff(method string, attempt uint) time.Duration
+	// ShouldRetry reports whether a call to method that failed with err is
+	// retried. idempotent tells whether repeating the call has no side effect,
+	// e.g. for a Range but not a Put, so that it is retried on any transient
+	// error, not only when it could not be sent at all.
+	ShouldRetry(method string, idempotent bool, err error) bool
+}
+
+// BackoffRetryPolicy is a RetryPolicy with per-method attempts and retry
+// budgets, and jittered exponential backoff.
+type BackoffRetryPolicy struct {
+	// Attempts is the maximum number of attempts of a call, the first one
+	// included. 0 or 1 disable the retries.
+	Attempts uint
+	// MethodAttempts overrides Attempts for the given gRPC methods.
+	MethodAttempts map[string]uint
+
+	// Budget limits the retries of the calls to each method, across the
+	// calls, if set. Every method has a budget of its own.
+	Budget *RetryBudget
+	// MethodBudgets overrides Budget for the given gRPC methods, a nil
+	// budget not limiting the retries of the method.
+	MethodBudgets map[string]*RetryBudget
+
+	// BaseDelay is the backoff before the first retry, doubled on every
+	// following retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff, if positive.
+	MaxDelay time.Duration
+	// Jitter is the fraction of the backoff randomly added or subtracted
+	// (e.g. 0.1 for ±10%).
+	Jitter float64
+
+	// RetryOn classifies the errors to retry on, if set. Otherwise, the calls
+	// are retried as by IsRetryableError.
+	RetryOn func(method string, idempotent bool, err error) bool
+
+	mu sync.Mutex
+	// buckets are the retry tokens left to the methods with a budget.
+	buckets map[string]*retryBucket
+}
+
+// RetryBudget is a token bucket limiting the retries of the calls to a
+// method: every retry spends a token, and a call is not retried when none is
+// left, whatever its attempts.
+type RetryBudget struct {
+	// Burst is the maximum number of tokens, which the budget starts with.
+	Burst float64
+	// Rate is the number of tokens refilled per second.
+	Rate float64
+}
+
+type retryBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (p *BackoffRetryPolicy) MaxAttempts(method string) uint {
+	if attempts, ok := p.MethodAttempts[method]; ok {
+		return attempts
+	}
+	return p.Attempts
+}
+
+func (p *BackoffRetryPolicy) Backoff(method string, attempt uint) time.Duration {
+	if attempt == 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := uint(1); i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return jitterUp(delay, p.Jitter)
+}
+
+func (p *BackoffRetryPolicy) ShouldRetry(method string, idempotent bool, err error) bool {
+	retry := false
+	if p.RetryOn != nil {
+		retry = p.RetryOn(method, idempotent, err)
+	} else {
+		retry = IsRetryableError(err, idempotent)
+	}
+	return retry && p.spendRetry(method)
+}
+
+// spendRetry spends a retry token of the budget of method, and returns false
+// if none is left.
+func (p *BackoffRetryPolicy) spendRetry(method string) bool {
+	budget, ok := p.MethodBudgets[method]
+	if !ok {
+		budget = p.Budget
+	}
+	if budget == nil {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	b, ok := p.buckets[method]
+	if !ok {
+		if p.buckets == nil {
+			p.buckets = make(map[string]*retryBucket)
+		}
+		b = &retryBucket{tokens: budget.Burst, last: now}
+		p.buckets[method] = b
+	}
+	b.tokens = math.Min(budget.Burst, b.tokens+now.Sub(b.last).Seconds()*budget.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// IsRetryableError reports whether a call that failed with err can be retried
+// without breaking the write-at-most-once semantics of etcd: the idempotent
+// calls are retried on any transient error, the others only when they could
+// not be sent at all. The calls refused by a learner or a witness are retried,
+// hoping to reach a voting member, which the client only does when it has
+// other endpoints.
+func IsRetryableError(err error, idempotent bool) bool {
+	if isContextError(err) {
+		return false
+	}
+	if isNotSupportedForMember(err) {
+		return true
+	}
+	if idempotent {
+		return isSafeRetryImmutableRPC(err)
+	}
+	return isSafeRetryMutableRPC(err)
+}
+
+// isNotSupportedForMember reports whether err refuses a call a learner or a
+// witness does not serve.
+func isNotSupportedForMember(err error) bool {
+	return errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) || errors.Is(err, rpctypes.ErrGRPCNotSupportedForWitness)
+}
+
+// applyRetryPolicy overrides the retry options of a call to method with the
+// RetryPolicy of the client, if any. The calls not to be retried stay so.
+func (c *Client) applyRetryPolicy(opts *options, method string) *options {
+	rp := c.cfg.RetryPolicy
+	if rp == nil || opts.max == 0 {
+		return opts
+	}
+	idempotent := opts.retryPolicy == repeatable
+	optCopy := &options{}
+	*optCopy = *opts
+	optCopy.max = rp.MaxAttempts(method)
+	optCopy.backoffFunc = func(attempt uint) time.Duration { return rp.Backoff(method, attempt) }
+	optCopy.retryOn = func(err error) bool { return rp.ShouldRetry(method, idempotent, err) }
+	return optCopy
+}
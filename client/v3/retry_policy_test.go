@@ -0,0 +1,204 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackoffRetryPolicyBackoff(t *testing.T) {
+	p := &BackoffRetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	tests := []struct {
+		attempt uint
+		want    time.Duration
+	}{
+		{0, 0},
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{100, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff("/etcdserverpb.KV/Range", tt.attempt); got != tt.want {
+			t.Errorf("attempt %d: expected backoff %v, got %v", tt.attempt, tt.want, got)
+		}
+	}
+
+	p.Jitter = 0.1
+	for i := 0; i < 100; i++ {
+		if got := p.Backoff("/etcdserverpb.KV/Range", 1); got < 9*time.Millisecond || got > 11*time.Millisecond {
+			t.Fatalf("expected backoff within 10ms±10%%, got %v", got)
+		}
+	}
+}
+
+func TestBackoffRetryPolicyBudget(t *testing.T) {
+	p := &BackoffRetryPolicy{
+		Budget:        &RetryBudget{Burst: 2},
+		MethodBudgets: map[string]*RetryBudget{"/etcdserverpb.KV/Txn": nil},
+	}
+	// the budget of a method is spent across its calls
+	for i := 0; i < 2; i++ {
+		if !p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCNoLeader) {
+			t.Fatalf("retry %d: expected the retry within the budget", i)
+		}
+	}
+	if p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCNoLeader) {
+		t.Error("expected no retry once the budget is spent")
+	}
+	// the other methods have a budget of their own
+	if !p.ShouldRetry("/etcdserverpb.KV/Put", true, rpctypes.ErrGRPCNoLeader) {
+		t.Error("expected the retry of another method")
+	}
+	// a nil method budget does not limit the retries
+	for i := 0; i < 10; i++ {
+		if !p.ShouldRetry("/etcdserverpb.KV/Txn", true, rpctypes.ErrGRPCNoLeader) {
+			t.Fatalf("retry %d: expected the retry of a method without budget", i)
+		}
+	}
+	// the calls not to retry do not spend the budget
+	p = &BackoffRetryPolicy{Budget: &RetryBudget{Burst: 1}}
+	if p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCCompacted) {
+		t.Error("expected no retry on a non transient error")
+	}
+	if !p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCNoLeader) {
+		t.Error("expected the retry within the budget")
+	}
+
+	// the tokens are refilled over time
+	p = &BackoffRetryPolicy{Budget: &RetryBudget{Burst: 1, Rate: 100}}
+	p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCNoLeader)
+	time.Sleep(20 * time.Millisecond)
+	if !p.ShouldRetry("/etcdserverpb.KV/Range", true, rpctypes.ErrGRPCNoLeader) {
+		t.Error("expected the retry once the budget is refilled")
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{rpctypes.ErrGRPCNoLeader, true, true},
+		{rpctypes.ErrGRPCNoLeader, false, false},
+		{status.Error(codes.Unavailable, "there is no address available"), false, true},
+		{rpctypes.ErrGRPCCompacted, true, false},
+		{rpctypes.ErrGRPCNotSupportedForLearner, false, true},
+		{status.Error(codes.DeadlineExceeded, "context deadline exceeded"), true, false},
+	}
+	for i, tt := range tests {
+		if got := IsRetryableError(tt.err, tt.idempotent); got != tt.want {
+			t.Errorf("#%d: expected %v for %v (idempotent %v), got %v", i, tt.want, tt.err, tt.idempotent, got)
+		}
+	}
+}
+
+func TestUnaryClientInterceptorRetryPolicy(t *testing.T) {
+	var mu sync.Mutex
+	var retried []string
+	c := &Client{
+		cfg: Config{RetryPolicy: &BackoffRetryPolicy{
+			Attempts:       3,
+			MethodAttempts: map[string]uint{"/etcdserverpb.KV/Put": 1},
+			RetryOn: func(method string, idempotent bool, err error) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				retried = append(retried, method)
+				return idempotent
+			},
+		}},
+		lg:   zap.NewNop(),
+		lgMu: new(sync.RWMutex),
+	}
+	interceptor := c.unaryClientInterceptor(withMax(defaultUnaryMaxRetries))
+
+	tests := []struct {
+		method     string
+		opts       []grpc.CallOption
+		wantCalls  int
+		wantChecks int
+	}{
+		{"/etcdserverpb.KV/Range", []grpc.CallOption{withRetryPolicy(repeatable)}, 3, 3},
+		// the per-method budget disables the retries
+		{"/etcdserverpb.KV/Put", nil, 1, 1},
+		// the policy refuses to retry the non idempotent calls
+		{"/etcdserverpb.KV/Txn", nil, 1, 1},
+		// the calls with retries disabled are never retried
+		{"/etcdserverpb.KV/Compact", []grpc.CallOption{withMax(0)}, 1, 0},
+	}
+	for _, tt := range tests {
+		retried = nil
+		calls := 0
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			return rpctypes.ErrGRPCNoLeader
+		}
+		err := interceptor(context.TODO(), tt.method, nil, nil, &grpc.ClientConn{}, invoker, tt.opts...)
+		if err != rpctypes.ErrGRPCNoLeader {
+			t.Errorf("%s: expected %v, got %v", tt.method, rpctypes.ErrGRPCNoLeader, err)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s: expected %d calls, got %d", tt.method, tt.wantCalls, calls)
+		}
+		if len(retried) != tt.wantChecks {
+			t.Errorf("%s: expected the policy to be asked %d times, got %d", tt.method, tt.wantChecks, len(retried))
+		}
+	}
+}
+
+func TestUnaryClientInterceptorRetryPolicyLearner(t *testing.T) {
+	tests := []struct {
+		endpoints []string
+		wantCalls int
+	}{
+		// a single endpoint cannot reach another member
+		{[]string{"a"}, 1},
+		{[]string{"a", "b"}, 3},
+	}
+	for _, tt := range tests {
+		c := &Client{
+			cfg:       Config{RetryPolicy: &BackoffRetryPolicy{Attempts: 3}},
+			endpoints: tt.endpoints,
+			epMu:      new(sync.RWMutex),
+			lg:        zap.NewNop(),
+			lgMu:      new(sync.RWMutex),
+		}
+		interceptor := c.unaryClientInterceptor(withMax(defaultUnaryMaxRetries))
+		calls := 0
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			return rpctypes.ErrGRPCNotSupportedForLearner
+		}
+		err := interceptor(context.TODO(), "/etcdserverpb.KV/Range", nil, nil, &grpc.ClientConn{}, invoker, withRetryPolicy(repeatable))
+		if err != rpctypes.ErrGRPCNotSupportedForLearner {
+			t.Errorf("%d endpoints: expected %v, got %v", len(tt.endpoints), rpctypes.ErrGRPCNotSupportedForLearner, err)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%d endpoints: expected %d calls, got %d", len(tt.endpoints), tt.wantCalls, calls)
+		}
+	}
+}
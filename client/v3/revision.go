@@ -0,0 +1,58 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+const (
+	minRevisionPollInterval = 10 * time.Millisecond
+	maxRevisionPollInterval = 500 * time.Millisecond
+)
+
+// WaitForRevision blocks until the member the client is connected to has
+// applied at least revision rev, or ctx is done. Once it returns, serializable
+// reads served by that member observe all the writes up to rev, e.g. the write
+// another client reported, without paying for linearizable reads. When the
+// client has several endpoints, later requests may be balanced to another
+// member than the one waited for.
+//
+// The revision of the member is polled with Status requests, which unlike
+// reads and watches do not require permissions on any key.
+func (c *Client) WaitForRevision(ctx context.Context, rev int64) error {
+	remote := RetryMaintenanceClient(c, c.conn)
+	interval := minRevisionPollInterval
+	for {
+		resp, err := remote.Status(ctx, &pb.StatusRequest{}, c.callOpts...)
+		if err != nil {
+			return toErr(ctx, err)
+		}
+		if resp.Header.Revision >= rev {
+			return nil
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if interval *= 2; interval > maxRevisionPollInterval {
+			interval = maxRevisionPollInterval
+		}
+	}
+}
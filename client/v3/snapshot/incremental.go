@@ -0,0 +1,187 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// An incremental backup file is a sequence of BackupResponse messages, each
+// prefixed by its size as an uvarint, ended by a zero size and the sha256
+// digest of all the bytes before it.
+
+// ErrIncrementalChecksum is returned when reading an incremental backup whose
+// sha256 digest does not match its content.
+var ErrIncrementalChecksum = errors.New("incremental backup sha256 checksum mismatch")
+
+// IncrementalWriter writes an incremental backup.
+type IncrementalWriter struct {
+	w *bufio.Writer
+	h hash.Hash
+}
+
+func NewIncrementalWriter(w io.Writer) *IncrementalWriter {
+	h := sha256.New()
+	return &IncrementalWriter{w: bufio.NewWriter(io.MultiWriter(w, h)), h: h}
+}
+
+// Write appends resp to the incremental backup.
+func (iw *IncrementalWriter) Write(resp *pb.BackupResponse) error {
+	d, err := resp.Marshal()
+	if err != nil {
+		return err
+	}
+	var size [binary.MaxVarintLen64]byte
+	if _, err = iw.w.Write(size[:binary.PutUvarint(size[:], uint64(len(d)))]); err != nil {
+		return err
+	}
+	_, err = iw.w.Write(d)
+	return err
+}
+
+// Close ends the incremental backup with its digest. It does not close the
+// underlying writer.
+func (iw *IncrementalWriter) Close() error {
+	if err := iw.w.WriteByte(0); err != nil {
+		return err
+	}
+	if err := iw.w.Flush(); err != nil {
+		return err
+	}
+	_, err := iw.w.Write(iw.h.Sum(nil))
+	if err != nil {
+		return err
+	}
+	return iw.w.Flush()
+}
+
+// ReadIncremental calls f with the messages of the incremental backup read
+// from r. The digest is only verified once all the messages are read, so f
+// should not make them visible before ReadIncremental returns without error.
+func ReadIncremental(r io.Reader, f func(*pb.BackupResponse) error) error {
+	h := sha256.New()
+	br := bufio.NewReader(r)
+	var (
+		buf  []byte
+		size [binary.MaxVarintLen64]byte
+	)
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fmt.Errorf("could not read incremental backup (%v)", err)
+		}
+		h.Write(size[:binary.PutUvarint(size[:], n)])
+		if n == 0 {
+			break
+		}
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		if _, err = io.ReadFull(br, buf[:n]); err != nil {
+			return fmt.Errorf("could not read incremental backup (%v)", err)
+		}
+		h.Write(buf[:n])
+		var resp pb.BackupResponse
+		if err = resp.Unmarshal(buf[:n]); err != nil {
+			return err
+		}
+		if err = f(&resp); err != nil {
+			return err
+		}
+	}
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(br, sum); err != nil {
+		return fmt.Errorf("could not read incremental backup checksum (%v)", err)
+	}
+	if !bytes.Equal(sum, h.Sum(nil)) {
+		return ErrIncrementalChecksum
+	}
+	return nil
+}
+
+// SaveIncremental fetches the changes made after sinceRev from the remote
+// node and saves them as an incremental backup to path. It returns the
+// revision the backup is up to date with.
+func SaveIncremental(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, sinceRev int64, path string) (rev int64, err error) {
+	cfg.Logger = lg.Named("client")
+	if len(cfg.Endpoints) != 1 {
+		return 0, fmt.Errorf("backup must be requested to one selected node, not multiple %v", cfg.Endpoints)
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer cli.Close()
+
+	partpath := path + ".part"
+	defer os.RemoveAll(partpath)
+
+	var f *os.File
+	f, err = os.OpenFile(partpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		return 0, fmt.Errorf("could not open %s (%v)", partpath, err)
+	}
+	defer f.Close()
+
+	start := time.Now()
+	lg.Info("fetching incremental backup", zap.String("endpoint", cfg.Endpoints[0]), zap.Int64("since-revision", sinceRev))
+	iw := NewIncrementalWriter(f)
+	events := 0
+	err = cli.Backup(ctx, sinceRev, func(resp *clientv3.BackupResponse) error {
+		rev = resp.Header.Revision
+		events += len(resp.Events)
+		return iw.Write((*pb.BackupResponse)(resp))
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = iw.Close(); err != nil {
+		return 0, err
+	}
+	if err = fileutil.Fsync(f); err != nil {
+		return 0, err
+	}
+	if err = f.Close(); err != nil {
+		return 0, err
+	}
+	lg.Info("fetched incremental backup",
+		zap.String("endpoint", cfg.Endpoints[0]),
+		zap.Int64("since-revision", sinceRev),
+		zap.Int64("revision", rev),
+		zap.Int("events", events),
+		zap.Duration("took", time.Since(start)),
+	)
+
+	if err = os.Rename(partpath, path); err != nil {
+		return 0, fmt.Errorf("could not rename %s to %s (%v)", partpath, path, err)
+	}
+	lg.Info("saved", zap.String("path", path))
+	return rev, nil
+}
@@ -15,6 +15,7 @@
 package snapshot
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"fmt"
@@ -36,6 +37,56 @@ func hasChecksum(n int64) bool {
 	return (n % 512) == sha256.Size
 }
 
+// SaveOption configures how a snapshot is fetched.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	compression string
+	maxResumes  int
+	minRevision int64
+}
+
+// WithCompression asks the server to compress the snapshot stream with the
+// given algorithm, e.g. clientv3.SnapshotCompressionZstd. The saved file is
+// always uncompressed.
+func WithCompression(compression string) SaveOption {
+	return func(o *saveOptions) { o.compression = compression }
+}
+
+// WithMaxResumes resumes the snapshot at most n times if its stream breaks,
+// fetching the rest of the same snapshot kept by the server. Servers that do
+// not keep snapshots, e.g. etcd < v3.6, are not resumed.
+func WithMaxResumes(n int) SaveOption {
+	return func(o *saveOptions) { o.maxResumes = n }
+}
+
+// WithMinRevision fails the save if the snapshot does not contain the writes
+// up to rev, as told by the revision in the header of the snapshot stream.
+// Servers that do not send it, e.g. etcd < v3.6, always fail.
+func WithMinRevision(rev int64) SaveOption {
+	return func(o *saveOptions) { o.minRevision = rev }
+}
+
+// verifyChecksum verifies the sha256 digest appended to the first n bytes of
+// the snapshot file f.
+func verifyChecksum(f *os.File, n int64) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, n-sha256.Size); err != nil {
+		return err
+	}
+	sha := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(f, sha); err != nil {
+		return err
+	}
+	if !bytes.Equal(sha, h.Sum(nil)) {
+		return fmt.Errorf("sha256 checksum mismatch")
+	}
+	return nil
+}
+
 // SaveWithVersion fetches snapshot from remote etcd server, saves data
 // to target path and returns server version. If the context "ctx" is canceled or timed out,
 // snapshot save stream will error out (e.g. context.Canceled,
@@ -44,7 +95,11 @@ func hasChecksum(n int64) bool {
 // selected node, and saved snapshot is the point-in-time state of
 // the selected node.
 // Etcd <v3.6 will return "" as version.
-func SaveWithVersion(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, dbPath string) (version string, err error) {
+func SaveWithVersion(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, dbPath string, opts ...SaveOption) (version string, err error) {
+	var so saveOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
 	cfg.Logger = lg.Named("client")
 	if len(cfg.Endpoints) != 1 {
 		return "", fmt.Errorf("snapshot must be requested to one selected node, not multiple %v", cfg.Endpoints)
@@ -59,32 +114,61 @@ func SaveWithVersion(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, d
 	defer os.RemoveAll(partpath)
 
 	var f *os.File
-	f, err = os.OpenFile(partpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	f, err = os.OpenFile(partpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
 	if err != nil {
 		return "", fmt.Errorf("could not open %s (%v)", partpath, err)
 	}
+	defer f.Close()
 	lg.Info("created temporary db file", zap.String("path", partpath))
 
 	start := time.Now()
-	resp, err := cli.SnapshotWithVersion(ctx)
-	if err != nil {
-		return resp.Version, err
-	}
-	defer resp.Snapshot.Close()
 	lg.Info("fetching snapshot", zap.String("endpoint", cfg.Endpoints[0]))
-	var size int64
-	size, err = io.Copy(f, resp.Snapshot)
-	if err != nil {
-		return resp.Version, err
+	var (
+		size int64
+		id   uint64
+	)
+	for resumes := 0; ; resumes++ {
+		var resp *clientv3.SnapshotResponse
+		if so.maxResumes > 0 {
+			resp, err = cli.SnapshotResumable(ctx, so.compression, id, size)
+		} else {
+			resp, err = cli.SnapshotWithCompression(ctx, so.compression)
+		}
+		if err != nil {
+			return version, err
+		}
+		version, id = resp.Version, resp.ID
+		if resumes == 0 && resp.Header.GetRevision() < so.minRevision {
+			resp.Snapshot.Close()
+			return version, fmt.Errorf("snapshot revision %d is behind the requested revision %d", resp.Header.GetRevision(), so.minRevision)
+		}
+		var n int64
+		n, err = io.Copy(f, resp.Snapshot)
+		resp.Snapshot.Close()
+		size += n
+		if err == nil {
+			break
+		}
+		if id == 0 || resumes >= so.maxResumes || ctx.Err() != nil {
+			return version, err
+		}
+		lg.Warn("snapshot stream broke; resuming",
+			zap.Uint64("snapshot-id", id),
+			zap.Int64("offset", size),
+			zap.Error(err),
+		)
 	}
 	if !hasChecksum(size) {
-		return resp.Version, fmt.Errorf("sha256 checksum not found [bytes: %d]", size)
+		return version, fmt.Errorf("sha256 checksum not found [bytes: %d]", size)
+	}
+	if err = verifyChecksum(f, size); err != nil {
+		return version, err
 	}
 	if err = fileutil.Fsync(f); err != nil {
-		return resp.Version, err
+		return version, err
 	}
 	if err = f.Close(); err != nil {
-		return resp.Version, err
+		return version, err
 	}
 	lg.Info("fetched snapshot",
 		zap.String("endpoint", cfg.Endpoints[0]),
@@ -94,10 +178,67 @@ func SaveWithVersion(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, d
 	)
 
 	if err = os.Rename(partpath, dbPath); err != nil {
-		return resp.Version, fmt.Errorf("could not rename %s to %s (%v)", partpath, dbPath, err)
+		return version, fmt.Errorf("could not rename %s to %s (%v)", partpath, dbPath, err)
 	}
 	lg.Info("saved", zap.String("path", dbPath))
-	return resp.Version, nil
+	return version, nil
+}
+
+// SaveFromFollowerWithVersion fetches a snapshot from a follower, saves data
+// to target path and returns server version. Unlike SaveWithVersion, the
+// client configuration should list the endpoints of all members: the revision
+// of the leader is captured as a fence, and the snapshot is only requested
+// once the first follower among the endpoints reached it, so that the
+// snapshot contains all writes acknowledged before the call.
+func SaveFromFollowerWithVersion(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, dbPath string, opts ...SaveOption) (version string, err error) {
+	cfg.Logger = lg.Named("client")
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	var (
+		fence    int64
+		follower string
+	)
+	for _, ep := range cfg.Endpoints {
+		resp, err := cli.Status(ctx, ep)
+		if err != nil {
+			lg.Warn("failed to get endpoint status", zap.String("endpoint", ep), zap.Error(err))
+			continue
+		}
+		if resp.Leader == resp.Header.MemberId {
+			fence = resp.Header.Revision
+		} else if follower == "" {
+			follower = ep
+		}
+	}
+	if fence == 0 {
+		return "", fmt.Errorf("leader not found among endpoints %v", cfg.Endpoints)
+	}
+	if follower == "" {
+		return "", fmt.Errorf("follower not found among endpoints %v", cfg.Endpoints)
+	}
+
+	lg.Info("waiting for follower to reach the leader revision", zap.String("endpoint", follower), zap.Int64("revision", fence))
+	for {
+		resp, err := cli.Status(ctx, follower)
+		if err != nil {
+			return "", err
+		}
+		if resp.Header.Revision >= fence {
+			break
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	cfg.Endpoints = []string{follower}
+	return SaveWithVersion(ctx, lg, cfg, dbPath, append(opts, WithMinRevision(fence))...)
 }
 
 // Save fetches snapshot from remote etcd server and saves data
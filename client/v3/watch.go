@@ -144,6 +144,11 @@ type watcher struct {
 	// streams holds all the active grpc streams keyed by ctx value.
 	streams map[string]*watchGrpcStream
 	lg      *zap.Logger
+
+	// reauth is set if the client authenticates, so that the watches refused
+	// for an invalid or outdated auth token are retried once on a new stream,
+	// authenticated again.
+	reauth bool
 }
 
 // watchGrpcStream tracks all watch resources attached to a single grpc stream.
@@ -158,6 +163,9 @@ type watchGrpcStream struct {
 	ctxKey string
 	cancel context.CancelFunc
 
+	// cancelWatchClient cancels the current grpc stream
+	cancelWatchClient context.CancelFunc
+
 	// substreams holds all active watchers on this grpc stream
 	substreams map[int64]*watcherStream
 	// resuming holds all resuming watchers on this grpc stream
@@ -165,8 +173,8 @@ type watchGrpcStream struct {
 
 	// reqc sends a watch request from Watch() to the main goroutine
 	reqc chan watchStreamRequest
-	// respc receives data from the watch client
-	respc chan *pb.WatchResponse
+	// respc receives data from the watch clients
+	respc chan watchClientResponse
 	// donec closes to broadcast shutdown
 	donec chan struct{}
 	// errc transmits errors from grpc Recv to the watch stream reconnect logic
@@ -184,6 +192,12 @@ type watchGrpcStream struct {
 	lg *zap.Logger
 }
 
+// watchClientResponse is a response received from a grpc stream.
+type watchClientResponse struct {
+	wc   pb.Watch_WatchClient
+	resp *pb.WatchResponse
+}
+
 // watchStreamRequest is a union of the supported watch request operation types
 type watchStreamRequest interface {
 	toPB() *pb.WatchRequest
@@ -207,6 +221,8 @@ type watchRequest struct {
 
 	// filters is the list of events to filter out
 	filters []pb.WatchCreateRequest_FilterType
+	// valueFilters filter out the put events whose values do not match them
+	valueFilters []*pb.WatchValueFilter
 	// get the previous key-value pair before the event happens
 	prevKV bool
 	// retc receives a chan WatchResponse once the watcher is established
@@ -235,6 +251,9 @@ type watcherStream struct {
 
 	// buf holds all events received from etcd but not yet consumed by the client
 	buf []*WatchResponse
+
+	// reauthed is set once the watcher is retried for an invalid auth token
+	reauthed bool
 }
 
 func NewWatcher(c *Client) Watcher {
@@ -249,6 +268,7 @@ func NewWatchFromWatchClient(wc pb.WatchClient, c *Client) Watcher {
 	if c != nil {
 		w.callOpts = c.callOpts
 		w.lg = c.lg
+		w.reauth = c.authTokenBundle != nil
 	}
 	return w
 }
@@ -274,7 +294,7 @@ func (w *watcher) newWatcherGrpcStream(inctx context.Context) *watchGrpcStream {
 		ctxKey:     streamKeyFromCtx(inctx),
 		cancel:     cancel,
 		substreams: make(map[int64]*watcherStream),
-		respc:      make(chan *pb.WatchResponse),
+		respc:      make(chan watchClientResponse),
 		reqc:       make(chan watchStreamRequest),
 		donec:      make(chan struct{}),
 		errc:       make(chan error, 1),
@@ -307,6 +327,7 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		progressNotify: ow.progressNotify,
 		fragment:       ow.fragment,
 		filters:        filters,
+		valueFilters:   ow.valueFilters,
 		prevKV:         ow.prevKV,
 		retc:           make(chan chan WatchResponse, 1),
 	}
@@ -568,7 +589,29 @@ func (w *watchGrpcStream) run() {
 			}
 
 		// new events from the watch client
-		case pbresp := <-w.respc:
+		case r := <-w.respc:
+			if r.wc != wc {
+				// received from a grpc stream since replaced
+				break
+			}
+			pbresp := r.resp
+			if pbresp.Created && w.shouldReauth(pbresp) {
+				// the auth token of a grpc stream is set once it opens, the
+				// stream interceptor authenticating again
+				w.resuming[0].reauthed = true
+				w.lg.Debug("reopening watch stream after an auth token error", zap.String("reason", pbresp.CancelReason))
+				if wc, closeErr = w.newWatchClient(); closeErr != nil {
+					return
+				}
+				if ws := w.nextResume(); ws != nil {
+					if err := wc.Send(ws.initReq.toPB()); err != nil {
+						w.lg.Debug("error when sending request", zap.Error(err))
+					}
+				}
+				cancelSet = make(map[int64]struct{})
+				cur = nil
+				break
+			}
 			if cur == nil || pbresp.Created || pbresp.Canceled {
 				cur = pbresp
 			} else if cur != nil && cur.WatchId == pbresp.WatchId {
@@ -688,6 +731,15 @@ func (w *watchGrpcStream) run() {
 	}
 }
 
+// shouldReauth returns true if the creation of the head of the resume queue
+// is refused for an invalid or outdated auth token, for the first time.
+func (w *watchGrpcStream) shouldReauth(resp *pb.WatchResponse) bool {
+	if !w.owner.reauth || !resp.Canceled || len(w.resuming) == 0 || w.resuming[0] == nil || w.resuming[0].reauthed {
+		return false
+	}
+	return resp.CancelReason == v3rpc.ErrGRPCInvalidAuthToken.Error() || resp.CancelReason == v3rpc.ErrGRPCAuthOldRevision.Error()
+}
+
 // nextResume chooses the next resuming to register with the grpc stream. Abandoned
 // streams are marked as nil in the queue since the head must wait for its inflight registration.
 func (w *watchGrpcStream) nextResume() *watcherStream {
@@ -752,10 +804,14 @@ func (w *watchGrpcStream) unicastResponse(wr *WatchResponse, watchId int64) bool
 }
 
 // serveWatchClient forwards messages from the grpc stream to run()
-func (w *watchGrpcStream) serveWatchClient(wc pb.Watch_WatchClient) {
+func (w *watchGrpcStream) serveWatchClient(ctx context.Context, wc pb.Watch_WatchClient) {
 	for {
 		resp, err := wc.Recv()
 		if err != nil {
+			if ctx.Err() != nil && w.ctx.Err() == nil {
+				// the stream was replaced
+				return
+			}
 			select {
 			case w.errc <- err:
 			case <-w.donec:
@@ -763,7 +819,7 @@ func (w *watchGrpcStream) serveWatchClient(wc pb.Watch_WatchClient) {
 			return
 		}
 		select {
-		case w.respc <- resp:
+		case w.respc <- watchClientResponse{wc: wc, resp: resp}:
 		case <-w.donec:
 			return
 		}
@@ -887,9 +943,14 @@ func (w *watchGrpcStream) newWatchClient() (pb.Watch_WatchClient, error) {
 	w.substreams = make(map[int64]*watcherStream)
 
 	// connect to grpc stream while accepting watcher cancelation
+	if w.cancelWatchClient != nil {
+		w.cancelWatchClient()
+	}
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.cancelWatchClient = cancel
 	stopc := make(chan struct{})
 	donec := w.waitCancelSubstreams(stopc)
-	wc, err := w.openWatchClient()
+	wc, err := w.openWatchClient(ctx)
 	close(stopc)
 	<-donec
 
@@ -909,7 +970,7 @@ func (w *watchGrpcStream) newWatchClient() (pb.Watch_WatchClient, error) {
 	}
 
 	// receive data from new grpc stream
-	go w.serveWatchClient(wc)
+	go w.serveWatchClient(ctx, wc)
 	return wc, nil
 }
 
@@ -966,24 +1027,24 @@ var maxBackoff = 100 * time.Millisecond
 // openWatchClient retries opening a watch client until success or halt.
 // manually retry in case "ws==nil && err==nil"
 // TODO: remove FailFast=false
-func (w *watchGrpcStream) openWatchClient() (ws pb.Watch_WatchClient, err error) {
+func (w *watchGrpcStream) openWatchClient(ctx context.Context) (ws pb.Watch_WatchClient, err error) {
 	backoff := time.Millisecond
 	for {
 		select {
-		case <-w.ctx.Done():
+		case <-ctx.Done():
 			if err == nil {
-				return nil, w.ctx.Err()
+				return nil, ctx.Err()
 			}
 			return nil, err
 		default:
 		}
-		if ws, err = w.remote.Watch(w.ctx, w.callOpts...); ws != nil && err == nil {
+		if ws, err = w.remote.Watch(ctx, w.callOpts...); ws != nil && err == nil {
 			break
 		}
-		if isHaltErr(w.ctx, err) {
+		if isHaltErr(ctx, err) {
 			return nil, v3rpc.Error(err)
 		}
-		if isUnavailableErr(w.ctx, err) {
+		if isUnavailableErr(ctx, err) {
 			// retry, but backoff
 			if backoff < maxBackoff {
 				// 25% backoff factor
@@ -1006,6 +1067,7 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 		RangeEnd:       []byte(wr.end),
 		ProgressNotify: wr.progressNotify,
 		Filters:        wr.filters,
+		ValueFilters:   wr.valueFilters,
 		PrevKv:         wr.prevKV,
 		Fragment:       wr.fragment,
 	}
@@ -0,0 +1,231 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	watchCacheMinResumeInterval = 50 * time.Millisecond
+	watchCacheMaxResumeInterval = 2 * time.Second
+)
+
+// watchCache wraps a Watcher so that the watches buffer their recent events
+// and outlive the loss of their watch stream.
+type watchCache struct {
+	Watcher
+	size int
+
+	wg       sync.WaitGroup
+	stopc    chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWatchCache wraps a Watcher so that every watch reads up to size of its
+// events ahead of the receiver into a local buffer. When the stream of a watch
+// is lost, e.g. the member loses its leader or the connection breaks, the
+// watch is recreated from the revision following the buffered events, on any
+// member, and the receiver sees neither gaps nor duplicates. If that revision
+// is already compacted, the receiver gets a response with the CompactRevision
+// set and Err() returning ErrCompacted, then the watch carries on from the
+// compaction revision instead of silently skipping the compacted events.
+//
+// The watch stream is always created with WithRequireLeader, so that a watch
+// on a partitioned member is resumed on another member.
+func NewWatchCache(w Watcher, size int) Watcher {
+	if size <= 0 {
+		size = 1
+	}
+	return &watchCache{Watcher: w, size: size, stopc: make(chan struct{})}
+}
+
+func (wc *watchCache) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	ow := opWatch(key, opts...)
+	cw := &cachedWatch{
+		wc:            wc,
+		ctx:           ctx,
+		key:           key,
+		opts:          opts,
+		nextRev:       ow.rev,
+		createdNotify: ow.createdNotify,
+		outc:          make(chan WatchResponse),
+	}
+	// as with the underlying Watcher, the watch is created once Watch returns
+	wctx, cancel := context.WithCancel(WithRequireLeader(ctx))
+	wch := cw.watch(wctx)
+	wc.wg.Add(1)
+	go cw.run(wch, cancel)
+	return cw.outc
+}
+
+func (wc *watchCache) Close() error {
+	err := wc.Watcher.Close()
+	wc.stopOnce.Do(func() { close(wc.stopc) })
+	wc.wg.Wait()
+	return err
+}
+
+// cachedWatch is a watch of the watch cache, recreated on the underlying
+// Watcher whenever its watch stream is lost.
+type cachedWatch struct {
+	wc   *watchCache
+	ctx  context.Context
+	key  string
+	opts []OpOption
+
+	// nextRev is the revision following the buffered events, the watch
+	// resumes from it.
+	nextRev int64
+	// created is set once the first watch is created.
+	created       bool
+	createdNotify bool
+
+	// buf holds the responses not yet received, and buffered the number of
+	// their events.
+	buf      []WatchResponse
+	buffered int
+	// done is set once buf ends with the response that canceled the watch.
+	done bool
+
+	outc chan WatchResponse
+}
+
+func (cw *cachedWatch) watch(ctx context.Context) WatchChan {
+	opts := append(cw.opts[:len(cw.opts):len(cw.opts)], WithRev(cw.nextRev), WithCreatedNotify())
+	return cw.wc.Watcher.Watch(ctx, cw.key, opts...)
+}
+
+func (cw *cachedWatch) run(wch WatchChan, cancel context.CancelFunc) {
+	defer func() {
+		close(cw.outc)
+		cw.wc.wg.Done()
+	}()
+
+	interval := watchCacheMinResumeInterval
+	for {
+		resume := cw.serve(wch, &interval)
+		cancel()
+		if !resume {
+			return
+		}
+
+		select {
+		case <-time.After(jitterUp(interval, 0.1)):
+		case <-cw.ctx.Done():
+			return
+		case <-cw.wc.stopc:
+			return
+		}
+		if interval *= 2; interval > watchCacheMaxResumeInterval {
+			interval = watchCacheMaxResumeInterval
+		}
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(WithRequireLeader(cw.ctx))
+		wch = cw.watch(ctx)
+	}
+}
+
+// serve buffers the responses of wch and sends them to the receiver until
+// the watch stream is lost, and returns whether the watch should resume.
+func (cw *cachedWatch) serve(wch WatchChan, interval *time.Duration) bool {
+	for {
+		var outc chan WatchResponse
+		var next WatchResponse
+		if len(cw.buf) > 0 {
+			outc, next = cw.outc, cw.buf[0]
+		} else if cw.done {
+			return false
+		}
+		inc := wch
+		if cw.done || cw.buffered >= cw.wc.size {
+			inc = nil
+		}
+
+		select {
+		case outc <- next:
+			cw.buf = cw.buf[1:]
+			cw.buffered -= len(next.Events)
+		case wr, ok := <-inc:
+			if !ok {
+				// the watch stream is lost, or the watcher closed
+				return cw.ctx.Err() == nil
+			}
+			if !cw.receive(wr) {
+				return true
+			}
+			if wr.Created {
+				*interval = watchCacheMinResumeInterval
+			}
+		case <-cw.ctx.Done():
+			return false
+		case <-cw.wc.stopc:
+			return false
+		}
+	}
+}
+
+// receive buffers wr, and returns false if the watch has to be resumed.
+func (cw *cachedWatch) receive(wr WatchResponse) bool {
+	switch {
+	case wr.CompactRevision != 0:
+		// surface the compacted events, then carry on after them
+		cw.buf = append(cw.buf, wr)
+		cw.nextRev = wr.CompactRevision
+		return false
+	case wr.closeErr != nil && !isHaltErr(nil, wr.closeErr):
+		return false
+	case wr.Err() != nil:
+		cw.buf = append(cw.buf, wr)
+		cw.done = true
+		return true
+	case wr.Created:
+		if !cw.created {
+			cw.created = true
+			if cw.nextRev == 0 {
+				cw.nextRev = wr.Header.Revision + 1
+			}
+			if cw.createdNotify {
+				cw.buf = append(cw.buf, wr)
+			}
+		}
+		return true
+	case wr.IsProgressNotify():
+		if wr.Header.Revision >= cw.nextRev {
+			cw.nextRev = wr.Header.Revision + 1
+		}
+		cw.buf = append(cw.buf, wr)
+		return true
+	}
+
+	// skip the events received before the watch resumed
+	events := wr.Events[:0]
+	for _, ev := range wr.Events {
+		if ev.Kv.ModRevision >= cw.nextRev {
+			events = append(events, ev)
+		}
+	}
+	if len(events) == 0 {
+		return true
+	}
+	wr.Events = events
+	cw.nextRev = events[len(events)-1].Kv.ModRevision + 1
+	cw.buf = append(cw.buf, wr)
+	cw.buffered += len(events)
+	return true
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeWatcher serves every watch with the next channel of wchs, and records
+// the revisions the watches start from.
+type fakeWatcher struct {
+	Watcher
+	wchs chan chan WatchResponse
+	revs chan int64
+}
+
+func (fw *fakeWatcher) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	fw.revs <- opWatch(key, opts...).rev
+	return <-fw.wchs
+}
+
+func (fw *fakeWatcher) Close() error { return nil }
+
+func putResponse(revs ...int64) WatchResponse {
+	wr := WatchResponse{Header: pb.ResponseHeader{Revision: revs[len(revs)-1]}}
+	for _, rev := range revs {
+		wr.Events = append(wr.Events, &Event{Type: EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("foo"), ModRevision: rev}})
+	}
+	return wr
+}
+
+func TestWatchCacheResume(t *testing.T) {
+	fw := &fakeWatcher{wchs: make(chan chan WatchResponse, 1), revs: make(chan int64, 1)}
+	wc := NewWatchCache(fw, 10)
+	defer wc.Close()
+
+	expectRev := func(rev int64) {
+		t.Helper()
+		select {
+		case r := <-fw.revs:
+			if r != rev {
+				t.Fatalf("expected the watch to start from revision %d, got %d", rev, r)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the watch to start from revision %d", rev)
+		}
+	}
+	expectEvents := func(wch WatchChan, revs ...int64) {
+		t.Helper()
+		var got []int64
+		for len(got) < len(revs) {
+			select {
+			case wr := <-wch:
+				if wr.Err() != nil {
+					t.Fatalf("unexpected error %v", wr.Err())
+				}
+				for _, ev := range wr.Events {
+					got = append(got, ev.Kv.ModRevision)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected events %v, got %v", revs, got)
+			}
+		}
+		if !reflect.DeepEqual(got, revs) {
+			t.Fatalf("expected events %v, got %v", revs, got)
+		}
+	}
+
+	wch1 := make(chan WatchResponse, 10)
+	wch1 <- WatchResponse{Header: pb.ResponseHeader{Revision: 4}, Created: true}
+	fw.wchs <- wch1
+	wch := wc.Watch(context.Background(), "foo")
+	expectRev(0)
+	wch1 <- putResponse(5)
+	wch1 <- putResponse(6, 6)
+	expectEvents(wch, 5, 6, 6)
+
+	// the watch stream is lost, the watch resumes after the last event
+	wch2 := make(chan WatchResponse, 10)
+	fw.wchs <- wch2
+	wch1 <- WatchResponse{Canceled: true, closeErr: status.Error(codes.Unavailable, "etcdserver: no leader")}
+	close(wch1)
+	expectRev(7)
+	wch2 <- WatchResponse{Header: pb.ResponseHeader{Revision: 8}, Created: true}
+	wch2 <- putResponse(7, 8)
+	expectEvents(wch, 7, 8)
+
+	// the events of the revisions already sent are skipped
+	wch3 := make(chan WatchResponse, 10)
+	fw.wchs <- wch3
+	close(wch2)
+	expectRev(9)
+	wch3 <- WatchResponse{Header: pb.ResponseHeader{Revision: 9}, Created: true}
+	wch3 <- putResponse(8, 9)
+	expectEvents(wch, 9)
+
+	// the compacted events are surfaced, then the watch carries on
+	wch4 := make(chan WatchResponse, 10)
+	fw.wchs <- wch4
+	wch3 <- WatchResponse{Header: pb.ResponseHeader{Revision: 12}, Canceled: true, CompactRevision: 11}
+	close(wch3)
+	select {
+	case wr := <-wch:
+		if wr.Err() != rpctypes.ErrCompacted || wr.CompactRevision != 11 {
+			t.Fatalf("expected compaction at revision 11, got %+v", wr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected compaction")
+	}
+	expectRev(11)
+	wch4 <- WatchResponse{Header: pb.ResponseHeader{Revision: 12}, Created: true}
+	wch4 <- putResponse(11)
+	expectEvents(wch, 11)
+
+	// an unrecoverable error cancels the watch
+	wch4 <- WatchResponse{Header: pb.ResponseHeader{Revision: 12}, Canceled: true, cancelReason: "permission denied"}
+	close(wch4)
+	select {
+	case wr := <-wch:
+		if !wr.Canceled || wr.Err() == nil {
+			t.Fatalf("expected the watch to be canceled, got %+v", wr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watch to be canceled")
+	}
+	if _, ok := <-wch; ok {
+		t.Fatal("expected the watch channel to be closed")
+	}
+}
+
+func TestWatchCacheBuffer(t *testing.T) {
+	fw := &fakeWatcher{wchs: make(chan chan WatchResponse, 1), revs: make(chan int64, 1)}
+	wc := NewWatchCache(fw, 2)
+	defer wc.Close()
+
+	wch1 := make(chan WatchResponse, 10)
+	wch1 <- WatchResponse{Header: pb.ResponseHeader{Revision: 1}, Created: true}
+	fw.wchs <- wch1
+	wch := wc.Watch(context.Background(), "foo", WithRev(2))
+	if rev := <-fw.revs; rev != 2 {
+		t.Fatalf("expected the watch to start from revision 2, got %d", rev)
+	}
+	for rev := int64(2); rev < 6; rev++ {
+		wch1 <- putResponse(rev)
+	}
+
+	// the cache reads ahead of the receiver, up to its size
+	time.Sleep(100 * time.Millisecond)
+	if len(wch1) != 2 {
+		t.Fatalf("expected 2 responses left unread, got %d", len(wch1))
+	}
+	// the buffered events are not lost with the watch stream
+	fw.wchs <- make(chan WatchResponse)
+	close(wch1)
+	for rev := int64(2); rev < 6; rev++ {
+		wr := <-wch
+		if len(wr.Events) != 1 || wr.Events[0].Kv.ModRevision != rev {
+			t.Fatalf("expected event at revision %d, got %+v", rev, wr)
+		}
+	}
+	if rev := <-fw.revs; rev != 6 {
+		t.Fatalf("expected the watch to resume from revision 6, got %d", rev)
+	}
+}
@@ -17,7 +17,9 @@ package clientv3
 import (
 	"testing"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 )
 
 func TestEvent(t *testing.T) {
@@ -53,3 +55,31 @@ func TestEvent(t *testing.T) {
 		}
 	}
 }
+
+func TestWatchShouldReauth(t *testing.T) {
+	tests := []struct {
+		name     string
+		reauth   bool
+		reauthed bool
+		resp     *pb.WatchResponse
+		want     bool
+	}{
+		{"invalid auth token", true, false, &pb.WatchResponse{Created: true, Canceled: true, CancelReason: rpctypes.ErrGRPCInvalidAuthToken.Error()}, true},
+		{"old auth revision", true, false, &pb.WatchResponse{Created: true, Canceled: true, CancelReason: rpctypes.ErrGRPCAuthOldRevision.Error()}, true},
+		{"permission denied", true, false, &pb.WatchResponse{Created: true, Canceled: true, CancelReason: rpctypes.ErrGRPCPermissionDenied.Error()}, false},
+		{"already reauthed", true, true, &pb.WatchResponse{Created: true, Canceled: true, CancelReason: rpctypes.ErrGRPCInvalidAuthToken.Error()}, false},
+		{"no authentication", false, false, &pb.WatchResponse{Created: true, Canceled: true, CancelReason: rpctypes.ErrGRPCInvalidAuthToken.Error()}, false},
+		{"created", true, false, &pb.WatchResponse{Created: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &watchGrpcStream{
+				owner:    &watcher{reauth: tt.reauth},
+				resuming: []*watcherStream{{reauthed: tt.reauthed}},
+			}
+			if got := w.shouldReauth(tt.resp); got != tt.want {
+				t.Errorf("shouldReauth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
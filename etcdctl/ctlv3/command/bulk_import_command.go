@@ -0,0 +1,108 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	bulkImportRev       int64
+	bulkImportBatchSize int
+)
+
+// NewBulkImportCommand returns the cobra command for "bulk-import".
+func NewBulkImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk-import [options] [filename]",
+		Short: "Imports key-value pairs into a single member cluster",
+		Long: `Imports key-value pairs into a single member cluster, bypassing raft.
+
+The key-value pairs are read from the given file, or from the standard input if
+no file or "-" is given. Each line holds a JSON object with the base64 encoded
+key and value of a pair, as found in the kvs of 'get -w json':
+
+	{"key":"Zm9v","value":"YmFy"}
+
+The keys must be sorted and unique. All the pairs are written at a single
+revision, and none of them are if the import fails.
+`,
+		Run: bulkImportCommandFunc,
+	}
+	cmd.Flags().Int64Var(&bulkImportRev, "revision", 0, "revision to import the keys at, defaults to the revision following the current one")
+	cmd.Flags().IntVar(&bulkImportBatchSize, "batch-size", 1000, "number of key-value pairs sent per request")
+	return cmd
+}
+
+// bulkImportCommandFunc executes the "bulk-import" command.
+func bulkImportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bulk-import command needs at most 1 argument"))
+	}
+	if bulkImportBatchSize <= 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("--batch-size must be positive"))
+	}
+
+	var in io.Reader = os.Stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitIO, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	next := func() ([]*mvccpb.KeyValue, error) {
+		var kvs []*mvccpb.KeyValue
+		for len(kvs) < bulkImportBatchSize && sc.Scan() {
+			line++
+			if len(sc.Bytes()) == 0 {
+				continue
+			}
+			kv := &mvccpb.KeyValue{}
+			if err := json.Unmarshal(sc.Bytes(), kv); err != nil {
+				return nil, fmt.Errorf("invalid key-value pair on line %d: %v", line, err)
+			}
+			kvs = append(kvs, kv)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		if len(kvs) == 0 {
+			return nil, io.EOF
+		}
+		return kvs, nil
+	}
+
+	// the import may take far longer than the command timeout
+	resp, err := mustClientFromCmd(cmd).BulkImport(context.Background(), bulkImportRev, next)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.BulkImport(*resp)
+}
@@ -20,9 +20,12 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
+var defragProgress bool
+
 // NewDefragCommand returns the cobra command for "Defrag".
 func NewDefragCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -31,6 +34,7 @@ func NewDefragCommand() *cobra.Command {
 		Run:   defragCommandFunc,
 	}
 	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().BoolVar(&defragProgress, "progress", false, "print the progress of the defragmentation of each member to stderr")
 	return cmd
 }
 
@@ -41,7 +45,14 @@ func defragCommandFunc(cmd *cobra.Command, args []string) {
 	for _, ep := range endpointsFromCluster(cmd) {
 		ctx, cancel := commandCtx(cmd)
 		start := time.Now()
-		_, err := c.Defragment(ctx, ep)
+		var err error
+		if defragProgress {
+			_, err = c.DefragmentWithProgress(ctx, ep, func(p *clientv3.DefragmentStreamResponse) {
+				fmt.Fprintf(os.Stderr, "Defragmenting etcd member[%s]: copied %d/%d keys, recopied %d keys written meanwhile\n", ep, p.CopiedKeys, p.TotalKeys, p.RecopiedKeys)
+			})
+		} else {
+			_, err = c.Defragment(ctx, ep)
+		}
 		d := time.Now().Sub(start)
 		cancel()
 		if err != nil {
@@ -0,0 +1,237 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+// diffPageSize is the number of keys read per request by diff.
+const diffPageSize = 1000
+
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+var (
+	diffRevs       []int64
+	diffEndpoints2 []string
+	diffPrefix     bool
+	diffFromKey    bool
+)
+
+// keyDiff is a difference between the key-values of the two sides of a diff.
+type keyDiff struct {
+	Type string           `json:"type"`
+	Old  *mvccpb.KeyValue `json:"old,omitempty"`
+	New  *mvccpb.KeyValue `json:"new,omitempty"`
+}
+
+// Key returns the key which differs.
+func (d keyDiff) Key() []byte {
+	if d.New != nil {
+		return d.New.Key
+	}
+	return d.Old.Key
+}
+
+// NewDiffCommand returns the cobra command for "diff".
+func NewDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [options] [key] [range_end]",
+		Short: "Shows the keys which differ between two revisions or two clusters",
+		Long: `Shows the keys added, removed or changed between two revisions of the cluster,
+or between the cluster and a second one, such as a mirror or a restored
+cluster. All the keys are compared if no key is given.
+
+The first --rev is the revision of the first side, and the second --rev the one
+of the second side; the current revision is compared if none is given. The
+second cluster is connected to with the same TLS and authentication options.
+
+Exits with a non-zero status if any key differs.
+`,
+		Run: diffCommandFunc,
+	}
+	cmd.Flags().Int64SliceVar(&diffRevs, "rev", nil, "revision of the first side, then of the second side, defaulting to the current one")
+	cmd.Flags().StringSliceVar(&diffEndpoints2, "endpoints2", nil, "gRPC endpoints of the second cluster, if comparing two clusters")
+	cmd.Flags().BoolVar(&diffPrefix, "prefix", false, "compare keys with matching prefix")
+	cmd.Flags().BoolVar(&diffFromKey, "from-key", false, "compare keys that are greater than or equal to the given key using byte compare")
+	return cmd
+}
+
+// diffCommandFunc executes the "diff" command.
+func diffCommandFunc(cmd *cobra.Command, args []string) {
+	key, end, err := diffRange(args)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if len(diffRevs) > 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("--rev can be given at most twice"))
+	}
+	if len(diffEndpoints2) == 0 && len(diffRevs) != 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("two --rev are needed without --endpoints2"))
+	}
+	revs := make([]int64, 2)
+	copy(revs, diffRevs)
+
+	c := mustClientFromCmd(cmd)
+	c2 := c
+	if len(diffEndpoints2) != 0 {
+		cfg := clientConfigFromCmd(cmd)
+		cfg.Endpoints = diffEndpoints2
+		c2 = mustClient(cfg)
+		defer c2.Close()
+	}
+
+	diffs, err := diffKVs(
+		&rangeIterator{cmd: cmd, kv: c, key: key, end: end, rev: revs[0]},
+		&rangeIterator{cmd: cmd, kv: c2, key: key, end: end, rev: revs[1]},
+	)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.Diff(diffs)
+	if len(diffs) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("%d keys differ", len(diffs)))
+	}
+}
+
+// diffRange returns the range of keys to compare, following the flags of get.
+func diffRange(args []string) (key, end string, err error) {
+	if diffPrefix && diffFromKey {
+		return "", "", fmt.Errorf("`--prefix` and `--from-key` cannot be set at the same time, choose one")
+	}
+	switch len(args) {
+	case 0:
+		return "\x00", "\x00", nil
+	case 2:
+		if diffPrefix || diffFromKey {
+			return "", "", fmt.Errorf("too many arguments, only accept one argument when `--prefix` or `--from-key` is set")
+		}
+		return args[0], args[1], nil
+	case 1:
+	default:
+		return "", "", fmt.Errorf("diff command accepts at most a key and a range_end")
+	}
+
+	key = args[0]
+	switch {
+	case diffPrefix && key == "", diffFromKey && key == "":
+		return "\x00", "\x00", nil
+	case diffPrefix:
+		return key, clientv3.GetPrefixRangeEnd(key), nil
+	case diffFromKey:
+		return key, "\x00", nil
+	}
+	// a single key
+	return key, key + "\x00", nil
+}
+
+// diffKVs returns the differences between the key-values of the old and of the
+// new side, in key order.
+func diffKVs(old, new kvIterator) ([]keyDiff, error) {
+	var diffs []keyDiff
+	o, err := old.next()
+	if err != nil {
+		return nil, err
+	}
+	n, err := new.next()
+	if err != nil {
+		return nil, err
+	}
+	for o != nil || n != nil {
+		var cmp int
+		switch {
+		case o == nil:
+			cmp = 1
+		case n == nil:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(o.Key, n.Key)
+		}
+
+		switch {
+		case cmp < 0:
+			diffs = append(diffs, keyDiff{Type: diffRemoved, Old: o})
+		case cmp > 0:
+			diffs = append(diffs, keyDiff{Type: diffAdded, New: n})
+		case !bytes.Equal(o.Value, n.Value):
+			diffs = append(diffs, keyDiff{Type: diffChanged, Old: o, New: n})
+		}
+
+		if cmp <= 0 {
+			if o, err = old.next(); err != nil {
+				return nil, err
+			}
+		}
+		if cmp >= 0 {
+			if n, err = new.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// kvIterator iterates over key-values in key order. next returns nil after
+// the last one.
+type kvIterator interface {
+	next() (*mvccpb.KeyValue, error)
+}
+
+// rangeIterator iterates over the key-values of a range at a revision, read
+// by pages.
+type rangeIterator struct {
+	cmd      *cobra.Command
+	kv       clientv3.KV
+	key, end string
+	// rev is the revision read at, the one of the first page if 0
+	rev int64
+
+	kvs  []*mvccpb.KeyValue
+	done bool
+}
+
+func (it *rangeIterator) next() (*mvccpb.KeyValue, error) {
+	if len(it.kvs) == 0 && !it.done {
+		ctx, cancel := commandCtx(it.cmd)
+		resp, err := it.kv.Get(ctx, it.key, clientv3.WithRange(it.end), clientv3.WithRev(it.rev), clientv3.WithLimit(diffPageSize))
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if it.rev == 0 {
+			it.rev = resp.Header.Revision
+		}
+		it.kvs, it.done = resp.Kvs, !resp.More
+		if len(it.kvs) != 0 {
+			it.key = string(it.kvs[len(it.kvs)-1].Key) + "\x00"
+		}
+	}
+	if len(it.kvs) == 0 {
+		return nil, nil
+	}
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+type sliceIterator []*mvccpb.KeyValue
+
+func (it *sliceIterator) next() (*mvccpb.KeyValue, error) {
+	if len(*it) == 0 {
+		return nil, nil
+	}
+	kv := (*it)[0]
+	*it = (*it)[1:]
+	return kv, nil
+}
+
+func kvs(pairs ...string) *sliceIterator {
+	var it sliceIterator
+	for i := 0; i < len(pairs); i += 2 {
+		it = append(it, &mvccpb.KeyValue{Key: []byte(pairs[i]), Value: []byte(pairs[i+1])})
+	}
+	return &it
+}
+
+func Test_diffKVs(t *testing.T) {
+	tests := []struct {
+		old, new *sliceIterator
+		want     []string
+	}{
+		{old: kvs(), new: kvs(), want: nil},
+		{old: kvs("a", "1", "b", "2"), new: kvs("a", "1", "b", "2"), want: nil},
+		{old: kvs(), new: kvs("a", "1"), want: []string{"added a"}},
+		{old: kvs("a", "1"), new: kvs(), want: []string{"removed a"}},
+		{
+			old:  kvs("a", "1", "b", "2", "d", "4", "e", "5"),
+			new:  kvs("b", "2", "c", "3", "d", "x", "f", "6"),
+			want: []string{"removed a", "added c", "changed d", "removed e", "added f"},
+		},
+	}
+	for i, tt := range tests {
+		diffs, err := diffKVs(tt.old, tt.new)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diffs {
+			got = append(got, fmt.Sprintf("%s %s", d.Type, d.Key()))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: diffs = %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+func Test_diffRange(t *testing.T) {
+	defer func() { diffPrefix, diffFromKey = false, false }()
+	tests := []struct {
+		args             []string
+		prefix, fromKey  bool
+		wantKey, wantEnd string
+		wantErr          bool
+	}{
+		{args: nil, wantKey: "\x00", wantEnd: "\x00"},
+		{args: []string{"a"}, wantKey: "a", wantEnd: "a\x00"},
+		{args: []string{"a", "c"}, wantKey: "a", wantEnd: "c"},
+		{args: []string{"a"}, prefix: true, wantKey: "a", wantEnd: "b"},
+		{args: []string{""}, prefix: true, wantKey: "\x00", wantEnd: "\x00"},
+		{args: []string{"a"}, fromKey: true, wantKey: "a", wantEnd: "\x00"},
+		{args: []string{"a", "c"}, prefix: true, wantErr: true},
+		{args: []string{"a"}, prefix: true, fromKey: true, wantErr: true},
+	}
+	for i, tt := range tests {
+		diffPrefix, diffFromKey = tt.prefix, tt.fromKey
+		key, end, err := diffRange(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("#%d: err = %v, want error %v", i, err, tt.wantErr)
+		}
+		if err == nil && (key != tt.wantKey || end != tt.wantEnd) {
+			t.Errorf("#%d: range = [%q, %q), want [%q, %q)", i, key, end, tt.wantKey, tt.wantEnd)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var fragmentationMinReclaimableRatio float64
+
+// NewFragmentationCommand returns the cobra command for "fragmentation".
+func NewFragmentationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fragmentation [options]",
+		Short: "Estimates the space the defragmentation of the etcd members with given endpoints would reclaim",
+		Long: `Reports how the pages of the backend of the etcd members with given endpoints
+are used, estimates the size of their backend once defragmented, and whether
+the space it would reclaim is worth the defragmentation.
+
+The defragmentation is recommended when it would reclaim at least the given
+ratio of the backend size. The report walks the whole backend of the members,
+which may take a while on large backends.
+`,
+		Run: fragmentationCommandFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().Float64Var(&fragmentationMinReclaimableRatio, "min-reclaimable-ratio", 0.25, "Ratio of the backend size the defragmentation must reclaim to be recommended")
+	return cmd
+}
+
+// fragmentationCommandFunc executes the "fragmentation" command.
+func fragmentationCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("fragmentation command does not take arguments"))
+	}
+
+	failures := 0
+	c := mustClientFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.Fragmentation(ctx, ep, fragmentationMinReclaimableRatio)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get the fragmentation of etcd member[%s] (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		display.Fragmentation(ep, *resp)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
@@ -71,13 +72,23 @@ func NewGetCommand() *cobra.Command {
 // getCommandFunc executes the "get" command.
 func getCommandFunc(cmd *cobra.Command, args []string) {
 	key, opts := getGetOp(args)
+	c := mustClientFromCmd(cmd)
 	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).Get(ctx, key, opts...)
+	resp, err := c.Get(ctx, key, opts...)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
 
+	if ep, export := display.(*exportPrinter); export {
+		if getKeysOnly || getCountOnly {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--write-out=export` cannot be set together with `--keys-only` or `--count-only`"))
+		}
+		if ep.ttls, err = leaseTTLs(cmd, c, resp.Kvs); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+	}
+
 	if getCountOnly {
 		if _, fields := display.(*fieldsPrinter); !fields {
 			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("--count-only is only for `--write-out=fields`"))
@@ -94,6 +105,31 @@ func getCommandFunc(cmd *cobra.Command, args []string) {
 	display.Get(*resp)
 }
 
+// leaseTTLs returns the remaining time-to-live of the leases the given keys are
+// attached to. The leases which expired meanwhile are left out.
+func leaseTTLs(cmd *cobra.Command, c *clientv3.Client, kvs []*mvccpb.KeyValue) (map[clientv3.LeaseID]int64, error) {
+	ttls := make(map[clientv3.LeaseID]int64)
+	for _, kv := range kvs {
+		id := clientv3.LeaseID(kv.Lease)
+		if _, ok := ttls[id]; ok || id == clientv3.NoLease {
+			continue
+		}
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.TimeToLive(ctx, id)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		ttls[id] = resp.TTL
+	}
+	for id, ttl := range ttls {
+		if ttl <= 0 {
+			delete(ttls, id)
+		}
+	}
+	return ttls, nil
+}
+
 func getGetOp(args []string) (string, []clientv3.OpOption) {
 	if len(args) == 0 {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("get command needs one argument as key and an optional argument as range_end"))
@@ -146,11 +146,27 @@ func mustClientCfgFromCmd(cmd *cobra.Command) *clientv3.Config {
 	return cfg
 }
 
+// sessionClient is the client shared by the commands run by a repl session.
+var sessionClient *clientv3.Client
+
 func mustClientFromCmd(cmd *cobra.Command) *clientv3.Client {
+	if sessionClient != nil {
+		initDisplayFromCmd(cmd)
+		return sessionClient
+	}
 	cfg := clientConfigFromCmd(cmd)
 	return mustClient(cfg)
 }
 
+// closeClient closes the given client, unless it is the one of the repl
+// session.
+func closeClient(c *clientv3.Client) error {
+	if c == sessionClient {
+		return nil
+	}
+	return c.Close()
+}
+
 func mustClient(cc *clientv3.ConfigSpec) *clientv3.Client {
 	lg, _ := logutil.CreateDefaultZapLogger(zap.InfoLevel)
 	cfg, err := clientv3.NewClientConfig(cc, lg)
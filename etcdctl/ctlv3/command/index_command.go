@@ -0,0 +1,203 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	indexGetConsistency string
+	indexGetLimit       int64
+	indexGetPrefix      bool
+	indexGetFromKey     bool
+	indexGetKeysOnly    bool
+	indexGetCountOnly   bool
+)
+
+// NewIndexCommand returns the cobra command for "index".
+func NewIndexCommand() *cobra.Command {
+	ic := &cobra.Command{
+		Use:   "index <subcommand>",
+		Short: "Secondary index related commands",
+	}
+
+	ic.AddCommand(newIndexPutCommand())
+	ic.AddCommand(newIndexDeleteCommand())
+	ic.AddCommand(newIndexListCommand())
+	ic.AddCommand(newIndexGetCommand())
+
+	return ic
+}
+
+func newIndexPutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "put <name> <prefix> <field>",
+		Short: "Registers an index of a field of the JSON values of the keys with a prefix, or replaces it",
+		Long: `Registers an index of a field of the JSON values of the keys with a prefix, or
+replaces the index with the same name. The field is the path of its names
+separated by dots, e.g. "spec.owner". The keys whose field is not a string, a
+number or a boolean are not indexed.`,
+		Run: indexPutCommandFunc,
+	}
+}
+
+func newIndexDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Unregisters an index",
+		Run:   indexDeleteCommandFunc,
+	}
+}
+
+func newIndexListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists the indexes with the number of keys they index",
+		Run:   indexListCommandFunc,
+	}
+}
+
+func newIndexGetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get [options] <name> <value> [range_end]",
+		Short: "Gets the keys with an indexed value, or with the indexed values in a range",
+		Run:   indexGetCommandFunc,
+	}
+	cmd.Flags().StringVar(&indexGetConsistency, "consistency", "l", "Linearizable(l) or Serializable(s)")
+	cmd.Flags().Int64Var(&indexGetLimit, "limit", 0, "Maximum number of results")
+	cmd.Flags().BoolVar(&indexGetPrefix, "prefix", false, "Get the keys with the indexed values with a matching prefix")
+	cmd.Flags().BoolVar(&indexGetFromKey, "from-key", false, "Get the keys with the indexed values greater than or equal to the given value")
+	cmd.Flags().BoolVar(&indexGetKeysOnly, "keys-only", false, "Get only the keys")
+	cmd.Flags().BoolVar(&indexGetCountOnly, "count-only", false, "Get only the count")
+	return cmd
+}
+
+// indexPutCommandFunc executes the "index put" command.
+func indexPutCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("index put command requires the name, the prefix and the field as its arguments"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).IndexPut(ctx, clientv3.Index{
+		Name:   args[0],
+		Prefix: []byte(args[1]),
+		Field:  args[2],
+	})
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.IndexPut(args[0], *resp)
+}
+
+// indexDeleteCommandFunc executes the "index delete" command.
+func indexDeleteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("index delete command requires the name as its argument"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).IndexDelete(ctx, args[0])
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.IndexDelete(args[0], *resp)
+}
+
+// indexListCommandFunc executes the "index list" command.
+func indexListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("index list command takes no arguments"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).IndexList(ctx)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.IndexList(*resp)
+}
+
+// indexGetCommandFunc executes the "index get" command. The keys are printed
+// as by the "get" command.
+func indexGetCommandFunc(cmd *cobra.Command, args []string) {
+	name, value, opts := getIndexGetOp(args)
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).IndexRange(ctx, name, value, opts...)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	if indexGetCountOnly {
+		if _, fields := display.(*fieldsPrinter); !fields {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("--count-only is only for `--write-out=fields`"))
+		}
+	}
+	display.Get(clientv3.GetResponse{Header: resp.Header, Kvs: resp.Kvs, More: resp.More, Count: resp.Count})
+}
+
+func getIndexGetOp(args []string) (string, string, []clientv3.OpOption) {
+	if len(args) < 2 || len(args) > 3 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("index get command needs the name of the index and a value as arguments, and an optional argument as range_end"))
+	}
+	if indexGetPrefix && indexGetFromKey {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--prefix` and `--from-key` cannot be set at the same time, choose one"))
+	}
+	if indexGetKeysOnly && indexGetCountOnly {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--keys-only` and `--count-only` cannot be set at the same time, choose one"))
+	}
+
+	opts := []clientv3.OpOption{clientv3.WithLimit(indexGetLimit)}
+	switch indexGetConsistency {
+	case "s":
+		opts = append(opts, clientv3.WithSerializable())
+	case "l":
+	default:
+		cobrautl.ExitWithError(cobrautl.ExitBadFeature, fmt.Errorf("unknown consistency flag %q", indexGetConsistency))
+	}
+
+	if len(args) > 2 {
+		if indexGetPrefix || indexGetFromKey {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("too many arguments, only accept two arguments when `--prefix` or `--from-key` is set"))
+		}
+		opts = append(opts, clientv3.WithRange(args[2]))
+	}
+	if indexGetPrefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	if indexGetFromKey {
+		opts = append(opts, clientv3.WithFromKey())
+	}
+	if indexGetKeysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	if indexGetCountOnly {
+		opts = append(opts, clientv3.WithCountOnly())
+	}
+	return args[0], args[1], opts
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	keyHistogramPrefix    bool
+	keyHistogramFromKey   bool
+	keyHistogramSeparator string
+	keyHistogramDepth     int64
+	keyHistogramRev       int64
+	keyHistogramLimit     int64
+)
+
+// NewKeyHistogramCommand returns the cobra command for "key-histogram".
+func NewKeyHistogramCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key-histogram [options] [key] [range_end]",
+		Short: "Counts the keys and their value bytes grouped by prefix",
+		Long: `Counts the keys and their value bytes grouped by prefix.
+
+The keys are grouped by their prefix ending with the depth-th separator. Keys
+with fewer separators are counted on their own. All the keys are counted if no
+key is given. The keys are read from the first endpoint.
+`,
+		Run: keyHistogramCommandFunc,
+	}
+	cmd.Flags().BoolVar(&keyHistogramPrefix, "prefix", false, "Count keys with matching prefix")
+	cmd.Flags().BoolVar(&keyHistogramFromKey, "from-key", false, "Count keys that are greater than or equal to the given key using byte compare")
+	cmd.Flags().StringVar(&keyHistogramSeparator, "separator", "/", "Separator of the key components")
+	cmd.Flags().Int64Var(&keyHistogramDepth, "depth", 1, "Number of separators the prefixes end with")
+	cmd.Flags().Int64Var(&keyHistogramRev, "rev", 0, "Specify the kv revision")
+	cmd.Flags().Int64Var(&keyHistogramLimit, "limit", 0, "Maximum number of prefixes, keeping those with the most keys")
+	return cmd
+}
+
+// keyHistogramCommandFunc executes the "key-histogram" command.
+func keyHistogramCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("key-histogram command needs an optional key and an optional range_end"))
+	}
+	if keyHistogramPrefix && keyHistogramFromKey {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--prefix` and `--from-key` cannot be set at the same time, choose one"))
+	}
+
+	var key string
+	var opts []clientv3.OpOption
+	if len(args) > 0 {
+		key = args[0]
+	}
+	if len(args) > 1 {
+		if keyHistogramPrefix || keyHistogramFromKey {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("too many arguments, only accept one argument when `--prefix` or `--from-key` is set"))
+		}
+		opts = append(opts, clientv3.WithRange(args[1]))
+	}
+	if keyHistogramPrefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	if keyHistogramFromKey {
+		opts = append(opts, clientv3.WithFromKey())
+	}
+	if keyHistogramRev > 0 {
+		opts = append(opts, clientv3.WithRev(keyHistogramRev))
+	}
+	opts = append(opts, clientv3.WithLimit(keyHistogramLimit))
+
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	resp, err := c.KeyHistogram(ctx, c.Endpoints()[0], key, keyHistogramSeparator, keyHistogramDepth, opts...)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.KeyHistogram(*resp)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	v3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
@@ -44,15 +45,19 @@ func NewLeaseCommand() *cobra.Command {
 // NewLeaseGrantCommand returns the cobra command for "lease grant".
 func NewLeaseGrantCommand() *cobra.Command {
 	lc := &cobra.Command{
-		Use:   "grant <ttl>",
+		Use:   "grant [options] <ttl>",
 		Short: "Creates leases",
 
 		Run: leaseGrantCommandFunc,
 	}
 
+	lc.Flags().StringArrayVar(&leaseGrantLabels, "label", nil, "Attaches a label to the lease, in the form of key=value (can be repeated)")
+
 	return lc
 }
 
+var leaseGrantLabels []string
+
 // leaseGrantCommandFunc executes the "lease grant" command.
 func leaseGrantCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
@@ -64,8 +69,21 @@ func leaseGrantCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad TTL (%v)", err))
 	}
 
+	var opts []v3.LeaseOption
+	if len(leaseGrantLabels) > 0 {
+		labels := make(map[string]string, len(leaseGrantLabels))
+		for _, l := range leaseGrantLabels {
+			kv := strings.SplitN(l, "=", 2)
+			if len(kv) != 2 || len(kv[0]) == 0 {
+				cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad label %q, expected key=value", l))
+			}
+			labels[kv[0]] = kv[1]
+		}
+		opts = append(opts, v3.WithLabels(labels))
+	}
+
 	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl)
+	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl, opts...)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to grant lease (%v)", err))
@@ -0,0 +1,75 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var logLevelDefault string
+
+// NewLogLevelCommand returns the cobra command for "log-level".
+func NewLogLevelCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log-level [options] [<module>=<level>...]",
+		Short: "Gets or sets the log levels of the etcd members with given endpoints",
+		Long: `Gets or sets the log levels of the etcd members with given endpoints at runtime.
+
+The modules are raft, mvcc, auth, lessor and grpc. The levels are debug, info,
+warn, error, dpanic, panic and fatal. A module given without a level, e.g.
+"raft=", logs at the default level again. The levels are reset to the
+configured level when the members restart.
+`,
+		Run: logLevelCommandFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().StringVar(&logLevelDefault, "default-level", "", "Level of the modules without their own level")
+	return cmd
+}
+
+// logLevelCommandFunc executes the "log-level" command.
+func logLevelCommandFunc(cmd *cobra.Command, args []string) {
+	levels := make(map[string]string, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("invalid module level %q, expected <module>=<level>", arg))
+		}
+		levels[kv[0]] = kv[1]
+	}
+
+	failures := 0
+	c := mustClientFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.LogLevel(ctx, ep, logLevelDefault, levels)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set the log levels of etcd member[%s] (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		display.LogLevel(ep, *resp)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
@@ -44,6 +44,18 @@ var (
 	mmpassword     string
 	mmnodestprefix bool
 	mmrev          int64
+
+	mmbidirectional  bool
+	mmconflictpolicy string
+	mmsourceowns     []string
+	mmdestowns       []string
+	mmexclude        []string
+	mmcheckpoint     string
+)
+
+const (
+	conflictLastWriterWins  = "last-writer-wins"
+	conflictPrefixOwnership = "prefix-ownership"
 )
 
 // NewMakeMirrorCommand returns the cobra command for "makeMirror".
@@ -51,13 +63,34 @@ func NewMakeMirrorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "make-mirror [options] <destination>",
 		Short: "Makes a mirror at the destination etcd cluster",
-		Run:   makeMirrorCommandFunc,
+		Long: `Makes a mirror at the destination etcd cluster.
+
+With --bidirectional, the changes of the destination are mirrored back to the
+source as well, for active/active clusters. The keys of both clusters are merged
+first, unless resuming from a checkpoint. A key changed on both clusters before
+either change is mirrored is a conflict, resolved by --conflict-policy:
+
+  last-writer-wins  the change of the higher mod revision wins, the source winning ties
+  prefix-ownership  the change of the cluster owning the longest prefix of the key
+                    in --source-owns and --dest-owns wins, falling back to
+                    last-writer-wins for the other keys
+
+With --checkpoint-file, the progress of the mirror is saved to the file, and the
+mirror resumes from it when restarted.
+`,
+		Run: makeMirrorCommandFunc,
 	}
 
 	c.Flags().StringVar(&mmprefix, "prefix", "", "Key-value prefix to mirror")
 	c.Flags().Int64Var(&mmrev, "rev", 0, "Specify the kv revision to start to mirror")
 	c.Flags().StringVar(&mmdestprefix, "dest-prefix", "", "destination prefix to mirror a prefix to a different prefix in the destination cluster")
 	c.Flags().BoolVar(&mmnodestprefix, "no-dest-prefix", false, "mirror key-values to the root of the destination cluster")
+	c.Flags().BoolVar(&mmbidirectional, "bidirectional", false, "mirror the changes of the destination cluster back to the source cluster as well")
+	c.Flags().StringVar(&mmconflictpolicy, "conflict-policy", conflictLastWriterWins, "policy resolving the keys changed on both clusters with --bidirectional, one of last-writer-wins or prefix-ownership")
+	c.Flags().StringSliceVar(&mmsourceowns, "source-owns", nil, "prefixes, relative to the mirrored prefix, of the keys whose conflicts the source cluster wins with prefix-ownership")
+	c.Flags().StringSliceVar(&mmdestowns, "dest-owns", nil, "prefixes, relative to the mirrored prefix, of the keys whose conflicts the destination cluster wins with prefix-ownership")
+	c.Flags().StringSliceVar(&mmexclude, "exclude-prefix", nil, "prefixes, relative to the mirrored prefix, of the keys not to mirror")
+	c.Flags().StringVar(&mmcheckpoint, "checkpoint-file", "", "file saving the progress of the mirror, to resume from when restarted")
 	c.Flags().StringVar(&mmcert, "dest-cert", "", "Identify secure client using this TLS certificate file for the destination cluster")
 	c.Flags().StringVar(&mmkey, "dest-key", "", "Identify secure client using this TLS key file")
 	c.Flags().StringVar(&mmcacert, "dest-cacert", "", "Verify certificates of TLS enabled secure servers using this CA bundle")
@@ -137,6 +170,23 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("`--dest-prefix` and `--no-dest-prefix` cannot be set at the same time, choose one"))
 	}
 
+	// if remove destination prefix is false and destination prefix is empty set the value of destination prefix same as prefix
+	if !mmnodestprefix && len(mmdestprefix) == 0 {
+		mmdestprefix = mmprefix
+	}
+
+	var cp mirror.Checkpoint
+	if mmcheckpoint != "" {
+		var err error
+		if cp, err = mirror.ReadCheckpoint(mmcheckpoint); err != nil {
+			return err
+		}
+	}
+
+	if mmbidirectional {
+		return makeBidirectionalMirror(ctx, c, dc, cp)
+	}
+
 	go func() {
 		for {
 			time.Sleep(30 * time.Second)
@@ -148,21 +198,25 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 	if startRev < 0 {
 		startRev = 0
 	}
-
-	s := mirror.NewSyncer(c, mmprefix, startRev)
+	if cp.SourceRev != 0 {
+		startRev = cp.SourceRev
+	}
 
 	// If a rev is provided, then do not sync the whole key space.
 	// Instead, just start watching the key space starting from the rev
 	if startRev == 0 {
-		rc, errc := s.SyncBase(ctx)
-
-		// if remove destination prefix is false and destination prefix is empty set the value of destination prefix same as prefix
-		if !mmnodestprefix && len(mmdestprefix) == 0 {
-			mmdestprefix = mmprefix
+		resp, err := c.Get(ctx, mmprefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			return err
 		}
+		startRev = resp.Header.Revision
 
+		rc, errc := mirror.NewSyncer(c, mmprefix, startRev).SyncBase(ctx)
 		for r := range rc {
 			for _, kv := range r.Kvs {
+				if mirrorExcluded(kv.Key) {
+					continue
+				}
 				_, err := dc.Put(ctx, modifyPrefix(string(kv.Key)), string(kv.Value))
 				if err != nil {
 					return err
@@ -171,13 +225,16 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 			}
 		}
 
-		err := <-errc
+		err = <-errc
 		if err != nil {
 			return err
 		}
+		if err = writeMirrorCheckpoint(mirror.Checkpoint{SourceRev: startRev}); err != nil {
+			return err
+		}
 	}
 
-	wc := s.SyncUpdates(ctx)
+	wc := mirror.NewSyncer(c, mmprefix, startRev).SyncUpdates(ctx)
 
 	for wr := range wc {
 		if wr.CompactRevision != 0 {
@@ -189,7 +246,7 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 
 		for _, ev := range wr.Events {
 			nextRev := ev.Kv.ModRevision
-			if lastRev != 0 && nextRev > lastRev {
+			if lastRev != 0 && nextRev > lastRev && len(ops) != 0 {
 				_, err := dc.Txn(ctx).Then(ops...).Commit()
 				if err != nil {
 					return err
@@ -197,6 +254,9 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 				ops = []clientv3.Op{}
 			}
 			lastRev = nextRev
+			if mirrorExcluded(ev.Kv.Key) {
+				continue
+			}
 			switch ev.Type {
 			case mvccpb.PUT:
 				ops = append(ops, clientv3.OpPut(modifyPrefix(string(ev.Kv.Key)), string(ev.Kv.Value)))
@@ -215,11 +275,76 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 				return err
 			}
 		}
+		if lastRev != 0 {
+			if err := writeMirrorCheckpoint(mirror.Checkpoint{SourceRev: lastRev}); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// makeBidirectionalMirror mirrors the changes of both clusters to each other,
+// resuming from cp if it is not zero.
+func makeBidirectionalMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client, cp mirror.Checkpoint) error {
+	if mmrev != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("`--rev` is not supported with `--bidirectional`, use `--checkpoint-file` to resume a mirror"))
+	}
+
+	var policy mirror.ConflictPolicy
+	switch mmconflictpolicy {
+	case conflictLastWriterWins:
+		if len(mmsourceowns) != 0 || len(mmdestowns) != 0 {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("`--source-owns` and `--dest-owns` require `--conflict-policy=prefix-ownership`"))
+		}
+		policy = mirror.LastWriterWins
+	case conflictPrefixOwnership:
+		owners := make(map[string]mirror.Side)
+		for _, prefix := range mmsourceowns {
+			owners[prefix] = mirror.Source
+		}
+		for _, prefix := range mmdestowns {
+			if side, ok := owners[prefix]; ok && side == mirror.Source {
+				cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("prefix %q is owned by both clusters", prefix))
+			}
+			owners[prefix] = mirror.Destination
+		}
+		policy = mirror.PrefixOwnership(owners, mirror.LastWriterWins)
+	default:
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("unknown conflict policy %q", mmconflictpolicy))
+	}
+
+	return mirror.SyncBidirectional(ctx, c, dc, mirror.BidirectionalConfig{
+		Prefix:          mmprefix,
+		DestPrefix:      mmdestprefix,
+		ExcludePrefixes: mmexclude,
+		Policy:          policy,
+		Checkpoint:      cp,
+		OnCheckpoint:    writeMirrorCheckpoint,
+	})
+}
+
+// mirrorExcluded returns whether the key of the source cluster is excluded
+// from the mirror by --exclude-prefix.
+func mirrorExcluded(key []byte) bool {
+	rel := strings.TrimPrefix(string(key), mmprefix)
+	for _, prefix := range mmexclude {
+		if strings.HasPrefix(rel, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMirrorCheckpoint saves cp to --checkpoint-file, if set.
+func writeMirrorCheckpoint(cp mirror.Checkpoint) error {
+	if mmcheckpoint == "" {
+		return nil
+	}
+	return mirror.WriteCheckpoint(mmcheckpoint, cp)
+}
+
 func modifyPrefix(key string) string {
 	return strings.Replace(key, mmprefix, mmdestprefix, 1)
 }
@@ -28,6 +28,7 @@ import (
 var (
 	memberPeerURLs string
 	isLearner      bool
+	isWitness      bool
 )
 
 // NewMemberCommand returns the cobra command for "member".
@@ -57,6 +58,7 @@ func NewMemberAddCommand() *cobra.Command {
 
 	cc.Flags().StringVar(&memberPeerURLs, "peer-urls", "", "comma separated peer URLs for the new member.")
 	cc.Flags().BoolVar(&isLearner, "learner", false, "indicates if the new member is raft learner")
+	cc.Flags().BoolVar(&isWitness, "witness", false, "indicates if the new member is a witness, which votes but stores no key-value data")
 
 	return cc
 }
@@ -136,6 +138,10 @@ func memberAddCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("member peer urls not provided"))
 	}
 
+	if isLearner && isWitness {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--learner and --witness cannot be set together"))
+	}
+
 	urls := strings.Split(memberPeerURLs, ",")
 	ctx, cancel := commandCtx(cmd)
 	cli := mustClientFromCmd(cmd)
@@ -143,9 +149,12 @@ func memberAddCommandFunc(cmd *cobra.Command, args []string) {
 		resp *clientv3.MemberAddResponse
 		err  error
 	)
-	if isLearner {
+	switch {
+	case isLearner:
 		resp, err = cli.MemberAddAsLearner(ctx, urls)
-	} else {
+	case isWitness:
+		resp, err = cli.MemberAddAsWitness(ctx, urls)
+	default:
 		resp, err = cli.MemberAdd(ctx, urls)
 	}
 	cancel()
@@ -45,7 +45,7 @@ func transferLeadershipCommandFunc(cmd *cobra.Command, args []string) {
 
 	c := mustClientFromCmd(cmd)
 	eps := c.Endpoints()
-	c.Close()
+	closeClient(c)
 
 	ctx, cancel := commandCtx(cmd)
 
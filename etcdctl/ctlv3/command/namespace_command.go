@@ -0,0 +1,124 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	namespaceQuotaBytes  int64
+	namespaceQuotaKeys   int64
+	namespaceMaxLeaseTTL int64
+)
+
+// NewNamespaceCommand returns the cobra command for "namespace".
+func NewNamespaceCommand() *cobra.Command {
+	nc := &cobra.Command{
+		Use:   "namespace <subcommand>",
+		Short: "Namespace related commands",
+	}
+
+	nc.AddCommand(newNamespacePutCommand())
+	nc.AddCommand(newNamespaceDeleteCommand())
+	nc.AddCommand(newNamespaceListCommand())
+
+	return nc
+}
+
+func newNamespacePutCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "put [options] <prefix>",
+		Short: "Registers a namespace of the keys with a prefix, or updates its limits",
+		Run:   namespacePutCommandFunc,
+	}
+	cmd.Flags().Int64Var(&namespaceQuotaBytes, "quota-bytes", 0, "Maximum total size of the keys and values of the namespace, unlimited if 0")
+	cmd.Flags().Int64Var(&namespaceQuotaKeys, "quota-keys", 0, "Maximum number of keys of the namespace, unlimited if 0")
+	cmd.Flags().Int64Var(&namespaceMaxLeaseTTL, "max-lease-ttl", 0, "Maximum TTL in seconds of the leases attached to the keys of the namespace, unlimited if 0")
+	return cmd
+}
+
+func newNamespaceDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <prefix>",
+		Short: "Unregisters a namespace, leaving its keys in place",
+		Run:   namespaceDeleteCommandFunc,
+	}
+}
+
+func newNamespaceListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists the namespaces with their usage",
+		Run:   namespaceListCommandFunc,
+	}
+}
+
+// namespacePutCommandFunc executes the "namespace put" command.
+func namespacePutCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("namespace put command requires the prefix as its argument"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).NamespacePut(ctx, clientv3.Namespace{
+		Prefix:      []byte(args[0]),
+		QuotaBytes:  namespaceQuotaBytes,
+		QuotaKeys:   namespaceQuotaKeys,
+		MaxLeaseTtl: namespaceMaxLeaseTTL,
+	})
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.NamespacePut(args[0], *resp)
+}
+
+// namespaceDeleteCommandFunc executes the "namespace delete" command.
+func namespaceDeleteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("namespace delete command requires the prefix as its argument"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).NamespaceDelete(ctx, args[0])
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.NamespaceDelete(args[0], *resp)
+}
+
+// namespaceListCommandFunc executes the "namespace list" command.
+func namespaceListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("namespace list command takes no arguments"))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).NamespaceList(ctx)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.NamespaceList(*resp)
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	v3 "go.etcd.io/etcd/client/v3"
@@ -48,6 +49,7 @@ type printer interface {
 	EndpointHealth([]epHealth)
 	EndpointStatus([]epStatus)
 	EndpointHashKV([]epHashKV)
+	Diff([]keyDiff)
 	MoveLeader(leader, target uint64, r v3.MoveLeaderResponse)
 
 	DowngradeValidate(r v3.DowngradeResponse)
@@ -56,6 +58,23 @@ type printer interface {
 
 	Alarm(v3.AlarmResponse)
 
+	BulkImport(v3.BulkImportResponse)
+	KeyHistogram(v3.KeyHistogramResponse)
+	ReadOnly(v3.ReadOnlyResponse)
+
+	NamespacePut(prefix string, r v3.NamespacePutResponse)
+	NamespaceDelete(prefix string, r v3.NamespaceDeleteResponse)
+	NamespaceList(v3.NamespaceListResponse)
+
+	IndexPut(name string, r v3.IndexPutResponse)
+	IndexDelete(name string, r v3.IndexDeleteResponse)
+	IndexList(v3.IndexListResponse)
+
+	LogLevel(endpoint string, r v3.LogLevelResponse)
+	SlowLog(endpoint string, r v3.SlowLogResponse)
+	TopKeys(endpoint string, r v3.TopKeysResponse)
+	Fragmentation(endpoint string, r v3.FragmentationResponse)
+
 	RoleAdd(role string, r v3.AuthRoleAddResponse)
 	RoleGet(role string, r v3.AuthRoleGetResponse)
 	RoleDelete(role string, r v3.AuthRoleDeleteResponse)
@@ -86,6 +105,8 @@ func NewPrinter(printerType string, isHex bool) printer {
 		return newPBPrinter()
 	case "table":
 		return &tablePrinter{newPrinterUnsupported("table")}
+	case "export":
+		return newExportPrinter()
 	}
 	return nil
 }
@@ -119,6 +140,41 @@ func (p *printerRPC) MemberPromote(id uint64, r v3.MemberPromoteResponse) {
 }
 func (p *printerRPC) MemberList(r v3.MemberListResponse) { p.p((*pb.MemberListResponse)(&r)) }
 func (p *printerRPC) Alarm(r v3.AlarmResponse)           { p.p((*pb.AlarmResponse)(&r)) }
+func (p *printerRPC) BulkImport(r v3.BulkImportResponse) { p.p((*pb.BulkImportResponse)(&r)) }
+func (p *printerRPC) KeyHistogram(r v3.KeyHistogramResponse) {
+	p.p((*pb.KeyHistogramResponse)(&r))
+}
+func (p *printerRPC) ReadOnly(r v3.ReadOnlyResponse) { p.p((*pb.ReadOnlyResponse)(&r)) }
+func (p *printerRPC) NamespacePut(_ string, r v3.NamespacePutResponse) {
+	p.p((*pb.NamespacePutResponse)(&r))
+}
+func (p *printerRPC) NamespaceDelete(_ string, r v3.NamespaceDeleteResponse) {
+	p.p((*pb.NamespaceDeleteResponse)(&r))
+}
+func (p *printerRPC) NamespaceList(r v3.NamespaceListResponse) {
+	p.p((*pb.NamespaceListResponse)(&r))
+}
+func (p *printerRPC) IndexPut(_ string, r v3.IndexPutResponse) {
+	p.p((*pb.IndexPutResponse)(&r))
+}
+func (p *printerRPC) IndexDelete(_ string, r v3.IndexDeleteResponse) {
+	p.p((*pb.IndexDeleteResponse)(&r))
+}
+func (p *printerRPC) IndexList(r v3.IndexListResponse) {
+	p.p((*pb.IndexListResponse)(&r))
+}
+func (p *printerRPC) LogLevel(_ string, r v3.LogLevelResponse) {
+	p.p((*pb.LogLevelResponse)(&r))
+}
+func (p *printerRPC) SlowLog(_ string, r v3.SlowLogResponse) {
+	p.p((*pb.SlowLogResponse)(&r))
+}
+func (p *printerRPC) TopKeys(_ string, r v3.TopKeysResponse) {
+	p.p((*pb.TopKeysResponse)(&r))
+}
+func (p *printerRPC) Fragmentation(_ string, r v3.FragmentationResponse) {
+	p.p((*pb.FragmentationResponse)(&r))
+}
 func (p *printerRPC) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) {
 	p.p((*pb.MoveLeaderResponse)(&r))
 }
@@ -168,6 +224,7 @@ func newPrinterUnsupported(n string) printer {
 func (p *printerUnsupported) EndpointHealth([]epHealth) { p.p(nil) }
 func (p *printerUnsupported) EndpointStatus([]epStatus) { p.p(nil) }
 func (p *printerUnsupported) EndpointHashKV([]epHashKV) { p.p(nil) }
+func (p *printerUnsupported) Diff([]keyDiff)            { p.p(nil) }
 
 func (p *printerUnsupported) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) { p.p(nil) }
 func (p *printerUnsupported) DowngradeValidate(r v3.DowngradeResponse)                  { p.p(nil) }
@@ -175,7 +232,7 @@ func (p *printerUnsupported) DowngradeEnable(r v3.DowngradeResponse)
 func (p *printerUnsupported) DowngradeCancel(r v3.DowngradeResponse)                    { p.p(nil) }
 
 func makeMemberListTable(r v3.MemberListResponse) (hdr []string, rows [][]string) {
-	hdr = []string{"ID", "Status", "Name", "Peer Addrs", "Client Addrs", "Is Learner"}
+	hdr = []string{"ID", "Status", "Name", "Peer Addrs", "Client Addrs", "Is Learner", "Is Witness"}
 	for _, m := range r.Members {
 		status := "started"
 		if len(m.Name) == 0 {
@@ -192,6 +249,107 @@ func makeMemberListTable(r v3.MemberListResponse) (hdr []string, rows [][]string
 			strings.Join(m.PeerURLs, ","),
 			strings.Join(m.ClientURLs, ","),
 			isLearner,
+			fmt.Sprint(m.IsWitness),
+		})
+	}
+	return hdr, rows
+}
+
+func makeLogLevelTable(ep string, r v3.LogLevelResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "module", "level", "overridden"}
+	rows = append(rows, []string{ep, "default", r.DefaultLevel, "false"})
+	for _, m := range r.Modules {
+		rows = append(rows, []string{ep, m.Module, m.Level, fmt.Sprint(m.Overridden)})
+	}
+	return hdr, rows
+}
+
+func makeSlowLogTable(ep string, r v3.SlowLogResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "start", "method", "key", "range end", "user", "request size", "response size", "total", "queue", "fsync", "apply", "error"}
+	seconds := func(s float64) string { return time.Duration(s * float64(time.Second)).String() }
+	for _, sr := range r.Requests {
+		rows = append(rows, []string{
+			ep,
+			time.Unix(0, sr.StartUnixNano).UTC().Format(time.RFC3339Nano),
+			sr.Method,
+			string(sr.Key),
+			string(sr.RangeEnd),
+			sr.User,
+			humanize.Bytes(uint64(sr.RequestBytes)),
+			humanize.Bytes(uint64(sr.ResponseBytes)),
+			seconds(sr.TotalSeconds),
+			seconds(sr.QueueSeconds),
+			seconds(sr.FsyncSeconds),
+			seconds(sr.ApplySeconds),
+			sr.Error,
+		})
+	}
+	return hdr, rows
+}
+
+func makeTopKeysTable(ep string, r v3.TopKeysResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "report", "key", "range end", "count", "size"}
+	for _, k := range r.LargestKeys {
+		rows = append(rows, []string{ep, "largest key", string(k.Key), "", fmt.Sprint(k.Version), humanize.Bytes(uint64(k.ValueBytes))})
+	}
+	for _, p := range r.Prefixes {
+		rows = append(rows, []string{ep, "prefix revisions", string(p.Prefix), "", fmt.Sprint(p.Revisions), humanize.Bytes(uint64(p.Bytes))})
+	}
+	for _, w := range r.WatchedRanges {
+		rows = append(rows, []string{ep, "watched range", string(w.Key), string(w.RangeEnd), fmt.Sprint(w.Watchers), fmt.Sprint(w.Backlog)})
+	}
+	for _, w := range r.BackloggedRanges {
+		rows = append(rows, []string{ep, "backlogged range", string(w.Key), string(w.RangeEnd), fmt.Sprint(w.Watchers), fmt.Sprint(w.Backlog)})
+	}
+	return hdr, rows
+}
+
+func makeFragmentationTable(ep string, r v3.FragmentationResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "db size", "free pages", "pending pages", "estimated defrag size", "reclaimable", "defrag recommended"}
+	rows = append(rows, []string{
+		ep,
+		humanize.Bytes(uint64(r.DbSize)),
+		fmt.Sprint(r.FreePages),
+		fmt.Sprint(r.PendingPages),
+		humanize.Bytes(uint64(r.EstimatedDefragSize)),
+		humanize.Bytes(uint64(r.ReclaimableBytes)),
+		fmt.Sprint(r.DefragRecommended),
+	})
+	return hdr, rows
+}
+
+func makeIndexListTable(r v3.IndexListResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"name", "prefix", "field", "keys", "values"}
+	for _, u := range r.Indexes {
+		rows = append(rows, []string{
+			u.Index.Name,
+			string(u.Index.Prefix),
+			u.Index.Field,
+			fmt.Sprint(u.IndexedKeys),
+			fmt.Sprint(u.DistinctValues),
+		})
+	}
+	return hdr, rows
+}
+
+func makeNamespaceListTable(r v3.NamespaceListResponse) (hdr []string, rows [][]string) {
+	hdr = []string{"prefix", "keys", "key quota", "size", "size quota", "max lease ttl"}
+	limit := func(v int64, format func(int64) string) string {
+		if v == 0 {
+			return "unlimited"
+		}
+		return format(v)
+	}
+	count := func(v int64) string { return fmt.Sprint(v) }
+	size := func(v int64) string { return humanize.Bytes(uint64(v)) }
+	for _, u := range r.Namespaces {
+		rows = append(rows, []string{
+			string(u.Namespace.Prefix),
+			count(u.UsedKeys),
+			limit(u.Namespace.QuotaKeys, count),
+			size(u.UsedBytes),
+			limit(u.Namespace.QuotaBytes, size),
+			limit(u.Namespace.MaxLeaseTtl, count),
 		})
 	}
 	return hdr, rows
@@ -0,0 +1,54 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+// exportedKV is a key-value pair as written by 'get -w export' and read by
+// 'put --from-file'. Its key, value and lease are encoded as in the kvs of
+// 'get -w json', so that an export can also be read by bulk-import.
+type exportedKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+	// Lease is the ID of the lease the key was attached to, keys sharing a
+	// lease being attached to a same new lease on import.
+	Lease int64 `json:"lease,omitempty"`
+	// TTL is the remaining time-to-live in seconds of the lease at export.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// exportPrinter prints the key-values of 'get' as lines of JSON exportedKV.
+type exportPrinter struct {
+	printer
+	// ttls holds the remaining time-to-live of the leases of the keys,
+	// looked up by the get command.
+	ttls map[v3.LeaseID]int64
+}
+
+func newExportPrinter() printer {
+	return &exportPrinter{printer: newPrinterUnsupported("export")}
+}
+
+func (p *exportPrinter) Get(r v3.GetResponse) {
+	for _, kv := range r.Kvs {
+		ekv := exportedKV{Key: kv.Key, Value: kv.Value}
+		if ttl, ok := p.ttls[v3.LeaseID(kv.Lease)]; ok && kv.Lease != 0 {
+			ekv.Lease, ekv.TTL = kv.Lease, ttl
+		}
+		printJSON(ekv)
+	}
+}
@@ -16,6 +16,7 @@ package command
 
 import (
 	"fmt"
+	"sort"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	spb "go.etcd.io/etcd/api/v3/mvccpb"
@@ -75,6 +76,8 @@ func (p *fieldsPrinter) Txn(r v3.TxnResponse) {
 			p.Put((v3.PutResponse)(*v.ResponsePut))
 		case *pb.ResponseOp_ResponseRange:
 			p.Get((v3.GetResponse)(*v.ResponseRange))
+		case *pb.ResponseOp_ResponseTxn:
+			p.Txn((v3.TxnResponse)(*v.ResponseTxn))
 		default:
 			fmt.Printf("\"Unknown\" : %q\n", fmt.Sprintf("%+v", v))
 		}
@@ -116,12 +119,25 @@ func (p *fieldsPrinter) TimeToLive(r v3.LeaseTimeToLiveResponse, keys bool) {
 	for _, k := range r.Keys {
 		fmt.Printf("\"Key\" : %q\n", string(k))
 	}
+	printLeaseLabelFields(r.Labels)
 }
 
 func (p *fieldsPrinter) Leases(r v3.LeaseLeasesResponse) {
 	p.hdr(r.ResponseHeader)
 	for _, item := range r.Leases {
 		fmt.Println(`"ID" :`, item.ID)
+		printLeaseLabelFields(item.Labels)
+	}
+}
+
+func printLeaseLabelFields(labels map[string]string) {
+	ks := make([]string, 0, len(labels))
+	for k := range labels {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	for _, k := range ks {
+		fmt.Printf("\"Label\" : %q\n", k+"="+labels[k])
 	}
 }
 
@@ -137,6 +153,7 @@ func (p *fieldsPrinter) MemberList(r v3.MemberListResponse) {
 			fmt.Printf("\"ClientURL\" : %q\n", u)
 		}
 		fmt.Println(`"IsLearner" :`, m.IsLearner)
+		fmt.Println(`"IsWitness" :`, m.IsWitness)
 		fmt.Println()
 	}
 }
@@ -187,6 +204,143 @@ func (p *fieldsPrinter) Alarm(r v3.AlarmResponse) {
 	}
 }
 
+func (p *fieldsPrinter) BulkImport(r v3.BulkImportResponse) {
+	p.hdr(r.Header)
+	fmt.Println(`"Revision" :`, r.Revision)
+	fmt.Println(`"Count" :`, r.Count)
+}
+
+func (p *fieldsPrinter) ReadOnly(r v3.ReadOnlyResponse) {
+	p.hdr(r.Header)
+	fmt.Println(`"ReadOnly" :`, r.ReadOnly)
+}
+
+func (p *fieldsPrinter) NamespaceList(r v3.NamespaceListResponse) {
+	p.hdr(r.Header)
+	for _, u := range r.Namespaces {
+		fmt.Printf("\"Prefix\" : %q\n", string(u.Namespace.Prefix))
+		fmt.Println(`"UsedKeys" :`, u.UsedKeys)
+		fmt.Println(`"QuotaKeys" :`, u.Namespace.QuotaKeys)
+		fmt.Println(`"UsedBytes" :`, u.UsedBytes)
+		fmt.Println(`"QuotaBytes" :`, u.Namespace.QuotaBytes)
+		fmt.Println(`"MaxLeaseTTL" :`, u.Namespace.MaxLeaseTtl)
+		fmt.Println()
+	}
+}
+
+func (p *fieldsPrinter) IndexList(r v3.IndexListResponse) {
+	p.hdr(r.Header)
+	for _, u := range r.Indexes {
+		fmt.Printf("\"Name\" : %q\n", u.Index.Name)
+		fmt.Printf("\"Prefix\" : %q\n", string(u.Index.Prefix))
+		fmt.Printf("\"Field\" : %q\n", u.Index.Field)
+		fmt.Println(`"IndexedKeys" :`, u.IndexedKeys)
+		fmt.Println(`"DistinctValues" :`, u.DistinctValues)
+		fmt.Println()
+	}
+}
+
+func (p *fieldsPrinter) LogLevel(ep string, r v3.LogLevelResponse) {
+	p.hdr(r.Header)
+	fmt.Printf("\"Endpoint\" : %q\n", ep)
+	fmt.Printf("\"DefaultLevel\" : %q\n", r.DefaultLevel)
+	for _, m := range r.Modules {
+		fmt.Printf("\"Module\" : %q\n", m.Module)
+		fmt.Printf("\"Level\" : %q\n", m.Level)
+		fmt.Println(`"Overridden" :`, m.Overridden)
+		fmt.Println()
+	}
+}
+
+func (p *fieldsPrinter) SlowLog(ep string, r v3.SlowLogResponse) {
+	p.hdr(r.Header)
+	fmt.Printf("\"Endpoint\" : %q\n", ep)
+	fmt.Println(`"ThresholdSeconds" :`, r.ThresholdSeconds)
+	fmt.Println(`"Total" :`, r.Total)
+	for _, sr := range r.Requests {
+		fmt.Println(`"StartUnixNano" :`, sr.StartUnixNano)
+		fmt.Printf("\"Method\" : %q\n", sr.Method)
+		fmt.Printf("\"Key\" : %q\n", string(sr.Key))
+		fmt.Printf("\"RangeEnd\" : %q\n", string(sr.RangeEnd))
+		fmt.Printf("\"User\" : %q\n", sr.User)
+		fmt.Println(`"RequestBytes" :`, sr.RequestBytes)
+		fmt.Println(`"ResponseBytes" :`, sr.ResponseBytes)
+		fmt.Println(`"TotalSeconds" :`, sr.TotalSeconds)
+		fmt.Println(`"QueueSeconds" :`, sr.QueueSeconds)
+		fmt.Println(`"FsyncSeconds" :`, sr.FsyncSeconds)
+		fmt.Println(`"ApplySeconds" :`, sr.ApplySeconds)
+		fmt.Printf("\"Error\" : %q\n", sr.Error)
+		fmt.Println()
+	}
+}
+
+func (p *fieldsPrinter) TopKeys(ep string, r v3.TopKeysResponse) {
+	p.hdr(r.Header)
+	fmt.Printf("\"Endpoint\" : %q\n", ep)
+	for _, k := range r.LargestKeys {
+		fmt.Printf("\"LargestKey\" : %q\n", string(k.Key))
+		fmt.Println(`"ValueBytes" :`, k.ValueBytes)
+		fmt.Println(`"ModRevision" :`, k.ModRevision)
+		fmt.Println(`"Version" :`, k.Version)
+		fmt.Println()
+	}
+	for _, pr := range r.Prefixes {
+		fmt.Printf("\"Prefix\" : %q\n", string(pr.Prefix))
+		fmt.Println(`"Revisions" :`, pr.Revisions)
+		fmt.Println(`"Bytes" :`, pr.Bytes)
+		fmt.Println()
+	}
+	for _, w := range r.WatchedRanges {
+		fmt.Printf("\"WatchedRange\" : %q\n", string(w.Key))
+		fmt.Printf("\"RangeEnd\" : %q\n", string(w.RangeEnd))
+		fmt.Println(`"Watchers" :`, w.Watchers)
+		fmt.Println(`"Backlog" :`, w.Backlog)
+		fmt.Println()
+	}
+	for _, w := range r.BackloggedRanges {
+		fmt.Printf("\"BackloggedRange\" : %q\n", string(w.Key))
+		fmt.Printf("\"RangeEnd\" : %q\n", string(w.RangeEnd))
+		fmt.Println(`"Watchers" :`, w.Watchers)
+		fmt.Println(`"Backlog" :`, w.Backlog)
+		fmt.Println()
+	}
+}
+
+func (p *fieldsPrinter) Fragmentation(ep string, r v3.FragmentationResponse) {
+	p.hdr(r.Header)
+	fmt.Printf("\"Endpoint\" : %q\n", ep)
+	fmt.Println(`"DbSize" :`, r.DbSize)
+	fmt.Println(`"PageSize" :`, r.PageSize)
+	fmt.Println(`"FreePages" :`, r.FreePages)
+	fmt.Println(`"PendingPages" :`, r.PendingPages)
+	fmt.Println(`"FreelistBytes" :`, r.FreelistBytes)
+	fmt.Println(`"EstimatedDefragSize" :`, r.EstimatedDefragSize)
+	fmt.Println(`"ReclaimableBytes" :`, r.ReclaimableBytes)
+	fmt.Println(`"DefragRecommended" :`, r.DefragRecommended)
+	for _, b := range r.Buckets {
+		fmt.Println()
+		fmt.Printf("\"Bucket\" : %q\n", string(b.Name))
+		fmt.Println(`"Keys" :`, b.Keys)
+		fmt.Println(`"BranchPages" :`, b.BranchPages)
+		fmt.Println(`"LeafPages" :`, b.LeafPages)
+		fmt.Println(`"BytesInUse" :`, b.BytesInUse)
+		fmt.Println(`"BytesAllocated" :`, b.BytesAllocated)
+		fmt.Println(`"FillRatio" :`, b.FillRatio)
+	}
+}
+
+func (p *fieldsPrinter) KeyHistogram(r v3.KeyHistogramResponse) {
+	p.hdr(r.Header)
+	for _, b := range r.Buckets {
+		fmt.Printf("\"Prefix\" : %q\n", string(b.Prefix))
+		fmt.Println(`"Count" :`, b.Count)
+		fmt.Println(`"ValueBytes" :`, b.ValueBytes)
+		fmt.Println()
+	}
+	fmt.Println(`"Count" :`, r.Count)
+	fmt.Println(`"ValueBytes" :`, r.ValueBytes)
+}
+
 func (p *fieldsPrinter) RoleAdd(role string, r v3.AuthRoleAddResponse) { p.hdr(r.Header) }
 func (p *fieldsPrinter) RoleGet(role string, r v3.AuthRoleGetResponse) {
 	p.hdr(r.Header)
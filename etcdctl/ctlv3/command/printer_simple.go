@@ -15,10 +15,13 @@
 package command
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/dustin/go-humanize"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	v3 "go.etcd.io/etcd/client/v3"
@@ -67,6 +70,8 @@ func (s *simplePrinter) Txn(resp v3.TxnResponse) {
 			s.Put((v3.PutResponse)(*v.ResponsePut))
 		case *pb.ResponseOp_ResponseRange:
 			s.Get(((v3.GetResponse)(*v.ResponseRange)))
+		case *pb.ResponseOp_ResponseTxn:
+			s.Txn((v3.TxnResponse)(*v.ResponseTxn))
 		default:
 			fmt.Printf("unexpected response %+v\n", r)
 		}
@@ -109,22 +114,120 @@ func (s *simplePrinter) TimeToLive(resp v3.LeaseTimeToLiveResponse, keys bool) {
 		}
 		txt += fmt.Sprintf(", attached keys(%v)", ks)
 	}
+	if len(resp.Labels) > 0 {
+		txt += fmt.Sprintf(", labels(%s)", formatLeaseLabels(resp.Labels))
+	}
 	fmt.Println(txt)
 }
 
 func (s *simplePrinter) Leases(resp v3.LeaseLeasesResponse) {
 	fmt.Printf("found %d leases\n", len(resp.Leases))
 	for _, item := range resp.Leases {
+		if len(item.Labels) > 0 {
+			fmt.Printf("%016x labels(%s)\n", item.ID, formatLeaseLabels(item.Labels))
+			continue
+		}
 		fmt.Printf("%016x\n", item.ID)
 	}
 }
 
+// formatLeaseLabels formats the labels of a lease as key=value pairs sorted by key.
+func formatLeaseLabels(labels map[string]string) string {
+	ls := make([]string, 0, len(labels))
+	for k, v := range labels {
+		ls = append(ls, k+"="+v)
+	}
+	sort.Strings(ls)
+	return strings.Join(ls, ",")
+}
+
 func (s *simplePrinter) Alarm(resp v3.AlarmResponse) {
 	for _, e := range resp.Alarms {
 		fmt.Printf("%+v\n", e)
 	}
 }
 
+func (s *simplePrinter) BulkImport(r v3.BulkImportResponse) {
+	fmt.Printf("Imported %d keys at revision %d\n", r.Count, r.Revision)
+}
+
+func (s *simplePrinter) ReadOnly(r v3.ReadOnlyResponse) {
+	if r.ReadOnly {
+		fmt.Println("Cluster is in read-only mode")
+	} else {
+		fmt.Println("Cluster is writable")
+	}
+}
+
+func (s *simplePrinter) NamespacePut(prefix string, r v3.NamespacePutResponse) {
+	fmt.Printf("Namespace %s updated\n", prefix)
+}
+
+func (s *simplePrinter) NamespaceDelete(prefix string, r v3.NamespaceDeleteResponse) {
+	fmt.Printf("Namespace %s deleted\n", prefix)
+}
+
+func (s *simplePrinter) NamespaceList(r v3.NamespaceListResponse) {
+	_, rows := makeNamespaceListTable(r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) IndexPut(name string, r v3.IndexPutResponse) {
+	fmt.Printf("Index %s updated\n", name)
+}
+
+func (s *simplePrinter) IndexDelete(name string, r v3.IndexDeleteResponse) {
+	fmt.Printf("Index %s deleted\n", name)
+}
+
+func (s *simplePrinter) IndexList(r v3.IndexListResponse) {
+	_, rows := makeIndexListTable(r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) LogLevel(ep string, r v3.LogLevelResponse) {
+	_, rows := makeLogLevelTable(ep, r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) SlowLog(ep string, r v3.SlowLogResponse) {
+	_, rows := makeSlowLogTable(ep, r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) TopKeys(ep string, r v3.TopKeysResponse) {
+	_, rows := makeTopKeysTable(ep, r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) Fragmentation(ep string, r v3.FragmentationResponse) {
+	_, rows := makeFragmentationTable(ep, r)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) KeyHistogram(r v3.KeyHistogramResponse) {
+	for _, b := range r.Buckets {
+		prefix := string(b.Prefix)
+		if s.isHex {
+			prefix = addHexPrefix(hex.EncodeToString(b.Prefix))
+		}
+		fmt.Printf("%s, %d, %s\n", prefix, b.Count, humanize.Bytes(uint64(b.ValueBytes)))
+	}
+	fmt.Printf("%d keys, %s\n", r.Count, humanize.Bytes(uint64(r.ValueBytes)))
+}
+
 func (s *simplePrinter) MemberAdd(r v3.MemberAddResponse) {
 	fmt.Printf("Member %16x added to cluster %16x\n", r.Member.ID, r.Header.ClusterId)
 }
@@ -172,6 +275,17 @@ func (s *simplePrinter) EndpointHashKV(hashList []epHashKV) {
 	}
 }
 
+func (s *simplePrinter) Diff(diffs []keyDiff) {
+	marks := map[string]string{diffAdded: "+", diffRemoved: "-", diffChanged: "~"}
+	for _, d := range diffs {
+		k := string(d.Key())
+		if s.isHex {
+			k = addHexPrefix(hex.EncodeToString(d.Key()))
+		}
+		fmt.Printf("%s %s\n", marks[d.Type], k)
+	}
+}
+
 func (s *simplePrinter) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) {
 	fmt.Printf("Leadership transferred from %s to %s\n", types.ID(leader), types.ID(target))
 }
@@ -235,6 +349,24 @@ func (s *simplePrinter) RoleGet(role string, r v3.AuthRoleGetResponse) {
 			}
 		}
 	}
+
+	var admin []*v3.Permission
+	for _, perm := range r.Perm {
+		if perm.PermType == v3.PermAdmin {
+			admin = append(admin, (*v3.Permission)(perm))
+		}
+	}
+	if len(admin) == 0 {
+		return
+	}
+	fmt.Println("Admin:")
+	for _, perm := range admin {
+		if len(perm.RangeEnd) == 0 {
+			fmt.Printf("\t%s\n", string(perm.Key))
+		} else {
+			printRange(perm)
+		}
+	}
 }
 
 func (s *simplePrinter) RoleList(r v3.AuthRoleListResponse) {
@@ -15,9 +15,14 @@
 package command
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -29,8 +34,15 @@ var (
 	putPrevKV      bool
 	putIgnoreVal   bool
 	putIgnoreLease bool
+	putTTL         int64
+	putFromFile    string
+	putFileFormat  string
 )
 
+// putFromFileBatchSize is the number of keys put per transaction with
+// --from-file, the default maximum number of operations in a transaction.
+const putFromFileBatchSize = 128
+
 // NewPutCommand returns the cobra command for "put".
 func NewPutCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -54,6 +66,25 @@ this command tries to read the value from standard input.
 For example,
 $ cat file | put <key>
 will store the content of the file to <key>.
+
+With '--from-file', the keys are read from the given file instead, or from
+standard input if "-" is given, such as an export written by 'get -w export':
+
+$ get --prefix foo -w export > foo.json
+$ put --from-file foo.json
+
+The file holds either lines of JSON objects with the base64 encoded key and
+value of a pair, and optionally the ID of the lease it was attached to and the
+remaining time-to-live in seconds of the lease:
+
+	{"key":"Zm9v","value":"YmFy","lease":7587862024383318534,"ttl":60}
+
+or CSV records of the key, the value and an optional time-to-live in seconds:
+
+	foo,bar,60
+
+The keys sharing a lease are attached to a same new lease granted with its
+time-to-live, and the keys with only a time-to-live are put as with '--ttl'.
 `,
 		Run: putCommandFunc,
 	}
@@ -61,11 +92,21 @@ will store the content of the file to <key>.
 	cmd.Flags().BoolVar(&putPrevKV, "prev-kv", false, "return the previous key-value pair before modification")
 	cmd.Flags().BoolVar(&putIgnoreVal, "ignore-value", false, "updates the key using its current value")
 	cmd.Flags().BoolVar(&putIgnoreLease, "ignore-lease", false, "updates the key using its current lease")
+	cmd.Flags().Int64Var(&putTTL, "ttl", 0, "time-to-live in seconds of the key, without granting a lease")
+	cmd.Flags().StringVar(&putFromFile, "from-file", "", "put the keys read from the given file, or from stdin if \"-\"")
+	cmd.Flags().StringVar(&putFileFormat, "file-format", "", "format of the file of '--from-file'; json or csv, by default guessed from its extension")
+	cmd.RegisterFlagCompletionFunc("file-format", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "csv"}, cobra.ShellCompDirectiveDefault
+	})
 	return cmd
 }
 
 // putCommandFunc executes the "put" command.
 func putCommandFunc(cmd *cobra.Command, args []string) {
+	if putFromFile != "" {
+		putFromFileCommandFunc(cmd, args)
+		return
+	}
 	key, value, opts := getPutOp(args)
 
 	ctx, cancel := commandCtx(cmd)
@@ -101,6 +142,10 @@ func getPutOp(args []string) (string, string, []clientv3.OpOption) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad lease ID (%v), expecting ID in Hex", err))
 	}
 
+	if putTTL > 0 && (id != 0 || putIgnoreLease) {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("put command cannot set 'ttl' together with 'lease' or 'ignore-lease'"))
+	}
+
 	opts := []clientv3.OpOption{}
 	if id != 0 {
 		opts = append(opts, clientv3.WithLease(clientv3.LeaseID(id)))
@@ -114,6 +159,129 @@ func getPutOp(args []string) (string, string, []clientv3.OpOption) {
 	if putIgnoreLease {
 		opts = append(opts, clientv3.WithIgnoreLease())
 	}
+	if putTTL > 0 {
+		opts = append(opts, clientv3.WithTTL(putTTL))
+	}
 
 	return key, value, opts
 }
+
+// putFromFileCommandFunc executes the "put" command with --from-file.
+func putFromFileCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("put command takes no argument when 'from-file' is set"))
+	}
+	if leaseStr != "0" || putTTL > 0 || putPrevKV || putIgnoreVal || putIgnoreLease {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("put command cannot set 'from-file' together with other options"))
+	}
+
+	format := putFileFormat
+	if format == "" {
+		format = "json"
+		if strings.EqualFold(filepath.Ext(putFromFile), ".csv") {
+			format = "csv"
+		}
+	}
+	in := os.Stdin
+	if putFromFile != "-" {
+		f, err := os.Open(putFromFile)
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitIO, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	next, err := newExportedKVReader(in, format)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	c := mustClientFromCmd(cmd)
+	// the new leases granted for the leases of the file
+	leases := make(map[int64]clientv3.LeaseID)
+	var ops []clientv3.Op
+	var n int
+	flush := func() {
+		if len(ops) == 0 {
+			return
+		}
+		ctx, cancel := commandCtx(cmd)
+		_, err := c.Txn(ctx).Then(ops...).Commit()
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to put the keys after the %d first ones (%v)", n, err))
+		}
+		n += len(ops)
+		ops = ops[:0]
+	}
+	for {
+		kv, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+		}
+
+		var opts []clientv3.OpOption
+		switch {
+		case kv.Lease != 0 && kv.TTL > 0:
+			id, ok := leases[kv.Lease]
+			if !ok {
+				ctx, cancel := commandCtx(cmd)
+				resp, err := c.Grant(ctx, kv.TTL)
+				cancel()
+				if err != nil {
+					cobrautl.ExitWithError(cobrautl.ExitError, err)
+				}
+				id = resp.ID
+				leases[kv.Lease] = id
+			}
+			opts = append(opts, clientv3.WithLease(id))
+		case kv.TTL > 0:
+			opts = append(opts, clientv3.WithTTL(kv.TTL))
+		}
+		ops = append(ops, clientv3.OpPut(string(kv.Key), string(kv.Value), opts...))
+		if len(ops) == putFromFileBatchSize {
+			flush()
+		}
+	}
+	flush()
+	fmt.Printf("Put %d keys, granted %d leases\n", n, len(leases))
+}
+
+// newExportedKVReader returns a function reading the key-values of r in the
+// given format, json or csv, one at a time. It returns io.EOF at the end of r.
+func newExportedKVReader(r io.Reader, format string) (func() (exportedKV, error), error) {
+	switch format {
+	case "json":
+		dec := json.NewDecoder(r)
+		return func() (kv exportedKV, err error) {
+			if err = dec.Decode(&kv); err != nil && err != io.EOF {
+				err = fmt.Errorf("invalid key-value pair at offset %d: %v", dec.InputOffset(), err)
+			}
+			return kv, err
+		}, nil
+	case "csv":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return func() (kv exportedKV, err error) {
+			rec, err := cr.Read()
+			if err != nil {
+				return kv, err
+			}
+			line, _ := cr.FieldPos(0)
+			if len(rec) != 2 && len(rec) != 3 {
+				return kv, fmt.Errorf("invalid key-value pair on line %d: expected 2 or 3 fields, got %d", line, len(rec))
+			}
+			kv.Key, kv.Value = []byte(rec[0]), []byte(rec[1])
+			if len(rec) == 3 && rec[2] != "" {
+				if kv.TTL, err = strconv.ParseInt(rec[2], 10, 64); err != nil || kv.TTL < 0 {
+					return kv, fmt.Errorf("invalid time-to-live on line %d: %q", line, rec[2])
+				}
+			}
+			return kv, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown file format %q, expecting json or csv", format)
+}
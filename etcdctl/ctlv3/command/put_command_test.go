@@ -0,0 +1,93 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_newExportedKVReader(t *testing.T) {
+	exported := []exportedKV{
+		{Key: []byte("foo"), Value: []byte("bar")},
+		{Key: []byte("lease\x00d"), Value: []byte("a,b\n"), Lease: 7587862024383318534, TTL: 60},
+	}
+	var export strings.Builder
+	for _, kv := range exported {
+		b, err := json.Marshal(kv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		export.Write(append(b, '\n'))
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		in      string
+		want    []exportedKV
+		wantErr bool
+	}{
+		{name: "json export", format: "json", in: export.String(), want: exported},
+		{
+			name:   "csv",
+			format: "csv",
+			in:     "foo,bar\n\"a,b\",\"c\nd\",60\nempty,,\n",
+			want: []exportedKV{
+				{Key: []byte("foo"), Value: []byte("bar")},
+				{Key: []byte("a,b"), Value: []byte("c\nd"), TTL: 60},
+				{Key: []byte("empty"), Value: []byte{}},
+			},
+		},
+		{name: "invalid json", format: "json", in: `{"key":"foo"`, wantErr: true},
+		{name: "too many csv fields", format: "csv", in: "a,b,1,2\n", wantErr: true},
+		{name: "invalid csv ttl", format: "csv", in: "a,b,-1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := newExportedKVReader(strings.NewReader(tt.in), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []exportedKV
+			for {
+				kv, err := next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				got = append(got, kv)
+			}
+			if tt.wantErr {
+				t.Fatal("expected an error")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newExportedKVReader(strings.NewReader(""), "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+// NewReadOnlyCommand returns the cobra command for "readonly".
+func NewReadOnlyCommand() *cobra.Command {
+	rc := &cobra.Command{
+		Use:   "readonly <subcommand>",
+		Short: "Read-only mode related commands",
+	}
+
+	rc.AddCommand(NewReadOnlyEnableCommand())
+	rc.AddCommand(NewReadOnlyDisableCommand())
+	rc.AddCommand(NewReadOnlyStatusCommand())
+
+	return rc
+}
+
+// NewReadOnlyEnableCommand returns the cobra command for "readonly enable".
+func NewReadOnlyEnableCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable",
+		Short: "Rejects the writes to keys, leases and auth in the cluster",
+
+		Run: readOnlyCommandFunc(clientv3.ReadOnlyEnable),
+	}
+}
+
+// NewReadOnlyDisableCommand returns the cobra command for "readonly disable".
+func NewReadOnlyDisableCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable",
+		Short: "Accepts the writes in the cluster again",
+
+		Run: readOnlyCommandFunc(clientv3.ReadOnlyDisable),
+	}
+}
+
+// NewReadOnlyStatusCommand returns the cobra command for "readonly status".
+func NewReadOnlyStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Prints if the cluster is in read-only mode",
+
+		Run: readOnlyCommandFunc(clientv3.ReadOnlyGet),
+	}
+}
+
+// readOnlyCommandFunc returns the function executing the "readonly" subcommand
+// of the given action.
+func readOnlyCommandFunc(action clientv3.ReadOnlyAction) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("readonly command takes no arguments"))
+		}
+
+		ctx, cancel := commandCtx(cmd)
+		cli := mustClientFromCmd(cmd)
+
+		resp, err := cli.ReadOnly(ctx, action)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+
+		display.ReadOnly(*resp)
+	}
+}
@@ -0,0 +1,614 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"golang.org/x/term"
+)
+
+const (
+	replPrompt = "etcdctl> "
+
+	// replKeysPageSize is the number of keys read per request to complete a key.
+	replKeysPageSize = 50
+	// replMaxCandidates is the maximum number of candidates listed to complete
+	// a word.
+	replMaxCandidates = 200
+)
+
+// replUnsupported are the commands running until interrupted, which cannot be
+// run by a repl session.
+var replUnsupported = map[string]bool{
+	"repl":             true,
+	"lock":             true,
+	"elect":            true,
+	"make-mirror":      true,
+	"lease keep-alive": true,
+}
+
+// NewREPLCommand returns the cobra command for "repl".
+func NewREPLCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repl",
+		Short: "Runs commands in an interactive session",
+		Long: `Runs the commands read from the standard input in a session sharing a single
+connection to the cluster, authenticated once.
+
+The global flags given to repl apply to all the commands of the session. The
+ones given in a command line, but those of the connection, apply to that
+command only.
+
+On a terminal, the session keeps a history of the commands, and completes the
+names of the commands and of their flags, and the keys, with the tab key.
+
+Besides the commands of etcdctl, the session supports:
+
+	txn                 builds a transaction, prompting for its compares, and
+	                    for its success and failure requests
+	watch [options] <key> [range_end]
+	                    watches in the background, printing the events while
+	                    other commands are run; it takes the options of
+	                    'etcdctl watch'
+	watch               lists the watches of the session
+	unwatch [<id>...]   cancels the given watches, or all of them
+	history             lists the commands of the session
+	exit, quit          ends the session, as does Ctrl-D
+`,
+		Run: replCommandFunc,
+	}
+}
+
+// replCommandFunc executes the "repl" command.
+func replCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("repl command does not accept argument"))
+	}
+
+	s := &replSession{
+		root:    cmd.Root(),
+		c:       mustClientFromCmd(cmd),
+		watches: make(map[int]*replWatch),
+	}
+	defer s.c.Close()
+	s.flags = saveFlags(s.root)
+
+	sessionClient = s.c
+	cobrautl.SetExit(func(code int) { panic(replExit(code)) })
+	defer func() {
+		sessionClient = nil
+		cobrautl.SetExit(os.Exit)
+	}()
+
+	// the commands are bounded by the command timeout, interrupting them
+	// would end the session
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	defer signal.Stop(sigc)
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		t, err := newTerminalReader(fd, s.complete)
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitIO, err)
+		}
+		defer t.close()
+		s.in = t
+	} else {
+		s.in = &scannerReader{sc: bufio.NewScanner(os.Stdin)}
+	}
+	s.loop()
+	s.unwatch(nil)
+}
+
+// replExit is the panic value of cobrautl.ExitWithError in a repl session.
+type replExit int
+
+// lineReader reads the lines of a repl session.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+type replSession struct {
+	root  *cobra.Command
+	c     *clientv3.Client
+	in    lineReader
+	flags map[*pflag.Flag]flagValue
+
+	history []string
+
+	mu          sync.Mutex
+	watches     map[int]*replWatch
+	lastWatchID int
+}
+
+type replWatch struct {
+	args   []string
+	cancel context.CancelFunc
+}
+
+func (s *replSession) loop() {
+	for {
+		line, err := s.in.readLine(replPrompt)
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+			}
+			return
+		}
+		args := Argify(line)
+		if len(args) == 0 {
+			continue
+		}
+		s.history = append(s.history, line)
+
+		switch args[0] {
+		case "exit", "quit":
+			return
+		case "history":
+			for i, l := range s.history {
+				fmt.Printf("%5d  %s\n", i+1, l)
+			}
+		case "unwatch":
+			s.unwatch(args[1:])
+		default:
+			s.run(args)
+		}
+	}
+}
+
+// run runs the command of the given arguments, with the flags of the session.
+func (s *replSession) run(args []string) (code int) {
+	defer restoreFlags(s.root, s.flags)
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(replExit)
+			if !ok {
+				panic(r)
+			}
+			code = int(exit)
+		}
+	}()
+
+	cmd, rest, err := s.root.Find(args)
+	if err == nil && cmd != s.root {
+		name := strings.TrimPrefix(cmd.CommandPath(), s.root.Name()+" ")
+		switch {
+		case replUnsupported[name]:
+			cobrautl.ExitWithError(cobrautl.ExitBadFeature, fmt.Errorf("%s is not supported in a repl session", name))
+		case name == "txn":
+			s.txn(cmd, rest)
+			return cobrautl.ExitSuccess
+		case name == "watch":
+			s.watch(cmd, rest)
+			return cobrautl.ExitSuccess
+		}
+	}
+
+	s.root.SetArgs(args)
+	if err = s.root.Execute(); err != nil {
+		return cobrautl.ExitError
+	}
+	return cobrautl.ExitSuccess
+}
+
+// txn builds a transaction by prompting for its compares and its requests,
+// in the format of 'txn --interactive'.
+func (s *replSession) txn(cmd *cobra.Command, args []string) {
+	if err := cmd.ParseFlags(args); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if len(cmd.Flags().Args()) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("txn command does not accept argument"))
+	}
+	c := mustClientFromCmd(cmd)
+
+	r := &promptReader{in: s.in}
+	br := bufio.NewReader(r)
+	r.prompt = "compares> "
+	cmps := readCompares(br)
+	r.prompt = "success> "
+	thenOps := readOps(br)
+	r.prompt = "failure> "
+	elseOps := readOps(br)
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := c.Txn(ctx).If(cmps...).Then(thenOps...).Else(elseOps...).Commit()
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.Txn(*resp)
+}
+
+// watch starts a watch printing its events in the background, or lists the
+// watches of the session if no key is given.
+func (s *replSession) watch(cmd *cobra.Command, args []string) {
+	if err := cmd.ParseFlags(args); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if cmd.Flags().ArgsLenAtDash() != -1 || watchInteractive || watchProgressFile != "" || watchRetry {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("commands, --interactive, --progress-file and --retry are not supported by watch in a repl session"))
+	}
+	args = cmd.Flags().Args()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(args) == 0 {
+		ids := make([]int, 0, len(s.watches))
+		for id := range s.watches {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			fmt.Printf("%d: %s\n", id, strings.Join(s.watches[id].args, " "))
+		}
+		return
+	}
+
+	tmpl, err := parseWatchTemplate()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := context.WithCancel(context.Background())
+	wc, err := getWatchChan(ctx, c, args)
+	if err != nil {
+		cancel()
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	s.lastWatchID++
+	id := s.lastWatchID
+	s.watches[id] = &replWatch{args: args, cancel: cancel}
+	fmt.Printf("watch %d started\n", id)
+
+	go func() {
+		printWatchCh(c, wc, nil, tmpl, nil)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.watches[id]; ok {
+			delete(s.watches, id)
+			cancel()
+			fmt.Fprintf(os.Stderr, "watch %d is canceled by the server\n", id)
+		}
+	}()
+}
+
+// unwatch cancels the watches of the given IDs, or all of them if none.
+func (s *replSession) unwatch(args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(args) == 0 {
+		for id := range s.watches {
+			args = append(args, strconv.Itoa(id))
+		}
+	}
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		w, ok := s.watches[id]
+		if err != nil || !ok {
+			fmt.Fprintf(os.Stderr, "Error: no watch %s\n", arg)
+			continue
+		}
+		delete(s.watches, id)
+		w.cancel()
+	}
+}
+
+// complete completes the word of line ending at pos.
+func (s *replSession) complete(line string, pos int) (string, int, []string) {
+	return completeLine(line, pos, func(words []string, word string) ([]string, bool) {
+		return s.candidates(words, word)
+	})
+}
+
+// candidates returns the candidates to complete the given word following the
+// given words, sorted, and whether there are more candidates than those.
+func (s *replSession) candidates(words []string, word string) ([]string, bool) {
+	cmd := s.root
+	for _, w := range words {
+		sub, _, err := cmd.Find([]string{w})
+		if err != nil || sub == cmd {
+			break
+		}
+		cmd = sub
+	}
+
+	var names []string
+	switch {
+	case strings.HasPrefix(word, "-"):
+		cmd.Flags().VisitAll(func(f *pflag.Flag) { names = append(names, "--"+f.Name) })
+		cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) { names = append(names, "--"+f.Name) })
+	case cmd.HasAvailableSubCommands() && len(words) == commandDepth(s.root, cmd):
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				names = append(names, sub.Name())
+			}
+		}
+		if cmd == s.root {
+			names = append(names, "exit", "history", "quit", "unwatch")
+		}
+	default:
+		ctx, cancel := commandCtx(s.root)
+		defer cancel()
+		keys, more, err := listKeys(ctx, s.c, word)
+		if err != nil {
+			return nil, false
+		}
+		return keys, more
+	}
+
+	var matches []string
+	for _, n := range names {
+		if strings.HasPrefix(n, word) {
+			matches = append(matches, n)
+		}
+	}
+	sort.Strings(matches)
+	return matches, false
+}
+
+// commandDepth returns the number of words naming cmd under root.
+func commandDepth(root, cmd *cobra.Command) int {
+	n := 0
+	for ; cmd != root && cmd != nil; cmd = cmd.Parent() {
+		n++
+	}
+	return n
+}
+
+// listKeys returns the first keys with the given prefix, read by pages, and
+// whether there are more. If there are, the last key with the prefix follows
+// them, so that their common prefix is the one of all the keys.
+func listKeys(ctx context.Context, kv clientv3.KV, prefix string) (keys []string, more bool, err error) {
+	key, end := prefix, clientv3.GetPrefixRangeEnd(prefix)
+	if prefix == "" {
+		key, end = "\x00", "\x00"
+	}
+	for {
+		limit := replKeysPageSize
+		if left := replMaxCandidates - len(keys); left < limit {
+			limit = left
+		}
+		resp, err := kv.Get(ctx, key, clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithSerializable(), clientv3.WithLimit(int64(limit)))
+		if err != nil {
+			return nil, false, err
+		}
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		if !resp.More {
+			return keys, false, nil
+		}
+		if len(keys) >= replMaxCandidates {
+			break
+		}
+		key = keys[len(keys)-1] + "\x00"
+	}
+
+	resp, err := kv.Get(ctx, key, clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithSerializable(), clientv3.WithLimit(1),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
+	if err != nil {
+		return nil, false, err
+	}
+	for _, kv := range resp.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	return keys, true, nil
+}
+
+// completeLine completes the word of line ending at pos with the candidates
+// returned for it. It returns the completed line and position, and the
+// candidates to list when the word cannot be completed further.
+func completeLine(line string, pos int, candidates func(words []string, word string) ([]string, bool)) (string, int, []string) {
+	start := strings.LastIndexAny(line[:pos], " \t") + 1
+	word := line[start:pos]
+	matches, more := candidates(Argify(line[:start]), word)
+	if len(matches) == 0 {
+		return line, pos, nil
+	}
+
+	// the matches are sorted, the first and the last sharing the prefix of
+	// all of them
+	common := commonPrefix(matches[0], matches[len(matches)-1])
+	if len(matches) == 1 && !more && !strings.HasSuffix(common, "/") {
+		common += " "
+	}
+	if len(common) > len(word) {
+		return line[:start] + common + line[pos:], start + len(common), nil
+	}
+	if more {
+		// the last match only helps completing
+		matches = append(matches[:len(matches)-1:len(matches)-1], "...")
+	}
+	return line, pos, matches
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// promptReader reads the lines of a repl session, prompting with the prompt
+// it is set.
+type promptReader struct {
+	in     lineReader
+	prompt string
+	buf    []byte
+}
+
+func (r *promptReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		line, err := r.in.readLine(r.prompt)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = []byte(line + "\n")
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// scannerReader reads the lines of a session from a non-terminal input,
+// without prompts.
+type scannerReader struct {
+	sc *bufio.Scanner
+}
+
+func (r *scannerReader) readLine(string) (string, error) {
+	if !r.sc.Scan() {
+		if err := r.sc.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.sc.Text(), nil
+}
+
+// terminalReader reads the lines of a session from a terminal, with history
+// and completion. The output of the commands is written through the terminal,
+// so that the line being edited is redrawn after it.
+type terminalReader struct {
+	fd int
+	t  *term.Terminal
+
+	stdout, stderr *os.File
+	pw             *os.File
+	copied         chan struct{}
+}
+
+func newTerminalReader(fd int, complete func(line string, pos int) (string, int, []string)) (*terminalReader, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	r := &terminalReader{
+		fd:     fd,
+		t:      term.NewTerminal(readWriter{os.Stdin, os.Stdout}, replPrompt),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		pw:     pw,
+		copied: make(chan struct{}),
+	}
+	r.t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, list := complete(line, pos)
+		if len(list) != 0 {
+			fmt.Fprintln(r.t, strings.Join(list, "  "))
+		}
+		return newLine, newPos, true
+	}
+	go func() {
+		io.Copy(r.t, pr)
+		pr.Close()
+		close(r.copied)
+	}()
+	os.Stdout, os.Stderr = pw, pw
+	return r, nil
+}
+
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func (r *terminalReader) readLine(prompt string) (string, error) {
+	if w, h, err := term.GetSize(r.fd); err == nil && w > 0 {
+		r.t.SetSize(w, h)
+	}
+	state, err := term.MakeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(r.fd, state)
+	r.t.SetPrompt(prompt)
+	line, err := r.t.ReadLine()
+	if errors.Is(err, term.ErrPasteIndicator) {
+		err = nil
+	}
+	return line, err
+}
+
+func (r *terminalReader) close() {
+	os.Stdout, os.Stderr = r.stdout, r.stderr
+	r.pw.Close()
+	<-r.copied
+}
+
+// flagValue is the saved value of a flag.
+type flagValue struct {
+	value   string
+	slice   []string
+	changed bool
+}
+
+// saveFlags returns the values of the flags of cmd and its subcommands.
+func saveFlags(cmd *cobra.Command) map[*pflag.Flag]flagValue {
+	values := make(map[*pflag.Flag]flagValue)
+	visitFlags(cmd, func(f *pflag.Flag) {
+		v := flagValue{value: f.Value.String(), changed: f.Changed}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			v.slice = sv.GetSlice()
+		}
+		values[f] = v
+	})
+	return values
+}
+
+// restoreFlags restores the flags of cmd and its subcommands to the saved
+// values, and the flags added since to their defaults.
+func restoreFlags(cmd *cobra.Command, values map[*pflag.Flag]flagValue) {
+	visitFlags(cmd, func(f *pflag.Flag) {
+		v, ok := values[f]
+		if !ok {
+			v = flagValue{value: f.DefValue}
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(v.slice)
+		} else {
+			f.Value.Set(v.value)
+		}
+		f.Changed = v.changed
+	})
+}
+
+func visitFlags(cmd *cobra.Command, fn func(f *pflag.Flag)) {
+	cmd.Flags().VisitAll(fn)
+	cmd.PersistentFlags().VisitAll(fn)
+	for _, sub := range cmd.Commands() {
+		visitFlags(sub, fn)
+	}
+}
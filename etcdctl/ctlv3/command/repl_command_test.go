@@ -0,0 +1,115 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_completeLine(t *testing.T) {
+	keys := []string{"foo/a", "foo/b/1", "foo/b/2", "other"}
+	candidates := func(words []string, word string) ([]string, bool) {
+		if len(words) == 0 {
+			return []string{"get", "put"}, false
+		}
+		var matches []string
+		for _, k := range keys {
+			if strings.HasPrefix(k, word) {
+				matches = append(matches, k)
+			}
+		}
+		sort.Strings(matches)
+		return matches, false
+	}
+
+	tests := []struct {
+		line     string
+		pos      int
+		wantLine string
+		wantPos  int
+		wantList []string
+	}{
+		{line: "", pos: 0, wantLine: "", wantPos: 0, wantList: []string{"get", "put"}},
+		{line: "get o", pos: 5, wantLine: "get other ", wantPos: 10},
+		{line: "get f", pos: 5, wantLine: "get foo/", wantPos: 8},
+		{line: "get foo/", pos: 8, wantLine: "get foo/", wantPos: 8, wantList: []string{"foo/a", "foo/b/1", "foo/b/2"}},
+		{line: "get foo/b x", pos: 9, wantLine: "get foo/b/ x", wantPos: 10},
+		{line: "get none", pos: 8, wantLine: "get none", wantPos: 8},
+	}
+	for _, tt := range tests {
+		line, pos, list := completeLine(tt.line, tt.pos, candidates)
+		if line != tt.wantLine || pos != tt.wantPos || !reflect.DeepEqual(list, tt.wantList) {
+			t.Errorf("completeLine(%q, %d) = %q, %d, %q, want %q, %d, %q", tt.line, tt.pos, line, pos, list, tt.wantLine, tt.wantPos, tt.wantList)
+		}
+	}
+}
+
+func Test_completeLineMore(t *testing.T) {
+	// the last candidate is the last key with the prefix, after a gap
+	candidates := func([]string, string) ([]string, bool) {
+		return []string{"k/a", "k/b", "k/z"}, true
+	}
+	line, pos, list := completeLine("get k", 5, candidates)
+	if line != "get k/" || pos != 6 || list != nil {
+		t.Errorf("got %q, %d, %q, want the common prefix", line, pos, list)
+	}
+	line, pos, list = completeLine("get k/", 6, candidates)
+	if want := []string{"k/a", "k/b", "..."}; line != "get k/" || pos != 6 || !reflect.DeepEqual(list, want) {
+		t.Errorf("got %q, %d, %q, want the list %q", line, pos, list, want)
+	}
+}
+
+func Test_restoreFlags(t *testing.T) {
+	var (
+		prefix    bool
+		endpoints []string
+		output    string
+	)
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().StringSliceVar(&endpoints, "endpoints", []string{"a"}, "")
+	root.PersistentFlags().StringVar(&output, "write-out", "simple", "")
+	get := &cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}}
+	get.Flags().BoolVar(&prefix, "prefix", false, "")
+	root.AddCommand(get)
+
+	// the flags of the session
+	if err := root.PersistentFlags().Parse([]string{"--endpoints", "b,c"}); err != nil {
+		t.Fatal(err)
+	}
+	saved := saveFlags(root)
+
+	root.SetOut(io.Discard)
+	root.SetArgs([]string{"get", "--prefix", "--write-out", "json", "--endpoints", "d", "--help", "foo"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	restoreFlags(root, saved)
+
+	if prefix || output != "simple" || !reflect.DeepEqual(endpoints, []string{"b", "c"}) {
+		t.Errorf("restored flags prefix=%v, write-out=%q, endpoints=%q", prefix, output, endpoints)
+	}
+	if f := get.Flags().Lookup("prefix"); f.Changed {
+		t.Error("restored flag --prefix is still changed")
+	}
+	if f := get.Flags().Lookup("help"); f == nil || f.Value.String() != "false" {
+		t.Errorf("flag --help added by the command is not reset: %v", f)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var slowLogLimit int64
+
+// NewSlowLogCommand returns the cobra command for "slow-log".
+func NewSlowLogCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slow-log [options]",
+		Short: "Lists the slow requests of the etcd members with given endpoints",
+		Long: `Lists the last requests which took longer than the slow request threshold
+of the etcd members with given endpoints, the most recent first.
+
+The requests are only recorded by the members started with
+--experimental-slow-request-threshold. The time of each request is broken down
+into the time it waited, mostly for the replication of its entry, the time its
+entry took to be synced to the WAL, and the time it took to be applied.
+`,
+		Run: slowLogCommandFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().Int64Var(&slowLogLimit, "limit", 0, "Maximum number of requests to list per member, 0 for all")
+	return cmd
+}
+
+// slowLogCommandFunc executes the "slow-log" command.
+func slowLogCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("slow-log command does not take arguments"))
+	}
+	if slowLogLimit < 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("--limit must be >= 0"))
+	}
+
+	failures := 0
+	c := mustClientFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.SlowLog(ctx, ep, slowLogLimit)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get the slow log of etcd member[%s] (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		display.SlowLog(ep, *resp)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
@@ -35,12 +35,24 @@ func NewSnapshotCommand() *cobra.Command {
 	return cmd
 }
 
+var (
+	snapshotFromFollower bool
+	snapshotCompression  string
+	snapshotMaxResumes   int
+)
+
 func NewSnapshotSaveCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "save <filename>",
 		Short: "Stores an etcd node backend snapshot to a given file",
 		Run:   snapshotSaveCommandFunc,
 	}
+	cmd.Flags().BoolVar(&snapshotFromFollower, "from-follower", false, "save the snapshot from a follower once it reached the revision of the leader, the endpoints must include the leader")
+	cmd.Flags().StringVar(&snapshotCompression, "compress", "", "compress the snapshot stream sent by the server ('zstd' or 'gzip'), the saved file is not compressed")
+	cmd.Flags().StringVar(&snapshotCompression, "compression", "", "compress the snapshot stream sent by the server ('zstd' or 'gzip'), the saved file is not compressed")
+	cmd.Flags().MarkDeprecated("compression", "use --compress instead")
+	cmd.Flags().IntVar(&snapshotMaxResumes, "max-resumes", 0, "resume the snapshot at most the given number of times if its stream breaks, requires etcd v3.6 or later")
+	return cmd
 }
 
 func snapshotSaveCommandFunc(cmd *cobra.Command, args []string) {
@@ -63,7 +75,11 @@ func snapshotSaveCommandFunc(cmd *cobra.Command, args []string) {
 	defer cancel()
 
 	path := args[0]
-	version, err := snapshot.SaveWithVersion(ctx, lg, *cfg, path)
+	save := snapshot.SaveWithVersion
+	if snapshotFromFollower {
+		save = snapshot.SaveFromFollowerWithVersion
+	}
+	version, err := save(ctx, lg, *cfg, path, snapshot.WithCompression(snapshotCompression), snapshot.WithMaxResumes(snapshotMaxResumes))
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitInterrupted, err)
 	}
@@ -0,0 +1,76 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+)
+
+var (
+	topKeysLimit     int64
+	topKeysSeparator string
+	topKeysDepth     int64
+)
+
+// NewTopKeysCommand returns the cobra command for "top-keys".
+func NewTopKeysCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top-keys [options]",
+		Short: "Reports the largest keys, busiest prefixes and watch ranges of the etcd members with given endpoints",
+		Long: `Reports the keys with the largest values, the prefixes with the most revisions
+kept in the backend, and the watch ranges with the most watchers or the largest
+backlog of the etcd members with given endpoints.
+
+The revisions of a prefix include the history not compacted yet, so a prefix
+with many revisions but few keys is rewritten often. The report scans the
+backend of the members, which may take a while on large backends.
+`,
+		Run: topKeysCommandFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().Int64Var(&topKeysLimit, "limit", 10, "Number of entries of each list of the report")
+	cmd.Flags().StringVar(&topKeysSeparator, "separator", "/", "Separator of the components of the keys")
+	cmd.Flags().Int64Var(&topKeysDepth, "depth", 1, "Number of separators the prefixes end with")
+	return cmd
+}
+
+// topKeysCommandFunc executes the "top-keys" command.
+func topKeysCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("top-keys command does not take arguments"))
+	}
+
+	failures := 0
+	c := mustClientFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.TopKeys(ctx, ep, topKeysSeparator, topKeysDepth, topKeysLimit)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get the top keys of etcd member[%s] (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		display.TopKeys(ep, *resp)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
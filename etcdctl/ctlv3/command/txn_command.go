@@ -108,6 +108,11 @@ func readOps(r *bufio.Reader) (ops []clientv3.Op) {
 			break
 		}
 
+		if line == "txn" {
+			ops = append(ops, readTxnOp(r))
+			continue
+		}
+
 		op, err := parseRequestUnion(line)
 		if err != nil {
 			cobrautl.ExitWithError(cobrautl.ExitInvalidInput, err)
@@ -118,6 +123,15 @@ func readOps(r *bufio.Reader) (ops []clientv3.Op) {
 	return ops
 }
 
+// readTxnOp reads a transaction nested in the requests of another one. It has
+// the same format as the top level transaction, without prompts.
+func readTxnOp(r *bufio.Reader) clientv3.Op {
+	cmps := readCompares(r)
+	thenOps := readOps(r)
+	elseOps := readOps(r)
+	return clientv3.OpTxn(cmps, thenOps, elseOps)
+}
+
 func parseRequestUnion(line string) (*clientv3.Op, error) {
 	args := Argify(line)
 	if len(args) < 2 {
@@ -21,8 +21,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 
@@ -42,8 +47,18 @@ var (
 	watchInteractive bool
 	watchPrevKey     bool
 	progressNotify   bool
+	watchFilterPut   bool
+	watchFilterDel   bool
+	watchFragment    bool
+	watchTemplate    string
+
+	watchProgressFile string
+	watchRetry        bool
 )
 
+// watchRetryInterval is how long --retry waits before watching again.
+const watchRetryInterval = time.Second
+
 // NewWatchCommand returns the cobra command for "watch".
 func NewWatchCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -57,6 +72,12 @@ func NewWatchCommand() *cobra.Command {
 	cmd.Flags().Int64Var(&watchRev, "rev", 0, "Revision to start watching")
 	cmd.Flags().BoolVar(&watchPrevKey, "prev-kv", false, "get the previous key-value pair before the event happens")
 	cmd.Flags().BoolVar(&progressNotify, "progress-notify", false, "get periodic watch progress notification from server")
+	cmd.Flags().BoolVar(&watchFilterPut, "filter-put", false, "discard PUT events")
+	cmd.Flags().BoolVar(&watchFilterDel, "filter-delete", false, "discard DELETE events")
+	cmd.Flags().BoolVar(&watchFragment, "fragment", false, "allow the server to split large watch responses into fragments")
+	cmd.Flags().StringVar(&watchTemplate, "template", "", "Go template to print each event with instead of the output format, e.g. '{{.Type}} {{.Key}}={{.Value}}'")
+	cmd.Flags().StringVar(&watchProgressFile, "progress-file", "", "File persisting the revision of the last event delivered, to resume watching after it on restart")
+	cmd.Flags().BoolVar(&watchRetry, "retry", false, "Watch again after the last event delivered when the watch is canceled, until the events are compacted")
 
 	return cmd
 }
@@ -78,19 +99,116 @@ func watchCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
 
-	c := mustClientFromCmd(cmd)
-	wc, err := getWatchChan(c, watchArgs)
+	tmpl, err := parseWatchTemplate()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
 
-	printWatchCh(c, wc, execArgs)
+	c := mustClientFromCmd(cmd)
+	if watchProgressFile == "" && !watchRetry {
+		wc, err := getWatchChan(context.Background(), c, watchArgs)
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+		}
+		printWatchCh(c, wc, execArgs, tmpl, nil)
+		if err = c.Close(); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadConnection, err)
+		}
+		cobrautl.ExitWithError(cobrautl.ExitInterrupted, fmt.Errorf("watch is canceled by the server"))
+	}
+
+	p, err := startWatchProgress(c, watchProgressFile, watchArgs)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	for {
+		watchRev = p.rev + 1
+		wc, err := getWatchChan(context.Background(), c, watchArgs)
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+		}
+		err = printWatchCh(c, wc, execArgs, tmpl, p)
+		if err == rpctypes.ErrCompacted {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("events after revision %d are compacted, cannot resume watching", p.rev))
+		}
+		if !watchRetry {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "watching again after revision %d\n", p.rev)
+		time.Sleep(watchRetryInterval)
+	}
 	if err = c.Close(); err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitBadConnection, err)
 	}
 	cobrautl.ExitWithError(cobrautl.ExitInterrupted, fmt.Errorf("watch is canceled by the server"))
 }
 
+// watchProgress tracks the revision up to which the events are delivered,
+// persisting it to a file if set, for the watch to resume after it.
+type watchProgress struct {
+	path string
+	rev  int64
+}
+
+// startWatchProgress returns the progress of the watch to resume. It starts
+// from the revision persisted to path if any, before --rev if set, or the
+// current revision otherwise.
+func startWatchProgress(c *clientv3.Client, path string, watchArgs []string) (*watchProgress, error) {
+	p := &watchProgress{path: path}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if p.rev, err = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid progress file %q (%v)", path, err)
+			}
+			return p, nil
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+	if watchRev > 0 {
+		p.rev = watchRev - 1
+		return p, nil
+	}
+	if len(watchArgs) < 1 {
+		return nil, errBadArgsNum
+	}
+	resp, err := c.Get(context.Background(), watchArgs[0], clientv3.WithCountOnly())
+	if err != nil {
+		return nil, err
+	}
+	p.rev = resp.Header.Revision
+	return p, nil
+}
+
+// delivered records that the events up to rev are delivered.
+func (p *watchProgress) delivered(rev int64) error {
+	if rev <= p.rev {
+		return nil
+	}
+	p.rev = rev
+	if p.path == "" {
+		return nil
+	}
+	// write then rename, not to leave a partial revision behind
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = fmt.Fprintf(tmp, "%d\n", rev); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.path)
+}
+
 func watchInteractiveFunc(cmd *cobra.Command, osArgs []string, envKey, envRange string) {
 	c := mustClientFromCmd(cmd)
 
@@ -119,12 +237,17 @@ func watchInteractiveFunc(cmd *cobra.Command, osArgs []string, envKey, envRange
 				cobrautl.ExitWithError(cobrautl.ExitBadArgs, perr)
 			}
 
-			ch, err := getWatchChan(c, watchArgs)
+			tmpl, err := parseWatchTemplate()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Invalid command %s (%v)\n", l, err)
 				continue
 			}
-			go printWatchCh(c, ch, execArgs)
+			ch, err := getWatchChan(context.Background(), c, watchArgs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid command %s (%v)\n", l, err)
+				continue
+			}
+			go printWatchCh(c, ch, execArgs, tmpl, nil)
 		case "progress":
 			err := c.RequestProgress(clientv3.WithRequireLeader(context.Background()))
 			if err != nil {
@@ -138,7 +261,7 @@ func watchInteractiveFunc(cmd *cobra.Command, osArgs []string, envKey, envRange
 	}
 }
 
-func getWatchChan(c *clientv3.Client, args []string) (clientv3.WatchChan, error) {
+func getWatchChan(ctx context.Context, c *clientv3.Client, args []string) (clientv3.WatchChan, error) {
 	if len(args) < 1 {
 		return nil, errBadArgsNum
 	}
@@ -160,27 +283,44 @@ func getWatchChan(c *clientv3.Client, args []string) (clientv3.WatchChan, error)
 	if progressNotify {
 		opts = append(opts, clientv3.WithProgressNotify())
 	}
-	return c.Watch(clientv3.WithRequireLeader(context.Background()), key, opts...), nil
+	if watchFilterPut {
+		opts = append(opts, clientv3.WithFilterPut())
+	}
+	if watchFilterDel {
+		opts = append(opts, clientv3.WithFilterDelete())
+	}
+	if watchFragment {
+		opts = append(opts, clientv3.WithFragment())
+	}
+	return c.Watch(clientv3.WithRequireLeader(ctx), key, opts...), nil
 }
 
-func printWatchCh(c *clientv3.Client, ch clientv3.WatchChan, execArgs []string) {
+// printWatchCh prints the events of ch until it is closed, returning the
+// error the watch is canceled with. The events delivered are recorded to p,
+// if set.
+func printWatchCh(c *clientv3.Client, ch clientv3.WatchChan, execArgs []string, tmpl *template.Template, p *watchProgress) (err error) {
 	for resp := range ch {
 		if resp.Canceled {
-			fmt.Fprintf(os.Stderr, "watch was canceled (%v)\n", resp.Err())
+			err = resp.Err()
+			fmt.Fprintf(os.Stderr, "watch was canceled (%v)\n", err)
 		}
 		if resp.IsProgressNotify() {
 			fmt.Fprintf(os.Stdout, "progress notify: %d\n", resp.Header.Revision)
 		}
-		display.Watch(resp)
+		if tmpl != nil {
+			for _, ev := range resp.Events {
+				if err := tmpl.Execute(os.Stdout, newWatchEventData(resp, ev)); err != nil {
+					fmt.Fprintf(os.Stderr, "template error (%v)\n", err)
+				}
+			}
+		} else {
+			display.Watch(resp)
+		}
 
 		if len(execArgs) > 0 {
 			for _, ev := range resp.Events {
 				cmd := exec.CommandContext(c.Ctx(), execArgs[0], execArgs[1:]...)
-				cmd.Env = os.Environ()
-				cmd.Env = append(cmd.Env, fmt.Sprintf("ETCD_WATCH_REVISION=%d", resp.Header.Revision))
-				cmd.Env = append(cmd.Env, fmt.Sprintf("ETCD_WATCH_EVENT_TYPE=%q", ev.Type))
-				cmd.Env = append(cmd.Env, fmt.Sprintf("ETCD_WATCH_KEY=%q", ev.Kv.Key))
-				cmd.Env = append(cmd.Env, fmt.Sprintf("ETCD_WATCH_VALUE=%q", ev.Kv.Value))
+				cmd.Env = append(os.Environ(), watchEventEnv(resp, ev)...)
 				cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
 				if err := cmd.Run(); err != nil {
 					fmt.Fprintf(os.Stderr, "command %q error (%v)\n", execArgs, err)
@@ -188,7 +328,85 @@ func printWatchCh(c *clientv3.Client, ch clientv3.WatchChan, execArgs []string)
 				}
 			}
 		}
+
+		if p == nil {
+			continue
+		}
+		// a progress notification reports all the events up to its revision
+		// as delivered
+		rev := resp.Header.Revision
+		if n := len(resp.Events); n > 0 {
+			rev = resp.Events[n-1].Kv.ModRevision
+		} else if !resp.IsProgressNotify() {
+			continue
+		}
+		if perr := p.delivered(rev); perr != nil {
+			cobrautl.ExitWithError(cobrautl.ExitIO, fmt.Errorf("failed to persist watch progress (%v)", perr))
+		}
+	}
+	return err
+}
+
+// watchEventEnv returns the environment variables describing ev that are
+// passed to the command executed for each event.
+func watchEventEnv(resp clientv3.WatchResponse, ev *clientv3.Event) []string {
+	env := []string{
+		fmt.Sprintf("ETCD_WATCH_REVISION=%d", resp.Header.Revision),
+		fmt.Sprintf("ETCD_WATCH_EVENT_TYPE=%q", ev.Type),
+		fmt.Sprintf("ETCD_WATCH_KEY=%q", ev.Kv.Key),
+		fmt.Sprintf("ETCD_WATCH_VALUE=%q", ev.Kv.Value),
+		fmt.Sprintf("ETCD_WATCH_MOD_REVISION=%d", ev.Kv.ModRevision),
+	}
+	if ev.PrevKv != nil {
+		env = append(env, fmt.Sprintf("ETCD_WATCH_PREV_VALUE=%q", ev.PrevKv.Value))
+	}
+	return env
+}
+
+// watchEventData is the data a watch template is executed with.
+type watchEventData struct {
+	Type           string
+	Key            string
+	Value          string
+	Revision       int64
+	ModRevision    int64
+	CreateRevision int64
+	Version        int64
+	Lease          int64
+	// PrevValue is set if the watch was created with --prev-kv
+	// and the key existed before the event.
+	PrevValue string
+	HasPrev   bool
+}
+
+func newWatchEventData(resp clientv3.WatchResponse, ev *clientv3.Event) watchEventData {
+	d := watchEventData{
+		Type:           ev.Type.String(),
+		Key:            string(ev.Kv.Key),
+		Value:          string(ev.Kv.Value),
+		Revision:       resp.Header.Revision,
+		ModRevision:    ev.Kv.ModRevision,
+		CreateRevision: ev.Kv.CreateRevision,
+		Version:        ev.Kv.Version,
+		Lease:          ev.Kv.Lease,
+	}
+	if ev.PrevKv != nil {
+		d.PrevValue, d.HasPrev = string(ev.PrevKv.Value), true
+	}
+	return d
+}
+
+// parseWatchTemplate parses the --template flag, appending a newline so each
+// event is printed on its own line. It returns nil if the flag is not set.
+func parseWatchTemplate() (*template.Template, error) {
+	if watchTemplate == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("watch").Parse(watchTemplate + "\n")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %v", err)
 	}
+	return tmpl, nil
 }
 
 // "commandArgs" is the command arguments after "spf13/cobra" parses
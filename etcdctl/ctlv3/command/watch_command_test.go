@@ -15,8 +15,15 @@
 package command
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func Test_parseWatchArgs(t *testing.T) {
@@ -556,3 +563,78 @@ func Test_parseWatchArgs(t *testing.T) {
 		}
 	}
 }
+
+func Test_watchEventEnvAndTemplate(t *testing.T) {
+	resp := clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: 7}}
+	ev := &clientv3.Event{
+		Type:   mvccpb.PUT,
+		Kv:     &mvccpb.KeyValue{Key: []byte("foo"), Value: []byte("bar"), ModRevision: 7, CreateRevision: 3, Version: 2},
+		PrevKv: &mvccpb.KeyValue{Key: []byte("foo"), Value: []byte("baz"), ModRevision: 3, CreateRevision: 3, Version: 1},
+	}
+
+	wenv := []string{
+		"ETCD_WATCH_REVISION=7",
+		`ETCD_WATCH_EVENT_TYPE="PUT"`,
+		`ETCD_WATCH_KEY="foo"`,
+		`ETCD_WATCH_VALUE="bar"`,
+		"ETCD_WATCH_MOD_REVISION=7",
+		`ETCD_WATCH_PREV_VALUE="baz"`,
+	}
+	if env := watchEventEnv(resp, ev); !reflect.DeepEqual(env, wenv) {
+		t.Errorf("env = %q, want %q", env, wenv)
+	}
+
+	defer func() { watchTemplate = "" }()
+	watchTemplate = `{{.Type}} {{.Key}}={{.Value}} rev={{.ModRevision}} ver={{.Version}}{{if .HasPrev}} prev={{.PrevValue}}{{end}}`
+	tmpl, err := parseWatchTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, newWatchEventData(resp, ev)); err != nil {
+		t.Fatal(err)
+	}
+	if w := "PUT foo=bar rev=7 ver=2 prev=baz\n"; buf.String() != w {
+		t.Errorf("template output = %q, want %q", buf.String(), w)
+	}
+
+	watchTemplate = "{{.Type"
+	if _, err = parseWatchTemplate(); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func Test_watchProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress")
+
+	defer func() { watchRev = 0 }()
+	watchRev = 5
+	p, err := startWatchProgress(nil, path, []string{"foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.rev != 4 {
+		t.Errorf("rev = %d, want 4 to start watching at --rev", p.rev)
+	}
+
+	for _, rev := range []int64{7, 9, 8} {
+		if err = p.delivered(rev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "9\n" {
+		t.Errorf("progress file = %q, want the last revision delivered", b)
+	}
+
+	// the progress file takes precedence over --rev on restart
+	if p, err = startWatchProgress(nil, path, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if p.rev != 9 {
+		t.Errorf("rev = %d, want 9 resumed from the progress file", p.rev)
+	}
+}
@@ -51,10 +51,10 @@ func init() {
 	rootCmd.PersistentFlags().StringSliceVar(&globalFlags.Endpoints, "endpoints", []string{"127.0.0.1:2379"}, "gRPC endpoints")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.Debug, "debug", false, "enable client-side debug logging")
 
-	rootCmd.PersistentFlags().StringVarP(&globalFlags.OutputFormat, "write-out", "w", "simple", "set the output format (fields, json, protobuf, simple, table)")
+	rootCmd.PersistentFlags().StringVarP(&globalFlags.OutputFormat, "write-out", "w", "simple", "set the output format (export, fields, json, protobuf, simple, table)")
 	rootCmd.PersistentFlags().BoolVar(&globalFlags.IsHex, "hex", false, "print byte strings as hex encoded strings")
 	rootCmd.RegisterFlagCompletionFunc("write-out", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-		return []string{"fields", "json", "protobuf", "simple", "table"}, cobra.ShellCompDirectiveDefault
+		return []string{"export", "fields", "json", "protobuf", "simple", "table"}, cobra.ShellCompDirectiveDefault
 	})
 
 	rootCmd.PersistentFlags().DurationVar(&globalFlags.DialTimeout, "dial-timeout", defaultDialTimeout, "dial timeout for client connections")
@@ -79,11 +79,21 @@ func init() {
 		command.NewPutCommand(),
 		command.NewDelCommand(),
 		command.NewTxnCommand(),
+		command.NewDiffCommand(),
 		command.NewCompactionCommand(),
 		command.NewAlarmCommand(),
 		command.NewDefragCommand(),
 		command.NewEndpointCommand(),
 		command.NewMoveLeaderCommand(),
+		command.NewBulkImportCommand(),
+		command.NewKeyHistogramCommand(),
+		command.NewLogLevelCommand(),
+		command.NewSlowLogCommand(),
+		command.NewTopKeysCommand(),
+		command.NewFragmentationCommand(),
+		command.NewReadOnlyCommand(),
+		command.NewNamespaceCommand(),
+		command.NewIndexCommand(),
 		command.NewWatchCommand(),
 		command.NewVersionCommand(),
 		command.NewLeaseCommand(),
@@ -98,6 +108,7 @@ func init() {
 		command.NewCheckCommand(),
 		command.NewCompletionCommand(),
 		command.NewDowngradeCommand(),
+		command.NewREPLCommand(),
 	)
 }
 
@@ -46,6 +46,11 @@ func init() {
 		etcdutl.NewVersionCommand(),
 		etcdutl.NewCompletionCommand(),
 		etcdutl.NewMigrateCommand(),
+		etcdutl.NewWALCommand(),
+		etcdutl.NewCheckCommand(),
+		etcdutl.NewRepairCommand(),
+		etcdutl.NewCompactCommand(),
+		etcdutl.NewDelCommand(),
 	)
 }
 
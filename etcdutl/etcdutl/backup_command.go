@@ -15,15 +15,19 @@
 package etcdutl
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"time"
 
 	"github.com/spf13/cobra"
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/client/v3/snapshot"
 	"go.etcd.io/etcd/pkg/v3/idutil"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
@@ -32,6 +36,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v2store"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.etcd.io/etcd/server/v3/storage/wal"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
@@ -47,6 +52,7 @@ var (
 	backupDir    string
 	walDir       string
 	backupWalDir string
+	sinceRev     int64
 )
 
 func NewBackupCommand() *cobra.Command {
@@ -61,6 +67,7 @@ func NewBackupCommand() *cobra.Command {
 	cmd.Flags().StringVar(&walDir, "wal-dir", "", "Path to the etcd wal dir")
 	cmd.Flags().StringVar(&backupDir, "backup-dir", "", "Path to the backup dir")
 	cmd.Flags().StringVar(&backupWalDir, "backup-wal-dir", "", "Path to the backup wal dir")
+	cmd.Flags().Int64Var(&sinceRev, "since-rev", 0, "Only backup the changes of the v3 key-value store after this revision, to layer on a snapshot restored with 'etcdutl snapshot restore --incremental'")
 	cmd.Flags().BoolVar(&withV3, "with-v3", true, "Backup v3 backend data. Note -with-v3=false is not supported since etcd v3.6. Please use v3.5.x client as the last supporting this deprecated functionality.")
 	cmd.MarkFlagRequired("data-dir")
 	cmd.MarkFlagRequired("backup-dir")
@@ -72,9 +79,82 @@ func NewBackupCommand() *cobra.Command {
 }
 
 func doBackup(cmd *cobra.Command, args []string) {
+	if sinceRev > 0 {
+		HandleIncrementalBackup(dataDir, backupDir, sinceRev)
+		return
+	}
 	HandleBackup(withV3, dataDir, backupDir, walDir, backupWalDir)
 }
 
+// HandleIncrementalBackup saves the changes of the key-value store of srcDir
+// after sinceRev to "<sinceRev>-<revision>.delta" in destDir, where revision
+// is the last revision of the changes.
+func HandleIncrementalBackup(srcDir string, destDir string, sinceRev int64) error {
+	lg := GetLogger()
+
+	if err := fileutil.CreateDirAll(lg, destDir); err != nil {
+		lg.Fatal("failed creating backup dir", zap.String("backup-dir", destDir), zap.Error(err))
+	}
+
+	be := backend.NewDefaultBackend(lg, datadir.ToBackendFileName(srcDir))
+	defer be.Close()
+
+	partpath := filepath.Join(destDir, fmt.Sprintf("%d.delta.part", sinceRev))
+	f, err := os.OpenFile(partpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		lg.Fatal("failed creating incremental backup", zap.String("path", partpath), zap.Error(err))
+	}
+	defer os.RemoveAll(partpath)
+	defer f.Close()
+
+	rev, events := sinceRev, 0
+	iw := snapshot.NewIncrementalWriter(f)
+	err = mvcc.ForEachChange(be.ReadTx(), sinceRev, 0, incrementalBackupPageSize, func(evs []mvccpb.Event) error {
+		resp := &etcdserverpb.BackupResponse{
+			SinceRevision: sinceRev,
+			Events:        make([]*mvccpb.Event, len(evs)),
+		}
+		for i := range evs {
+			resp.Events[i] = &evs[i]
+		}
+		rev = evs[len(evs)-1].Kv.ModRevision
+		resp.Header = &etcdserverpb.ResponseHeader{Revision: rev}
+		events += len(evs)
+		return iw.Write(resp)
+	})
+	if err == nil && events == 0 {
+		err = iw.Write(&etcdserverpb.BackupResponse{
+			Header:        &etcdserverpb.ResponseHeader{Revision: rev},
+			SinceRevision: sinceRev,
+		})
+	}
+	if err == nil {
+		err = iw.Close()
+	}
+	if err == nil {
+		err = fileutil.Fsync(f)
+	}
+	if err != nil {
+		lg.Fatal("failed saving incremental backup", zap.Int64("since-revision", sinceRev), zap.Error(err))
+	}
+
+	destPath := filepath.Join(destDir, fmt.Sprintf("%d-%d.delta", sinceRev, rev))
+	if err = os.Rename(partpath, destPath); err != nil {
+		lg.Fatal("failed renaming incremental backup", zap.String("path", destPath), zap.Error(err))
+	}
+	lg.Info("saved incremental backup",
+		zap.String("path", destPath),
+		zap.Int64("since-revision", sinceRev),
+		zap.Int64("revision", rev),
+		zap.Int("events", events),
+	)
+	return nil
+}
+
+// incrementalBackupPageSize is the number of events read from the backend at
+// once by incremental backups.
+const incrementalBackupPageSize = 1000
+
 type desiredCluster struct {
 	clusterId types.ID
 	nodeId    types.ID
@@ -0,0 +1,142 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/verify"
+)
+
+var (
+	checkDataDir string
+	repairYes    bool
+)
+
+// NewCheckCommand returns the cobra command for "check".
+func NewCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Checks the consistency of the backend of a data directory not in use by etcd",
+		Long: `Checks the pages of the backend, the consistent index against the WAL, and the
+consistency between the MVCC, lease and auth buckets. Prints a line per issue
+found, and exits with a non-zero status if any.
+`,
+		Run: checkCommandFunc,
+	}
+	cmd.Flags().StringVar(&checkDataDir, "data-dir", "", "Required. Path to the etcd data dir")
+	cmd.MarkFlagRequired("data-dir")
+	cmd.MarkFlagDirname("data-dir")
+	return cmd
+}
+
+// NewRepairCommand returns the cobra command for "repair".
+func NewRepairCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Repairs the issues found by check in the backend of a data directory not in use by etcd",
+		Long: `Repairs the issues found by check that can be repaired, after confirmation:
+the backend with corrupted pages is rebuilt from the key-values that can still
+be read, the original file being kept with a ".corrupted" suffix; the keys
+attached to missing leases are detached from them; and the missing roles are
+revoked from the users granted them.
+`,
+		Run: repairCommandFunc,
+	}
+	cmd.Flags().StringVar(&checkDataDir, "data-dir", "", "Required. Path to the etcd data dir")
+	cmd.Flags().BoolVar(&repairYes, "yes", false, "Repair without asking for confirmation")
+	cmd.MarkFlagRequired("data-dir")
+	cmd.MarkFlagDirname("data-dir")
+	return cmd
+}
+
+func checkCommandFunc(cmd *cobra.Command, args []string) {
+	issues, err := verify.CheckBackend(GetLogger(), checkDataDir)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	printIssues(issues)
+	if len(issues) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("found %d issues in etcd data[%s]", len(issues), checkDataDir))
+	}
+}
+
+func repairCommandFunc(cmd *cobra.Command, args []string) {
+	lg := GetLogger()
+	issues, err := verify.CheckBackend(lg, checkDataDir)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	printIssues(issues)
+
+	var repairable int
+	for _, i := range issues {
+		if i.Repairable() {
+			repairable++
+		}
+	}
+	if repairable == 0 {
+		if len(issues) != 0 {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("none of the %d issues found in etcd data[%s] can be repaired", len(issues), checkDataDir))
+		}
+		fmt.Println("No issues found.")
+		return
+	}
+	if !repairYes && !confirm(os.Stdin, fmt.Sprintf("Repair %d of the %d issues in %s? [y/N] ", repairable, len(issues), datadir.ToBackendFileName(checkDataDir))) {
+		cobrautl.ExitWithError(cobrautl.ExitInterrupted, fmt.Errorf("repair aborted"))
+	}
+
+	left, err := verify.RepairBackend(lg, checkDataDir, issues)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to repair etcd data[%s] (%v)", checkDataDir, err))
+	}
+	if len(left) != 0 {
+		fmt.Println("Issues left:")
+		printIssues(left)
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("%d issues left in etcd data[%s]", len(left), checkDataDir))
+	}
+	fmt.Println("Repaired all the issues.")
+}
+
+func printIssues(issues []verify.Issue) {
+	for _, i := range issues {
+		repairable := ""
+		if i.Repairable() {
+			repairable = " (repairable)"
+		}
+		fmt.Printf("%s%s\n", i, repairable)
+	}
+}
+
+// confirm prints the prompt and returns whether the answer read from r is yes.
+func confirm(r io.Reader, prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.uber.org/zap"
+)
+
+var (
+	compactRev     int64
+	delPrefix      bool
+	delFromKey     bool
+	surgeryDefrag  bool
+	surgeryHasHash bool
+	surgeryConfirm bool
+)
+
+// DBFileOptions are the options of the offline edits of a db file.
+type DBFileOptions struct {
+	// Defrag defragments the db file once edited, to release the space freed.
+	Defrag bool
+	// HasHash tells that the db file ends with the sha256 integrity hash
+	// appended by snapshot save. The hash is verified before the db file is
+	// edited and appended back once it is.
+	HasHash bool
+}
+
+// NewCompactCommand returns the cobra command for "compact".
+func NewCompactCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact <filename> --rev <revision>",
+		Short: "Compacts the key-value history of a db file, such as a snapshot, offline",
+		Long: `Compacts the key-value history of a db file up to the given revision, then
+defragments it, so that an oversized snapshot can be trimmed before restore.
+The file must not be in use by etcd: operate on a copy of the db file of a
+member. The integrity hash of a snapshot is verified and updated with
+--has-hash.
+`,
+		Run: compactCommandFunc,
+	}
+	cmd.Flags().Int64Var(&compactRev, "rev", 0, "Required. Revision to compact the history up to")
+	cmd.Flags().BoolVar(&surgeryDefrag, "defrag", true, "Defragment the db file to release the space freed")
+	cmd.Flags().BoolVar(&surgeryHasHash, "has-hash", false, "The db file is a snapshot ending with the integrity hash appended by snapshot save")
+	cmd.MarkFlagRequired("rev")
+	return cmd
+}
+
+// NewDelCommand returns the cobra command for "del".
+func NewDelCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "del <filename> <key> [range_end]",
+		Short: "Deletes keys from a db file, such as a snapshot, offline",
+		Long: `Deletes the keys of the given range from a db file at a new revision, then
+defragments it. The deleted key-values are only released once the history is
+compacted past the new revision, with compact. The file must not be in use by
+etcd: operate on a copy of the db file of a member. The integrity hash of a
+snapshot is verified and updated with --has-hash.
+`,
+		Run: delCommandFunc,
+	}
+	cmd.Flags().BoolVar(&delPrefix, "prefix", false, "delete keys with matching prefix")
+	cmd.Flags().BoolVar(&delFromKey, "from-key", false, "delete keys that are greater than or equal to the given key using byte compare")
+	cmd.Flags().BoolVar(&surgeryDefrag, "defrag", true, "Defragment the db file to release the space freed")
+	cmd.Flags().BoolVar(&surgeryHasHash, "has-hash", false, "The db file is a snapshot ending with the integrity hash appended by snapshot save")
+	cmd.Flags().BoolVar(&surgeryConfirm, "yes", false, "Delete without asking for confirmation")
+	return cmd
+}
+
+func compactCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("compact requires exactly one argument"))
+	}
+	if err := CompactDBFile(GetLogger(), args[0], compactRev, surgeryOptions()); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to compact %s (%v)", args[0], err))
+	}
+	fmt.Printf("Compacted %s at revision %d\n", args[0], compactRev)
+}
+
+func delCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 2 || len(args) > 3 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("del requires a filename and a key or a range"))
+	}
+	key, end, err := delRange(args[1:])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	if !surgeryConfirm && !confirm(os.Stdin, fmt.Sprintf("Delete the keys of [%q, %q) from %s? [y/N] ", key, end, args[0])) {
+		cobrautl.ExitWithError(cobrautl.ExitInterrupted, fmt.Errorf("deletion aborted"))
+	}
+	n, rev, err := DeleteFromDBFile(GetLogger(), args[0], key, end, surgeryOptions())
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to delete from %s (%v)", args[0], err))
+	}
+	fmt.Printf("Deleted %d keys from %s at revision %d\n", n, args[0], rev)
+}
+
+func surgeryOptions() DBFileOptions {
+	return DBFileOptions{Defrag: surgeryDefrag, HasHash: surgeryHasHash}
+}
+
+// delRange returns the range of keys to delete, following the flags of
+// "etcdctl del".
+func delRange(args []string) (key, end []byte, err error) {
+	key = []byte(args[0])
+	if len(args) == 2 {
+		if delPrefix || delFromKey {
+			return nil, nil, fmt.Errorf("too many arguments, only accept one argument when `--prefix` or `--from-key` is set")
+		}
+		return key, []byte(args[1]), nil
+	}
+	switch {
+	case delPrefix && delFromKey:
+		return nil, nil, fmt.Errorf("`--prefix` and `--from-key` cannot be set at the same time, choose one")
+	case delPrefix:
+		if len(key) == 0 {
+			return []byte{0}, []byte{0}, nil
+		}
+		return key, prefixEnd(key), nil
+	case delFromKey:
+		if len(key) == 0 {
+			key = []byte{0}
+		}
+		return key, []byte{0}, nil
+	}
+	return key, nil, nil
+}
+
+// prefixEnd returns the end of the range of the keys with the given prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i] = end[i] + 1
+			return end[:i+1]
+		}
+	}
+	// the prefix is all 0xff, the range goes to the end of the keys
+	return []byte{0}
+}
+
+// CompactDBFile compacts the key-value history of the db file at path up to
+// rev.
+func CompactDBFile(lg *zap.Logger, path string, rev int64, opts DBFileOptions) error {
+	return withDBFile(lg, path, opts, func(kv mvcc.KV) error {
+		done, err := kv.Compact(traceutil.TODO(), rev)
+		if err != nil {
+			return err
+		}
+		<-done
+		return nil
+	})
+}
+
+// DeleteFromDBFile deletes the keys of [key, end) from the db file at path.
+// It returns the number of keys deleted and the revision they are deleted at.
+func DeleteFromDBFile(lg *zap.Logger, path string, key, end []byte, opts DBFileOptions) (n, rev int64, err error) {
+	err = withDBFile(lg, path, opts, func(kv mvcc.KV) error {
+		n, rev = kv.DeleteRange(key, end)
+		return nil
+	})
+	return n, rev, err
+}
+
+// withDBFile calls f with the store of the db file at path. The integrity
+// hash of a snapshot file is verified before f, and appended back after f and
+// the defragmentation.
+func withDBFile(lg *zap.Logger, path string, opts DBFileOptions, f func(kv mvcc.KV) error) error {
+	if opts.HasHash {
+		// bbolt would overwrite the hash, which is truncated away as for
+		// restore
+		if err := truncateHash(path); err != nil {
+			return err
+		}
+	}
+
+	cfg := backend.DefaultBackendConfig(lg)
+	cfg.Path = path
+	be := backend.New(cfg)
+	kv := mvcc.NewStore(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	err := f(kv)
+	kv.Close()
+	if err == nil && opts.Defrag {
+		err = be.Defrag()
+	}
+	if cerr := be.Close(); err == nil {
+		err = cerr
+	}
+	if opts.HasHash {
+		// the hash is appended back even on errors, the file being unusable
+		// by restore otherwise
+		if herr := appendHash(path); err == nil {
+			err = herr
+		}
+	}
+	return err
+}
+
+// truncateHash verifies the sha256 the db file at path ends with, and
+// truncates it away.
+func truncateHash(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size, err := f.Seek(-sha256.Size, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("db file too small to end with a hash (%v)", err)
+	}
+	sha := make([]byte, sha256.Size)
+	if _, err = io.ReadFull(f, sha); err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err = io.CopyN(h, f, size); err != nil {
+		return err
+	}
+	if !bytes.Equal(sha, h.Sum(nil)) {
+		return fmt.Errorf("db file does not end with its sha256, is it a snapshot?")
+	}
+	return f.Truncate(size)
+}
+
+// appendHash appends the sha256 of the db file at path, as snapshot save does.
+func appendHash(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return err
+	}
+	if _, err = f.Write(h.Sum(nil)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
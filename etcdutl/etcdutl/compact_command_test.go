@@ -0,0 +1,187 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.uber.org/zap/zaptest"
+)
+
+// createDBFile creates a db file with the keys foo0 to foo4, each put at two
+// revisions: foo<i> is put at revisions i+2 and i+7.
+func createDBFile(t *testing.T) string {
+	lg := zaptest.NewLogger(t)
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	be := backend.NewDefaultBackend(lg, path)
+	kv := mvcc.NewStore(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 5; i++ {
+			kv.Put([]byte(fmt.Sprintf("foo%d", i)), []byte(fmt.Sprintf("bar%d", round)), lease.NoLease)
+		}
+	}
+	kv.Close()
+	if err := be.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rangeDBFile returns the number of keys of [key, end) at rev in the db file
+// at path.
+func rangeDBFile(t *testing.T, path string, key, end []byte, rev int64) (int, error) {
+	lg := zaptest.NewLogger(t)
+	be := backend.NewDefaultBackend(lg, path)
+	defer be.Close()
+	kv := mvcc.NewStore(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer kv.Close()
+	r, err := kv.Range(context.TODO(), key, end, mvcc.RangeOptions{Rev: rev})
+	if err != nil {
+		return 0, err
+	}
+	return len(r.KVs), nil
+}
+
+func TestCompactDBFile(t *testing.T) {
+	path := createDBFile(t)
+	if err := CompactDBFile(zaptest.NewLogger(t), path, 7, DBFileOptions{Defrag: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rangeDBFile(t, path, []byte("foo"), []byte("fop"), 6); err != mvcc.ErrCompacted {
+		t.Errorf("range below the compact revision: err = %v, want %v", err, mvcc.ErrCompacted)
+	}
+	for rev, want := range map[int64]int{7: 5, 11: 5} {
+		n, err := rangeDBFile(t, path, []byte("foo"), []byte("fop"), rev)
+		if err != nil {
+			t.Fatalf("range at revision %d: %v", rev, err)
+		}
+		if n != want {
+			t.Errorf("range at revision %d: %d keys, want %d", rev, n, want)
+		}
+	}
+}
+
+func TestDeleteFromDBFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		key, end []byte
+		wantN    int64
+	}{
+		{name: "key", key: []byte("foo2"), wantN: 1},
+		{name: "range", key: []byte("foo1"), end: []byte("foo4"), wantN: 3},
+		{name: "prefix", key: []byte("foo"), end: prefixEnd([]byte("foo")), wantN: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createDBFile(t)
+			n, rev, err := DeleteFromDBFile(zaptest.NewLogger(t), path, tt.key, tt.end, DBFileOptions{Defrag: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.wantN || rev != 12 {
+				t.Errorf("deleted %d keys at revision %d, want %d at 12", n, rev, tt.wantN)
+			}
+
+			left, err := rangeDBFile(t, path, []byte("foo"), []byte("fop"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := 5 - int(tt.wantN); left != want {
+				t.Errorf("%d keys left, want %d", left, want)
+			}
+			// the history before the deletion is kept until compacted
+			if before, err := rangeDBFile(t, path, []byte("foo"), []byte("fop"), 11); err != nil || before != 5 {
+				t.Errorf("range before deletion: %d keys (%v), want 5", before, err)
+			}
+		})
+	}
+}
+
+func TestDBFileHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		hasHash bool
+	}{
+		{name: "with hash", hasHash: true},
+		{name: "without hash", hasHash: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg := zaptest.NewLogger(t)
+			path := createDBFile(t)
+			if tt.hasHash {
+				if err := appendHash(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			opts := DBFileOptions{Defrag: true, HasHash: tt.hasHash}
+			if _, _, err := DeleteFromDBFile(lg, path, []byte("foo0"), nil, opts); err != nil {
+				t.Fatal(err)
+			}
+			if err := CompactDBFile(lg, path, 12, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			dataDir := filepath.Join(t.TempDir(), "restored.etcd")
+			err := snapshot.NewV3(lg).Restore(snapshot.RestoreConfig{
+				SnapshotPath:        path,
+				Name:                "default",
+				OutputDataDir:       dataDir,
+				PeerURLs:            []string{"http://127.0.0.1:2380"},
+				InitialCluster:      "default=http://127.0.0.1:2380",
+				InitialClusterToken: "etcd-cluster",
+				SkipHashCheck:       !tt.hasHash,
+			})
+			if err != nil {
+				t.Fatalf("restore failed: %v", err)
+			}
+			n, err := rangeDBFile(t, filepath.Join(dataDir, "member", "snap", "db"), []byte("foo"), []byte("fop"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 4 {
+				t.Errorf("restored %d keys, want 4", n)
+			}
+		})
+	}
+}
+
+func TestDBFileHashMismatch(t *testing.T) {
+	path := createDBFile(t)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = CompactDBFile(zaptest.NewLogger(t), path, 7, DBFileOptions{HasHash: true}); err == nil {
+		t.Fatal("expected compaction of a db file without hash to fail with --has-hash")
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != fi.Size() {
+		t.Errorf("db file size changed from %d to %d", fi.Size(), after.Size())
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 
 	"github.com/dustin/go-humanize"
 )
@@ -31,6 +32,7 @@ var (
 
 type printer interface {
 	DBStatus(snapshot.Status)
+	WALRecord(wal.DumpedRecord)
 }
 
 func NewPrinter(printerType string) printer {
@@ -63,7 +65,8 @@ func newPrinterUnsupported(n string) printer {
 	return &printerUnsupported{printerRPC{nil, f}}
 }
 
-func (p *printerUnsupported) DBStatus(snapshot.Status) { p.p(nil) }
+func (p *printerUnsupported) DBStatus(snapshot.Status)   { p.p(nil) }
+func (p *printerUnsupported) WALRecord(wal.DumpedRecord) { p.p(nil) }
 
 func makeDBStatusTable(ds snapshot.Status) (hdr []string, rows [][]string) {
 	hdr = []string{"hash", "revision", "total keys", "total size", "version"}
@@ -20,6 +20,7 @@ import (
 	"os"
 
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
 type jsonPrinter struct {
@@ -34,6 +35,36 @@ func newJSONPrinter() printer {
 
 func (p *jsonPrinter) DBStatus(r snapshot.Status) { printJSON(r) }
 
+func (p *jsonPrinter) WALRecord(r wal.DumpedRecord) {
+	jr := struct {
+		File       string `json:"file"`
+		Offset     int64  `json:"offset"`
+		Type       string `json:"type"`
+		EntryType  string `json:"entry_type,omitempty"`
+		Term       uint64 `json:"term,omitempty"`
+		Index      uint64 `json:"index,omitempty"`
+		Vote       uint64 `json:"vote,omitempty"`
+		Commit     uint64 `json:"commit,omitempty"`
+		Crc        uint32 `json:"crc,omitempty"`
+		Compressed bool   `json:"compressed,omitempty"`
+		Encrypted  bool   `json:"encrypted,omitempty"`
+		// Data is the entry data, or the metadata.
+		Data []byte `json:"data,omitempty"`
+		// Description is the entry as printed by the simple format.
+		Description string `json:"description,omitempty"`
+	}{File: r.File, Offset: r.Offset, Type: r.Type, Crc: r.Crc, Compressed: r.Compressed, Encrypted: r.Encrypted, Data: r.Metadata}
+	switch {
+	case r.Entry != nil:
+		jr.EntryType, jr.Term, jr.Index, jr.Data = r.Entry.Type.String(), r.Entry.Term, r.Entry.Index, r.Entry.Data
+		jr.Description = describeWALRecord(r)
+	case r.State != nil:
+		jr.Term, jr.Vote, jr.Commit = r.State.Term, r.State.Vote, r.State.Commit
+	case r.Snapshot != nil:
+		jr.Term, jr.Index = r.Snapshot.Term, r.Snapshot.Index
+	}
+	printJSON(jr)
+}
+
 // !!! Share ??
 func printJSON(v interface{}) {
 	b, err := json.Marshal(v)
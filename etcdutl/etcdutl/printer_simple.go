@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
 type simplePrinter struct {
@@ -30,3 +31,7 @@ func (s *simplePrinter) DBStatus(ds snapshot.Status) {
 		fmt.Println(strings.Join(row, ", "))
 	}
 }
+
+func (s *simplePrinter) WALRecord(r wal.DumpedRecord) {
+	fmt.Printf("%s:%d %s %s\n", r.File, r.Offset, r.Type, describeWALRecord(r))
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+)
+
+var (
+	walDumpStartIndex uint64
+	walDumpEndIndex   uint64
+	walDumpTerm       uint64
+	walDumpTypes      []string
+)
+
+// walDumpEntryTypes maps the --type names of entries to their raft type.
+var walDumpEntryTypes = map[string]raftpb.EntryType{
+	"normal":         raftpb.EntryNormal,
+	"conf-change":    raftpb.EntryConfChange,
+	"conf-change-v2": raftpb.EntryConfChangeV2,
+}
+
+// NewWALCommand returns the cobra command for "wal".
+func NewWALCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wal <subcommand>",
+		Short: "Inspects the WAL of an etcd member offline",
+	}
+	cmd.AddCommand(newWALDumpCommand())
+	return cmd
+}
+
+func newWALDumpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump <dir>",
+		Short: "Decodes the records of a WAL directory, or of the WAL of a data directory",
+		Long: `Prints the records of the WAL in the order they are laid out, including the
+entries overwritten by later terms and the ones preceding the last snapshot.
+
+When --write-out is set to simple, a line is printed per record. It is set to
+json to print a JSON object per record. Entry data that is encrypted is printed
+as is, the key provider not being available offline.
+`,
+		Run: walDumpCommandFunc,
+	}
+	cmd.Flags().Uint64Var(&walDumpStartIndex, "start-index", 0, "Only print the entries and snapshots from this index")
+	cmd.Flags().Uint64Var(&walDumpEndIndex, "end-index", 0, "Only print the entries and snapshots up to this index (0 for no limit)")
+	cmd.Flags().Uint64Var(&walDumpTerm, "term", 0, "Only print the entries, hard states and snapshots of this term (0 for any)")
+	cmd.Flags().StringSliceVar(&walDumpTypes, "type", nil, "Only print the records of these types: metadata, state, snapshot, crc, key, entry, or the entries of type normal, conf-change, conf-change-v2")
+	return cmd
+}
+
+func walDumpCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("wal dump requires exactly one argument"))
+	}
+	f, err := newWALDumpFilter(walDumpStartIndex, walDumpEndIndex, walDumpTerm, walDumpTypes)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	printer := initPrinterFromCmd(cmd)
+
+	dir := args[0]
+	if walDir := datadir.ToWalDir(dir); wal.Exist(walDir) {
+		dir = walDir
+	}
+	err = wal.Dump(GetLogger(), dir, func(r wal.DumpedRecord) error {
+		if f.match(r) {
+			printer.WALRecord(r)
+		}
+		return nil
+	})
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
+type walDumpFilter struct {
+	startIndex, endIndex uint64
+	term                 uint64
+	// types holds the selected record types, all of them if empty.
+	types map[string]bool
+}
+
+func newWALDumpFilter(startIndex, endIndex, term uint64, types []string) (*walDumpFilter, error) {
+	if endIndex != 0 && endIndex < startIndex {
+		return nil, fmt.Errorf("--end-index %d is lower than --start-index %d", endIndex, startIndex)
+	}
+	f := &walDumpFilter{startIndex: startIndex, endIndex: endIndex, term: term, types: make(map[string]bool)}
+	for _, t := range types {
+		switch t {
+		case wal.RecordMetadata, wal.RecordState, wal.RecordSnapshot, wal.RecordCRC, wal.RecordKey, wal.RecordEntry:
+		default:
+			if _, ok := walDumpEntryTypes[t]; !ok {
+				return nil, fmt.Errorf("unknown record type %q", t)
+			}
+		}
+		f.types[t] = true
+	}
+	return f, nil
+}
+
+// match returns whether the record is printed. The records with no index,
+// or no term, are only printed if not filtered by index, or by term.
+func (f *walDumpFilter) match(r wal.DumpedRecord) bool {
+	var index, term uint64
+	var hasIndex, hasTerm bool
+	switch {
+	case r.Entry != nil:
+		index, term, hasIndex, hasTerm = r.Entry.Index, r.Entry.Term, true, true
+	case r.Snapshot != nil:
+		index, term, hasIndex, hasTerm = r.Snapshot.Index, r.Snapshot.Term, true, true
+	case r.State != nil:
+		term, hasTerm = r.State.Term, true
+	}
+
+	if f.startIndex != 0 || f.endIndex != 0 {
+		if !hasIndex || index < f.startIndex || (f.endIndex != 0 && index > f.endIndex) {
+			return false
+		}
+	}
+	if f.term != 0 && (!hasTerm || term != f.term) {
+		return false
+	}
+	if len(f.types) == 0 || f.types[r.Type] {
+		return true
+	}
+	for name, typ := range walDumpEntryTypes {
+		if f.types[name] && r.Entry != nil && r.Entry.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// describeEntryData formats the data of the normal entries as the request
+// they carry.
+func describeEntryData(data []byte) string {
+	var rr etcdserverpb.InternalRaftRequest
+	if len(data) != 0 && rr.Unmarshal(data) == nil {
+		return strings.TrimSpace(rr.String())
+	}
+	return fmt.Sprintf("%q", data)
+}
+
+func describeWALRecord(r wal.DumpedRecord) string {
+	var desc string
+	switch {
+	case r.Entry != nil && r.Encrypted:
+		desc = fmt.Sprintf("%d/%d %s (encrypted)", r.Entry.Term, r.Entry.Index, r.Entry.Type)
+	case r.Entry != nil:
+		desc = raft.DescribeEntry(*r.Entry, describeEntryData)
+	case r.State != nil:
+		desc = fmt.Sprintf("term=%d vote=%x commit=%d", r.State.Term, r.State.Vote, r.State.Commit)
+	case r.Snapshot != nil:
+		desc = fmt.Sprintf("term=%d index=%d", r.Snapshot.Term, r.Snapshot.Index)
+	case r.Type == wal.RecordCRC:
+		desc = fmt.Sprintf("%08x", r.Crc)
+	case r.Type == wal.RecordMetadata:
+		var md etcdserverpb.Metadata
+		if err := md.Unmarshal(r.Metadata); err != nil {
+			desc = fmt.Sprintf("%q", r.Metadata)
+		} else {
+			desc = fmt.Sprintf("member=%x cluster=%x", md.NodeID, md.ClusterID)
+		}
+	}
+	if r.Compressed {
+		desc += " (compressed)"
+	}
+	return desc
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"os"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.uber.org/zap"
+)
+
+// lastRevision returns the revision of the last key-value stored in the db
+// at dbPath.
+func lastRevision(dbPath string) (rev int64, err error) {
+	db, err := bolt.Open(dbPath, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(schema.Key.Name())
+		if b == nil {
+			return nil
+		}
+		if k, _ := b.Cursor().Last(); k != nil {
+			rev = bytesToRev(k).main
+		}
+		return nil
+	})
+	return rev, err
+}
+
+// applyIncrementals layers the incremental backups on the key-values of be,
+// which are up to date with revision rev. Each incremental backup must start
+// at most at the revision the previous ones are up to date with.
+func (s *v3Manager) applyIncrementals(be backend.Backend, rev int64) error {
+	w := mvcc.NewChangeWriter(be.BatchTx())
+	for _, path := range s.incrementalPaths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		until := rev
+		err = snapshot.ReadIncremental(f, func(resp *etcdserverpb.BackupResponse) error {
+			if resp.SinceRevision > rev {
+				return fmt.Errorf("incremental backup %q starts after revision %d, the changes in between are missing", path, rev)
+			}
+			if resp.Header != nil && resp.Header.Revision > until {
+				until = resp.Header.Revision
+			}
+			evs := resp.Events[:0]
+			for _, ev := range resp.Events {
+				if ev.Kv != nil && ev.Kv.ModRevision > rev {
+					evs = append(evs, ev)
+				}
+			}
+			if len(evs) == 0 {
+				return nil
+			}
+			events := make([]mvccpb.Event, len(evs))
+			for i := range evs {
+				events[i] = *evs[i]
+			}
+			return w.Write(events)
+		})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not apply incremental backup %q (%v)", path, err)
+		}
+		s.lg.Info(
+			"applied incremental backup",
+			zap.String("path", path),
+			zap.Int64("since-revision", rev),
+			zap.Int64("revision", until),
+		)
+		rev = until
+	}
+	be.ForceCommit()
+	return nil
+}
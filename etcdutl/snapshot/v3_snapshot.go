@@ -82,7 +82,8 @@ type v3Manager struct {
 	snapDir   string
 	cl        *membership.RaftCluster
 
-	skipHashCheck bool
+	skipHashCheck    bool
+	incrementalPaths []string
 }
 
 // hasChecksum returns "true" if the file size "n"
@@ -202,6 +203,12 @@ type RestoreConfig struct {
 	// SkipHashCheck is "true" to ignore snapshot integrity hash value
 	// (required if copied from data directory).
 	SkipHashCheck bool
+
+	// IncrementalPaths are the paths of the incremental backups, saved by
+	// "etcdutl backup --since-rev", to layer in order on the snapshot. Leases
+	// are not part of incremental backups, the restored keys keep the leases
+	// of the snapshot.
+	IncrementalPaths []string
 }
 
 // Restore restores a new etcd data directory from given snapshot file.
@@ -252,6 +259,7 @@ func (s *v3Manager) Restore(cfg RestoreConfig) error {
 	s.walDir = walDir
 	s.snapDir = filepath.Join(dataDir, "member", "snap")
 	s.skipHashCheck = cfg.SkipHashCheck
+	s.incrementalPaths = cfg.IncrementalPaths
 
 	s.lg.Info(
 		"restoring snapshot",
@@ -299,6 +307,13 @@ func (s *v3Manager) saveDB() error {
 		return err
 	}
 
+	var rev int64
+	if len(s.incrementalPaths) > 0 {
+		if rev, err = lastRevision(s.outDbPath()); err != nil {
+			return err
+		}
+	}
+
 	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
 	defer be.Close()
 
@@ -307,6 +322,9 @@ func (s *v3Manager) saveDB() error {
 		return err
 	}
 
+	if len(s.incrementalPaths) > 0 {
+		return s.applyIncrementals(be, rev)
+	}
 	return nil
 }
 
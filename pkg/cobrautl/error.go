@@ -34,7 +34,17 @@ const (
 	ExitClusterNotHealthy = 5
 )
 
+// exit terminates the process on errors, see SetExit.
+var exit = os.Exit
+
 func ExitWithError(code int, err error) {
 	fmt.Fprintln(os.Stderr, "Error:", err)
-	os.Exit(code)
+	exit(code)
+}
+
+// SetExit replaces the function ExitWithError terminates the process with,
+// for commands run in-process such as by an interactive session. The given
+// function must not return, callers of ExitWithError relying on it.
+func SetExit(f func(code int)) {
+	exit = f
 }
@@ -40,6 +40,7 @@ type ExpectProcess struct {
 	mu    sync.Mutex // protects lines and err
 	lines []string
 	count int // increment whenever new line gets added
+	cur   int // current reading line
 	err   error
 
 	// StopSignal is the signal Stop sends to the process; defaults to SIGKILL.
@@ -154,18 +155,32 @@ func (ep *ExpectProcess) Signal(sig os.Signal) error {
 // TODO: Close should expose underlying process failure by default.
 func (ep *ExpectProcess) Close() error { return ep.close(false) }
 
-func (ep *ExpectProcess) close(kill bool) error {
+// Wait waits for the expect process to exit and returns its exit error,
+// including a non-zero exit status which Close ignores.
+func (ep *ExpectProcess) Wait() error {
 	if ep.cmd == nil {
 		return ep.err
 	}
-	if kill {
-		ep.Signal(ep.StopSignal)
-	}
+	return ep.wait()
+}
 
+func (ep *ExpectProcess) wait() error {
 	err := ep.cmd.Wait()
 	ep.fpty.Close()
 	ep.wg.Wait()
+	ep.cmd = nil
+	return err
+}
+
+func (ep *ExpectProcess) close(kill bool) error {
+	if ep.cmd == nil {
+		return ep.err
+	}
+	if kill {
+		ep.Signal(ep.StopSignal)
+	}
 
+	err := ep.wait()
 	if err != nil {
 		if !kill && strings.Contains(err.Error(), "exit status") {
 			// non-zero exit code
@@ -174,8 +189,6 @@ func (ep *ExpectProcess) close(kill bool) error {
 			err = nil
 		}
 	}
-
-	ep.cmd = nil
 	return err
 }
 
@@ -193,6 +206,27 @@ func (ep *ExpectProcess) ProcessError() error {
 	return ep.err
 }
 
+// ReadLine returns the next line not yet returned by ReadLine,
+// or an empty string if no new line has been read from the process.
+func (ep *ExpectProcess) ReadLine() string {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.count > ep.cur {
+		line := ep.lines[ep.cur]
+		ep.cur++
+		return line
+	}
+	return ""
+}
+
+// Err returns the error which ended reading the output of the process,
+// e.g. once the process exited, or nil while the output is still read.
+func (ep *ExpectProcess) Err() error {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.err
+}
+
 func (ep *ExpectProcess) Lines() []string {
 	ep.mu.Lock()
 	defer ep.mu.Unlock()
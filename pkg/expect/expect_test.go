@@ -82,6 +82,38 @@ func TestLineCount(t *testing.T) {
 	}
 }
 
+func TestReadLine(t *testing.T) {
+	ep, err := NewExpect("printf", "1\n2\n3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ep.Expect("3"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"1\r\n", "2\r\n", "3", ""} {
+		if l := ep.ReadLine(); l != want {
+			t.Fatalf("got %q, expected %q", l, want)
+		}
+	}
+	if cerr := ep.Close(); cerr != nil {
+		t.Fatal(cerr)
+	}
+}
+
+func TestWait(t *testing.T) {
+	ep, err := NewExpect("sh", "-c", "echo done; exit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ep.Expect("done"); err != nil {
+		t.Fatal(err)
+	}
+	werr := "exit status 3"
+	if err = ep.Wait(); err == nil || err.Error() != werr {
+		t.Fatalf("got error %v, wanted error %s", err, werr)
+	}
+}
+
 func TestSend(t *testing.T) {
 	ep, err := NewExpect("tr", "a", "b")
 	if err != nil {
@@ -0,0 +1,393 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+)
+
+const (
+	optOIDCIssuer         = "issuer"
+	optOIDCAudience       = "audience"
+	optOIDCJWKSFile       = "jwks-file"
+	optOIDCUsernameClaim  = "username-claim"
+	optOIDCUsernamePrefix = "username-prefix"
+	optOIDCGroupsClaim    = "groups-claim"
+	optOIDCGroupRoles     = "group-roles"
+)
+
+var knownOIDCOptions = map[string]bool{
+	optOIDCIssuer:         true,
+	optOIDCAudience:       true,
+	optOIDCJWKSFile:       true,
+	optOIDCUsernameClaim:  true,
+	optOIDCUsernamePrefix: true,
+	optOIDCGroupsClaim:    true,
+	optOIDCGroupRoles:     true,
+}
+
+const (
+	defaultOIDCUsernameClaim  = "sub"
+	defaultOIDCUsernamePrefix = "oidc:"
+	defaultOIDCGroupsClaim    = "groups"
+
+	// oidcKeysRefreshInterval is the least time between two fetches of the
+	// keys of the issuer, which tokens signed with unknown keys trigger.
+	oidcKeysRefreshInterval = time.Minute
+	oidcRequestTimeout      = 10 * time.Second
+)
+
+// tokenOIDC validates the ID tokens of an OpenID Connect issuer, and hands the
+// other tokens to the token provider of the auth store users. The users of
+// the ID tokens are not users of the auth store, their roles are mapped from
+// their groups by an explicit mapping, a group never granting the role of the
+// same name by itself.
+type tokenOIDC struct {
+	TokenProvider
+
+	lg             *zap.Logger
+	issuer         string
+	audience       string
+	usernameClaim  string
+	usernamePrefix string
+	groupsClaim    string
+	// groupRoles maps the groups to roles, the groups not in it granting no
+	// role.
+	groupRoles map[string][]string
+	keys       *oidcKeySet
+}
+
+// NewTokenProviderOIDC returns a token provider validating the ID tokens of
+// the OpenID Connect issuer given by opts, and handing the other tokens to
+// base. The options are comma separated key=value pairs:
+//
+//	issuer           URL of the issuer, required
+//	audience         client ID the tokens are issued to, required
+//	jwks-file        file of the JSON web key set the tokens are signed with,
+//	                 fetched from the issuer if not given
+//	username-claim   claim holding the user name, "sub" by default
+//	username-prefix  prefix added to the user names, "oidc:" by default
+//	groups-claim     claim holding the groups, "groups" by default
+//	group-roles      JSON file mapping each group to a list of roles,
+//	                 required
+func NewTokenProviderOIDC(lg *zap.Logger, base TokenProvider, opts string) (TokenProvider, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	optMap, err := decomposeOptPairs(lg, strings.Split(opts, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for k := range optMap {
+		if !knownOIDCOptions[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		lg.Warn("unknown OIDC options", zap.Strings("keys", unknown))
+	}
+
+	t := &tokenOIDC{
+		TokenProvider:  base,
+		lg:             lg,
+		issuer:         optMap[optOIDCIssuer],
+		audience:       optMap[optOIDCAudience],
+		usernameClaim:  defaultOIDCUsernameClaim,
+		usernamePrefix: defaultOIDCUsernamePrefix,
+		groupsClaim:    defaultOIDCGroupsClaim,
+	}
+	if t.issuer == "" || t.audience == "" {
+		lg.Error("OIDC issuer and audience are required", zap.String("issuer", t.issuer), zap.String("audience", t.audience))
+		return nil, ErrInvalidAuthOpts
+	}
+	// Mapping the groups to the roles of the same name would let whoever
+	// manages the groups of the issuer grant any role, root included.
+	file := optMap[optOIDCGroupRoles]
+	if file == "" {
+		lg.Error("OIDC group roles are required")
+		return nil, ErrInvalidAuthOpts
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &t.groupRoles); err != nil {
+		return nil, fmt.Errorf("invalid OIDC group roles: %v", err)
+	}
+	if v, ok := optMap[optOIDCUsernameClaim]; ok {
+		t.usernameClaim = v
+	}
+	if v, ok := optMap[optOIDCUsernamePrefix]; ok {
+		t.usernamePrefix = v
+	}
+	if v, ok := optMap[optOIDCGroupsClaim]; ok {
+		t.groupsClaim = v
+	}
+	t.keys = &oidcKeySet{lg: lg, issuer: t.issuer, client: &http.Client{Timeout: oidcRequestTimeout}}
+	if file := optMap[optOIDCJWKSFile]; file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if t.keys.keys, err = parseJWKS(b); err != nil {
+			return nil, err
+		}
+		t.keys.static = true
+	}
+	return t, nil
+}
+
+func (t *tokenOIDC) info(ctx context.Context, token string, rev uint64) (*AuthInfo, bool) {
+	// the ID tokens may come as bearer tokens through the gRPC gateway
+	bearer := strings.TrimPrefix(token, "Bearer ")
+	if !t.isIssuedToken(bearer) {
+		return t.TokenProvider.info(ctx, token, rev)
+	}
+	token = bearer
+
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unsupported signing method %q", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return t.keys.get(kid)
+	})
+	if err != nil {
+		t.lg.Warn("failed to parse an OIDC token", zap.Error(err))
+		return nil, false
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !parsed.Valid || !ok {
+		t.lg.Warn("failed to obtain claims from an OIDC token")
+		return nil, false
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) || !claims.VerifyAudience(t.audience, true) {
+		t.lg.Warn("OIDC token without expiration or issued to another audience")
+		return nil, false
+	}
+
+	username, _ := claims[t.usernameClaim].(string)
+	if username == "" {
+		t.lg.Warn("OIDC token without user name", zap.String("claim", t.usernameClaim))
+		return nil, false
+	}
+	username = t.usernamePrefix + username
+	roles := t.roles(claims[t.groupsClaim])
+	if len(roles) == 0 {
+		t.lg.Warn("OIDC token granting no role", zap.String("user-name", username))
+		return nil, false
+	}
+	return &AuthInfo{Username: username, Revision: rev, Roles: roles}, true
+}
+
+// isIssuedToken returns whether token looks like an ID token of the issuer.
+// Its signature is not verified.
+func (t *tokenOIDC) isIssuedToken(token string) bool {
+	if strings.Count(token, ".") != 2 {
+		return false
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return false
+	}
+	return claims.VerifyIssuer(t.issuer, true)
+}
+
+// roles returns the sorted roles the groups of a token are mapped to.
+func (t *tokenOIDC) roles(claim interface{}) []string {
+	var groups []string
+	switch v := claim.(type) {
+	case string:
+		groups = []string{v}
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	set := make(map[string]struct{})
+	for _, g := range groups {
+		for _, r := range t.groupRoles[g] {
+			set[r] = struct{}{}
+		}
+	}
+	roles := make([]string, 0, len(set))
+	for r := range set {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// oidcKeySet holds the public keys of an issuer, fetched again when a token
+// is signed with an unknown key, unless they are static.
+type oidcKeySet struct {
+	lg     *zap.Logger
+	issuer string
+	client *http.Client
+	static bool
+
+	mu      sync.Mutex
+	keys    map[string]interface{}
+	fetched time.Time
+}
+
+func (s *oidcKeySet) get(kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.keys[kid]; ok {
+		return k, nil
+	}
+	if s.static || time.Since(s.fetched) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	s.fetched = time.Now()
+	keys, err := s.fetch()
+	if err != nil {
+		s.lg.Warn("failed to fetch the OIDC issuer keys", zap.String("issuer", s.issuer), zap.Error(err))
+		return nil, err
+	}
+	s.keys = keys
+	if k, ok := s.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// fetch discovers the key set of the issuer and fetches it.
+func (s *oidcKeySet) fetch() (map[string]interface{}, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := s.getJSON(strings.TrimSuffix(s.issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != s.issuer {
+		return nil, fmt.Errorf("discovered issuer %q does not match %q", discovery.Issuer, s.issuer)
+	}
+	var jwks json.RawMessage
+	if err := s.getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	return parseJWKS(jwks)
+}
+
+func (s *oidcKeySet) getJSON(url string, v interface{}) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q getting %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseJWKS returns the RSA and EC signing keys of a JSON web key set by ID.
+func parseJWKS(b []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("invalid JSON web key set: %v", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeJWKInt(k.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := decodeJWKInt(k.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				return nil, fmt.Errorf("unsupported curve %q of key %q", k.Crv, k.Kid)
+			}
+			x, err := decodeJWKInt(k.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := decodeJWKInt(k.Y)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing key in the JSON web key set")
+	}
+	return keys, nil
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON web key: %v", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
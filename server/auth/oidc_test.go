@@ -0,0 +1,260 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"go.uber.org/zap/zaptest"
+)
+
+const oidcTestIssuer = "https://sso.example.com"
+
+func newOIDCTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func oidcTestJWKS(kid string, key *rsa.PublicKey) []byte {
+	b, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	return b
+}
+
+func signOIDCTestToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	tk := jwt.NewWithClaims(method, claims)
+	tk.Header["kid"] = kid
+	token, err := tk.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func oidcTestClaims(mutate func(jwt.MapClaims)) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":    oidcTestIssuer,
+		"aud":    []string{"etcd", "other"},
+		"sub":    "alice",
+		"groups": []string{"dev", "ops"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+	return claims
+}
+
+func TestOIDCInfo(t *testing.T) {
+	dir := t.TempDir()
+	key := newOIDCTestKey(t)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err := os.WriteFile(jwksFile, oidcTestJWKS("k1", &key.PublicKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rolesFile := filepath.Join(dir, "roles.json")
+	if err := os.WriteFile(rolesFile, []byte(`{"dev": ["reader"], "ops": ["reader", "writer"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.enable()
+	defer base.disable()
+	tp, err := NewTokenProviderOIDC(zaptest.NewLogger(t), base, fmt.Sprintf("issuer=%s,audience=etcd,jwks-file=%s,group-roles=%s", oidcTestIssuer, jwksFile, rolesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey := newOIDCTestKey(t)
+	tcs := []struct {
+		name  string
+		token string
+		want  *AuthInfo
+	}{
+		{
+			name:  "valid",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(nil)),
+			want:  &AuthInfo{Username: "oidc:alice", Revision: 10, Roles: []string{"reader", "writer"}},
+		},
+		{
+			name:  "bearer",
+			token: "Bearer " + signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(nil)),
+			want:  &AuthInfo{Username: "oidc:alice", Revision: 10, Roles: []string{"reader", "writer"}},
+		},
+		{
+			name:  "expired",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })),
+		},
+		{
+			name:  "no expiration",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { delete(c, "exp") })),
+		},
+		{
+			name:  "other audience",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { c["aud"] = "other" })),
+		},
+		{
+			name:  "other key",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", otherKey, oidcTestClaims(nil)),
+		},
+		{
+			name:  "unknown key",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k2", key, oidcTestClaims(nil)),
+		},
+		{
+			name:  "HMAC",
+			token: signOIDCTestToken(t, jwt.SigningMethodHS256, "k1", []byte("secret"), oidcTestClaims(nil)),
+		},
+		{
+			name:  "no user name",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { delete(c, "sub") })),
+		},
+		{
+			name:  "no role",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { c["groups"] = []string{"sales"} })),
+		},
+		{
+			name:  "unmapped root group",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { c["groups"] = []string{"root", "reader"} })),
+		},
+		{
+			name:  "unmapped root group besides mapped group",
+			token: signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, oidcTestClaims(func(c jwt.MapClaims) { c["groups"] = []string{"root", "dev"} })),
+			want:  &AuthInfo{Username: "oidc:alice", Revision: 10, Roles: []string{"reader"}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ai, ok := tp.info(context.TODO(), tc.token, 10)
+			if ok != (tc.want != nil) {
+				t.Fatalf("expected the token to be valid: %v, got %v", tc.want != nil, ok)
+			}
+			if !reflect.DeepEqual(ai, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, ai)
+			}
+		})
+	}
+
+	// the tokens of the auth store users are handed to the base provider
+	ctx := context.WithValue(context.WithValue(context.TODO(), AuthenticateParamIndex{}, uint64(1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
+	token, err := tp.assign(ctx, "bob", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ai, ok := tp.info(context.TODO(), token, 10)
+	if !ok || ai.Username != "bob" || ai.Roles != nil {
+		t.Errorf("expected the simple token of bob, got %+v", ai)
+	}
+}
+
+func TestOIDCKeysDiscovery(t *testing.T) {
+	key := newOIDCTestKey(t)
+	var jwks []byte
+	var fetches int
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, srv.URL, srv.URL+"/keys")
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write(jwks)
+	})
+
+	rolesFile := filepath.Join(t.TempDir(), "roles.json")
+	if err := os.WriteFile(rolesFile, []byte(`{"dev": ["dev"], "ops": ["ops"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tp, err := NewTokenProviderOIDC(zaptest.NewLogger(t), &tokenNop{}, fmt.Sprintf("issuer=%s,audience=etcd,username-claim=email,username-prefix=,group-roles=%s", srv.URL, rolesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := oidcTestClaims(func(c jwt.MapClaims) {
+		c["iss"] = srv.URL
+		c["email"] = "alice@example.com"
+	})
+
+	jwks = oidcTestJWKS("k1", &key.PublicKey)
+	ai, ok := tp.info(context.TODO(), signOIDCTestToken(t, jwt.SigningMethodRS256, "k1", key, claims), 1)
+	if !ok {
+		t.Fatal("expected the token signed with the key of the issuer to be valid")
+	}
+	if want := (&AuthInfo{Username: "alice@example.com", Revision: 1, Roles: []string{"dev", "ops"}}); !reflect.DeepEqual(ai, want) {
+		t.Errorf("expected %+v, got %+v", want, ai)
+	}
+
+	// the keys are not fetched again before the refresh interval
+	key2 := newOIDCTestKey(t)
+	jwks = oidcTestJWKS("k2", &key2.PublicKey)
+	if _, ok = tp.info(context.TODO(), signOIDCTestToken(t, jwt.SigningMethodRS256, "k2", key2, claims), 1); ok {
+		t.Error("expected the token signed with a new key to be invalid before the refresh interval")
+	}
+	tp.(*tokenOIDC).keys.fetched = time.Now().Add(-oidcKeysRefreshInterval)
+	if _, ok = tp.info(context.TODO(), signOIDCTestToken(t, jwt.SigningMethodRS256, "k2", key2, claims), 1); !ok {
+		t.Error("expected the token signed with a new key to be valid after the refresh interval")
+	}
+	if fetches != 2 {
+		t.Errorf("expected the keys to be fetched twice, got %d", fetches)
+	}
+}
+
+func TestNewTokenProviderOIDCInvalidOptions(t *testing.T) {
+	rolesFile := filepath.Join(t.TempDir(), "roles.json")
+	if err := os.WriteFile(rolesFile, []byte(`{"dev": ["reader"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []string{
+		"",
+		"issuer=" + oidcTestIssuer + ",group-roles=" + rolesFile,
+		"audience=etcd,group-roles=" + rolesFile,
+		"issuer=" + oidcTestIssuer + ",audience=etcd",
+		"issuer=" + oidcTestIssuer + ",audience=etcd,group-roles=/nonexistent",
+		"issuer=" + oidcTestIssuer + ",audience=etcd,audience=other,group-roles=" + rolesFile,
+		"issuer=" + oidcTestIssuer + ",audience=etcd,jwks-file=/nonexistent,group-roles=" + rolesFile,
+	} {
+		if _, err := NewTokenProviderOIDC(zaptest.NewLogger(t), &tokenNop{}, opts); err == nil {
+			t.Errorf("expected options %q to be invalid", opts)
+		}
+	}
+}
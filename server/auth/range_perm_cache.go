@@ -25,11 +25,15 @@ func getMergedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 	if user == nil {
 		return nil
 	}
+	return getMergedRolePerms(tx, user.Roles)
+}
 
+func getMergedRolePerms(tx AuthReadTx, roles []string) *unifiedRangePermissions {
 	readPerms := adt.NewIntervalTree()
 	writePerms := adt.NewIntervalTree()
+	adminPerms := adt.NewIntervalTree()
 
-	for _, roleName := range user.Roles {
+	for _, roleName := range roles {
 		role := tx.UnsafeGetRole(roleName)
 		if role == nil {
 			continue
@@ -59,6 +63,9 @@ func getMergedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 
 			case authpb.WRITE:
 				writePerms.Insert(ivl, struct{}{})
+
+			case authpb.ADMIN:
+				adminPerms.Insert(ivl, struct{}{})
 			}
 		}
 	}
@@ -66,6 +73,7 @@ func getMergedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 	return &unifiedRangePermissions{
 		readPerms:  readPerms,
 		writePerms: writePerms,
+		adminPerms: adminPerms,
 	}
 }
 
@@ -84,6 +92,8 @@ func checkKeyInterval(
 		return cachedPerms.readPerms.Contains(ivl)
 	case authpb.WRITE:
 		return cachedPerms.writePerms.Contains(ivl)
+	case authpb.ADMIN:
+		return cachedPerms.adminPerms.Contains(ivl)
 	default:
 		lg.Panic("unknown auth type", zap.String("auth-type", permtyp.String()))
 	}
@@ -97,6 +107,8 @@ func checkKeyPoint(lg *zap.Logger, cachedPerms *unifiedRangePermissions, key []b
 		return cachedPerms.readPerms.Intersects(pt)
 	case authpb.WRITE:
 		return cachedPerms.writePerms.Intersects(pt)
+	case authpb.ADMIN:
+		return cachedPerms.adminPerms.Intersects(pt)
 	default:
 		lg.Panic("unknown auth type", zap.String("auth-type", permtyp.String()))
 	}
@@ -104,25 +116,37 @@ func checkKeyPoint(lg *zap.Logger, cachedPerms *unifiedRangePermissions, key []b
 }
 
 func (as *authStore) isRangeOpPermitted(tx AuthReadTx, userName string, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	perms := as.getCachedPerms(tx, userName)
+	if perms == nil {
+		return false
+	}
+
+	return checkRangePerms(as.lg, perms, key, rangeEnd, permtyp)
+}
+
+func (as *authStore) getCachedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 	// assumption: tx is Lock()ed
-	_, ok := as.rangePermCache[userName]
+	perms, ok := as.rangePermCache[userName]
 	if !ok {
-		perms := getMergedPerms(tx, userName)
+		perms = getMergedPerms(tx, userName)
 		if perms == nil {
 			as.lg.Error(
 				"failed to create a merged permission",
 				zap.String("user-name", userName),
 			)
-			return false
+			return nil
 		}
 		as.rangePermCache[userName] = perms
 	}
+	return perms
+}
 
+func checkRangePerms(lg *zap.Logger, perms *unifiedRangePermissions, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
 	if len(rangeEnd) == 0 {
-		return checkKeyPoint(as.lg, as.rangePermCache[userName], key, permtyp)
+		return checkKeyPoint(lg, perms, key, permtyp)
 	}
 
-	return checkKeyInterval(as.lg, as.rangePermCache[userName], key, rangeEnd, permtyp)
+	return checkKeyInterval(lg, perms, key, rangeEnd, permtyp)
 }
 
 func (as *authStore) clearCachedPerm() {
@@ -136,4 +160,7 @@ func (as *authStore) invalidateCachedPerm(userName string) {
 type unifiedRangePermissions struct {
 	readPerms  adt.IntervalTree
 	writePerms adt.IntervalTree
+	// adminPerms are the ranges over which the users and roles can be
+	// managed without the root role
+	adminPerms adt.IntervalTree
 }
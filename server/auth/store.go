@@ -78,6 +78,10 @@ const (
 type AuthInfo struct {
 	Username string
 	Revision uint64
+	// Roles are the roles of a user authenticated by an external identity
+	// provider, who is not a user of the auth store. Their permissions are
+	// only given by these roles.
+	Roles []string
 }
 
 // AuthenticateParamIndex is used for a key of context in the parameters of Authenticate()
@@ -154,6 +158,20 @@ type AuthStore interface {
 	// IsAdminPermitted checks admin permission of the user
 	IsAdminPermitted(authInfo *AuthInfo) error
 
+	// IsAnyAdminPermitted checks the user has admin permission, or admin
+	// permission on any range
+	IsAnyAdminPermitted(authInfo *AuthInfo) error
+
+	// IsUserAdminPermitted checks the permission of the user to manage a user
+	IsUserAdminPermitted(authInfo *AuthInfo, userName string) error
+
+	// IsRoleAdminPermitted checks the permission of the user to manage a role
+	IsRoleAdminPermitted(authInfo *AuthInfo, roleName string) error
+
+	// IsRangeAdminPermitted checks the permission of the user to grant a
+	// permission on a range
+	IsRangeAdminPermitted(authInfo *AuthInfo, key, rangeEnd []byte) error
+
 	// GenTokenPrefix produces a random string in a case of simple token
 	// in a case of JWT, it produces an empty string
 	GenTokenPrefix() (string, error)
@@ -842,18 +860,8 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 		return nil
 	}
 
-	// only gets rev == 0 when passed AuthInfo{}; no user given
-	if revision == 0 {
-		return ErrUserEmpty
-	}
-	rev := as.Revision()
-	if revision < rev {
-		as.lg.Warn("request auth revision is less than current node auth revision",
-			zap.Uint64("current node auth revision", rev),
-			zap.Uint64("request auth revision", revision),
-			zap.ByteString("request key", key),
-			zap.Error(ErrAuthOldRevision))
-		return ErrAuthOldRevision
+	if err := as.checkAuthRevision(revision, key); err != nil {
+		return err
 	}
 
 	tx := as.be.ReadTx()
@@ -878,16 +886,67 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 	return ErrPermissionDenied
 }
 
+// isExternalOpPermitted checks the permissions of a user authenticated by an
+// external identity provider, which are given by roles.
+func (as *authStore) isExternalOpPermitted(roles []string, revision uint64, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+	if !as.IsAuthEnabled() {
+		return nil
+	}
+
+	if err := as.checkAuthRevision(revision, key); err != nil {
+		return err
+	}
+
+	if containsRole(roles, rootRole) {
+		return nil
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	// the permissions are not cached, as the roles vary with the tokens
+	if checkRangePerms(as.lg, getMergedRolePerms(tx, roles), key, rangeEnd, permTyp) {
+		return nil
+	}
+
+	return ErrPermissionDenied
+}
+
+func (as *authStore) checkAuthRevision(revision uint64, key []byte) error {
+	// only gets rev == 0 when passed AuthInfo{}; no user given
+	if revision == 0 {
+		return ErrUserEmpty
+	}
+	rev := as.Revision()
+	if revision < rev {
+		as.lg.Warn("request auth revision is less than current node auth revision",
+			zap.Uint64("current node auth revision", rev),
+			zap.Uint64("request auth revision", revision),
+			zap.ByteString("request key", key),
+			zap.Error(ErrAuthOldRevision))
+		return ErrAuthOldRevision
+	}
+	return nil
+}
+
+func (as *authStore) isAuthInfoOpPermitted(authInfo *AuthInfo, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+	if len(authInfo.Roles) > 0 {
+		return as.isExternalOpPermitted(authInfo.Roles, authInfo.Revision, key, rangeEnd, permTyp)
+	}
+	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, permTyp)
+}
+
 func (as *authStore) IsPutPermitted(authInfo *AuthInfo, key []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, nil, authpb.WRITE)
+	return as.isAuthInfoOpPermitted(authInfo, key, nil, authpb.WRITE)
 }
 
 func (as *authStore) IsRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.READ)
+	return as.isAuthInfoOpPermitted(authInfo, key, rangeEnd, authpb.READ)
 }
 
 func (as *authStore) IsDeleteRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.WRITE)
+	return as.isAuthInfoOpPermitted(authInfo, key, rangeEnd, authpb.WRITE)
 }
 
 func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
@@ -898,6 +957,13 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 		return ErrUserEmpty
 	}
 
+	if len(authInfo.Roles) > 0 {
+		if !containsRole(authInfo.Roles, rootRole) {
+			return ErrPermissionDenied
+		}
+		return nil
+	}
+
 	tx := as.be.ReadTx()
 	tx.Lock()
 	defer tx.Unlock()
@@ -914,6 +980,106 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 	return nil
 }
 
+// isDelegatedAdminPermitted checks the user has the root role, or admin
+// permissions satisfying check.
+func (as *authStore) isDelegatedAdminPermitted(authInfo *AuthInfo, check func(tx AuthReadTx, perms *unifiedRangePermissions) bool) error {
+	if !as.IsAuthEnabled() {
+		return nil
+	}
+	if authInfo == nil || authInfo.Username == "" {
+		return ErrUserEmpty
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	var perms *unifiedRangePermissions
+	if len(authInfo.Roles) > 0 {
+		if containsRole(authInfo.Roles, rootRole) {
+			return nil
+		}
+		perms = getMergedRolePerms(tx, authInfo.Roles)
+	} else {
+		u := tx.UnsafeGetUser(authInfo.Username)
+		if u == nil {
+			return ErrUserNotFound
+		}
+		if hasRootRole(u) {
+			return nil
+		}
+		if perms = as.getCachedPerms(tx, authInfo.Username); perms == nil {
+			return ErrPermissionDenied
+		}
+	}
+
+	if !check(tx, perms) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (as *authStore) IsAnyAdminPermitted(authInfo *AuthInfo) error {
+	return as.isDelegatedAdminPermitted(authInfo, func(tx AuthReadTx, perms *unifiedRangePermissions) bool {
+		return perms.adminPerms.Len() > 0
+	})
+}
+
+func (as *authStore) IsUserAdminPermitted(authInfo *AuthInfo, userName string) error {
+	return as.isDelegatedAdminPermitted(authInfo, func(tx AuthReadTx, perms *unifiedRangePermissions) bool {
+		return as.isUserManageable(tx, perms, userName)
+	})
+}
+
+func (as *authStore) IsRoleAdminPermitted(authInfo *AuthInfo, roleName string) error {
+	return as.isDelegatedAdminPermitted(authInfo, func(tx AuthReadTx, perms *unifiedRangePermissions) bool {
+		return as.isRoleManageable(tx, perms, roleName)
+	})
+}
+
+func (as *authStore) IsRangeAdminPermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
+	return as.isDelegatedAdminPermitted(authInfo, func(tx AuthReadTx, perms *unifiedRangePermissions) bool {
+		return checkRangePerms(as.lg, perms, key, rangeEnd, authpb.ADMIN)
+	})
+}
+
+// isUserManageable returns true if the user is named within the admin
+// permissions, and so are all of its roles. Otherwise, managing the user, for
+// instance changing its password, would grant the permissions of its roles.
+func (as *authStore) isUserManageable(tx AuthReadTx, perms *unifiedRangePermissions, userName string) bool {
+	if userName == rootUser || !checkKeyPoint(as.lg, perms, []byte(userName), authpb.ADMIN) {
+		return false
+	}
+	user := tx.UnsafeGetUser(userName)
+	if user == nil {
+		return true
+	}
+	for _, role := range user.Roles {
+		if !as.isRoleManageable(tx, perms, role) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRoleManageable returns true if the role is named within the admin
+// permissions, and all of its permissions are within them.
+func (as *authStore) isRoleManageable(tx AuthReadTx, perms *unifiedRangePermissions, roleName string) bool {
+	if roleName == rootRole || !checkKeyPoint(as.lg, perms, []byte(roleName), authpb.ADMIN) {
+		return false
+	}
+	role := tx.UnsafeGetRole(roleName)
+	if role == nil {
+		return true
+	}
+	for _, perm := range role.KeyPermission {
+		if !checkRangePerms(as.lg, perms, perm.Key, perm.RangeEnd, authpb.ADMIN) {
+			return false
+		}
+	}
+	return true
+}
+
 func (as *authStore) IsAuthEnabled() bool {
 	as.enabledMu.RLock()
 	defer as.enabledMu.RUnlock()
@@ -975,6 +1141,15 @@ func hasRootRole(u *authpb.User) bool {
 	return idx != len(u.Roles) && u.Roles[idx] == rootRole
 }
 
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 func (as *authStore) commitRevision(tx AuthBatchTx) {
 	atomic.AddUint64(&as.revision, 1)
 	tx.UnsafeSaveAuthRevision(as.Revision())
@@ -1068,33 +1243,42 @@ func decomposeOpts(lg *zap.Logger, optstr string) (string, map[string]string, er
 	opts := strings.Split(optstr, ",")
 	tokenType := opts[0]
 
-	typeSpecificOpts := make(map[string]string)
-	for i := 1; i < len(opts); i++ {
-		pair := strings.Split(opts[i], "=")
+	typeSpecificOpts, err := decomposeOptPairs(lg, opts[1:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenType, typeSpecificOpts, nil
+
+}
+
+// decomposeOptPairs returns the options given as key=value pairs.
+func decomposeOptPairs(lg *zap.Logger, pairs []string) (map[string]string, error) {
+	opts := make(map[string]string)
+	for _, p := range pairs {
+		pair := strings.Split(p, "=")
 
 		if len(pair) != 2 {
 			if lg != nil {
-				lg.Error("invalid token option", zap.String("option", optstr))
+				lg.Error("invalid token option", zap.String("option", p))
 			}
-			return "", nil, ErrInvalidAuthOpts
+			return nil, ErrInvalidAuthOpts
 		}
 
-		if _, ok := typeSpecificOpts[pair[0]]; ok {
+		if _, ok := opts[pair[0]]; ok {
 			if lg != nil {
 				lg.Error(
 					"invalid token option",
-					zap.String("option", optstr),
+					zap.String("option", p),
 					zap.String("duplicate-parameter", pair[0]),
 				)
 			}
-			return "", nil, ErrInvalidAuthOpts
+			return nil, ErrInvalidAuthOpts
 		}
 
-		typeSpecificOpts[pair[0]] = pair[1]
+		opts[pair[0]] = pair[1]
 	}
-
-	return tokenType, typeSpecificOpts, nil
-
+	return opts, nil
 }
 
 // NewTokenProvider creates a new token provider.
@@ -733,6 +733,125 @@ func TestIsAdminPermitted(t *testing.T) {
 	}
 }
 
+func TestIsExternalOpPermitted(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.READ, Key: []byte("a"), RangeEnd: []byte("c")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the external user has the roles of the token, not those of the user of
+	// the same name
+	ai := &AuthInfo{Username: "root", Revision: as.Revision(), Roles: []string{"role-test", "missing-role"}}
+	if err = as.IsRangePermitted(ai, []byte("a"), []byte("b")); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err = as.IsPutPermitted(ai, []byte("a")); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err = as.IsAdminPermitted(ai); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+
+	ai = &AuthInfo{Username: "oidc:admin", Revision: as.Revision(), Roles: []string{"root"}}
+	if err = as.IsDeleteRangePermitted(ai, []byte("x"), []byte("y")); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err = as.IsAdminPermitted(ai); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	ai = &AuthInfo{Username: "oidc:alice", Revision: as.Revision() - 1, Roles: []string{"root"}}
+	if err = as.IsPutPermitted(ai, []byte("a")); err != ErrAuthOldRevision {
+		t.Errorf("expected %v, got %v", ErrAuthOldRevision, err)
+	}
+}
+
+func TestIsDelegatedAdminPermitted(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	// role-test is admin over the team-a/ prefix, and granted to foo
+	mustApply := func(_ interface{}, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustApply(as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.ADMIN, Key: []byte("team-a/"), RangeEnd: []byte("team-a0")},
+	}))
+	mustApply(as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}))
+	mustApply(as.RoleAdd(&pb.AuthRoleAddRequest{Name: "team-a/wide"}))
+	mustApply(as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "team-a/wide",
+		Perm: &authpb.Permission{PermType: authpb.READ, Key: []byte("team-b/"), RangeEnd: []byte("team-b0")},
+	}))
+	mustApply(as.UserAdd(&pb.AuthUserAddRequest{Name: "team-a/wide-user", Options: &authpb.UserAddOptions{NoPassword: true}}))
+	mustApply(as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "team-a/wide-user", Role: "team-a/wide"}))
+	mustApply(as.UserAdd(&pb.AuthUserAddRequest{Name: "bar", Options: &authpb.UserAddOptions{NoPassword: true}}))
+
+	tcs := []struct {
+		name string
+		ai   *AuthInfo
+	}{
+		{name: "admin user", ai: &AuthInfo{Username: "foo", Revision: as.Revision()}},
+		{name: "external admin user", ai: &AuthInfo{Username: "oidc:foo", Revision: as.Revision(), Roles: []string{"role-test"}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			checks := []struct {
+				name string
+				err  error
+				want error
+			}{
+				{name: "any", err: as.IsAnyAdminPermitted(tc.ai)},
+				{name: "new user", err: as.IsUserAdminPermitted(tc.ai, "team-a/new")},
+				{name: "user outside", err: as.IsUserAdminPermitted(tc.ai, "team-b/new"), want: ErrPermissionDenied},
+				{name: "user with a role outside", err: as.IsUserAdminPermitted(tc.ai, "team-a/wide-user"), want: ErrPermissionDenied},
+				{name: "root user", err: as.IsUserAdminPermitted(tc.ai, "root"), want: ErrPermissionDenied},
+				{name: "new role", err: as.IsRoleAdminPermitted(tc.ai, "team-a/new")},
+				{name: "role outside", err: as.IsRoleAdminPermitted(tc.ai, "role-test"), want: ErrPermissionDenied},
+				{name: "role with a permission outside", err: as.IsRoleAdminPermitted(tc.ai, "team-a/wide"), want: ErrPermissionDenied},
+				{name: "root role", err: as.IsRoleAdminPermitted(tc.ai, "root"), want: ErrPermissionDenied},
+				{name: "key", err: as.IsRangeAdminPermitted(tc.ai, []byte("team-a/x"), nil)},
+				{name: "prefix", err: as.IsRangeAdminPermitted(tc.ai, []byte("team-a/x/"), []byte("team-a/x0"))},
+				{name: "range outside", err: as.IsRangeAdminPermitted(tc.ai, []byte("team-a/"), []byte("team-b0")), want: ErrPermissionDenied},
+				{name: "from key", err: as.IsRangeAdminPermitted(tc.ai, []byte("team-a/"), []byte{0}), want: ErrPermissionDenied},
+				{name: "root", err: as.IsAdminPermitted(tc.ai), want: ErrPermissionDenied},
+			}
+			for _, c := range checks {
+				if c.err != c.want {
+					t.Errorf("%s: expected %v, got %v", c.name, c.want, c.err)
+				}
+			}
+		})
+	}
+
+	// the users without admin permissions cannot manage any user, while root
+	// can manage all of them
+	bar := &AuthInfo{Username: "bar", Revision: as.Revision()}
+	if err := as.IsAnyAdminPermitted(bar); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err := as.IsUserAdminPermitted(bar, "team-a/new"); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	root := &AuthInfo{Username: "root", Revision: as.Revision()}
+	if err := as.IsUserAdminPermitted(root, "team-a/wide-user"); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err := as.IsRangeAdminPermitted(root, []byte{0}, []byte{0}); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
 func TestRecoverFromSnapshot(t *testing.T) {
 	as, teardown := setupAuthStore(t)
 	defer teardown(t)
@@ -0,0 +1,89 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "strings"
+
+// AuditLogFilter selects the requests recorded in the audit log. A request is
+// recorded if it matches all the non empty fields.
+type AuditLogFilter struct {
+	// Methods are the names of the RPCs recorded, such as "Put" or "UserAdd".
+	Methods []string `json:"methods"`
+	// Users are the auth users whose requests are recorded. The requests of
+	// unauthenticated clients are recorded under the empty user.
+	Users []string `json:"users"`
+	// Prefixes are the prefixes of the keys the recorded requests touch.
+	// Requests touching no keys, such as the auth ones, are not filtered by
+	// prefix.
+	Prefixes []string `json:"prefixes"`
+	// FailuresOnly only records the failed requests.
+	FailuresOnly bool `json:"failures-only"`
+}
+
+// Matches returns whether a request to method of user is recorded, provided
+// it touches keys of the prefixes or no keys.
+func (f AuditLogFilter) Matches(method, user string, failed bool) bool {
+	if f.FailuresOnly && !failed {
+		return false
+	}
+	if len(f.Methods) > 0 && !contains(f.Methods, method) {
+		return false
+	}
+	return len(f.Users) == 0 || contains(f.Users, user)
+}
+
+// MatchesRange returns whether the keys from key to end, or key if end is
+// empty, as in a RangeRequest, include keys of the prefixes.
+func (f AuditLogFilter) MatchesRange(key, end string) bool {
+	if len(f.Prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.Prefixes {
+		if end == "" {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+			continue
+		}
+		// the range starts before the end of the prefix, and ends after its start
+		pend := prefixEnd(prefix)
+		if (pend == "" || key < pend) && (end == "\x00" || end > prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixEnd returns the end of the range of the keys with prefix, or "" if
+// the range is unbounded.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+func TestAuditLogFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter AuditLogFilter
+		method string
+		user   string
+		failed bool
+		want   bool
+	}{
+		{name: "empty", method: "Put", want: true},
+		{name: "method", filter: AuditLogFilter{Methods: []string{"Put", "UserAdd"}}, method: "UserAdd", want: true},
+		{name: "other method", filter: AuditLogFilter{Methods: []string{"Put"}}, method: "UserAdd", want: false},
+		{name: "user", filter: AuditLogFilter{Users: []string{"alice"}}, method: "Put", user: "alice", want: true},
+		{name: "unauthenticated", filter: AuditLogFilter{Users: []string{"alice"}}, method: "Put", want: false},
+		{name: "failure", filter: AuditLogFilter{FailuresOnly: true}, method: "Put", failed: true, want: true},
+		{name: "success", filter: AuditLogFilter{FailuresOnly: true}, method: "Put", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.method, tt.user, tt.failed); got != tt.want {
+				t.Errorf("Matches(%q, %q, %v) = %v, want %v", tt.method, tt.user, tt.failed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditLogFilterMatchesRange(t *testing.T) {
+	f := AuditLogFilter{Prefixes: []string{"/secret/", "/a\xff"}}
+	tests := []struct {
+		key, end string
+		want     bool
+	}{
+		{key: "/secret/x", want: true},
+		{key: "/secret", want: false},
+		{key: "/public/", end: "/secret/", want: false},
+		{key: "/public/", end: "/secret/x", want: true},
+		{key: "\x00", end: "\x00", want: true},
+		{key: "/secret0", end: "\x00", want: false},
+		{key: "/a\xff\xff", end: "/b", want: true},
+		{key: "/b", end: "/c", want: false},
+	}
+	for _, tt := range tests {
+		if got := f.MatchesRange(tt.key, tt.end); got != tt.want {
+			t.Errorf("MatchesRange(%q, %q) = %v, want %v", tt.key, tt.end, got, tt.want)
+		}
+	}
+	if !(AuditLogFilter{}).MatchesRange("/public/", "") {
+		t.Errorf("expected a filter without prefixes to match all keys")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCDCSinkBatchSize is the default maximum number of events published
+// to a change data capture sink at once.
+const DefaultCDCSinkBatchSize = 100
+
+// CDCSink publishes the events of the key-value store to an external broker,
+// such as Kafka or NATS, while the member is leader. The revision of the last
+// event published is checkpointed through raft, so that the events are
+// published at least once, in revision order, across leader changes.
+type CDCSink struct {
+	// Name identifies the checkpoint of the sink. Renaming a sink publishes
+	// its events from the current revision on.
+	Name string `json:"name"`
+	// URL is the broker the events are published to, such as
+	// "nats://127.0.0.1:4222/etcd.events" for a subject of a JetStream stream
+	// or "kafka+http://127.0.0.1:8082/etcd-events" for a Kafka REST proxy.
+	URL string `json:"url"`
+	// Prefix is the prefix of the keys whose events are published. An empty
+	// prefix matches all keys.
+	Prefix string `json:"prefix"`
+	// BatchSize is the maximum number of events published at once.
+	BatchSize int `json:"batch-size"`
+}
+
+// ParseCDCSink parses a CDCSink from semicolon separated fields named after
+// its json fields, such as "name=orders;url=nats://127.0.0.1:4222/orders;prefix=/orders/".
+func ParseCDCSink(s string) (CDCSink, error) {
+	c := CDCSink{BatchSize: DefaultCDCSinkBatchSize}
+	for _, field := range strings.Split(s, ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return c, fmt.Errorf("invalid cdc sink %q: field %q is not a key=value pair", s, field)
+		}
+		var err error
+		switch kv[0] {
+		case "name":
+			c.Name = kv[1]
+		case "url":
+			c.URL = kv[1]
+		case "prefix":
+			c.Prefix = kv[1]
+		case "batch-size":
+			c.BatchSize, err = strconv.Atoi(kv[1])
+		default:
+			return c, fmt.Errorf("invalid cdc sink %q: unknown field %q", s, kv[0])
+		}
+		if err != nil {
+			return c, fmt.Errorf("invalid cdc sink %q: %v", s, err)
+		}
+	}
+	return c, c.Validate()
+}
+
+// Validate returns an error if the sink has no name or URL, or a batch size
+// that is not positive.
+func (c CDCSink) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("invalid cdc sink %q: missing name", c.URL)
+	}
+	if c.URL == "" {
+		return fmt.Errorf("invalid cdc sink %q: missing url", c.Name)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("invalid cdc sink %q: batch-size must be >0", c.Name)
+	}
+	return nil
+}
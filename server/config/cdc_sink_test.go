@@ -0,0 +1,51 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+func TestParseCDCSink(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    CDCSink
+		wantErr bool
+	}{
+		{
+			s:    "name=orders;url=nats://127.0.0.1:4222/orders;prefix=/orders/",
+			want: CDCSink{Name: "orders", URL: "nats://127.0.0.1:4222/orders", Prefix: "/orders/", BatchSize: DefaultCDCSinkBatchSize},
+		},
+		{
+			s:    "name=all;url=kafka+http://127.0.0.1:8082/etcd;batch-size=10",
+			want: CDCSink{Name: "all", URL: "kafka+http://127.0.0.1:8082/etcd", BatchSize: 10},
+		},
+		{s: "url=nats://127.0.0.1:4222/orders", wantErr: true},
+		{s: "name=orders", wantErr: true},
+		{s: "name=orders;url=nats://127.0.0.1:4222/orders;batch-size=0", wantErr: true},
+		{s: "name=orders;url=nats://127.0.0.1:4222/orders;batch-size=many", wantErr: true},
+		{s: "name=orders;topic=orders", wantErr: true},
+		{s: "name=orders;url", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseCDCSink(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCDCSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseCDCSink() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
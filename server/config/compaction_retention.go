@@ -0,0 +1,81 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CompactionRetentionPolicy retains the history of the keys of a prefix
+// through the auto compactions: the last versions of each key, the revisions
+// younger than an age, or both. The compactions keep the revisions retained
+// of the keys of the prefix only, which stay readable by the ranges within the
+// prefix at revisions before the compaction, from the lowest revision all the
+// keys of the prefix keep their history from.
+type CompactionRetentionPolicy struct {
+	// Prefix is the prefix of the keys the policy applies to. An empty prefix
+	// matches all keys.
+	Prefix string `json:"prefix"`
+	// Versions is the number of the last versions of each key retained.
+	Versions int64 `json:"versions"`
+	// Age is how long the revisions of the keys are retained.
+	Age time.Duration `json:"age"`
+}
+
+// ParseCompactionRetentionPolicy parses a CompactionRetentionPolicy from
+// semicolon separated fields named after its json fields, such as
+// "prefix=/config/;versions=10;age=24h".
+func ParseCompactionRetentionPolicy(s string) (CompactionRetentionPolicy, error) {
+	var p CompactionRetentionPolicy
+	hasPrefix := false
+	for _, field := range strings.Split(s, ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return p, fmt.Errorf("invalid compaction retention policy %q: field %q is not a key=value pair", s, field)
+		}
+		var err error
+		switch kv[0] {
+		case "prefix":
+			p.Prefix, hasPrefix = kv[1], true
+		case "versions":
+			p.Versions, err = strconv.ParseInt(kv[1], 10, 64)
+		case "age":
+			p.Age, err = time.ParseDuration(kv[1])
+		default:
+			return p, fmt.Errorf("invalid compaction retention policy %q: unknown field %q", s, kv[0])
+		}
+		if err != nil {
+			return p, fmt.Errorf("invalid compaction retention policy %q: %v", s, err)
+		}
+	}
+	if !hasPrefix {
+		return p, fmt.Errorf("invalid compaction retention policy %q: missing prefix", s)
+	}
+	return p, p.Validate()
+}
+
+// Validate returns an error if the policy has a negative or no retention.
+func (p CompactionRetentionPolicy) Validate() error {
+	if p.Versions < 0 || p.Age < 0 {
+		return fmt.Errorf("invalid compaction retention policy of prefix %q: retentions must be >=0", p.Prefix)
+	}
+	if p.Versions == 0 && p.Age == 0 {
+		return fmt.Errorf("invalid compaction retention policy of prefix %q: no retention set", p.Prefix)
+	}
+	return nil
+}
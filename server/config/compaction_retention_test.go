@@ -0,0 +1,54 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCompactionRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    CompactionRetentionPolicy
+		wantErr bool
+	}{
+		{
+			s:    "prefix=/config/;versions=10",
+			want: CompactionRetentionPolicy{Prefix: "/config/", Versions: 10},
+		},
+		{
+			s:    "prefix=;versions=3;age=1h30m",
+			want: CompactionRetentionPolicy{Versions: 3, Age: 90 * time.Minute},
+		},
+		{s: "versions=10", wantErr: true},
+		{s: "prefix=/config/", wantErr: true},
+		{s: "prefix=/config/;versions=-1", wantErr: true},
+		{s: "prefix=/config/;age=1", wantErr: true},
+		{s: "prefix=/config/;revisions=1", wantErr: true},
+		{s: "prefix=/config/;versions", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseCompactionRetentionPolicy(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCompactionRetentionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseCompactionRetentionPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,12 +22,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jonboulle/clockwork"
+	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/pkg/v3/netutil"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
 	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
@@ -126,6 +130,44 @@ type ServerConfig struct {
 	QuotaBackendBytes       int64
 	MaxTxnOps               uint
 
+	// CompactionTargetCommitLatency paces the compaction batches so that
+	// their commits take no longer than it. 0 disables the pacing.
+	CompactionTargetCommitLatency time.Duration
+
+	// CompactionRetentionPolicies retain the history of the keys of prefixes
+	// through the auto compactions.
+	CompactionRetentionPolicies []CompactionRetentionPolicy
+
+	// QuotaExemptPrefixes are key prefixes that can still be written after
+	// the backend quota is exhausted, e.g. to write recovery markers.
+	QuotaExemptPrefixes []string
+	// QuotaExemptBytes is the number of bytes past QuotaBackendBytes that
+	// writes to QuotaExemptPrefixes may grow the backend by.
+	QuotaExemptBytes int64
+
+	// RangeRateLimits limit the rate of the requests on the keys of prefixes
+	// served by this member.
+	RangeRateLimits []RangeRateLimit
+
+	// CDCSinks publish the events of the key-value store to external brokers
+	// while the member is leader.
+	CDCSinks []CDCSink
+
+	// RequestIDWindow is the number of the most recent request IDs of the
+	// mutations remembered to apply the retries of a mutation once. 0 ignores
+	// the request IDs.
+	RequestIDWindow int
+
+	// MaxFollowerLag is the number of raft entries a voting member may lag
+	// behind the leader before the leader throttles new proposals. 0 disables
+	// throttling.
+	MaxFollowerLag uint64
+
+	// LearnerAutoPromote makes the leader promote the learners once they
+	// replicate its log with at most LearnerAutoPromoteMaxLag entries of lag.
+	LearnerAutoPromote       bool
+	LearnerAutoPromoteMaxLag uint64
+
 	// MaxRequestBytes is the maximum request size to send over raft.
 	MaxRequestBytes uint
 
@@ -140,6 +182,9 @@ type ServerConfig struct {
 	AuthToken  string
 	BcryptCost uint
 	TokenTTL   uint
+	// AuthTokenOIDC are the options of the OpenID Connect issuer whose ID tokens
+	// are accepted besides the tokens of AuthToken. Empty disables them.
+	AuthTokenOIDC string
 
 	// InitialCorruptCheck is true to check data corruption on boot
 	// before serving any peer/client traffic.
@@ -154,6 +199,14 @@ type ServerConfig struct {
 
 	// Logger logs server-side operations.
 	Logger *zap.Logger
+	// LogLevels, if set, holds the log levels of the modules of the server,
+	// which may then be changed at runtime.
+	LogLevels *logutil.ModuleLevels
+	// AuditLogger records the mutating and auth requests, nil if the audit
+	// log is disabled.
+	AuditLogger *zap.Logger
+	// AuditLogFilter selects the requests recorded by AuditLogger.
+	AuditLogFilter AuditLogFilter
 
 	ForceNewCluster bool
 
@@ -163,6 +216,17 @@ type ServerConfig struct {
 	LeaseCheckpointInterval time.Duration
 	// LeaseCheckpointPersist enables persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled.
 	LeaseCheckpointPersist bool
+	// LeaseFastRenew batches the raft entries clearing the checkpointed remaining TTL of renewed leases,
+	// so that keepalives do not wait for raft. Requires EnableLeaseCheckpoint.
+	LeaseFastRenew bool
+	// LeaseRevokeMaxKeys bounds the number of keys deleted by a single apply when revoking expired leases,
+	// a lease attaching more keys is revoked over several applies. 0 revokes every expired lease in one apply.
+	LeaseRevokeMaxKeys int
+	// LeaseRevokeSpread is the wait duration between the batches revoking expired leases.
+	LeaseRevokeSpread time.Duration
+	// LeaseClock is the clock used to expire leases, the real clock if nil.
+	// Tests may set a fake clock to expire leases without waiting.
+	LeaseClock clockwork.Clock
 
 	EnableGRPCGateway bool
 
@@ -170,8 +234,24 @@ type ServerConfig struct {
 	ExperimentalEnableDistributedTracing bool
 	// ExperimentalTracerOptions are options for OpenTelemetry gRPC interceptor.
 	ExperimentalTracerOptions []otelgrpc.Option
+	// ExperimentalTracerProvider, if set, traces the raft requests through
+	// propose, apply, backend commit and watch delivery.
+	ExperimentalTracerProvider trace.TracerProvider
 
 	WatchProgressNotifyInterval time.Duration
+	// WatchMaxEventsPerSecond is the maximum number of events sent per second
+	// on each watch stream. Streams over the limit stop draining their watchers,
+	// which fall behind until the stream catches up. 0 means no limit.
+	WatchMaxEventsPerSecond int
+	// WatchMetricsPrefixes are the key prefixes the watch events sent are
+	// counted by. The watches of other keys are counted as "other".
+	WatchMetricsPrefixes []string
+
+	// SlowRequestThreshold is the latency above which the requests are
+	// recorded in the slow log. 0 disables the slow log.
+	SlowRequestThreshold time.Duration
+	// SlowRequestLogSize is the number of slow requests kept in the slow log.
+	SlowRequestLogSize int
 
 	// UnsafeNoFsync disables all uses of fsync.
 	// Setting this is unsafe and will cause data loss.
@@ -195,6 +275,19 @@ type ServerConfig struct {
 	// consider running defrag during bootstrap. Needs to be set to non-zero value to take effect.
 	ExperimentalBootstrapDefragThresholdMegabytes uint `json:"experimental-bootstrap-defrag-threshold-megabytes"`
 
+	// EncryptionKeyProvider wraps the keys encrypting the backend values and
+	// the WAL entries at rest. The data is stored in plaintext if nil.
+	EncryptionKeyProvider encryption.KeyProvider `json:"-"`
+	// EncryptionKeyRotationInterval is the interval between the rotations of
+	// the data encryption key. 0 only rotates the key on restart.
+	EncryptionKeyRotationInterval time.Duration
+
+	// WALCompression compresses the data of the WAL entries.
+	WALCompression bool
+	// WALSegmentSizeBytes is the size from which the WAL files are cut.
+	// 0 uses wal.SegmentSizeBytes.
+	WALSegmentSizeBytes int64
+
 	// ExperimentalMaxLearners sets a limit to the number of learner members that can exist in the cluster membership.
 	ExperimentalMaxLearners int `json:"experimental-max-learners"`
 
@@ -299,6 +392,12 @@ func (c *ServerConfig) advertiseMatchesCluster() error {
 
 func (c *ServerConfig) MemberDir() string { return datadir.ToMemberDir(c.DataDir) }
 
+// ModuleLogger returns the logger of module, which logs at the level of the
+// module in LogLevels.
+func (c *ServerConfig) ModuleLogger(module string) *zap.Logger {
+	return c.LogLevels.Logger(c.Logger, module)
+}
+
 func (c *ServerConfig) WALDir() string {
 	if c.DedicatedWALDir != "" {
 		return c.DedicatedWALDir
@@ -0,0 +1,97 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RangeRateLimit limits the rate of the requests on the keys of a prefix.
+// Requests over any of its limits fail with a resource exhausted error.
+// Zero limits are not enforced. Each member enforces the limits on the
+// requests it serves on its own, so the rates of a cluster add up over its
+// members.
+type RangeRateLimit struct {
+	// Prefix is the prefix of the keys the limit applies to. An empty prefix
+	// matches all keys.
+	Prefix string `json:"prefix"`
+	// User restricts the limit to the requests of an auth user. The limit
+	// applies to the requests of all users if empty.
+	User string `json:"user"`
+
+	// ReadRequestsPerSecond is the number of requests per second reading the
+	// keys of the prefix.
+	ReadRequestsPerSecond float64 `json:"read-requests-per-second"`
+	// WriteRequestsPerSecond is the number of requests per second writing the
+	// keys of the prefix.
+	WriteRequestsPerSecond float64 `json:"write-requests-per-second"`
+	// ReadBytesPerSecond is the number of bytes of keys and values of the
+	// prefix read per second.
+	ReadBytesPerSecond int64 `json:"read-bytes-per-second"`
+	// WriteBytesPerSecond is the number of bytes of keys and values of the
+	// prefix written per second.
+	WriteBytesPerSecond int64 `json:"write-bytes-per-second"`
+}
+
+// ParseRangeRateLimit parses a RangeRateLimit from semicolon separated
+// fields named after its json fields, such as
+// "prefix=/tenant/;user=alice;write-requests-per-second=100".
+func ParseRangeRateLimit(s string) (RangeRateLimit, error) {
+	var l RangeRateLimit
+	hasPrefix := false
+	for _, field := range strings.Split(s, ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return l, fmt.Errorf("invalid range rate limit %q: field %q is not a key=value pair", s, field)
+		}
+		var err error
+		switch kv[0] {
+		case "prefix":
+			l.Prefix, hasPrefix = kv[1], true
+		case "user":
+			l.User = kv[1]
+		case "read-requests-per-second":
+			l.ReadRequestsPerSecond, err = strconv.ParseFloat(kv[1], 64)
+		case "write-requests-per-second":
+			l.WriteRequestsPerSecond, err = strconv.ParseFloat(kv[1], 64)
+		case "read-bytes-per-second":
+			l.ReadBytesPerSecond, err = strconv.ParseInt(kv[1], 10, 64)
+		case "write-bytes-per-second":
+			l.WriteBytesPerSecond, err = strconv.ParseInt(kv[1], 10, 64)
+		default:
+			return l, fmt.Errorf("invalid range rate limit %q: unknown field %q", s, kv[0])
+		}
+		if err != nil {
+			return l, fmt.Errorf("invalid range rate limit %q: %v", s, err)
+		}
+	}
+	if !hasPrefix {
+		return l, fmt.Errorf("invalid range rate limit %q: missing prefix", s)
+	}
+	return l, l.Validate()
+}
+
+// Validate returns an error if the limit has a negative or no limit.
+func (l RangeRateLimit) Validate() error {
+	if l.ReadRequestsPerSecond < 0 || l.WriteRequestsPerSecond < 0 || l.ReadBytesPerSecond < 0 || l.WriteBytesPerSecond < 0 {
+		return fmt.Errorf("invalid range rate limit of prefix %q: limits must be >=0", l.Prefix)
+	}
+	if l.ReadRequestsPerSecond == 0 && l.WriteRequestsPerSecond == 0 && l.ReadBytesPerSecond == 0 && l.WriteBytesPerSecond == 0 {
+		return fmt.Errorf("invalid range rate limit of prefix %q: no limit set", l.Prefix)
+	}
+	return nil
+}
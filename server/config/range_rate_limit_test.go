@@ -0,0 +1,58 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+func TestParseRangeRateLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    RangeRateLimit
+		wantErr bool
+	}{
+		{
+			s:    "prefix=/tenant/;write-requests-per-second=100",
+			want: RangeRateLimit{Prefix: "/tenant/", WriteRequestsPerSecond: 100},
+		},
+		{
+			s: "prefix=;user=alice;read-requests-per-second=0.5;write-requests-per-second=10;read-bytes-per-second=1024;write-bytes-per-second=2048",
+			want: RangeRateLimit{
+				User:                   "alice",
+				ReadRequestsPerSecond:  0.5,
+				WriteRequestsPerSecond: 10,
+				ReadBytesPerSecond:     1024,
+				WriteBytesPerSecond:    2048,
+			},
+		},
+		{s: "prefix=a=b;read-bytes-per-second=1", want: RangeRateLimit{Prefix: "a=b", ReadBytesPerSecond: 1}},
+		{s: "write-requests-per-second=100", wantErr: true},
+		{s: "prefix=/tenant/", wantErr: true},
+		{s: "prefix=/tenant/;write-requests-per-second=-1", wantErr: true},
+		{s: "prefix=/tenant/;write-bytes-per-second=1.5", wantErr: true},
+		{s: "prefix=/tenant/;writes=1", wantErr: true},
+		{s: "prefix=/tenant/;write-requests-per-second", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseRangeRateLimit(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRangeRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseRangeRateLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
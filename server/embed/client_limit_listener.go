@@ -0,0 +1,204 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	rejectReasonMaxConnections = "max_connections"
+	rejectReasonAcceptRate     = "accept_rate"
+
+	// overloadWriteTimeout bounds the time spent writing the overload
+	// response to a rejected connection, so that slow clients cannot
+	// stall the accept loop.
+	overloadWriteTimeout = 100 * time.Millisecond
+)
+
+var (
+	clientConnsActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_connections_active",
+		Help:      "The number of client connections currently open on each client listener.",
+	},
+		[]string{"address"},
+	)
+	clientConnsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "client_connections_rejected_total",
+		Help:      "The total number of client connections rejected by a client listener because it was overloaded.",
+	},
+		[]string{"address", "reason"},
+	)
+
+	// overloadResponse is written to rejected connections on plaintext
+	// listeners. It mimics the grpc-gateway error body, so HTTP/JSON clients
+	// and health probes get a typed error instead of a bare connection reset.
+	overloadResponse = func() []byte {
+		body := fmt.Sprintf(`{"error":%q,"code":%d,"message":%q}`,
+			rpctypes.ErrorDesc(rpctypes.ErrGRPCTooManyConnections), codes.Unavailable,
+			rpctypes.ErrorDesc(rpctypes.ErrGRPCTooManyConnections))
+		return []byte(fmt.Sprintf("HTTP/1.1 503 Service Unavailable\r\n"+
+			"Content-Type: application/json\r\n"+
+			"Retry-After: 1\r\n"+
+			"Connection: close\r\n"+
+			"Content-Length: %d\r\n\r\n%s", len(body), body))
+	}()
+)
+
+func init() {
+	prometheus.MustRegister(clientConnsActive)
+	prometheus.MustRegister(clientConnsRejected)
+}
+
+// clientConnLimitListener caps the number of concurrently open client
+// connections and the rate at which new ones are accepted. Unlike
+// transport.LimitListener, which stops accepting once the limit is reached
+// and leaves new connections queued in the kernel backlog, connections over
+// the limit are accepted and closed right away, so clients fail fast and
+// back off instead of piling up behind the listener.
+type clientConnLimitListener struct {
+	net.Listener
+	lg   *zap.Logger
+	addr string
+
+	maxConns int64
+	active   int64
+	limiter  *rate.Limiter
+
+	// writeOverload reports whether rejected connections should receive
+	// the plaintext overload response before being closed. Connections on
+	// TLS listeners have not completed the handshake at this point, so
+	// they are closed without a response.
+	writeOverload func() bool
+
+	activeGauge prometheus.Gauge
+}
+
+// newClientConnLimitListener wraps l with the configured limits.
+// A maxConns of zero disables the concurrent connection limit, an
+// acceptRate of zero disables accept-rate throttling.
+func newClientConnLimitListener(lg *zap.Logger, l net.Listener, addr string, maxConns int, acceptRate float64, acceptBurst int, writeOverload func() bool) net.Listener {
+	ll := &clientConnLimitListener{
+		Listener:      l,
+		lg:            lg,
+		addr:          addr,
+		maxConns:      int64(maxConns),
+		writeOverload: writeOverload,
+		activeGauge:   clientConnsActive.WithLabelValues(addr),
+	}
+	if acceptRate > 0 {
+		if acceptBurst <= 0 {
+			acceptBurst = int(acceptRate)
+			if acceptBurst < 1 {
+				acceptBurst = 1
+			}
+		}
+		ll.limiter = rate.NewLimiter(rate.Limit(acceptRate), acceptBurst)
+	}
+	return ll
+}
+
+func (l *clientConnLimitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.limiter != nil && !l.limiter.Allow() {
+			l.reject(c, rejectReasonAcceptRate)
+			continue
+		}
+		if n := atomic.AddInt64(&l.active, 1); l.maxConns > 0 && n > l.maxConns {
+			atomic.AddInt64(&l.active, -1)
+			l.reject(c, rejectReasonMaxConnections)
+			continue
+		}
+		l.activeGauge.Inc()
+		return &clientConnLimitConn{Conn: c, release: l.release}, nil
+	}
+}
+
+func (l *clientConnLimitListener) release() {
+	atomic.AddInt64(&l.active, -1)
+	l.activeGauge.Dec()
+}
+
+func (l *clientConnLimitListener) reject(c net.Conn, reason string) {
+	clientConnsRejected.WithLabelValues(l.addr, reason).Inc()
+	if l.lg != nil {
+		l.lg.Debug(
+			"rejected client connection",
+			zap.String("address", l.addr),
+			zap.String("remote-addr", c.RemoteAddr().String()),
+			zap.String("reason", reason),
+		)
+	}
+	if l.writeOverload != nil && l.writeOverload() {
+		c.SetWriteDeadline(time.Now().Add(overloadWriteTimeout))
+		c.Write(overloadResponse)
+	}
+	c.Close()
+}
+
+type clientConnLimitConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *clientConnLimitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
+
+// keepAliveConn is implemented by both *net.TCPConn and the connections
+// returned by transport.LimitListener.
+type keepAliveConn interface {
+	SetKeepAlive(bool) error
+	SetKeepAlivePeriod(d time.Duration) error
+}
+
+func (c *clientConnLimitConn) SetKeepAlive(doKeepAlive bool) error {
+	kac, ok := c.Conn.(keepAliveConn)
+	if !ok {
+		return transport.ErrNotTCP
+	}
+	return kac.SetKeepAlive(doKeepAlive)
+}
+
+func (c *clientConnLimitConn) SetKeepAlivePeriod(d time.Duration) error {
+	kac, ok := c.Conn.(keepAliveConn)
+	if !ok {
+		return transport.ErrNotTCP
+	}
+	return kac.SetKeepAlivePeriod(d)
+}
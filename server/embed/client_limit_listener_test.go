@@ -0,0 +1,112 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestClientConnLimitListener(t *testing.T, maxConns int, acceptRate float64, acceptBurst int) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ll := newClientConnLimitListener(zaptest.NewLogger(t), l, l.Addr().String(), maxConns, acceptRate, acceptBurst, func() bool { return true })
+	t.Cleanup(func() { ll.Close() })
+	return ll
+}
+
+// acceptAll accepts connections from l and sends them to connc until l is closed.
+func acceptAll(l net.Listener) <-chan net.Conn {
+	connc := make(chan net.Conn, 16)
+	go func() {
+		defer close(connc)
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			connc <- c
+		}
+	}()
+	return connc
+}
+
+func expectOverloadResponse(t *testing.T, addr string) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(c), nil)
+	if err != nil {
+		t.Fatalf("expected overload response, got error %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestClientConnLimitListenerMaxConnections(t *testing.T) {
+	l := newTestClientConnLimitListener(t, 1, 0, 0)
+	connc := acceptAll(l)
+
+	c1, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	sc1 := <-connc
+
+	expectOverloadResponse(t, l.Addr().String())
+
+	// closing the accepted connection frees up the slot
+	sc1.Close()
+	c2, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	select {
+	case sc2 := <-connc:
+		sc2.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected connection to be accepted after a slot was released")
+	}
+}
+
+func TestClientConnLimitListenerAcceptRate(t *testing.T) {
+	l := newTestClientConnLimitListener(t, 0, 0.001, 1)
+	connc := acceptAll(l)
+
+	c1, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	sc1 := <-connc
+	defer sc1.Close()
+
+	// the burst is used up, and the next token is far in the future
+	expectOverloadResponse(t, l.Addr().String())
+}
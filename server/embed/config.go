@@ -39,6 +39,8 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
+	"go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/multierr"
@@ -58,12 +60,19 @@ const (
 	DefaultMaxTxnOps                   = uint(128)
 	DefaultWarningApplyDuration        = 100 * time.Millisecond
 	DefaultWarningUnaryRequestDuration = 300 * time.Millisecond
+	DefaultSlowRequestLogSize          = 256
 	DefaultMaxRequestBytes             = 1.5 * 1024 * 1024
 	DefaultGRPCKeepAliveMinTime        = 5 * time.Second
 	DefaultGRPCKeepAliveInterval       = 2 * time.Hour
 	DefaultGRPCKeepAliveTimeout        = 20 * time.Second
 	DefaultDowngradeCheckTime          = 5 * time.Second
 	DefaultWaitClusterReadyTimeout     = 5 * time.Second
+	DefaultLearnerAutoPromoteMaxLag    = 1000
+	DefaultRequestIDWindow             = 10000
+
+	// DefaultCompactionTargetCommitLatency is the latency above which the
+	// commits of the compaction batches slow down the compaction.
+	DefaultCompactionTargetCommitLatency = 100 * time.Millisecond
 
 	DefaultDiscoveryDialTimeout      = 2 * time.Second
 	DefaultDiscoveryRequestTimeOut   = 5 * time.Second
@@ -313,6 +322,12 @@ type Config struct {
 
 	//The AuthTokenTTL in seconds of the simple token
 	AuthTokenTTL uint `json:"auth-token-ttl"`
+	// ExperimentalAuthTokenOIDC are the options of the OpenID Connect issuer
+	// whose ID tokens are accepted besides the tokens of AuthToken, such as
+	// "issuer=https://sso.example.com,audience=etcd,group-roles=roles.json",
+	// group-roles being the JSON file mapping their groups to roles. Empty
+	// disables them.
+	ExperimentalAuthTokenOIDC string `json:"experimental-auth-token-oidc"`
 
 	ExperimentalInitialCorruptCheck bool          `json:"experimental-initial-corrupt-check"`
 	ExperimentalCorruptCheckTime    time.Duration `json:"experimental-corrupt-check-time"`
@@ -323,10 +338,38 @@ type Config struct {
 	// Deprecated in v3.6.
 	// TODO: Delete in v3.7
 	ExperimentalEnableLeaseCheckpointPersist bool `json:"experimental-enable-lease-checkpoint-persist"`
-	ExperimentalCompactionBatchLimit         int  `json:"experimental-compaction-batch-limit"`
+	// ExperimentalEnableLeaseFastRenew lets the leader renew a checkpointed lease without waiting for raft,
+	// the clears of the checkpointed remaining TTL are batched with the next lease checkpoints.
+	// Requires experimental-enable-lease-checkpoint to be enabled.
+	ExperimentalEnableLeaseFastRenew bool `json:"experimental-enable-lease-fast-renew"`
+	// ExperimentalLeaseRevokeMaxKeys is the maximum number of keys deleted by a single apply when revoking expired leases.
+	// Expired leases are revoked in deterministic expiry order, a lease attaching more keys is revoked over several applies.
+	// 0 revokes every expired lease in its own apply.
+	ExperimentalLeaseRevokeMaxKeys int `json:"experimental-lease-revoke-max-keys"`
+	// ExperimentalLeaseRevokeSpread is the wait duration between the batches revoking expired leases.
+	// Requires experimental-lease-revoke-max-keys to be set.
+	ExperimentalLeaseRevokeSpread    time.Duration `json:"experimental-lease-revoke-spread"`
+	ExperimentalCompactionBatchLimit int           `json:"experimental-compaction-batch-limit"`
 	// ExperimentalCompactionSleepInterval is the sleep interval between every etcd compaction loop.
 	ExperimentalCompactionSleepInterval     time.Duration `json:"experimental-compaction-sleep-interval"`
 	ExperimentalWatchProgressNotifyInterval time.Duration `json:"experimental-watch-progress-notify-interval"`
+	// ExperimentalCompactionTargetCommitLatency paces the compaction batches so that their commits take
+	// no longer than it, shrinking the batches and pausing longer between them when the commits are slower.
+	// 0 keeps the batch limit and sleep interval fixed.
+	ExperimentalCompactionTargetCommitLatency time.Duration `json:"experimental-compaction-target-commit-latency"`
+	// ExperimentalWatchMaxEventsPerSecond is the maximum number of events sent per second
+	// on each watch stream, so that a greedy or slow watcher cannot monopolize the server.
+	// Watchers of a stream over the limit are held back until it catches up. 0 means no limit.
+	ExperimentalWatchMaxEventsPerSecond int `json:"experimental-watch-max-events-per-second"`
+	// ExperimentalWatchMetricsPrefixes are the key prefixes the watch events sent are
+	// counted by, e.g. "/registry/pods/". The watches of other keys are counted as
+	// "other", so that the cardinality of the metric stays bounded.
+	ExperimentalWatchMetricsPrefixes []string `json:"experimental-watch-metrics-prefixes"`
+	// ExperimentalSlowRequestThreshold is the latency above which the requests are recorded
+	// in the slow log, with the time they spent waiting, syncing the WAL and applying. 0 disables it.
+	ExperimentalSlowRequestThreshold time.Duration `json:"experimental-slow-request-threshold"`
+	// ExperimentalSlowRequestLogSize is the number of the last slow requests kept in the slow log.
+	ExperimentalSlowRequestLogSize int `json:"experimental-slow-request-log-size"`
 	// ExperimentalWarningApplyDuration is the time duration after which a warning is generated if applying request
 	// takes more time than this value.
 	ExperimentalWarningApplyDuration time.Duration `json:"experimental-warning-apply-duration"`
@@ -338,6 +381,52 @@ type Config struct {
 	ExperimentalWarningUnaryRequestDuration time.Duration `json:"experimental-warning-unary-request-duration"`
 	// ExperimentalMaxLearners sets a limit to the number of learner members that can exist in the cluster membership.
 	ExperimentalMaxLearners int `json:"experimental-max-learners"`
+	// ExperimentalEnableLearnerAutoPromote lets the leader promote a learner once it replicates the leader's log
+	// with at most ExperimentalLearnerAutoPromoteMaxLag entries of lag and does not receive a snapshot.
+	ExperimentalEnableLearnerAutoPromote bool `json:"experimental-enable-learner-auto-promote"`
+	// ExperimentalLearnerAutoPromoteMaxLag is the number of raft entries a learner may lag behind the leader to be
+	// promoted automatically. Requires experimental-enable-learner-auto-promote to be enabled.
+	ExperimentalLearnerAutoPromoteMaxLag uint64 `json:"experimental-learner-auto-promote-max-lag"`
+	// ExperimentalMaxConcurrentClientConnections is the maximum number of concurrently open
+	// connections on each client listener. Connections over the limit are closed right
+	// after being accepted. 0 means no limit other than the file descriptor limit.
+	ExperimentalMaxConcurrentClientConnections int `json:"experimental-max-concurrent-client-connections"`
+	// ExperimentalClientAcceptRate is the maximum number of new connections per second
+	// accepted on each client listener. 0 means no limit.
+	ExperimentalClientAcceptRate float64 `json:"experimental-client-accept-rate"`
+	// ExperimentalClientAcceptBurst is the number of connections that can be accepted in
+	// a burst above ExperimentalClientAcceptRate. Defaults to the accept rate if not set.
+	ExperimentalClientAcceptBurst int `json:"experimental-client-accept-burst"`
+	// ExperimentalQuotaExemptPrefixes are key prefixes that can still be written
+	// once the backend quota is exhausted, e.g. to write recovery markers.
+	ExperimentalQuotaExemptPrefixes []string `json:"experimental-quota-exempt-prefixes"`
+	// ExperimentalQuotaExemptBytes is the number of bytes past the backend quota
+	// that writes to ExperimentalQuotaExemptPrefixes may grow the backend by.
+	ExperimentalQuotaExemptBytes int64 `json:"experimental-quota-exempt-bytes"`
+	// ExperimentalRangeRateLimits limit the rate of the requests and bytes read and
+	// written on the keys of prefixes, optionally for a single user, so that a tenant
+	// of a shared cluster cannot starve the others. The limits apply to the requests
+	// served by each member, so a cluster of N members serves up to N times their rates.
+	ExperimentalRangeRateLimits []config.RangeRateLimit `json:"experimental-range-rate-limits"`
+	// ExperimentalCompactionRetentionPolicies retain the last versions of the keys of
+	// prefixes, or their revisions younger than an age, through the auto compactions,
+	// which stop short of the revisions retained.
+	ExperimentalCompactionRetentionPolicies []config.CompactionRetentionPolicy `json:"experimental-compaction-retention-policies"`
+	// ExperimentalMaxFollowerLag is the number of raft entries a voting member may
+	// lag behind the leader before the leader delays, and eventually rejects, new
+	// proposals. This bounds the staleness of followers and the size of catch-up
+	// snapshots at the cost of write throughput. 0 disables throttling.
+	ExperimentalMaxFollowerLag uint64 `json:"experimental-max-follower-lag"`
+	// ExperimentalCDCSinks publish the events of the key-value store to external
+	// brokers, such as Kafka or NATS, while the member is leader, so that downstream
+	// systems consume the changes without holding watches. They should be configured
+	// alike on all the members, for the next leader to resume publishing.
+	ExperimentalCDCSinks []config.CDCSink `json:"experimental-cdc-sinks"`
+	// ExperimentalRequestIDWindow is the number of the most recent request IDs of the
+	// mutations remembered, so that a Put, Delete or Txn retried by a client with the
+	// same request ID, e.g. after a leader change, is applied once and the retries get
+	// the response of the first attempt. 0 ignores the request IDs.
+	ExperimentalRequestIDWindow int `json:"experimental-request-id-window"`
 
 	// ForceNewCluster starts a new cluster even if previously started; unsafe.
 	ForceNewCluster bool `json:"force-new-cluster"`
@@ -364,6 +453,15 @@ type Config struct {
 	// Defaults to 0.
 	ExperimentalDistributedTracingSamplingRatePerMillion int `json:"experimental-distributed-tracing-sampling-rate"`
 
+	// ExperimentalAuditLogPath is the file the mutating and auth requests are
+	// recorded to, apart from the server logs. The audit log is disabled if empty.
+	ExperimentalAuditLogPath string `json:"experimental-audit-log-path"`
+	// ExperimentalAuditLogRotationConfigJSON configures the rotation of the audit log
+	// with a JSON logger config, as LogRotationConfigJSON.
+	ExperimentalAuditLogRotationConfigJSON string `json:"experimental-audit-log-rotation-config-json"`
+	// ExperimentalAuditLogFilter selects the requests recorded in the audit log.
+	ExperimentalAuditLogFilter config.AuditLogFilter `json:"experimental-audit-log-filter"`
+
 	// Logger is logger options: currently only supports "zap".
 	// "capnslog" is removed in v3.5.
 	Logger string `json:"logger"`
@@ -390,6 +488,9 @@ type Config struct {
 	// Do not set logger directly.
 	loggerMu *sync.RWMutex
 	logger   *zap.Logger
+	// logLevels holds the log levels of the modules of the server, set up
+	// along with logger.
+	logLevels *logutil.ModuleLevels
 	// EnableGRPCGateway enables grpc gateway.
 	// The gateway translates a RESTful HTTP API into gRPC.
 	EnableGRPCGateway bool `json:"enable-grpc-gateway"`
@@ -411,6 +512,23 @@ type Config struct {
 	// ExperimentalTxnModeWriteWithSharedBuffer enables write transaction to use a shared buffer in its readonly check operations.
 	ExperimentalTxnModeWriteWithSharedBuffer bool `json:"experimental-txn-mode-write-with-shared-buffer"`
 
+	// ExperimentalEncryptionKeyFile is the path to the file of the key encryption keys, one "<id>:<base64 key>"
+	// per line, the first being used to wrap new data encryption keys. Setting it encrypts the backend values
+	// and the WAL entries at rest. All the members must be able to unwrap the keys of the others.
+	ExperimentalEncryptionKeyFile string `json:"experimental-encryption-key-file"`
+	// ExperimentalEncryptionKeyProvider wraps the data encryption keys in place of ExperimentalEncryptionKeyFile,
+	// e.g. with a KMS. It can only be set by embedding etcd.
+	ExperimentalEncryptionKeyProvider encryption.KeyProvider `json:"-"`
+	// ExperimentalEncryptionKeyRotationInterval is the interval between the rotations of the data encryption key.
+	// 0 only rotates the key on restart.
+	ExperimentalEncryptionKeyRotationInterval time.Duration `json:"experimental-encryption-key-rotation-interval"`
+
+	// ExperimentalWALCompression compresses the data of the WAL entries, reducing the disk usage and the
+	// bytes synced for large values. The WAL files are read whether their entries are compressed or not.
+	ExperimentalWALCompression bool `json:"experimental-wal-compression"`
+	// ExperimentalWALSegmentSizeBytes is the size from which the WAL files are cut. 0 uses the default 64MB.
+	ExperimentalWALSegmentSizeBytes int64 `json:"experimental-wal-segment-size-bytes"`
+
 	// V2Deprecation describes phase of API & Storage V2 support
 	V2Deprecation config.V2DeprecationEnum `json:"v2-deprecation"`
 }
@@ -465,6 +583,9 @@ func NewConfig() *Config {
 		ExperimentalWarningApplyDuration: DefaultWarningApplyDuration,
 
 		ExperimentalWarningUnaryRequestDuration: DefaultWarningUnaryRequestDuration,
+		ExperimentalSlowRequestLogSize:          DefaultSlowRequestLogSize,
+
+		ExperimentalCompactionTargetCommitLatency: DefaultCompactionTargetCommitLatency,
 
 		GRPCKeepAliveMinTime:  DefaultGRPCKeepAliveMinTime,
 		GRPCKeepAliveInterval: DefaultGRPCKeepAliveInterval,
@@ -513,6 +634,10 @@ func NewConfig() *Config {
 		ExperimentalMemoryMlock:                  false,
 		ExperimentalTxnModeWriteWithSharedBuffer: true,
 		ExperimentalMaxLearners:                  membership.DefaultMaxLearners,
+		ExperimentalLearnerAutoPromoteMaxLag:     DefaultLearnerAutoPromoteMaxLag,
+		ExperimentalRequestIDWindow:              DefaultRequestIDWindow,
+		ExperimentalQuotaExemptBytes:             storage.DefaultQuotaExemptBytes,
+		ExperimentalAuditLogRotationConfigJSON:   DefaultLogRotationConfig,
 
 		V2Deprecation: config.V2_DEPR_DEFAULT,
 
@@ -744,6 +869,12 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.ExperimentalAuditLogPath != "" {
+		if err := validateAuditLogConfig(cfg.ExperimentalAuditLogRotationConfigJSON, cfg.ExperimentalAuditLogFilter); err != nil {
+			return fmt.Errorf("audit log configuration is not valid: (%v)", err)
+		}
+	}
+
 	if !cfg.ExperimentalEnableLeaseCheckpointPersist && cfg.ExperimentalEnableLeaseCheckpoint {
 		cfg.logger.Warn("Detected that checkpointing is enabled without persistence. Consider enabling experimental-enable-lease-checkpoint-persist")
 	}
@@ -752,6 +883,93 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("setting experimental-enable-lease-checkpoint-persist requires experimental-enable-lease-checkpoint")
 	}
 
+	if cfg.ExperimentalEncryptionKeyFile != "" && cfg.ExperimentalEncryptionKeyProvider != nil {
+		return fmt.Errorf("cannot set both experimental-encryption-key-file and an encryption key provider")
+	}
+
+	if cfg.ExperimentalEncryptionKeyRotationInterval < 0 {
+		return fmt.Errorf("experimental-encryption-key-rotation-interval must not be negative")
+	}
+
+	if cfg.ExperimentalEncryptionKeyRotationInterval != 0 && cfg.ExperimentalEncryptionKeyFile == "" && cfg.ExperimentalEncryptionKeyProvider == nil {
+		return fmt.Errorf("setting experimental-encryption-key-rotation-interval requires experimental-encryption-key-file")
+	}
+
+	if cfg.ExperimentalWALSegmentSizeBytes < 0 {
+		return fmt.Errorf("experimental-wal-segment-size-bytes must not be negative")
+	}
+
+	if cfg.ExperimentalRequestIDWindow < 0 {
+		return fmt.Errorf("experimental-request-id-window must not be negative")
+	}
+
+	if cfg.ExperimentalLeaseRevokeMaxKeys < 0 {
+		return fmt.Errorf("experimental-lease-revoke-max-keys must not be negative")
+	}
+
+	if cfg.ExperimentalLeaseRevokeSpread != 0 && cfg.ExperimentalLeaseRevokeMaxKeys == 0 {
+		return fmt.Errorf("setting experimental-lease-revoke-spread requires experimental-lease-revoke-max-keys")
+	}
+
+	if cfg.ExperimentalEnableLeaseFastRenew && !cfg.ExperimentalEnableLeaseCheckpoint {
+		return fmt.Errorf("setting experimental-enable-lease-fast-renew requires experimental-enable-lease-checkpoint")
+	}
+
+	if cfg.ExperimentalCompactionTargetCommitLatency < 0 {
+		return fmt.Errorf("--experimental-compaction-target-commit-latency must be >=0 (set to %v)", cfg.ExperimentalCompactionTargetCommitLatency)
+	}
+	if cfg.ExperimentalWatchMaxEventsPerSecond < 0 {
+		return fmt.Errorf("--experimental-watch-max-events-per-second must be >=0 (set to %d)", cfg.ExperimentalWatchMaxEventsPerSecond)
+	}
+	if cfg.ExperimentalSlowRequestThreshold < 0 {
+		return fmt.Errorf("--experimental-slow-request-threshold must be >=0 (set to %v)", cfg.ExperimentalSlowRequestThreshold)
+	}
+	if cfg.ExperimentalSlowRequestThreshold > 0 && cfg.ExperimentalSlowRequestLogSize <= 0 {
+		return fmt.Errorf("--experimental-slow-request-log-size must be >0 (set to %d)", cfg.ExperimentalSlowRequestLogSize)
+	}
+	if cfg.ExperimentalMaxConcurrentClientConnections < 0 {
+		return fmt.Errorf("--experimental-max-concurrent-client-connections must be >=0 (set to %d)", cfg.ExperimentalMaxConcurrentClientConnections)
+	}
+	if cfg.ExperimentalClientAcceptRate < 0 {
+		return fmt.Errorf("--experimental-client-accept-rate must be >=0 (set to %v)", cfg.ExperimentalClientAcceptRate)
+	}
+	if cfg.ExperimentalClientAcceptBurst < 0 {
+		return fmt.Errorf("--experimental-client-accept-burst must be >=0 (set to %d)", cfg.ExperimentalClientAcceptBurst)
+	}
+	if cfg.ExperimentalQuotaExemptBytes < 0 {
+		return fmt.Errorf("--experimental-quota-exempt-bytes must be >=0 (set to %d)", cfg.ExperimentalQuotaExemptBytes)
+	}
+	for _, prefix := range cfg.ExperimentalWatchMetricsPrefixes {
+		if prefix == "" {
+			return errors.New("--experimental-watch-metrics-prefixes must not contain an empty prefix")
+		}
+	}
+	for _, prefix := range cfg.ExperimentalQuotaExemptPrefixes {
+		if prefix == "" {
+			return errors.New("--experimental-quota-exempt-prefixes must not contain an empty prefix, it would exempt all keys")
+		}
+	}
+	for _, l := range cfg.ExperimentalRangeRateLimits {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, p := range cfg.ExperimentalCompactionRetentionPolicies {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	sinks := make(map[string]bool)
+	for _, c := range cfg.ExperimentalCDCSinks {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if sinks[c.Name] {
+			return fmt.Errorf("--experimental-cdc-sinks has more than one sink named %q", c.Name)
+		}
+		sinks[c.Name] = true
+	}
+
 	return nil
 }
 
@@ -0,0 +1,57 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/etcd/client/pkg/v3/logutil"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func validateAuditLogConfig(rotationConfigJSON string, filter config.AuditLogFilter) error {
+	var rotation lumberjack.Logger
+	if err := json.Unmarshal([]byte(rotationConfigJSON), &rotation); err != nil {
+		return fmt.Errorf("invalid audit log rotation config: %v", err)
+	}
+	for _, m := range filter.Methods {
+		if !v3rpc.IsAuditedMethod(m) {
+			return fmt.Errorf("method %q of the audit log filter is not audited", m)
+		}
+	}
+	return nil
+}
+
+// setupAuditLogger returns a logger writing the audit log as JSON lines to its
+// own rotated file, and the function closing the file.
+func setupAuditLogger(cfg *Config) (*zap.Logger, func() error, error) {
+	rotation := &lumberjack.Logger{}
+	if err := json.Unmarshal([]byte(cfg.ExperimentalAuditLogRotationConfigJSON), rotation); err != nil {
+		return nil, nil, fmt.Errorf("invalid audit log rotation config: %v", err)
+	}
+	rotation.Filename = cfg.ExperimentalAuditLogPath
+
+	encoderConfig := logutil.DefaultZapLoggerConfig.EncoderConfig
+	// the audit records are not leveled
+	encoderConfig.LevelKey = zapcore.OmitKey
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(rotation), zap.InfoLevel)
+	return zap.New(core), rotation.Close, nil
+}
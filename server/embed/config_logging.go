@@ -24,6 +24,7 @@ import (
 	"os"
 
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
+	"go.etcd.io/etcd/server/v3/etcdserver"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zapgrpc"
@@ -64,6 +65,18 @@ func (cfg *Config) setupLogging() error {
 			}
 		}
 
+		// The loggers built here log at every level, leaving the filtering to
+		// the levels of the modules of the server, which may be changed at
+		// runtime. The loggers of ZapLoggerBuilder filter by their own level.
+		// The levels are kept when set up again, as ZapLoggerBuilder is then set.
+		if cfg.logLevels == nil {
+			level := zap.NewAtomicLevelAt(zap.DebugLevel)
+			if cfg.ZapLoggerBuilder == nil {
+				level.SetLevel(logutil.ConvertToZapLevel(cfg.LogLevel))
+			}
+			cfg.logLevels = logutil.NewModuleLevels(level, etcdserver.LogModules...)
+		}
+
 		outputPaths, errOutputPaths := make([]string, 0), make([]string, 0)
 		isJournal := false
 		for _, v := range cfg.LogOutputs {
@@ -105,7 +118,7 @@ func (cfg *Config) setupLogging() error {
 			copied.OutputPaths = outputPaths
 			copied.ErrorOutputPaths = errOutputPaths
 			copied = logutil.MergeOutputPaths(copied)
-			copied.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(cfg.LogLevel))
+			copied.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
 			encoding, err := logutil.ConvertToZapFormat(cfg.LogFormat)
 			if err != nil {
 				return err
@@ -133,7 +146,7 @@ func (cfg *Config) setupLogging() error {
 				return lerr
 			}
 
-			lvl := zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(cfg.LogLevel))
+			lvl := zap.NewAtomicLevelAt(zap.DebugLevel)
 
 			var encoder zapcore.Encoder
 			encoding, err := logutil.ConvertToZapFormat(cfg.LogFormat)
@@ -163,6 +176,9 @@ func (cfg *Config) setupLogging() error {
 		if err != nil {
 			return err
 		}
+		cfg.loggerMu.Lock()
+		cfg.logger = cfg.logLevels.Logger(cfg.logger, "")
+		cfg.loggerMu.Unlock()
 
 		logTLSHandshakeFailure := func(conn *tls.Conn, err error) {
 			state := conn.ConnectionState()
@@ -228,7 +244,11 @@ func (cfg *Config) SetupGlobalLoggers() {
 	if lg != nil {
 		if cfg.LogLevel == "debug" {
 			grpc.EnableTracing = true
-			grpclog.SetLoggerV2(zapgrpc.NewLogger(lg))
+			grpclog.SetLoggerV2(zapgrpc.NewLogger(cfg.logLevels.Logger(lg, etcdserver.LogModuleGRPC)))
+		} else if cfg.logLevels != nil {
+			// grpc only logs the warnings unless its level is changed
+			cfg.logLevels.SetLevel(etcdserver.LogModuleGRPC, zap.WarnLevel)
+			grpclog.SetLoggerV2(zapgrpc.NewLogger(cfg.logLevels.Logger(lg, etcdserver.LogModuleGRPC)))
 		} else {
 			grpclog.SetLoggerV2(grpclog.NewLoggerV2(io.Discard, os.Stderr, os.Stderr))
 		}
@@ -39,6 +39,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
 	"go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/verify"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -71,6 +72,7 @@ type Etcd struct {
 	metricsListeners []net.Listener
 
 	tracingExporterShutdown func()
+	auditLogClose           func() error
 
 	Server *etcdserver.EtcdServer
 
@@ -159,9 +161,19 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 	if err != nil {
 		return e, err
 	}
+	if len(cfg.ExperimentalCompactionRetentionPolicies) > 0 && autoCompactionRetention == 0 {
+		return e, fmt.Errorf("--experimental-compaction-retention-policies requires --auto-compaction-retention to be set")
+	}
 
 	backendFreelistType := parseBackendFreelistType(cfg.BackendFreelistType)
 
+	keyProvider := cfg.ExperimentalEncryptionKeyProvider
+	if cfg.ExperimentalEncryptionKeyFile != "" {
+		if keyProvider, err = encryption.NewFileKeyProvider(cfg.ExperimentalEncryptionKeyFile); err != nil {
+			return e, err
+		}
+	}
+
 	srvcfg := config.ServerConfig{
 		Name:                                     cfg.Name,
 		ClientURLs:                               cfg.ACUrls,
@@ -186,6 +198,13 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		AutoCompactionRetention:                  autoCompactionRetention,
 		AutoCompactionMode:                       cfg.AutoCompactionMode,
 		QuotaBackendBytes:                        cfg.QuotaBackendBytes,
+		QuotaExemptPrefixes:                      cfg.ExperimentalQuotaExemptPrefixes,
+		RangeRateLimits:                          cfg.ExperimentalRangeRateLimits,
+		CompactionRetentionPolicies:              cfg.ExperimentalCompactionRetentionPolicies,
+		QuotaExemptBytes:                         cfg.ExperimentalQuotaExemptBytes,
+		MaxFollowerLag:                           cfg.ExperimentalMaxFollowerLag,
+		CDCSinks:                                 cfg.ExperimentalCDCSinks,
+		RequestIDWindow:                          cfg.ExperimentalRequestIDWindow,
 		BackendBatchLimit:                        cfg.BackendBatchLimit,
 		BackendFreelistType:                      backendFreelistType,
 		BackendBatchInterval:                     cfg.BackendBatchInterval,
@@ -197,48 +216,76 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		AuthToken:                                cfg.AuthToken,
 		BcryptCost:                               cfg.BcryptCost,
 		TokenTTL:                                 cfg.AuthTokenTTL,
+		AuthTokenOIDC:                            cfg.ExperimentalAuthTokenOIDC,
 		CORS:                                     cfg.CORS,
 		HostWhitelist:                            cfg.HostWhitelist,
 		InitialCorruptCheck:                      cfg.ExperimentalInitialCorruptCheck,
 		CorruptCheckTime:                         cfg.ExperimentalCorruptCheckTime,
 		PreVote:                                  cfg.PreVote,
 		Logger:                                   cfg.logger,
+		LogLevels:                                cfg.logLevels,
 		ForceNewCluster:                          cfg.ForceNewCluster,
 		EnableGRPCGateway:                        cfg.EnableGRPCGateway,
 		ExperimentalEnableDistributedTracing:     cfg.ExperimentalEnableDistributedTracing,
 		UnsafeNoFsync:                            cfg.UnsafeNoFsync,
 		EnableLeaseCheckpoint:                    cfg.ExperimentalEnableLeaseCheckpoint,
 		LeaseCheckpointPersist:                   cfg.ExperimentalEnableLeaseCheckpointPersist,
+		LeaseFastRenew:                           cfg.ExperimentalEnableLeaseFastRenew,
+		LeaseRevokeMaxKeys:                       cfg.ExperimentalLeaseRevokeMaxKeys,
+		LeaseRevokeSpread:                        cfg.ExperimentalLeaseRevokeSpread,
 		CompactionBatchLimit:                     cfg.ExperimentalCompactionBatchLimit,
 		CompactionSleepInterval:                  cfg.ExperimentalCompactionSleepInterval,
+		CompactionTargetCommitLatency:            cfg.ExperimentalCompactionTargetCommitLatency,
 		WatchProgressNotifyInterval:              cfg.ExperimentalWatchProgressNotifyInterval,
+		WatchMaxEventsPerSecond:                  cfg.ExperimentalWatchMaxEventsPerSecond,
+		WatchMetricsPrefixes:                     cfg.ExperimentalWatchMetricsPrefixes,
+		SlowRequestThreshold:                     cfg.ExperimentalSlowRequestThreshold,
+		SlowRequestLogSize:                       cfg.ExperimentalSlowRequestLogSize,
 		DowngradeCheckTime:                       cfg.ExperimentalDowngradeCheckTime,
 		WarningApplyDuration:                     cfg.ExperimentalWarningApplyDuration,
 		WarningUnaryRequestDuration:              cfg.ExperimentalWarningUnaryRequestDuration,
 		ExperimentalMemoryMlock:                  cfg.ExperimentalMemoryMlock,
+		EncryptionKeyProvider:                    keyProvider,
+		EncryptionKeyRotationInterval:            cfg.ExperimentalEncryptionKeyRotationInterval,
+		WALCompression:                           cfg.ExperimentalWALCompression,
+		WALSegmentSizeBytes:                      cfg.ExperimentalWALSegmentSizeBytes,
 		ExperimentalTxnModeWriteWithSharedBuffer: cfg.ExperimentalTxnModeWriteWithSharedBuffer,
 		ExperimentalBootstrapDefragThresholdMegabytes: cfg.ExperimentalBootstrapDefragThresholdMegabytes,
 		ExperimentalMaxLearners:                       cfg.ExperimentalMaxLearners,
+		LearnerAutoPromote:                            cfg.ExperimentalEnableLearnerAutoPromote,
+		LearnerAutoPromoteMaxLag:                      cfg.ExperimentalLearnerAutoPromoteMaxLag,
 		V2Deprecation:                                 cfg.V2DeprecationEffective(),
 	}
 
 	if srvcfg.ExperimentalEnableDistributedTracing {
 		tctx := context.Background()
-		tracingExporter, opts, err := setupTracingExporter(tctx, cfg)
+		tracingExporter, tracerProvider, opts, err := setupTracingExporter(tctx, cfg)
 		if err != nil {
 			return e, err
 		}
 		if tracingExporter == nil || len(opts) == 0 {
 			return e, fmt.Errorf("error setting up distributed tracing")
 		}
-		e.tracingExporterShutdown = func() { tracingExporter.Shutdown(tctx) }
+		e.tracingExporterShutdown = func() {
+			// flush the spans batched before the exporter stops
+			tracerProvider.Shutdown(tctx)
+			tracingExporter.Shutdown(tctx)
+		}
 		srvcfg.ExperimentalTracerOptions = opts
+		srvcfg.ExperimentalTracerProvider = tracerProvider
 
 		e.cfg.logger.Info(
 			"distributed tracing setup enabled",
 		)
 	}
 
+	if cfg.ExperimentalAuditLogPath != "" {
+		if srvcfg.AuditLogger, e.auditLogClose, err = setupAuditLogger(cfg); err != nil {
+			return e, err
+		}
+		srvcfg.AuditLogFilter = cfg.ExperimentalAuditLogFilter
+	}
+
 	print(e.cfg.logger, *cfg, srvcfg, memberInitialized)
 
 	if e.Server, err = etcdserver.NewServer(srvcfg); err != nil {
@@ -286,6 +333,21 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 	return e, nil
 }
 
+// redactedCDCSinks returns the names and the URLs of the sinks, without the
+// credentials of the URLs.
+func redactedCDCSinks(sinks []config.CDCSink) []string {
+	ss := make([]string, len(sinks))
+	for i, c := range sinks {
+		if u, err := url.Parse(c.URL); err == nil {
+			u.User = nil
+			ss[i] = c.Name + "=" + u.String()
+		} else {
+			ss[i] = c.Name
+		}
+	}
+	return ss
+}
+
 func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized bool) {
 	cors := make([]string, 0, len(ec.CORS))
 	for v := range ec.CORS {
@@ -337,12 +399,21 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.String("initial-cluster-state", ec.ClusterState),
 		zap.String("initial-cluster-token", sc.InitialClusterToken),
 		zap.Int64("quota-size-bytes", quota),
+		zap.Strings("quota-exempt-prefixes", sc.QuotaExemptPrefixes),
+		zap.Any("range-rate-limits", sc.RangeRateLimits),
+		zap.String("audit-log-path", ec.ExperimentalAuditLogPath),
+		zap.Any("audit-log-filter", sc.AuditLogFilter),
+		zap.Int64("quota-exempt-bytes", sc.QuotaExemptBytes),
+		zap.Uint64("max-follower-lag", sc.MaxFollowerLag),
+		zap.Strings("cdc-sinks", redactedCDCSinks(sc.CDCSinks)),
+		zap.Int("request-id-window", sc.RequestIDWindow),
 		zap.Bool("pre-vote", sc.PreVote),
 		zap.Bool("initial-corrupt-check", sc.InitialCorruptCheck),
 		zap.String("corrupt-check-time-interval", sc.CorruptCheckTime.String()),
 		zap.String("auto-compaction-mode", sc.AutoCompactionMode),
 		zap.Duration("auto-compaction-retention", sc.AutoCompactionRetention),
 		zap.String("auto-compaction-interval", sc.AutoCompactionRetention.String()),
+		zap.Any("compaction-retention-policies", sc.CompactionRetentionPolicies),
 		zap.String("discovery-url", sc.DiscoveryURL),
 		zap.String("discovery-proxy", sc.DiscoveryProxy),
 
@@ -361,6 +432,18 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 
 		zap.String("downgrade-check-interval", sc.DowngradeCheckTime.String()),
 		zap.Int("max-learners", sc.ExperimentalMaxLearners),
+		zap.Bool("learner-auto-promote", sc.LearnerAutoPromote),
+		zap.Uint64("learner-auto-promote-max-lag", sc.LearnerAutoPromoteMaxLag),
+		zap.String("compaction-target-commit-latency", sc.CompactionTargetCommitLatency.String()),
+		zap.Bool("wal-compression", sc.WALCompression),
+		zap.Int64("wal-segment-size-bytes", sc.WALSegmentSizeBytes),
+		zap.Int("watch-max-events-per-second", sc.WatchMaxEventsPerSecond),
+		zap.Strings("watch-metrics-prefixes", sc.WatchMetricsPrefixes),
+		zap.String("slow-request-threshold", sc.SlowRequestThreshold.String()),
+		zap.Int("slow-request-log-size", sc.SlowRequestLogSize),
+		zap.Int("max-concurrent-client-connections", ec.ExperimentalMaxConcurrentClientConnections),
+		zap.Float64("client-accept-rate", ec.ExperimentalClientAcceptRate),
+		zap.Int("client-accept-burst", ec.ExperimentalClientAcceptBurst),
 	)
 }
 
@@ -432,6 +515,10 @@ func (e *Etcd) Close() {
 		e.Server.Stop()
 	}
 
+	if e.auditLogClose != nil {
+		e.auditLogClose()
+	}
+
 	// close all idle connections in peer handler (wait up to 1-second)
 	for i := range e.Peers {
 		if e.Peers[i] != nil && e.Peers[i].close != nil {
@@ -658,6 +745,15 @@ func configureClientListeners(cfg *Config) (sctxs map[string]*serveCtx, err erro
 			sctx.l = transport.LimitListener(sctx.l, int(fdLimit-reservedInternalFDNum))
 		}
 
+		if cfg.ExperimentalMaxConcurrentClientConnections > 0 || cfg.ExperimentalClientAcceptRate > 0 {
+			sctx.l = newClientConnLimitListener(cfg.logger, sctx.l, addr,
+				cfg.ExperimentalMaxConcurrentClientConnections,
+				cfg.ExperimentalClientAcceptRate,
+				cfg.ExperimentalClientAcceptBurst,
+				func() bool { return !sctx.secure },
+			)
+		}
+
 		if network == "tcp" {
 			if sctx.l, err = transport.NewKeepAliveListener(sctx.l, network, nil); err != nil {
 				return nil, err
@@ -0,0 +1,200 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	streamModeSSE    = "sse"
+	streamModeNDJSON = "ndjson"
+)
+
+// gatewayStreams are the paths of the streaming RPCs of the gRPC gateway
+// which may be served as server-sent events or newline delimited JSON.
+var gatewayStreams = map[string]bool{
+	"/v3/watch":           true,
+	"/v3/lease/keepalive": true,
+}
+
+// streamingGateway serves the streaming RPCs of the gRPC gateway as
+// server-sent events or newline delimited JSON, as selected by the "stream"
+// query parameter of the request ("sse" or "ndjson") or its Accept header
+// ("text/event-stream" or "application/x-ndjson"). Each event, or line, holds
+// a response message; the errors are sent as "error" events, or as lines
+// without a response message. Otherwise the responses are streamed as
+// "result" objects as usual.
+//
+// A browser EventSource cannot send a request body, so the request message
+// may instead be passed as JSON in the "request" query parameter, and resent
+// every "interval" query parameter, e.g. "interval=1s" to keep a lease alive.
+func streamingGateway(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mode := streamMode(req)
+		if mode == "" || !gatewayStreams[req.URL.Path] || strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		q := req.URL.Query()
+		if msg := q.Get("request"); msg != "" {
+			var interval time.Duration
+			if iv := q.Get("interval"); iv != "" {
+				var err error
+				if interval, err = time.ParseDuration(iv); err != nil || interval <= 0 {
+					http.Error(w, fmt.Sprintf("invalid interval %q", iv), http.StatusBadRequest)
+					return
+				}
+			}
+			req = req.Clone(req.Context())
+			req.Method = http.MethodPost
+			req.Body = io.NopCloser(&repeatReader{ctx: req.Context(), msg: []byte(msg), buf: []byte(msg), interval: interval})
+			req.ContentLength = -1
+		}
+
+		sw := &streamWriter{ResponseWriter: w, mode: mode}
+		h.ServeHTTP(sw, req)
+		sw.writeLines(true)
+	})
+}
+
+func streamMode(req *http.Request) string {
+	switch mode := req.URL.Query().Get("stream"); mode {
+	case streamModeSSE, streamModeNDJSON:
+		return mode
+	}
+	for _, accept := range req.Header.Values("Accept") {
+		switch {
+		case strings.Contains(accept, "text/event-stream"):
+			return streamModeSSE
+		case strings.Contains(accept, "application/x-ndjson"):
+			return streamModeNDJSON
+		}
+	}
+	return ""
+}
+
+// repeatReader reads msg, then reads it again every interval until ctx is
+// done. It reads msg once if interval is zero.
+type repeatReader struct {
+	ctx      context.Context
+	msg      []byte
+	buf      []byte
+	interval time.Duration
+}
+
+func (rr *repeatReader) Read(p []byte) (int, error) {
+	if len(rr.buf) == 0 {
+		if rr.interval == 0 {
+			return 0, io.EOF
+		}
+		select {
+		case <-time.After(rr.interval):
+		case <-rr.ctx.Done():
+			return 0, io.EOF
+		}
+		rr.buf = rr.msg
+	}
+	n := copy(p, rr.buf)
+	rr.buf = rr.buf[n:]
+	return n, nil
+}
+
+// streamWriter rewrites the newline delimited chunks written by the gRPC
+// gateway as server-sent events or newline delimited JSON on flush.
+type streamWriter struct {
+	http.ResponseWriter
+	mode string
+
+	wroteHeader bool
+	buf         []byte
+}
+
+func (sw *streamWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	h := sw.Header()
+	h.Del("Content-Length")
+	if sw.mode == streamModeSSE {
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+	} else {
+		h.Set("Content-Type", "application/x-ndjson")
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.buf = append(sw.buf, p...)
+	return len(p), nil
+}
+
+func (sw *streamWriter) Flush() {
+	sw.writeLines(false)
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeLines writes the complete lines buffered, and the incomplete last
+// line as well if final.
+func (sw *streamWriter) writeLines(final bool) {
+	sw.WriteHeader(http.StatusOK)
+	for len(sw.buf) != 0 {
+		i := bytes.IndexByte(sw.buf, '\n')
+		if i < 0 {
+			if !final {
+				return
+			}
+			i = len(sw.buf)
+		}
+		line := bytes.TrimSpace(sw.buf[:i])
+		if i < len(sw.buf) {
+			i++
+		}
+		sw.buf = sw.buf[i:]
+		if len(line) != 0 {
+			sw.writeLine(line)
+		}
+	}
+}
+
+func (sw *streamWriter) writeLine(line []byte) {
+	var chunk map[string]json.RawMessage
+	event, data := "error", line
+	if json.Unmarshal(line, &chunk) == nil {
+		if result, ok := chunk["result"]; ok && len(chunk) == 1 {
+			event, data = "", result
+		}
+	}
+	if sw.mode == streamModeNDJSON {
+		fmt.Fprintf(sw.ResponseWriter, "%s\n", data)
+		return
+	}
+	if event != "" {
+		fmt.Fprintf(sw.ResponseWriter, "event: %s\n", event)
+	}
+	fmt.Fprintf(sw.ResponseWriter, "data: %s\n\n", data)
+}
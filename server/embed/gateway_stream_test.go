@@ -0,0 +1,122 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// echoStream streams the request messages back like the gRPC gateway,
+// followed by an error chunk after n messages.
+func echoStream(n int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		dec := json.NewDecoder(req.Body)
+		for i := 0; i < n; i++ {
+			var msg json.RawMessage
+			if err := dec.Decode(&msg); err != nil {
+				break
+			}
+			fmt.Fprintf(w, `{"result":%s}`, msg)
+			w.Write([]byte("\n"))
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, `{"error":{"code":1,"message":"canceled"}}`)
+	})
+}
+
+func TestStreamingGateway(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		accept string
+		body   string
+
+		wantType string
+		want     string
+	}{
+		{
+			name:     "sse request in query repeated",
+			method:   http.MethodGet,
+			query:    "stream=sse&interval=1ms&request=" + url.QueryEscape(`{"id":1}`),
+			wantType: "text/event-stream",
+			want:     "data: {\"id\":1}\n\ndata: {\"id\":1}\n\nevent: error\ndata: {\"error\":{\"code\":1,\"message\":\"canceled\"}}\n\n",
+		},
+		{
+			name:     "ndjson by accept header",
+			method:   http.MethodPost,
+			accept:   "application/x-ndjson",
+			body:     `{"id":1}{"id":2}`,
+			wantType: "application/x-ndjson",
+			want:     "{\"id\":1}\n{\"id\":2}\n{\"error\":{\"code\":1,\"message\":\"canceled\"}}\n",
+		},
+		{
+			name:     "sse by accept header",
+			method:   http.MethodPost,
+			accept:   "text/event-stream",
+			body:     `{"id":1}`,
+			wantType: "text/event-stream",
+			want:     "data: {\"id\":1}\n\nevent: error\ndata: {\"error\":{\"code\":1,\"message\":\"canceled\"}}\n\n",
+		},
+		{
+			name:     "default stream",
+			method:   http.MethodPost,
+			body:     `{"id":1}`,
+			wantType: "application/json",
+			want:     "{\"result\":{\"id\":1}}\n{\"error\":{\"code\":1,\"message\":\"canceled\"}}",
+		},
+	}
+	ts := httptest.NewServer(streamingGateway(echoStream(2)))
+	defer ts.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+"/v3/watch?"+tt.query, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("expected content type %q, got %q", tt.wantType, ct)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
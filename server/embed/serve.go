@@ -280,7 +280,7 @@ func (sctx *serveCtx) createMux(gwmux *gw.ServeMux, handler http.Handler) *http.
 	if gwmux != nil {
 		httpmux.Handle(
 			"/v3/",
-			wsproxy.WebsocketProxy(
+			streamingGateway(wsproxy.WebsocketProxy(
 				gwmux,
 				wsproxy.WithRequestMutator(
 					// Default to the POST method for streams
@@ -290,7 +290,7 @@ func (sctx *serveCtx) createMux(gwmux *gw.ServeMux, handler http.Handler) *http.
 					},
 				),
 				wsproxy.WithMaxRespBodyBufferSize(0x7fffffff),
-			),
+			)),
 		)
 	}
 	if handler != nil {
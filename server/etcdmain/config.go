@@ -82,6 +82,7 @@ type config struct {
 	cf           configFlags
 	configFile   string
 	printVersion bool
+	selfTest     bool
 	ignored      []string
 }
 
@@ -270,6 +271,9 @@ func newConfig() *config {
 	// version
 	fs.BoolVar(&cfg.printVersion, "version", false, "Print the version and exit.")
 
+	// self test
+	fs.BoolVar(&cfg.selfTest, "self-test", false, "Check disk, clock and network prerequisites of the given configuration, print a JSON report and exit.")
+
 	fs.StringVar(&cfg.ec.AutoCompactionRetention, "auto-compaction-retention", "0", "Auto compaction retention for mvcc key value store. 0 means disable auto compaction.")
 	fs.StringVar(&cfg.ec.AutoCompactionMode, "auto-compaction-mode", "periodic", "interpret 'auto-compaction-retention' one of: periodic|revision. 'periodic' for duration based retention, defaulting to hours if no time unit is provided (e.g. '5m'). 'revision' for revision number based retention.")
 
@@ -286,10 +290,19 @@ func newConfig() *config {
 	fs.StringVar(&cfg.ec.ExperimentalDistributedTracingServiceInstanceID, "experimental-distributed-tracing-instance-id", "", "Configures service instance ID for distributed tracing to be used to define service instance ID key for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag). There is no default value set. This ID must be unique per etcd instance.")
 	fs.IntVar(&cfg.ec.ExperimentalDistributedTracingSamplingRatePerMillion, "experimental-distributed-tracing-sampling-rate", 0, "Number of samples to collect per million spans for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag).")
 
+	// experimental audit logging
+	fs.StringVar(&cfg.ec.ExperimentalAuditLogPath, "experimental-audit-log-path", "", "File to record the mutating and auth requests to, apart from the server logs. Empty disables the audit log.")
+	fs.StringVar(&cfg.ec.ExperimentalAuditLogRotationConfigJSON, "experimental-audit-log-rotation-config-json", embed.DefaultLogRotationConfig, "Configures the rotation of the audit log with a JSON logger config, as log-rotation-config-json.")
+	fs.Var(flags.NewStringsValue(""), "experimental-audit-log-methods", "Comma-separated list of the RPCs recorded in the audit log, such as 'Put,UserAdd'. Empty records all the audited RPCs.")
+	fs.Var(flags.NewStringsValue(""), "experimental-audit-log-users", "Comma-separated list of the users whose requests are recorded in the audit log. Empty records the requests of all users.")
+	fs.Var(flags.NewStringsValue(""), "experimental-audit-log-prefixes", "Comma-separated list of the key prefixes the requests recorded in the audit log touch. Requests touching no keys are always recorded.")
+	fs.BoolVar(&cfg.ec.ExperimentalAuditLogFilter.FailuresOnly, "experimental-audit-log-failures-only", false, "Only record the failed requests in the audit log.")
+
 	// auth
 	fs.StringVar(&cfg.ec.AuthToken, "auth-token", cfg.ec.AuthToken, "Specify auth token specific options.")
 	fs.UintVar(&cfg.ec.BcryptCost, "bcrypt-cost", cfg.ec.BcryptCost, "Specify bcrypt algorithm cost factor for auth password hashing.")
 	fs.UintVar(&cfg.ec.AuthTokenTTL, "auth-token-ttl", cfg.ec.AuthTokenTTL, "The lifetime in seconds of the auth token.")
+	fs.StringVar(&cfg.ec.ExperimentalAuthTokenOIDC, "experimental-auth-token-oidc", "", "Specify the options of the OpenID Connect issuer whose ID tokens are accepted besides the auth tokens.")
 
 	// gateway
 	fs.BoolVar(&cfg.ec.EnableGRPCGateway, "enable-grpc-gateway", cfg.ec.EnableGRPCGateway, "Enable GRPC gateway.")
@@ -301,17 +314,41 @@ func newConfig() *config {
 	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpoint, "experimental-enable-lease-checkpoint", false, "Enable leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change.")
 	// TODO: delete in v3.7
 	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpointPersist, "experimental-enable-lease-checkpoint-persist", false, "Enable persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled. Requires experimental-enable-lease-checkpoint to be enabled.")
+	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseFastRenew, "experimental-enable-lease-fast-renew", false, "Enable leader to renew leases without waiting for raft to clear their checkpointed remaining TTL, the clears are batched instead. Requires experimental-enable-lease-checkpoint to be enabled.")
+	fs.IntVar(&cfg.ec.ExperimentalLeaseRevokeMaxKeys, "experimental-lease-revoke-max-keys", cfg.ec.ExperimentalLeaseRevokeMaxKeys, "Maximum number of keys deleted by a single apply when revoking expired leases. 0 revokes every expired lease in its own apply.")
+	fs.DurationVar(&cfg.ec.ExperimentalLeaseRevokeSpread, "experimental-lease-revoke-spread", cfg.ec.ExperimentalLeaseRevokeSpread, "Wait duration between the batches revoking expired leases. Requires experimental-lease-revoke-max-keys to be set.")
 	fs.IntVar(&cfg.ec.ExperimentalCompactionBatchLimit, "experimental-compaction-batch-limit", cfg.ec.ExperimentalCompactionBatchLimit, "Sets the maximum revisions deleted in each compaction batch.")
+	fs.Var(flags.NewStringsValue(""), "experimental-compaction-retention-policies", "Comma-separated list of policies retaining the history of the keys of prefixes through the auto compactions, each made of semicolon-separated fields, e.g. 'prefix=/config/;versions=10;age=24h'.")
 	fs.DurationVar(&cfg.ec.ExperimentalCompactionSleepInterval, "experimental-compaction-sleep-interval", cfg.ec.ExperimentalCompactionSleepInterval, "Sets the sleep interval between each compaction batch.")
+	fs.DurationVar(&cfg.ec.ExperimentalCompactionTargetCommitLatency, "experimental-compaction-target-commit-latency", cfg.ec.ExperimentalCompactionTargetCommitLatency, "Commit latency above which the compaction batches shrink and pause longer between them. 0 disables the pacing.")
 	fs.DurationVar(&cfg.ec.ExperimentalWatchProgressNotifyInterval, "experimental-watch-progress-notify-interval", cfg.ec.ExperimentalWatchProgressNotifyInterval, "Duration of periodic watch progress notifications.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchMaxEventsPerSecond, "experimental-watch-max-events-per-second", cfg.ec.ExperimentalWatchMaxEventsPerSecond, "Maximum number of events sent per second on each watch stream. 0 means no limit.")
+	fs.Var(flags.NewStringsValue(""), "experimental-watch-metrics-prefixes", "Comma-separated list of key prefixes the watch events sent are counted by, the other watches being counted as 'other'.")
+	fs.DurationVar(&cfg.ec.ExperimentalSlowRequestThreshold, "experimental-slow-request-threshold", cfg.ec.ExperimentalSlowRequestThreshold, "Latency above which the requests are recorded in the slow log. 0 disables the slow log.")
+	fs.IntVar(&cfg.ec.ExperimentalSlowRequestLogSize, "experimental-slow-request-log-size", cfg.ec.ExperimentalSlowRequestLogSize, "Number of the last slow requests kept in the slow log.")
 	fs.DurationVar(&cfg.ec.ExperimentalDowngradeCheckTime, "experimental-downgrade-check-time", cfg.ec.ExperimentalDowngradeCheckTime, "Duration of time between two downgrade status check.")
 	fs.DurationVar(&cfg.ec.ExperimentalWarningApplyDuration, "experimental-warning-apply-duration", cfg.ec.ExperimentalWarningApplyDuration, "Time duration after which a warning is generated if request takes more time.")
 	fs.DurationVar(&cfg.ec.ExperimentalWarningUnaryRequestDuration, "experimental-warning-unary-request-duration", cfg.ec.ExperimentalWarningUnaryRequestDuration, "Time duration after which a warning is generated if a unary request takes more time.")
 	fs.BoolVar(&cfg.ec.ExperimentalMemoryMlock, "experimental-memory-mlock", cfg.ec.ExperimentalMemoryMlock, "Enable to enforce etcd pages (in particular bbolt) to stay in RAM.")
 	fs.BoolVar(&cfg.ec.ExperimentalTxnModeWriteWithSharedBuffer, "experimental-txn-mode-write-with-shared-buffer", true, "Enable the write transaction to use a shared buffer in its readonly check operations.")
 	fs.UintVar(&cfg.ec.ExperimentalBootstrapDefragThresholdMegabytes, "experimental-bootstrap-defrag-threshold-megabytes", 0, "Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.")
+	fs.StringVar(&cfg.ec.ExperimentalEncryptionKeyFile, "experimental-encryption-key-file", "", "Path to the key encryption keys, one '<id>:<base64 key>' per line, the first wrapping new data encryption keys. Enables the encryption of the backend values and the WAL entries at rest.")
+	fs.DurationVar(&cfg.ec.ExperimentalEncryptionKeyRotationInterval, "experimental-encryption-key-rotation-interval", 0, "Interval between the rotations of the data encryption key. 0 only rotates the key on restart. Requires experimental-encryption-key-file to be set.")
+	fs.BoolVar(&cfg.ec.ExperimentalWALCompression, "experimental-wal-compression", false, "Compresses the data of the WAL entries.")
+	fs.Int64Var(&cfg.ec.ExperimentalWALSegmentSizeBytes, "experimental-wal-segment-size-bytes", 0, "Size from which the WAL files are cut. 0 uses the default 64MB.")
 	fs.IntVar(&cfg.ec.ExperimentalMaxLearners, "experimental-max-learners", membership.DefaultMaxLearners, "Sets the maximum number of learners that can be available in the cluster membership.")
+	fs.BoolVar(&cfg.ec.ExperimentalEnableLearnerAutoPromote, "experimental-enable-learner-auto-promote", false, "Enable the leader to promote a learner once it is within experimental-learner-auto-promote-max-lag entries of the leader's log and does not receive a snapshot.")
+	fs.Uint64Var(&cfg.ec.ExperimentalLearnerAutoPromoteMaxLag, "experimental-learner-auto-promote-max-lag", cfg.ec.ExperimentalLearnerAutoPromoteMaxLag, "Number of raft entries a learner may lag behind the leader to be promoted automatically. Requires experimental-enable-learner-auto-promote to be enabled.")
 	fs.DurationVar(&cfg.ec.ExperimentalWaitClusterReadyTimeout, "experimental-wait-cluster-ready-timeout", cfg.ec.ExperimentalWaitClusterReadyTimeout, "Maximum duration to wait for the cluster to be ready.")
+	fs.IntVar(&cfg.ec.ExperimentalMaxConcurrentClientConnections, "experimental-max-concurrent-client-connections", cfg.ec.ExperimentalMaxConcurrentClientConnections, "Maximum number of concurrently open connections on each client listener. 0 means no limit.")
+	fs.Float64Var(&cfg.ec.ExperimentalClientAcceptRate, "experimental-client-accept-rate", cfg.ec.ExperimentalClientAcceptRate, "Maximum number of new connections per second accepted on each client listener. 0 means no limit.")
+	fs.IntVar(&cfg.ec.ExperimentalClientAcceptBurst, "experimental-client-accept-burst", cfg.ec.ExperimentalClientAcceptBurst, "Number of connections that can be accepted in a burst above experimental-client-accept-rate. Defaults to the accept rate.")
+	fs.Var(flags.NewStringsValue(""), "experimental-quota-exempt-prefixes", "Comma-separated list of key prefixes that can still be written after the backend quota is exceeded.")
+	fs.Int64Var(&cfg.ec.ExperimentalQuotaExemptBytes, "experimental-quota-exempt-bytes", cfg.ec.ExperimentalQuotaExemptBytes, "Number of bytes past the backend quota that writes to experimental-quota-exempt-prefixes may use.")
+	fs.Var(flags.NewStringsValue(""), "experimental-range-rate-limits", "Comma-separated list of rate limits of the requests on key prefixes, each made of semicolon-separated fields, e.g. 'prefix=/tenant/;user=alice;write-requests-per-second=100;write-bytes-per-second=1048576'. The limits apply to the requests served by each member.")
+	fs.Uint64Var(&cfg.ec.ExperimentalMaxFollowerLag, "experimental-max-follower-lag", cfg.ec.ExperimentalMaxFollowerLag, "Number of raft entries a voting member may lag behind the leader before new proposals are throttled. 0 disables throttling.")
+	fs.Var(flags.NewStringsValue(""), "experimental-cdc-sinks", "Comma-separated list of sinks the events of the key-value store are published to while the member is leader, each made of semicolon-separated fields, e.g. 'name=orders;url=nats://127.0.0.1:4222/etcd.orders;prefix=/orders/'.")
+	fs.IntVar(&cfg.ec.ExperimentalRequestIDWindow, "experimental-request-id-window", cfg.ec.ExperimentalRequestIDWindow, "Number of the most recent request IDs of the mutations remembered to apply the client retries of a mutation once. 0 ignores the request IDs.")
 
 	// unsafe
 	fs.BoolVar(&cfg.ec.UnsafeNoFsync, "unsafe-no-fsync", false, "Disables fsync, unsafe, will cause data loss.")
@@ -419,6 +456,32 @@ func (cfg *config) configFromCmdLine() error {
 	cfg.ec.HostWhitelist = flags.UniqueStringsMapFromFlag(cfg.cf.flagSet, "host-whitelist")
 
 	cfg.ec.CipherSuites = flags.StringsFromFlag(cfg.cf.flagSet, "cipher-suites")
+	cfg.ec.ExperimentalQuotaExemptPrefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-quota-exempt-prefixes")
+	cfg.ec.ExperimentalWatchMetricsPrefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-watch-metrics-prefixes")
+	cfg.ec.ExperimentalAuditLogFilter.Methods = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-audit-log-methods")
+	cfg.ec.ExperimentalAuditLogFilter.Users = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-audit-log-users")
+	cfg.ec.ExperimentalAuditLogFilter.Prefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-audit-log-prefixes")
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-range-rate-limits") {
+		l, err := cconfig.ParseRangeRateLimit(s)
+		if err != nil {
+			return err
+		}
+		cfg.ec.ExperimentalRangeRateLimits = append(cfg.ec.ExperimentalRangeRateLimits, l)
+	}
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-compaction-retention-policies") {
+		p, err := cconfig.ParseCompactionRetentionPolicy(s)
+		if err != nil {
+			return err
+		}
+		cfg.ec.ExperimentalCompactionRetentionPolicies = append(cfg.ec.ExperimentalCompactionRetentionPolicies, p)
+	}
+	for _, s := range flags.StringsFromFlag(cfg.cf.flagSet, "experimental-cdc-sinks") {
+		c, err := cconfig.ParseCDCSink(s)
+		if err != nil {
+			return err
+		}
+		cfg.ec.ExperimentalCDCSinks = append(cfg.ec.ExperimentalCDCSinks, c)
+	}
 
 	cfg.ec.LogOutputs = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "log-outputs")
 
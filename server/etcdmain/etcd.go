@@ -108,6 +108,10 @@ func startEtcdOrProxyV2(args []string) {
 		)
 	}
 
+	if cfg.selfTest {
+		os.Exit(runSelfTest(lg, &cfg.ec, os.Stdout))
+	}
+
 	var stopped <-chan struct{}
 	var errc <-chan error
 
@@ -15,13 +15,16 @@
 package etcdmain
 
 import (
+	"crypto/tls"
 	"fmt"
+	"math"
 	"net"
 	"net/url"
 	"os"
 	"time"
 
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/server/v3/proxy/tcpproxy"
 
 	"github.com/spf13/cobra"
@@ -36,6 +39,11 @@ var (
 	gatewayInsecureDiscovery     bool
 	gatewayRetryDelay            time.Duration
 	gatewayCA                    string
+
+	gatewayHealthCheck         string
+	gatewayHealthCheckInterval time.Duration
+	gatewayHealthCheckMaxLag   uint64
+	gatewayEndpointWeights     map[string]int
 )
 
 var (
@@ -78,6 +86,11 @@ func newGatewayStartCommand() *cobra.Command {
 
 	cmd.Flags().DurationVar(&gatewayRetryDelay, "retry-delay", time.Minute, "duration of delay before retrying failed endpoints")
 
+	cmd.Flags().StringVar(&gatewayHealthCheck, "health-check", "", "actively check the health of the endpoints through 'grpc' or 'http', and stop routing new connections to unhealthy endpoints (uses the CA of --trusted-ca-file for TLS if set)")
+	cmd.Flags().DurationVar(&gatewayHealthCheckInterval, "health-check-interval", 5*time.Second, "duration between the health checks of the endpoints")
+	cmd.Flags().Uint64Var(&gatewayHealthCheckMaxLag, "health-check-max-lag", 0, "raft index an endpoint may lag behind the most advanced endpoint before it is considered unhealthy with the 'grpc' health check, 0 to disable")
+	cmd.Flags().StringToIntVar(&gatewayEndpointWeights, "endpoint-weights", nil, "comma separated endpoint=weight pairs routing new connections to the endpoints in proportion to their weights")
+
 	return &cmd
 }
 
@@ -92,6 +105,14 @@ func stripSchema(eps []string) []string {
 	return endpoints
 }
 
+func stripWeightSchema(weights map[string]int) map[string]int {
+	stripped := make(map[string]int, len(weights))
+	for ep, w := range weights {
+		stripped[stripSchema([]string{ep})[0]] = w
+	}
+	return stripped
+}
+
 func startGateway(cmd *cobra.Command, args []string) {
 	lg, err := logutil.CreateDefaultZapLogger(zap.InfoLevel)
 	if err != nil {
@@ -162,6 +183,44 @@ func startGateway(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	for ep, w := range stripWeightSchema(gatewayEndpointWeights) {
+		if w < 0 || w > math.MaxUint16 {
+			fmt.Printf("invalid weight %d of endpoint %q\n", w, ep)
+			os.Exit(1)
+		}
+		found := false
+		for _, srv := range srvs.SRVs {
+			if net.JoinHostPort(srv.Target, fmt.Sprintf("%d", srv.Port)) == ep {
+				srv.Weight, found = uint16(w), true
+			}
+		}
+		if !found {
+			fmt.Printf("weighted endpoint %q is not an endpoint\n", ep)
+			os.Exit(1)
+		}
+	}
+
+	var hc tcpproxy.HealthChecker
+	if gatewayHealthCheck != "" {
+		var tlsConfig *tls.Config
+		if gatewayCA != "" {
+			tlsInfo := transport.TLSInfo{TrustedCAFile: gatewayCA, Logger: lg}
+			if tlsConfig, err = tlsInfo.ClientConfig(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		switch gatewayHealthCheck {
+		case "grpc":
+			hc = tcpproxy.NewGRPCHealthChecker(tlsConfig)
+		case "http":
+			hc = tcpproxy.NewHTTPHealthChecker(tlsConfig)
+		default:
+			fmt.Printf("unknown health check %q (expected 'grpc' or 'http')\n", gatewayHealthCheck)
+			os.Exit(1)
+		}
+	}
+
 	var l net.Listener
 	l, err = net.Listen("tcp", gatewayListenAddr)
 	if err != nil {
@@ -174,6 +233,10 @@ func startGateway(cmd *cobra.Command, args []string) {
 		Listener:        l,
 		Endpoints:       srvs.SRVs,
 		MonitorInterval: gatewayRetryDelay,
+
+		HealthCheck:         hc,
+		HealthCheckInterval: gatewayHealthCheckInterval,
+		MaxLag:              gatewayHealthCheckMaxLag,
 	}
 
 	// At this point, etcd gateway listener is initialized
@@ -41,6 +41,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3election/v3electionpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy/cache"
 	"go.uber.org/zap/zapgrpc"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -89,6 +90,9 @@ var (
 	grpcProxyEnablePprof    bool
 	grpcProxyEnableOrdering bool
 
+	grpcProxyCacheMaxEntries int
+	grpcProxyCacheTTL        time.Duration
+
 	grpcProxyDebug bool
 
 	// GRPC keep alive related options.
@@ -157,6 +161,10 @@ func newGRPCProxyStartCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&grpcProxyEnableOrdering, "experimental-serializable-ordering", false, "Ensure serializable reads have monotonically increasing store revisions across endpoints.")
 	cmd.Flags().StringVar(&grpcProxyLeasing, "experimental-leasing-prefix", "", "leasing metadata prefix for disconnected linearized reads.")
 
+	// cache of the serializable ranges
+	cmd.Flags().IntVar(&grpcProxyCacheMaxEntries, "cache-max-entries", cache.DefaultMaxEntries, "Maximum number of range responses cached.")
+	cmd.Flags().DurationVar(&grpcProxyCacheTTL, "cache-ttl", 0, "Time after which a cached range response expires (0 to never expire).")
+
 	cmd.Flags().BoolVar(&grpcProxyDebug, "debug", false, "Enable debug-level logging for grpc-proxy.")
 
 	return &cmd
@@ -383,7 +391,7 @@ func mustListenCMux(lg *zap.Logger, tlsinfo *transport.TLSInfo) cmux.CMux {
 func newGRPCProxyServer(lg *zap.Logger, client *clientv3.Client) *grpc.Server {
 	if grpcProxyEnableOrdering {
 		vf := ordering.NewOrderViolationSwitchEndpointClosure(client)
-		client.KV = ordering.NewKV(client.KV, vf)
+		client.KV = ordering.NewKV(client.KV, vf, ordering.WithReroute(client))
 		lg.Info("waiting for linearized read from cluster to recover ordering")
 		for {
 			_, err := client.KV.Get(context.TODO(), "_", clientv3.WithKeysOnly())
@@ -405,7 +413,7 @@ func newGRPCProxyServer(lg *zap.Logger, client *clientv3.Client) *grpc.Server {
 		client.KV, _, _ = leasing.NewKV(client, grpcProxyLeasing)
 	}
 
-	kvp, _ := grpcproxy.NewKvProxy(client)
+	kvp, _ := grpcproxy.NewKvProxyWithCache(client.Ctx(), client, cache.Config{MaxEntries: grpcProxyCacheMaxEntries, TTL: grpcProxyCacheTTL})
 	watchp, _ := grpcproxy.NewWatchProxy(client.Ctx(), lg, client)
 	if grpcProxyResolverPrefix != "" {
 		grpcproxy.Register(lg, client, grpcProxyResolverPrefix, grpcProxyAdvertiseClientURL, grpcProxyResolverTTL)
@@ -35,6 +35,9 @@ var (
   etcd -h | --help
     Show the help information about etcd.
 
+  etcd --self-test [flags]
+    Check fsync latency of the data and wal directories, clock resolution, listen URLs and reachability of initial cluster peers, print a JSON report and exit. Exits with a non-zero status if any check fails.
+
   etcd --config-file
     Path to the server configuration file. Note that if a configuration file is provided, other command line flags and environment variables will be ignored.
 
@@ -204,6 +207,8 @@ Auth:
     Specify the cost / strength of the bcrypt algorithm for hashing auth passwords. Valid values are between ` + fmt.Sprintf("%d", bcrypt.MinCost) + ` and ` + fmt.Sprintf("%d", bcrypt.MaxCost) + `.
   --auth-token-ttl 300
     Time (in seconds) of the auth-token-ttl.
+  --experimental-auth-token-oidc ''
+    Specify the options of the OpenID Connect issuer whose ID tokens are accepted besides the auth tokens, such as 'issuer=https://sso.example.com,audience=etcd,group-roles=/etc/etcd/group-roles.json', the JSON file of 'group-roles' mapping the groups of the ID tokens to roles.
 
 Profiling and Monitoring:
   --enable-pprof 'false'
@@ -239,6 +244,20 @@ Experimental distributed tracing:
   --experimental-distributed-tracing-sampling-rate '0'
     Number of samples to collect per million spans for distributed tracing. Disabled by default.
 
+Experimental audit logging:
+  --experimental-audit-log-path ''
+    File to record the mutating and auth requests to, apart from the server logs. Empty disables the audit log.
+  --experimental-audit-log-rotation-config-json '{"maxsize": 100, "maxage": 0, "maxbackups": 0, "localtime": false, "compress": false}'
+    Configures the rotation of the audit log with a JSON logger config, as log-rotation-config-json.
+  --experimental-audit-log-methods ''
+    Comma-separated list of the RPCs recorded in the audit log, such as 'Put,UserAdd'. Empty records all the audited RPCs.
+  --experimental-audit-log-users ''
+    Comma-separated list of the users whose requests are recorded in the audit log. Empty records the requests of all users.
+  --experimental-audit-log-prefixes ''
+    Comma-separated list of the key prefixes the requests recorded in the audit log touch. Requests touching no keys are always recorded.
+  --experimental-audit-log-failures-only 'false'
+    Only record the failed requests in the audit log.
+
 v2 Proxy (to be deprecated in v3.6):
   --proxy 'off'
     Proxy mode setting ('off', 'readonly' or 'on').
@@ -260,24 +279,85 @@ Experimental feature:
     Duration of time between cluster corruption check passes.
   --experimental-enable-lease-checkpoint 'false'
     ExperimentalEnableLeaseCheckpoint enables primary lessor to persist lease remainingTTL to prevent indefinite auto-renewal of long lived leases.
+  --experimental-enable-lease-fast-renew 'false'
+    Enable leader to renew leases without waiting for raft to clear their checkpointed remaining TTL. Requires experimental-enable-lease-checkpoint to be enabled.
+  --experimental-lease-revoke-max-keys '0'
+    Maximum number of keys deleted by a single apply when revoking expired leases. 0 revokes every expired lease in its own apply.
+  --experimental-lease-revoke-spread '0s'
+    Wait duration between the batches revoking expired leases. Requires experimental-lease-revoke-max-keys to be set.
   --experimental-compaction-batch-limit 1000
     ExperimentalCompactionBatchLimit sets the maximum revisions deleted in each compaction batch.
+  --experimental-compaction-retention-policies ''
+    Comma-separated list of policies retaining the history of the keys of prefixes through the auto compactions, each made
+    of semicolon-separated fields among prefix, versions and age, e.g. 'prefix=/config/;versions=10;age=24h'. The
+    revisions retained stay readable within the prefix after the compactions, from the lowest revision all the keys of the
+    prefix keep their history from. Requires auto-compaction-retention to be set.
+  --experimental-compaction-target-commit-latency '100ms'
+    Commit latency above which the compaction batches shrink and pause longer between them, so that large compactions
+    do not stall the reads. 0 keeps the batch limit and sleep interval fixed.
   --experimental-peer-skip-client-san-verification 'false'
     Skip verification of SAN field in client certificate for peer connections.
   --experimental-watch-progress-notify-interval '10m'
     Duration of periodical watch progress notification.
+  --experimental-watch-max-events-per-second 0
+    Maximum number of events sent per second on each watch stream. 0 means no limit.
+  --experimental-watch-metrics-prefixes ''
+    Comma-separated list of key prefixes the watch events sent are counted by, the other watches being counted as 'other'.
+  --experimental-slow-request-threshold '0s'
+    Latency above which the requests are recorded in the slow log, queried with 'etcdctl slow-log'. 0 disables the slow log.
+  --experimental-slow-request-log-size 256
+    Number of the last slow requests kept in the slow log.
   --experimental-warning-apply-duration '100ms'
     Warning is generated if requests take more than this duration.
   --experimental-txn-mode-write-with-shared-buffer 'true'
     Enable the write transaction to use a shared buffer in its readonly check operations.
   --experimental-bootstrap-defrag-threshold-megabytes
     Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.
+  --experimental-encryption-key-file ''
+    Path to the key encryption keys, one '<id>:<base64 key>' per line, the first wrapping new data encryption keys. Enables the encryption of the backend values and the WAL entries at rest.
+  --experimental-encryption-key-rotation-interval '0s'
+    Interval between the rotations of the data encryption key. 0 only rotates the key on restart. Requires experimental-encryption-key-file to be set.
+  --experimental-wal-compression 'false'
+    Compresses the data of the WAL entries, reducing the disk usage and the bytes synced for large values. The WAL files are read whether their entries are compressed or not.
+  --experimental-wal-segment-size-bytes '0'
+    Size from which the WAL files are cut. 0 uses the default 64MB.
   --experimental-warning-unary-request-duration '300ms'
     Set time duration after which a warning is generated if a unary request takes more than this duration.
   --experimental-max-learners '1'
     Set the max number of learner members allowed in the cluster membership.
+  --experimental-enable-learner-auto-promote 'false'
+    Enable the leader to promote a learner once it is within experimental-learner-auto-promote-max-lag entries of the leader's log and does not receive a snapshot.
+  --experimental-learner-auto-promote-max-lag '1000'
+    Number of raft entries a learner may lag behind the leader to be promoted automatically. Requires experimental-enable-learner-auto-promote to be enabled.
   --experimental-wait-cluster-ready-timeout '5s'
     Set the maximum time duration to wait for the cluster to be ready.
+  --experimental-max-concurrent-client-connections 0
+    Maximum number of concurrently open connections on each client listener. 0 means no limit.
+  --experimental-client-accept-rate 0
+    Maximum number of new connections per second accepted on each client listener. 0 means no limit.
+  --experimental-client-accept-burst 0
+    Number of connections that can be accepted in a burst above experimental-client-accept-rate. Defaults to the accept rate.
+  --experimental-quota-exempt-prefixes ''
+    Comma-separated list of key prefixes that can still be written after the backend quota is exceeded.
+  --experimental-quota-exempt-bytes '1048576'
+    Number of bytes past the backend quota that writes to experimental-quota-exempt-prefixes may use.
+  --experimental-range-rate-limits ''
+    Comma-separated list of rate limits of the requests on key prefixes, each made of semicolon-separated fields
+    among prefix, user, read-requests-per-second, write-requests-per-second, read-bytes-per-second and
+    write-bytes-per-second, e.g. 'prefix=/tenant/;user=alice;write-requests-per-second=100'. The limits apply to the
+    requests served by each member, so a cluster of N members serves up to N times their rates.
+  --experimental-max-follower-lag 0
+    Number of raft entries a voting member may lag behind the leader before new proposals are throttled. 0 disables throttling.
+  --experimental-cdc-sinks ''
+    Comma-separated list of sinks the events of the key-value store are published to while the member is leader, each
+    made of semicolon-separated fields among name, url, prefix and batch-size, e.g.
+    'name=orders;url=nats://127.0.0.1:4222/etcd.orders;prefix=/orders/'. The url schemes are nats and nats+tls for a
+    subject of a NATS JetStream stream, and kafka+http and kafka+https for a Kafka REST proxy. The revision of the last event published is checkpointed, so
+    that the events are published at least once across leader changes.
+  --experimental-request-id-window 10000
+    Number of the most recent request IDs of the mutations remembered, so that a Put, Delete or Txn retried by a client
+    with the same request ID is applied once and the retries get the response of the first attempt. 0 ignores the
+    request IDs.
 
 Unsafe feature:
   --force-new-cluster 'false'
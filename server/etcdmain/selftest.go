@@ -0,0 +1,252 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdmain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// selfTestFsyncSamples is the number of write+fdatasync rounds used to
+	// probe the latency of each directory.
+	selfTestFsyncSamples = 20
+	// selfTestFsyncBlockSize approximates the size of a small WAL append.
+	selfTestFsyncBlockSize = 8 * 1024
+	// selfTestMaxFsyncP99 is the highest 99th percentile fsync latency that
+	// passes. Slower disks lead to missed heartbeats and leader elections.
+	selfTestMaxFsyncP99 = 10 * time.Millisecond
+
+	// selfTestClockSamples is the number of clock readings used to estimate
+	// the clock resolution.
+	selfTestClockSamples = 1000
+	// selfTestMaxClockResolution is the coarsest clock resolution that passes.
+	// Heartbeat and election timeouts are configured in milliseconds.
+	selfTestMaxClockResolution = time.Millisecond
+)
+
+// selfTestReport is printed as JSON by "etcd --self-test".
+type selfTestReport struct {
+	Passed  bool             `json:"passed"`
+	Results []selfTestResult `json:"results"`
+}
+
+type selfTestResult struct {
+	Check   string             `json:"check"`
+	Target  string             `json:"target,omitempty"`
+	Passed  bool               `json:"passed"`
+	Error   string             `json:"error,omitempty"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// runSelfTest checks that the node meets the disk, clock and network
+// prerequisites of the given configuration, writes the report to w and
+// returns the process exit code.
+func runSelfTest(lg *zap.Logger, cfg *embed.Config, w io.Writer) int {
+	report := selfTest(lg, cfg)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		lg.Warn("failed to write self-test report", zap.Error(err))
+		return 1
+	}
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}
+
+func selfTest(lg *zap.Logger, cfg *embed.Config) selfTestReport {
+	var results []selfTestResult
+	results = append(results, checkFsyncLatency(lg, cfg.Dir))
+	if cfg.WalDir != "" {
+		results = append(results, checkFsyncLatency(lg, cfg.WalDir))
+	}
+	results = append(results, checkClockResolution())
+	for _, u := range cfg.LPUrls {
+		results = append(results, checkListen(u))
+	}
+	for _, u := range cfg.LCUrls {
+		results = append(results, checkListen(u))
+	}
+	results = append(results, checkPeerReachability(cfg)...)
+
+	report := selfTestReport{Passed: true, Results: results}
+	for _, r := range results {
+		if !r.Passed {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+func checkFsyncLatency(lg *zap.Logger, dir string) selfTestResult {
+	r := selfTestResult{Check: "fsync-latency", Target: dir}
+	created := !fileutil.Exist(dir)
+	if err := fileutil.TouchDirAll(lg, dir); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if created {
+		defer os.RemoveAll(dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".etcd-self-test-")
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	buf := make([]byte, selfTestFsyncBlockSize)
+	latencies := make([]time.Duration, 0, selfTestFsyncSamples)
+	for i := 0; i < selfTestFsyncSamples; i++ {
+		if _, err = f.Write(buf); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		start := time.Now()
+		if err = fileutil.Fdatasync(f); err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p50 := latencies[len(latencies)*50/100]
+	p99 := latencies[(len(latencies)*99-1)/100]
+	r.Metrics = map[string]float64{
+		"p50_ms": toMilliseconds(p50),
+		"p99_ms": toMilliseconds(p99),
+		"max_ms": toMilliseconds(latencies[len(latencies)-1]),
+	}
+	r.Passed = p99 <= selfTestMaxFsyncP99
+	if !r.Passed {
+		r.Error = fmt.Sprintf("99th percentile fsync latency %v exceeds %v", p99, selfTestMaxFsyncP99)
+	}
+	return r
+}
+
+func checkClockResolution() selfTestResult {
+	r := selfTestResult{Check: "clock-resolution"}
+	var resolution time.Duration
+	prev := time.Now()
+	for i := 0; i < selfTestClockSamples; i++ {
+		now := time.Now()
+		d := now.Sub(prev)
+		if d < 0 {
+			r.Error = "monotonic clock went backwards"
+			return r
+		}
+		if d > 0 && (resolution == 0 || d < resolution) {
+			resolution = d
+		}
+		prev = now
+	}
+	if resolution == 0 {
+		r.Error = "clock did not advance"
+		return r
+	}
+	r.Metrics = map[string]float64{"resolution_us": float64(resolution) / float64(time.Microsecond)}
+	r.Passed = resolution <= selfTestMaxClockResolution
+	if !r.Passed {
+		r.Error = fmt.Sprintf("clock resolution %v is coarser than %v", resolution, selfTestMaxClockResolution)
+	}
+	return r
+}
+
+// checkListen verifies that the server would be able to bind the listen URL.
+func checkListen(u url.URL) selfTestResult {
+	r := selfTestResult{Check: "listen", Target: u.String()}
+	network, addr := selfTestNetworkAddr(u)
+	if network == "unix" && fileutil.Exist(addr) {
+		r.Error = fmt.Sprintf("socket file %q already exists", addr)
+		return r
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	l.Close()
+	r.Passed = true
+	return r
+}
+
+// checkPeerReachability dials the peer URLs of the other members of the
+// initial cluster, using the election timeout as the dial timeout.
+func checkPeerReachability(cfg *embed.Config) []selfTestResult {
+	if cfg.InitialCluster == "" {
+		return nil
+	}
+	var results []selfTestResult
+	urlsmap, err := types.NewURLsMap(cfg.InitialCluster)
+	if err != nil {
+		return []selfTestResult{{Check: "peer-reachability", Target: cfg.InitialCluster, Error: err.Error()}}
+	}
+	names := make([]string, 0, len(urlsmap))
+	for name := range urlsmap {
+		if name != cfg.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	timeout := time.Duration(cfg.ElectionMs) * time.Millisecond
+	for _, name := range names {
+		for _, u := range urlsmap[name] {
+			r := selfTestResult{Check: "peer-reachability", Target: name + "=" + u.String()}
+			network, addr := selfTestNetworkAddr(u)
+			start := time.Now()
+			conn, err := net.DialTimeout(network, addr, timeout)
+			if err != nil {
+				r.Error = err.Error()
+				results = append(results, r)
+				continue
+			}
+			r.Metrics = map[string]float64{"dial_ms": toMilliseconds(time.Since(start))}
+			conn.Close()
+			r.Passed = true
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func selfTestNetworkAddr(u url.URL) (network, addr string) {
+	if u.Scheme == "unix" || u.Scheme == "unixs" {
+		return "unix", u.Host
+	}
+	return "tcp", u.Host
+}
+
+func toMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
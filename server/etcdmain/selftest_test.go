@@ -0,0 +1,88 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdmain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSelfTestReport(t *testing.T) {
+	peer, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	unreachable, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable.Close()
+
+	cfg := embed.NewConfig()
+	cfg.Name = "m1"
+	cfg.Dir = filepath.Join(t.TempDir(), "m1.etcd")
+	cfg.LPUrls = []url.URL{{Scheme: "http", Host: "127.0.0.1:0"}}
+	cfg.LCUrls = []url.URL{{Scheme: "http", Host: "127.0.0.1:0"}}
+	cfg.InitialCluster = fmt.Sprintf("m1=http://127.0.0.1:0,m2=http://%s,m3=http://%s", peer.Addr(), unreachable.Addr())
+
+	var buf bytes.Buffer
+	runSelfTest(zaptest.NewLogger(t), cfg, &buf)
+	var report selfTestReport
+	if err = json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report %q: %v", buf.String(), err)
+	}
+	if report.Passed {
+		t.Fatalf("expected report to fail because of unreachable peer, got %+v", report)
+	}
+	if fileutil.Exist(cfg.Dir) {
+		t.Errorf("expected data dir %q created for the self test to be removed", cfg.Dir)
+	}
+
+	results := make(map[string]selfTestResult)
+	for _, r := range report.Results {
+		results[r.Check+" "+r.Target] = r
+	}
+	for _, key := range []string{
+		"fsync-latency " + cfg.Dir,
+		"clock-resolution ",
+		"listen http://127.0.0.1:0",
+		"peer-reachability m2=http://" + peer.Addr().String(),
+		"peer-reachability m3=http://" + unreachable.Addr().String(),
+	} {
+		if _, ok := results[key]; !ok {
+			t.Errorf("missing result %q in %+v", key, report.Results)
+		}
+	}
+	if r := results["fsync-latency "+cfg.Dir]; r.Error == "" && r.Metrics["p99_ms"] <= 0 {
+		t.Errorf("expected fsync latency metrics, got %+v", r)
+	}
+	if r := results["peer-reachability m2=http://"+peer.Addr().String()]; !r.Passed {
+		t.Errorf("expected reachable peer to pass, got %+v", r)
+	}
+	if r := results["peer-reachability m3=http://"+unreachable.Addr().String()]; r.Passed || r.Error == "" {
+		t.Errorf("expected unreachable peer to fail, got %+v", r)
+	}
+}
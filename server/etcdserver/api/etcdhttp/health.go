@@ -43,8 +43,9 @@ type ServerHealth interface {
 }
 
 // HandleHealth registers metrics and health handlers. it checks health by using v3 range request
-// and its corresponding timeout.
+// and its corresponding timeout. It also registers the livez and readyz endpoints.
 func HandleHealth(lg *zap.Logger, mux *http.ServeMux, srv ServerHealth) {
+	HandleHealthChecks(lg, mux, srv)
 	mux.Handle(PathHealth, NewHealthHandler(lg, func(excludedAlarms AlarmSet, serializable bool) Health {
 		if h := checkAlarms(lg, srv, excludedAlarms); h.Health != "true" {
 			return h
@@ -150,6 +151,10 @@ func checkAlarms(lg *zap.Logger, srv ServerHealth, excludedAlarms AlarmSet) Heal
 				lg.Debug("/health excluded alarm", zap.String("alarm", v.String()))
 				continue
 			}
+			if v.Alarm == etcdserverpb.AlarmType_READONLY {
+				// the read-only mode is set on purpose, the member still serves.
+				continue
+			}
 
 			h.Health = "false"
 			switch v.Alarm {
@@ -0,0 +1,167 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/server/v3/auth"
+	"go.uber.org/zap"
+)
+
+const (
+	PathLivez   = "/livez"
+	PathReadyz  = "/readyz"
+	PathHealthz = "/healthz"
+)
+
+// HealthCheck is a named check of a health endpoint. Check returns the reason
+// of a failure.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+var healthChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "etcd",
+	Subsystem: "server",
+	Name:      "health_checks_total",
+	Help:      "The total number of checks of the livez and readyz endpoints by endpoint, check and status.",
+},
+	[]string{"endpoint", "check", "status"},
+)
+
+func init() {
+	prometheus.MustRegister(healthChecks)
+}
+
+// HandleHealthChecks registers the livez and readyz endpoints. A member is
+// live as long as it serves the local reads, so that it is only restarted if
+// the process is wedged, and ready if it is part of a working cluster. The
+// healthz endpoint is an alias of readyz.
+//
+// Each check is also served on its own, e.g. on '/readyz/leader'. The checks
+// are skipped with the "exclude" query parameter, e.g. '/readyz?exclude=NOSPACE',
+// and listed with the "verbose" query parameter.
+func HandleHealthChecks(lg *zap.Logger, mux *http.ServeMux, srv ServerHealth) {
+	livez := []HealthCheck{
+		{Name: "serializable_read", Check: readCheck(srv, true)},
+	}
+	readyz := []HealthCheck{
+		{Name: "leader", Check: leaderCheck(srv)},
+		{Name: pb.AlarmType_NOSPACE.String(), Check: alarmCheck(srv, pb.AlarmType_NOSPACE)},
+		{Name: pb.AlarmType_CORRUPT.String(), Check: alarmCheck(srv, pb.AlarmType_CORRUPT)},
+		{Name: "serializable_read", Check: readCheck(srv, true)},
+		{Name: "linearizable_read", Check: readCheck(srv, false)},
+	}
+	installHealthChecks(lg, mux, PathLivez, livez)
+	installHealthChecks(lg, mux, PathReadyz, readyz)
+	installHealthChecks(lg, mux, PathHealthz, readyz)
+}
+
+func installHealthChecks(lg *zap.Logger, mux *http.ServeMux, path string, checks []HealthCheck) {
+	mux.Handle(path, newHealthChecksHandler(lg, path, checks))
+	for _, c := range checks {
+		mux.Handle(path+"/"+c.Name, newHealthChecksHandler(lg, path, []HealthCheck{c}))
+	}
+}
+
+func newHealthChecksHandler(lg *zap.Logger, path string, checks []HealthCheck) http.HandlerFunc {
+	endpoint := strings.TrimPrefix(path, "/")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// the checks are excluded as the alarms of '/health'
+		excluded := getExcludedAlarms(r)
+		verbose := r.URL.Query().Has("verbose")
+
+		var out bytes.Buffer
+		failed := false
+		for _, c := range checks {
+			if _, ok := excluded[c.Name]; ok {
+				fmt.Fprintf(&out, "[+]%s excluded: ok\n", c.Name)
+				healthChecks.WithLabelValues(endpoint, c.Name, "excluded").Inc()
+				continue
+			}
+			if err := c.Check(r.Context()); err != nil {
+				failed = true
+				fmt.Fprintf(&out, "[-]%s failed: %v\n", c.Name, err)
+				healthChecks.WithLabelValues(endpoint, c.Name, "failed").Inc()
+				lg.Warn("health check failed", zap.String("endpoint", path), zap.String("check", c.Name), zap.Error(err))
+				continue
+			}
+			fmt.Fprintf(&out, "[+]%s ok\n", c.Name)
+			healthChecks.WithLabelValues(endpoint, c.Name, "success").Inc()
+		}
+
+		if failed {
+			fmt.Fprintf(&out, "%s check failed", endpoint)
+			http.Error(w, out.String(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !verbose {
+			fmt.Fprint(w, "ok")
+			return
+		}
+		fmt.Fprintf(&out, "%s check passed\n", endpoint)
+		w.Write(out.Bytes())
+	}
+}
+
+func leaderCheck(srv ServerHealth) func(context.Context) error {
+	return func(context.Context) error {
+		if uint64(srv.Leader()) == raft.None {
+			return errors.New("RAFT NO LEADER")
+		}
+		return nil
+	}
+}
+
+func alarmCheck(srv ServerHealth, alarm pb.AlarmType) func(context.Context) error {
+	return func(context.Context) error {
+		for _, v := range srv.Alarms() {
+			if v.Alarm == alarm {
+				return fmt.Errorf("ALARM %s", alarm)
+			}
+		}
+		return nil
+	}
+}
+
+func readCheck(srv ServerHealth, serializable bool) func(context.Context) error {
+	return func(ctx context.Context) error {
+		cfg := srv.Config()
+		ctx, cancel := context.WithTimeout(ctx, cfg.ReqTimeout())
+		defer cancel()
+		_, err := srv.Range(ctx, &pb.RangeRequest{KeysOnly: true, Limit: 1, Serializable: serializable})
+		if err != nil && err != auth.ErrUserEmpty && err != auth.ErrPermissionDenied {
+			return fmt.Errorf("RANGE ERROR:%s", err)
+		}
+		return nil
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -170,6 +171,110 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestHealthChecksHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		alarms   []*pb.AlarmMember
+		noLeader bool
+		apiError error
+		url      string
+
+		expectStatusCode int
+		expectBody       []string
+	}{
+		{
+			name:             "Live and ready",
+			url:              "/readyz",
+			expectStatusCode: http.StatusOK,
+			expectBody:       []string{"ok"},
+		},
+		{
+			name:             "Live without a leader",
+			noLeader:         true,
+			url:              "/livez",
+			expectStatusCode: http.StatusOK,
+			expectBody:       []string{"ok"},
+		},
+		{
+			name:             "Not ready without a leader",
+			noLeader:         true,
+			url:              "/readyz",
+			expectStatusCode: http.StatusServiceUnavailable,
+			expectBody:       []string{"[-]leader failed: RAFT NO LEADER", "[+]NOSPACE ok", "readyz check failed"},
+		},
+		{
+			name:             "Not ready if NOSPACE alarm is on",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(0), Alarm: pb.AlarmType_NOSPACE}},
+			url:              "/readyz",
+			expectStatusCode: http.StatusServiceUnavailable,
+			expectBody:       []string{"[+]leader ok", "[-]NOSPACE failed: ALARM NOSPACE"},
+		},
+		{
+			name:             "Ready if NOSPACE alarm is on and excluded",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(0), Alarm: pb.AlarmType_NOSPACE}},
+			url:              "/readyz?exclude=NOSPACE&verbose",
+			expectStatusCode: http.StatusOK,
+			expectBody:       []string{"[+]NOSPACE excluded: ok", "[+]CORRUPT ok", "readyz check passed"},
+		},
+		{
+			name:             "Single check",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(0), Alarm: pb.AlarmType_NOSPACE}},
+			url:              "/readyz/leader",
+			expectStatusCode: http.StatusOK,
+			expectBody:       []string{"ok"},
+		},
+		{
+			name:             "Healthz aliases readyz",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(0), Alarm: pb.AlarmType_CORRUPT}},
+			url:              "/healthz",
+			expectStatusCode: http.StatusServiceUnavailable,
+			expectBody:       []string{"[-]CORRUPT failed: ALARM CORRUPT", "healthz check failed"},
+		},
+		{
+			name:             "Not live if api is not available",
+			apiError:         fmt.Errorf("Unexpected error"),
+			url:              "/livez?verbose",
+			expectStatusCode: http.StatusServiceUnavailable,
+			expectBody:       []string{"[-]serializable_read failed: RANGE ERROR:Unexpected error", "livez check failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := "true"
+			if tt.noLeader {
+				health = "false"
+			}
+			mux := http.NewServeMux()
+			HandleHealth(zaptest.NewLogger(t), mux, &fakeHealthServer{
+				fakeServer: fakeServer{alarms: tt.alarms},
+				health:     health,
+				apiError:   tt.apiError,
+			})
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+
+			res, err := ts.Client().Get(ts.URL + tt.url)
+			if err != nil {
+				t.Fatalf("fail serve http request %s %v", tt.url, err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.expectStatusCode {
+				t.Errorf("want statusCode %d but got %d", tt.expectStatusCode, res.StatusCode)
+			}
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.expectBody {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("want %q in body %q", want, body)
+				}
+			}
+		})
+	}
+}
+
 func parseHealthOutput(body io.Reader) (Health, error) {
 	obj := Health{}
 	d, derr := io.ReadAll(body)
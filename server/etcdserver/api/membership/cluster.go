@@ -344,6 +344,11 @@ func (c *RaftCluster) ValidateConfigurationChange(cc raftpb.ConfChange) error {
 				}
 			}
 
+			if confChangeContext.Member.RaftAttributes.IsWitness &&
+				(confChangeContext.Member.RaftAttributes.IsLearner || cc.Type == raftpb.ConfChangeAddLearnerNode) {
+				return ErrWitnessLearner
+			}
+
 			if confChangeContext.Member.RaftAttributes.IsLearner && cc.Type == raftpb.ConfChangeAddLearnerNode { // the new member is a learner
 				scaleUpLearners := true
 				if err := ValidateMaxLearnerConfig(c.maxLearners, members, scaleUpLearners); err != nil {
@@ -408,6 +413,7 @@ func (c *RaftCluster) AddMember(m *Member, shouldApplyV3 ShouldApplyV3) {
 		zap.String("added-peer-id", m.ID.String()),
 		zap.Strings("added-peer-peer-urls", m.PeerURLs),
 		zap.Bool("added-peer-is-learner", m.IsLearner),
+		zap.Bool("added-peer-is-witness", m.IsWitness),
 	)
 }
 
@@ -715,6 +721,8 @@ func ValidateClusterAndAssignIDs(lg *zap.Logger, local *RaftCluster, existing *R
 		for j := range lms {
 			if ok, err = netutil.URLStringsEqual(ctx, lg, ems[i].PeerURLs, lms[j].PeerURLs); ok {
 				lms[j].ID = ems[i].ID
+				// a witness learns its role from the existing cluster.
+				lms[j].IsWitness = ems[i].IsWitness
 				break
 			}
 		}
@@ -745,6 +753,20 @@ func (c *RaftCluster) IsLocalMemberLearner() bool {
 	return localMember.IsLearner
 }
 
+// IsLocalMemberWitness returns if the local member is a witness. It returns
+// false if the local member is not (yet) known to the cluster.
+func (c *RaftCluster) IsLocalMemberWitness() bool {
+	return c.IsMemberWitness(c.localID)
+}
+
+// IsMemberWitness returns if the member with the given id is a witness.
+func (c *RaftCluster) IsMemberWitness(id types.ID) bool {
+	c.Lock()
+	defer c.Unlock()
+	m, ok := c.members[id]
+	return ok && m.IsWitness
+}
+
 // DowngradeInfo returns the downgrade status of the cluster
 func (c *RaftCluster) DowngradeInfo() *serverversion.DowngradeInfo {
 	c.Lock()
@@ -339,6 +339,12 @@ func TestClusterValidateConfigurationChange(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	attr = RaftAttributes{PeerURLs: []string{fmt.Sprintf("http://127.0.0.1:%d", 9)}, IsWitness: true}
+	ctx9, err := json.Marshal(&ConfigChangeContext{Member: Member{ID: types.ID(9), RaftAttributes: attr}})
+	if err != nil {
+		t.Fatal(err)
+	}
 	tests := []struct {
 		cc   raftpb.ConfChange
 		werr error
@@ -452,6 +458,22 @@ func TestClusterValidateConfigurationChange(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			raftpb.ConfChange{
+				Type:    raftpb.ConfChangeAddLearnerNode,
+				NodeID:  9,
+				Context: ctx9,
+			},
+			ErrWitnessLearner,
+		},
+		{
+			raftpb.ConfChange{
+				Type:    raftpb.ConfChangeAddNode,
+				NodeID:  9,
+				Context: ctx9,
+			},
+			nil,
+		},
 	}
 	for i, tt := range tests {
 		err := cl.ValidateConfigurationChange(tt.cc)
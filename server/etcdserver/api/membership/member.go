@@ -33,6 +33,9 @@ type RaftAttributes struct {
 	PeerURLs []string `json:"peerURLs"`
 	// IsLearner indicates if the member is raft learner.
 	IsLearner bool `json:"isLearner,omitempty"`
+	// IsWitness indicates if the member is a witness that votes in raft
+	// elections but stores no key-value data and hands over any leadership.
+	IsWitness bool `json:"isWitness,omitempty"`
 }
 
 // Attributes represents all the non-raft related attributes of an etcd member.
@@ -61,6 +64,14 @@ func NewMemberAsLearner(name string, peerURLs types.URLs, clusterName string, no
 	return newMember(name, peerURLs, memberId, true)
 }
 
+// NewMemberAsWitness creates a witness Member without an ID and generates one based on the
+// cluster name, peer URLs, and time. This is used for adding new witness member.
+func NewMemberAsWitness(name string, peerURLs types.URLs, clusterName string, now *time.Time) *Member {
+	m := NewMember(name, peerURLs, clusterName, now)
+	m.IsWitness = true
+	return m
+}
+
 func computeMemberId(peerURLs types.URLs, clusterName string, now *time.Time) types.ID {
 	peerURLstrs := peerURLs.StringSlice()
 	sort.Strings(peerURLstrs)
@@ -105,6 +116,7 @@ func (m *Member) Clone() *Member {
 		ID: m.ID,
 		RaftAttributes: RaftAttributes{
 			IsLearner: m.IsLearner,
+			IsWitness: m.IsWitness,
 		},
 		Attributes: Attributes{
 			Name: m.Name,
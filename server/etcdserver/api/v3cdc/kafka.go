@@ -0,0 +1,150 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3cdc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// kafkaRESTSink publishes the events to a Kafka topic through a Kafka REST
+// proxy, with its v2 API. The records are keyed by the keys of the events, so
+// that the events of a key are published to the same partition, in order. A
+// batch of events is acknowledged once the proxy returns the offsets of all
+// its records.
+//
+// The URL is "kafka+http://[user:password@]host:port[/path]/topic", or
+// "kafka+https://..." to connect with TLS, where path is the path of the API
+// of the proxy.
+type kafkaRESTSink struct {
+	lg       *zap.Logger
+	endpoint string
+	user     *url.Userinfo
+	client   *http.Client
+}
+
+func newKafkaRESTSink(lg *zap.Logger, u *url.URL) (Sink, error) {
+	dir, topic := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if topic == "" {
+		return nil, fmt.Errorf("missing kafka topic in %q", u.Redacted())
+	}
+	endpoint := url.URL{
+		Scheme: strings.TrimPrefix(u.Scheme, "kafka+"),
+		Host:   u.Host,
+		Path:   path.Join(dir, "topics", topic),
+	}
+	return &kafkaRESTSink{
+		lg:       lg,
+		endpoint: endpoint.String(),
+		user:     u.User,
+		client:   &http.Client{Timeout: publishTimeout},
+	}, nil
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int32   `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+type kafkaErrorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (s *kafkaRESTSink) Publish(ctx context.Context, evs []Event) error {
+	pr := kafkaProduceRequest{Records: make([]kafkaRecord, len(evs))}
+	for i, ev := range evs {
+		v, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		pr.Records[i] = kafkaRecord{Key: ev.Key, Value: v}
+	}
+	body, err := json.Marshal(pr)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// the binary embedded format encodes the keys and the values in base64
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if s.user != nil {
+		pass, _ := s.user.Password()
+		req.SetBasicAuth(s.user.Username(), pass)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var er kafkaErrorResponse
+		if json.Unmarshal(b, &er) == nil && er.Message != "" {
+			return fmt.Errorf("kafka rest proxy: %s (error code %d)", er.Message, er.ErrorCode)
+		}
+		return fmt.Errorf("kafka rest proxy: unexpected status %s", resp.Status)
+	}
+	var r kafkaProduceResponse
+	if err = json.Unmarshal(b, &r); err != nil {
+		return fmt.Errorf("kafka rest proxy: invalid response: %v", err)
+	}
+	if len(r.Offsets) != len(evs) {
+		return fmt.Errorf("kafka rest proxy: %d offsets returned for %d records", len(r.Offsets), len(evs))
+	}
+	for _, o := range r.Offsets {
+		if o.ErrorCode != nil || o.Error != nil {
+			msg := "unknown error"
+			if o.Error != nil {
+				msg = *o.Error
+			}
+			return fmt.Errorf("kafka rest proxy: failed to produce record to partition %d: %s", o.Partition, msg)
+		}
+	}
+	return nil
+}
+
+func (s *kafkaRESTSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
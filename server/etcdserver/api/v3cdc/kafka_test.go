@@ -0,0 +1,90 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3cdc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestKafkaRESTSink(t *testing.T) {
+	var (
+		records []kafkaRecord
+		status  = http.StatusOK
+		offsets = `{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null},{"partition":1,"offset":1,"error_code":null,"error":null}]}`
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/kafka/topics/etcd-events" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/vnd.kafka.binary.v2+json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			t.Errorf("unexpected basic auth %q, %q", user, pass)
+		}
+		var pr kafkaProduceRequest
+		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+			t.Error(err)
+		}
+		records = pr.Records
+		w.WriteHeader(status)
+		w.Write([]byte(offsets))
+	}))
+	defer srv.Close()
+
+	s, err := NewSink(zaptest.NewLogger(t), "kafka+"+strings.Replace(srv.URL, "://", "://alice:secret@", 1)+"/kafka/etcd-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	evs := []Event{
+		{Type: "PUT", Key: []byte("foo"), Value: []byte("bar"), Revision: 2, CreateRevision: 2, Version: 1, Lease: 5},
+		{Type: "DELETE", Key: []byte("baz"), Revision: 3},
+	}
+	if err = s.Publish(context.TODO(), evs); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(evs) {
+		t.Fatalf("expected %d records, got %d", len(evs), len(records))
+	}
+	for i, ev := range evs {
+		var got Event
+		if err = json.Unmarshal(records[i].Value, &got); err != nil {
+			t.Fatal(err)
+		}
+		if string(records[i].Key) != string(ev.Key) || !reflect.DeepEqual(got, ev) {
+			t.Errorf("expected record %s: %+v, got %s: %+v", ev.Key, ev, records[i].Key, got)
+		}
+	}
+
+	// the batch fails if any of its records is not produced
+	offsets = `{"offsets":[{"partition":0,"offset":2,"error_code":null,"error":null},{"partition":1,"offset":null,"error_code":50003,"error":"Leader not available"}]}`
+	if err = s.Publish(context.TODO(), evs); err == nil || !strings.Contains(err.Error(), "Leader not available") {
+		t.Errorf("expected the error of the record, got %v", err)
+	}
+	status, offsets = http.StatusNotFound, `{"error_code":40401,"message":"Topic not found."}`
+	if err = s.Publish(context.TODO(), evs); err == nil || !strings.Contains(err.Error(), "Topic not found.") {
+		t.Errorf("expected the error of the proxy, got %v", err)
+	}
+}
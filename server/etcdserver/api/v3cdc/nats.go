@@ -0,0 +1,333 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3cdc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/version"
+
+	"go.uber.org/zap"
+)
+
+// natsSink publishes the events as JSON messages on a subject of a NATS
+// JetStream stream. Each message is published with a reply subject, on which
+// JetStream sends its PubAck once the stream stored the message, and a batch
+// of events is acknowledged once all its messages are. Publishing on a subject
+// no stream captures fails, rather than the messages being dropped by core
+// NATS.
+//
+// The URL is "nats://[user:password@|token@]host:port/subject", or
+// "nats+tls://..." to connect with TLS.
+type natsSink struct {
+	lg      *zap.Logger
+	addr    string
+	host    string
+	tls     bool
+	subject string
+	user    *url.Userinfo
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	// inbox is the prefix of the reply subjects of the connection, on which
+	// the PubAcks are received.
+	inbox string
+}
+
+// natsSubscriptionID is the ID of the subscription to the inbox of a
+// connection, its only subscription.
+const natsSubscriptionID = "1"
+
+// natsPubAck is the reply of JetStream to a message published to a stream.
+type natsPubAck struct {
+	Stream string `json:"stream"`
+	Seq    uint64 `json:"seq"`
+	Error  *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+func newNATSSink(lg *zap.Logger, u *url.URL) (Sink, error) {
+	subject := strings.TrimPrefix(u.Path, "/")
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return nil, fmt.Errorf("invalid nats subject %q in %q", subject, u.Redacted())
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsSink{
+		lg:      lg,
+		addr:    addr,
+		host:    u.Hostname(),
+		tls:     u.Scheme == "nats+tls",
+		subject: subject,
+		user:    u.User,
+	}, nil
+}
+
+func (s *natsSink) Publish(ctx context.Context, evs []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.publish(ctx, evs); err != nil {
+		// the connection is in an unknown state, and late PubAcks must not
+		// be taken for the ones of the next batch
+		s.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeConn()
+}
+
+func (s *natsSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+// connect connects to the server, upgrading the connection to TLS once the
+// server sent its INFO if required, authenticates and subscribes to the inbox
+// of the connection.
+func (s *natsSink) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: publishTimeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline(ctx))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected nats greeting %q", strings.TrimSpace(line))
+	}
+	if s.tls {
+		tconn := tls.Client(conn, &tls.Config{ServerName: s.host})
+		if err = tconn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn, r = tconn, bufio.NewReader(tconn)
+	}
+
+	opts := map[string]interface{}{
+		"verbose":      false,
+		"pedantic":     false,
+		"tls_required": s.tls,
+		"name":         "etcd-cdc",
+		"lang":         "go",
+		"version":      version.Version,
+		"protocol":     1,
+		// a publication without stream gets a 503 status instead of no reply
+		"headers":       true,
+		"no_responders": true,
+	}
+	if s.user != nil {
+		if pass, ok := s.user.Password(); ok {
+			opts["user"], opts["pass"] = s.user.Username(), pass
+		} else {
+			opts["auth_token"] = s.user.Username()
+		}
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	id := make([]byte, 12)
+	if _, err = rand.Read(id); err != nil {
+		conn.Close()
+		return err
+	}
+	s.conn, s.r, s.inbox = conn, r, "_INBOX."+hex.EncodeToString(id)
+	// the PONG confirms that the server accepted the connection and the
+	// subscription
+	if _, err = fmt.Fprintf(conn, "CONNECT %s\r\nSUB %s.* %s\r\nPING\r\n", b, s.inbox, natsSubscriptionID); err == nil {
+		err = s.waitPong()
+	}
+	if err != nil {
+		s.closeConn()
+		return err
+	}
+	s.lg.Info("connected to nats cdc sink", zap.String("address", s.addr), zap.String("subject", s.subject))
+	return nil
+}
+
+func (s *natsSink) publish(ctx context.Context, evs []Event) error {
+	s.conn.SetDeadline(deadline(ctx))
+	w := bufio.NewWriter(s.conn)
+	for i, ev := range evs {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "PUB %s %s.%d %d\r\n", s.subject, s.inbox, i, len(b))
+		w.Write(b)
+		w.WriteString("\r\n")
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	acked := make([]bool, len(evs))
+	for pending := len(evs); pending > 0; {
+		i, err := s.readPubAck()
+		if err != nil {
+			return err
+		}
+		if i < 0 || i >= len(evs) {
+			return fmt.Errorf("nats: unexpected reply %d for a batch of %d events", i, len(evs))
+		}
+		if !acked[i] {
+			acked[i] = true
+			pending--
+		}
+	}
+	return nil
+}
+
+// readPubAck reads the protocol messages of the server until a PubAck,
+// replying to its PINGs, and returns the index of the event acknowledged.
+func (s *natsSink) readPubAck() (int, error) {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			if _, err = s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return 0, err
+			}
+		case "-ERR":
+			return 0, natsError(line)
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			if len(fields) < 4 {
+				return 0, fmt.Errorf("nats: malformed message %q", strings.TrimSpace(line))
+			}
+			payload, err := s.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return 0, err
+			}
+			var ack natsPubAck
+			if err = json.Unmarshal(payload, &ack); err != nil {
+				return 0, fmt.Errorf("nats: malformed pub ack %q: %v", payload, err)
+			}
+			if ack.Error != nil {
+				return 0, fmt.Errorf("nats: jetstream: %s (%d)", ack.Error.Description, ack.Error.Code)
+			}
+			return s.replyIndex(fields[1])
+		case "HMSG":
+			// HMSG <subject> <sid> [reply-to] <#header bytes> <#total bytes>,
+			// only sent as a status without payload
+			if len(fields) < 5 {
+				return 0, fmt.Errorf("nats: malformed message %q", strings.TrimSpace(line))
+			}
+			payload, err := s.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return 0, err
+			}
+			status := strings.TrimSpace(strings.SplitN(string(payload), "\r\n", 2)[0])
+			if strings.HasPrefix(status, "NATS/1.0 503") {
+				return 0, fmt.Errorf("nats: no jetstream stream for subject %q", s.subject)
+			}
+			return 0, fmt.Errorf("nats: unexpected reply %q", status)
+		}
+	}
+}
+
+// readPayload reads the payload of a message, of size bytes followed by CRLF.
+func (s *natsSink) readPayload(size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("nats: malformed message size %q", size)
+	}
+	b := make([]byte, n+2)
+	if _, err = io.ReadFull(s.r, b); err != nil {
+		return nil, err
+	}
+	return b[:n], nil
+}
+
+// replyIndex returns the index of the event of the reply subject.
+func (s *natsSink) replyIndex(subject string) (int, error) {
+	if !strings.HasPrefix(subject, s.inbox+".") {
+		return 0, fmt.Errorf("nats: unexpected reply subject %q", subject)
+	}
+	return strconv.Atoi(strings.TrimPrefix(subject, s.inbox+"."))
+}
+
+// waitPong reads the protocol messages of the server until a PONG, replying
+// to its PINGs.
+func (s *natsSink) waitPong() error {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		}
+	}
+}
+
+func natsError(line string) error {
+	return errors.New("nats: " + strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-ERR")), "'"))
+}
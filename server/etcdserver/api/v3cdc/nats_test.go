@@ -0,0 +1,191 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3cdc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeNATSServer accepts NATS connections, records the messages published
+// on them and replies with the PubAcks of a JetStream stream, with an error to
+// the messages published after failAfter messages if not zero. It replies
+// with no responders if noStream is set, and not at all if noAck is.
+type fakeNATSServer struct {
+	t  *testing.T
+	l  net.Listener
+	ch chan string
+
+	connects  chan map[string]interface{}
+	failAfter int32
+	noStream  int32
+	noAck     int32
+}
+
+func newFakeNATSServer(t *testing.T) *fakeNATSServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATSServer{t: t, l: l, ch: make(chan string, 100), connects: make(chan map[string]interface{}, 10)}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeNATSServer) serve() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *fakeNATSServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"max_payload\":1048576,\"headers\":true}\r\n")
+	r := bufio.NewReader(conn)
+	var inbox, sid string
+	published := 0
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			var opts map[string]interface{}
+			json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &opts)
+			s.connects <- opts
+		case "SUB":
+			inbox, sid = strings.TrimSuffix(fields[1], "*"), fields[2]
+		case "PUB":
+			if len(fields) != 4 || !strings.HasPrefix(fields[2], inbox) {
+				s.t.Errorf("expected a publication with a reply subject of the inbox, got %q", line)
+				return
+			}
+			var n int
+			fmt.Sscan(fields[3], &n)
+			b := make([]byte, n+2)
+			if _, err = io.ReadFull(r, b); err != nil {
+				return
+			}
+			published++
+			s.ch <- fields[1] + " " + string(b[:n])
+			// the sink must reply to the PINGs of the server
+			fmt.Fprintf(conn, "PING\r\n")
+			switch {
+			case atomic.LoadInt32(&s.noAck) != 0:
+			case atomic.LoadInt32(&s.noStream) != 0:
+				fmt.Fprintf(conn, "HMSG %s %s 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", fields[2], sid)
+			default:
+				ack := fmt.Sprintf(`{"stream":"EVENTS","seq":%d}`, published)
+				if n := int(atomic.LoadInt32(&s.failAfter)); n > 0 && published > n {
+					ack = `{"error":{"code":503,"description":"insufficient resources"}}`
+				}
+				fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", fields[2], sid, len(ack), ack)
+			}
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PONG":
+		default:
+			s.t.Errorf("unexpected nats message %q", line)
+			return
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	srv := newFakeNATSServer(t)
+	s, err := NewSink(zaptest.NewLogger(t), fmt.Sprintf("nats://alice:secret@%s/etcd.events", srv.l.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	evs := []Event{
+		{Type: "PUT", Key: []byte("foo"), Value: []byte("bar"), Revision: 2, CreateRevision: 2, Version: 1},
+		{Type: "DELETE", Key: []byte("foo"), Revision: 3},
+	}
+	if err = s.Publish(context.TODO(), evs); err != nil {
+		t.Fatal(err)
+	}
+	opts := <-srv.connects
+	if opts["user"] != "alice" || opts["pass"] != "secret" || opts["verbose"] != false || opts["no_responders"] != true {
+		t.Errorf("unexpected connect options %v", opts)
+	}
+	for _, ev := range evs {
+		b, _ := json.Marshal(ev)
+		if msg := <-srv.ch; msg != "etcd.events "+string(b) {
+			t.Errorf("expected message %s, got %s", b, msg)
+		}
+	}
+
+	// the events are published again on a new connection after an error
+	atomic.StoreInt32(&srv.failAfter, 2)
+	if err = s.Publish(context.TODO(), evs); err == nil || !strings.Contains(err.Error(), "insufficient resources") {
+		t.Fatalf("expected the error of the stream, got %v", err)
+	}
+	<-srv.ch
+	<-srv.ch
+	atomic.StoreInt32(&srv.failAfter, 0)
+	if err = s.Publish(context.TODO(), evs[1:]); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.connects
+	b, _ := json.Marshal(evs[1])
+	if msg := <-srv.ch; msg != "etcd.events "+string(b) {
+		t.Errorf("expected message %s, got %s", b, msg)
+	}
+}
+
+func TestNATSSinkWithoutAck(t *testing.T) {
+	srv := newFakeNATSServer(t)
+	s, err := NewSink(zaptest.NewLogger(t), fmt.Sprintf("nats://%s/etcd.events", srv.l.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	evs := []Event{{Type: "PUT", Key: []byte("foo"), Value: []byte("bar"), Revision: 2, CreateRevision: 2, Version: 1}}
+
+	// no stream captures the subject
+	atomic.StoreInt32(&srv.noStream, 1)
+	if err = s.Publish(context.TODO(), evs); err == nil || !strings.Contains(err.Error(), "no jetstream stream") {
+		t.Fatalf("expected no stream, got %v", err)
+	}
+	<-srv.ch
+
+	// the publication is not acknowledged before the stream stored the message
+	atomic.StoreInt32(&srv.noStream, 0)
+	atomic.StoreInt32(&srv.noAck, 1)
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	if err = s.Publish(ctx, evs); err == nil {
+		t.Fatal("expected the publication without pub ack to fail")
+	}
+}
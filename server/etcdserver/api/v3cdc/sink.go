@@ -0,0 +1,137 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3cdc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+
+	"go.uber.org/zap"
+)
+
+// publishTimeout bounds the connection to a broker and the publication of a
+// batch of events when the context has no deadline.
+const publishTimeout = 10 * time.Second
+
+// Event is a change of a key of the key-value store, as published to the
+// sinks. It is encoded as a JSON object, with the key and the value in base64.
+type Event struct {
+	// Type is "PUT" or "DELETE".
+	Type string `json:"type"`
+	Key  []byte `json:"key"`
+	// Value is empty for a DELETE.
+	Value []byte `json:"value,omitempty"`
+	// Revision is the revision of the change.
+	Revision int64 `json:"revision"`
+	// CreateRevision is the revision of the creation of the key, 0 for a
+	// DELETE.
+	CreateRevision int64 `json:"create_revision,omitempty"`
+	// Version is the version of the key, 0 for a DELETE.
+	Version int64 `json:"version,omitempty"`
+	// Lease is the ID of the lease attached to the key, 0 if none.
+	Lease int64 `json:"lease,omitempty"`
+}
+
+// NewEvent returns the Event published for an event of the key-value store.
+func NewEvent(ev mvccpb.Event) Event {
+	return Event{
+		Type:           ev.Type.String(),
+		Key:            ev.Kv.Key,
+		Value:          ev.Kv.Value,
+		Revision:       ev.Kv.ModRevision,
+		CreateRevision: ev.Kv.CreateRevision,
+		Version:        ev.Kv.Version,
+		Lease:          ev.Kv.Lease,
+	}
+}
+
+// Sink publishes events to an external broker. The events of a sink are
+// published by a single goroutine, in revision order.
+type Sink interface {
+	// Publish returns once the broker acknowledged all the events. The
+	// events may be published again if it fails.
+	Publish(ctx context.Context, evs []Event) error
+	// Close releases the connections to the broker.
+	Close() error
+}
+
+// NewSinkFunc returns a sink publishing to the broker of the URL. It should
+// not connect to the broker, which may not be up yet.
+type NewSinkFunc func(lg *zap.Logger, u *url.URL) (Sink, error)
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = map[string]NewSinkFunc{
+		"nats":        newNATSSink,
+		"nats+tls":    newNATSSink,
+		"kafka+http":  newKafkaRESTSink,
+		"kafka+https": newKafkaRESTSink,
+	}
+)
+
+// RegisterSink makes the sinks returned by f available for the URLs with the
+// scheme, e.g. to publish with a native Kafka client. It panics if the scheme
+// is already registered.
+func RegisterSink(scheme string, f NewSinkFunc) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if _, ok := sinks[scheme]; ok {
+		panic(fmt.Sprintf("v3cdc: sink scheme %q registered twice", scheme))
+	}
+	sinks[scheme] = f
+}
+
+// Schemes returns the schemes of the URLs of the registered sinks, sorted.
+func Schemes() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	schemes := make([]string, 0, len(sinks))
+	for scheme := range sinks {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// NewSink returns the sink publishing to the broker of rawURL, with the sink
+// registered for its scheme.
+func NewSink(lg *zap.Logger, rawURL string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	sinksMu.RLock()
+	f, ok := sinks[u.Scheme]
+	sinksMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported cdc sink scheme %q (supported %v)", u.Scheme, Schemes())
+	}
+	return f(lg, u)
+}
+
+// deadline returns the deadline of ctx, or publishTimeout from now if it has
+// none.
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(publishTimeout)
+}
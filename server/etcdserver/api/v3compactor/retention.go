@@ -0,0 +1,180 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3compactor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+
+	"github.com/jonboulle/clockwork"
+	"go.uber.org/zap"
+)
+
+// RetentionCompactable compacts the store keeping the history of the keys
+// retained by the retentions.
+type RetentionCompactable interface {
+	CompactWithRetentions(ctx context.Context, r *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, error)
+}
+
+// NewWithRetention returns a Compactor like New, whose compactions keep the
+// history of the keys retained by policies.
+func NewWithRetention(
+	lg *zap.Logger,
+	mode string,
+	retention time.Duration,
+	rg RevGetter,
+	c RetentionCompactable,
+	policies []config.CompactionRetentionPolicy,
+) (Compactor, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	r := newRetention(lg, clockwork.NewRealClock(), policies, rg, c)
+	comp, err := New(lg, mode, retention, rg, r)
+	if err != nil {
+		return nil, err
+	}
+	return &retentionCompactor{Compactor: comp, r: r}, nil
+}
+
+type retentionCompactor struct {
+	Compactor
+	r *retentionCompactable
+}
+
+func (rc *retentionCompactor) Run() {
+	rc.r.run()
+	rc.Compactor.Run()
+}
+
+func (rc *retentionCompactor) Stop() {
+	rc.Compactor.Stop()
+	rc.r.cancel()
+}
+
+// retentionCompactable compacts c keeping the history retained by the
+// policies. The retentions are resolved to revisions when compacting, and
+// travel with the compaction so that all members keep the same revisions.
+type retentionCompactable struct {
+	lg       *zap.Logger
+	clock    clockwork.Clock
+	policies []config.CompactionRetentionPolicy
+	rg       RevGetter
+	c        RetentionCompactable
+
+	// maxAge is the longest age retained by the policies, the revisions being
+	// sampled every interval to find the revisions younger than an age.
+	maxAge   time.Duration
+	interval time.Duration
+
+	mu      sync.Mutex
+	samples []revSample
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// revSample is the revision of the store at a time.
+type revSample struct {
+	t   time.Time
+	rev int64
+}
+
+func newRetention(lg *zap.Logger, clock clockwork.Clock, policies []config.CompactionRetentionPolicy, rg RevGetter, c RetentionCompactable) *retentionCompactable {
+	r := &retentionCompactable{lg: lg, clock: clock, policies: policies, rg: rg, c: c}
+	for _, p := range policies {
+		if p.Age > r.maxAge {
+			r.maxAge = p.Age
+		}
+		if itv := p.Age / retryDivisor; itv > 0 && (r.interval == 0 || itv < r.interval) {
+			r.interval = itv
+		}
+	}
+	if r.interval > time.Minute {
+		r.interval = time.Minute
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	return r
+}
+
+// run samples the revision of the store until the compactor stops, if any
+// policy retains the revisions by age.
+func (r *retentionCompactable) run() {
+	if r.maxAge == 0 {
+		return
+	}
+	go func() {
+		for {
+			r.sample()
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-r.clock.After(r.interval):
+			}
+		}
+	}()
+}
+
+func (r *retentionCompactable) sample() {
+	now := r.clock.Now()
+	rev := r.rg.Rev()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, revSample{t: now, rev: rev})
+	// keep the last sample older than the longest age
+	i := sort.Search(len(r.samples), func(i int) bool { return r.samples[i].t.After(now.Add(-r.maxAge)) })
+	if i > 1 {
+		r.samples = r.samples[i-1:]
+	}
+}
+
+// since returns the first revision which may have been written after t, or 0
+// if the revisions have not been sampled for long enough.
+func (r *retentionCompactable) since(t time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.samples) - 1; i >= 0; i-- {
+		if !r.samples[i].t.After(t) {
+			return r.samples[i].rev + 1
+		}
+	}
+	return 0
+}
+
+// retentions returns the retentions of the policies, their ages resolved to
+// the first revisions written since.
+func (r *retentionCompactable) retentions() []*pb.CompactionRetention {
+	now := r.clock.Now()
+	rets := make([]*pb.CompactionRetention, len(r.policies))
+	for i, p := range r.policies {
+		rets[i] = &pb.CompactionRetention{Prefix: []byte(p.Prefix), Versions: p.Versions}
+		if p.Age > 0 {
+			// keep all the revisions until they are sampled for long enough
+			if rets[i].Revision = r.since(now.Add(-p.Age)); rets[i].Revision == 0 {
+				rets[i].Revision = 1
+			}
+		}
+	}
+	return rets
+}
+
+func (r *retentionCompactable) Compact(ctx context.Context, req *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	return r.c.CompactWithRetentions(ctx, req, r.retentions())
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3compactor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/jonboulle/clockwork"
+)
+
+type fakeRetentionCompactable struct {
+	testutil.Recorder
+}
+
+func (fc *fakeRetentionCompactable) CompactWithRetentions(ctx context.Context, r *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, error) {
+	fc.Record(testutil.Action{Name: "c", Params: []interface{}{r, retentions}})
+	return &pb.CompactionResponse{}, nil
+}
+
+type fixedRev int64
+
+func (r *fixedRev) Rev() int64 { return int64(*r) }
+
+func TestRetentionVersions(t *testing.T) {
+	rev := fixedRev(60)
+	compactable := &fakeRetentionCompactable{&testutil.RecorderBuffered{}}
+	policies := []config.CompactionRetentionPolicy{{Prefix: "/config/", Versions: 3}}
+	r := newRetention(zaptest.NewLogger(t), clockwork.NewFakeClock(), policies, &rev, compactable)
+
+	// the compaction revision is kept, the retention travels with it
+	if _, err := r.Compact(context.Background(), &pb.CompactionRequest{Revision: 55}); err != nil {
+		t.Fatal(err)
+	}
+	want := []testutil.Action{{Name: "c", Params: []interface{}{
+		&pb.CompactionRequest{Revision: 55},
+		[]*pb.CompactionRetention{{Prefix: []byte("/config/"), Versions: 3}},
+	}}}
+	if got := compactable.Action(); !reflect.DeepEqual(got, want) {
+		t.Errorf("compacted with %+v, want %+v", got, want)
+	}
+}
+
+func TestRetentionAge(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	rev := fixedRev(0)
+	compactable := &fakeRetentionCompactable{&testutil.RecorderBuffered{}}
+	policies := []config.CompactionRetentionPolicy{{Prefix: "", Age: time.Hour}}
+	r := newRetention(zaptest.NewLogger(t), fc, policies, &rev, compactable)
+
+	// the revisions are not sampled for an hour yet, all are retained
+	r.sample()
+	if _, err := r.Compact(context.Background(), &pb.CompactionRequest{Revision: 35}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rv := range []fixedRev{15, 25, 45} {
+		fc.Advance(30 * time.Minute)
+		rev = rv
+		r.sample()
+	}
+	// the revisions after 15, sampled an hour ago, are retained
+	if _, err := r.Compact(context.Background(), &pb.CompactionRequest{Revision: 35}); err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, a := range compactable.Action() {
+		got = append(got, a.Params[1].([]*pb.CompactionRetention)[0].Revision)
+	}
+	if want := []int64{1, 16}; !reflect.DeepEqual(got, want) {
+		t.Errorf("retained the revisions from %v, want %v", got, want)
+	}
+	if len(r.samples) != 3 {
+		t.Errorf("expected the samples older than an hour pruned but the last, got %v", r.samples)
+	}
+}
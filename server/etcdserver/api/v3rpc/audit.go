@@ -0,0 +1,317 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"context"
+	"strings"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3election/v3electionpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// auditedMethods are the mutating RPCs recorded in the audit log, besides the
+// ones of the Auth service, which are all recorded.
+var auditedMethods = map[string]bool{
+	"/etcdserverpb.KV/Put":                       true,
+	"/etcdserverpb.KV/DeleteRange":               true,
+	"/etcdserverpb.KV/Txn":                       true,
+	"/etcdserverpb.KV/Compact":                   true,
+	"/etcdserverpb.Lease/LeaseGrant":             true,
+	"/etcdserverpb.Lease/LeaseRevoke":            true,
+	"/etcdserverpb.Cluster/MemberAdd":            true,
+	"/etcdserverpb.Cluster/MemberRemove":         true,
+	"/etcdserverpb.Cluster/MemberUpdate":         true,
+	"/etcdserverpb.Cluster/MemberPromote":        true,
+	"/etcdserverpb.Maintenance/Alarm":            true,
+	"/etcdserverpb.Maintenance/Defragment":       true,
+	"/etcdserverpb.Maintenance/MoveLeader":       true,
+	"/etcdserverpb.Maintenance/Downgrade":        true,
+	"/v3lockpb.Lock/Lock":                        true,
+	"/v3lockpb.Lock/Unlock":                      true,
+	"/v3electionpb.Election/Campaign":            true,
+	"/v3electionpb.Election/Proclaim":            true,
+	"/v3electionpb.Election/Resign":              true,
+	"/etcdserverpb.Maintenance/BulkImport":       true,
+	"/etcdserverpb.Maintenance/DefragmentStream": true,
+}
+
+const authServicePrefix = "/etcdserverpb.Auth/"
+
+// IsAuditedMethod returns whether the RPC named method, such as "Put" or
+// "UserAdd", is recorded in the audit log.
+func IsAuditedMethod(method string) bool {
+	for m := range auditedMethods {
+		if methodName(m) == method {
+			return true
+		}
+	}
+	switch method {
+	case "AuthEnable", "AuthDisable", "AuthStatus", "Authenticate",
+		"UserAdd", "UserGet", "UserList", "UserDelete", "UserChangePassword", "UserGrantRole", "UserRevokeRole",
+		"RoleAdd", "RoleGet", "RoleList", "RoleDelete", "RoleGrantPermission", "RoleRevokePermission":
+		return true
+	}
+	return false
+}
+
+// methodName returns the name of the RPC of a full method name, such as "Put"
+// for "/etcdserverpb.KV/Put".
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// auditKey is a key, or the keys from Key to RangeEnd as in a RangeRequest,
+// touched by an audited request.
+type auditKey struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end,omitempty"`
+}
+
+// auditor records the mutating and auth requests to the audit log of a server.
+type auditor struct {
+	s      *etcdserver.EtcdServer
+	lg     *zap.Logger
+	filter config.AuditLogFilter
+}
+
+func newAuditUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
+	a := &auditor{s: s, lg: s.Cfg.AuditLogger, filter: s.Cfg.AuditLogFilter}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !isAuditedRequest(info.FullMethod, req) {
+			return handler(ctx, req)
+		}
+		// the user is resolved first, as the request may change its permissions
+		user := a.user(ctx, req)
+		resp, err := handler(ctx, req)
+		a.record(ctx, info.FullMethod, user, auditKeys(req), auditFields(req, resp), err)
+		return resp, err
+	}
+}
+
+func newAuditStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor {
+	a := &auditor{s: s, lg: s.Cfg.AuditLogger, filter: s.Cfg.AuditLogFilter}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !auditedMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		user := a.user(ss.Context(), nil)
+		err := handler(srv, ss)
+		a.record(ss.Context(), info.FullMethod, user, nil, nil, err)
+		return err
+	}
+}
+
+// isAuditedRequest returns whether a request to fullMethod is recorded. Read
+// only transactions and alarm listings are not.
+func isAuditedRequest(fullMethod string, req interface{}) bool {
+	switch r := req.(type) {
+	case *pb.TxnRequest:
+		return !isTxnReadonly(r)
+	case *pb.AlarmRequest:
+		return r.Action != pb.AlarmRequest_GET
+	}
+	return auditedMethods[fullMethod] || strings.HasPrefix(fullMethod, authServicePrefix)
+}
+
+func isTxnReadonly(r *pb.TxnRequest) bool {
+	for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range ops {
+			switch u := op.Request.(type) {
+			case *pb.RequestOp_RequestRange:
+			case *pb.RequestOp_RequestTxn:
+				if !isTxnReadonly(u.RequestTxn) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// user returns the auth user of a request, or the user authenticating for
+// Authenticate requests.
+func (a *auditor) user(ctx context.Context, req interface{}) string {
+	if r, ok := req.(*pb.AuthenticateRequest); ok {
+		return r.Name
+	}
+	ai, err := a.s.AuthInfoFromCtx(ctx)
+	if err != nil || ai == nil {
+		return ""
+	}
+	return ai.Username
+}
+
+func (a *auditor) record(ctx context.Context, fullMethod, user string, keys []auditKey, fields []zap.Field, err error) {
+	method := methodName(fullMethod)
+	if !a.filter.Matches(method, user, err != nil) || !a.matchesKeys(keys) {
+		return
+	}
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	fields = append([]zap.Field{
+		zap.String("method", method),
+		zap.String("user", user),
+		zap.String("remote", remote),
+		zap.String("member-id", a.s.ID().String()),
+	}, fields...)
+	if len(keys) > 0 {
+		fields = append(fields, zap.Any("keys", keys))
+	}
+	if err != nil {
+		fields = append(fields,
+			zap.String("result", "failure"),
+			zap.String("code", status.Code(err).String()),
+			zap.String("error", err.Error()),
+		)
+	} else {
+		fields = append(fields, zap.String("result", "success"))
+	}
+	a.lg.Info("audit", fields...)
+}
+
+func (a *auditor) matchesKeys(keys []auditKey) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	for _, k := range keys {
+		if a.filter.MatchesRange(k.Key, k.RangeEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditKeys returns the keys touched by a request, including the ones
+// compared and read by transactions.
+func auditKeys(req interface{}) (keys []auditKey) {
+	switch r := req.(type) {
+	case *pb.PutRequest:
+		keys = append(keys, auditKey{Key: string(r.Key)})
+	case *pb.DeleteRangeRequest:
+		keys = append(keys, auditKey{Key: string(r.Key), RangeEnd: string(r.RangeEnd)})
+	case *pb.TxnRequest:
+		for _, c := range r.Compare {
+			keys = append(keys, auditKey{Key: string(c.Key), RangeEnd: string(c.RangeEnd)})
+		}
+		for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+			for _, op := range ops {
+				switch u := op.Request.(type) {
+				case *pb.RequestOp_RequestRange:
+					keys = append(keys, auditKey{Key: string(u.RequestRange.Key), RangeEnd: string(u.RequestRange.RangeEnd)})
+				case *pb.RequestOp_RequestPut:
+					keys = append(keys, auditKeys(u.RequestPut)...)
+				case *pb.RequestOp_RequestDeleteRange:
+					keys = append(keys, auditKeys(u.RequestDeleteRange)...)
+				case *pb.RequestOp_RequestTxn:
+					keys = append(keys, auditKeys(u.RequestTxn)...)
+				}
+			}
+		}
+	case *v3lockpb.LockRequest:
+		keys = append(keys, auditKey{Key: string(r.Name)})
+	case *v3lockpb.UnlockRequest:
+		keys = append(keys, auditKey{Key: string(r.Key)})
+	case *v3electionpb.CampaignRequest:
+		keys = append(keys, auditKey{Key: string(r.Name)})
+	case *v3electionpb.ProclaimRequest:
+		if r.Leader != nil {
+			keys = append(keys, auditKey{Key: string(r.Leader.Key)})
+		}
+	case *v3electionpb.ResignRequest:
+		if r.Leader != nil {
+			keys = append(keys, auditKey{Key: string(r.Leader.Key)})
+		}
+	}
+	return keys
+}
+
+// auditFields returns the fields recording the subject of a request other
+// than keys, and the revision it was applied at. Values and passwords are
+// never recorded.
+func auditFields(req, resp interface{}) (fields []zap.Field) {
+	switch r := req.(type) {
+	case *pb.CompactionRequest:
+		fields = append(fields, zap.Int64("compact-revision", r.Revision))
+	case *pb.LeaseGrantRequest:
+		fields = append(fields, zap.Int64("ttl", r.TTL))
+		if gresp, ok := resp.(*pb.LeaseGrantResponse); ok && gresp != nil {
+			fields = append(fields, zap.Int64("lease-id", gresp.ID))
+		}
+	case *pb.LeaseRevokeRequest:
+		fields = append(fields, zap.Int64("lease-id", r.ID))
+	case *pb.MemberAddRequest:
+		fields = append(fields, zap.Strings("peer-urls", r.PeerURLs), zap.Bool("is-learner", r.IsLearner))
+	case *pb.MemberRemoveRequest:
+		fields = append(fields, zap.Uint64("target-member-id", r.ID))
+	case *pb.MemberUpdateRequest:
+		fields = append(fields, zap.Uint64("target-member-id", r.ID), zap.Strings("peer-urls", r.PeerURLs))
+	case *pb.MemberPromoteRequest:
+		fields = append(fields, zap.Uint64("target-member-id", r.ID))
+	case *pb.AlarmRequest:
+		fields = append(fields, zap.String("action", r.Action.String()), zap.String("alarm", r.Alarm.String()))
+	case *pb.MoveLeaderRequest:
+		fields = append(fields, zap.Uint64("target-member-id", r.TargetID))
+	case *pb.DowngradeRequest:
+		fields = append(fields, zap.String("action", r.Action.String()), zap.String("target-version", r.Version))
+	case *pb.AuthUserAddRequest:
+		fields = append(fields, zap.String("target-user", r.Name))
+	case *pb.AuthUserGetRequest:
+		fields = append(fields, zap.String("target-user", r.Name))
+	case *pb.AuthUserDeleteRequest:
+		fields = append(fields, zap.String("target-user", r.Name))
+	case *pb.AuthUserChangePasswordRequest:
+		fields = append(fields, zap.String("target-user", r.Name))
+	case *pb.AuthUserGrantRoleRequest:
+		fields = append(fields, zap.String("target-user", r.User), zap.String("role", r.Role))
+	case *pb.AuthUserRevokeRoleRequest:
+		fields = append(fields, zap.String("target-user", r.Name), zap.String("role", r.Role))
+	case *pb.AuthRoleAddRequest:
+		fields = append(fields, zap.String("role", r.Name))
+	case *pb.AuthRoleGetRequest:
+		fields = append(fields, zap.String("role", r.Role))
+	case *pb.AuthRoleDeleteRequest:
+		fields = append(fields, zap.String("role", r.Role))
+	case *pb.AuthRoleGrantPermissionRequest:
+		fields = append(fields, zap.String("role", r.Name))
+		if r.Perm != nil {
+			fields = append(fields,
+				zap.String("permission", r.Perm.PermType.String()),
+				zap.Any("permission-keys", auditKey{Key: string(r.Perm.Key), RangeEnd: string(r.Perm.RangeEnd)}),
+			)
+		}
+	case *pb.AuthRoleRevokePermissionRequest:
+		fields = append(fields,
+			zap.String("role", r.Role),
+			zap.Any("permission-keys", auditKey{Key: string(r.Key), RangeEnd: string(r.RangeEnd)}),
+		)
+	}
+	if h, ok := resp.(interface{ GetHeader() *pb.ResponseHeader }); ok && h.GetHeader() != nil && h.GetHeader().Revision != 0 {
+		fields = append(fields, zap.Int64("revision", h.GetHeader().Revision))
+	}
+	return fields
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"reflect"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestIsAuditedRequest(t *testing.T) {
+	readTxn := &pb.TxnRequest{Success: []*pb.RequestOp{
+		{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("a")}}},
+	}}
+	writeTxn := &pb.TxnRequest{Failure: []*pb.RequestOp{
+		{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("a")}}},
+		}}}},
+	}}
+	tt := []struct {
+		method string
+		req    interface{}
+		want   bool
+	}{
+		{method: "/etcdserverpb.KV/Put", req: &pb.PutRequest{}, want: true},
+		{method: "/etcdserverpb.KV/Range", req: &pb.RangeRequest{}, want: false},
+		{method: "/etcdserverpb.KV/Txn", req: readTxn, want: false},
+		{method: "/etcdserverpb.KV/Txn", req: writeTxn, want: true},
+		{method: "/etcdserverpb.Maintenance/Alarm", req: &pb.AlarmRequest{Action: pb.AlarmRequest_GET}, want: false},
+		{method: "/etcdserverpb.Maintenance/Alarm", req: &pb.AlarmRequest{Action: pb.AlarmRequest_DEACTIVATE}, want: true},
+		{method: "/etcdserverpb.Maintenance/Status", req: &pb.StatusRequest{}, want: false},
+		{method: "/etcdserverpb.Auth/UserGet", req: &pb.AuthUserGetRequest{}, want: true},
+	}
+	for _, tc := range tt {
+		if got := isAuditedRequest(tc.method, tc.req); got != tc.want {
+			t.Errorf("expected audit of %s %+v to be %v, got %v", tc.method, tc.req, tc.want, got)
+		}
+	}
+}
+
+func TestIsAuditedMethod(t *testing.T) {
+	for _, m := range []string{"Put", "Txn", "UserAdd", "Authenticate", "BulkImport", "Lock"} {
+		if !IsAuditedMethod(m) {
+			t.Errorf("expected %s to be audited", m)
+		}
+	}
+	for _, m := range []string{"Range", "Watch", "Status", "put"} {
+		if IsAuditedMethod(m) {
+			t.Errorf("expected %s not to be audited", m)
+		}
+	}
+}
+
+func TestAuditKeys(t *testing.T) {
+	r := &pb.TxnRequest{
+		Compare: []*pb.Compare{{Key: []byte("c")}},
+		Success: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("r"), RangeEnd: []byte("s")}}},
+			{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Failure: []*pb.RequestOp{
+				{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("p"), Value: []byte("secret")}}},
+			}}}},
+		},
+		Failure: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte("d"), RangeEnd: []byte{0}}}},
+		},
+	}
+	want := []auditKey{{Key: "c"}, {Key: "r", RangeEnd: "s"}, {Key: "p"}, {Key: "d", RangeEnd: "\x00"}}
+	if got := auditKeys(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected keys %+v, got %+v", want, got)
+	}
+}
+
+func TestAuditFieldsRedactPasswords(t *testing.T) {
+	reqs := []interface{}{
+		&pb.AuthUserAddRequest{Name: "alice", Password: "secret"},
+		&pb.AuthUserChangePasswordRequest{Name: "alice", Password: "secret"},
+	}
+	for _, req := range reqs {
+		for _, f := range auditFields(req, nil) {
+			if f.String == "secret" {
+				t.Errorf("expected the password of %T not to be recorded, got field %s", req, f.Key)
+			}
+		}
+	}
+}
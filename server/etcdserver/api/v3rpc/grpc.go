@@ -45,17 +45,25 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 	}
 	chainUnaryInterceptors := []grpc.UnaryServerInterceptor{
 		newLogUnaryInterceptor(s),
+	}
+	var chainStreamInterceptors []grpc.StreamServerInterceptor
+	if s.Cfg.AuditLogger != nil {
+		// recording the requests rejected by the following interceptors too
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newAuditUnaryInterceptor(s))
+		chainStreamInterceptors = append(chainStreamInterceptors, newAuditStreamInterceptor(s))
+	}
+	chainUnaryInterceptors = append(chainUnaryInterceptors,
 		newUnaryInterceptor(s),
 		grpc_prometheus.UnaryServerInterceptor,
-	}
+	)
 	if interceptor != nil {
 		chainUnaryInterceptors = append(chainUnaryInterceptors, interceptor)
 	}
 
-	chainStreamInterceptors := []grpc.StreamServerInterceptor{
+	chainStreamInterceptors = append(chainStreamInterceptors,
 		newStreamInterceptor(s),
 		grpc_prometheus.StreamServerInterceptor,
-	}
+	)
 
 	if s.Cfg.ExperimentalEnableDistributedTracing {
 		chainUnaryInterceptors = append(chainUnaryInterceptors, otelgrpc.UnaryServerInterceptor(s.Cfg.ExperimentalTracerOptions...))
@@ -32,10 +32,16 @@ type kvServer struct {
 	// Txn.Success can have at most 128 operations,
 	// and Txn.Failure can have at most 128 operations.
 	maxTxnOps uint
+	// maxStreamBytes is the size RangeStream aims for its responses to stay under.
+	maxStreamBytes int
 }
 
+// rangeStreamPageSize is the number of keys RangeStream reads from the
+// key-value store at once.
+var rangeStreamPageSize int64 = 1000
+
 func NewKVServer(s *etcdserver.EtcdServer) pb.KVServer {
-	return &kvServer{hdr: newHeader(s), kv: s, maxTxnOps: s.Cfg.MaxTxnOps}
+	return &kvServer{hdr: newHeader(s), kv: s, maxTxnOps: s.Cfg.MaxTxnOps, maxStreamBytes: int(s.Cfg.MaxRequestBytes)}
 }
 
 func (s *kvServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -52,6 +58,92 @@ func (s *kvServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResp
 	return resp, nil
 }
 
+func (s *kvServer) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	if err := checkRangeRequest(r); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+
+	if !isRangePageable(r) {
+		// the whole range has to be read to be filtered or sorted
+		resp, err := s.kv.Range(ctx, r)
+		if err != nil {
+			return togRPCError(err)
+		}
+		s.hdr.fill(resp.Header)
+		return s.sendRange(stream, resp)
+	}
+
+	// Read the range a page at a time, at the revision of the first page.
+	// The first page is read as requested, e.g. linearizably, the next ones
+	// at its revision need not be.
+	page := *r
+	var count, sent int64
+	for first := true; ; first = false {
+		page.Limit = rangeStreamPageSize
+		if r.Limit > 0 && r.Limit-sent < page.Limit {
+			page.Limit = r.Limit - sent
+		}
+		resp, err := s.kv.Range(ctx, &page)
+		if err != nil {
+			return togRPCError(err)
+		}
+		s.hdr.fill(resp.Header)
+		if first {
+			count = resp.Count
+			if page.Revision == 0 {
+				page.Revision = resp.Header.Revision
+			}
+			page.Serializable = true
+		}
+		resp.Count = count
+		sent += int64(len(resp.Kvs))
+
+		last := !resp.More || (r.Limit > 0 && sent >= r.Limit)
+		if !last {
+			resp.More = false
+			page.Key = append(append([]byte{}, resp.Kvs[len(resp.Kvs)-1].Key...), 0)
+		}
+		if err = s.sendRange(stream, resp); err != nil || last {
+			return err
+		}
+	}
+}
+
+// isRangePageable reports whether r can be read a page of keys at a time,
+// that is, it is not filtered and its keys are sorted in ascending order.
+func isRangePageable(r *pb.RangeRequest) bool {
+	return len(r.RangeEnd) != 0 && !r.CountOnly &&
+		r.SortTarget == pb.RangeRequest_KEY && r.SortOrder != pb.RangeRequest_DESCEND &&
+		r.MinModRevision == 0 && r.MaxModRevision == 0 &&
+		r.MinCreateRevision == 0 && r.MaxCreateRevision == 0
+}
+
+// sendRange sends the keys of resp in responses of at most maxStreamBytes,
+// unless a single key is larger. Every response has the header and count of
+// resp, the last one has its more flag.
+func (s *kvServer) sendRange(stream pb.KV_RangeStreamServer, resp *pb.RangeResponse) error {
+	kvs := resp.Kvs
+	for {
+		chunk := &pb.RangeResponse{Header: resp.Header, Count: resp.Count}
+		size := 0
+		for len(kvs) > 0 && (len(chunk.Kvs) == 0 || size+kvs[0].Size() <= s.maxStreamBytes) {
+			size += kvs[0].Size()
+			chunk.Kvs = append(chunk.Kvs, kvs[0])
+			kvs = kvs[1:]
+		}
+		if len(kvs) == 0 {
+			chunk.More = resp.More
+		}
+		if err := stream.Send(&pb.RangeStreamResponse{RangeResponse: chunk}); err != nil {
+			return err
+		}
+		if len(kvs) == 0 {
+			return nil
+		}
+	}
+}
+
 func (s *kvServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
 	if err := checkPutRequest(r); err != nil {
 		return nil, err
@@ -137,6 +229,12 @@ func checkPutRequest(r *pb.PutRequest) error {
 	if r.IgnoreLease && r.Lease != 0 {
 		return rpctypes.ErrGRPCLeaseProvided
 	}
+	if r.Ttl < 0 {
+		return rpctypes.ErrGRPCLeaseTTLNegative
+	}
+	if r.Ttl > 0 && (r.Lease != 0 || r.IgnoreLease) {
+		return rpctypes.ErrGRPCLeaseProvided
+	}
 	return nil
 }
 
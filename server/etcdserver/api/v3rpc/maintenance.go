@@ -15,12 +15,15 @@
 package v3rpc
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"io"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/klauspost/compress/zstd"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
@@ -54,6 +57,55 @@ type Downgrader interface {
 	Downgrade(ctx context.Context, dr *pb.DowngradeRequest) (*pb.DowngradeResponse, error)
 }
 
+type ConfigAdvisor interface {
+	ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest) (*pb.ConfigAdviceResponse, error)
+}
+
+type BulkImporter interface {
+	BulkImport(recv func() (*pb.BulkImportRequest, error)) (*pb.BulkImportResponse, error)
+}
+
+type KeyHistogrammer interface {
+	KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest) (*pb.KeyHistogramResponse, error)
+}
+
+type ReadOnlyer interface {
+	ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest) (*pb.ReadOnlyResponse, error)
+}
+
+type Namespacer interface {
+	NamespacePut(ctx context.Context, r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error)
+	NamespaceDelete(ctx context.Context, r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error)
+	NamespaceList(ctx context.Context, r *pb.NamespaceListRequest) (*pb.NamespaceListResponse, error)
+}
+
+type Indexer interface {
+	IndexPut(ctx context.Context, r *pb.IndexPutRequest) (*pb.IndexPutResponse, error)
+	IndexDelete(ctx context.Context, r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error)
+	IndexList(ctx context.Context, r *pb.IndexListRequest) (*pb.IndexListResponse, error)
+	IndexRange(ctx context.Context, r *pb.IndexRangeRequest) (*pb.IndexRangeResponse, error)
+}
+
+type Backuper interface {
+	Backup(ctx context.Context, r *pb.BackupRequest, send func(*pb.BackupResponse) error) error
+}
+
+type LogLeveler interface {
+	LogLevel(ctx context.Context, r *pb.LogLevelRequest) (*pb.LogLevelResponse, error)
+}
+
+type SlowLogger interface {
+	SlowLog(ctx context.Context, r *pb.SlowLogRequest) (*pb.SlowLogResponse, error)
+}
+
+type TopKeyser interface {
+	TopKeys(ctx context.Context, r *pb.TopKeysRequest) (*pb.TopKeysResponse, error)
+}
+
+type FragmentationReporter interface {
+	Fragmentation(ctx context.Context, r *pb.FragmentationRequest) (*pb.FragmentationResponse, error)
+}
+
 type LeaderTransferrer interface {
 	MoveLeader(ctx context.Context, lead, target uint64) error
 }
@@ -67,6 +119,11 @@ type ClusterStatusGetter interface {
 	IsLearner() bool
 }
 
+type ApplyStatusGetter interface {
+	PendingProposals() int64
+	PendingApplies() int64
+}
+
 type maintenanceServer struct {
 	lg  *zap.Logger
 	rg  etcdserver.RaftStatusGetter
@@ -76,12 +133,26 @@ type maintenanceServer struct {
 	lt  LeaderTransferrer
 	hdr header
 	cs  ClusterStatusGetter
+	as  ApplyStatusGetter
 	d   Downgrader
 	vs  serverversion.Server
+	wc  *etcdserver.WatchConsumers
+	ca  ConfigAdvisor
+	bi  BulkImporter
+	kh  KeyHistogrammer
+	bk  Backuper
+	rs  *etcdserver.ResumableSnapshots
+	ro  ReadOnlyer
+	ns  Namespacer
+	ix  Indexer
+	ll  LogLeveler
+	sl  SlowLogger
+	tk  TopKeyser
+	fr  FragmentationReporter
 }
 
 func NewMaintenanceServer(s *etcdserver.EtcdServer) pb.MaintenanceServer {
-	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, kg: s, bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, d: s, vs: etcdserver.NewServerVersionAdapter(s)}
+	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, kg: s, bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, as: s, d: s, vs: etcdserver.NewServerVersionAdapter(s), wc: s.WatchConsumers(), ca: s, bi: s, kh: s, bk: s, rs: s.ResumableSnapshots(), ro: s, ns: s, ix: s, ll: s, sl: s, tk: s, fr: s}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
@@ -99,41 +170,175 @@ func (ms *maintenanceServer) Defragment(ctx context.Context, sr *pb.DefragmentRe
 	return &pb.DefragmentResponse{}, nil
 }
 
+func (ms *maintenanceServer) DefragmentStream(sr *pb.DefragmentRequest, srv pb.Maintenance_DefragmentStreamServer) error {
+	ms.lg.Info("starting defragment")
+	// the defragmentation goes on if the client goes away, as with Defragment
+	var (
+		last backend.DefragProgress
+		serr error
+	)
+	err := ms.bg.Backend().DefragWithProgress(func(p backend.DefragProgress) {
+		last = p
+		if serr == nil {
+			serr = srv.Send(&pb.DefragmentStreamResponse{TotalKeys: p.TotalKeys, CopiedKeys: p.CopiedKeys, RecopiedKeys: p.RecopiedKeys})
+		}
+	})
+	if err != nil {
+		ms.lg.Warn("failed to defragment", zap.Error(err))
+		return err
+	}
+	ms.lg.Info("finished defragment")
+	if serr != nil {
+		return serr
+	}
+	resp := &pb.DefragmentStreamResponse{
+		Header:       &pb.ResponseHeader{},
+		TotalKeys:    last.TotalKeys,
+		CopiedKeys:   last.CopiedKeys,
+		RecopiedKeys: last.RecopiedKeys,
+		Done:         true,
+	}
+	ms.hdr.fill(resp.Header)
+	return srv.Send(resp)
+}
+
 // big enough size to hold >1 OS pages in the buffer
 const snapshotSendBufferSize = 32 * 1024
 
-func (ms *maintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance_SnapshotServer) error {
-	ver := schema.ReadStorageVersion(ms.bg.Backend().ReadTx())
-	storageVersion := ""
-	if ver != nil {
-		storageVersion = ver.String()
-	}
-	snap := ms.bg.Backend().Snapshot()
-	pr, pw := io.Pipe()
+// snapshotCompressedBufferSize is the uncompressed size of each blob when the
+// client asked for compression; larger chunks compress noticeably better.
+const snapshotCompressedBufferSize = 1024 * 1024
 
-	defer pr.Close()
+const (
+	snapshotCompressionZstd = "zstd"
+	snapshotCompressionGzip = "gzip"
+)
 
+func (ms *maintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance_SnapshotServer) (err error) {
+	var (
+		snap           backend.Snapshot
+		rsnap          *etcdserver.ResumableSnapshot
+		storageVersion string
+		rev            int64
+	)
+	if sr.SnapshotId != 0 {
+		var ok bool
+		if rsnap, ok = ms.rs.Resume(sr.SnapshotId); !ok {
+			return rpctypes.ErrGRPCSnapshotNotFound
+		}
+		snap, storageVersion, rev = rsnap, rsnap.Version, rsnap.Revision
+	} else {
+		ver := schema.ReadStorageVersion(ms.bg.Backend().ReadTx())
+		if ver != nil {
+			storageVersion = ver.String()
+		}
+		// the revision is read before the snapshot is taken, so that the
+		// snapshot contains at least the writes up to it
+		rev = ms.kg.KV().Rev()
+		snap = ms.bg.Backend().Snapshot()
+		if sr.Resumable {
+			var ok bool
+			if rsnap, ok = ms.rs.Add(snap, storageVersion, rev); ok {
+				snap = rsnap
+			} else {
+				ms.lg.Warn("too many resumable snapshots; sending snapshot which cannot be resumed")
+			}
+		}
+	}
+	if sr.Offset > uint64(snap.Size()) {
+		if rsnap != nil {
+			rsnap.Keep()
+		} else {
+			snap.Close()
+		}
+		return rpctypes.ErrGRPCInvalidSnapshotOffset
+	}
+
+	pr, pw := io.Pipe()
+	donec := make(chan struct{})
 	go func() {
+		defer close(donec)
 		snap.WriteTo(pw)
-		if err := snap.Close(); err != nil {
-			ms.lg.Warn("failed to close snapshot", zap.Error(err))
-		}
 		pw.Close()
 	}()
+	defer func() {
+		pr.Close()
+		<-donec
+		// a resumable snapshot whose stream broke is kept for the client to
+		// fetch the rest of it
+		if rsnap != nil && err != nil {
+			rsnap.Keep()
+			return
+		}
+		if cerr := snap.Close(); cerr != nil {
+			ms.lg.Warn("failed to close snapshot", zap.Error(cerr))
+		}
+	}()
 
 	// record SHA digest of snapshot data
 	// used for integrity checks during snapshot restore operation
 	h := sha256.New()
 
-	sent := int64(0)
+	// unknown compression algorithms fall back to an uncompressed stream,
+	// the client tells them apart by SnapshotResponse.Compression.
+	bufSize := snapshotSendBufferSize
+	var compress func([]byte) ([]byte, error)
+	switch sr.Compression {
+	case "":
+	case snapshotCompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return togRPCError(err)
+		}
+		defer enc.Close()
+		compress = func(b []byte) ([]byte, error) { return enc.EncodeAll(b, nil), nil }
+		bufSize = snapshotCompressedBufferSize
+	case snapshotCompressionGzip:
+		gz := gzip.NewWriter(nil)
+		compress = func(b []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			gz.Reset(&buf)
+			if _, err := gz.Write(b); err != nil {
+				return nil, err
+			}
+			if err := gz.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+		bufSize = snapshotCompressedBufferSize
+	default:
+		ms.lg.Warn("unsupported snapshot compression; sending uncompressed snapshot",
+			zap.String("compression", sr.Compression),
+		)
+	}
+
+	var snapshotID uint64
+	if rsnap != nil {
+		snapshotID = rsnap.ID
+	}
 	total := snap.Size()
 	size := humanize.Bytes(uint64(total))
 
+	// only the first response carries the header, with the revision of the
+	// snapshot
+	header := &pb.ResponseHeader{Revision: rev}
+	ms.hdr.fill(header)
+
+	// the skipped bytes are read anyway, as the digest covers the whole snapshot
+	sent := int64(sr.Offset)
+	if _, err = io.CopyN(h, pr, sent); err != nil {
+		return togRPCError(err)
+	}
+
 	start := time.Now()
 	ms.lg.Info("sending database snapshot to client",
 		zap.Int64("total-bytes", total),
 		zap.String("size", size),
 		zap.String("storage-version", storageVersion),
+		zap.String("compression", sr.Compression),
+		zap.Uint64("snapshot-id", snapshotID),
+		zap.Uint64("offset", sr.Offset),
 	)
 	for total-sent > 0 {
 		// buffer just holds read bytes from stream
@@ -141,7 +346,7 @@ func (ms *maintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance
 		// e.g. 4*1024
 		// NOTE: srv.Send does not wait until the message is received by the client.
 		// Therefore the buffer can not be safely reused between Send operations
-		buf := make([]byte, snapshotSendBufferSize)
+		buf := make([]byte, bufSize)
 
 		n, err := io.ReadFull(pr, buf)
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
@@ -158,13 +363,23 @@ func (ms *maintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance
 		// No, the client will still receive non-nil response
 		// until server closes the stream with EOF
 		resp := &pb.SnapshotResponse{
+			Header:         header,
 			RemainingBytes: uint64(total - sent),
 			Blob:           buf[:n],
 			Version:        storageVersion,
+			SnapshotId:     snapshotID,
+		}
+		if compress != nil {
+			// the digest always covers the uncompressed snapshot
+			if resp.Blob, err = compress(buf[:n]); err != nil {
+				return togRPCError(err)
+			}
+			resp.Compression = sr.Compression
 		}
 		if err = srv.Send(resp); err != nil {
 			return togRPCError(err)
 		}
+		header = nil
 		h.Write(buf[:n])
 	}
 
@@ -176,7 +391,7 @@ func (ms *maintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance
 		zap.Int64("total-bytes", total),
 		zap.Int("checksum-size", len(sha)),
 	)
-	hresp := &pb.SnapshotResponse{RemainingBytes: 0, Blob: sha, Version: storageVersion}
+	hresp := &pb.SnapshotResponse{Header: header, RemainingBytes: 0, Blob: sha, Version: storageVersion, SnapshotId: snapshotID}
 	if err := srv.Send(hresp); err != nil {
 		return togRPCError(err)
 	}
@@ -236,7 +451,13 @@ func (ms *maintenanceServer) Status(ctx context.Context, ar *pb.StatusRequest) (
 		DbSize:           ms.bg.Backend().Size(),
 		DbSizeInUse:      ms.bg.Backend().SizeInUse(),
 		IsLearner:        ms.cs.IsLearner(),
+		PendingProposals: ms.as.PendingProposals(),
+		ApplyQueueLength: ms.as.PendingApplies(),
+		IsDefragmenting:  ms.bg.Backend().IsDefragActive(),
 	}
+	compactRev, compactTook := ms.kg.KV().LastCompaction()
+	resp.LastCompactionRevision = compactRev
+	resp.LastCompactionDurationMs = compactTook.Milliseconds()
 	if storageVersion := ms.vs.GetStorageVersion(); storageVersion != nil {
 		resp.StorageVersion = storageVersion.String()
 	}
@@ -270,6 +491,190 @@ func (ms *maintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeReque
 	return resp, nil
 }
 
+func (ms *maintenanceServer) ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest) (*pb.ReadOnlyResponse, error) {
+	resp, err := ms.ro.ReadOnly(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) NamespacePut(ctx context.Context, r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error) {
+	resp, err := ms.ns.NamespacePut(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) NamespaceDelete(ctx context.Context, r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error) {
+	resp, err := ms.ns.NamespaceDelete(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) NamespaceList(ctx context.Context, r *pb.NamespaceListRequest) (*pb.NamespaceListResponse, error) {
+	resp, err := ms.ns.NamespaceList(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) IndexPut(ctx context.Context, r *pb.IndexPutRequest) (*pb.IndexPutResponse, error) {
+	resp, err := ms.ix.IndexPut(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) IndexDelete(ctx context.Context, r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error) {
+	resp, err := ms.ix.IndexDelete(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) IndexList(ctx context.Context, r *pb.IndexListRequest) (*pb.IndexListResponse, error) {
+	resp, err := ms.ix.IndexList(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) IndexRange(ctx context.Context, r *pb.IndexRangeRequest) (*pb.IndexRangeResponse, error) {
+	resp, err := ms.ix.IndexRange(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) LogLevel(ctx context.Context, r *pb.LogLevelRequest) (*pb.LogLevelResponse, error) {
+	resp, err := ms.ll.LogLevel(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) SlowLog(ctx context.Context, r *pb.SlowLogRequest) (*pb.SlowLogResponse, error) {
+	resp, err := ms.sl.SlowLog(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) TopKeys(ctx context.Context, r *pb.TopKeysRequest) (*pb.TopKeysResponse, error) {
+	resp, err := ms.tk.TopKeys(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) Fragmentation(ctx context.Context, r *pb.FragmentationRequest) (*pb.FragmentationResponse, error) {
+	resp, err := ms.fr.Fragmentation(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+// defaultWatchConsumersLimit is the number of watch consumers reported when
+// the request does not set a limit.
+const defaultWatchConsumersLimit = 10
+
+func (ms *maintenanceServer) WatchConsumers(ctx context.Context, r *pb.WatchConsumersRequest) (*pb.WatchConsumersResponse, error) {
+	limit := r.Limit
+	if limit <= 0 {
+		limit = defaultWatchConsumersLimit
+	}
+	resp := &pb.WatchConsumersResponse{Header: &pb.ResponseHeader{}, Consumers: ms.wc.Top(int(limit))}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest) (*pb.ConfigAdviceResponse, error) {
+	resp, err := ms.ca.ConfigAdvice(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) BulkImport(srv pb.Maintenance_BulkImportServer) error {
+	resp, err := ms.bi.BulkImport(srv.Recv)
+	if err != nil {
+		return togRPCError(err)
+	}
+	resp.Header = &pb.ResponseHeader{}
+	ms.hdr.fill(resp.Header)
+	return srv.SendAndClose(resp)
+}
+
+func (ms *maintenanceServer) KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest) (*pb.KeyHistogramResponse, error) {
+	resp, err := ms.kh.KeyHistogram(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) Backup(r *pb.BackupRequest, srv pb.Maintenance_BackupServer) error {
+	err := ms.bk.Backup(srv.Context(), r, func(resp *pb.BackupResponse) error {
+		ms.hdr.fill(resp.Header)
+		return srv.Send(resp)
+	})
+	if err != nil {
+		return togRPCError(err)
+	}
+	return nil
+}
+
 type authMaintenanceServer struct {
 	*maintenanceServer
 	ag AuthGetter
@@ -292,6 +697,14 @@ func (ams *authMaintenanceServer) Defragment(ctx context.Context, sr *pb.Defragm
 	return ams.maintenanceServer.Defragment(ctx, sr)
 }
 
+func (ams *authMaintenanceServer) DefragmentStream(sr *pb.DefragmentRequest, srv pb.Maintenance_DefragmentStreamServer) error {
+	if err := ams.isAuthenticated(srv.Context()); err != nil {
+		return err
+	}
+
+	return ams.maintenanceServer.DefragmentStream(sr, srv)
+}
+
 func (ams *authMaintenanceServer) Snapshot(sr *pb.SnapshotRequest, srv pb.Maintenance_SnapshotServer) error {
 	if err := ams.isAuthenticated(srv.Context()); err != nil {
 		return err
@@ -326,3 +739,87 @@ func (ams *authMaintenanceServer) MoveLeader(ctx context.Context, tr *pb.MoveLea
 func (ams *authMaintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
 	return ams.maintenanceServer.Downgrade(ctx, r)
 }
+
+func (ams *authMaintenanceServer) WatchConsumers(ctx context.Context, r *pb.WatchConsumersRequest) (*pb.WatchConsumersResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.WatchConsumers(ctx, r)
+}
+
+func (ams *authMaintenanceServer) ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest) (*pb.ConfigAdviceResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.ConfigAdvice(ctx, r)
+}
+
+func (ams *authMaintenanceServer) BulkImport(srv pb.Maintenance_BulkImportServer) error {
+	if err := ams.isAuthenticated(srv.Context()); err != nil {
+		return err
+	}
+	return ams.maintenanceServer.BulkImport(srv)
+}
+
+func (ams *authMaintenanceServer) KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest) (*pb.KeyHistogramResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.KeyHistogram(ctx, r)
+}
+
+func (ams *authMaintenanceServer) ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest) (*pb.ReadOnlyResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.ReadOnly(ctx, r)
+}
+
+func (ams *authMaintenanceServer) NamespaceList(ctx context.Context, r *pb.NamespaceListRequest) (*pb.NamespaceListResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.NamespaceList(ctx, r)
+}
+
+func (ams *authMaintenanceServer) IndexList(ctx context.Context, r *pb.IndexListRequest) (*pb.IndexListResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.IndexList(ctx, r)
+}
+
+func (ams *authMaintenanceServer) LogLevel(ctx context.Context, r *pb.LogLevelRequest) (*pb.LogLevelResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.LogLevel(ctx, r)
+}
+
+func (ams *authMaintenanceServer) SlowLog(ctx context.Context, r *pb.SlowLogRequest) (*pb.SlowLogResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.SlowLog(ctx, r)
+}
+
+func (ams *authMaintenanceServer) TopKeys(ctx context.Context, r *pb.TopKeysRequest) (*pb.TopKeysResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.TopKeys(ctx, r)
+}
+
+func (ams *authMaintenanceServer) Fragmentation(ctx context.Context, r *pb.FragmentationRequest) (*pb.FragmentationResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.Fragmentation(ctx, r)
+}
+
+func (ams *authMaintenanceServer) Backup(r *pb.BackupRequest, srv pb.Maintenance_BackupServer) error {
+	if err := ams.isAuthenticated(srv.Context()); err != nil {
+		return err
+	}
+	return ams.maintenanceServer.Backup(r, srv)
+}
@@ -46,9 +46,14 @@ func (cs *ClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest)
 
 	now := time.Now()
 	var m *membership.Member
-	if r.IsLearner {
+	switch {
+	case r.IsLearner && r.IsWitness:
+		return nil, rpctypes.ErrGRPCWitnessLearner
+	case r.IsLearner:
 		m = membership.NewMemberAsLearner("", urls, "", &now)
-	} else {
+	case r.IsWitness:
+		m = membership.NewMemberAsWitness("", urls, "", &now)
+	default:
 		m = membership.NewMember("", urls, "", &now)
 	}
 	membs, merr := cs.server.AddMember(ctx, *m)
@@ -62,6 +67,7 @@ func (cs *ClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest)
 			ID:        uint64(m.ID),
 			PeerURLs:  m.PeerURLs,
 			IsLearner: m.IsLearner,
+			IsWitness: m.IsWitness,
 		},
 		Members: membersToProtoMembers(membs),
 	}, nil
@@ -118,6 +124,7 @@ func membersToProtoMembers(membs []*membership.Member) []*pb.Member {
 			PeerURLs:   membs[i].PeerURLs,
 			ClientURLs: membs[i].ClientURLs,
 			IsLearner:  membs[i].IsLearner,
+			IsWitness:  membs[i].IsWitness,
 		}
 	}
 	return protoMembs
@@ -48,6 +48,22 @@ var (
 	},
 		[]string{"type", "client_api_version"},
 	)
+
+	watchEventsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "grpc",
+		Name:      "watch_events_sent_total",
+		Help:      "The total number of watch events sent to grpc clients per watched key prefix of experimental-watch-metrics-prefixes.",
+	},
+		[]string{"prefix"},
+	)
+
+	watchStreamThrottledSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "grpc",
+		Name:      "watch_stream_throttled_seconds_total",
+		Help:      "The total time watch streams waited to stay under the experimental-watch-max-events-per-second limit.",
+	})
 )
 
 func init() {
@@ -55,4 +71,6 @@ func init() {
 	prometheus.MustRegister(receivedBytes)
 	prometheus.MustRegister(streamFailures)
 	prometheus.MustRegister(clientRequests)
+	prometheus.MustRegister(watchEventsSent)
+	prometheus.MustRegister(watchStreamThrottledSeconds)
 }
@@ -38,6 +38,7 @@ var toGRPCErrorMap = map[error]error{
 	membership.ErrPeerURLexists:           rpctypes.ErrGRPCPeerURLExist,
 	membership.ErrMemberNotLearner:        rpctypes.ErrGRPCMemberNotLearner,
 	membership.ErrTooManyLearners:         rpctypes.ErrGRPCTooManyLearners,
+	membership.ErrWitnessLearner:          rpctypes.ErrGRPCWitnessLearner,
 	etcdserver.ErrNotEnoughStartedMembers: rpctypes.ErrMemberNotEnoughStarted,
 	etcdserver.ErrLearnerNotReady:         rpctypes.ErrGRPCLearnerNotReady,
 
@@ -45,8 +46,32 @@ var toGRPCErrorMap = map[error]error{
 	mvcc.ErrFutureRev:             rpctypes.ErrGRPCFutureRev,
 	etcdserver.ErrRequestTooLarge: rpctypes.ErrGRPCRequestTooLarge,
 	etcdserver.ErrNoSpace:         rpctypes.ErrGRPCNoSpace,
+	etcdserver.ErrReadOnly:        rpctypes.ErrGRPCReadOnly,
 	etcdserver.ErrTooManyRequests: rpctypes.ErrTooManyRequests,
 
+	mvcc.ErrImportRevision:              rpctypes.ErrGRPCImportRevision,
+	mvcc.ErrImportKeyOrder:              rpctypes.ErrGRPCImportKeyOrder,
+	etcdserver.ErrImportNotSingleMember: rpctypes.ErrGRPCImportNotSingleMember,
+	etcdserver.ErrImportInProgress:      rpctypes.ErrGRPCImportInProgress,
+
+	etcdserver.ErrRangeRateLimitExceeded: rpctypes.ErrGRPCRangeRateLimitExceeded,
+
+	etcdserver.ErrNamespaceEmpty:            rpctypes.ErrGRPCNamespaceEmpty,
+	etcdserver.ErrNamespaceNotFound:         rpctypes.ErrGRPCNamespaceNotFound,
+	etcdserver.ErrNamespaceQuotaExceeded:    rpctypes.ErrGRPCNamespaceQuotaExceeded,
+	etcdserver.ErrNamespaceLeaseTTLTooLarge: rpctypes.ErrGRPCNamespaceLeaseTTLTooLarge,
+
+	etcdserver.ErrIndexNameEmpty:    rpctypes.ErrGRPCIndexNameEmpty,
+	etcdserver.ErrIndexFieldInvalid: rpctypes.ErrGRPCIndexFieldInvalid,
+	etcdserver.ErrIndexNotFound:     rpctypes.ErrGRPCIndexNotFound,
+
+	etcdserver.ErrRequestIDTooLong:  rpctypes.ErrGRPCRequestIDTooLong,
+	etcdserver.ErrRequestIDConflict: rpctypes.ErrGRPCRequestIDConflict,
+
+	etcdserver.ErrUnknownLogModule:      rpctypes.ErrGRPCUnknownLogModule,
+	etcdserver.ErrInvalidLogLevel:       rpctypes.ErrGRPCInvalidLogLevel,
+	etcdserver.ErrLogLevelsUnconfigured: rpctypes.ErrGRPCLogLevelsUnconfigured,
+
 	etcdserver.ErrNoLeader:                   rpctypes.ErrGRPCNoLeader,
 	etcdserver.ErrNotLeader:                  rpctypes.ErrGRPCNotLeader,
 	etcdserver.ErrLeaderChanged:              rpctypes.ErrGRPCLeaderChanged,
@@ -66,9 +91,10 @@ var toGRPCErrorMap = map[error]error{
 	version.ErrDowngradeInProcess:             rpctypes.ErrGRPCDowngradeInProcess,
 	version.ErrNoInflightDowngrade:            rpctypes.ErrGRPCNoInflightDowngrade,
 
-	lease.ErrLeaseNotFound:    rpctypes.ErrGRPCLeaseNotFound,
-	lease.ErrLeaseExists:      rpctypes.ErrGRPCLeaseExist,
-	lease.ErrLeaseTTLTooLarge: rpctypes.ErrGRPCLeaseTTLTooLarge,
+	lease.ErrLeaseNotFound:       rpctypes.ErrGRPCLeaseNotFound,
+	lease.ErrLeaseExists:         rpctypes.ErrGRPCLeaseExist,
+	lease.ErrLeaseTTLTooLarge:    rpctypes.ErrGRPCLeaseTTLTooLarge,
+	lease.ErrLeaseLabelsTooLarge: rpctypes.ErrGRPCLeaseLabelsTooLarge,
 
 	auth.ErrRootUserNotExist:     rpctypes.ErrGRPCRootUserNotExist,
 	auth.ErrRootRoleNotExist:     rpctypes.ErrGRPCRootRoleNotExist,
@@ -148,3 +174,14 @@ func isRPCSupportedForLearner(req interface{}) bool {
 		return false
 	}
 }
+
+// isRPCSupportedForWitness returns if the request can be served by a witness,
+// which holds no key-value data.
+func isRPCSupportedForWitness(req interface{}) bool {
+	switch req.(type) {
+	case *pb.StatusRequest, *pb.MemberListRequest:
+		return true
+	default:
+		return false
+	}
+}
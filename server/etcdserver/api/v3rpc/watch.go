@@ -15,6 +15,7 @@
 package v3rpc
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"math/rand"
@@ -28,7 +29,10 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/peer"
 )
 
 const minWatchProgressInterval = 100 * time.Millisecond
@@ -39,11 +43,18 @@ type watchServer struct {
 	clusterID int64
 	memberID  int64
 
-	maxRequestBytes int
+	maxRequestBytes    int
+	maxEventsPerSecond int
+	// metricsPrefixes are the key prefixes the events sent are counted by
+	metricsPrefixes []string
 
 	sg        etcdserver.RaftStatusGetter
 	watchable mvcc.WatchableKV
 	ag        AuthGetter
+	consumers *etcdserver.WatchConsumers
+	// traceSend, if set, traces the delivery of the events of the traced
+	// requests.
+	traceSend func(evs []mvccpb.Event, watchID int64) func()
 }
 
 // NewWatchServer returns a new watch server.
@@ -54,15 +65,21 @@ func NewWatchServer(s *etcdserver.EtcdServer) pb.WatchServer {
 		clusterID: int64(s.Cluster().ID()),
 		memberID:  int64(s.ID()),
 
-		maxRequestBytes: int(s.Cfg.MaxRequestBytes + grpcOverheadBytes),
+		maxRequestBytes:    int(s.Cfg.MaxRequestBytes + grpcOverheadBytes),
+		maxEventsPerSecond: s.Cfg.WatchMaxEventsPerSecond,
+		metricsPrefixes:    s.Cfg.WatchMetricsPrefixes,
 
 		sg:        s,
 		watchable: s.Watchable(),
 		ag:        s,
+		consumers: s.WatchConsumers(),
 	}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
+	if s.Cfg.ExperimentalTracerProvider != nil {
+		srv.traceSend = s.TraceWatchSend
+	}
 	if s.Cfg.WatchProgressNotifyInterval > 0 {
 		if s.Cfg.WatchProgressNotifyInterval < minWatchProgressInterval {
 			srv.lg.Warn(
@@ -131,8 +148,15 @@ type serverWatchStream struct {
 	gRPCStream  pb.Watch_WatchServer
 	watchStream mvcc.WatchStream
 	ctrlStream  chan *pb.WatchResponse
-
-	// mu protects progress, prevKV, fragment
+	consumers   *etcdserver.WatchConsumerStream
+	// limiter bounds the events sent per second, nil if unlimited
+	limiter *rate.Limiter
+	// metricsPrefixes are the key prefixes the events sent are counted by
+	metricsPrefixes []string
+	// traceSend traces the delivery of events, nil if not traced
+	traceSend func(evs []mvccpb.Event, watchID int64) func()
+
+	// mu protects progress, prevKV, fragment, eventsSent
 	mu sync.RWMutex
 	// tracks the watchID that stream might need to send progress to
 	// TODO: combine progress and prevKV into a single struct?
@@ -141,6 +165,8 @@ type serverWatchStream struct {
 	prevKV map[mvcc.WatchID]bool
 	// records fragmented watch IDs
 	fragment map[mvcc.WatchID]bool
+	// counts the events sent by watch ID, labeled by the watched prefix
+	eventsSent map[mvcc.WatchID]prometheus.Counter
 
 	// closec indicates the stream is closed.
 	closec chan struct{}
@@ -161,18 +187,28 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 		sg:        ws.sg,
 		watchable: ws.watchable,
 		ag:        ws.ag,
+		traceSend: ws.traceSend,
+
+		metricsPrefixes: ws.metricsPrefixes,
 
 		gRPCStream:  stream,
 		watchStream: ws.watchable.NewWatchStream(),
 		// chan for sending control response like watcher created and canceled.
 		ctrlStream: make(chan *pb.WatchResponse, ctrlStreamBufLen),
 
-		progress: make(map[mvcc.WatchID]bool),
-		prevKV:   make(map[mvcc.WatchID]bool),
-		fragment: make(map[mvcc.WatchID]bool),
+		progress:   make(map[mvcc.WatchID]bool),
+		prevKV:     make(map[mvcc.WatchID]bool),
+		fragment:   make(map[mvcc.WatchID]bool),
+		eventsSent: make(map[mvcc.WatchID]prometheus.Counter),
 
 		closec: make(chan struct{}),
 	}
+	if ws.maxEventsPerSecond > 0 {
+		sws.limiter = rate.NewLimiter(rate.Limit(ws.maxEventsPerSecond), ws.maxEventsPerSecond)
+	}
+	sws.consumers = ws.consumers.NewStream(sws.user(), remoteAddr(stream.Context()), func() int {
+		return len(sws.watchStream.Chan())
+	})
 
 	sws.wg.Add(1)
 	go func() {
@@ -223,16 +259,44 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 	return err
 }
 
-func (sws *serverWatchStream) isWatchPermitted(wcr *pb.WatchCreateRequest) bool {
+// user returns the name of the user that opened the stream, if any.
+func (sws *serverWatchStream) user() string {
 	authInfo, err := sws.ag.AuthInfoFromCtx(sws.gRPCStream.Context())
-	if err != nil {
-		return false
+	if err != nil || authInfo == nil {
+		return ""
+	}
+	return authInfo.Username
+}
+
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// checkWatchPermitted returns the reason to refuse the watch, if any. The
+// clients re-authenticate on an invalid or outdated auth token, hence it is
+// told apart from a permission denied.
+func (sws *serverWatchStream) checkWatchPermitted(wcr *pb.WatchCreateRequest) string {
+	authInfo, err := sws.ag.AuthInfoFromCtx(sws.gRPCStream.Context())
+	if err == nil {
+		if authInfo == nil {
+			// if auth is enabled, IsRangePermitted() can cause an error
+			authInfo = &auth.AuthInfo{}
+		}
+		err = sws.ag.AuthStore().IsRangePermitted(authInfo, wcr.Key, wcr.RangeEnd)
 	}
-	if authInfo == nil {
-		// if auth is enabled, IsRangePermitted() can cause an error
-		authInfo = &auth.AuthInfo{}
+	switch err {
+	case nil:
+		return ""
+	case auth.ErrInvalidAuthToken:
+		return rpctypes.ErrGRPCInvalidAuthToken.Error()
+	case auth.ErrAuthOldRevision:
+		return rpctypes.ErrGRPCAuthOldRevision.Error()
+	default:
+		return rpctypes.ErrGRPCPermissionDenied.Error()
 	}
-	return sws.ag.AuthStore().IsRangePermitted(authInfo, wcr.Key, wcr.RangeEnd) == nil
 }
 
 func (sws *serverWatchStream) recvLoop() error {
@@ -266,13 +330,13 @@ func (sws *serverWatchStream) recvLoop() error {
 				creq.RangeEnd = []byte{}
 			}
 
-			if !sws.isWatchPermitted(creq) {
+			if reason := sws.checkWatchPermitted(creq); reason != "" {
 				wr := &pb.WatchResponse{
 					Header:       sws.newResponseHeader(sws.watchStream.Rev()),
 					WatchId:      creq.WatchId,
 					Canceled:     true,
 					Created:      true,
-					CancelReason: rpctypes.ErrGRPCPermissionDenied.Error(),
+					CancelReason: reason,
 				}
 
 				select {
@@ -302,7 +366,14 @@ func (sws *serverWatchStream) recvLoop() error {
 				if creq.Fragment {
 					sws.fragment[id] = true
 				}
+				sws.eventsSent[id] = watchEventsSent.WithLabelValues(watchPrefixLabel(sws.metricsPrefixes, creq.Key))
 				sws.mu.Unlock()
+				end := creq.RangeEnd
+				if end != nil && len(end) == 0 {
+					// report watches of all keys >= key as requested
+					end = []byte{0}
+				}
+				sws.consumers.Add(int64(id), creq.Key, end)
 			}
 			wr := &pb.WatchResponse{
 				Header:   sws.newResponseHeader(wsrev),
@@ -333,7 +404,9 @@ func (sws *serverWatchStream) recvLoop() error {
 					delete(sws.progress, mvcc.WatchID(id))
 					delete(sws.prevKV, mvcc.WatchID(id))
 					delete(sws.fragment, mvcc.WatchID(id))
+					delete(sws.eventsSent, mvcc.WatchID(id))
 					sws.mu.Unlock()
+					sws.consumers.Remove(id)
 				}
 			}
 		case *pb.WatchRequest_ProgressRequest:
@@ -416,12 +489,20 @@ func (sws *serverWatchStream) sendLoop() {
 				continue
 			}
 
+			if !sws.throttle(len(evs)) {
+				return
+			}
 			mvcc.ReportEventReceived(len(evs))
 
 			sws.mu.RLock()
 			fragmented, ok := sws.fragment[wresp.WatchID]
 			sws.mu.RUnlock()
 
+			var sent func()
+			if sws.traceSend != nil && len(evs) > 0 {
+				sent = sws.traceSend(evs, int64(wresp.WatchID))
+			}
+
 			var serr error
 			if !fragmented && !ok {
 				serr = sws.gRPCStream.Send(wr)
@@ -438,6 +519,13 @@ func (sws *serverWatchStream) sendLoop() {
 				}
 				return
 			}
+			if sent != nil {
+				sent()
+			}
+			sws.recordSent(wresp.WatchID, len(evs))
+			if canceled {
+				sws.consumers.Remove(int64(wresp.WatchID))
+			}
 
 			sws.mu.Lock()
 			if len(evs) > 0 && sws.progress[wresp.WatchID] {
@@ -471,6 +559,9 @@ func (sws *serverWatchStream) sendLoop() {
 				// flush buffered events
 				ids[wid] = struct{}{}
 				for _, v := range pending[wid] {
+					if !sws.throttle(len(v.Events)) {
+						return
+					}
 					mvcc.ReportEventReceived(len(v.Events))
 					if err := sws.gRPCStream.Send(v); err != nil {
 						if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
@@ -481,6 +572,7 @@ func (sws *serverWatchStream) sendLoop() {
 						}
 						return
 					}
+					sws.recordSent(wid, len(v.Events))
 				}
 				delete(pending, wid)
 			}
@@ -501,6 +593,68 @@ func (sws *serverWatchStream) sendLoop() {
 	}
 }
 
+// throttle waits until events can be sent without exceeding the events per
+// second limit of the stream, and reports whether the stream is still open.
+// The watchers of the stream are not drained meanwhile, so the watchable store
+// holds them back as slow watchers instead of blocking other streams.
+func (sws *serverWatchStream) throttle(events int) bool {
+	if sws.limiter == nil || events == 0 {
+		return true
+	}
+	start := time.Now()
+	defer func() {
+		watchStreamThrottledSeconds.Add(time.Since(start).Seconds())
+	}()
+	for events > 0 {
+		// a response may carry more events than the limiter allows at once
+		n := events
+		if burst := sws.limiter.Burst(); n > burst {
+			n = burst
+		}
+		events -= n
+		delay := sws.limiter.ReserveN(time.Now(), n).Delay()
+		if delay == 0 {
+			continue
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-sws.closec:
+			t.Stop()
+			return false
+		}
+	}
+	return true
+}
+
+// recordSent accounts events sent to the client for the watch.
+func (sws *serverWatchStream) recordSent(id mvcc.WatchID, events int) {
+	if events == 0 {
+		return
+	}
+	sws.mu.RLock()
+	c := sws.eventsSent[id]
+	sws.mu.RUnlock()
+	if c != nil {
+		c.Add(float64(events))
+	}
+	sws.consumers.Record(int64(id), events)
+}
+
+// watchPrefixLabel returns the prefix a watch on key is accounted under: the
+// longest of the configured prefixes key starts with, and "other" for any other
+// key, so that the clients cannot grow the cardinality of the metric.
+func watchPrefixLabel(prefixes []string, key []byte) string {
+	label := "other"
+	n := 0
+	for _, p := range prefixes {
+		if len(p) > n && bytes.HasPrefix(key, []byte(p)) {
+			label, n = p, len(p)
+		}
+	}
+	return label
+}
+
 func IsCreateEvent(e mvccpb.Event) bool {
 	return e.Type == mvccpb.PUT && e.Kv.CreateRevision == e.Kv.ModRevision
 }
@@ -548,6 +702,7 @@ func (sws *serverWatchStream) close() {
 	sws.watchStream.Close()
 	close(sws.closec)
 	sws.wg.Wait()
+	sws.consumers.Close()
 }
 
 func (sws *serverWatchStream) newResponseHeader(rev int64) *pb.ResponseHeader {
@@ -567,9 +722,41 @@ func filterNoPut(e mvccpb.Event) bool {
 	return e.Type == mvccpb.PUT
 }
 
+// filterValue returns a filter discarding the put events whose values do not
+// match f, or nil if f is of an unknown type.
+func filterValue(f *pb.WatchValueFilter) mvcc.FilterFunc {
+	var match func(v []byte) bool
+	switch f.Type {
+	case pb.WatchValueFilter_PREFIX:
+		match = func(v []byte) bool { return bytes.HasPrefix(v, f.Value) }
+	case pb.WatchValueFilter_CONTAINS:
+		match = func(v []byte) bool { return bytes.Contains(v, f.Value) }
+	case pb.WatchValueFilter_SIZE:
+		match = func(v []byte) bool {
+			size := int64(len(v))
+			switch f.Result {
+			case pb.Compare_EQUAL:
+				return size == f.SizeBytes
+			case pb.Compare_NOT_EQUAL:
+				return size != f.SizeBytes
+			case pb.Compare_GREATER:
+				return size > f.SizeBytes
+			case pb.Compare_LESS:
+				return size < f.SizeBytes
+			}
+			return true
+		}
+	default:
+		return nil
+	}
+	return func(e mvccpb.Event) bool {
+		return e.Type == mvccpb.PUT && !match(e.Kv.Value)
+	}
+}
+
 // FiltersFromRequest returns "mvcc.FilterFunc" from a given watch create request.
 func FiltersFromRequest(creq *pb.WatchCreateRequest) []mvcc.FilterFunc {
-	filters := make([]mvcc.FilterFunc, 0, len(creq.Filters))
+	filters := make([]mvcc.FilterFunc, 0, len(creq.Filters)+len(creq.ValueFilters))
 	for _, ft := range creq.Filters {
 		switch ft {
 		case pb.WatchCreateRequest_NOPUT:
@@ -579,5 +766,10 @@ func FiltersFromRequest(creq *pb.WatchCreateRequest) []mvcc.FilterFunc {
 		default:
 		}
 	}
+	for _, vf := range creq.ValueFilters {
+		if f := filterValue(vf); f != nil {
+			filters = append(filters, f)
+		}
+	}
 	return filters
 }
@@ -18,9 +18,11 @@ import (
 	"bytes"
 	"math"
 	"testing"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"golang.org/x/time/rate"
 )
 
 func TestSendFragment(t *testing.T) {
@@ -93,3 +95,82 @@ func createResponse(dataSize, events int) (resp *pb.WatchResponse) {
 	}
 	return resp
 }
+
+func TestWatchPrefixLabel(t *testing.T) {
+	prefixes := []string{"/registry/", "/registry/pods/", "/registry/events/"}
+	tests := []struct {
+		prefixes []string
+		key      string
+		want     string
+	}{
+		{prefixes, "/registry/pods/default/nginx", "/registry/pods/"},
+		{prefixes, "/registry/pods/", "/registry/pods/"},
+		{prefixes, "/registry/pods", "/registry/"},
+		{prefixes, "/registry/leases/kube-node-lease/n1", "/registry/"},
+		{prefixes, "/registry", "other"},
+		{prefixes, "/tenants/a/b", "other"},
+		{prefixes, "", "other"},
+		{nil, "/registry/pods/default/nginx", "other"},
+	}
+	for _, tt := range tests {
+		if got := watchPrefixLabel(tt.prefixes, []byte(tt.key)); got != tt.want {
+			t.Errorf("watchPrefixLabel(%v, %q) = %q, want %q", tt.prefixes, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestWatchStreamThrottle(t *testing.T) {
+	sws := &serverWatchStream{
+		limiter: rate.NewLimiter(100, 100),
+		closec:  make(chan struct{}),
+	}
+	start := time.Now()
+	// the burst is sent at once, the events over it at 100 per second
+	if !sws.throttle(150) {
+		t.Fatal("expected open stream to be throttled")
+	}
+	if took := time.Since(start); took < 400*time.Millisecond {
+		t.Errorf("expected 150 events to take at least 500ms, took %v", took)
+	}
+
+	close(sws.closec)
+	if sws.throttle(100) {
+		t.Error("expected throttling to stop once the stream is closed")
+	}
+	if !(&serverWatchStream{}).throttle(1000) {
+		t.Error("expected unlimited stream not to be throttled")
+	}
+}
+
+func TestFiltersFromRequestValueFilters(t *testing.T) {
+	creq := &pb.WatchCreateRequest{
+		ValueFilters: []*pb.WatchValueFilter{
+			{Type: pb.WatchValueFilter_PREFIX, Value: []byte("{")},
+			{Type: pb.WatchValueFilter_CONTAINS, Value: []byte(`"ready"`)},
+			{Type: pb.WatchValueFilter_SIZE, Result: pb.Compare_LESS, SizeBytes: 20},
+		},
+	}
+	filters := FiltersFromRequest(creq)
+	tt := []struct {
+		ev   mvccpb.Event
+		want bool
+	}{
+		{ev: mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Value: []byte(`{"ready"}`)}}, want: false},
+		{ev: mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Value: []byte(`["ready"]`)}}, want: true},
+		{ev: mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Value: []byte(`{"starting"}`)}}, want: true},
+		{ev: mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Value: []byte(`{"ready", "extra": "fields"}`)}}, want: true},
+		// delete events are not filtered by value
+		{ev: mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{}}, want: false},
+	}
+	for i, tc := range tt {
+		filtered := false
+		for _, f := range filters {
+			if f(tc.ev) {
+				filtered = true
+			}
+		}
+		if filtered != tc.want {
+			t.Errorf("#%d: expected event %v to be filtered %v, got %v", i, tc.ev, tc.want, filtered)
+		}
+	}
+}
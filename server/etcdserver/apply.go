@@ -52,6 +52,11 @@ type applyResult struct {
 	// Compaction requests.
 	physc <-chan struct{}
 	trace *traceutil.Trace
+	// index is the index of the entry of the request, which took applyTook
+	// to be applied from applyStart.
+	index      uint64
+	applyStart time.Time
+	applyTook  time.Duration
 }
 
 // applierV3Internal is the interface for processing internal V3 raft request
@@ -69,15 +74,25 @@ type applierV3 interface {
 	Range(ctx context.Context, txn mvcc.TxnRead, r *pb.RangeRequest) (*pb.RangeResponse, error)
 	DeleteRange(txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error)
 	Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error)
-	Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error)
+	Compaction(compaction *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error)
 
 	LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error)
 	LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error)
 
 	LeaseCheckpoint(lc *pb.LeaseCheckpointRequest) (*pb.LeaseCheckpointResponse, error)
 
+	LeaseExpire(lc *pb.LeaseExpireRequest) (*pb.LeaseExpireResponse, error)
+
 	Alarm(*pb.AlarmRequest) (*pb.AlarmResponse, error)
 
+	NamespacePut(r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error)
+	NamespaceDelete(r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error)
+
+	IndexPut(r *pb.IndexPutRequest) (*pb.IndexPutResponse, error)
+	IndexDelete(r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error)
+
+	CDCCheckpoint(r *pb.CDCCheckpointRequest) (*pb.CDCCheckpointResponse, error)
+
 	Authenticate(r *pb.InternalAuthenticateRequest) (*pb.AuthenticateResponse, error)
 
 	AuthEnable() (*pb.AuthEnableResponse, error)
@@ -129,6 +144,7 @@ func (s *EtcdServer) newApplierV3() applierV3 {
 		s.AuthStore(),
 		newQuotaApplierV3(s, s.newApplierV3Backend()),
 		s.lessor,
+		s.indexes,
 	)
 }
 
@@ -163,23 +179,34 @@ func (a *applierV3backend) Apply(r *pb.InternalRaftRequest, shouldApplyV3 member
 		return nil
 	}
 
+	// a retried mutation gets the response of its first attempt.
+	deduplicate := a.s.requestIDs.deduplicates(r)
+	if deduplicate {
+		var found bool
+		if ar.resp, found, ar.err = a.s.requestIDs.lookup(r); found || ar.err != nil {
+			op = "Duplicate"
+			return ar
+		}
+	}
+
 	// call into a.s.applyV3.F instead of a.F so upper appliers can check individual calls
+	ctx := withQuotaExemption(context.TODO(), r.Header)
 	switch {
 	case r.Range != nil:
 		op = "Range"
 		ar.resp, ar.err = a.s.applyV3.Range(context.TODO(), nil, r.Range)
 	case r.Put != nil:
 		op = "Put"
-		ar.resp, ar.trace, ar.err = a.s.applyV3.Put(context.TODO(), nil, r.Put)
+		ar.resp, ar.trace, ar.err = a.s.applyV3.Put(ctx, nil, r.Put)
 	case r.DeleteRange != nil:
 		op = "DeleteRange"
 		ar.resp, ar.err = a.s.applyV3.DeleteRange(nil, r.DeleteRange)
 	case r.Txn != nil:
 		op = "Txn"
-		ar.resp, ar.trace, ar.err = a.s.applyV3.Txn(context.TODO(), r.Txn)
+		ar.resp, ar.trace, ar.err = a.s.applyV3.Txn(ctx, r.Txn)
 	case r.Compaction != nil:
 		op = "Compaction"
-		ar.resp, ar.physc, ar.trace, ar.err = a.s.applyV3.Compaction(r.Compaction)
+		ar.resp, ar.physc, ar.trace, ar.err = a.s.applyV3.Compaction(r.Compaction, r.CompactionRetentions)
 	case r.LeaseGrant != nil:
 		op = "LeaseGrant"
 		ar.resp, ar.err = a.s.applyV3.LeaseGrant(r.LeaseGrant)
@@ -189,9 +216,27 @@ func (a *applierV3backend) Apply(r *pb.InternalRaftRequest, shouldApplyV3 member
 	case r.LeaseCheckpoint != nil:
 		op = "LeaseCheckpoint"
 		ar.resp, ar.err = a.s.applyV3.LeaseCheckpoint(r.LeaseCheckpoint)
+	case r.LeaseExpire != nil:
+		op = "LeaseExpire"
+		ar.resp, ar.err = a.s.applyV3.LeaseExpire(r.LeaseExpire)
 	case r.Alarm != nil:
 		op = "Alarm"
 		ar.resp, ar.err = a.s.applyV3.Alarm(r.Alarm)
+	case r.NamespacePut != nil:
+		op = "NamespacePut"
+		ar.resp, ar.err = a.s.applyV3.NamespacePut(r.NamespacePut)
+	case r.NamespaceDelete != nil:
+		op = "NamespaceDelete"
+		ar.resp, ar.err = a.s.applyV3.NamespaceDelete(r.NamespaceDelete)
+	case r.IndexPut != nil:
+		op = "IndexPut"
+		ar.resp, ar.err = a.s.applyV3.IndexPut(r.IndexPut)
+	case r.IndexDelete != nil:
+		op = "IndexDelete"
+		ar.resp, ar.err = a.s.applyV3.IndexDelete(r.IndexDelete)
+	case r.CdcCheckpoint != nil:
+		op = "CDCCheckpoint"
+		ar.resp, ar.err = a.s.applyV3.CDCCheckpoint(r.CdcCheckpoint)
 	case r.Authenticate != nil:
 		op = "Authenticate"
 		ar.resp, ar.err = a.s.applyV3.Authenticate(r.Authenticate)
@@ -245,6 +290,10 @@ func (a *applierV3backend) Apply(r *pb.InternalRaftRequest, shouldApplyV3 member
 	default:
 		a.s.lg.Panic("not implemented apply", zap.Stringer("raft-request", r))
 	}
+	if deduplicate && ar.err == nil {
+		index, _ := a.s.consistIndex.ConsistentApplyingIndex()
+		a.s.requestIDs.record(r, index, ar.resp)
+	}
 	return ar
 }
 
@@ -267,6 +316,9 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 				return nil, nil, lease.ErrLeaseNotFound
 			}
 		}
+		if err = a.s.namespaces.checkPuts(a.s.lessor, []*pb.PutRequest{p}); err != nil {
+			return nil, nil, err
+		}
 		txn = a.s.KV().Write(trace)
 		defer txn.End()
 	}
@@ -469,6 +521,10 @@ func (a *applierV3backend) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnR
 			txn.End()
 			return nil, nil, err
 		}
+		if err := a.s.namespaces.checkPuts(a.s.lessor, txnPuts(txn, rt, txnPath)); err != nil {
+			txn.End()
+			return nil, nil, err
+		}
 	}
 	if _, err := checkRequests(txn, rt, txnPath, a.checkRange); err != nil {
 		txn.End()
@@ -500,6 +556,17 @@ func (a *applierV3backend) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnR
 	return txnResp, trace, nil
 }
 
+// txnPuts returns the put requests of a txn request given a path.
+func txnPuts(rv mvcc.ReadView, rt *pb.TxnRequest, txnPath []bool) (puts []*pb.PutRequest) {
+	checkRequests(rv, rt, txnPath, func(_ mvcc.ReadView, req *pb.RequestOp) error {
+		if tv, ok := req.Request.(*pb.RequestOp_RequestPut); ok && tv.RequestPut != nil {
+			puts = append(puts, tv.RequestPut)
+		}
+		return nil
+	})
+	return puts
+}
+
 // newTxnResp allocates a txn response for a txn request given a path.
 func newTxnResp(rt *pb.TxnRequest, txnPath []bool) (txnResp *pb.TxnResponse, txnCount int) {
 	reqs := rt.Success
@@ -679,7 +746,7 @@ func (a *applierV3backend) applyTxn(ctx context.Context, txn mvcc.TxnWrite, rt *
 	return txns
 }
 
-func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
+func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
 	resp := &pb.CompactionResponse{}
 	resp.Header = &pb.ResponseHeader{}
 	trace := traceutil.New("compact",
@@ -687,7 +754,17 @@ func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.Com
 		traceutil.Field{Key: "revision", Value: compaction.Revision},
 	)
 
-	ch, err := a.s.KV().Compact(trace, compaction.Revision)
+	var ch <-chan struct{}
+	var err error
+	if len(retentions) > 0 {
+		mrs := make([]mvcc.Retention, len(retentions))
+		for i, r := range retentions {
+			mrs[i] = mvcc.Retention{Prefix: r.Prefix, Versions: r.Versions, Revision: r.Revision}
+		}
+		ch, err = a.s.KV().CompactWithRetentions(trace, compaction.Revision, mrs)
+	} else {
+		ch, err = a.s.KV().Compact(trace, compaction.Revision)
+	}
 	if err != nil {
 		return nil, ch, nil, err
 	}
@@ -698,7 +775,7 @@ func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.Com
 }
 
 func (a *applierV3backend) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
-	l, err := a.s.lessor.Grant(lease.LeaseID(lc.ID), lc.TTL)
+	l, err := a.s.lessor.GrantWithLabels(lease.LeaseID(lc.ID), lc.TTL, lc.Labels)
 	resp := &pb.LeaseGrantResponse{}
 	if err == nil {
 		resp.ID = int64(l.ID)
@@ -723,6 +800,58 @@ func (a *applierV3backend) LeaseCheckpoint(lc *pb.LeaseCheckpointRequest) (*pb.L
 	return &pb.LeaseCheckpointResponse{Header: newHeader(a.s)}, nil
 }
 
+func (a *applierV3backend) LeaseExpire(lc *pb.LeaseExpireRequest) (*pb.LeaseExpireResponse, error) {
+	ids := make([]lease.LeaseID, len(lc.IDs))
+	for i, id := range lc.IDs {
+		ids[i] = lease.LeaseID(id)
+	}
+	revoked, deleted := a.s.lessor.RevokeBatch(ids, int(lc.MaxKeys))
+	return &pb.LeaseExpireResponse{
+		Header:  newHeader(a.s),
+		Revoked: int64(revoked),
+		Deleted: int64(deleted),
+	}, nil
+}
+
+func (a *applierV3backend) NamespacePut(r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error) {
+	if r.Namespace == nil {
+		return nil, ErrNamespaceEmpty
+	}
+	if err := a.s.namespaces.put(r.Namespace); err != nil {
+		return nil, err
+	}
+	return &pb.NamespacePutResponse{Header: newHeader(a.s)}, nil
+}
+
+func (a *applierV3backend) NamespaceDelete(r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error) {
+	if err := a.s.namespaces.delete(r.Prefix); err != nil {
+		return nil, err
+	}
+	return &pb.NamespaceDeleteResponse{Header: newHeader(a.s)}, nil
+}
+
+func (a *applierV3backend) IndexPut(r *pb.IndexPutRequest) (*pb.IndexPutResponse, error) {
+	if r.Index == nil {
+		return nil, ErrIndexNameEmpty
+	}
+	if err := a.s.indexes.put(r.Index); err != nil {
+		return nil, err
+	}
+	return &pb.IndexPutResponse{Header: newHeader(a.s)}, nil
+}
+
+func (a *applierV3backend) IndexDelete(r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error) {
+	if err := a.s.indexes.delete(r.Name); err != nil {
+		return nil, err
+	}
+	return &pb.IndexDeleteResponse{Header: newHeader(a.s)}, nil
+}
+
+func (a *applierV3backend) CDCCheckpoint(r *pb.CDCCheckpointRequest) (*pb.CDCCheckpointResponse, error) {
+	a.s.updateCDCCheckpoint(r.Sink, r.Revision)
+	return &pb.CDCCheckpointResponse{Header: newHeader(a.s)}, nil
+}
+
 func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error) {
 	resp := &pb.AlarmResponse{}
 	oldCount := len(a.s.alarmStore.Get(ar.Alarm))
@@ -747,10 +876,9 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 
 		lg.Warn("alarm raised", zap.String("alarm", m.Alarm.String()), zap.String("from", types.ID(m.MemberID).String()))
 		switch m.Alarm {
-		case pb.AlarmType_CORRUPT:
-			a.s.applyV3 = newApplierV3Corrupt(a)
-		case pb.AlarmType_NOSPACE:
-			a.s.applyV3 = newApplierV3Capped(a)
+		case pb.AlarmType_CORRUPT, pb.AlarmType_NOSPACE, pb.AlarmType_READONLY:
+			// rebuild the whole chain so the new alarm stacks on top of any already raised
+			a.s.applyV3 = a.s.newApplierV3WithAlarms()
 		default:
 			lg.Panic("unimplemented alarm activation", zap.String("alarm", fmt.Sprintf("%+v", m)))
 		}
@@ -766,10 +894,10 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 		}
 
 		switch m.Alarm {
-		case pb.AlarmType_NOSPACE, pb.AlarmType_CORRUPT:
+		case pb.AlarmType_NOSPACE, pb.AlarmType_CORRUPT, pb.AlarmType_READONLY:
 			// TODO: check kv hash before deactivating CORRUPT?
 			lg.Warn("alarm disarmed", zap.String("alarm", m.Alarm.String()), zap.String("from", types.ID(m.MemberID).String()))
-			a.s.applyV3 = a.s.newApplierV3()
+			a.s.applyV3 = a.s.newApplierV3WithAlarms()
 		default:
 			lg.Warn("unimplemented alarm deactivation", zap.String("alarm", fmt.Sprintf("%+v", m)))
 		}
@@ -785,15 +913,34 @@ type applierV3Capped struct {
 }
 
 // newApplierV3Capped creates an applyV3 that will reject Puts and transactions
-// with Puts so that the number of keys in the store is capped.
+// with Puts so that the number of keys in the store is capped. Writes to quota
+// exempted prefixes are still applied if the proposing member admitted them.
 func newApplierV3Capped(base applierV3) applierV3 { return &applierV3Capped{applierV3: base} }
 
+type quotaExemptKey struct{}
+
+// withQuotaExemption returns a context carrying whether the proposing member
+// admitted the write of header past the quota, see RequestHeader.QuotaExempt.
+func withQuotaExemption(ctx context.Context, header *pb.RequestHeader) context.Context {
+	return context.WithValue(ctx, quotaExemptKey{}, header != nil && header.QuotaExempt)
+}
+
+// quotaExempted reports whether the write applied with ctx was admitted past
+// the quota.
+func quotaExempted(ctx context.Context) bool {
+	exempt, _ := ctx.Value(quotaExemptKey{}).(bool)
+	return exempt
+}
+
 func (a *applierV3Capped) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	if quotaExempted(ctx) {
+		return a.applierV3.Put(ctx, txn, p)
+	}
 	return nil, nil, ErrNoSpace
 }
 
 func (a *applierV3Capped) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
-	if a.q.Cost(r) > 0 {
+	if a.q.Cost(r) > 0 && !quotaExempted(ctx) {
 		return nil, nil, ErrNoSpace
 	}
 	return a.applierV3.Txn(ctx, r)
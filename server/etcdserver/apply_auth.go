@@ -28,8 +28,9 @@ import (
 
 type authApplierV3 struct {
 	applierV3
-	as     auth.AuthStore
-	lessor lease.Lessor
+	as      auth.AuthStore
+	lessor  lease.Lessor
+	indexes *indexStore
 
 	// mu serializes Apply so that user isn't corrupted and so that
 	// serialized requests don't leak data from TOCTOU errors
@@ -38,8 +39,8 @@ type authApplierV3 struct {
 	authInfo auth.AuthInfo
 }
 
-func newAuthApplierV3(as auth.AuthStore, base applierV3, lessor lease.Lessor) *authApplierV3 {
-	return &authApplierV3{applierV3: base, as: as, lessor: lessor}
+func newAuthApplierV3(as auth.AuthStore, base applierV3, lessor lease.Lessor, indexes *indexStore) *authApplierV3 {
+	return &authApplierV3{applierV3: base, as: as, lessor: lessor, indexes: indexes}
 }
 
 func (aa *authApplierV3) Apply(r *pb.InternalRaftRequest, shouldApplyV3 membership.ShouldApplyV3) *applyResult {
@@ -50,17 +51,20 @@ func (aa *authApplierV3) Apply(r *pb.InternalRaftRequest, shouldApplyV3 membersh
 		// does not have header field
 		aa.authInfo.Username = r.Header.Username
 		aa.authInfo.Revision = r.Header.AuthRevision
+		aa.authInfo.Roles = r.Header.Roles
 	}
 	if needAdminPermission(r) {
-		if err := aa.as.IsAdminPermitted(&aa.authInfo); err != nil {
+		if err := aa.checkAdminPermission(r); err != nil {
 			aa.authInfo.Username = ""
 			aa.authInfo.Revision = 0
+			aa.authInfo.Roles = nil
 			return &applyResult{err: err}
 		}
 	}
 	ret := aa.applierV3.Apply(r, shouldApplyV3)
 	aa.authInfo.Username = ""
 	aa.authInfo.Revision = 0
+	aa.authInfo.Roles = nil
 	return ret
 }
 
@@ -190,7 +194,7 @@ func (aa *authApplierV3) checkLeasePuts(leaseID lease.LeaseID) error {
 }
 
 func (aa *authApplierV3) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error) {
-	err := aa.as.IsAdminPermitted(&aa.authInfo)
+	err := aa.as.IsUserAdminPermitted(&aa.authInfo, r.Name)
 	if err != nil && r.Name != aa.authInfo.Username {
 		aa.authInfo.Username = ""
 		aa.authInfo.Revision = 0
@@ -201,7 +205,7 @@ func (aa *authApplierV3) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetRespo
 }
 
 func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse, error) {
-	err := aa.as.IsAdminPermitted(&aa.authInfo)
+	err := aa.as.IsRoleAdminPermitted(&aa.authInfo, r.Role)
 	if err != nil && !aa.as.HasRole(aa.authInfo.Username, r.Role) {
 		aa.authInfo.Username = ""
 		aa.authInfo.Revision = 0
@@ -211,6 +215,107 @@ func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetRespo
 	return aa.applierV3.RoleGet(r)
 }
 
+// UserList lists only the users the user can manage, unless it has the root
+// role.
+func (aa *authApplierV3) UserList(r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error) {
+	resp, err := aa.applierV3.UserList(r)
+	if err != nil || aa.as.IsAdminPermitted(&aa.authInfo) == nil {
+		return resp, err
+	}
+	users := resp.Users[:0]
+	for _, user := range resp.Users {
+		if aa.as.IsUserAdminPermitted(&aa.authInfo, user) == nil {
+			users = append(users, user)
+		}
+	}
+	resp.Users = users
+	return resp, nil
+}
+
+// RoleList lists only the roles the user can manage, unless it has the root
+// role.
+func (aa *authApplierV3) RoleList(r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error) {
+	resp, err := aa.applierV3.RoleList(r)
+	if err != nil || aa.as.IsAdminPermitted(&aa.authInfo) == nil {
+		return resp, err
+	}
+	roles := resp.Roles[:0]
+	for _, role := range resp.Roles {
+		if aa.as.IsRoleAdminPermitted(&aa.authInfo, role) == nil {
+			roles = append(roles, role)
+		}
+	}
+	resp.Roles = roles
+	return resp, nil
+}
+
+// checkAdminPermission checks the permission of the user to apply an auth
+// management request. The users and roles named within the ranges of admin
+// permissions can be managed without the root role, as long as they do not
+// grant permissions outside of these ranges.
+func (aa *authApplierV3) checkAdminPermission(r *pb.InternalRaftRequest) error {
+	ai := &aa.authInfo
+	switch {
+	case r.AuthUserAdd != nil:
+		return aa.as.IsUserAdminPermitted(ai, r.AuthUserAdd.Name)
+	case r.AuthUserDelete != nil:
+		return aa.as.IsUserAdminPermitted(ai, r.AuthUserDelete.Name)
+	case r.AuthUserChangePassword != nil:
+		return aa.as.IsUserAdminPermitted(ai, r.AuthUserChangePassword.Name)
+	case r.AuthUserGrantRole != nil:
+		if err := aa.as.IsUserAdminPermitted(ai, r.AuthUserGrantRole.User); err != nil {
+			return err
+		}
+		return aa.as.IsRoleAdminPermitted(ai, r.AuthUserGrantRole.Role)
+	case r.AuthUserRevokeRole != nil:
+		return aa.as.IsUserAdminPermitted(ai, r.AuthUserRevokeRole.Name)
+	case r.AuthRoleAdd != nil:
+		return aa.as.IsRoleAdminPermitted(ai, r.AuthRoleAdd.Name)
+	case r.AuthRoleGrantPermission != nil:
+		if err := aa.as.IsRoleAdminPermitted(ai, r.AuthRoleGrantPermission.Name); err != nil {
+			return err
+		}
+		if perm := r.AuthRoleGrantPermission.Perm; perm != nil {
+			return aa.as.IsRangeAdminPermitted(ai, perm.Key, perm.RangeEnd)
+		}
+		return nil
+	case r.AuthRoleRevokePermission != nil:
+		return aa.as.IsRoleAdminPermitted(ai, r.AuthRoleRevokePermission.Role)
+	case r.AuthRoleDelete != nil:
+		return aa.as.IsRoleAdminPermitted(ai, r.AuthRoleDelete.Role)
+	case r.AuthUserList != nil, r.AuthRoleList != nil:
+		return aa.as.IsAnyAdminPermitted(ai)
+	case r.NamespacePut != nil && r.NamespacePut.Namespace != nil:
+		key, end := namespaceRange(r.NamespacePut.Namespace.Prefix)
+		return aa.as.IsRangeAdminPermitted(ai, key, end)
+	case r.NamespaceDelete != nil:
+		key, end := namespaceRange(r.NamespaceDelete.Prefix)
+		return aa.as.IsRangeAdminPermitted(ai, key, end)
+	case r.IndexPut != nil && r.IndexPut.Index != nil:
+		key, end := namespaceRange(r.IndexPut.Index.Prefix)
+		if err := aa.as.IsRangeAdminPermitted(ai, key, end); err != nil {
+			return err
+		}
+		// replacing an index deletes the one with the same name
+		if prefix, err := aa.indexes.prefix(r.IndexPut.Index.Name); err == nil {
+			key, end = namespaceRange(prefix)
+			return aa.as.IsRangeAdminPermitted(ai, key, end)
+		}
+		return nil
+	case r.IndexDelete != nil:
+		// the admins who may put an index may delete it
+		prefix, err := aa.indexes.prefix(r.IndexDelete.Name)
+		if err != nil {
+			// the deletion fails with ErrIndexNotFound, as a range would
+			return aa.as.IsAnyAdminPermitted(ai)
+		}
+		key, end := namespaceRange(prefix)
+		return aa.as.IsRangeAdminPermitted(ai, key, end)
+	default:
+		return aa.as.IsAdminPermitted(ai)
+	}
+}
+
 func needAdminPermission(r *pb.InternalRaftRequest) bool {
 	switch {
 	case r.AuthEnable != nil:
@@ -241,6 +346,14 @@ func needAdminPermission(r *pb.InternalRaftRequest) bool {
 		return true
 	case r.AuthRoleList != nil:
 		return true
+	case r.NamespacePut != nil:
+		return true
+	case r.NamespaceDelete != nil:
+		return true
+	case r.IndexPut != nil:
+		return true
+	case r.IndexDelete != nil:
+		return true
 	default:
 		return false
 	}
@@ -0,0 +1,61 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+// backupPageSize is the number of changes read and sent at once, so the read
+// transactions stay short on large backups.
+const backupPageSize = 1000
+
+// Backup sends the changes after the revision of the request up to the current
+// revision with send, in revision order. Like HashKV, it reads the local state
+// of the member.
+func (s *EtcdServer) Backup(ctx context.Context, r *pb.BackupRequest, send func(*pb.BackupResponse) error) error {
+	rev := s.KV().Rev()
+	if r.SinceRevision < 0 {
+		return mvcc.ErrCompacted
+	}
+	if r.SinceRevision > rev {
+		return mvcc.ErrFutureRev
+	}
+	sent := false
+	err := mvcc.ForEachChange(s.Backend().ReadTx(), r.SinceRevision, rev, backupPageSize, func(evs []mvccpb.Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp := &pb.BackupResponse{
+			Header:        &pb.ResponseHeader{Revision: rev},
+			SinceRevision: r.SinceRevision,
+			Events:        make([]*mvccpb.Event, len(evs)),
+		}
+		for i := range evs {
+			resp.Events[i] = &evs[i]
+		}
+		sent = true
+		return send(resp)
+	})
+	if err != nil || sent {
+		return err
+	}
+	// the stream always tells the revision of the backup
+	return send(&pb.BackupResponse{Header: &pb.ResponseHeader{Revision: rev}, SinceRevision: r.SinceRevision})
+}
@@ -44,6 +44,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	serverstorage "go.etcd.io/etcd/server/v3/storage"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.etcd.io/etcd/server/v3/storage/wal"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
@@ -204,6 +205,9 @@ func bootstrapBackend(cfg config.ServerConfig, haveWAL bool, st v2store.Store, s
 	beExist := fileutil.Exist(cfg.BackendPath())
 	ci := cindex.NewConsistentIndex(nil)
 	beHooks := serverstorage.NewBackendHooks(cfg.Logger, ci)
+	if cfg.EncryptionKeyProvider != nil {
+		beHooks.SetEncryptionKeyring(encryption.NewKeyring(cfg.EncryptionKeyProvider))
+	}
 	be := serverstorage.OpenBackend(cfg, beHooks)
 	defer func() {
 		if err != nil && be != nil {
@@ -530,7 +534,7 @@ func raftConfig(cfg config.ServerConfig, id uint64, s *raft.MemoryStorage) *raft
 		MaxInflightMsgs: maxInflightMsgs,
 		CheckQuorum:     true,
 		PreVote:         cfg.PreVote,
-		Logger:          NewRaftLoggerZap(cfg.Logger.Named("raft")),
+		Logger:          NewRaftLoggerZap(cfg.ModuleLogger(LogModuleRaft).Named("raft")),
 	}
 }
 
@@ -608,6 +612,14 @@ func openWALFromSnapshot(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (*w
 		if cfg.UnsafeNoFsync {
 			w.SetUnsafeNoFsync()
 		}
+		if cfg.EncryptionKeyProvider != nil {
+			if err = w.Encrypt(cfg.EncryptionKeyProvider); err != nil {
+				cfg.Logger.Fatal("failed to encrypt WAL", zap.Error(err))
+			}
+		}
+		if err = configureWAL(cfg, w); err != nil {
+			cfg.Logger.Fatal("failed to configure WAL", zap.Error(err))
+		}
 		wmetadata, st, ents, err := w.ReadAll()
 		if err != nil {
 			w.Close()
@@ -632,6 +644,19 @@ func openWALFromSnapshot(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (*w
 	}
 }
 
+// configureWAL sets the compression and the segment size of w.
+func configureWAL(cfg config.ServerConfig, w *wal.WAL) error {
+	if cfg.WALCompression {
+		if err := w.Compress(); err != nil {
+			return err
+		}
+	}
+	if cfg.WALSegmentSizeBytes > 0 {
+		return w.SetSegmentSize(cfg.WALSegmentSizeBytes)
+	}
+	return nil
+}
+
 type snapshotMetadata struct {
 	nodeID, clusterID types.ID
 }
@@ -650,6 +675,14 @@ func bootstrapNewWAL(cfg config.ServerConfig, cl *bootstrapedCluster) *bootstrap
 	if cfg.UnsafeNoFsync {
 		w.SetUnsafeNoFsync()
 	}
+	if cfg.EncryptionKeyProvider != nil {
+		if err = w.Encrypt(cfg.EncryptionKeyProvider); err != nil {
+			cfg.Logger.Panic("failed to encrypt WAL", zap.Error(err))
+		}
+	}
+	if err = configureWAL(cfg, w); err != nil {
+		cfg.Logger.Panic("failed to configure WAL", zap.Error(err))
+	}
 	return &bootstrappedWAL{
 		lg: cfg.Logger,
 		w:  w,
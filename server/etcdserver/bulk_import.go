@@ -0,0 +1,109 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+
+	"go.uber.org/zap"
+)
+
+// BulkImport writes the key-value pairs received from recv straight into the
+// backend, bypassing raft, until recv returns io.EOF. As the other members
+// would never see the keys, it is only allowed in a single member cluster;
+// members added later receive them with the snapshot of the backend. The
+// proposals are rejected with ErrImportInProgress until the import is done.
+// If it fails, the keys imported so far are removed.
+func (s *EtcdServer) BulkImport(recv func() (*pb.BulkImportRequest, error)) (*pb.BulkImportResponse, error) {
+	if !atomic.CompareAndSwapInt32(&s.importing, 0, 1) {
+		return nil, ErrImportInProgress
+	}
+	defer atomic.StoreInt32(&s.importing, 0)
+	// wait for the proposals in flight, then for their entries to be applied
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+	if len(s.cluster.Members()) != 1 {
+		return nil, ErrImportNotSingleMember
+	}
+	if s.IsReadOnly() {
+		return nil, ErrReadOnly
+	}
+	if err := s.waitAppliedIndex(); err != nil {
+		return nil, err
+	}
+
+	lg := s.Logger()
+	start := time.Now()
+	var im mvcc.Importer
+	for {
+		r, err := recv()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && im == nil {
+			im, err = s.KV().Import(r.Revision)
+			if err != nil {
+				return nil, err
+			}
+			lg.Info("starting bulk import")
+		}
+		if err == nil {
+			kvs := make([]mvccpb.KeyValue, len(r.Kvs))
+			for i := range r.Kvs {
+				kvs[i] = *r.Kvs[i]
+			}
+			err = im.Import(kvs)
+		}
+		if err != nil {
+			if im != nil {
+				if aerr := im.Abort(); aerr != nil {
+					lg.Warn("failed to abort bulk import", zap.Error(aerr))
+				}
+			}
+			lg.Warn("failed bulk import", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	var count int64
+	if im != nil {
+		var err error
+		if count, err = im.Close(); err != nil {
+			return nil, err
+		}
+		// the imported keys are not checked against the quotas of the
+		// namespaces, but count against them.
+		if err = s.namespaces.recover(s.be, s.kv); err != nil {
+			return nil, err
+		}
+		if err = s.indexes.recover(s.be, s.kv); err != nil {
+			return nil, err
+		}
+	}
+	rev := s.KV().Rev()
+	lg.Info(
+		"finished bulk import",
+		zap.Int64("revision", rev),
+		zap.Int64("count", count),
+		zap.Duration("took", time.Since(start)),
+	)
+	return &pb.BulkImportResponse{Revision: rev, Count: count}, nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3cdc"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// cdcLeaderCheckInterval is the interval between the checks of whether
+	// this member is leader and should publish the events to the sinks.
+	cdcLeaderCheckInterval = time.Second
+	// cdcCheckpointInterval is the interval between the checkpoints of the
+	// revision of the events published to a sink.
+	cdcCheckpointInterval = 5 * time.Second
+	// cdcMaxRetryInterval bounds the exponential backoff between the
+	// attempts to publish a batch of events.
+	cdcMaxRetryInterval = 30 * time.Second
+)
+
+// A cdcSink publishes the events of the key-value store to an external broker
+// while this member is leader. It watches the keys from the revision after
+// its checkpoint, which is proposed through raft once the events up to it are
+// acknowledged by the broker, so that the next leader publishes them again if
+// they are not.
+type cdcSink struct {
+	config.CDCSink
+	sink v3cdc.Sink
+}
+
+func newCDCSinks(lg *zap.Logger, cfgs []config.CDCSink) ([]*cdcSink, error) {
+	sinks := make([]*cdcSink, 0, len(cfgs))
+	for _, c := range cfgs {
+		s, err := v3cdc.NewSink(lg.With(zap.String("cdc-sink", c.Name)), c.URL)
+		if err != nil {
+			for _, sink := range sinks {
+				sink.sink.Close()
+			}
+			return nil, fmt.Errorf("invalid cdc sink %q: %v", c.Name, err)
+		}
+		sinks = append(sinks, &cdcSink{CDCSink: c, sink: s})
+	}
+	return sinks, nil
+}
+
+// runCDCSinks publishes the events to each sink in its own goroutine.
+func (s *EtcdServer) runCDCSinks() {
+	for _, c := range s.cdcSinks {
+		c := c
+		s.GoAttach(func() { s.runCDCSink(c) })
+	}
+}
+
+func (s *EtcdServer) runCDCSink(c *cdcSink) {
+	defer c.sink.Close()
+	lg := s.Logger().With(zap.String("cdc-sink", c.Name))
+	t := time.NewTicker(cdcLeaderCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-s.stopping:
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		lg.Info("publishing events to cdc sink")
+		if err := s.publishCDCEvents(lg, c); err != nil {
+			lg.Warn("stopped publishing events to cdc sink", zap.Error(err))
+			continue
+		}
+		lg.Info("stopped publishing events to cdc sink")
+	}
+}
+
+// publishCDCEvents publishes the events after the checkpoint of the sink until
+// this member is no longer leader.
+func (s *EtcdServer) publishCDCEvents(lg *zap.Logger, c *cdcSink) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	leaderChanged := s.leaderChanged.Receive()
+	go func() {
+		select {
+		case <-leaderChanged:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	rev := schema.ReadCDCCheckpoint(s.Backend().ReadTx(), c.Name)
+	if rev == 0 {
+		// the sink is new, its events are published from now on
+		rev = s.KV().Rev()
+		if err := s.checkpointCDC(ctx, c.Name, rev); err != nil {
+			return err
+		}
+	}
+
+	ws := s.Watchable().NewWatchStream()
+	defer ws.Close()
+	key, end := cdcWatchRange(c.Prefix)
+	if _, err := ws.Watch(mvcc.AutoWatchID, key, end, rev+1); err != nil {
+		return err
+	}
+
+	published := rev
+	// the events published since the last checkpoint are checkpointed once
+	// this member stops publishing, so that the next leader does not publish
+	// them again.
+	defer func() {
+		if published > rev && s.ctx.Err() == nil {
+			if err := s.checkpointCDC(s.ctx, c.Name, published); err != nil {
+				lg.Warn("failed to checkpoint cdc sink", zap.Int64("revision", published), zap.Error(err))
+			}
+		}
+	}()
+	t := time.NewTicker(cdcCheckpointInterval)
+	defer t.Stop()
+	for {
+		select {
+		case resp := <-ws.Chan():
+			if resp.CompactRevision != 0 {
+				// the events up to the compaction are lost
+				cdcSkippedRevisions.WithLabelValues(c.Name).Add(float64(resp.CompactRevision - 1 - published))
+				lg.Error(
+					"events of cdc sink compacted before they were published",
+					zap.Int64("published-revision", published),
+					zap.Int64("compact-revision", resp.CompactRevision),
+				)
+				published = resp.CompactRevision - 1
+				return nil
+			}
+			if err := c.publish(ctx, lg, resp.Events); err != nil {
+				// no longer leader
+				return nil
+			}
+			if n := len(resp.Events); n > 0 {
+				published = resp.Events[n-1].Kv.ModRevision
+			}
+		case <-t.C:
+			if !s.isLeader() {
+				return nil
+			}
+			if published > rev {
+				if err := s.checkpointCDC(ctx, c.Name, published); err != nil {
+					return err
+				}
+				rev = published
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// publish publishes the events in batches of at most BatchSize events,
+// retrying a batch until it is acknowledged. It only fails once ctx is done.
+func (c *cdcSink) publish(ctx context.Context, lg *zap.Logger, evs []mvccpb.Event) error {
+	for len(evs) > 0 {
+		n := len(evs)
+		if n > c.BatchSize {
+			n = c.BatchSize
+		}
+		batch := make([]v3cdc.Event, n)
+		for i := range batch {
+			batch[i] = v3cdc.NewEvent(evs[i])
+		}
+		for wait := cdcLeaderCheckInterval; ; wait *= 2 {
+			err := c.sink.Publish(ctx, batch)
+			if err == nil {
+				break
+			}
+			cdcPublishFailures.WithLabelValues(c.Name).Inc()
+			lg.Warn(
+				"failed to publish events to cdc sink",
+				zap.Int64("from-revision", batch[0].Revision),
+				zap.Int64("to-revision", batch[n-1].Revision),
+				zap.Duration("retry-in", wait),
+				zap.Error(err),
+			)
+			if wait > cdcMaxRetryInterval {
+				wait = cdcMaxRetryInterval
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		cdcEventsPublished.WithLabelValues(c.Name).Add(float64(n))
+		evs = evs[n:]
+	}
+	return nil
+}
+
+func (s *EtcdServer) checkpointCDC(ctx context.Context, name string, rev int64) error {
+	ctx, cancel := context.WithTimeout(ctx, s.Cfg.ReqTimeout())
+	defer cancel()
+	_, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{CdcCheckpoint: &pb.CDCCheckpointRequest{Sink: name, Revision: rev}})
+	return err
+}
+
+// updateCDCCheckpoint saves the checkpoint of a sink unless it is behind the
+// saved one, e.g. if proposed by a former leader.
+func (s *EtcdServer) updateCDCCheckpoint(name string, rev int64) {
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	updated := schema.UnsafeUpdateCDCCheckpoint(tx, name, rev)
+	tx.Unlock()
+	if updated {
+		cdcCheckpointRevision.WithLabelValues(name).Set(float64(rev))
+	}
+}
+
+// cdcWatchRange returns the range of the keys of prefix to watch, all the
+// keys if it is empty.
+func cdcWatchRange(prefix string) (key, end []byte) {
+	if prefix == "" {
+		return []byte{0}, []byte{}
+	}
+	if end = prefixEnd([]byte(prefix)); end == nil {
+		// the keys from prefix
+		end = []byte{}
+	}
+	return []byte(prefix), end
+}
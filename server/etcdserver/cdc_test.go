@@ -0,0 +1,118 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3cdc"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// flakySink fails every other attempt to publish a batch.
+type flakySink struct {
+	attempts int
+	batches  [][]int64
+}
+
+func (s *flakySink) Publish(ctx context.Context, evs []v3cdc.Event) error {
+	if s.attempts++; s.attempts%2 == 1 {
+		return errors.New("broker unavailable")
+	}
+	var revs []int64
+	for _, ev := range evs {
+		revs = append(revs, ev.Revision)
+	}
+	s.batches = append(s.batches, revs)
+	return nil
+}
+
+func (s *flakySink) Close() error { return nil }
+
+func TestCDCSinkPublish(t *testing.T) {
+	defer func(d time.Duration) { cdcLeaderCheckInterval = d }(cdcLeaderCheckInterval)
+	cdcLeaderCheckInterval = time.Millisecond
+
+	sink := &flakySink{}
+	c := &cdcSink{CDCSink: config.CDCSink{Name: "test", BatchSize: 2}, sink: sink}
+	var evs []mvccpb.Event
+	for rev := int64(2); rev <= 6; rev++ {
+		evs = append(evs, mvccpb.Event{Kv: &mvccpb.KeyValue{Key: []byte("foo"), ModRevision: rev}})
+	}
+	if err := c.publish(context.TODO(), zaptest.NewLogger(t), evs); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]int64{{2, 3}, {4, 5}, {6}}
+	if !reflect.DeepEqual(sink.batches, want) {
+		t.Errorf("expected batches %v, got %v", want, sink.batches)
+	}
+
+	// the batches are retried until ctx is done
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	c.sink = alwaysFailingSink{}
+	if err := c.publish(ctx, zaptest.NewLogger(t), evs); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+type alwaysFailingSink struct{}
+
+func (alwaysFailingSink) Publish(ctx context.Context, evs []v3cdc.Event) error {
+	return errors.New("broker unavailable")
+}
+func (alwaysFailingSink) Close() error { return nil }
+
+func TestUpdateCDCCheckpoint(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	s := &EtcdServer{be: be}
+
+	s.updateCDCCheckpoint("test", 10)
+	// a checkpoint proposed by a former leader is ignored
+	s.updateCDCCheckpoint("test", 5)
+	if rev := schema.ReadCDCCheckpoint(be.ReadTx(), "test"); rev != 10 {
+		t.Errorf("expected checkpoint 10, got %d", rev)
+	}
+	if rev := schema.ReadCDCCheckpoint(be.ReadTx(), "other"); rev != 0 {
+		t.Errorf("expected no checkpoint, got %d", rev)
+	}
+}
+
+func TestCDCWatchRange(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		key, end string
+	}{
+		{prefix: "", key: "\x00", end: ""},
+		{prefix: "/orders/", key: "/orders/", end: "/orders0"},
+		{prefix: "\xff", key: "\xff", end: ""},
+	}
+	for _, tt := range tests {
+		key, end := cdcWatchRange(tt.prefix)
+		if string(key) != tt.key || string(end) != tt.end || end == nil {
+			t.Errorf("%q: expected [%q, %q), got [%q, %q)", tt.prefix, tt.key, tt.end, key, end)
+		}
+	}
+}
@@ -351,6 +351,39 @@ func getDowngradeEnabledFromRemotePeers(lg *zap.Logger, cl *membership.RaftClust
 	return false
 }
 
+// getFollowerLag returns the number of entries the slowest voting member lags
+// behind the given leader, via its peerURLs. Returns the last error if it fails
+// to get it.
+func getFollowerLag(m *membership.Member, rt http.RoundTripper, timeout time.Duration) (uint64, error) {
+	cc := &http.Client{
+		Transport: rt,
+		Timeout:   timeout,
+	}
+	err := fmt.Errorf("no peer URL")
+	for _, u := range m.PeerURLs {
+		var resp *http.Response
+		if resp, err = cc.Get(u + FollowerLagPath); err != nil {
+			continue
+		}
+		var b []byte
+		b, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected status %q: %s", resp.Status, strings.TrimSpace(string(b)))
+			continue
+		}
+		var lag uint64
+		if lag, err = strconv.ParseUint(string(b), 10, 64); err != nil {
+			continue
+		}
+		return lag, nil
+	}
+	return 0, err
+}
+
 // getDowngradeEnabled returns the downgrade enabled status of the given member
 // via its peerURLs. Returns the last error if it fails to get it.
 func getDowngradeEnabled(lg *zap.Logger, m *membership.Member, rt http.RoundTripper, timeout time.Duration) (bool, error) {
@@ -0,0 +1,192 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+	serverstorage "go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+const (
+	// quotaUsageWarnRatio is the share of the quota the database may grow to
+	// before raising the quota is advised.
+	quotaUsageWarnRatio = 0.8
+	// autoCompactionRetention is the retention advised when enabling
+	// auto compaction.
+	autoCompactionRetention = time.Hour
+	// largeDbSize is the database size from which sending snapshots to
+	// followers gets expensive enough to take them less often.
+	largeDbSize = 1024 * 1024 * 1024
+	// minSnapshotCountLargeDb is the lowest snapshot count advised for
+	// large databases.
+	minSnapshotCountLargeDb = 10000
+	// manyWatches is the number of watches from which frequent progress
+	// notifications are a noticeable load.
+	manyWatches = 1000
+	// minProgressNotifyIntervalManyWatches is the lowest progress notify
+	// interval advised with many watches.
+	minProgressNotifyIntervalManyWatches = time.Minute
+	// minElectionHeartbeatRatio is the lowest ratio of the election timeout
+	// to the heartbeat interval that tolerates a few lost heartbeats.
+	minElectionHeartbeatRatio = 5
+	// electionHeartbeatRatio is the ratio of the election timeout to the
+	// heartbeat interval advised when changing either.
+	electionHeartbeatRatio = 10
+)
+
+// configStats are the runtime stats of the member the configuration advice
+// is based on.
+type configStats struct {
+	dbSize      int64
+	dbSizeInUse int64
+	keys        int64
+	watches     int64
+	// walFsyncP99 is the 99th percentile of the WAL fsync duration.
+	walFsyncP99 time.Duration
+}
+
+// ConfigAdvice analyzes the runtime stats of the member against its
+// configuration and recommends configuration changes.
+func (s *EtcdServer) ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest) (*pb.ConfigAdviceResponse, error) {
+	rr, err := s.KV().Range(ctx, []byte{0}, []byte{}, mvcc.RangeOptions{Count: true})
+	if err != nil {
+		return nil, err
+	}
+	st := configStats{
+		dbSize:      s.Backend().Size(),
+		dbSizeInUse: s.Backend().SizeInUse(),
+		keys:        int64(rr.Count),
+		watches:     int64(s.WatchConsumers().Len()),
+		walFsyncP99: histogramQuantile("etcd_disk_wal_fsync_duration_seconds", 0.99),
+	}
+	return &pb.ConfigAdviceResponse{
+		DbSize:             st.dbSize,
+		DbSizeInUse:        st.dbSizeInUse,
+		Keys:               st.keys,
+		Watches:            st.watches,
+		WalFsyncP99Seconds: st.walFsyncP99.Seconds(),
+		Recommendations:    adviseConfig(s.Cfg, st),
+	}, nil
+}
+
+// adviseConfig returns the changes to cfg advised for a member with the
+// given stats.
+func adviseConfig(cfg config.ServerConfig, st configStats) []*pb.ConfigRecommendation {
+	var recs []*pb.ConfigRecommendation
+	add := func(parameter, current, recommended, reason string, args ...interface{}) {
+		recs = append(recs, &pb.ConfigRecommendation{
+			Parameter:        parameter,
+			CurrentValue:     current,
+			RecommendedValue: recommended,
+			Reason:           fmt.Sprintf(reason, args...),
+		})
+	}
+
+	quota := cfg.QuotaBackendBytes
+	if quota == 0 {
+		quota = serverstorage.DefaultQuotaBytes
+	}
+	if quota > 0 {
+		current := fmt.Sprint(cfg.QuotaBackendBytes)
+		switch {
+		case quota > serverstorage.MaxQuotaBytes:
+			add("quota-backend-bytes", current, fmt.Sprint(serverstorage.MaxQuotaBytes),
+				"the quota exceeds the maximum suggested size of %s", humanize.Bytes(uint64(serverstorage.MaxQuotaBytes)))
+		case float64(st.dbSizeInUse) > quotaUsageWarnRatio*float64(quota):
+			recommended := 2 * quota
+			if recommended > serverstorage.MaxQuotaBytes {
+				recommended = serverstorage.MaxQuotaBytes
+			}
+			if recommended > quota {
+				add("quota-backend-bytes", current, fmt.Sprint(recommended),
+					"%s of the %s quota are in use, the member raises a NOSPACE alarm once it is exhausted",
+					humanize.Bytes(uint64(st.dbSizeInUse)), humanize.Bytes(uint64(quota)))
+			}
+			if cfg.AutoCompactionRetention == 0 {
+				add("auto-compaction-retention", "0", autoCompactionRetention.String(),
+					"the database is close to the quota and its history is never compacted")
+			}
+		}
+	}
+
+	if cfg.SnapshotCount > DefaultSnapshotCount {
+		add("snapshot-count", fmt.Sprint(cfg.SnapshotCount), fmt.Sprint(DefaultSnapshotCount),
+			"the raft log of up to %d entries is kept in memory between snapshots", cfg.SnapshotCount)
+	} else if st.dbSize > largeDbSize && cfg.SnapshotCount < minSnapshotCountLargeDb {
+		add("snapshot-count", fmt.Sprint(cfg.SnapshotCount), fmt.Sprint(minSnapshotCountLargeDb),
+			"snapshots of the %s database are expensive to send to slow followers, take them less often",
+			humanize.Bytes(uint64(st.dbSize)))
+	}
+
+	heartbeat := time.Duration(cfg.TickMs) * time.Millisecond
+	if st.walFsyncP99 > heartbeat {
+		// heartbeats are delayed by the fsyncs of the leader
+		recommendedMs := int64(math.Ceil(float64(2*st.walFsyncP99) / float64(time.Millisecond)))
+		add("heartbeat-interval", fmt.Sprint(cfg.TickMs), fmt.Sprint(recommendedMs),
+			"the 99th percentile of WAL fsync durations, %v, exceeds the heartbeat interval", st.walFsyncP99)
+		add("election-timeout", fmt.Sprint(cfg.ElectionTimeout().Milliseconds()), fmt.Sprint(electionHeartbeatRatio*recommendedMs),
+			"the election timeout should be %d times the heartbeat interval", electionHeartbeatRatio)
+	} else if cfg.ElectionTicks < minElectionHeartbeatRatio {
+		add("election-timeout", fmt.Sprint(cfg.ElectionTimeout().Milliseconds()), fmt.Sprint(electionHeartbeatRatio*int64(cfg.TickMs)),
+			"an election timeout of less than %d heartbeat intervals triggers elections when a few heartbeats are late",
+			minElectionHeartbeatRatio)
+	}
+
+	if st.watches >= manyWatches && cfg.WatchProgressNotifyInterval > 0 && cfg.WatchProgressNotifyInterval < minProgressNotifyIntervalManyWatches {
+		add("experimental-watch-progress-notify-interval", cfg.WatchProgressNotifyInterval.String(), minProgressNotifyIntervalManyWatches.String(),
+			"progress notifications are sent to each of the %d watches every interval", st.watches)
+	}
+	return recs
+}
+
+// histogramQuantile approximates the q quantile of the histogram of durations
+// in seconds registered by name with the upper bound of the bucket it falls in. It returns 0 if the
+// histogram is not registered or empty.
+func histogramQuantile(name string, q float64) time.Duration {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return 0
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != name || mf.GetType() != dto.MetricType_HISTOGRAM || len(mf.Metric) == 0 {
+			continue
+		}
+		h := mf.Metric[0].GetHistogram()
+		rank := q * float64(h.GetSampleCount())
+		if rank == 0 {
+			return 0
+		}
+		bound := 0.0
+		for _, b := range h.GetBucket() {
+			bound = b.GetUpperBound()
+			if float64(b.GetCumulativeCount()) >= rank {
+				break
+			}
+		}
+		return time.Duration(bound * float64(time.Second))
+	}
+	return 0
+}
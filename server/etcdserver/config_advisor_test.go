@@ -0,0 +1,128 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+func TestAdviseConfig(t *testing.T) {
+	defaultCfg := config.ServerConfig{
+		SnapshotCount: DefaultSnapshotCount,
+		TickMs:        100,
+		ElectionTicks: 10,
+	}
+	tcs := []struct {
+		name  string
+		cfg   func(cfg *config.ServerConfig)
+		stats configStats
+		// want maps the advised parameters to their recommended value
+		want map[string]string
+	}{
+		{
+			name: "Healthy",
+			stats: configStats{
+				dbSize:      100 * 1024 * 1024,
+				dbSizeInUse: 100 * 1024 * 1024,
+				walFsyncP99: 8 * time.Millisecond,
+			},
+			want: map[string]string{},
+		},
+		{
+			name:  "QuotaAboveMax",
+			cfg:   func(cfg *config.ServerConfig) { cfg.QuotaBackendBytes = 16 * 1024 * 1024 * 1024 },
+			stats: configStats{dbSizeInUse: 1024},
+			want:  map[string]string{"quota-backend-bytes": "8589934592"},
+		},
+		{
+			name:  "DefaultQuotaAlmostFull",
+			stats: configStats{dbSize: 1900 * 1024 * 1024, dbSizeInUse: 1800 * 1024 * 1024},
+			want: map[string]string{
+				"quota-backend-bytes":       "4294967296",
+				"auto-compaction-retention": "1h0m0s",
+			},
+		},
+		{
+			name: "QuotaAlmostFullWithAutoCompaction",
+			cfg: func(cfg *config.ServerConfig) {
+				cfg.QuotaBackendBytes = 1024 * 1024
+				cfg.AutoCompactionRetention = time.Minute
+			},
+			stats: configStats{dbSizeInUse: 1000 * 1024},
+			want:  map[string]string{"quota-backend-bytes": "2097152"},
+		},
+		{
+			name:  "QuotaDisabled",
+			cfg:   func(cfg *config.ServerConfig) { cfg.QuotaBackendBytes = -1 },
+			stats: configStats{dbSizeInUse: 16 * 1024 * 1024 * 1024},
+			want:  map[string]string{},
+		},
+		{
+			name: "HighSnapshotCount",
+			cfg:  func(cfg *config.ServerConfig) { cfg.SnapshotCount = 1000000 },
+			want: map[string]string{"snapshot-count": "100000"},
+		},
+		{
+			name:  "LowSnapshotCountLargeDb",
+			cfg:   func(cfg *config.ServerConfig) { cfg.SnapshotCount = 100 },
+			stats: configStats{dbSize: 2 * 1024 * 1024 * 1024, dbSizeInUse: 1024},
+			want:  map[string]string{"snapshot-count": "10000"},
+		},
+		{
+			name:  "SlowFsync",
+			stats: configStats{walFsyncP99: 256 * time.Millisecond},
+			want: map[string]string{
+				"heartbeat-interval": "512",
+				"election-timeout":   "5120",
+			},
+		},
+		{
+			name: "ShortElectionTimeout",
+			cfg:  func(cfg *config.ServerConfig) { cfg.ElectionTicks = 3 },
+			want: map[string]string{"election-timeout": "1000"},
+		},
+		{
+			name:  "FrequentProgressNotifyManyWatches",
+			cfg:   func(cfg *config.ServerConfig) { cfg.WatchProgressNotifyInterval = time.Second },
+			stats: configStats{watches: 5000},
+			want:  map[string]string{"experimental-watch-progress-notify-interval": "1m0s"},
+		},
+		{
+			name:  "FrequentProgressNotifyFewWatches",
+			cfg:   func(cfg *config.ServerConfig) { cfg.WatchProgressNotifyInterval = time.Second },
+			stats: configStats{watches: 10},
+			want:  map[string]string{},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultCfg
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+			got := map[string]string{}
+			for _, rec := range adviseConfig(cfg, tc.stats) {
+				assert.NotEmpty(t, rec.Reason)
+				got[rec.Parameter] = rec.RecommendedValue
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
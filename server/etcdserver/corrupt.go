@@ -37,7 +37,8 @@ import (
 // before serving any peer/client traffic. Only mismatch when hashes
 // are different at requested revision, with same compact revision.
 func (s *EtcdServer) CheckInitialHashKV() error {
-	if !s.Cfg.InitialCorruptCheck {
+	// witnesses store no key-value data to compare.
+	if !s.Cfg.InitialCorruptCheck || s.IsWitness() {
 		return nil
 	}
 
@@ -262,7 +263,7 @@ func (s *EtcdServer) getPeerHashKVs(rev int64) []*peerHashKVResp {
 	members := s.cluster.Members()
 	peers := make([]peerInfo, 0, len(members))
 	for _, m := range members {
-		if m.ID == s.ID() {
+		if m.ID == s.ID() || m.IsWitness {
 			continue
 		}
 		peers = append(peers, peerInfo{id: m.ID, eps: m.PeerURLs})
@@ -325,7 +326,7 @@ func (a *applierV3Corrupt) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnR
 	return nil, nil, ErrCorrupt
 }
 
-func (a *applierV3Corrupt) Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
+func (a *applierV3Corrupt) Compaction(compaction *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
 	return nil, nil, nil, ErrCorrupt
 }
 
@@ -337,6 +338,10 @@ func (a *applierV3Corrupt) LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevo
 	return nil, ErrCorrupt
 }
 
+func (a *applierV3Corrupt) LeaseExpire(lc *pb.LeaseExpireRequest) (*pb.LeaseExpireResponse, error) {
+	return nil, ErrCorrupt
+}
+
 const PeerHashKVPath = "/members/hashkv"
 
 type hashKVHandler struct {
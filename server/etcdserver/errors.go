@@ -35,6 +35,7 @@ var (
 	ErrNotLeader                   = errors.New("etcdserver: not leader")
 	ErrRequestTooLarge             = errors.New("etcdserver: request is too large")
 	ErrNoSpace                     = errors.New("etcdserver: no space")
+	ErrReadOnly                    = errors.New("etcdserver: cluster is in read-only mode")
 	ErrTooManyRequests             = errors.New("etcdserver: too many requests")
 	ErrUnhealthy                   = errors.New("etcdserver: unhealthy cluster")
 	ErrKeyNotFound                 = errors.New("etcdserver: key not found")
@@ -42,6 +43,21 @@ var (
 	ErrBadLeaderTransferee         = errors.New("etcdserver: bad leader transferee")
 	ErrClusterVersionUnavailable   = errors.New("etcdserver: cluster version not found during downgrade")
 	ErrWrongDowngradeVersionFormat = errors.New("etcdserver: wrong downgrade target version format")
+	ErrImportNotSingleMember       = errors.New("etcdserver: bulk import requires a single member cluster")
+	ErrImportInProgress            = errors.New("etcdserver: bulk import in progress")
+	ErrRangeRateLimitExceeded      = errors.New("etcdserver: range rate limit exceeded")
+	ErrNamespaceEmpty              = errors.New("etcdserver: namespace prefix is empty")
+	ErrNamespaceNotFound           = errors.New("etcdserver: namespace not found")
+	ErrNamespaceQuotaExceeded      = errors.New("etcdserver: namespace quota exceeded")
+	ErrNamespaceLeaseTTLTooLarge   = errors.New("etcdserver: lease TTL exceeds the namespace limit")
+	ErrIndexNameEmpty              = errors.New("etcdserver: index name is empty")
+	ErrIndexFieldInvalid           = errors.New("etcdserver: invalid index field path")
+	ErrIndexNotFound               = errors.New("etcdserver: index not found")
+	ErrRequestIDTooLong            = errors.New("etcdserver: request id is too long")
+	ErrRequestIDConflict           = errors.New("etcdserver: request id is already used by a different request")
+	ErrUnknownLogModule            = errors.New("etcdserver: unknown log module")
+	ErrInvalidLogLevel             = errors.New("etcdserver: invalid log level")
+	ErrLogLevelsUnconfigured       = errors.New("etcdserver: log levels are not configurable")
 )
 
 type DiscoveryError struct {
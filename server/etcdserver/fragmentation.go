@@ -0,0 +1,61 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// defaultMinReclaimableRatio is the part of the backend size defragmentation
+// must reclaim to be recommended, if the request sets none.
+const defaultMinReclaimableRatio = 0.25
+
+// Fragmentation reports how the pages of the backend of the member are used
+// and estimates the space its defragmentation would reclaim.
+func (s *EtcdServer) Fragmentation(ctx context.Context, r *pb.FragmentationRequest) (*pb.FragmentationResponse, error) {
+	ps, err := s.Backend().PageStats()
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.FragmentationResponse{
+		DbSize:              ps.Size,
+		PageSize:            int64(ps.PageSize),
+		FreePages:           int64(ps.FreePages),
+		PendingPages:        int64(ps.PendingPages),
+		FreelistBytes:       int64(ps.FreelistBytes),
+		EstimatedDefragSize: ps.DefragSize(),
+	}
+	for _, bs := range ps.Buckets {
+		resp.Buckets = append(resp.Buckets, &pb.BucketPages{
+			Name:           []byte(bs.Name),
+			Keys:           int64(bs.Keys),
+			BranchPages:    int64(bs.BranchPages),
+			LeafPages:      int64(bs.LeafPages),
+			BytesInUse:     int64(bs.InuseBytes),
+			BytesAllocated: int64(bs.AllocBytes),
+			FillRatio:      bs.FillRatio(),
+		})
+	}
+	resp.ReclaimableBytes = resp.DbSize - resp.EstimatedDefragSize
+
+	ratio := r.MinReclaimableRatio
+	if ratio <= 0 {
+		ratio = defaultMinReclaimableRatio
+	}
+	resp.DefragRecommended = resp.DbSize > 0 && float64(resp.ReclaimableBytes) >= ratio*float64(resp.DbSize)
+	return resp, nil
+}
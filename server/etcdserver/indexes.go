@@ -0,0 +1,399 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	"github.com/google/btree"
+	"go.uber.org/zap"
+)
+
+// indexScanLimit is the number of keys read at once when an index is built.
+const indexScanLimit = 1000
+
+// indexStore keeps the secondary indexes of the values of the keys. They are
+// updated with the events of the writes to the key-value store while they are
+// applied, before the writes are visible, so that all the members index the
+// keys alike and a lookup reads the keys at the revision of the indexes.
+type indexStore struct {
+	lg *zap.Logger
+	be backend.Backend
+	kv mvcc.KV
+
+	mu sync.RWMutex
+	// indexes are sorted by name.
+	indexes []*index
+	// rev is the revision of the last write the indexes are updated with.
+	rev int64
+	// visibleRev is the revision of the last write that ended, whose updates
+	// of the indexes are visible in the key-value store.
+	visibleRev int64
+	// visible is signaled with mu read locked when visibleRev advances.
+	visible *sync.Cond
+}
+
+type index struct {
+	*pb.Index
+	path []string
+	// entries are the indexed values with their keys, sorted by value then
+	// by key.
+	entries *btree.BTree
+	// values are the indexed values of the keys.
+	values map[string]string
+	// counts are the number of keys of the indexed values.
+	counts map[string]int
+}
+
+type indexEntry struct {
+	value, key string
+}
+
+func (e indexEntry) Less(than btree.Item) bool {
+	o := than.(indexEntry)
+	if e.value != o.value {
+		return e.value < o.value
+	}
+	return e.key < o.key
+}
+
+func newIndexStore(lg *zap.Logger, be backend.Backend) *indexStore {
+	ix := &indexStore{lg: lg, be: be}
+	ix.visible = sync.NewCond(ix.mu.RLocker())
+	return ix
+}
+
+// parseIndexField returns the names of the path of a field, e.g. "spec.owner".
+func parseIndexField(field string) ([]string, error) {
+	path := strings.Split(field, ".")
+	for _, name := range path {
+		if name == "" {
+			return nil, ErrIndexFieldInvalid
+		}
+	}
+	return path, nil
+}
+
+func newIndex(r *pb.Index) (*index, error) {
+	path, err := parseIndexField(r.Field)
+	if err != nil {
+		return nil, err
+	}
+	return &index{
+		Index:   r,
+		path:    path,
+		entries: btree.New(32),
+		values:  make(map[string]string),
+		counts:  make(map[string]int),
+	}, nil
+}
+
+// extractIndexValue returns the value of the field at path of a JSON object
+// as text, and whether the field is a string, a number or a boolean.
+func extractIndexValue(v []byte, path []string) (string, bool) {
+	if v = bytes.TrimLeft(v, " \t\r\n"); len(v) == 0 || v[0] != '{' {
+		return "", false
+	}
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.UseNumber()
+	var field interface{}
+	if err := dec.Decode(&field); err != nil {
+		return "", false
+	}
+	for _, name := range path {
+		obj, ok := field.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if field, ok = obj[name]; !ok {
+			return "", false
+		}
+	}
+	switch f := field.(type) {
+	case string:
+		return f, true
+	case json.Number:
+		return f.String(), true
+	case bool:
+		return strconv.FormatBool(f), true
+	default:
+		return "", false
+	}
+}
+
+func (idx *index) contains(key []byte) bool {
+	return bytes.HasPrefix(key, idx.Prefix)
+}
+
+// update indexes the value of key, or unindexes the key if it has no value.
+func (idx *index) update(key string, value string, ok bool) {
+	if old, existed := idx.values[key]; existed {
+		if ok && old == value {
+			return
+		}
+		idx.entries.Delete(indexEntry{value: old, key: key})
+		delete(idx.values, key)
+		if idx.counts[old]--; idx.counts[old] == 0 {
+			delete(idx.counts, old)
+		}
+	}
+	if !ok {
+		return
+	}
+	idx.entries.ReplaceOrInsert(indexEntry{value: value, key: key})
+	idx.values[key] = value
+	idx.counts[value]++
+}
+
+func (idx *index) observe(ev mvccpb.Event) {
+	switch ev.Type {
+	case mvccpb.PUT:
+		value, ok := extractIndexValue(ev.Kv.Value, idx.path)
+		idx.update(string(ev.Kv.Key), value, ok)
+	case mvccpb.DELETE:
+		idx.update(string(ev.Kv.Key), "", false)
+	}
+}
+
+// build indexes the keys of the prefix of the index as of the read txn.
+func (idx *index) build(txn mvcc.TxnRead) error {
+	key, end := namespaceRange(idx.Prefix)
+	for {
+		rr, err := txn.Range(context.TODO(), key, end, mvcc.RangeOptions{Limit: indexScanLimit})
+		if err != nil {
+			return err
+		}
+		for i := range rr.KVs {
+			kv := &rr.KVs[i]
+			value, ok := extractIndexValue(kv.Value, idx.path)
+			idx.update(string(kv.Key), value, ok)
+		}
+		if len(rr.KVs) < indexScanLimit {
+			return nil
+		}
+		key = append(append([]byte{}, rr.KVs[len(rr.KVs)-1].Key...), 0)
+	}
+}
+
+// recover loads the indexes from the backend and builds them from kv, e.g.
+// once the backend is restored from a snapshot.
+func (ix *indexStore) recover(be backend.Backend, kv mvcc.KV) error {
+	ib := schema.NewIndexBackend(ix.lg, be)
+	ib.CreateIndexBucket()
+	saved, err := ib.GetAllIndexes()
+	if err != nil {
+		return err
+	}
+
+	txn := kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+	defer txn.End()
+	var idxs []*index
+	for _, s := range saved {
+		idx, err := newIndex(s)
+		if err != nil {
+			return err
+		}
+		if err = idx.build(txn); err != nil {
+			return err
+		}
+		idxs = append(idxs, idx)
+	}
+	sort.Slice(idxs, func(i, j int) bool { return idxs[i].Name < idxs[j].Name })
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.be, ix.kv = be, kv
+	ix.indexes, ix.rev, ix.visibleRev = idxs, txn.Rev(), txn.Rev()
+	ix.visible.Broadcast()
+	return nil
+}
+
+// observe updates the indexes with the events of a write to the key-value
+// store.
+func (ix *indexStore) observe(evs []mvccpb.Event) {
+	if ix == nil || len(evs) == 0 {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.rev = evs[0].Kv.ModRevision
+	for _, idx := range ix.indexes {
+		for _, ev := range evs {
+			if idx.contains(ev.Kv.Key) {
+				idx.observe(ev)
+			}
+		}
+	}
+}
+
+// observeEnd records that the write of revision rev the indexes are updated
+// with ended, so that the lookups waiting for it can read it.
+func (ix *indexStore) observeEnd(rev int64) {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if rev > ix.visibleRev {
+		ix.visibleRev = rev
+		ix.visible.Broadcast()
+	}
+}
+
+// search returns the position of the index name.
+func (ix *indexStore) search(name string) (int, bool) {
+	i := sort.Search(len(ix.indexes), func(i int) bool { return ix.indexes[i].Name >= name })
+	return i, i < len(ix.indexes) && ix.indexes[i].Name == name
+}
+
+// put registers an index, or replaces the one with the same name. It is
+// called by the apply loop, so the key-value store does not change while the
+// index is built.
+func (ix *indexStore) put(r *pb.Index) error {
+	if r.Name == "" {
+		return ErrIndexNameEmpty
+	}
+	idx, err := newIndex(r)
+	if err != nil {
+		return err
+	}
+	txn := ix.kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+	err = idx.build(txn)
+	txn.End()
+	if err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	schema.NewIndexBackend(ix.lg, ix.be).MustPutIndex(r)
+	i, found := ix.search(r.Name)
+	if found {
+		ix.indexes[i] = idx
+		return nil
+	}
+	ix.indexes = append(ix.indexes, nil)
+	copy(ix.indexes[i+1:], ix.indexes[i:])
+	ix.indexes[i] = idx
+	return nil
+}
+
+// delete unregisters the index name.
+func (ix *indexStore) delete(name string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	i, found := ix.search(name)
+	if !found {
+		return ErrIndexNotFound
+	}
+	schema.NewIndexBackend(ix.lg, ix.be).MustDeleteIndex(name)
+	ix.indexes = append(ix.indexes[:i], ix.indexes[i+1:]...)
+	return nil
+}
+
+// list returns the indexes with the number of keys they index, sorted by
+// name.
+func (ix *indexStore) list() []*pb.IndexUsage {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	usages := make([]*pb.IndexUsage, len(ix.indexes))
+	for i, idx := range ix.indexes {
+		usages[i] = &pb.IndexUsage{Index: idx.Index, IndexedKeys: int64(len(idx.values)), DistinctValues: int64(len(idx.counts))}
+	}
+	return usages
+}
+
+// prefix returns the prefix of the keys of the index name.
+func (ix *indexStore) prefix(name string) ([]byte, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	i, found := ix.search(name)
+	if !found {
+		return nil, ErrIndexNotFound
+	}
+	return ix.indexes[i].Prefix, nil
+}
+
+// lookup returns the keys of the index with a value in the range of the
+// request, at most limit of them if not zero, with the total number of keys
+// in the range and the read txn of the key-value store at the revision of
+// the index to read them with. The txn must be ended by the caller.
+func (ix *indexStore) lookup(r *pb.IndexRangeRequest, trace *traceutil.Trace) (keys []string, count int64, txn mvcc.TxnRead, err error) {
+	for {
+		// the read txn is opened first, as the writes updating the indexes
+		// hold the key-value store until they end.
+		txn = ix.kv.Read(mvcc.ConcurrentReadTxMode, trace)
+		ix.mu.RLock()
+		if ix.rev > txn.Rev() {
+			// the write the indexes are updated with is not visible yet, wait
+			// for it to end without holding the key-value store.
+			txn.End()
+			for ix.visibleRev < ix.rev {
+				ix.visible.Wait()
+			}
+			ix.mu.RUnlock()
+			continue
+		}
+		i, found := ix.search(r.Name)
+		if !found {
+			ix.mu.RUnlock()
+			txn.End()
+			return nil, 0, nil, ErrIndexNotFound
+		}
+		keys, count = ix.indexes[i].lookup(r)
+		ix.mu.RUnlock()
+		return keys, count, txn, nil
+	}
+}
+
+func (idx *index) lookup(r *pb.IndexRangeRequest) (keys []string, count int64) {
+	value := string(r.Value)
+	visit := func(item btree.Item) bool {
+		count++
+		if !r.CountOnly && (r.Limit <= 0 || int64(len(keys)) < r.Limit) {
+			keys = append(keys, item.(indexEntry).key)
+		}
+		return true
+	}
+	from := indexEntry{value: value}
+	switch {
+	case len(r.RangeEnd) == 0:
+		idx.entries.AscendGreaterOrEqual(from, func(item btree.Item) bool {
+			return item.(indexEntry).value == value && visit(item)
+		})
+	case bytes.Equal(r.RangeEnd, []byte{0}):
+		if bytes.Equal(r.Value, []byte{0}) {
+			// all the values, as all the keys of a RangeRequest
+			from = indexEntry{}
+		}
+		idx.entries.AscendGreaterOrEqual(from, visit)
+	default:
+		idx.entries.AscendRange(from, indexEntry{value: string(r.RangeEnd)}, visit)
+	}
+	return keys, count
+}
@@ -0,0 +1,251 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestExtractIndexValue(t *testing.T) {
+	tcs := []struct {
+		value string
+		field string
+		want  string
+		ok    bool
+	}{
+		{value: `{"owner":"alice"}`, field: "owner", want: "alice", ok: true},
+		{value: ` {"spec":{"owner":"bob"}}`, field: "spec.owner", want: "bob", ok: true},
+		{value: `{"size":1.50}`, field: "size", want: "1.50", ok: true},
+		{value: `{"ready":true}`, field: "ready", want: "true", ok: true},
+		{value: `{"owner":null}`, field: "owner"},
+		{value: `{"owner":{"name":"alice"}}`, field: "owner"},
+		{value: `{"spec":["owner"]}`, field: "spec.owner"},
+		{value: `{"name":"alice"}`, field: "owner"},
+		{value: `["owner"]`, field: "owner"},
+		{value: `owner`, field: "owner"},
+		{value: ``, field: "owner"},
+	}
+	for _, tc := range tcs {
+		path, err := parseIndexField(tc.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := extractIndexValue([]byte(tc.value), path)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s of %s: expected %q, %v, got %q, %v", tc.field, tc.value, tc.want, tc.ok, got, ok)
+		}
+	}
+
+	for _, field := range []string{"", ".owner", "spec..owner", "owner."} {
+		if _, err := parseIndexField(field); err != ErrIndexFieldInvalid {
+			t.Errorf("%q: expected %v, got %v", field, ErrIndexFieldInvalid, err)
+		}
+	}
+}
+
+func TestIndexStore(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	ix := newIndexStore(lg, be)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{OnWrite: ix.observe, OnWriteEnd: ix.observeEnd})
+	defer kv.Close()
+
+	kv.Put([]byte("/pods/a"), []byte(`{"node":"n1"}`), lease.NoLease)
+	kv.Put([]byte("/pods/b"), []byte(`{"node":"n2"}`), lease.NoLease)
+	kv.Put([]byte("/nodes/n1"), []byte(`{"node":"n1"}`), lease.NoLease)
+	if err := ix.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.put(&pb.Index{Name: "by-node", Prefix: []byte("/pods/"), Field: "node"}); err != nil {
+		t.Fatal(err)
+	}
+	kv.Put([]byte("/pods/c"), []byte(`{"node":"n1"}`), lease.NoLease)
+	kv.Put([]byte("/pods/d"), []byte(`not json`), lease.NoLease)
+
+	lookup := func(r *pb.IndexRangeRequest) ([]string, int64) {
+		t.Helper()
+		keys, count, txn, err := ix.lookup(r, traceutil.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txn.Rev() != kv.Rev() {
+			t.Errorf("expected the read txn at revision %d, got %d", kv.Rev(), txn.Rev())
+		}
+		txn.End()
+		return keys, count
+	}
+	tcs := []struct {
+		name  string
+		r     *pb.IndexRangeRequest
+		keys  []string
+		count int64
+	}{
+		{
+			name:  "value",
+			r:     &pb.IndexRangeRequest{Name: "by-node", Value: []byte("n1")},
+			keys:  []string{"/pods/a", "/pods/c"},
+			count: 2,
+		},
+		{
+			name:  "limit",
+			r:     &pb.IndexRangeRequest{Name: "by-node", Value: []byte("n1"), Limit: 1},
+			keys:  []string{"/pods/a"},
+			count: 2,
+		},
+		{
+			name:  "range",
+			r:     &pb.IndexRangeRequest{Name: "by-node", Value: []byte("n1"), RangeEnd: []byte("n3")},
+			keys:  []string{"/pods/a", "/pods/c", "/pods/b"},
+			count: 3,
+		},
+		{
+			name:  "from value",
+			r:     &pb.IndexRangeRequest{Name: "by-node", Value: []byte("n2"), RangeEnd: []byte{0}},
+			keys:  []string{"/pods/b"},
+			count: 1,
+		},
+		{
+			name:  "count only",
+			r:     &pb.IndexRangeRequest{Name: "by-node", Value: []byte{0}, RangeEnd: []byte{0}, CountOnly: true},
+			count: 3,
+		},
+		{
+			name: "no such value",
+			r:    &pb.IndexRangeRequest{Name: "by-node", Value: []byte("n3")},
+		},
+	}
+	for _, tc := range tcs {
+		keys, count := lookup(tc.r)
+		if !reflect.DeepEqual(keys, tc.keys) || count != tc.count {
+			t.Errorf("%s: expected %v (%d), got %v (%d)", tc.name, tc.keys, tc.count, keys, count)
+		}
+	}
+
+	// the keys moved or deleted are indexed again or unindexed
+	kv.Put([]byte("/pods/a"), []byte(`{"node":"n2"}`), lease.NoLease)
+	kv.Put([]byte("/pods/c"), []byte(`{}`), lease.NoLease)
+	kv.DeleteRange([]byte("/pods/b"), nil)
+	if keys, _ := lookup(&pb.IndexRangeRequest{Name: "by-node", Value: []byte{0}, RangeEnd: []byte{0}}); !reflect.DeepEqual(keys, []string{"/pods/a"}) {
+		t.Errorf("expected [/pods/a], got %v", keys)
+	}
+	want := []*pb.IndexUsage{{Index: &pb.Index{Name: "by-node", Prefix: []byte("/pods/"), Field: "node"}, IndexedKeys: 1, DistinctValues: 1}}
+	if got := ix.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, _, _, err := ix.lookup(&pb.IndexRangeRequest{Name: "by-owner"}, traceutil.TODO()); err != ErrIndexNotFound {
+		t.Errorf("expected %v, got %v", ErrIndexNotFound, err)
+	}
+	if err := ix.put(&pb.Index{Field: "node"}); err != ErrIndexNameEmpty {
+		t.Errorf("expected %v, got %v", ErrIndexNameEmpty, err)
+	}
+	if err := ix.put(&pb.Index{Name: "by-owner", Field: "spec..owner"}); err != ErrIndexFieldInvalid {
+		t.Errorf("expected %v, got %v", ErrIndexFieldInvalid, err)
+	}
+
+	// the indexes are loaded from the backend and built again
+	ix = newIndexStore(lg, be)
+	if err := ix.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	if got := ix.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v once recovered, got %v", want, got)
+	}
+
+	if err := ix.delete("by-node"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.delete("by-node"); err != ErrIndexNotFound {
+		t.Errorf("expected %v, got %v", ErrIndexNotFound, err)
+	}
+}
+
+// TestIndexStoreLookupWaitsForWrite ensures a lookup waits for the write the
+// indexes are updated with to end, to read the keys at its revision.
+func TestIndexStoreLookupWaitsForWrite(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	ix := newIndexStore(lg, be)
+	observedc, releasec := make(chan struct{}), make(chan struct{})
+	var block bool
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{
+		OnWrite: func(evs []mvccpb.Event) {
+			ix.observe(evs)
+			if block {
+				close(observedc)
+				<-releasec
+			}
+		},
+		OnWriteEnd: ix.observeEnd,
+	})
+	defer kv.Close()
+	if err := ix.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.put(&pb.Index{Name: "by-node", Prefix: []byte("/pods/"), Field: "node"}); err != nil {
+		t.Fatal(err)
+	}
+
+	block = true
+	go kv.Put([]byte("/pods/a"), []byte(`{"node":"n1"}`), lease.NoLease)
+	<-observedc
+
+	type result struct {
+		keys []string
+		rev  int64
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		keys, _, txn, err := ix.lookup(&pb.IndexRangeRequest{Name: "by-node", Value: []byte("n1")}, traceutil.TODO())
+		if err != nil {
+			resc <- result{err: err}
+			return
+		}
+		resc <- result{keys: keys, rev: txn.Rev()}
+		txn.End()
+	}()
+	select {
+	case r := <-resc:
+		t.Fatalf("expected the lookup to wait for the write to end, got %+v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(releasec)
+	select {
+	case r := <-resc:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if !reflect.DeepEqual(r.keys, []string{"/pods/a"}) || r.rev != 2 {
+			t.Errorf("expected /pods/a at revision 2, got %v at revision %d", r.keys, r.rev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lookup to end once the write ended")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+// keyHistogramPageSize is the number of keys read at once, so the read
+// transactions stay short on large keyspaces.
+const keyHistogramPageSize = 1000
+
+var defaultKeyHistogramSeparator = []byte("/")
+
+// KeyHistogram counts the keys of the range of the request and their value
+// bytes grouped by prefix. Like HashKV, it reads the local state of the member.
+func (s *EtcdServer) KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest) (*pb.KeyHistogramResponse, error) {
+	key, end := r.Key, mkGteRange(r.RangeEnd)
+	if len(key) == 0 && len(end) == 0 {
+		key, end = []byte{0}, []byte{}
+	}
+	h := newKeyHistogram(r.Separator, r.Depth)
+
+	rev := r.Revision
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rr, err := s.KV().Range(ctx, key, end, mvcc.RangeOptions{Limit: keyHistogramPageSize, Rev: rev})
+		if err != nil {
+			return nil, err
+		}
+		if rev <= 0 {
+			// the following pages are read at the revision of the first one
+			rev = rr.Rev
+		}
+		for i := range rr.KVs {
+			h.add(&rr.KVs[i])
+		}
+		if len(rr.KVs) < keyHistogramPageSize {
+			break
+		}
+		last := rr.KVs[len(rr.KVs)-1].Key
+		key = append(append(make([]byte, 0, len(last)+1), last...), 0)
+	}
+
+	resp := &pb.KeyHistogramResponse{
+		Header:     &pb.ResponseHeader{Revision: rev},
+		Buckets:    h.buckets,
+		Count:      h.count,
+		ValueBytes: h.valueBytes,
+	}
+	if r.Limit > 0 && int64(len(resp.Buckets)) > r.Limit {
+		sort.SliceStable(resp.Buckets, func(i, j int) bool {
+			return resp.Buckets[i].Count > resp.Buckets[j].Count
+		})
+		resp.Buckets = resp.Buckets[:r.Limit]
+	}
+	return resp, nil
+}
+
+// keyHistogram groups keys added in order by prefix. The keys sharing a
+// prefix are contiguous, so only the last bucket is ever added to.
+type keyHistogram struct {
+	separator  []byte
+	depth      int
+	buckets    []*pb.KeyHistogramBucket
+	count      int64
+	valueBytes int64
+}
+
+func newKeyHistogram(separator []byte, depth int64) *keyHistogram {
+	if len(separator) == 0 {
+		separator = defaultKeyHistogramSeparator
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+	return &keyHistogram{separator: separator, depth: int(depth)}
+}
+
+func (h *keyHistogram) add(kv *mvccpb.KeyValue) {
+	h.count++
+	h.valueBytes += int64(len(kv.Value))
+
+	prefix := h.prefix(kv.Key)
+	if n := len(h.buckets); n > 0 && bytes.Equal(h.buckets[n-1].Prefix, prefix) {
+		h.buckets[n-1].Count++
+		h.buckets[n-1].ValueBytes += int64(len(kv.Value))
+		return
+	}
+	h.buckets = append(h.buckets, &pb.KeyHistogramBucket{
+		Prefix:     append([]byte(nil), prefix...),
+		Count:      1,
+		ValueBytes: int64(len(kv.Value)),
+	})
+}
+
+// prefix returns key up to and including its depth-th separator, or the whole
+// key if it has fewer separators.
+func (h *keyHistogram) prefix(key []byte) []byte {
+	n := 0
+	for i := 0; i < h.depth; i++ {
+		j := bytes.Index(key[n:], h.separator)
+		if j < 0 {
+			return key
+		}
+		n += j + len(h.separator)
+	}
+	return key[:n]
+}
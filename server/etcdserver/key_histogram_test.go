@@ -0,0 +1,133 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestKeyHistogramPrefix(t *testing.T) {
+	tcs := []struct {
+		key       string
+		separator string
+		depth     int64
+		want      string
+	}{
+		{key: "/a/b/c", depth: 1, want: "/"},
+		{key: "/a/b/c", depth: 2, want: "/a/"},
+		{key: "/a/b/c", depth: 4, want: "/a/b/c"},
+		{key: "a", want: "a"},
+		{key: "a::b::c", separator: "::", depth: 2, want: "a::b::"},
+	}
+	for _, tc := range tcs {
+		h := newKeyHistogram([]byte(tc.separator), tc.depth)
+		if got := string(h.prefix([]byte(tc.key))); got != tc.want {
+			t.Errorf("expected prefix of %q at depth %d to be %q, got %q", tc.key, tc.depth, tc.want, got)
+		}
+	}
+}
+
+func TestKeyHistogram(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	s := &EtcdServer{kv: mvcc.New(zaptest.NewLogger(t), be, &lease.FakeLessor{}, mvcc.StoreConfig{})}
+	defer s.kv.Close()
+
+	// more keys than fit in a page
+	for i := 0; i < keyHistogramPageSize+10; i++ {
+		s.kv.Put([]byte(fmt.Sprintf("/a/%04d", i)), []byte("v"), lease.NoLease)
+	}
+	s.kv.Put([]byte("/b/x"), []byte("vv"), lease.NoLease)
+	rev := s.kv.Put([]byte("/b/y/z"), []byte("vvv"), lease.NoLease)
+	s.kv.Put([]byte("/b/y/z"), []byte("vvvv"), lease.NoLease)
+	s.kv.Put([]byte("c"), []byte("v"), lease.NoLease)
+
+	tcs := []struct {
+		name string
+		req  *pb.KeyHistogramRequest
+		want *pb.KeyHistogramResponse
+	}{
+		{
+			name: "all keys",
+			req:  &pb.KeyHistogramRequest{Depth: 2},
+			want: &pb.KeyHistogramResponse{
+				Buckets: []*pb.KeyHistogramBucket{
+					{Prefix: []byte("/a/"), Count: keyHistogramPageSize + 10, ValueBytes: keyHistogramPageSize + 10},
+					{Prefix: []byte("/b/"), Count: 2, ValueBytes: 6},
+					{Prefix: []byte("c"), Count: 1, ValueBytes: 1},
+				},
+				Count:      keyHistogramPageSize + 13,
+				ValueBytes: keyHistogramPageSize + 17,
+			},
+		},
+		{
+			name: "range at revision",
+			req:  &pb.KeyHistogramRequest{Key: []byte("/b/"), RangeEnd: []byte("/b0"), Depth: 3, Revision: rev},
+			want: &pb.KeyHistogramResponse{
+				Buckets: []*pb.KeyHistogramBucket{
+					{Prefix: []byte("/b/x"), Count: 1, ValueBytes: 2},
+					{Prefix: []byte("/b/y/"), Count: 1, ValueBytes: 3},
+				},
+				Count:      2,
+				ValueBytes: 5,
+			},
+		},
+		{
+			name: "limit",
+			req:  &pb.KeyHistogramRequest{Key: []byte{0}, RangeEnd: []byte{0}, Depth: 2, Limit: 2},
+			want: &pb.KeyHistogramResponse{
+				Buckets: []*pb.KeyHistogramBucket{
+					{Prefix: []byte("/a/"), Count: keyHistogramPageSize + 10, ValueBytes: keyHistogramPageSize + 10},
+					{Prefix: []byte("/b/"), Count: 2, ValueBytes: 6},
+				},
+				Count:      keyHistogramPageSize + 13,
+				ValueBytes: keyHistogramPageSize + 17,
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := s.KeyHistogram(context.Background(), tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRev := tc.req.Revision
+			if wantRev == 0 {
+				wantRev = s.kv.Rev()
+			}
+			if resp.Header.Revision != wantRev {
+				t.Errorf("expected revision %d, got %d", wantRev, resp.Header.Revision)
+			}
+			resp.Header = nil
+			if !reflect.DeepEqual(resp, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, resp)
+			}
+		})
+	}
+
+	if _, err := s.KeyHistogram(context.Background(), &pb.KeyHistogramRequest{Revision: s.kv.Rev() + 1}); err != mvcc.ErrFutureRev {
+		t.Errorf("expected %v, got %v", mvcc.ErrFutureRev, err)
+	}
+}
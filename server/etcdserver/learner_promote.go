@@ -0,0 +1,126 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/tracker"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+
+	"go.uber.org/zap"
+)
+
+// learnerAutoPromoteInterval is the interval between the checks of the
+// progress of the learners.
+var learnerAutoPromoteInterval = time.Second
+
+// monitorLearners promotes, while this member is leader, the learners whose
+// log is within LearnerAutoPromoteMaxLag entries of the leader's and which are
+// not receiving a snapshot.
+func (s *EtcdServer) monitorLearners() {
+	if !s.Cfg.LearnerAutoPromote {
+		return
+	}
+	t := time.NewTicker(learnerAutoPromoteInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-s.stopping:
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		for _, id := range promotableLearners(s.raftStatus(), s.Cfg.LearnerAutoPromoteMaxLag) {
+			if !s.autoPromoteLearner(id) {
+				break
+			}
+		}
+	}
+}
+
+// promotableLearners returns the learners replicating the log of the leader
+// with at most maxLag entries of lag, ordered by id.
+func promotableLearners(rs raft.Status, maxLag uint64) []types.ID {
+	leader, ok := rs.Progress[rs.ID]
+	if !ok {
+		return nil
+	}
+	var ids []types.ID
+	for id, pr := range rs.Progress {
+		if !pr.IsLearner || !pr.RecentActive || pr.State != tracker.StateReplicate {
+			continue
+		}
+		if pr.Match >= leader.Match || leader.Match-pr.Match <= maxLag {
+			ids = append(ids, types.ID(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// autoPromoteLearner promotes the learner id, and returns false if the other
+// learners should not be promoted before the next check.
+func (s *EtcdServer) autoPromoteLearner(id types.ID) bool {
+	lg := s.Logger()
+	ctx, cancel := context.WithTimeout(s.authStore.WithRoot(s.ctx), s.Cfg.ReqTimeout())
+	defer cancel()
+	_, err := s.promoteMember(ctx, uint64(id))
+	switch err {
+	case nil:
+		learnerAutoPromoteSucceed.Inc()
+		lg.Info(
+			"automatically promoted learner",
+			zap.String("local-member-id", s.ID().String()),
+			zap.String("promoted-member-id", id.String()),
+		)
+		return true
+	case ErrLearnerNotReady:
+		// the learner is within LearnerAutoPromoteMaxLag entries but not yet
+		// ready for a manual promotion, it is checked again later.
+		return true
+	default:
+		learnerAutoPromoteFailed.WithLabelValues(autoPromoteFailureReason(err)).Inc()
+		lg.Warn(
+			"failed to automatically promote learner",
+			zap.String("local-member-id", s.ID().String()),
+			zap.String("learner-member-id", id.String()),
+			zap.Error(err),
+		)
+		return false
+	}
+}
+
+// autoPromoteFailureReason returns the reason label of a failed automatic
+// promotion. The reasons are a fixed set, so that the errors, which may hold
+// member ids, do not create a time series each.
+func autoPromoteFailureReason(err error) string {
+	switch err {
+	case ErrLearnerNotReady, ErrNotEnoughStartedMembers:
+		return "not_ready"
+	case membership.ErrTooManyLearners:
+		return "too_many_learners"
+	case ErrTimeout, ErrTimeoutDueToLeaderFail, ErrTimeoutDueToConnectionLost, ErrTimeoutLeaderTransfer, context.DeadlineExceeded:
+		return "timeout"
+	}
+	return "other"
+}
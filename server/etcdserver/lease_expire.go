@@ -0,0 +1,202 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/lease"
+
+	"go.uber.org/zap"
+)
+
+// maxLeaseExpireBatch is the maximum number of leases revoked by a batch.
+const maxLeaseExpireBatch = 1000
+
+// leaseExpireQueue revokes expired leases in batches, each applied by a single
+// LeaseExpire raft request deleting at most LeaseRevokeMaxKeys keys. Leases
+// are revoked in the order the lessor expired them, a lease attaching more keys
+// than a batch allows is revoked over several batches.
+type leaseExpireQueue struct {
+	mu sync.Mutex
+	// pending are the expired leases waiting to be revoked, in expiry order.
+	pending []lease.LeaseID
+	// queued are the leases of pending, the lessor keeps reporting a lease as
+	// expired until it is revoked.
+	queued map[lease.LeaseID]struct{}
+	// running is whether a worker is revoking the pending leases.
+	running bool
+}
+
+// revokeExpiredLeases revokes the leases expired by the lessor.
+func (s *EtcdServer) revokeExpiredLeases(leases []*lease.Lease) {
+	if s.IsReadOnly() {
+		// the lessor reports the leases again until they are revoked.
+		return
+	}
+	if s.Cfg.LeaseRevokeMaxKeys > 0 {
+		s.queueExpiredLeases(leases)
+		return
+	}
+	lg := s.Logger()
+	s.GoAttach(func() {
+		// Increases throughput of expired leases deletion process through parallelization
+		c := make(chan struct{}, maxPendingRevokes)
+		for _, lease := range leases {
+			select {
+			case c <- struct{}{}:
+			case <-s.stopping:
+				return
+			}
+			lid := lease.ID
+			s.GoAttach(func() {
+				ctx := s.authStore.WithRoot(s.ctx)
+				_, lerr := s.LeaseRevoke(ctx, &pb.LeaseRevokeRequest{ID: int64(lid)})
+				if lerr == nil {
+					leaseExpired.Inc()
+				} else {
+					lg.Warn(
+						"failed to revoke lease",
+						zap.String("lease-id", fmt.Sprintf("%016x", lid)),
+						zap.Error(lerr),
+					)
+				}
+
+				<-c
+			})
+		}
+	})
+}
+
+// queueExpiredLeases appends the leases not queued yet to the pending leases,
+// starting a worker revoking them if none is running.
+func (s *EtcdServer) queueExpiredLeases(leases []*lease.Lease) {
+	q := &s.leaseExpireQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued == nil {
+		q.queued = make(map[lease.LeaseID]struct{})
+	}
+	for _, l := range leases {
+		if _, ok := q.queued[l.ID]; ok {
+			continue
+		}
+		q.queued[l.ID] = struct{}{}
+		q.pending = append(q.pending, l.ID)
+	}
+	if q.running || len(q.pending) == 0 {
+		return
+	}
+	q.running = true
+	s.GoAttach(s.runLeaseExpireQueue)
+}
+
+// clearLeaseExpireQueue drops the pending leases, e.g. once the member is no
+// longer leader: the next leader expires and revokes them on its own.
+func (s *EtcdServer) clearLeaseExpireQueue() {
+	q := &s.leaseExpireQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending, q.queued = nil, nil
+}
+
+// dropUnexpiredLeases drops the pending leases which are gone or no longer
+// expired, e.g. once the lessor is demoted. q.mu must be held.
+func (s *EtcdServer) dropUnexpiredLeases() {
+	q := &s.leaseExpireQueue
+	pending := q.pending[:0]
+	for _, id := range q.pending {
+		if l := s.lessor.Lookup(id); l != nil && l.Remaining() <= 0 {
+			pending = append(pending, id)
+		} else {
+			delete(q.queued, id)
+		}
+	}
+	q.pending = pending
+}
+
+func (s *EtcdServer) runLeaseExpireQueue() {
+	q := &s.leaseExpireQueue
+	for {
+		q.mu.Lock()
+		if s.isLeader() {
+			s.dropUnexpiredLeases()
+		} else {
+			q.pending, q.queued = nil, nil
+		}
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		n := len(q.pending)
+		if n > maxLeaseExpireBatch {
+			n = maxLeaseExpireBatch
+		}
+		ids := make([]int64, n)
+		for i, id := range q.pending[:n] {
+			ids[i] = int64(id)
+		}
+		q.mu.Unlock()
+
+		revoked, err := s.expireLeases(ids)
+
+		q.mu.Lock()
+		if err != nil {
+			// The lessor reports the leases as expired again after its retry interval.
+			q.pending, q.queued = nil, nil
+		} else {
+			// the queue may have been cleared meanwhile
+			i := 0
+			for i < revoked && i < len(q.pending) && int64(q.pending[i]) == ids[i] {
+				i++
+			}
+			for _, id := range q.pending[:i] {
+				delete(q.queued, id)
+			}
+			q.pending = q.pending[i:]
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-time.After(s.Cfg.LeaseRevokeSpread):
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// expireLeases proposes a batch revoking the leases of ids in order, returning
+// the number of leases fully revoked by it.
+func (s *EtcdServer) expireLeases(ids []int64) (int, error) {
+	ctx := s.authStore.WithRoot(s.ctx)
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{
+		LeaseExpire: &pb.LeaseExpireRequest{IDs: ids, MaxKeys: int64(s.Cfg.LeaseRevokeMaxKeys)},
+	})
+	if err != nil {
+		s.Logger().Warn(
+			"failed to revoke expired leases",
+			zap.Int("leases", len(ids)),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+	r := resp.(*pb.LeaseExpireResponse)
+	leaseExpired.Add(float64(r.Revoked))
+	return int(r.Revoked), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// The modules of the server whose log level may be changed at runtime.
+const (
+	LogModuleRaft   = "raft"
+	LogModuleMVCC   = "mvcc"
+	LogModuleAuth   = "auth"
+	LogModuleLessor = "lessor"
+	LogModuleGRPC   = "grpc"
+)
+
+// LogModules are the modules of the server whose log level may be changed at
+// runtime.
+var LogModules = []string{LogModuleRaft, LogModuleMVCC, LogModuleAuth, LogModuleLessor, LogModuleGRPC}
+
+// LogLevel sets the log levels of the modules of the member, and returns
+// them. Nothing is set unless all the levels of the request are valid.
+func (s *EtcdServer) LogLevel(ctx context.Context, r *pb.LogLevelRequest) (*pb.LogLevelResponse, error) {
+	ml := s.Cfg.LogLevels
+	if ml == nil {
+		return nil, ErrLogLevelsUnconfigured
+	}
+
+	var defaultLevel zapcore.Level
+	if r.DefaultLevel != "" {
+		if err := defaultLevel.Set(r.DefaultLevel); err != nil {
+			return nil, ErrInvalidLogLevel
+		}
+	}
+	levels := make([]zapcore.Level, len(r.Modules))
+	for i, m := range r.Modules {
+		if _, _, err := ml.Level(m.Module); err != nil {
+			return nil, ErrUnknownLogModule
+		}
+		if m.Level == "" {
+			continue
+		}
+		if err := levels[i].Set(m.Level); err != nil {
+			return nil, ErrInvalidLogLevel
+		}
+	}
+
+	lg := s.Logger()
+	if r.DefaultLevel != "" {
+		ml.DefaultLevel().SetLevel(defaultLevel)
+		lg.Info("set default log level", zap.Stringer("level", defaultLevel))
+	}
+	for i, m := range r.Modules {
+		if m.Level == "" {
+			ml.ResetLevel(m.Module)
+			lg.Info("reset module log level", zap.String("module", m.Module), zap.Stringer("level", ml.DefaultLevel().Level()))
+			continue
+		}
+		ml.SetLevel(m.Module, levels[i])
+		lg.Info("set module log level", zap.String("module", m.Module), zap.Stringer("level", levels[i]))
+	}
+
+	resp := &pb.LogLevelResponse{DefaultLevel: ml.DefaultLevel().Level().String()}
+	for _, m := range ml.Modules() {
+		l, overridden, _ := ml.Level(m)
+		resp.Modules = append(resp.Modules, &pb.ModuleLogLevel{Module: m, Level: l.String(), Overridden: overridden})
+	}
+	return resp, nil
+}
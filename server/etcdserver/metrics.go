@@ -64,18 +64,92 @@ var (
 		Name:      "learner_promote_successes",
 		Help:      "The total number of successful learner promotions while this member is leader.",
 	})
+	learnerAutoPromoteFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "learner_auto_promote_failures",
+		Help:      "The total number of failed automatic learner promotions while this member is leader, by reason (not_ready, too_many_learners, timeout, other).",
+	},
+		[]string{"Reason"},
+	)
+	learnerAutoPromoteSucceed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "learner_auto_promote_successes",
+		Help:      "The total number of learners automatically promoted while this member is leader.",
+	})
 	heartbeatSendFailures = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
 		Name:      "heartbeat_send_failures_total",
 		Help:      "The total number of leader heartbeat send failures (likely overloaded from slow disk).",
 	})
+	rangeRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "range_rate_limited_total",
+		Help:      "The total number of requests rejected by the rate limit of a key prefix.",
+	},
+		[]string{"prefix"},
+	)
 	slowApplies = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
 		Name:      "slow_apply_total",
 		Help:      "The total number of slow apply requests (likely overloaded from slow disk).",
 	})
+	slowRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "slow_requests_total",
+		Help:      "The total number of requests which took longer than the slow request threshold.",
+	},
+		[]string{"method"},
+	)
+	cdcEventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "cdc_events_published_total",
+		Help:      "The total number of events published to a cdc sink while this member is leader.",
+	},
+		[]string{"sink"},
+	)
+	cdcPublishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "cdc_publish_failures_total",
+		Help:      "The total number of failed attempts to publish a batch of events to a cdc sink.",
+	},
+		[]string{"sink"},
+	)
+	cdcSkippedRevisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "cdc_skipped_revisions_total",
+		Help:      "The total number of revisions compacted before their events were published to a cdc sink.",
+	},
+		[]string{"sink"},
+	)
+	cdcCheckpointRevision = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "cdc_checkpoint_revision",
+		Help:      "The revision of the last event published to a cdc sink, as checkpointed through raft.",
+	},
+		[]string{"sink"},
+	)
+	duplicateRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "duplicate_requests_total",
+		Help:      "The total number of retried mutations answered with the response of their first attempt instead of being applied again.",
+	})
+	rememberedRequestIDs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "request_ids",
+		Help:      "The number of request IDs of the mutations remembered to answer their retries.",
+	})
 	applySnapshotInProgress = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
@@ -178,6 +252,14 @@ func init() {
 	prometheus.MustRegister(leaderChanges)
 	prometheus.MustRegister(heartbeatSendFailures)
 	prometheus.MustRegister(slowApplies)
+	prometheus.MustRegister(slowRequests)
+	prometheus.MustRegister(rangeRateLimited)
+	prometheus.MustRegister(cdcEventsPublished)
+	prometheus.MustRegister(cdcPublishFailures)
+	prometheus.MustRegister(cdcSkippedRevisions)
+	prometheus.MustRegister(cdcCheckpointRevision)
+	prometheus.MustRegister(duplicateRequests)
+	prometheus.MustRegister(rememberedRequestIDs)
 	prometheus.MustRegister(applySnapshotInProgress)
 	prometheus.MustRegister(proposalsCommitted)
 	prometheus.MustRegister(proposalsApplied)
@@ -192,6 +274,8 @@ func init() {
 	prometheus.MustRegister(isLearner)
 	prometheus.MustRegister(learnerPromoteSucceed)
 	prometheus.MustRegister(learnerPromoteFailed)
+	prometheus.MustRegister(learnerAutoPromoteSucceed)
+	prometheus.MustRegister(learnerAutoPromoteFailed)
 	prometheus.MustRegister(fdUsed)
 	prometheus.MustRegister(fdLimit)
 	prometheus.MustRegister(applySec)
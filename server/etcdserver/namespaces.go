@@ -0,0 +1,386 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/btree"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	"go.uber.org/zap"
+)
+
+// namespaceScanLimit is the number of keys read at once when the sizes of the
+// keys of a namespace are read from the key-value store.
+const namespaceScanLimit = 1000
+
+// namespaceStore keeps the registered namespaces with the usage of their
+// quotas. The usage is updated with the events of the writes to the key-value
+// store while they are applied, so all the members enforce the quotas alike.
+type namespaceStore struct {
+	lg *zap.Logger
+	be backend.Backend
+	kv mvcc.KV
+
+	mu sync.RWMutex
+	// namespaces are sorted by prefix.
+	namespaces []*namespace
+	// sizes are the sizes of the keys and values of the keys of the
+	// namespaces, read from the key-value store when the namespaces are
+	// registered and updated with the writes.
+	sizes *btree.BTree
+}
+
+type namespace struct {
+	*pb.Namespace
+	usedBytes, usedKeys int64
+}
+
+// keySize is the size of the key and the value of a key, ordered by key.
+type keySize struct {
+	key  string
+	size int64
+}
+
+func (k keySize) Less(than btree.Item) bool {
+	return k.key < than.(keySize).key
+}
+
+func newNamespaceStore(lg *zap.Logger, be backend.Backend) *namespaceStore {
+	return &namespaceStore{lg: lg, be: be, sizes: btree.New(32)}
+}
+
+func (n *namespace) contains(key []byte) bool {
+	return bytes.HasPrefix(key, n.Prefix)
+}
+
+// namespaceRange returns the range of the keys of prefix, as in a
+// RangeRequest.
+func namespaceRange(prefix []byte) (key, end []byte) {
+	if end = prefixEnd(prefix); end == nil {
+		end = []byte{0}
+	}
+	return prefix, end
+}
+
+// recover loads the namespaces from the backend and computes their usage from
+// kv, e.g. once the backend is restored from a snapshot.
+func (ns *namespaceStore) recover(be backend.Backend, kv mvcc.KV) error {
+	nb := schema.NewNamespaceBackend(ns.lg, be)
+	nb.CreateNamespaceBucket()
+	saved, err := nb.GetAllNamespaces()
+	if err != nil {
+		return err
+	}
+	sort.Slice(saved, func(i, j int) bool { return bytes.Compare(saved[i].Prefix, saved[j].Prefix) < 0 })
+
+	sizes := btree.New(32)
+	txn := kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+	defer txn.End()
+	var read []byte
+	for _, s := range saved {
+		// the keys of nested namespaces are read with the enclosing one,
+		// sorted right before them
+		if read != nil && bytes.HasPrefix(s.Prefix, read) {
+			continue
+		}
+		if err = readSizes(txn, s.Prefix, sizes); err != nil {
+			return err
+		}
+		read = s.Prefix
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.be, ns.kv, ns.sizes = be, kv, sizes
+	ns.namespaces = nil
+	for _, s := range saved {
+		n := &namespace{Namespace: s}
+		n.usedBytes, n.usedKeys = ns.usage(s.Prefix)
+		ns.namespaces = append(ns.namespaces, n)
+	}
+	return nil
+}
+
+// readSizes reads the sizes of the keys of prefix as of the read txn into
+// sizes.
+func readSizes(txn mvcc.TxnRead, prefix []byte, sizes *btree.BTree) error {
+	key, end := namespaceRange(prefix)
+	for {
+		rr, err := txn.Range(context.TODO(), key, end, mvcc.RangeOptions{Limit: namespaceScanLimit})
+		if err != nil {
+			return err
+		}
+		for i := range rr.KVs {
+			kv := &rr.KVs[i]
+			sizes.ReplaceOrInsert(keySize{key: string(kv.Key), size: int64(len(kv.Key) + len(kv.Value))})
+		}
+		if len(rr.KVs) < namespaceScanLimit {
+			return nil
+		}
+		key = append(append([]byte{}, rr.KVs[len(rr.KVs)-1].Key...), 0)
+	}
+}
+
+// usage returns the size and the number of the keys of prefix.
+func (ns *namespaceStore) usage(prefix []byte) (usedBytes, usedKeys int64) {
+	ns.sizes.AscendGreaterOrEqual(keySize{key: string(prefix)}, func(item btree.Item) bool {
+		k := item.(keySize)
+		if !strings.HasPrefix(k.key, string(prefix)) {
+			return false
+		}
+		usedBytes += k.size
+		usedKeys++
+		return true
+	})
+	return usedBytes, usedKeys
+}
+
+// size returns the size of key and its value, if it exists.
+func (ns *namespaceStore) size(key []byte) (int64, bool) {
+	item := ns.sizes.Get(keySize{key: string(key)})
+	if item == nil {
+		return 0, false
+	}
+	return item.(keySize).size, true
+}
+
+// search returns the position of the namespace of prefix.
+func (ns *namespaceStore) search(prefix []byte) (int, bool) {
+	i := sort.Search(len(ns.namespaces), func(i int) bool { return bytes.Compare(ns.namespaces[i].Prefix, prefix) >= 0 })
+	return i, i < len(ns.namespaces) && bytes.Equal(ns.namespaces[i].Prefix, prefix)
+}
+
+// covered reports whether the keys of prefix are in another namespace, whose
+// prefix is a prefix of it, so that their sizes are tracked.
+func (ns *namespaceStore) covered(prefix []byte) bool {
+	for _, n := range ns.namespaces {
+		if len(n.Prefix) < len(prefix) && n.contains(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containing returns the namespaces containing key.
+func (ns *namespaceStore) containing(key []byte) (nss []*namespace) {
+	for _, n := range ns.namespaces {
+		if n.contains(key) {
+			nss = append(nss, n)
+		}
+	}
+	return nss
+}
+
+// observe updates the sizes of the keys and the usage of the namespaces with
+// the events of a write to the key-value store. The keys outside of the
+// namespaces are ignored.
+func (ns *namespaceStore) observe(evs []mvccpb.Event) {
+	if ns == nil {
+		return
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if len(ns.namespaces) == 0 {
+		return
+	}
+	for _, ev := range evs {
+		nss := ns.containing(ev.Kv.Key)
+		if len(nss) == 0 {
+			continue
+		}
+		old, existed := ns.size(ev.Kv.Key)
+		var size int64
+		switch ev.Type {
+		case mvccpb.PUT:
+			size = int64(len(ev.Kv.Key) + len(ev.Kv.Value))
+			ns.sizes.ReplaceOrInsert(keySize{key: string(ev.Kv.Key), size: size})
+		case mvccpb.DELETE:
+			if !existed {
+				continue
+			}
+			ns.sizes.Delete(keySize{key: string(ev.Kv.Key)})
+		}
+		for _, n := range nss {
+			n.usedBytes += size - old
+			switch {
+			case ev.Type == mvccpb.PUT && !existed:
+				n.usedKeys++
+			case ev.Type == mvccpb.DELETE:
+				n.usedKeys--
+			}
+		}
+	}
+}
+
+// checkPuts returns an error if the puts exceed the quotas of the namespaces
+// of their keys, or attach leases with a larger TTL than their limits. The
+// deletes of the same txn are not accounted for.
+func (ns *namespaceStore) checkPuts(lessor lease.Lessor, puts []*pb.PutRequest) error {
+	if ns == nil {
+		return nil
+	}
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if len(ns.namespaces) == 0 {
+		return nil
+	}
+	type usage struct{ bytes, keys int64 }
+	deltas := make(map[*namespace]*usage)
+	// sizes are the sizes of the keys put so far.
+	sizes := make(map[string]int64)
+	for _, p := range puts {
+		nss := ns.containing(p.Key)
+		if len(nss) == 0 {
+			continue
+		}
+		old, existed := sizes[string(p.Key)]
+		if !existed {
+			old, existed = ns.size(p.Key)
+		}
+		size := int64(len(p.Key) + len(p.Value))
+		if p.IgnoreValue {
+			size = old
+		}
+		sizes[string(p.Key)] = size
+
+		var ttl int64
+		if p.Lease != 0 && !p.IgnoreLease {
+			if l := lessor.Lookup(lease.LeaseID(p.Lease)); l != nil {
+				ttl = l.TTL()
+			}
+		}
+		for _, n := range nss {
+			if n.MaxLeaseTtl > 0 && ttl > n.MaxLeaseTtl {
+				return ErrNamespaceLeaseTTLTooLarge
+			}
+			d := deltas[n]
+			if d == nil {
+				d = &usage{}
+				deltas[n] = d
+			}
+			d.bytes += size - old
+			if !existed {
+				d.keys++
+			}
+		}
+	}
+	for n, d := range deltas {
+		if n.QuotaBytes > 0 && d.bytes > 0 && n.usedBytes+d.bytes > n.QuotaBytes {
+			return ErrNamespaceQuotaExceeded
+		}
+		if n.QuotaKeys > 0 && d.keys > 0 && n.usedKeys+d.keys > n.QuotaKeys {
+			return ErrNamespaceQuotaExceeded
+		}
+	}
+	return nil
+}
+
+// put registers a namespace, or updates the limits of the one with the same
+// prefix. It is called by the apply loop, so the key-value store does not
+// change while the sizes of the keys of a new namespace are read from it,
+// before the namespaces are locked.
+func (ns *namespaceStore) put(r *pb.Namespace) error {
+	if len(r.Prefix) == 0 {
+		return ErrNamespaceEmpty
+	}
+	ns.mu.RLock()
+	i, found := ns.search(r.Prefix)
+	covered := ns.covered(r.Prefix)
+	ns.mu.RUnlock()
+	var sizes *btree.BTree
+	if !found && !covered {
+		sizes = btree.New(32)
+		txn := ns.kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+		err := readSizes(txn, r.Prefix, sizes)
+		txn.End()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	schema.NewNamespaceBackend(ns.lg, ns.be).MustPutNamespace(r)
+	if found {
+		ns.namespaces[i].Namespace = r
+		return nil
+	}
+	if sizes != nil {
+		sizes.Ascend(func(item btree.Item) bool {
+			ns.sizes.ReplaceOrInsert(item)
+			return true
+		})
+	}
+	n := &namespace{Namespace: r}
+	n.usedBytes, n.usedKeys = ns.usage(r.Prefix)
+	ns.namespaces = append(ns.namespaces, nil)
+	copy(ns.namespaces[i+1:], ns.namespaces[i:])
+	ns.namespaces[i] = n
+	return nil
+}
+
+// delete unregisters the namespace of prefix.
+func (ns *namespaceStore) delete(prefix []byte) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	i, found := ns.search(prefix)
+	if !found {
+		return ErrNamespaceNotFound
+	}
+	schema.NewNamespaceBackend(ns.lg, ns.be).MustDeleteNamespace(prefix)
+	ns.namespaces = append(ns.namespaces[:i], ns.namespaces[i+1:]...)
+	if ns.covered(prefix) {
+		return nil
+	}
+	// forget the sizes of the keys outside of the remaining namespaces
+	var keys []keySize
+	ns.sizes.AscendGreaterOrEqual(keySize{key: string(prefix)}, func(item btree.Item) bool {
+		k := item.(keySize)
+		if !strings.HasPrefix(k.key, string(prefix)) {
+			return false
+		}
+		if len(ns.containing([]byte(k.key))) == 0 {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	for _, k := range keys {
+		ns.sizes.Delete(k)
+	}
+	return nil
+}
+
+// list returns the namespaces with their usage, sorted by prefix.
+func (ns *namespaceStore) list() []*pb.NamespaceUsage {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	usages := make([]*pb.NamespaceUsage, len(ns.namespaces))
+	for i, n := range ns.namespaces {
+		usages[i] = &pb.NamespaceUsage{Namespace: n.Namespace, UsedBytes: n.usedBytes, UsedKeys: n.usedKeys}
+	}
+	return usages
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestNamespaceStore(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	ns := newNamespaceStore(lg, be)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{OnWrite: ns.observe})
+	defer kv.Close()
+
+	kv.Put([]byte("/a/x"), []byte("1"), lease.NoLease)
+	kv.Put([]byte("/a/b/x"), []byte("22"), lease.NoLease)
+	kv.Put([]byte("/c"), []byte("333"), lease.NoLease)
+	if err := ns.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	checkSizes := func(want int) {
+		t.Helper()
+		if got := ns.sizes.Len(); got != want {
+			t.Errorf("expected the sizes of %d keys, got %d", want, got)
+		}
+	}
+	// only the keys of the namespaces are tracked
+	checkSizes(0)
+	kv.Put([]byte("/d"), []byte("1"), lease.NoLease)
+	checkSizes(0)
+	if err := ns.put(&pb.Namespace{Prefix: []byte("/a/"), QuotaKeys: 3, QuotaBytes: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.put(&pb.Namespace{Prefix: []byte("/a/b/"), QuotaKeys: 1}); err != nil {
+		t.Fatal(err)
+	}
+	checkUsage := func(want ...int64) {
+		t.Helper()
+		var got []int64
+		for _, u := range ns.list() {
+			got = append(got, u.UsedKeys, u.UsedBytes)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected usage %v, got %v", want, got)
+		}
+	}
+	checkUsage(2, 13, 1, 8)
+	checkSizes(2)
+
+	tcs := []struct {
+		name string
+		puts []*pb.PutRequest
+		want error
+	}{
+		{name: "outside of the namespaces", puts: []*pb.PutRequest{{Key: []byte("/d"), Value: make([]byte, 100)}}},
+		{name: "overwrite", puts: []*pb.PutRequest{{Key: []byte("/a/b/x"), Value: []byte("4444")}}},
+		{name: "key quota of nested namespace", puts: []*pb.PutRequest{{Key: []byte("/a/b/y")}}, want: ErrNamespaceQuotaExceeded},
+		{name: "key quota", puts: []*pb.PutRequest{{Key: []byte("/a/y")}, {Key: []byte("/a/z")}}, want: ErrNamespaceQuotaExceeded},
+		{name: "same key twice", puts: []*pb.PutRequest{{Key: []byte("/a/y")}, {Key: []byte("/a/y")}}},
+		{name: "byte quota", puts: []*pb.PutRequest{{Key: []byte("/a/x"), Value: make([]byte, 27)}}, want: ErrNamespaceQuotaExceeded},
+	}
+	for _, tc := range tcs {
+		if err := ns.checkPuts(&lease.FakeLessor{}, tc.puts); err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	kv.Put([]byte("/a/y"), []byte("55555"), lease.NoLease)
+	kv.Put([]byte("/a/b/x"), nil, lease.NoLease)
+	kv.Put([]byte("/c"), []byte("4444"), lease.NoLease)
+	checkUsage(3, 20, 1, 6)
+	checkSizes(3)
+
+	// the keys of the nested namespace are still tracked
+	if err := ns.delete([]byte("/a/")); err != nil {
+		t.Fatal(err)
+	}
+	checkUsage(1, 6)
+	checkSizes(1)
+	kv.DeleteRange([]byte("/a/"), []byte("/a0"))
+	checkUsage(0, 0)
+	checkSizes(0)
+
+	if err := ns.delete([]byte("/a/")); err != ErrNamespaceNotFound {
+		t.Errorf("expected %v, got %v", ErrNamespaceNotFound, err)
+	}
+	if err := ns.put(&pb.Namespace{}); err != ErrNamespaceEmpty {
+		t.Errorf("expected %v, got %v", ErrNamespaceEmpty, err)
+	}
+
+	// the namespaces are loaded from the backend
+	ns = newNamespaceStore(lg, be)
+	if err := ns.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	if got := ns.list(); len(got) != 1 || string(got[0].Namespace.Prefix) != "/a/b/" {
+		t.Errorf("expected the namespace /a/b/, got %v", got)
+	}
+}
+
+func TestNamespaceStoreUsageOfExistingKeys(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	ns := newNamespaceStore(lg, be)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{OnWrite: ns.observe})
+	defer kv.Close()
+
+	// more keys than read at once by recover
+	n := 3 * namespaceScanLimit / 2
+	for i := 0; i < n; i++ {
+		kv.Put([]byte(fmt.Sprintf("/a/%04d", i)), []byte("v"), lease.NoLease)
+	}
+	kv.Put([]byte("/b"), []byte("v"), lease.NoLease)
+	if err := ns.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	// the keys are read when the namespace is registered
+	kv.Put([]byte("/a/z"), []byte("vv"), lease.NoLease)
+	kv.DeleteRange([]byte("/a/0000"), nil)
+
+	if err := ns.put(&pb.Namespace{Prefix: []byte("/a/")}); err != nil {
+		t.Fatal(err)
+	}
+	got := ns.list()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 namespace, got %v", got)
+	}
+	if wantKeys, wantBytes := int64(n), int64(8*n-8+6); got[0].UsedKeys != wantKeys || got[0].UsedBytes != wantBytes {
+		t.Errorf("expected usage %d keys and %d bytes, got %d keys and %d bytes", wantKeys, wantBytes, got[0].UsedKeys, got[0].UsedBytes)
+	}
+}
+
+func TestNamespaceStoreLeaseTTL(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	le := lease.NewLessor(lg, be, nil, lease.LessorConfig{MinLeaseTTL: 5})
+	defer le.Stop()
+	short, err := le.Grant(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := le.Grant(2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := newNamespaceStore(lg, be)
+	kv := mvcc.New(lg, be, le, mvcc.StoreConfig{OnWrite: ns.observe})
+	defer kv.Close()
+	if err = ns.recover(be, kv); err != nil {
+		t.Fatal(err)
+	}
+	if err = ns.put(&pb.Namespace{Prefix: []byte("/a/"), MaxLeaseTtl: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ns.checkPuts(le, []*pb.PutRequest{{Key: []byte("/a/x"), Lease: int64(short.ID)}}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err = ns.checkPuts(le, []*pb.PutRequest{{Key: []byte("/a/x"), Lease: int64(long.ID)}}); err != ErrNamespaceLeaseTTLTooLarge {
+		t.Errorf("expected %v, got %v", ErrNamespaceLeaseTTLTooLarge, err)
+	}
+	if err = ns.checkPuts(le, []*pb.PutRequest{{Key: []byte("/b"), Lease: int64(long.ID)}}); err != nil {
+		t.Errorf("expected no error outside of the namespace, got %v", err)
+	}
+}
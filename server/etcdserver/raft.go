@@ -87,6 +87,11 @@ type raftNode struct {
 	// a chan to send out readState
 	readStateC chan raft.ReadState
 
+	// spans tracks the spans of the traced requests in flight.
+	spans *requestSpans
+	// slowLog keeps the time the entries took to be saved.
+	slowLog *slowLog
+
 	// utility
 	ticker *time.Ticker
 	// contention detectors for raft heartbeat message
@@ -200,6 +205,10 @@ func (r *raftNode) start(rh *raftReadyHandler) {
 					}
 				}
 
+				for _, span := range r.spans.entrySpans(rd.CommittedEntries) {
+					span.AddEvent("committed")
+				}
+
 				notifyc := make(chan struct{}, 1)
 				ap := apply{
 					entries:  rd.CommittedEntries,
@@ -234,9 +243,12 @@ func (r *raftNode) start(rh *raftReadyHandler) {
 				}
 
 				// gofail: var raftBeforeSave struct{}
+				saveStart := time.Now()
 				if err := r.storage.Save(rd.HardState, rd.Entries); err != nil {
 					r.lg.Fatal("failed to save Raft hard state and entries", zap.Error(err))
 				}
+				r.spans.record("raft.wal_save", saveStart, r.spans.entrySpans(rd.Entries))
+				r.slowLog.walSaved(rd.Entries, time.Since(saveStart))
 				if !raft.IsEmptyHardState(rd.HardState) {
 					proposalsCommitted.Set(float64(rd.HardState.Commit))
 				}
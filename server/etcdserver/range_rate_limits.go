@@ -0,0 +1,294 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+// rangeRateLimiter enforces the rate limits of the requests on the keys of
+// prefixes. A request counts once against each limit whose prefix one of its
+// operations reads or writes keys of.
+type rangeRateLimiter struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	limits []*rangeRateLimit
+}
+
+type rangeRateLimit struct {
+	config.RangeRateLimit
+	// end is the end of the range of the keys of the prefix, nil if the range
+	// is unbounded.
+	end []byte
+
+	// the buckets of the zero limits are nil
+	readRequests, writeRequests *tokenBucket
+	readBytes, writeBytes       *tokenBucket
+}
+
+// rangeRateOp is an operation of a request reading or writing the keys from
+// key to end, or key if end is empty, as in a RangeRequest.
+type rangeRateOp struct {
+	key, end []byte
+	write    bool
+	// bytes is the number of bytes written by the operation.
+	bytes int64
+}
+
+// newRangeRateLimiter returns a rangeRateLimiter enforcing limits, or nil if
+// there are none.
+func newRangeRateLimiter(limits []config.RangeRateLimit) *rangeRateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	l := &rangeRateLimiter{now: time.Now}
+	for _, cfg := range limits {
+		l.limits = append(l.limits, &rangeRateLimit{
+			RangeRateLimit: cfg,
+			end:            prefixEnd([]byte(cfg.Prefix)),
+			readRequests:   newTokenBucket(cfg.ReadRequestsPerSecond),
+			writeRequests:  newTokenBucket(cfg.WriteRequestsPerSecond),
+			readBytes:      newTokenBucket(float64(cfg.ReadBytesPerSecond)),
+			writeBytes:     newTokenBucket(float64(cfg.WriteBytesPerSecond)),
+		})
+	}
+	return l
+}
+
+// allow returns ErrRangeRateLimitExceeded if the operations of a request of
+// user exceed a limit, and otherwise counts them against the limits.
+func (l *rangeRateLimiter) allow(user string, ops []rangeRateOp) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	type usage struct {
+		limit        *rangeRateLimit
+		read, write  bool
+		bytesWritten int64
+	}
+	var usages []usage
+	for _, limit := range l.limits {
+		if limit.User != "" && limit.User != user {
+			continue
+		}
+		u := usage{limit: limit}
+		for _, op := range ops {
+			if !limit.overlaps(op.key, op.end) {
+				continue
+			}
+			if op.write {
+				u.write = true
+				u.bytesWritten += op.bytes
+			} else {
+				u.read = true
+			}
+		}
+		if u.read || u.write {
+			usages = append(usages, u)
+		}
+	}
+
+	// check all the limits before counting the request against any
+	for _, u := range usages {
+		if (u.read && (!u.limit.readRequests.allows(now, 1) || u.limit.readBytes.exhausted(now))) ||
+			(u.write && (!u.limit.writeRequests.allows(now, 1) || u.limit.writeBytes.exhausted(now))) {
+			rangeRateLimited.WithLabelValues(u.limit.Prefix).Inc()
+			return ErrRangeRateLimitExceeded
+		}
+	}
+	for _, u := range usages {
+		if u.read {
+			u.limit.readRequests.take(now, 1)
+		}
+		if u.write {
+			u.limit.writeRequests.take(now, 1)
+			u.limit.writeBytes.take(now, float64(u.bytesWritten))
+		}
+	}
+	return nil
+}
+
+// chargeReads counts the bytes of kvs read by a request of user against the
+// limits of their prefixes. As they are only known once read, the following
+// requests are rejected until they are paid back.
+func (l *rangeRateLimiter) chargeReads(user string, kvs []*mvccpb.KeyValue) {
+	if l == nil || len(kvs) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for _, limit := range l.limits {
+		if limit.readBytes == nil || (limit.User != "" && limit.User != user) {
+			continue
+		}
+		var n int
+		for _, kv := range kvs {
+			if bytes.HasPrefix(kv.Key, []byte(limit.Prefix)) {
+				n += len(kv.Key) + len(kv.Value)
+			}
+		}
+		limit.readBytes.take(now, float64(n))
+	}
+}
+
+// overlaps returns whether the keys from key to end, as in a RangeRequest,
+// include keys of the prefix of the limit.
+func (l *rangeRateLimit) overlaps(key, end []byte) bool {
+	if len(end) == 0 {
+		return bytes.HasPrefix(key, []byte(l.Prefix))
+	}
+	// the range starts before the end of the prefix, and ends after its start
+	return (l.end == nil || bytes.Compare(key, l.end) < 0) &&
+		(bytes.Equal(end, []byte{0}) || bytes.Compare(end, []byte(l.Prefix)) > 0)
+}
+
+// prefixEnd returns the end of the range of the keys with prefix, or nil if
+// the range is unbounded.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// tokenBucket holds up to a second worth of tokens, refilled at rate per
+// second. Its tokens may go negative, so that a read or write larger than a
+// second worth of bytes goes through and is paid back before the next one.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full tokenBucket, or nil if rate is zero. The
+// methods of a nil tokenBucket always have tokens available.
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate == 0 {
+		return nil
+	}
+	return &tokenBucket{rate: rate, tokens: burst(rate)}
+}
+
+// burst returns the number of tokens a bucket holds at most, at least one so
+// that requests are let through at rates below one per second.
+func burst(rate float64) float64 {
+	if rate < 1 {
+		return 1
+	}
+	return rate
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		// the bucket starts full when first used
+		b.last = now
+		return
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if max := burst(b.rate); b.tokens > max {
+			b.tokens = max
+		}
+		b.last = now
+	}
+}
+
+// allows returns whether n tokens can be taken.
+func (b *tokenBucket) allows(now time.Time, n float64) bool {
+	if b == nil {
+		return true
+	}
+	b.refill(now)
+	return b.tokens >= n
+}
+
+// exhausted returns whether no tokens are left, including when more tokens
+// than there were have been taken.
+func (b *tokenBucket) exhausted(now time.Time) bool {
+	if b == nil {
+		return false
+	}
+	b.refill(now)
+	return b.tokens <= 0
+}
+
+func (b *tokenBucket) take(now time.Time, n float64) {
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	b.tokens -= n
+}
+
+// rangeRateOps returns the operations of ops, including the ones of nested
+// transactions, whichever branch they are in.
+func rangeRateOps(ops []*pb.RequestOp) (rops []rangeRateOp) {
+	for _, op := range ops {
+		switch r := op.Request.(type) {
+		case *pb.RequestOp_RequestRange:
+			rops = append(rops, rangeRateOp{key: r.RequestRange.Key, end: r.RequestRange.RangeEnd})
+		case *pb.RequestOp_RequestPut:
+			rops = append(rops, putRangeRateOp(r.RequestPut))
+		case *pb.RequestOp_RequestDeleteRange:
+			rops = append(rops, deleteRangeRateOp(r.RequestDeleteRange))
+		case *pb.RequestOp_RequestTxn:
+			rops = append(rops, txnRangeRateOps(r.RequestTxn)...)
+		}
+	}
+	return rops
+}
+
+func txnRangeRateOps(r *pb.TxnRequest) []rangeRateOp {
+	return append(rangeRateOps(r.Success), rangeRateOps(r.Failure)...)
+}
+
+func putRangeRateOp(r *pb.PutRequest) rangeRateOp {
+	return rangeRateOp{key: r.Key, write: true, bytes: int64(len(r.Key) + len(r.Value))}
+}
+
+func deleteRangeRateOp(r *pb.DeleteRangeRequest) rangeRateOp {
+	return rangeRateOp{key: r.Key, end: r.RangeEnd, write: true, bytes: int64(len(r.Key))}
+}
+
+// txnRangeKVs returns the keys read by the ranges of a transaction, including
+// the ones of nested transactions.
+func txnRangeKVs(resp *pb.TxnResponse) (kvs []*mvccpb.KeyValue) {
+	for _, op := range resp.Responses {
+		switch r := op.Response.(type) {
+		case *pb.ResponseOp_ResponseRange:
+			kvs = append(kvs, r.ResponseRange.Kvs...)
+		case *pb.ResponseOp_ResponseTxn:
+			kvs = append(kvs, txnRangeKVs(r.ResponseTxn)...)
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+func TestPrefixEnd(t *testing.T) {
+	tcs := []struct {
+		prefix, want []byte
+	}{
+		{prefix: []byte("a"), want: []byte("b")},
+		{prefix: []byte("a\xff"), want: []byte("b")},
+		{prefix: []byte("\xff\xff"), want: nil},
+		{prefix: []byte{}, want: nil},
+	}
+	for _, tc := range tcs {
+		if got := prefixEnd(tc.prefix); !bytes.Equal(got, tc.want) {
+			t.Errorf("expected end of prefix %q to be %q, got %q", tc.prefix, tc.want, got)
+		}
+	}
+}
+
+func TestRangeRateLimitOverlaps(t *testing.T) {
+	l := &rangeRateLimit{RangeRateLimit: config.RangeRateLimit{Prefix: "/b/"}, end: prefixEnd([]byte("/b/"))}
+	tcs := []struct {
+		key, end string
+		want     bool
+	}{
+		{key: "/b/x", want: true},
+		{key: "/b", want: false},
+		{key: "/a/", end: "/b/", want: false},
+		{key: "/a/", end: "/b/x", want: true},
+		{key: "/a/", end: "\x00", want: true},
+		{key: "/b0", end: "\x00", want: false},
+		{key: "\x00", end: "\x00", want: true},
+	}
+	for _, tc := range tcs {
+		if got := l.overlaps([]byte(tc.key), []byte(tc.end)); got != tc.want {
+			t.Errorf("expected [%q, %q) overlapping /b/ to be %v, got %v", tc.key, tc.end, tc.want, got)
+		}
+	}
+}
+
+func TestRangeRateLimiterRequests(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newRangeRateLimiter([]config.RangeRateLimit{
+		{Prefix: "/a/", WriteRequestsPerSecond: 2},
+		{Prefix: "/b/", User: "alice", ReadRequestsPerSecond: 1},
+	})
+	l.now = func() time.Time { return now }
+
+	put := func(key string) []rangeRateOp {
+		return []rangeRateOp{putRangeRateOp(&pb.PutRequest{Key: []byte(key)})}
+	}
+	get := []rangeRateOp{{key: []byte("/b/x")}}
+
+	for i := 0; i < 2; i++ {
+		if err := l.allow("", put("/a/x")); err != nil {
+			t.Fatalf("expected put %d to be allowed, got %v", i, err)
+		}
+	}
+	if err := l.allow("", put("/a/x")); err != ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrRangeRateLimitExceeded, err)
+	}
+	if err := l.allow("", put("/c/x")); err != nil {
+		t.Fatalf("expected put outside of limited prefixes to be allowed, got %v", err)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if err := l.allow("", put("/a/x")); err != nil {
+		t.Fatalf("expected put to be allowed once refilled, got %v", err)
+	}
+
+	// the limit of /b/ only applies to alice
+	for i := 0; i < 3; i++ {
+		if err := l.allow("bob", get); err != nil {
+			t.Fatalf("expected get %d of bob to be allowed, got %v", i, err)
+		}
+	}
+	if err := l.allow("alice", get); err != nil {
+		t.Fatalf("expected get of alice to be allowed, got %v", err)
+	}
+	if err := l.allow("alice", get); err != ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrRangeRateLimitExceeded, err)
+	}
+
+	// a request over one limit is not counted against the others
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if err := l.allow("alice", put("/a/x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.allow("alice", append(put("/a/x"), get...)); err != ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrRangeRateLimitExceeded, err)
+	}
+	if err := l.allow("alice", get); err != nil {
+		t.Fatalf("expected get of rejected txn not to be counted, got %v", err)
+	}
+}
+
+func TestRangeRateLimiterBytes(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newRangeRateLimiter([]config.RangeRateLimit{
+		{Prefix: "/a/", ReadBytesPerSecond: 100, WriteBytesPerSecond: 100},
+	})
+	l.now = func() time.Time { return now }
+
+	get := []rangeRateOp{{key: []byte("/a/"), end: []byte("/a0")}}
+	// a read larger than the limit goes through, and is paid back before the
+	// next one
+	if err := l.allow("", get); err != nil {
+		t.Fatal(err)
+	}
+	l.chargeReads("", []*mvccpb.KeyValue{
+		{Key: []byte("/a/x"), Value: make([]byte, 246)},
+		{Key: []byte("/b/x"), Value: make([]byte, 1000)},
+	})
+	now = now.Add(time.Second)
+	if err := l.allow("", get); err != ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrRangeRateLimitExceeded, err)
+	}
+	now = now.Add(time.Second)
+	if err := l.allow("", get); err != nil {
+		t.Fatalf("expected get to be allowed once paid back, got %v", err)
+	}
+
+	put := []rangeRateOp{putRangeRateOp(&pb.PutRequest{Key: []byte("/a/x"), Value: make([]byte, 196)})}
+	if err := l.allow("", put); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.allow("", put); err != ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrRangeRateLimitExceeded, err)
+	}
+}
+
+func TestRangeRateOps(t *testing.T) {
+	r := &pb.TxnRequest{
+		Success: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("a")}}},
+			{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+				Failure: []*pb.RequestOp{
+					{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("b"), Value: []byte("v")}}},
+				},
+			}}},
+		},
+		Failure: []*pb.RequestOp{
+			{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte("c"), RangeEnd: []byte("d")}}},
+		},
+	}
+	ops := txnRangeRateOps(r)
+	if len(ops) != 3 {
+		t.Fatalf("expected 3 operations, got %+v", ops)
+	}
+	if string(ops[0].key) != "a" || ops[0].write {
+		t.Errorf("expected read of a, got %+v", ops[0])
+	}
+	if string(ops[1].key) != "b" || !ops[1].write || ops[1].bytes != 2 {
+		t.Errorf("expected write of 2 bytes to b, got %+v", ops[1])
+	}
+	if string(ops[2].key) != "c" || string(ops[2].end) != "d" || !ops[2].write {
+		t.Errorf("expected write of [c, d), got %+v", ops[2])
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+// The read-only mode of the cluster is kept as a READONLY alarm, so that it is
+// replicated, persisted and restored like the other alarms.
+
+// ReadOnly enables, disables or gets the read-only mode of the cluster.
+func (s *EtcdServer) ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest) (*pb.ReadOnlyResponse, error) {
+	switch r.Action {
+	case pb.ReadOnlyRequest_GET:
+		if err := s.linearizableReadNotify(ctx); err != nil {
+			return nil, err
+		}
+	case pb.ReadOnlyRequest_ENABLE:
+		_, err := s.Alarm(ctx, &pb.AlarmRequest{
+			Action:   pb.AlarmRequest_ACTIVATE,
+			MemberID: uint64(s.ID()),
+			Alarm:    pb.AlarmType_READONLY,
+		})
+		if err != nil {
+			return nil, err
+		}
+	case pb.ReadOnlyRequest_DISABLE:
+		// the mode may have been enabled through any member.
+		if err := s.linearizableReadNotify(ctx); err != nil {
+			return nil, err
+		}
+		for _, m := range s.alarmStore.Get(pb.AlarmType_READONLY) {
+			_, err := s.Alarm(ctx, &pb.AlarmRequest{
+				Action:   pb.AlarmRequest_DEACTIVATE,
+				MemberID: m.MemberID,
+				Alarm:    pb.AlarmType_READONLY,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, ErrUnknownMethod
+	}
+	return &pb.ReadOnlyResponse{ReadOnly: s.IsReadOnly()}, nil
+}
+
+// IsReadOnly returns if the cluster is in read-only mode.
+func (s *EtcdServer) IsReadOnly() bool {
+	return len(s.alarmStore.Get(pb.AlarmType_READONLY)) > 0
+}
+
+// newApplierV3WithAlarms returns the applier of the server restricted by the
+// active alarms.
+func (s *EtcdServer) newApplierV3WithAlarms() applierV3 {
+	a := s.newApplierV3()
+	if len(s.alarmStore.Get(pb.AlarmType_NOSPACE)) > 0 {
+		a = newApplierV3Capped(a)
+	}
+	if len(s.alarmStore.Get(pb.AlarmType_READONLY)) > 0 {
+		a = newApplierV3ReadOnly(a)
+	}
+	if len(s.alarmStore.Get(pb.AlarmType_CORRUPT)) > 0 {
+		a = newApplierV3Corrupt(a)
+	}
+	return a
+}
+
+// applierV3ReadOnly rejects the requests mutating keys, leases or auth.
+type applierV3ReadOnly struct {
+	applierV3
+}
+
+func newApplierV3ReadOnly(a applierV3) *applierV3ReadOnly { return &applierV3ReadOnly{a} }
+
+func (a *applierV3ReadOnly) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	return nil, nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) DeleteRange(txn mvcc.TxnWrite, p *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
+	if !isTxnReadonly(rt) {
+		return nil, nil, ErrReadOnly
+	}
+	return a.applierV3.Txn(ctx, rt)
+}
+
+func (a *applierV3ReadOnly) Compaction(compaction *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
+	return nil, nil, nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) LeaseExpire(lc *pb.LeaseExpireRequest) (*pb.LeaseExpireResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) AuthEnable() (*pb.AuthEnableResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) AuthDisable() (*pb.AuthDisableResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) UserAdd(ua *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) UserDelete(ua *pb.AuthUserDeleteRequest) (*pb.AuthUserDeleteResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) UserChangePassword(ua *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) UserGrantRole(ua *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) UserRevokeRole(ua *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) RoleAdd(ua *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) RoleGrantPermission(ua *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) RoleRevokePermission(ua *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) RoleDelete(ua *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error) {
+	return nil, ErrReadOnly
+}
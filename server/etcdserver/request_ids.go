@@ -0,0 +1,220 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sort"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// maxRequestIDBytes is the maximum length of a request ID.
+const maxRequestIDBytes = 256
+
+// requestIDStore remembers the responses of the last mutations applied with a
+// request ID, so that the retries of a mutation, e.g. by a client that lost
+// the response to a leader change, get the response of the first attempt
+// instead of being applied again. The request IDs are evicted in apply order
+// beyond the window carried by the raft requests, so that all the members
+// remember the same request IDs whatever their configuration.
+//
+// A response is saved after its mutation in the same backend batch; a member
+// crashing after the batch is committed in between applies a retry of the
+// mutation again.
+type requestIDStore struct {
+	lg *zap.Logger
+	be backend.Backend
+
+	// responses are the elements of order by key.
+	responses map[string]*list.Element
+	// order holds the remembered schema.RequestID in apply order.
+	order *list.List
+}
+
+func newRequestIDStore(lg *zap.Logger, be backend.Backend) *requestIDStore {
+	return &requestIDStore{
+		lg:        lg,
+		be:        be,
+		responses: make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// recover loads the remembered responses from the backend, e.g. once the
+// backend is restored from a snapshot.
+func (rs *requestIDStore) recover(be backend.Backend) error {
+	rb := schema.NewRequestIDBackend(rs.lg, be)
+	rb.CreateRequestIDBucket()
+	saved, err := rb.GetAllRequestIDs()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(saved, func(i, j int) bool { return saved[i].Index < saved[j].Index })
+
+	rs.be = be
+	rs.responses = make(map[string]*list.Element, len(saved))
+	rs.order = list.New()
+	for _, r := range saved {
+		rs.responses[r.Key] = rs.order.PushBack(r)
+	}
+	rememberedRequestIDs.Set(float64(rs.order.Len()))
+	return nil
+}
+
+// deduplicates returns whether r is a mutation applied once per request ID.
+func (rs *requestIDStore) deduplicates(r *pb.InternalRaftRequest) bool {
+	if r.Header == nil || r.Header.RequestId == "" || r.Header.RequestIdWindow <= 0 {
+		return false
+	}
+	return r.Put != nil || r.DeleteRange != nil || r.Txn != nil
+}
+
+// lookup returns the response of the first attempt of the mutation r, if it
+// is remembered.
+func (rs *requestIDStore) lookup(r *pb.InternalRaftRequest) (proto.Message, bool, error) {
+	e, ok := rs.responses[requestIDKey(r.Header)]
+	if !ok {
+		return nil, false, nil
+	}
+	saved := e.Value.(schema.RequestID)
+	if saved.Hash != r.Header.RequestHash {
+		return nil, false, ErrRequestIDConflict
+	}
+	var op pb.ResponseOp
+	if err := op.Unmarshal(saved.Response); err != nil {
+		rs.lg.Panic("failed to unmarshal remembered response", zap.String("request-id", r.Header.RequestId), zap.Error(err))
+	}
+	duplicateRequests.Inc()
+	switch resp := op.Response.(type) {
+	case *pb.ResponseOp_ResponsePut:
+		return resp.ResponsePut, true, nil
+	case *pb.ResponseOp_ResponseDeleteRange:
+		return resp.ResponseDeleteRange, true, nil
+	case *pb.ResponseOp_ResponseTxn:
+		return resp.ResponseTxn, true, nil
+	}
+	rs.lg.Panic("unexpected remembered response", zap.String("request-id", r.Header.RequestId))
+	return nil, false, nil
+}
+
+// record remembers the response of the mutation r applied at index, and
+// evicts the oldest request IDs beyond the window of r.
+func (rs *requestIDStore) record(r *pb.InternalRaftRequest, index uint64, resp proto.Message) {
+	var op pb.ResponseOp
+	switch resp := resp.(type) {
+	case *pb.PutResponse:
+		op.Response = &pb.ResponseOp_ResponsePut{ResponsePut: resp}
+	case *pb.DeleteRangeResponse:
+		op.Response = &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: resp}
+	case *pb.TxnResponse:
+		op.Response = &pb.ResponseOp_ResponseTxn{ResponseTxn: resp}
+	default:
+		rs.lg.Panic("unexpected response of a mutation", zap.String("request-id", r.Header.RequestId))
+	}
+	saved := schema.RequestID{
+		Key:      requestIDKey(r.Header),
+		Index:    index,
+		Hash:     r.Header.RequestHash,
+		Response: pbutil.MustMarshal(&op),
+	}
+	rs.responses[saved.Key] = rs.order.PushBack(saved)
+
+	var evicted []string
+	for int64(rs.order.Len()) > r.Header.RequestIdWindow {
+		k := rs.order.Remove(rs.order.Front()).(schema.RequestID).Key
+		delete(rs.responses, k)
+		evicted = append(evicted, k)
+	}
+	schema.NewRequestIDBackend(rs.lg, rs.be).MustPutRequestID(saved, evicted)
+	rememberedRequestIDs.Set(float64(rs.order.Len()))
+}
+
+// requestIDKey qualifies the request ID of a mutation with its user, so that
+// users do not share request IDs.
+func requestIDKey(h *pb.RequestHeader) string {
+	return h.Username + "\x00" + h.RequestId
+}
+
+// requestHash returns the hash of the mutation r, to tell its retries from
+// another mutation with the same request ID.
+func requestHash(r *pb.InternalRaftRequest) uint64 {
+	h := fnv.New64a()
+	switch {
+	case r.Put != nil:
+		h.Write([]byte("put"))
+		h.Write(pbutil.MustMarshal(r.Put))
+	case r.DeleteRange != nil:
+		h.Write([]byte("delete_range"))
+		h.Write(pbutil.MustMarshal(r.DeleteRange))
+	case r.Txn != nil:
+		h.Write([]byte("txn"))
+		h.Write(pbutil.MustMarshal(r.Txn))
+	}
+	return h.Sum64()
+}
+
+type requestHashKey struct{}
+
+// withRequestHash returns a context carrying the hash of the mutation r as the
+// client sent it, for its raft request to be hashed before it is changed.
+func withRequestHash(ctx context.Context, r *pb.InternalRaftRequest) context.Context {
+	return context.WithValue(ctx, requestHashKey{}, requestHash(r))
+}
+
+// setRequestID sets the request ID the client attached to the mutation r, and
+// the hash of the mutation, in the header of r.
+func (s *EtcdServer) setRequestID(ctx context.Context, r *pb.InternalRaftRequest) error {
+	if err := s.setRequestIDHeader(ctx, r.Header); err != nil || r.Header.RequestId == "" {
+		return err
+	}
+	if hash, ok := ctx.Value(requestHashKey{}).(uint64); ok {
+		r.Header.RequestHash = hash
+	} else {
+		r.Header.RequestHash = requestHash(r)
+	}
+	return nil
+}
+
+// setRequestIDHeader sets the request ID the client attached to a mutation in
+// the header of its raft request.
+func (s *EtcdServer) setRequestIDHeader(ctx context.Context, h *pb.RequestHeader) error {
+	if s.Cfg.RequestIDWindow <= 0 {
+		return nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	ids := md.Get(rpctypes.MetadataRequestIDKey)
+	if len(ids) == 0 || ids[0] == "" {
+		return nil
+	}
+	if len(ids[0]) > maxRequestIDBytes {
+		return ErrRequestIDTooLong
+	}
+	h.RequestId, h.RequestIdWindow = ids[0], int64(s.Cfg.RequestIDWindow)
+	return nil
+}
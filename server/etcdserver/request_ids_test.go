@@ -0,0 +1,141 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/metadata"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/server/v3/config"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+func TestRequestIDStore(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+
+	rs := newRequestIDStore(lg, be)
+	if err := rs.recover(be); err != nil {
+		t.Fatal(err)
+	}
+	put := func(user, id, key string) *pb.InternalRaftRequest {
+		r := &pb.InternalRaftRequest{
+			Header: &pb.RequestHeader{Username: user, RequestId: id, RequestIdWindow: 2},
+			Put:    &pb.PutRequest{Key: []byte(key), Value: []byte("v")},
+		}
+		r.Header.RequestHash = requestHash(r)
+		return r
+	}
+
+	if rs.deduplicates(&pb.InternalRaftRequest{Header: &pb.RequestHeader{}, Put: &pb.PutRequest{}}) {
+		t.Fatal("expected a put without request id not to be deduplicated")
+	}
+	if rs.deduplicates(&pb.InternalRaftRequest{Header: &pb.RequestHeader{RequestId: "a", RequestIdWindow: 2}, Range: &pb.RangeRequest{}}) {
+		t.Fatal("expected a range not to be deduplicated")
+	}
+	if !rs.deduplicates(put("", "a", "foo")) {
+		t.Fatal("expected a put with request id to be deduplicated")
+	}
+
+	rs.record(put("", "a", "foo"), 1, &pb.PutResponse{Header: &pb.ResponseHeader{Revision: 2}})
+	rs.record(put("", "b", "foo"), 2, &pb.TxnResponse{Header: &pb.ResponseHeader{Revision: 3}, Succeeded: true})
+
+	resp, found, err := rs.lookup(put("", "a", "foo"))
+	if err != nil || !found {
+		t.Fatalf("expected request id a to be remembered, got %v, %v", found, err)
+	}
+	if rev := resp.(*pb.PutResponse).Header.Revision; rev != 2 {
+		t.Fatalf("expected the revision of the first attempt 2, got %d", rev)
+	}
+	if _, _, err = rs.lookup(put("", "a", "bar")); err != ErrRequestIDConflict {
+		t.Fatalf("expected %v for another put with request id a, got %v", ErrRequestIDConflict, err)
+	}
+	if _, found, _ = rs.lookup(put("alice", "a", "foo")); found {
+		t.Fatal("expected the request ids of users to be apart")
+	}
+
+	// the window of the last request evicts the oldest request id.
+	rs.record(put("alice", "a", "foo"), 3, &pb.DeleteRangeResponse{Header: &pb.ResponseHeader{Revision: 4}, Deleted: 1})
+	if _, found, _ = rs.lookup(put("", "a", "foo")); found {
+		t.Fatal("expected request id a to be evicted")
+	}
+
+	recovered := newRequestIDStore(lg, be)
+	if err = recovered.recover(be); err != nil {
+		t.Fatal(err)
+	}
+	if recovered.order.Len() != 2 {
+		t.Fatalf("expected 2 recovered request ids, got %d", recovered.order.Len())
+	}
+	if k := recovered.order.Front().Value.(schema.RequestID).Key; k != requestIDKey(put("", "b", "").Header) {
+		t.Fatalf("expected request id b to be the oldest, got %q", k)
+	}
+	req := &pb.InternalRaftRequest{Header: &pb.RequestHeader{Username: "alice", RequestId: "a"}, Put: &pb.PutRequest{Key: []byte("foo"), Value: []byte("v")}}
+	req.Header.RequestHash = requestHash(req)
+	resp, found, err = recovered.lookup(req)
+	if err != nil || !found {
+		t.Fatalf("expected request id a of alice to be recovered, got %v, %v", found, err)
+	}
+	if dr := resp.(*pb.DeleteRangeResponse); dr.Deleted != 1 || dr.Header.Revision != 4 {
+		t.Fatalf("unexpected recovered response %v", dr)
+	}
+}
+
+func TestSetRequestID(t *testing.T) {
+	s := &EtcdServer{Cfg: config.ServerConfig{RequestIDWindow: 10}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(rpctypes.MetadataRequestIDKey, "a"))
+	put := func() *pb.InternalRaftRequest {
+		return &pb.InternalRaftRequest{Header: &pb.RequestHeader{}, Put: &pb.PutRequest{Key: []byte("foo"), Ttl: 10}}
+	}
+
+	r := put()
+	if err := s.setRequestID(context.Background(), r); err != nil || r.Header.RequestId != "" || r.Header.RequestHash != 0 {
+		t.Fatalf("expected no request id, got %q, %v", r.Header.RequestId, err)
+	}
+	r = put()
+	if err := s.setRequestID(ctx, r); err != nil || r.Header.RequestId != "a" || r.Header.RequestIdWindow != 10 {
+		t.Fatalf("expected request id a with window 10, got %q, %d, %v", r.Header.RequestId, r.Header.RequestIdWindow, err)
+	}
+	if r.Header.RequestHash != requestHash(put()) {
+		t.Fatalf("expected the hash of the request")
+	}
+
+	// the hash is of the request before its ttl is replaced by a lease
+	r = put()
+	hctx := withRequestHash(ctx, r)
+	r.Put.Lease, r.Put.Ttl = 1, 0
+	if err := s.setRequestID(hctx, r); err != nil || r.Header.RequestHash != requestHash(put()) {
+		t.Fatalf("expected the hash of the request as sent, got %v", err)
+	}
+
+	long := metadata.NewIncomingContext(context.Background(), metadata.Pairs(rpctypes.MetadataRequestIDKey, strings.Repeat("a", maxRequestIDBytes+1)))
+	if err := s.setRequestID(long, put()); err != ErrRequestIDTooLong {
+		t.Fatalf("expected %v, got %v", ErrRequestIDTooLong, err)
+	}
+
+	s.Cfg.RequestIDWindow = 0
+	r = put()
+	if err := s.setRequestID(ctx, r); err != nil || r.Header.RequestId != "" {
+		t.Fatalf("expected the request id to be ignored, got %q, %v", r.Header.RequestId, err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+)
+
+const (
+	// resumableSnapshotTTL is how long a snapshot whose stream broke is kept
+	// for resumption. A kept snapshot holds a backend read transaction, which
+	// delays defragmentation and the reuse of the pages freed meanwhile.
+	resumableSnapshotTTL = time.Minute
+	// maxResumableSnapshots is the maximum number of snapshots kept or sent
+	// as resumable at the same time.
+	maxResumableSnapshots = 4
+)
+
+// ResumableSnapshots keeps the backend snapshots sent to clients asking for
+// resumable snapshots, so that a client whose stream broke can fetch the rest
+// of the same snapshot.
+type ResumableSnapshots struct {
+	mu        sync.Mutex
+	nextID    uint64
+	snapshots map[uint64]*ResumableSnapshot
+	closed    bool
+}
+
+// ResumableSnapshot is a backend snapshot which may be resumed by its ID.
+type ResumableSnapshot struct {
+	backend.Snapshot
+	ID uint64
+	// Version is the storage version of the snapshot.
+	Version string
+	// Revision is the revision of the key-value store the snapshot contains
+	// at least.
+	Revision int64
+
+	rs *ResumableSnapshots
+	// timer closes the snapshot once it is kept for too long, nil while it is
+	// being sent.
+	timer *time.Timer
+}
+
+func NewResumableSnapshots() *ResumableSnapshots {
+	return &ResumableSnapshots{
+		nextID:    uint64(time.Now().UnixNano()),
+		snapshots: make(map[uint64]*ResumableSnapshot),
+	}
+}
+
+// Add registers a snapshot being sent, returning false if too many snapshots
+// are registered already.
+func (rs *ResumableSnapshots) Add(snap backend.Snapshot, version string, rev int64) (*ResumableSnapshot, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.snapshots) >= maxResumableSnapshots {
+		return nil, false
+	}
+	rs.nextID++
+	s := &ResumableSnapshot{Snapshot: snap, ID: rs.nextID, Version: version, Revision: rev, rs: rs}
+	rs.snapshots[s.ID] = s
+	return s, true
+}
+
+// Resume returns the kept snapshot with the given ID to send it again, or
+// false if there is none.
+func (rs *ResumableSnapshots) Resume(id uint64) (*ResumableSnapshot, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	s, ok := rs.snapshots[id]
+	if !ok || s.timer == nil || !s.timer.Stop() {
+		// not kept, or being sent or closed
+		return nil, false
+	}
+	s.timer = nil
+	return s, true
+}
+
+// Close closes the kept snapshots, which would block closing the backend.
+func (rs *ResumableSnapshots) Close() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.closed = true
+	for id, s := range rs.snapshots {
+		if s.timer != nil && s.timer.Stop() {
+			delete(rs.snapshots, id)
+			s.Snapshot.Close()
+		}
+	}
+}
+
+// Keep keeps the snapshot for resumption once its stream broke.
+func (s *ResumableSnapshot) Keep() {
+	s.rs.mu.Lock()
+	defer s.rs.mu.Unlock()
+	if s.rs.closed {
+		delete(s.rs.snapshots, s.ID)
+		s.Snapshot.Close()
+		return
+	}
+	s.timer = time.AfterFunc(resumableSnapshotTTL, func() {
+		s.rs.mu.Lock()
+		delete(s.rs.snapshots, s.ID)
+		s.rs.mu.Unlock()
+		s.Snapshot.Close()
+	})
+}
+
+// Close unregisters and closes the snapshot once it is sent.
+func (s *ResumableSnapshot) Close() error {
+	s.rs.mu.Lock()
+	delete(s.rs.snapshots, s.ID)
+	s.rs.mu.Unlock()
+	return s.Snapshot.Close()
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSnapshot struct{ closed bool }
+
+func (s *fakeSnapshot) Size() int64                        { return 0 }
+func (s *fakeSnapshot) WriteTo(w io.Writer) (int64, error) { return 0, nil }
+func (s *fakeSnapshot) Close() error                       { s.closed = true; return nil }
+
+func TestResumableSnapshots(t *testing.T) {
+	rs := NewResumableSnapshots()
+
+	snaps := make([]*fakeSnapshot, maxResumableSnapshots)
+	var kept []*ResumableSnapshot
+	for i := range snaps {
+		snaps[i] = &fakeSnapshot{}
+		s, ok := rs.Add(snaps[i], "3.6.0", 1)
+		assert.True(t, ok)
+		kept = append(kept, s)
+	}
+	_, ok := rs.Add(&fakeSnapshot{}, "3.6.0", 1)
+	assert.False(t, ok, "expected too many resumable snapshots")
+
+	// a snapshot being sent cannot be resumed
+	_, ok = rs.Resume(kept[0].ID)
+	assert.False(t, ok)
+
+	kept[0].Keep()
+	s, ok := rs.Resume(kept[0].ID)
+	assert.True(t, ok)
+	assert.Equal(t, "3.6.0", s.Version)
+	assert.False(t, snaps[0].closed)
+
+	// a sent snapshot is closed and cannot be resumed
+	assert.NoError(t, s.Close())
+	assert.True(t, snaps[0].closed)
+	_, ok = rs.Resume(kept[0].ID)
+	assert.False(t, ok)
+
+	// closing closes the kept snapshots, and the snapshots kept afterwards
+	kept[1].Keep()
+	rs.Close()
+	assert.True(t, snaps[1].closed)
+	kept[2].Keep()
+	assert.True(t, snaps[2].closed)
+	assert.False(t, snaps[3].closed)
+}
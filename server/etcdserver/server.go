@@ -38,6 +38,7 @@ import (
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/membershippb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/client/pkg/v3/types"
@@ -103,6 +104,7 @@ const (
 	readyPercent = 0.9
 
 	DowngradeEnabledPath = "/downgrade/enabled"
+	FollowerLagPath      = "/follower/lag"
 )
 
 var (
@@ -210,6 +212,14 @@ type EtcdServer struct {
 	committedIndex    uint64 // must use atomic operations to access; keep 64-bit aligned.
 	term              uint64 // must use atomic operations to access; keep 64-bit aligned.
 	lead              uint64 // must use atomic operations to access; keep 64-bit aligned.
+	// pendingProposals counts the proposals waiting to be applied.
+	pendingProposals int64 // must use atomic operations to access; keep 64-bit aligned.
+	// pendingApplies counts the batches of committed entries waiting to be applied.
+	pendingApplies int64 // must use atomic operations to access; keep 64-bit aligned.
+	// followerLag is the number of entries the slowest voting member lags
+	// behind the leader, as recorded by this member while it is the leader or
+	// last fetched from the leader otherwise.
+	followerLag uint64 // must use atomic operations to access; keep 64-bit aligned.
 
 	consistIndex cindex.ConsistentIndexer // consistIndex is used to get/set/save consistentIndex
 	r            raftNode                 // uses 64-bit atomics; keep 64-bit aligned.
@@ -265,6 +275,10 @@ type EtcdServer struct {
 	beHooks    *serverstorage.BackendHooks
 	authStore  auth.AuthStore
 	alarmStore *v3alarm.AlarmStore
+	namespaces *namespaceStore
+	indexes    *indexStore
+	cdcSinks   []*cdcSink
+	requestIDs *requestIDStore
 
 	stats  *stats.ServerStats
 	lstats *stats.LeaderStats
@@ -294,6 +308,32 @@ type EtcdServer struct {
 	firstCommitInTerm     *notify.Notifier
 	clusterVersionChanged *notify.Notifier
 
+	// watchConsumers tracks the watches of all the watch servers of the
+	// member, including the one of the embedded client.
+	watchConsumers *WatchConsumers
+
+	// resumableSnapshots keeps the snapshots of the maintenance servers
+	// which may be resumed.
+	resumableSnapshots *ResumableSnapshots
+
+	// ttlLeases holds the leases the keys put with a ttl are attached to.
+	ttlLeases *ttlLeasePool
+	// leaseExpireQueue holds the expired leases revoked in batches.
+	leaseExpireQueue leaseExpireQueue
+	// rangeRateLimiter is nil if no range rate limits are configured.
+	rangeRateLimiter *rangeRateLimiter
+	// spans tracks the spans of the traced requests in flight.
+	spans *requestSpans
+	// slowLog is nil if no slow request threshold is configured.
+	slowLog *slowLog
+
+	// importing is set while a bulk import writes to the backend, to reject
+	// the proposals.
+	importing int32
+	// importMu is read locked by the proposals in flight, so that bulk
+	// imports wait for them to be applied.
+	importMu sync.RWMutex
+
 	*AccessController
 	// forceSnapshot can force snapshot be triggered after apply, independent of the snapshotCount.
 	// Should only be set within apply code path. Used to force snapshot after cluster version downgrade.
@@ -339,22 +379,41 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		consistIndex:          b.storage.backend.ci,
 		firstCommitInTerm:     notify.NewNotifier(),
 		clusterVersionChanged: notify.NewNotifier(),
+		watchConsumers:        NewWatchConsumers(),
+		resumableSnapshots:    NewResumableSnapshots(),
 	}
 	serverID.With(prometheus.Labels{"server_id": b.cluster.nodeID.String()}).Set(1)
 	srv.cluster.SetVersionChangedNotifier(srv.clusterVersionChanged)
 	srv.applyV2 = NewApplierV2(cfg.Logger, srv.v2store, srv.cluster)
+	srv.rangeRateLimiter = newRangeRateLimiter(cfg.RangeRateLimits)
+	srv.ttlLeases = newTTLLeasePool(func(ctx context.Context, ttl int64) (int64, error) {
+		resp, err := srv.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: ttl})
+		if err != nil {
+			return 0, err
+		}
+		return resp.ID, nil
+	})
 
 	srv.be = b.storage.backend.be
 	srv.beHooks = b.storage.backend.beHooks
+	srv.spans = newRequestSpans(cfg.ExperimentalTracerProvider)
+	srv.r.spans = srv.spans
+	srv.slowLog = newSlowLog(cfg.Logger, cfg.SlowRequestThreshold, cfg.SlowRequestLogSize)
+	srv.r.slowLog = srv.slowLog
+	if srv.spans != nil {
+		srv.beHooks.SetCommitObserver(srv.spans.commitStarted)
+	}
 	minTTL := time.Duration((3*cfg.ElectionTicks)/2) * heartbeat
 
 	// always recover lessor before kv. When we recover the mvcc.KV it will reattach keys to its leases.
 	// If we recover mvcc.KV first, it will attach the keys to the wrong lessor before it recovers.
-	srv.lessor = lease.NewLessor(srv.Logger(), srv.be, srv.cluster, lease.LessorConfig{
+	srv.lessor = lease.NewLessor(cfg.ModuleLogger(LogModuleLessor), srv.be, srv.cluster, lease.LessorConfig{
 		MinLeaseTTL:                int64(math.Ceil(minTTL.Seconds())),
 		CheckpointInterval:         cfg.LeaseCheckpointInterval,
 		CheckpointPersist:          cfg.LeaseCheckpointPersist,
+		FastRenew:                  cfg.LeaseFastRenew,
 		ExpiredLeasesRetryInterval: srv.Cfg.ReqTimeout(),
+		Clock:                      cfg.LeaseClock,
 	})
 
 	tp, err := auth.NewTokenProvider(cfg.Logger, cfg.AuthToken,
@@ -367,14 +426,31 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		cfg.Logger.Warn("failed to create token provider", zap.Error(err))
 		return nil, err
 	}
+	if cfg.AuthTokenOIDC != "" {
+		tp, err = auth.NewTokenProviderOIDC(cfg.Logger, tp, cfg.AuthTokenOIDC)
+		if err != nil {
+			cfg.Logger.Warn("failed to create OIDC token provider", zap.Error(err))
+			return nil, err
+		}
+	}
 
+	srv.namespaces = newNamespaceStore(srv.Logger(), srv.be)
+	srv.indexes = newIndexStore(srv.Logger(), srv.be)
+	srv.requestIDs = newRequestIDStore(srv.Logger(), srv.be)
 	mvccStoreConfig := mvcc.StoreConfig{
-		CompactionBatchLimit:    cfg.CompactionBatchLimit,
-		CompactionSleepInterval: cfg.CompactionSleepInterval,
+		CompactionBatchLimit:          cfg.CompactionBatchLimit,
+		CompactionSleepInterval:       cfg.CompactionSleepInterval,
+		CompactionTargetCommitLatency: cfg.CompactionTargetCommitLatency,
+		OnWrite: func(evs []mvccpb.Event) {
+			srv.namespaces.observe(evs)
+			srv.indexes.observe(evs)
+		},
+		OnWriteEnd: srv.indexes.observeEnd,
 	}
-	srv.kv = mvcc.New(srv.Logger(), srv.be, srv.lessor, mvccStoreConfig)
+	srv.kv = mvcc.New(cfg.ModuleLogger(LogModuleMVCC), srv.be, srv.lessor, mvccStoreConfig)
 
-	srv.authStore = auth.NewAuthStore(srv.Logger(), schema.NewAuthBackend(srv.Logger(), srv.be), tp, int(cfg.BcryptCost))
+	authLogger := cfg.ModuleLogger(LogModuleAuth)
+	srv.authStore = auth.NewAuthStore(authLogger, schema.NewAuthBackend(authLogger, srv.be), tp, int(cfg.BcryptCost))
 
 	newSrv := srv // since srv == nil in defer if srv is returned as nil
 	defer func() {
@@ -385,7 +461,11 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		}
 	}()
 	if num := cfg.AutoCompactionRetention; num != 0 {
-		srv.compactor, err = v3compactor.New(cfg.Logger, cfg.AutoCompactionMode, num, srv.kv, srv)
+		if len(cfg.CompactionRetentionPolicies) > 0 {
+			srv.compactor, err = v3compactor.NewWithRetention(cfg.Logger, cfg.AutoCompactionMode, num, srv.kv, srv, cfg.CompactionRetentionPolicies)
+		} else {
+			srv.compactor, err = v3compactor.New(cfg.Logger, cfg.AutoCompactionMode, num, srv.kv, srv)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -397,6 +477,18 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	if err = srv.restoreAlarms(); err != nil {
 		return nil, err
 	}
+	if err = srv.namespaces.recover(srv.be, srv.kv); err != nil {
+		return nil, err
+	}
+	if err = srv.indexes.recover(srv.be, srv.kv); err != nil {
+		return nil, err
+	}
+	if err = srv.requestIDs.recover(srv.be); err != nil {
+		return nil, err
+	}
+	if srv.cdcSinks, err = newCDCSinks(srv.Logger(), cfg.CDCSinks); err != nil {
+		return nil, err
+	}
 
 	if srv.Cfg.EnableLeaseCheckpoint {
 		// setting checkpointer enables lease checkpoint feature.
@@ -534,6 +626,11 @@ func (s *EtcdServer) Start() {
 	s.GoAttach(s.linearizableReadLoop)
 	s.GoAttach(s.monitorKVHash)
 	s.GoAttach(s.monitorDowngrade)
+	s.GoAttach(s.monitorFollowerLag)
+	s.GoAttach(s.pollFollowerLag)
+	s.GoAttach(s.rotateEncryptionKeys)
+	s.GoAttach(s.monitorLearners)
+	s.GoAttach(s.runCDCSinks)
 }
 
 // start prepares and starts server in a new goroutine. It is no longer safe to
@@ -647,6 +744,7 @@ type ServerPeerV2 interface {
 	ServerPeer
 	HashKVHandler() http.Handler
 	DowngradeEnabledHandler() http.Handler
+	FollowerLagHandler() http.Handler
 }
 
 func (s *EtcdServer) DowngradeInfo() *serverversion.DowngradeInfo { return s.cluster.DowngradeInfo() }
@@ -693,6 +791,44 @@ func (h *downgradeEnabledHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	w.Write([]byte(strconv.FormatBool(enabled)))
 }
 
+type followerLagHandler struct {
+	cluster api.Cluster
+	server  *EtcdServer
+}
+
+func (s *EtcdServer) FollowerLagHandler() http.Handler {
+	if s.Cfg.MaxFollowerLag == 0 {
+		return nil
+	}
+	return &followerLagHandler{
+		cluster: s.cluster,
+		server:  s,
+	}
+}
+
+// ServeHTTP serves the number of entries the slowest voting member lags behind
+// the leader, for the followers to throttle their proposals too.
+func (h *followerLagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("X-Etcd-Cluster-ID", h.cluster.ID().String())
+
+	if r.URL.Path != FollowerLagPath {
+		http.Error(w, "bad path", http.StatusBadRequest)
+		return
+	}
+	if !h.server.isLeader() {
+		http.Error(w, ErrNotLeader.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(strconv.FormatUint(atomic.LoadUint64(&h.server.followerLag), 10)))
+}
+
 // Process takes a raft message and applies it to the server's raft state
 // machine, respecting any timeout of the given context.
 func (s *EtcdServer) Process(ctx context.Context, m raftpb.Message) error {
@@ -772,6 +908,7 @@ func (s *EtcdServer) run() {
 				if s.lessor != nil {
 					s.lessor.Demote()
 				}
+				s.clearLeaseExpireQueue()
 				if s.compactor != nil {
 					s.compactor.Pause()
 				}
@@ -784,7 +921,11 @@ func (s *EtcdServer) run() {
 					s.leadTimeMu.Unlock()
 				}
 				setSyncC(s.SyncTicker.C)
-				if s.compactor != nil {
+				if s.IsWitness() {
+					if newLeader {
+						s.GoAttach(s.handOverWitnessLeadership)
+					}
+				} else if s.compactor != nil {
 					s.compactor.Resume()
 				}
 			}
@@ -842,36 +983,14 @@ func (s *EtcdServer) run() {
 	for {
 		select {
 		case ap := <-s.r.apply():
-			f := func(context.Context) { s.applyAll(&ep, &ap) }
+			f := func(context.Context) {
+				s.applyAll(&ep, &ap)
+				atomic.AddInt64(&s.pendingApplies, -1)
+			}
+			atomic.AddInt64(&s.pendingApplies, 1)
 			sched.Schedule(f)
 		case leases := <-expiredLeaseC:
-			s.GoAttach(func() {
-				// Increases throughput of expired leases deletion process through parallelization
-				c := make(chan struct{}, maxPendingRevokes)
-				for _, lease := range leases {
-					select {
-					case c <- struct{}{}:
-					case <-s.stopping:
-						return
-					}
-					lid := lease.ID
-					s.GoAttach(func() {
-						ctx := s.authStore.WithRoot(s.ctx)
-						_, lerr := s.LeaseRevoke(ctx, &pb.LeaseRevokeRequest{ID: int64(lid)})
-						if lerr == nil {
-							leaseExpired.Inc()
-						} else {
-							lg.Warn(
-								"failed to revoke lease",
-								zap.String("lease-id", fmt.Sprintf("%016x", lid)),
-								zap.Error(lerr),
-							)
-						}
-
-						<-c
-					})
-				}
-			})
+			s.revokeExpiredLeases(leases)
 		case err := <-s.errorc:
 			lg.Warn("server error", zap.Error(err))
 			lg.Warn("data-dir used by this member must be removed")
@@ -900,6 +1019,9 @@ func (s *EtcdServer) Cleanup() {
 	if s.authStore != nil {
 		s.authStore.Close()
 	}
+	if s.resumableSnapshots != nil {
+		s.resumableSnapshots.Close()
+	}
 	if s.be != nil {
 		s.be.Close()
 	}
@@ -924,6 +1046,10 @@ func (s *EtcdServer) applyAll(ep *etcdProgress, apply *apply) {
 	select {
 	// snapshot requested via send()
 	case m := <-s.r.msgSnapC:
+		if !s.canSendSnapshotTo(types.ID(m.To)) {
+			s.r.ReportSnapshot(m.To, raft.SnapshotFailure)
+			break
+		}
 		merged := s.createMergedSnapshotMessage(m, ep.appliedt, ep.appliedi, ep.confState)
 		s.sendMergedSnap(merged)
 	default:
@@ -1027,6 +1153,36 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, apply *apply) {
 
 	lg.Info("restored alarm store")
 
+	if s.namespaces != nil {
+		lg.Info("restoring namespace store")
+
+		if err := s.namespaces.recover(newbe, s.kv); err != nil {
+			lg.Panic("failed to restore namespace store", zap.Error(err))
+		}
+
+		lg.Info("restored namespace store")
+	}
+
+	if s.indexes != nil {
+		lg.Info("restoring index store")
+
+		if err := s.indexes.recover(newbe, s.kv); err != nil {
+			lg.Panic("failed to restore index store", zap.Error(err))
+		}
+
+		lg.Info("restored index store")
+	}
+
+	if s.requestIDs != nil {
+		lg.Info("restoring request id store")
+
+		if err := s.requestIDs.recover(newbe); err != nil {
+			lg.Panic("failed to restore request id store", zap.Error(err))
+		}
+
+		lg.Info("restored request id store")
+	}
+
 	if s.authStore != nil {
 		lg.Info("restoring auth store")
 
@@ -1143,7 +1299,8 @@ func (s *EtcdServer) isLeader() bool {
 
 // MoveLeader transfers the leader to the given transferee.
 func (s *EtcdServer) MoveLeader(ctx context.Context, lead, transferee uint64) error {
-	if !s.cluster.IsMemberExist(types.ID(transferee)) || s.cluster.Member(types.ID(transferee)).IsLearner {
+	if !s.cluster.IsMemberExist(types.ID(transferee)) || s.cluster.Member(types.ID(transferee)).IsLearner ||
+		s.cluster.Member(types.ID(transferee)).IsWitness {
 		return ErrBadLeaderTransferee
 	}
 
@@ -1199,7 +1356,7 @@ func (s *EtcdServer) TransferLeadership() error {
 		return nil
 	}
 
-	transferee, ok := longestConnected(s.r.transport, s.cluster.VotingMemberIDs())
+	transferee, ok := longestConnected(s.r.transport, s.leaderCandidateIDs())
 	if !ok {
 		return ErrUnhealthy
 	}
@@ -1617,6 +1774,12 @@ func (s *EtcdServer) AppliedIndex() uint64 { return s.getAppliedIndex() }
 
 func (s *EtcdServer) Term() uint64 { return s.getTerm() }
 
+// PendingProposals returns the number of proposals of this member waiting to be applied.
+func (s *EtcdServer) PendingProposals() int64 { return atomic.LoadInt64(&s.pendingProposals) }
+
+// PendingApplies returns the number of batches of committed entries waiting to be applied.
+func (s *EtcdServer) PendingApplies() int64 { return atomic.LoadInt64(&s.pendingApplies) }
+
 type confChangeResponse struct {
 	membs []*membership.Member
 	err   error
@@ -1626,6 +1789,12 @@ type confChangeResponse struct {
 // then waits for it to be applied to the server. It
 // will block until the change is performed or there is an error.
 func (s *EtcdServer) configure(ctx context.Context, cc raftpb.ConfChange) ([]*membership.Member, error) {
+	if atomic.LoadInt32(&s.importing) != 0 {
+		return nil, ErrImportInProgress
+	}
+	s.importMu.RLock()
+	defer s.importMu.RUnlock()
+
 	lg := s.Logger()
 	cc.ID = s.reqIDGen.Next()
 	ch := s.w.Register(cc.ID)
@@ -1885,13 +2054,29 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry) {
 		id = raftReq.Header.ID
 	}
 
+	// witnesses store no key-value data, so they only apply cluster-wide state.
+	if s.IsWitness() && !isAppliedByWitness(&raftReq) {
+		s.w.Trigger(id, nil)
+		return
+	}
+
 	needResult := s.w.IsRegistered(id)
 	if needResult || !noSideEffect(&raftReq) {
-		if !needResult && raftReq.Txn != nil {
+		// the response of a txn with a request ID is remembered alike by
+		// all the members to answer its retries, ranges included.
+		if !needResult && raftReq.Txn != nil && !s.requestIDs.deduplicates(&raftReq) {
 			removeNeedlessRangeReqs(raftReq.Txn)
 		}
 		applyV3Performed = true
+		span, traced := s.spans.lookup(id)
+		start := time.Now()
 		ar = s.applyV3.Apply(&raftReq, shouldApplyV3)
+		if ar != nil {
+			ar.index, ar.applyStart, ar.applyTook = e.Index, start, time.Since(start)
+		}
+		if traced {
+			s.spans.applied(span, start, ar)
+		}
 	}
 
 	// do not re-apply applied entries.
@@ -2252,6 +2437,166 @@ func (s *EtcdServer) monitorDowngrade() {
 	}
 }
 
+// monitorFollowerLag records on every tick how far the slowest voting member
+// lags behind the leader while this member is the leader, so that proposals
+// can be throttled without querying raft on every request. Only the leader
+// tracks the progress of the members.
+func (s *EtcdServer) monitorFollowerLag() {
+	if s.Cfg.MaxFollowerLag == 0 {
+		return
+	}
+	t := time.NewTicker(time.Duration(s.Cfg.TickMs) * time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-s.stopping:
+			return
+		}
+
+		if s.isLeader() {
+			atomic.StoreUint64(&s.followerLag, maxFollowerLag(s.raftStatus()))
+		}
+	}
+}
+
+// followerLagPollTicks is the number of ticks between the fetches of the
+// follower lag from the leader by the followers.
+const followerLagPollTicks = 5
+
+// pollFollowerLag periodically fetches the follower lag recorded by the leader
+// while this member is a follower, so that it throttles the proposals it
+// forwards like the leader. The last fetched lag is kept if the leader cannot
+// be reached, and dropped once another member becomes the leader.
+func (s *EtcdServer) pollFollowerLag() {
+	if s.Cfg.MaxFollowerLag == 0 {
+		return
+	}
+	interval := time.Duration(followerLagPollTicks*s.Cfg.TickMs) * time.Millisecond
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var lagLeader types.ID
+	for {
+		select {
+		case <-t.C:
+		case <-s.stopping:
+			return
+		}
+
+		leader := s.Leader()
+		if s.isLeader() || leader == types.ID(raft.None) {
+			lagLeader = types.ID(raft.None)
+			continue
+		}
+		if leader != lagLeader {
+			atomic.StoreUint64(&s.followerLag, 0)
+		}
+		m := s.cluster.Member(leader)
+		if m == nil {
+			continue
+		}
+		lag, err := getFollowerLag(m, s.peerRt, interval)
+		if err != nil {
+			s.Logger().Debug("failed to get follower lag from leader", zap.String("leader-member-id", m.ID.String()), zap.Error(err))
+			continue
+		}
+		lagLeader = leader
+		atomic.StoreUint64(&s.followerLag, lag)
+	}
+}
+
+// rotateEncryptionKeys periodically generates a new data encryption key for
+// the backend values written from then on, and wraps the existing keys again
+// if the primary key encryption key changed. The keys are saved with the next
+// backend commit.
+func (s *EtcdServer) rotateEncryptionKeys() {
+	kr := s.beHooks.EncryptionKeyring()
+	if kr == nil || s.Cfg.EncryptionKeyRotationInterval == 0 {
+		return
+	}
+	lg := s.Logger()
+	t := time.NewTicker(s.Cfg.EncryptionKeyRotationInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-s.stopping:
+			return
+		}
+
+		k, err := kr.Rotate()
+		if err == nil {
+			err = kr.Rewrap()
+		}
+		if err != nil {
+			lg.Warn("failed to rotate the data encryption key", zap.Error(err))
+			continue
+		}
+		lg.Info(
+			"rotated the data encryption key",
+			zap.String("key-id", fmt.Sprintf("%016x", k.ID)),
+			zap.String("key-encryption-key-id", k.KEKID),
+		)
+	}
+}
+
+// maxFollowerLag returns the number of entries the slowest voting member lags
+// behind the leader. Learners are ignored, and so are members the leader has
+// not heard from recently: a member that is down must not block all writes.
+func maxFollowerLag(rs raft.Status) uint64 {
+	leader, ok := rs.Progress[rs.ID]
+	if !ok {
+		return 0
+	}
+	var lag uint64
+	for id, pr := range rs.Progress {
+		if id == rs.ID || pr.IsLearner || !pr.RecentActive {
+			continue
+		}
+		if pr.Match < leader.Match && leader.Match-pr.Match > lag {
+			lag = leader.Match - pr.Match
+		}
+	}
+	return lag
+}
+
+// throttledRequest reports whether r is throttled while a follower lags. Only
+// the key writes of the clients are, the requests the members issue
+// themselves, such as the revocations of the expired leases, the lease
+// checkpoints or the compactions, help the followers catch up or must not be
+// delayed.
+func throttledRequest(r *pb.InternalRaftRequest) bool {
+	return r.Put != nil || r.DeleteRange != nil || r.Txn != nil
+}
+
+// throttleOnFollowerLag delays a proposal while a voting member lags more than
+// MaxFollowerLag entries behind the leader, to give it a chance to catch up.
+// The proposal is rejected if the member does not catch up within an election
+// timeout.
+func (s *EtcdServer) throttleOnFollowerLag(ctx context.Context) error {
+	if s.Cfg.MaxFollowerLag == 0 || atomic.LoadUint64(&s.followerLag) <= s.Cfg.MaxFollowerLag {
+		return nil
+	}
+	start := time.Now()
+	interval := time.Duration(s.Cfg.TickMs) * time.Millisecond
+	deadline := time.NewTimer(time.Duration(s.Cfg.ElectionTicks) * interval)
+	defer deadline.Stop()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for atomic.LoadUint64(&s.followerLag) > s.Cfg.MaxFollowerLag {
+		select {
+		case <-t.C:
+		case <-deadline.C:
+			return ErrTooManyRequests
+		case <-ctx.Done():
+			return s.parseProposeCtxErr(ctx.Err(), start)
+		case <-s.stopping:
+			return ErrStopped
+		}
+	}
+	return nil
+}
+
 func (s *EtcdServer) parseProposeCtxErr(err error, start time.Time) error {
 	switch err {
 	case context.Canceled:
@@ -2294,6 +2639,10 @@ func (s *EtcdServer) Backend() backend.Backend {
 
 func (s *EtcdServer) AuthStore() auth.AuthStore { return s.authStore }
 
+func (s *EtcdServer) WatchConsumers() *WatchConsumers { return s.watchConsumers }
+
+func (s *EtcdServer) ResumableSnapshots() *ResumableSnapshots { return s.resumableSnapshots }
+
 func (s *EtcdServer) restoreAlarms() error {
 	s.applyV3 = s.newApplierV3()
 	as, err := v3alarm.NewAlarmStore(s.lg, schema.NewAlarmBackend(s.lg, s.be))
@@ -2301,12 +2650,7 @@ func (s *EtcdServer) restoreAlarms() error {
 		return err
 	}
 	s.alarmStore = as
-	if len(as.Get(pb.AlarmType_NOSPACE)) > 0 {
-		s.applyV3 = newApplierV3Capped(s.applyV3)
-	}
-	if len(as.Get(pb.AlarmType_CORRUPT)) > 0 {
-		s.applyV3 = newApplierV3Corrupt(s.applyV3)
-	}
+	s.applyV3 = s.newApplierV3WithAlarms()
 	return nil
 }
 
@@ -2341,6 +2685,11 @@ func (s *EtcdServer) IsLearner() bool {
 	return s.cluster.IsLocalMemberLearner()
 }
 
+// IsWitness returns if the local member is a witness.
+func (s *EtcdServer) IsWitness() bool {
+	return s.cluster.IsLocalMemberWitness()
+}
+
 // IsMemberExist returns if the member with the given id exists in cluster.
 func (s *EtcdServer) IsMemberExist(id types.ID) bool {
 	return s.cluster.IsMemberExist(id)
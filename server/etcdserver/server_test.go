@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -40,6 +42,7 @@ import (
 	"go.etcd.io/etcd/pkg/v3/wait"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/raft/v3/tracker"
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
@@ -974,6 +977,7 @@ func TestSyncTrigger(t *testing.T) {
 		Cfg:        config.ServerConfig{Logger: zaptest.NewLogger(t), TickMs: 1, SnapshotCatchUpEntries: DefaultSnapshotCatchUpEntries},
 		r:          *r,
 		v2store:    mockstore.NewNop(),
+		cluster:    newTestCluster(t, nil),
 		SyncTicker: tk,
 		reqIDGen:   idutil.NewGenerator(0, time.Time{}),
 	}
@@ -1973,3 +1977,264 @@ func TestWaitAppliedIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxFollowerLag(t *testing.T) {
+	cases := []struct {
+		name     string
+		progress map[uint64]tracker.Progress
+		want     uint64
+	}{
+		{
+			name: "Not leader",
+			want: 0,
+		},
+		{
+			name: "Slowest voting member",
+			progress: map[uint64]tracker.Progress{
+				1: {Match: 100, RecentActive: true},
+				2: {Match: 90, RecentActive: true},
+				3: {Match: 60, RecentActive: true},
+			},
+			want: 40,
+		},
+		{
+			name: "Learners and inactive members are ignored",
+			progress: map[uint64]tracker.Progress{
+				1: {Match: 100, RecentActive: true},
+				2: {Match: 90, RecentActive: true},
+				3: {Match: 10, RecentActive: false},
+				4: {Match: 20, RecentActive: true, IsLearner: true},
+			},
+			want: 10,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := raft.Status{Progress: tc.progress}
+			rs.ID = 1
+			if got := maxFollowerLag(rs); got != tc.want {
+				t.Errorf("maxFollowerLag() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPromotableLearners(t *testing.T) {
+	cases := []struct {
+		name     string
+		progress map[uint64]tracker.Progress
+		want     []types.ID
+	}{
+		{
+			name: "Not leader",
+		},
+		{
+			name: "Learners within the lag",
+			progress: map[uint64]tracker.Progress{
+				1: {Match: 100, RecentActive: true, State: tracker.StateReplicate},
+				2: {Match: 10, RecentActive: true, State: tracker.StateReplicate},
+				4: {Match: 100, RecentActive: true, State: tracker.StateReplicate, IsLearner: true},
+				3: {Match: 90, RecentActive: true, State: tracker.StateReplicate, IsLearner: true},
+				5: {Match: 89, RecentActive: true, State: tracker.StateReplicate, IsLearner: true},
+			},
+			want: []types.ID{3, 4},
+		},
+		{
+			name: "Inactive, probed learners and learners receiving a snapshot are ignored",
+			progress: map[uint64]tracker.Progress{
+				1: {Match: 100, RecentActive: true, State: tracker.StateReplicate},
+				2: {Match: 100, RecentActive: false, State: tracker.StateReplicate, IsLearner: true},
+				3: {Match: 100, RecentActive: true, State: tracker.StateProbe, IsLearner: true},
+				4: {Match: 100, RecentActive: true, State: tracker.StateSnapshot, IsLearner: true},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := raft.Status{Progress: tc.progress}
+			rs.ID = 1
+			if got := promotableLearners(rs, 10); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("promotableLearners() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAutoPromoteFailureReason(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{err: ErrNotEnoughStartedMembers, want: "not_ready"},
+		{err: membership.ErrTooManyLearners, want: "too_many_learners"},
+		{err: ErrTimeout, want: "timeout"},
+		{err: context.DeadlineExceeded, want: "timeout"},
+		{err: fmt.Errorf("membership: member %x not found", 2), want: "other"},
+	}
+	for _, tc := range cases {
+		if got := autoPromoteFailureReason(tc.err); got != tc.want {
+			t.Errorf("autoPromoteFailureReason(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestThrottleOnFollowerLag(t *testing.T) {
+	cases := []struct {
+		name           string
+		maxFollowerLag uint64
+		followerLag    uint64
+		action         func(s *EtcdServer)
+		expectedError  error
+	}{
+		{
+			name:           "Throttling disabled",
+			maxFollowerLag: 0,
+			followerLag:    1000,
+		},
+		{
+			name:           "Lag within the limit",
+			maxFollowerLag: 100,
+			followerLag:    100,
+		},
+		{
+			name:           "Follower catches up",
+			maxFollowerLag: 100,
+			followerLag:    1000,
+			action: func(s *EtcdServer) {
+				time.Sleep(10 * time.Millisecond)
+				atomic.StoreUint64(&s.followerLag, 50)
+			},
+		},
+		{
+			name:           "Follower does not catch up",
+			maxFollowerLag: 100,
+			followerLag:    1000,
+			expectedError:  ErrTooManyRequests,
+		},
+		{
+			name:           "Server stopped",
+			maxFollowerLag: 100,
+			followerLag:    1000,
+			action: func(s *EtcdServer) {
+				s.stopping <- struct{}{}
+			},
+			expectedError: ErrStopped,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &EtcdServer{
+				Cfg:         config.ServerConfig{TickMs: 1, ElectionTicks: 50, MaxFollowerLag: tc.maxFollowerLag},
+				followerLag: tc.followerLag,
+				stopping:    make(chan struct{}, 1),
+			}
+
+			if tc.action != nil {
+				go tc.action(s)
+			}
+
+			err := s.throttleOnFollowerLag(context.Background())
+
+			if err != tc.expectedError {
+				t.Errorf("Unexpected error, want (%v), got (%v)", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+// TestFollowerLagFromLeader ensures the followers get the lag the leader
+// recorded, and that only the key writes of the clients are throttled.
+func TestFollowerLagFromLeader(t *testing.T) {
+	s := &EtcdServer{
+		id:          1,
+		lead:        1,
+		cluster:     newTestCluster(t, nil),
+		Cfg:         config.ServerConfig{MaxFollowerLag: 100},
+		followerLag: 150,
+	}
+	srv := httptest.NewServer(s.FollowerLagHandler())
+	defer srv.Close()
+	leader := &membership.Member{ID: 1, RaftAttributes: membership.RaftAttributes{PeerURLs: []string{srv.URL}}}
+
+	lag, err := getFollowerLag(leader, http.DefaultTransport, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag != 150 {
+		t.Errorf("expected lag 150, got %d", lag)
+	}
+
+	// a former leader does not serve its stale lag
+	s.setLead(2)
+	if lag, err = getFollowerLag(leader, http.DefaultTransport, time.Second); err == nil {
+		t.Errorf("expected an error from a member that is not the leader, got lag %d", lag)
+	}
+
+	for _, tc := range []struct {
+		r    pb.InternalRaftRequest
+		want bool
+	}{
+		{r: pb.InternalRaftRequest{Put: &pb.PutRequest{}}, want: true},
+		{r: pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{}}, want: true},
+		{r: pb.InternalRaftRequest{Txn: &pb.TxnRequest{}}, want: true},
+		{r: pb.InternalRaftRequest{LeaseRevoke: &pb.LeaseRevokeRequest{}}},
+		{r: pb.InternalRaftRequest{LeaseCheckpoint: &pb.LeaseCheckpointRequest{}}},
+		{r: pb.InternalRaftRequest{Compaction: &pb.CompactionRequest{}}},
+		{r: pb.InternalRaftRequest{ClusterVersionSet: &membershippb.ClusterVersionSetRequest{}}},
+	} {
+		if got := throttledRequest(&tc.r); got != tc.want {
+			t.Errorf("expected throttledRequest(%v) to be %v, got %v", tc.r.String(), tc.want, got)
+		}
+	}
+}
+
+// TestPollFollowerLag ensures a follower keeps the lag it last fetched while
+// the leader cannot be reached, and drops it once another member leads.
+func TestPollFollowerLag(t *testing.T) {
+	leader := &EtcdServer{
+		id:          1,
+		lead:        1,
+		cluster:     newTestCluster(t, nil),
+		Cfg:         config.ServerConfig{MaxFollowerLag: 100},
+		followerLag: 150,
+	}
+	srv := httptest.NewServer(leader.FollowerLagHandler())
+	defer srv.Close()
+	s := &EtcdServer{
+		lgMu:     new(sync.RWMutex),
+		lg:       zaptest.NewLogger(t),
+		id:       2,
+		lead:     1,
+		cluster:  newTestCluster(t, []*membership.Member{{ID: 1, RaftAttributes: membership.RaftAttributes{PeerURLs: []string{srv.URL}}}}),
+		Cfg:      config.ServerConfig{TickMs: 1, MaxFollowerLag: 100},
+		peerRt:   http.DefaultTransport,
+		stopping: make(chan struct{}),
+	}
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		s.pollFollowerLag()
+	}()
+	defer func() {
+		close(s.stopping)
+		<-donec
+	}()
+
+	waitLag := func(want uint64) {
+		t.Helper()
+		for i := 0; atomic.LoadUint64(&s.followerLag) != want; i++ {
+			if i == 100 {
+				t.Fatalf("expected lag %d, got %d", want, atomic.LoadUint64(&s.followerLag))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitLag(150)
+
+	srv.Close()
+	time.Sleep(10 * followerLagPollTicks * time.Millisecond)
+	waitLag(150)
+
+	s.setLead(3)
+	waitLag(0)
+}
@@ -0,0 +1,275 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// slowLogKeyBytes is the number of bytes of the keys kept in the slow log.
+	slowLogKeyBytes = 64
+	// walSaveHistory is the number of the last WAL saves kept to find the
+	// time the entries of the slow requests took to be synced.
+	walSaveHistory = 256
+)
+
+// slowLog records the requests which took longer than a threshold, with the
+// time they spent waiting, syncing the WAL and applying. A nil slowLog
+// records nothing.
+type slowLog struct {
+	lg        *zap.Logger
+	threshold time.Duration
+
+	mu sync.Mutex
+	// entries is a ring of the last slow requests, next being the oldest
+	// once it is full.
+	entries []*pb.SlowRequest
+	next    int
+	total   int64
+
+	savesMu  sync.Mutex
+	saves    [walSaveHistory]walSave
+	nextSave int
+}
+
+// walSave is the time the entries between first and last took to be saved.
+type walSave struct {
+	first, last uint64
+	took        time.Duration
+}
+
+// newSlowLog returns nil if threshold is not positive.
+func newSlowLog(lg *zap.Logger, threshold time.Duration, size int) *slowLog {
+	if threshold <= 0 || size <= 0 {
+		return nil
+	}
+	return &slowLog{lg: lg, threshold: threshold, entries: make([]*pb.SlowRequest, 0, size)}
+}
+
+// walSaved records the time ents took to be saved to the WAL.
+func (sl *slowLog) walSaved(ents []raftpb.Entry, took time.Duration) {
+	if sl == nil || len(ents) == 0 {
+		return
+	}
+	sl.savesMu.Lock()
+	sl.saves[sl.nextSave] = walSave{first: ents[0].Index, last: ents[len(ents)-1].Index, took: took}
+	sl.nextSave = (sl.nextSave + 1) % walSaveHistory
+	sl.savesMu.Unlock()
+}
+
+// fsync returns the time the entry at index took to be saved to the WAL, or
+// 0 if it is no longer known.
+func (sl *slowLog) fsync(index uint64) time.Duration {
+	sl.savesMu.Lock()
+	defer sl.savesMu.Unlock()
+	// the most recent save of the entry, as it may be saved again after a
+	// leader change
+	for i := 1; i <= walSaveHistory; i++ {
+		s := sl.saves[(sl.nextSave-i+walSaveHistory)%walSaveHistory]
+		if s.first <= index && index <= s.last && s.last != 0 {
+			return s.took
+		}
+	}
+	return 0
+}
+
+func (sl *slowLog) slow(start time.Time) bool {
+	return sl != nil && time.Since(start) >= sl.threshold
+}
+
+func (sl *slowLog) record(r *pb.SlowRequest) {
+	slowRequests.WithLabelValues(r.Method).Inc()
+	sl.lg.Warn(
+		"slow request",
+		zap.String("method", r.Method),
+		zap.ByteString("key", r.Key),
+		zap.ByteString("range-end", r.RangeEnd),
+		zap.String("user", r.User),
+		zap.Int64("request-bytes", r.RequestBytes),
+		zap.Int64("response-bytes", r.ResponseBytes),
+		zap.Float64("total-seconds", r.TotalSeconds),
+		zap.Float64("queue-seconds", r.QueueSeconds),
+		zap.Float64("fsync-seconds", r.FsyncSeconds),
+		zap.Float64("apply-seconds", r.ApplySeconds),
+		zap.String("error", r.Error),
+	)
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.total++
+	if len(sl.entries) < cap(sl.entries) {
+		sl.entries = append(sl.entries, r)
+		return
+	}
+	sl.entries[sl.next] = r
+	sl.next = (sl.next + 1) % len(sl.entries)
+}
+
+// list returns at most limit of the slow requests, the most recent first,
+// and the total number of slow requests recorded. A limit of 0 returns all
+// the requests kept.
+func (sl *slowLog) list(limit int64) ([]*pb.SlowRequest, int64) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	n := int64(len(sl.entries))
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	rs := make([]*pb.SlowRequest, 0, n)
+	for i := 1; int64(len(rs)) < n; i++ {
+		rs = append(rs, sl.entries[(sl.next-i+len(sl.entries))%len(sl.entries)])
+	}
+	return rs, sl.total
+}
+
+// observeRaftRequest records r in the slow log if it took longer than the
+// threshold since it was proposed at start.
+func (sl *slowLog) observeRaftRequest(r *pb.InternalRaftRequest, reqBytes int, start time.Time, ar *applyResult, err error) {
+	if !sl.slow(start) {
+		return
+	}
+	sr := newSlowRequest(raftRequestType(r), start, reqBytes)
+	sr.Key, sr.RangeEnd = raftRequestRange(r)
+	if r.Header != nil {
+		sr.User = r.Header.Username
+	}
+	if ar != nil {
+		if err == nil {
+			err = ar.err
+		}
+		sr.ResponseBytes = responseBytes(ar.resp)
+		if !ar.applyStart.IsZero() {
+			fsync := sl.fsync(ar.index)
+			// the rest of the time before the apply is spent replicating the
+			// entry and waiting for the entries before it to be applied
+			queue := ar.applyStart.Sub(start) - fsync
+			if queue < 0 {
+				queue = 0
+			}
+			sr.QueueSeconds = queue.Seconds()
+			sr.FsyncSeconds = fsync.Seconds()
+			sr.ApplySeconds = ar.applyTook.Seconds()
+		}
+	}
+	if err != nil {
+		sr.Error = err.Error()
+	}
+	sl.record(sr)
+}
+
+// observeRead records a read only request in the slow log if it took longer
+// than the threshold since start. The request waits for the linearizable
+// read until readStart, if not serializable.
+func (s *EtcdServer) observeRead(ctx context.Context, method string, key, end []byte, reqBytes int, resp interface{}, err error, start, readStart time.Time) {
+	if !s.slowLog.slow(start) {
+		return
+	}
+	sr := newSlowRequest(method, start, reqBytes)
+	sr.Key, sr.RangeEnd = truncateKey(key), truncateKey(end)
+	if ai, aerr := s.AuthInfoFromCtx(ctx); aerr == nil && ai != nil {
+		sr.User = ai.Username
+	}
+	sr.ResponseBytes = responseBytes(resp)
+	if !readStart.IsZero() {
+		sr.QueueSeconds = readStart.Sub(start).Seconds()
+		sr.ApplySeconds = time.Since(readStart).Seconds()
+	}
+	if err != nil {
+		sr.Error = err.Error()
+	}
+	s.slowLog.record(sr)
+}
+
+func newSlowRequest(method string, start time.Time, reqBytes int) *pb.SlowRequest {
+	return &pb.SlowRequest{
+		StartUnixNano: start.UnixNano(),
+		Method:        method,
+		RequestBytes:  int64(reqBytes),
+		TotalSeconds:  time.Since(start).Seconds(),
+	}
+}
+
+func responseBytes(resp interface{}) int64 {
+	if m, ok := resp.(interface{ Size() int }); ok {
+		return int64(m.Size())
+	}
+	return 0
+}
+
+// raftRequestRange returns the key range of r, the one of its first operation
+// for a transaction, truncated for the slow log.
+func raftRequestRange(r *pb.InternalRaftRequest) (key, end []byte) {
+	switch {
+	case r.Put != nil:
+		key = r.Put.Key
+	case r.DeleteRange != nil:
+		key, end = r.DeleteRange.Key, r.DeleteRange.RangeEnd
+	case r.Range != nil:
+		key, end = r.Range.Key, r.Range.RangeEnd
+	case r.Txn != nil:
+		key, end = txnRange(r.Txn)
+	}
+	return truncateKey(key), truncateKey(end)
+}
+
+func txnRange(r *pb.TxnRequest) (key, end []byte) {
+	if len(r.Compare) > 0 {
+		return r.Compare[0].Key, r.Compare[0].RangeEnd
+	}
+	for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range ops {
+			switch tv := op.Request.(type) {
+			case *pb.RequestOp_RequestRange:
+				return tv.RequestRange.Key, tv.RequestRange.RangeEnd
+			case *pb.RequestOp_RequestPut:
+				return tv.RequestPut.Key, nil
+			case *pb.RequestOp_RequestDeleteRange:
+				return tv.RequestDeleteRange.Key, tv.RequestDeleteRange.RangeEnd
+			case *pb.RequestOp_RequestTxn:
+				return txnRange(tv.RequestTxn)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// truncateKey keeps at most slowLogKeyBytes of key, so that the slow log
+// holds no large keys.
+func truncateKey(key []byte) []byte {
+	if len(key) > slowLogKeyBytes {
+		key = key[:slowLogKeyBytes]
+	}
+	return append([]byte(nil), key...)
+}
+
+// SlowLog returns the last requests which took longer than the slow request
+// threshold, the most recent first.
+func (s *EtcdServer) SlowLog(ctx context.Context, r *pb.SlowLogRequest) (*pb.SlowLogResponse, error) {
+	resp := &pb.SlowLogResponse{}
+	if s.slowLog == nil {
+		return resp, nil
+	}
+	resp.ThresholdSeconds = s.slowLog.threshold.Seconds()
+	resp.Requests, resp.Total = s.slowLog.list(r.Limit)
+	return resp, nil
+}
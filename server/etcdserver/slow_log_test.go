@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSlowLogList(t *testing.T) {
+	sl := newSlowLog(zaptest.NewLogger(t), time.Nanosecond, 3)
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		sl.record(&pb.SlowRequest{Method: m})
+	}
+
+	tests := []struct {
+		limit int64
+		want  []string
+	}{
+		{0, []string{"e", "d", "c"}},
+		{2, []string{"e", "d"}},
+		{5, []string{"e", "d", "c"}},
+	}
+	for _, tt := range tests {
+		rs, total := sl.list(tt.limit)
+		var got []string
+		for _, r := range rs {
+			got = append(got, r.Method)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("limit %d: expected %v, got %v", tt.limit, tt.want, got)
+		}
+		if total != 5 {
+			t.Errorf("limit %d: expected total 5, got %d", tt.limit, total)
+		}
+	}
+
+	if newSlowLog(zaptest.NewLogger(t), 0, 3) != nil {
+		t.Errorf("expected no slow log without a threshold")
+	}
+}
+
+func TestSlowLogObserveRaftRequest(t *testing.T) {
+	sl := newSlowLog(zaptest.NewLogger(t), time.Nanosecond, 8)
+	sl.walSaved([]raftpb.Entry{{Index: 4}, {Index: 6}}, 20*time.Millisecond)
+	sl.walSaved([]raftpb.Entry{{Index: 7}}, 30*time.Millisecond)
+
+	start := time.Now().Add(-time.Second)
+	r := &pb.InternalRaftRequest{
+		Header:      &pb.RequestHeader{Username: "alice"},
+		DeleteRange: &pb.DeleteRangeRequest{Key: bytes.Repeat([]byte("k"), 100), RangeEnd: []byte("z")},
+	}
+	ar := &applyResult{
+		resp:       &pb.DeleteRangeResponse{Deleted: 1},
+		index:      5,
+		applyStart: start.Add(100 * time.Millisecond),
+		applyTook:  10 * time.Millisecond,
+	}
+	sl.observeRaftRequest(r, 42, start, ar, nil)
+	sl.observeRaftRequest(&pb.InternalRaftRequest{Put: &pb.PutRequest{Key: []byte("foo")}}, 10, start, nil, errors.New("timed out"))
+
+	rs, _ := sl.list(0)
+	if len(rs) != 2 {
+		t.Fatalf("expected 2 slow requests, got %d", len(rs))
+	}
+	put, del := rs[0], rs[1]
+	if put.Method != "put" || string(put.Key) != "foo" || put.Error != "timed out" {
+		t.Errorf("unexpected put %+v", put)
+	}
+	if del.Method != "delete_range" || del.User != "alice" || del.RequestBytes != 42 || del.ResponseBytes == 0 {
+		t.Errorf("unexpected delete range %+v", del)
+	}
+	if len(del.Key) != slowLogKeyBytes || string(del.RangeEnd) != "z" {
+		t.Errorf("expected the key truncated to %d bytes, got %q-%q", slowLogKeyBytes, del.Key, del.RangeEnd)
+	}
+	if del.FsyncSeconds != 0.02 || del.ApplySeconds != 0.01 || del.QueueSeconds != 0.08 {
+		t.Errorf("unexpected timing queue %v, fsync %v, apply %v", del.QueueSeconds, del.FsyncSeconds, del.ApplySeconds)
+	}
+	if del.TotalSeconds < 1 {
+		t.Errorf("expected total of at least 1s, got %v", del.TotalSeconds)
+	}
+}
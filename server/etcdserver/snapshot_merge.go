@@ -17,6 +17,7 @@ package etcdserver
 import (
 	"io"
 
+	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
 	"go.etcd.io/etcd/server/v3/storage/backend"
@@ -39,7 +40,7 @@ func (s *EtcdServer) createMergedSnapshotMessage(m raftpb.Message, snapt, snapi
 
 	// commit kv to write metadata(for example: consistent index).
 	s.KV().Commit()
-	dbsnap := s.be.Snapshot()
+	dbsnap := s.backendSnapshotFor(types.ID(m.To))
 	// get a snapshot of v3 KV as readCloser
 	rc := newSnapshotReaderCloser(lg, dbsnap)
 
@@ -0,0 +1,151 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+const defaultTopKeysLimit = 10
+
+// TopKeys reports the largest keys, the prefixes with the most revisions and
+// the watch ranges with the most watchers or backlog of the member. Like
+// HashKV, it reads the local state of the member.
+func (s *EtcdServer) TopKeys(ctx context.Context, r *pb.TopKeysRequest) (*pb.TopKeysResponse, error) {
+	limit := int(r.Limit)
+	if limit <= 0 {
+		limit = defaultTopKeysLimit
+	}
+	resp := &pb.TopKeysResponse{}
+	var err error
+	if resp.LargestKeys, err = s.largestKeys(ctx, limit); err != nil {
+		return nil, err
+	}
+	if resp.Prefixes, err = s.prefixRevisions(ctx, newKeyHistogram(r.Separator, r.Depth), limit); err != nil {
+		return nil, err
+	}
+	resp.WatchedRanges, resp.BackloggedRanges = s.WatchConsumers().TopRanges(limit)
+	return resp, nil
+}
+
+// largestKeys pages through the keys of the current revision, keeping the
+// limit keys with the largest values.
+func (s *EtcdServer) largestKeys(ctx context.Context, limit int) ([]*pb.KeySize, error) {
+	h := make(keySizeHeap, 0, limit+1)
+	key, end := []byte{0}, []byte{}
+	var rev int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rr, err := s.KV().Range(ctx, key, end, mvcc.RangeOptions{Limit: keyHistogramPageSize, Rev: rev})
+		if err != nil {
+			return nil, err
+		}
+		rev = rr.Rev
+		for i := range rr.KVs {
+			kv := &rr.KVs[i]
+			if len(h) == limit && int64(len(kv.Value)) <= h[0].ValueBytes {
+				continue
+			}
+			heap.Push(&h, &pb.KeySize{Key: kv.Key, ValueBytes: int64(len(kv.Value)), ModRevision: kv.ModRevision, Version: kv.Version})
+			if len(h) > limit {
+				heap.Pop(&h)
+			}
+		}
+		if len(rr.KVs) < keyHistogramPageSize {
+			break
+		}
+		last := rr.KVs[len(rr.KVs)-1].Key
+		key = append(append(make([]byte, 0, len(last)+1), last...), 0)
+	}
+	keys := make([]*pb.KeySize, len(h))
+	for i := len(keys) - 1; i >= 0; i-- {
+		keys[i] = heap.Pop(&h).(*pb.KeySize)
+	}
+	return keys, nil
+}
+
+// prefixRevisions counts the revisions kept in the backend by the prefix of
+// their key, returning the limit prefixes with the most revisions.
+func (s *EtcdServer) prefixRevisions(ctx context.Context, h *keyHistogram, limit int) ([]*pb.PrefixRevisions, error) {
+	prefixes := make(map[string]*pb.PrefixRevisions)
+	tx := s.be.ConcurrentReadTx()
+	tx.RLock()
+	err := tx.UnsafeForEach(schema.Key, func(k, v []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var kv mvccpb.KeyValue
+		if err := kv.Unmarshal(v); err != nil {
+			return err
+		}
+		prefix := h.prefix(kv.Key)
+		p, ok := prefixes[string(prefix)]
+		if !ok {
+			p = &pb.PrefixRevisions{Prefix: append([]byte(nil), prefix...)}
+			prefixes[string(prefix)] = p
+		}
+		p.Revisions++
+		p.Bytes += int64(len(kv.Key) + len(kv.Value))
+		return nil
+	})
+	tx.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := make([]*pb.PrefixRevisions, 0, len(prefixes))
+	for _, p := range prefixes {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Revisions != ps[j].Revisions {
+			return ps[i].Revisions > ps[j].Revisions
+		}
+		return string(ps[i].Prefix) < string(ps[j].Prefix)
+	})
+	if len(ps) > limit {
+		ps = ps[:limit]
+	}
+	return ps, nil
+}
+
+// keySizeHeap is a min-heap of keys by value size.
+type keySizeHeap []*pb.KeySize
+
+func (h keySizeHeap) Len() int { return len(h) }
+func (h keySizeHeap) Less(i, j int) bool {
+	if h[i].ValueBytes != h[j].ValueBytes {
+		return h[i].ValueBytes < h[j].ValueBytes
+	}
+	// the first keys are kept among those of the same size
+	return string(h[i].Key) > string(h[j].Key)
+}
+func (h keySizeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *keySizeHeap) Push(x interface{}) { *h = append(*h, x.(*pb.KeySize)) }
+func (h *keySizeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
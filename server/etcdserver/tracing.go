@@ -0,0 +1,260 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "go.etcd.io/etcd/server/v3/etcdserver"
+
+	// maxTracedRevisions bounds the revisions whose watch deliveries are
+	// traced, as the watchers may never catch up with them.
+	maxTracedRevisions = 1024
+)
+
+// requestSpans tracks the spans of the sampled raft requests in flight, so
+// that the raft loop, the apply loop, the backend and the watchers record
+// their part of the requests as children of the spans of the requests, which
+// are children of the spans propagated from the clients. A nil requestSpans
+// traces nothing.
+type requestSpans struct {
+	tracer trace.Tracer
+
+	mu sync.Mutex
+	// spans are the spans of the requests in flight by request ID.
+	spans map[uint64]trace.Span
+	// uncommitted are the spans of the requests applied since the last
+	// commit of the backend.
+	uncommitted []trace.SpanContext
+	// revs are the spans of the requests by the revision they wrote, in
+	// the order of revs.
+	revs     map[int64]trace.SpanContext
+	revOrder []int64
+}
+
+func newRequestSpans(tp trace.TracerProvider) *requestSpans {
+	if tp == nil {
+		return nil
+	}
+	return &requestSpans{
+		tracer: tp.Tracer(tracerName),
+		spans:  make(map[uint64]trace.Span),
+		revs:   make(map[int64]trace.SpanContext),
+	}
+}
+
+// start starts the span of a raft request, tracked until finish is called
+// if sampled.
+func (rs *requestSpans) start(ctx context.Context, r *pb.InternalRaftRequest, id uint64) trace.Span {
+	if rs == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	_, span := rs.tracer.Start(ctx, "etcdserver.raft_request", trace.WithAttributes(
+		attribute.Int64("request_id", int64(id)),
+		attribute.String("request_type", raftRequestType(r)),
+	))
+	if span.SpanContext().IsSampled() {
+		rs.mu.Lock()
+		rs.spans[id] = span
+		rs.mu.Unlock()
+	}
+	return span
+}
+
+func (rs *requestSpans) finish(id uint64, span trace.Span) {
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	delete(rs.spans, id)
+	rs.mu.Unlock()
+	span.End()
+}
+
+func (rs *requestSpans) lookup(id uint64) (trace.Span, bool) {
+	if rs == nil {
+		return nil, false
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	span, ok := rs.spans[id]
+	return span, ok
+}
+
+func (rs *requestSpans) empty() bool {
+	if rs == nil {
+		return true
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return len(rs.spans) == 0
+}
+
+// entrySpans returns the spans of the requests in flight among ents.
+func (rs *requestSpans) entrySpans(ents []raftpb.Entry) []trace.Span {
+	if rs.empty() {
+		return nil
+	}
+	var spans []trace.Span
+	for i := range ents {
+		if ents[i].Type != raftpb.EntryNormal || len(ents[i].Data) == 0 {
+			continue
+		}
+		var r pb.InternalRaftRequest
+		if !pbutil.MaybeUnmarshal(&r, ents[i].Data) || r.Header == nil {
+			continue
+		}
+		id := r.ID
+		if id == 0 {
+			id = r.Header.ID
+		}
+		if span, ok := rs.lookup(id); ok {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// record records a span named name from start until now as a child of each
+// of spans.
+func (rs *requestSpans) record(name string, start time.Time, spans []trace.Span, attrs ...attribute.KeyValue) {
+	if len(spans) == 0 {
+		return
+	}
+	end := time.Now()
+	for _, parent := range spans {
+		ctx := trace.ContextWithSpan(context.Background(), parent)
+		_, span := rs.tracer.Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+		span.End(trace.WithTimestamp(end))
+	}
+}
+
+// applied records the apply of the request of span from start until now,
+// and the revision it wrote, for its backend commit and watch deliveries to
+// be traced.
+func (rs *requestSpans) applied(span trace.Span, start time.Time, ar *applyResult) {
+	rs.record("etcdserver.apply", start, []trace.Span{span})
+	var rev int64
+	if ar != nil && ar.err == nil {
+		if r, ok := ar.resp.(interface{ GetHeader() *pb.ResponseHeader }); ok && r.GetHeader() != nil {
+			rev = r.GetHeader().Revision
+		}
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.uncommitted = append(rs.uncommitted, span.SpanContext())
+	if rev == 0 {
+		return
+	}
+	if _, ok := rs.revs[rev]; !ok {
+		rs.revOrder = append(rs.revOrder, rev)
+	}
+	rs.revs[rev] = span.SpanContext()
+	if len(rs.revOrder) > maxTracedRevisions {
+		delete(rs.revs, rs.revOrder[0])
+		rs.revOrder = rs.revOrder[1:]
+	}
+}
+
+// commitStarted returns a function recording the backend commit starting
+// now, once done, for the requests applied since the last commit.
+func (rs *requestSpans) commitStarted() func() {
+	rs.mu.Lock()
+	scs := rs.uncommitted
+	rs.uncommitted = nil
+	rs.mu.Unlock()
+	if len(scs) == 0 {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		for _, sc := range scs {
+			ctx := trace.ContextWithSpanContext(context.Background(), sc)
+			_, span := rs.tracer.Start(ctx, "backend.commit", trace.WithTimestamp(start))
+			span.End(trace.WithTimestamp(end))
+		}
+	}
+}
+
+// watchSendStarted returns a function recording the delivery of evs to a
+// watcher starting now, once done, for the requests which wrote evs.
+func (rs *requestSpans) watchSendStarted(evs []mvccpb.Event, watchID int64) func() {
+	var scs []trace.SpanContext
+	rs.mu.Lock()
+	for i := range evs {
+		rev := evs[i].Kv.ModRevision
+		if sc, ok := rs.revs[rev]; ok && (i == 0 || evs[i-1].Kv.ModRevision != rev) {
+			scs = append(scs, sc)
+		}
+	}
+	rs.mu.Unlock()
+	if len(scs) == 0 {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		for _, sc := range scs {
+			ctx := trace.ContextWithSpanContext(context.Background(), sc)
+			_, span := rs.tracer.Start(ctx, "watch.send", trace.WithTimestamp(start), trace.WithAttributes(attribute.Int64("watch_id", watchID)))
+			span.End(trace.WithTimestamp(end))
+		}
+	}
+}
+
+// TraceWatchSend returns a function to call once the watch response holding
+// evs is sent, which records the delivery in the traces of the requests
+// which wrote evs.
+func (s *EtcdServer) TraceWatchSend(evs []mvccpb.Event, watchID int64) func() {
+	if s.spans == nil {
+		return func() {}
+	}
+	return s.spans.watchSendStarted(evs, watchID)
+}
+
+func raftRequestType(r *pb.InternalRaftRequest) string {
+	switch {
+	case r.Put != nil:
+		return "put"
+	case r.DeleteRange != nil:
+		return "delete_range"
+	case r.Txn != nil:
+		return "txn"
+	case r.Range != nil:
+		return "range"
+	case r.Compaction != nil:
+		return "compaction"
+	case r.LeaseGrant != nil:
+		return "lease_grant"
+	case r.LeaseRevoke != nil:
+		return "lease_revoke"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestSpans(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sr))
+	rs := newRequestSpans(tp)
+
+	ctx, client := tp.Tracer("client").Start(context.Background(), "client")
+	r := &pb.InternalRaftRequest{Header: &pb.RequestHeader{ID: 1}, Put: &pb.PutRequest{Key: []byte("foo")}}
+	span := rs.start(ctx, r, 1)
+
+	ents := []raftpb.Entry{
+		{Type: raftpb.EntryNormal, Data: pbutil.MustMarshal(r)},
+		{Type: raftpb.EntryNormal, Data: pbutil.MustMarshal(&pb.InternalRaftRequest{Header: &pb.RequestHeader{ID: 2}})},
+		{Type: raftpb.EntryConfChange},
+	}
+	spans := rs.entrySpans(ents)
+	if len(spans) != 1 || spans[0] != span {
+		t.Fatalf("expected the span of request 1, got %v", spans)
+	}
+	rs.record("raft.wal_save", time.Now(), spans)
+	rs.applied(span, time.Now(), &applyResult{resp: &pb.PutResponse{Header: &pb.ResponseHeader{Revision: 5}}})
+
+	rs.commitStarted()()
+	// the requests are only traced by the first commit after their apply
+	rs.commitStarted()()
+
+	evs := []mvccpb.Event{
+		{Kv: &mvccpb.KeyValue{Key: []byte("foo"), ModRevision: 5}},
+		{Kv: &mvccpb.KeyValue{Key: []byte("bar"), ModRevision: 5}},
+		{Kv: &mvccpb.KeyValue{Key: []byte("baz"), ModRevision: 6}},
+	}
+	rs.watchSendStarted(evs, 1)()
+	rs.finish(1, span)
+	client.End()
+
+	if !rs.empty() {
+		t.Errorf("expected no span in flight after finish")
+	}
+
+	var got []string
+	for _, s := range sr.Ended() {
+		if s.SpanContext().TraceID() != client.SpanContext().TraceID() {
+			t.Errorf("expected span %q in the trace of the client", s.Name())
+		}
+		switch s.Name() {
+		case "client":
+		case "etcdserver.raft_request":
+			if s.Parent().SpanID() != client.SpanContext().SpanID() {
+				t.Errorf("expected %q to be a child of the client span", s.Name())
+			}
+		default:
+			if s.Parent().SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("expected %q to be a child of the request span", s.Name())
+			}
+		}
+		got = append(got, s.Name())
+	}
+	sort.Strings(got)
+	want := []string{"backend.commit", "client", "etcdserver.apply", "etcdserver.raft_request", "raft.wal_save", "watch.send"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected spans %v, got %v", want, got)
+	}
+}
+
+func TestRequestSpansNotSampled(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sr), tracesdk.WithSampler(tracesdk.NeverSample()))
+	rs := newRequestSpans(tp)
+
+	r := &pb.InternalRaftRequest{Header: &pb.RequestHeader{ID: 1}, Put: &pb.PutRequest{Key: []byte("foo")}}
+	span := rs.start(context.Background(), r, 1)
+	if !rs.empty() {
+		t.Fatalf("expected the span not sampled not to be tracked")
+	}
+	if spans := rs.entrySpans([]raftpb.Entry{{Type: raftpb.EntryNormal, Data: pbutil.MustMarshal(r)}}); len(spans) != 0 {
+		t.Errorf("expected no span, got %v", spans)
+	}
+	rs.finish(1, span)
+	if ended := sr.Ended(); len(ended) != 0 {
+		t.Errorf("expected no span recorded, got %d", len(ended))
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// ttlLeaseSpread is the fraction of its ttl a key may outlive it by, so that
+// keys expiring around the same time share a lease.
+const ttlLeaseSpread = 10
+
+// ttlLeasePool attaches the keys put with a ttl to leases granted on behalf of
+// their users. Keys of a user expiring within the same second, or within a
+// tenth of their ttl for long ttls, share a lease, so that users putting keys
+// with ttls cause about a lease grant per second rather than one per key.
+type ttlLeasePool struct {
+	now   func() time.Time
+	grant func(ctx context.Context, ttl int64) (int64, error)
+
+	mu     sync.Mutex
+	leases map[ttlLeaseKey]*ttlLease
+}
+
+type ttlLeaseKey struct {
+	// user is the user putting the keys. Leases are not shared between users,
+	// as a user may only attach keys to a lease if it can write all the keys
+	// already attached to it.
+	user string
+	// expiry is the unix time in seconds the lease expires at.
+	expiry int64
+}
+
+type ttlLease struct {
+	// ready is closed once the lease is granted or failed to be.
+	ready chan struct{}
+	id    int64
+	err   error
+}
+
+func newTTLLeasePool(grant func(ctx context.Context, ttl int64) (int64, error)) *ttlLeasePool {
+	return &ttlLeasePool{now: time.Now, grant: grant, leases: make(map[ttlLeaseKey]*ttlLease)}
+}
+
+// Lease returns the ID of a lease of user expiring no earlier than ttl seconds
+// from now, granting it if needed.
+func (p *ttlLeasePool) Lease(ctx context.Context, user string, ttl int64) (int64, error) {
+	now := p.now()
+	key := ttlLeaseKey{user: user, expiry: ttlLeaseExpiry(now, ttl)}
+
+	p.mu.Lock()
+	l, ok := p.leases[key]
+	if !ok {
+		p.evictExpired(now)
+		l = &ttlLease{ready: make(chan struct{})}
+		p.leases[key] = l
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		// the lease expires when granted plus its ttl, which is after expiry
+		// as the ttl is counted from the start of the current second.
+		l.id, l.err = p.grant(ctx, key.expiry-now.Unix())
+		if l.err != nil {
+			p.mu.Lock()
+			if p.leases[key] == l {
+				delete(p.leases, key)
+			}
+			p.mu.Unlock()
+		}
+		close(l.ready)
+	}
+
+	select {
+	case <-l.ready:
+		return l.id, l.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Forget stops handing out the lease id of user, e.g. after finding it was
+// revoked.
+func (p *ttlLeasePool) Forget(user string, id int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, l := range p.leases {
+		if key.user != user {
+			continue
+		}
+		select {
+		case <-l.ready:
+			if l.id == id {
+				delete(p.leases, key)
+			}
+		default:
+		}
+	}
+}
+
+func (p *ttlLeasePool) evictExpired(now time.Time) {
+	for key := range p.leases {
+		if key.expiry <= now.Unix() {
+			delete(p.leases, key)
+		}
+	}
+}
+
+// ttlLeaseExpiry returns the unix time in seconds a key put at now with ttl
+// expires at, rounded up so that keys of close expiries share it.
+func ttlLeaseExpiry(now time.Time, ttl int64) int64 {
+	granularity := ttl / ttlLeaseSpread
+	if granularity < 1 {
+		granularity = 1
+	}
+	expiry := now.Unix() + ttl
+	if now.Nanosecond() != 0 {
+		expiry++
+	}
+	return (expiry + granularity - 1) / granularity * granularity
+}
+
+// ttlPuts returns the puts with a ttl of ops, including the ones of nested
+// transactions.
+func ttlPuts(ops []*pb.RequestOp) (puts []*pb.PutRequest) {
+	for _, op := range ops {
+		switch r := op.Request.(type) {
+		case *pb.RequestOp_RequestPut:
+			if r.RequestPut.Ttl > 0 {
+				puts = append(puts, r.RequestPut)
+			}
+		case *pb.RequestOp_RequestTxn:
+			puts = append(puts, ttlPuts(r.RequestTxn.Success)...)
+			puts = append(puts, ttlPuts(r.RequestTxn.Failure)...)
+		}
+	}
+	return puts
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestTTLLeaseExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tcs := []struct {
+		now  time.Time
+		ttl  int64
+		want int64
+	}{
+		{now: now, ttl: 5, want: 1005},
+		{now: now.Add(time.Millisecond), ttl: 5, want: 1006},
+		{now: now.Add(999 * time.Millisecond), ttl: 5, want: 1006},
+		// expiries are rounded up to a tenth of the ttl
+		{now: now, ttl: 100, want: 1100},
+		{now: now.Add(time.Second), ttl: 100, want: 1110},
+		{now: now.Add(9 * time.Second), ttl: 100, want: 1110},
+	}
+	for _, tc := range tcs {
+		if got := ttlLeaseExpiry(tc.now, tc.ttl); got != tc.want {
+			t.Errorf("expected expiry of ttl %d at %v to be %d, got %d", tc.ttl, tc.now, tc.want, got)
+		}
+	}
+}
+
+func TestTTLLeasePool(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var grants []int64
+	p := newTTLLeasePool(func(ctx context.Context, ttl int64) (int64, error) {
+		grants = append(grants, ttl)
+		return int64(len(grants)), nil
+	})
+	p.now = func() time.Time { return now }
+
+	lease := func(user string, ttl int64) int64 {
+		t.Helper()
+		id, err := p.Lease(context.Background(), user, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	if id := lease("", 10); id != 1 {
+		t.Fatalf("expected lease 1, got %d", id)
+	}
+	now = now.Add(300 * time.Millisecond)
+	if id := lease("", 9); id != 1 {
+		t.Errorf("expected keys expiring in the same second to share lease 1, got %d", id)
+	}
+	if id := lease("", 10); id != 2 {
+		t.Errorf("expected keys expiring a second later to get lease 2, got %d", id)
+	}
+	if id := lease("alice", 9); id != 3 {
+		t.Errorf("expected keys of another user to get lease 3, got %d", id)
+	}
+	if want := []int64{10, 11, 10}; !reflect.DeepEqual(grants, want) {
+		t.Errorf("expected grants of ttls %v, got %v", want, grants)
+	}
+
+	p.Forget("", 1)
+	if id := lease("", 9); id != 4 {
+		t.Errorf("expected forgotten lease to be replaced by lease 4, got %d", id)
+	}
+
+	now = now.Add(20 * time.Second)
+	lease("", 1)
+	if len(p.leases) != 1 {
+		t.Errorf("expected expired leases to be evicted, got %d leases", len(p.leases))
+	}
+}
+
+func TestTTLLeasePoolGrantError(t *testing.T) {
+	errGrant := errors.New("grant failed")
+	err := errGrant
+	p := newTTLLeasePool(func(ctx context.Context, ttl int64) (int64, error) { return 1, err })
+	if _, gerr := p.Lease(context.Background(), "", 5); !errors.Is(gerr, errGrant) {
+		t.Fatalf("expected %v, got %v", errGrant, gerr)
+	}
+	err = nil
+	if id, gerr := p.Lease(context.Background(), "", 5); gerr != nil || id != 1 {
+		t.Fatalf("expected failed grant to be retried, got lease %d and error %v", id, gerr)
+	}
+}
+
+func TestTTLPuts(t *testing.T) {
+	put := func(ttl int64) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("foo"), Ttl: ttl}}}
+	}
+	ops := []*pb.RequestOp{
+		put(0),
+		put(5),
+		{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("foo")}}},
+		{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+			Success: []*pb.RequestOp{put(6)},
+			Failure: []*pb.RequestOp{put(7), put(0)},
+		}}},
+	}
+	var ttls []int64
+	for _, p := range ttlPuts(ops) {
+		ttls = append(ttls, p.Ttl)
+	}
+	if want := []int64{5, 6, 7}; !reflect.DeepEqual(ttls, want) {
+		t.Errorf("expected puts with ttls %v, got %v", want, ttls)
+	}
+}
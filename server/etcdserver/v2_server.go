@@ -16,6 +16,7 @@ package etcdserver
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -105,6 +106,8 @@ func (a *reqV2HandlerEtcdServer) processRaftRequest(ctx context.Context, r *Requ
 	a.s.r.Propose(ctx, data)
 	proposalsPending.Inc()
 	defer proposalsPending.Dec()
+	atomic.AddInt64(&a.s.pendingProposals, 1)
+	defer atomic.AddInt64(&a.s.pendingProposals, -1)
 
 	select {
 	case x := <-ch:
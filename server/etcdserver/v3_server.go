@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -30,6 +31,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/lease/leasehttp"
+	serverstorage "go.etcd.io/etcd/server/v3/storage"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 
 	"github.com/gogo/protobuf/proto"
@@ -104,10 +106,16 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 	)
 	ctx = context.WithValue(ctx, traceutil.TraceKey, trace)
 
+	user, err := s.checkRangeRateLimits(ctx, rangeRateOp{key: r.Key, end: r.RangeEnd})
+	if err != nil {
+		return nil, err
+	}
+
 	var resp *pb.RangeResponse
-	var err error
+	var readStart time.Time
 	defer func(start time.Time) {
 		warnOfExpensiveReadOnlyRangeRequest(s.Logger(), s.Cfg.WarningApplyDuration, start, r, resp, err)
+		s.observeRead(ctx, "range", r.Key, r.RangeEnd, r.Size(), resp, err, start, readStart)
 		if resp != nil {
 			trace.AddField(
 				traceutil.Field{Key: "response_count", Value: len(resp.Kvs)},
@@ -124,6 +132,7 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 			return nil, err
 		}
 	}
+	readStart = time.Now()
 	chk := func(ai *auth.AuthInfo) error {
 		return s.authStore.IsRangePermitted(ai, r.Key, r.RangeEnd)
 	}
@@ -133,12 +142,18 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 		err = serr
 		return nil, err
 	}
+	if resp != nil {
+		s.rangeRateLimiter.chargeReads(user, resp.Kvs)
+	}
 	return resp, err
 }
 
 func (s *EtcdServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	if _, err := s.checkRangeRateLimits(ctx, putRangeRateOp(r)); err != nil {
+		return nil, err
+	}
 	ctx = context.WithValue(ctx, traceutil.StartTimeKey, time.Now())
-	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{Put: r})
+	resp, err := s.raftRequestWithTTLs(ctx, pb.InternalRaftRequest{Put: r}, ttlPuts([]*pb.RequestOp{{Request: &pb.RequestOp_RequestPut{RequestPut: r}}}))
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +161,9 @@ func (s *EtcdServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse
 }
 
 func (s *EtcdServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	if _, err := s.checkRangeRateLimits(ctx, deleteRangeRateOp(r)); err != nil {
+		return nil, err
+	}
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{DeleteRange: r})
 	if err != nil {
 		return nil, err
@@ -154,12 +172,17 @@ func (s *EtcdServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest)
 }
 
 func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	user, err := s.checkRangeRateLimits(ctx, txnRangeRateOps(r)...)
+	if err != nil {
+		return nil, err
+	}
 	if isTxnReadonly(r) {
 		trace := traceutil.New("transaction",
 			s.Logger(),
 			traceutil.Field{Key: "read_only", Value: true},
 		)
 		ctx = context.WithValue(ctx, traceutil.TraceKey, trace)
+		start := time.Now()
 		if !isTxnSerializable(r) {
 			err := s.linearizableReadNotify(ctx)
 			trace.Step("agreement among raft nodes before linearized reading")
@@ -173,8 +196,10 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 			return checkTxnAuth(s.authStore, ai, r)
 		}
 
-		defer func(start time.Time) {
-			warnOfExpensiveReadOnlyTxnRequest(s.Logger(), s.Cfg.WarningApplyDuration, start, r, resp, err)
+		defer func(readStart time.Time) {
+			warnOfExpensiveReadOnlyTxnRequest(s.Logger(), s.Cfg.WarningApplyDuration, readStart, r, resp, err)
+			key, end := txnRange(r)
+			s.observeRead(ctx, "txn", key, end, r.Size(), resp, err, start, readStart)
 			trace.LogIfLong(traceThreshold)
 		}(time.Now())
 
@@ -182,14 +207,18 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 		if serr := s.doSerialize(ctx, chk, get); serr != nil {
 			return nil, serr
 		}
+		if resp != nil {
+			s.rangeRateLimiter.chargeReads(user, txnRangeKVs(resp))
+		}
 		return resp, err
 	}
 
 	ctx = context.WithValue(ctx, traceutil.StartTimeKey, time.Now())
-	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{Txn: r})
+	resp, err := s.raftRequestWithTTLs(ctx, pb.InternalRaftRequest{Txn: r}, append(ttlPuts(r.Success), ttlPuts(r.Failure)...))
 	if err != nil {
 		return nil, err
 	}
+	s.rangeRateLimiter.chargeReads(user, txnRangeKVs(resp.(*pb.TxnResponse)))
 	return resp.(*pb.TxnResponse), nil
 }
 
@@ -222,8 +251,14 @@ func isTxnReadonly(r *pb.TxnRequest) bool {
 }
 
 func (s *EtcdServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	return s.CompactWithRetentions(ctx, r, nil)
+}
+
+// CompactWithRetentions compacts like Compact, keeping the history of the keys
+// retained by the retentions on all members.
+func (s *EtcdServer) CompactWithRetentions(ctx context.Context, r *pb.CompactionRequest, retentions []*pb.CompactionRetention) (*pb.CompactionResponse, error) {
 	startTime := time.Now()
-	result, err := s.processInternalRaftRequestOnce(ctx, pb.InternalRaftRequest{Compaction: r})
+	result, err := s.processInternalRaftRequestOnce(ctx, pb.InternalRaftRequest{Compaction: r, CompactionRetentions: retentions})
 	trace := traceutil.TODO()
 	if result != nil && result.trace != nil {
 		trace = result.trace
@@ -351,7 +386,7 @@ func (s *EtcdServer) LeaseTimeToLive(ctx context.Context, r *pb.LeaseTimeToLiveR
 			return nil, lease.ErrLeaseNotFound
 		}
 		// TODO: fill out ResponseHeader
-		resp := &pb.LeaseTimeToLiveResponse{Header: &pb.ResponseHeader{}, ID: r.ID, TTL: int64(le.Remaining().Seconds()), GrantedTTL: le.TTL()}
+		resp := &pb.LeaseTimeToLiveResponse{Header: &pb.ResponseHeader{}, ID: r.ID, TTL: int64(le.Remaining().Seconds()), GrantedTTL: le.TTL(), Labels: le.Labels()}
 		if r.Keys {
 			ks := le.Keys()
 			kbs := make([][]byte, len(ks))
@@ -394,7 +429,7 @@ func (s *EtcdServer) LeaseLeases(ctx context.Context, r *pb.LeaseLeasesRequest)
 	ls := s.lessor.Leases()
 	lss := make([]*pb.LeaseStatus, len(ls))
 	for i := range ls {
-		lss[i] = &pb.LeaseStatus{ID: int64(ls[i].ID)}
+		lss[i] = &pb.LeaseStatus{ID: int64(ls[i].ID), Labels: ls[i].Labels()}
 	}
 	return &pb.LeaseLeasesResponse{Header: newHeader(s), Leases: lss}, nil
 }
@@ -419,6 +454,123 @@ func (s *EtcdServer) waitLeader(ctx context.Context) (*membership.Member, error)
 	return leader, nil
 }
 
+func (s *EtcdServer) NamespacePut(ctx context.Context, r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error) {
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{NamespacePut: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.NamespacePutResponse), nil
+}
+
+func (s *EtcdServer) NamespaceDelete(ctx context.Context, r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error) {
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{NamespaceDelete: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.NamespaceDeleteResponse), nil
+}
+
+// NamespaceList returns the namespaces with their usage, as of the last write
+// applied by the member once it caught up with the leader.
+func (s *EtcdServer) NamespaceList(ctx context.Context, r *pb.NamespaceListRequest) (*pb.NamespaceListResponse, error) {
+	if err := s.linearizableReadNotify(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.NamespaceListResponse{Namespaces: s.namespaces.list()}, nil
+}
+
+func (s *EtcdServer) IndexPut(ctx context.Context, r *pb.IndexPutRequest) (*pb.IndexPutResponse, error) {
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{IndexPut: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.IndexPutResponse), nil
+}
+
+func (s *EtcdServer) IndexDelete(ctx context.Context, r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error) {
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{IndexDelete: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.IndexDeleteResponse), nil
+}
+
+// IndexList returns the indexes with the number of keys they index, as of the
+// last write applied by the member once it caught up with the leader.
+func (s *EtcdServer) IndexList(ctx context.Context, r *pb.IndexListRequest) (*pb.IndexListResponse, error) {
+	if err := s.linearizableReadNotify(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.IndexListResponse{Indexes: s.indexes.list()}, nil
+}
+
+// IndexRange gets the keys of an index with a value in the range of the
+// request. The keys are read at the revision the index is up to date with.
+func (s *EtcdServer) IndexRange(ctx context.Context, r *pb.IndexRangeRequest) (*pb.IndexRangeResponse, error) {
+	trace := traceutil.New("index_range",
+		s.Logger(),
+		traceutil.Field{Key: "index", Value: r.Name},
+		traceutil.Field{Key: "value", Value: string(r.Value)},
+		traceutil.Field{Key: "range_end", Value: string(r.RangeEnd)},
+	)
+	ctx = context.WithValue(ctx, traceutil.TraceKey, trace)
+	defer trace.LogIfLong(traceThreshold)
+
+	if !r.Serializable {
+		err := s.linearizableReadNotify(ctx)
+		trace.Step("agreement among raft nodes before linearized reading")
+		if err != nil {
+			return nil, err
+		}
+	}
+	prefix, err := s.indexes.prefix(r.Name)
+	if err != nil {
+		return nil, err
+	}
+	chk := func(ai *auth.AuthInfo) error {
+		key, end := namespaceRange(prefix)
+		return s.authStore.IsRangePermitted(ai, key, end)
+	}
+
+	var resp *pb.IndexRangeResponse
+	get := func() { resp, err = s.indexRange(ctx, r) }
+	if serr := s.doSerialize(ctx, chk, get); serr != nil {
+		return nil, serr
+	}
+	return resp, err
+}
+
+func (s *EtcdServer) indexRange(ctx context.Context, r *pb.IndexRangeRequest) (*pb.IndexRangeResponse, error) {
+	trace := traceutil.Get(ctx)
+	keys, count, txn, err := s.indexes.lookup(r, trace)
+	if err != nil {
+		return nil, err
+	}
+	defer txn.End()
+	trace.Step("look up the keys in the index")
+
+	resp := &pb.IndexRangeResponse{
+		Header: &pb.ResponseHeader{Revision: txn.Rev()},
+		Count:  count,
+		More:   int64(len(keys)) < count && !r.CountOnly,
+	}
+	for _, key := range keys {
+		rr, err := txn.Range(ctx, []byte(key), nil, mvcc.RangeOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range rr.KVs {
+			kv := &rr.KVs[i]
+			if r.KeysOnly {
+				kv.Value = nil
+			}
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	trace.Step("read the keys")
+	return resp, nil
+}
+
 func (s *EtcdServer) Alarm(ctx context.Context, r *pb.AlarmRequest) (*pb.AlarmResponse, error) {
 	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{Alarm: r})
 	if err != nil {
@@ -644,6 +796,65 @@ func (s *EtcdServer) raftRequest(ctx context.Context, r pb.InternalRaftRequest)
 	return s.raftRequestOnce(ctx, r)
 }
 
+// raftRequestWithTTLs attaches puts, the puts with a ttl of r, to leases of the
+// ttl lease pool before proposing r. If one of the leases is gone, e.g. revoked
+// by a user, r is proposed once more with new leases.
+func (s *EtcdServer) raftRequestWithTTLs(ctx context.Context, r pb.InternalRaftRequest, puts []*pb.PutRequest) (proto.Message, error) {
+	if len(puts) == 0 {
+		return s.raftRequest(ctx, r)
+	}
+	var user string
+	ai, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ai != nil {
+		user = ai.Username
+	}
+	ttls := make([]int64, len(puts))
+	for i, put := range puts {
+		ttls[i] = put.Ttl
+	}
+	// the leases differ between members, retries through another member must
+	// hash the same
+	ctx = withRequestHash(ctx, &r)
+	for retried := false; ; retried = true {
+		for i, put := range puts {
+			id, err := s.ttlLeases.Lease(ctx, user, ttls[i])
+			if err != nil {
+				return nil, err
+			}
+			put.Lease, put.Ttl = id, 0
+		}
+		resp, err := s.raftRequest(ctx, r)
+		if err != lease.ErrLeaseNotFound || retried {
+			return resp, err
+		}
+		for _, put := range puts {
+			s.ttlLeases.Forget(user, put.Lease)
+		}
+	}
+}
+
+// checkRangeRateLimits returns ErrRangeRateLimitExceeded if the operations of a
+// request exceed the range rate limits, and otherwise the user the bytes read
+// by the request are to be charged to. The limits only account for the
+// requests served by this member.
+func (s *EtcdServer) checkRangeRateLimits(ctx context.Context, ops ...rangeRateOp) (string, error) {
+	if s.rangeRateLimiter == nil {
+		return "", nil
+	}
+	var user string
+	ai, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ai != nil {
+		user = ai.Username
+	}
+	return user, s.rangeRateLimiter.allow(user, ops)
+}
+
 // doSerialize handles the auth logic, with permissions checked by "chk", for a serialized request "get". Returns a non-nil error on authentication failure.
 func (s *EtcdServer) doSerialize(ctx context.Context, chk func(*auth.AuthInfo) error, get func()) error {
 	trace := traceutil.Get(ctx)
@@ -669,12 +880,23 @@ func (s *EtcdServer) doSerialize(ctx context.Context, chk func(*auth.AuthInfo) e
 	return nil
 }
 
-func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.InternalRaftRequest) (*applyResult, error) {
+func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.InternalRaftRequest) (ar *applyResult, err error) {
+	if atomic.LoadInt32(&s.importing) != 0 {
+		return nil, ErrImportInProgress
+	}
+	s.importMu.RLock()
+	defer s.importMu.RUnlock()
+
 	ai := s.getAppliedIndex()
 	ci := s.getCommittedIndex()
 	if ci > ai+maxGapBetweenApplyAndCommitIndex {
 		return nil, ErrTooManyRequests
 	}
+	if throttledRequest(&r) {
+		if err := s.throttleOnFollowerLag(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	r.Header = &pb.RequestHeader{
 		ID: s.reqIDGen.Next(),
@@ -689,8 +911,15 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		if authInfo != nil {
 			r.Header.Username = authInfo.Username
 			r.Header.AuthRevision = authInfo.Revision
+			r.Header.Roles = authInfo.Roles
+		}
+	}
+	if r.Put != nil || r.DeleteRange != nil || r.Txn != nil {
+		if err := s.setRequestID(ctx, &r); err != nil {
+			return nil, err
 		}
 	}
+	r.Header.QuotaExempt = s.quotaExempt(&r)
 
 	data, err := r.Marshal()
 	if err != nil {
@@ -705,20 +934,29 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 	if id == 0 {
 		id = r.Header.ID
 	}
+	span := s.spans.start(ctx, &r, id)
+	defer s.spans.finish(id, span)
 	ch := s.w.Register(id)
 
 	cctx, cancel := context.WithTimeout(ctx, s.Cfg.ReqTimeout())
 	defer cancel()
 
 	start := time.Now()
+	if s.slowLog != nil {
+		defer func() { s.slowLog.observeRaftRequest(&r, len(data), start, ar, err) }()
+	}
 	err = s.r.Propose(cctx, data)
 	if err != nil {
 		proposalsFailed.Inc()
+		span.RecordError(err)
 		s.w.Trigger(id, nil) // GC wait
 		return nil, err
 	}
+	span.AddEvent("proposed")
 	proposalsPending.Inc()
 	defer proposalsPending.Dec()
+	atomic.AddInt64(&s.pendingProposals, 1)
+	defer atomic.AddInt64(&s.pendingProposals, -1)
 
 	select {
 	case x := <-ch:
@@ -732,6 +970,27 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 	}
 }
 
+// quotaExempt reports whether the write of r only writes keys under the quota
+// exempted prefixes and fits in the exempted bytes, so that it is applied
+// while the NOSPACE alarm is raised. The backend sizes differ between members,
+// so the proposing member decides for all of them.
+func (s *EtcdServer) quotaExempt(r *pb.InternalRaftRequest) bool {
+	if len(s.Cfg.QuotaExemptPrefixes) == 0 {
+		return false
+	}
+	var req interface{}
+	switch {
+	case r.Put != nil:
+		req = r.Put
+	case r.Txn != nil:
+		req = r.Txn
+	default:
+		return false
+	}
+	q := serverstorage.NewBackendQuota(s.Cfg, s.Backend(), "v3-exempt")
+	return q.Exempt(req) && q.Available(req)
+}
+
 // Watchable returns a watchable interface attached to the etcdserver.
 func (s *EtcdServer) Watchable() mvcc.WatchableKV { return s.KV() }
 
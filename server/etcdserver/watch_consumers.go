@@ -0,0 +1,264 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// watchRateWindow is the period the event rate of watches is computed over.
+const watchRateWindow = 10 * time.Second
+
+// WatchConsumers tracks the watches registered by the clients of the member
+// and the events sent to them, to find the watches overloading the member.
+type WatchConsumers struct {
+	now func() time.Time
+
+	mu           sync.Mutex
+	nextStreamID uint64
+	streams      map[uint64]*WatchConsumerStream
+}
+
+func NewWatchConsumers() *WatchConsumers {
+	return &WatchConsumers{now: time.Now, streams: make(map[uint64]*WatchConsumerStream)}
+}
+
+// NewStream registers a watch stream opened by user from remoteAddr.
+// backlog returns the number of responses waiting to be sent on the stream.
+// The stream must be closed once it ends.
+func (wc *WatchConsumers) NewStream(user, remoteAddr string, backlog func() int) *WatchConsumerStream {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.nextStreamID++
+	s := &WatchConsumerStream{
+		wc:         wc,
+		id:         wc.nextStreamID,
+		user:       user,
+		remoteAddr: remoteAddr,
+		backlog:    backlog,
+		watches:    make(map[int64]*watchConsumer),
+	}
+	wc.streams[s.id] = s
+	return s
+}
+
+// Top returns the n watches with the highest event rate.
+func (wc *WatchConsumers) Top(n int) []*pb.WatchConsumer {
+	now := wc.now()
+	var consumers []*pb.WatchConsumer
+	wc.mu.Lock()
+	for _, s := range wc.streams {
+		consumers = append(consumers, s.consumers(now)...)
+	}
+	wc.mu.Unlock()
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].EventsPerSecond != consumers[j].EventsPerSecond {
+			return consumers[i].EventsPerSecond > consumers[j].EventsPerSecond
+		}
+		return consumers[i].EventsSent > consumers[j].EventsSent
+	})
+	if len(consumers) > n {
+		consumers = consumers[:n]
+	}
+	return consumers
+}
+
+// TopRanges returns the n watched ranges with the most watches, and the n
+// with the largest backlog, the backlog of a range being the one of the
+// streams of its watches.
+func (wc *WatchConsumers) TopRanges(n int) (watched, backlogged []*pb.WatchRange) {
+	type rangeKey struct{ key, end string }
+	ranges := make(map[rangeKey]*pb.WatchRange)
+	wc.mu.Lock()
+	for _, s := range wc.streams {
+		backlog := int64(s.backlog())
+		s.mu.Lock()
+		seen := make(map[rangeKey]bool)
+		for _, w := range s.watches {
+			k := rangeKey{string(w.key), string(w.end)}
+			r, ok := ranges[k]
+			if !ok {
+				r = &pb.WatchRange{Key: w.key, RangeEnd: w.end}
+				ranges[k] = r
+			}
+			r.Watchers++
+			if !seen[k] {
+				// the backlog of the stream is counted once per range
+				seen[k] = true
+				r.Backlog += backlog
+			}
+		}
+		s.mu.Unlock()
+	}
+	wc.mu.Unlock()
+
+	all := make([]*pb.WatchRange, 0, len(ranges))
+	for _, r := range ranges {
+		all = append(all, r)
+	}
+	top := func(count func(*pb.WatchRange) int64) []*pb.WatchRange {
+		rs := append([]*pb.WatchRange(nil), all...)
+		sort.Slice(rs, func(i, j int) bool {
+			if ci, cj := count(rs[i]), count(rs[j]); ci != cj {
+				return ci > cj
+			}
+			if !bytes.Equal(rs[i].Key, rs[j].Key) {
+				return bytes.Compare(rs[i].Key, rs[j].Key) < 0
+			}
+			return bytes.Compare(rs[i].RangeEnd, rs[j].RangeEnd) < 0
+		})
+		if len(rs) > n {
+			rs = rs[:n]
+		}
+		return rs
+	}
+	watched = top(func(r *pb.WatchRange) int64 { return r.Watchers })
+	for _, r := range top(func(r *pb.WatchRange) int64 { return r.Backlog }) {
+		if r.Backlog > 0 {
+			backlogged = append(backlogged, r)
+		}
+	}
+	return watched, backlogged
+}
+
+// Len returns the number of tracked watches.
+func (wc *WatchConsumers) Len() int {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	n := 0
+	for _, s := range wc.streams {
+		s.mu.Lock()
+		n += len(s.watches)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// WatchConsumerStream tracks the watches of a single watch stream.
+type WatchConsumerStream struct {
+	wc         *WatchConsumers
+	id         uint64
+	user       string
+	remoteAddr string
+	backlog    func() int
+
+	mu      sync.Mutex
+	watches map[int64]*watchConsumer
+}
+
+// Add starts tracking the watch of the range [key, end).
+func (s *WatchConsumerStream) Add(watchID int64, key, end []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches[watchID] = &watchConsumer{key: key, end: end, created: s.wc.now()}
+}
+
+// Remove stops tracking the watch.
+func (s *WatchConsumerStream) Remove(watchID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watches, watchID)
+}
+
+// Record counts events sent to the watch.
+func (s *WatchConsumerStream) Record(watchID int64, events int) {
+	now := s.wc.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.watches[watchID]; ok {
+		w.record(now, int64(events))
+	}
+}
+
+// Close stops tracking the stream and its watches.
+func (s *WatchConsumerStream) Close() {
+	s.wc.mu.Lock()
+	defer s.wc.mu.Unlock()
+	delete(s.wc.streams, s.id)
+}
+
+func (s *WatchConsumerStream) consumers(now time.Time) []*pb.WatchConsumer {
+	backlog := int64(s.backlog())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	consumers := make([]*pb.WatchConsumer, 0, len(s.watches))
+	for id, w := range s.watches {
+		consumers = append(consumers, &pb.WatchConsumer{
+			StreamId:        s.id,
+			WatchId:         id,
+			User:            s.user,
+			RemoteAddr:      s.remoteAddr,
+			Key:             w.key,
+			RangeEnd:        w.end,
+			EventsPerSecond: w.rate(now),
+			EventsSent:      w.sent,
+			Backlog:         backlog,
+		})
+	}
+	return consumers
+}
+
+// watchConsumer counts the events sent to a watch. The rate is estimated
+// from the events of the current window and, proportionally to the part of
+// the window that overlaps the last watchRateWindow, of the previous one.
+type watchConsumer struct {
+	key, end []byte
+	created  time.Time
+	sent     int64
+
+	windowStart time.Time
+	cur, prev   int64
+}
+
+func (w *watchConsumer) record(now time.Time, events int64) {
+	w.roll(now)
+	w.cur += events
+	w.sent += events
+}
+
+func (w *watchConsumer) roll(now time.Time) {
+	if w.windowStart.IsZero() {
+		w.windowStart = w.created
+	}
+	elapsed := now.Sub(w.windowStart)
+	if elapsed < watchRateWindow {
+		return
+	}
+	if elapsed < 2*watchRateWindow {
+		w.prev = w.cur
+	} else {
+		w.prev = 0
+	}
+	w.cur = 0
+	w.windowStart = w.windowStart.Add(elapsed.Truncate(watchRateWindow))
+}
+
+func (w *watchConsumer) rate(now time.Time) float64 {
+	w.roll(now)
+	if age := now.Sub(w.created); age < watchRateWindow {
+		// the watch is younger than the window, there is no previous one
+		if age <= 0 {
+			return 0
+		}
+		return float64(w.cur) / age.Seconds()
+	}
+	overlap := 1 - float64(now.Sub(w.windowStart))/float64(watchRateWindow)
+	return (float64(w.prev)*overlap + float64(w.cur)) / watchRateWindow.Seconds()
+}
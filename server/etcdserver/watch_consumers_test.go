@@ -0,0 +1,105 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchConsumersTop(t *testing.T) {
+	now := time.Unix(0, 0)
+	wc := NewWatchConsumers()
+	wc.now = func() time.Time { return now }
+
+	s1 := wc.NewStream("alice", "10.0.0.1:1234", func() int { return 3 })
+	s1.Add(1, []byte("/a"), nil)
+	s1.Add(2, []byte("/b"), []byte("/c"))
+	s2 := wc.NewStream("", "10.0.0.2:1234", func() int { return 0 })
+	s2.Add(1, []byte("/d"), nil)
+
+	now = now.Add(5 * time.Second)
+	s1.Record(1, 10)
+	s1.Record(2, 50)
+	s2.Record(1, 20)
+	// events of unknown watches are ignored
+	s2.Record(7, 100)
+
+	top := wc.Top(2)
+	assert.Len(t, top, 2)
+	assert.Equal(t, []byte("/b"), top[0].Key)
+	assert.Equal(t, []byte("/c"), top[0].RangeEnd)
+	assert.Equal(t, "alice", top[0].User)
+	assert.Equal(t, "10.0.0.1:1234", top[0].RemoteAddr)
+	assert.Equal(t, int64(3), top[0].Backlog)
+	assert.Equal(t, int64(50), top[0].EventsSent)
+	assert.Equal(t, 10.0, top[0].EventsPerSecond)
+	assert.Equal(t, []byte("/d"), top[1].Key)
+	assert.Equal(t, 4.0, top[1].EventsPerSecond)
+
+	s1.Remove(2)
+	s2.Close()
+	top = wc.Top(10)
+	assert.Len(t, top, 1)
+	assert.Equal(t, int64(1), top[0].WatchId)
+}
+
+func TestWatchConsumersTopRanges(t *testing.T) {
+	wc := NewWatchConsumers()
+	s1 := wc.NewStream("", "10.0.0.1:1234", func() int { return 5 })
+	s1.Add(1, []byte("/a"), nil)
+	s1.Add(2, []byte("/a"), nil)
+	s1.Add(3, []byte("/b"), []byte("/c"))
+	s2 := wc.NewStream("", "10.0.0.2:1234", func() int { return 0 })
+	s2.Add(1, []byte("/a"), nil)
+	s2.Add(2, []byte("/d"), nil)
+
+	watched, backlogged := wc.TopRanges(2)
+	assert.Len(t, watched, 2)
+	assert.Equal(t, []byte("/a"), watched[0].Key)
+	assert.Equal(t, int64(3), watched[0].Watchers)
+	// the backlog of a stream is counted once for its watches of a range
+	assert.Equal(t, int64(5), watched[0].Backlog)
+	assert.Equal(t, []byte("/b"), watched[1].Key)
+	assert.Equal(t, []byte("/c"), watched[1].RangeEnd)
+
+	// the ranges without backlog are left out
+	assert.Len(t, backlogged, 2)
+	assert.Equal(t, []byte("/a"), backlogged[0].Key)
+	assert.Equal(t, []byte("/b"), backlogged[1].Key)
+	assert.Equal(t, int64(5), backlogged[1].Backlog)
+}
+
+func TestWatchConsumerRate(t *testing.T) {
+	start := time.Unix(0, 0)
+	w := &watchConsumer{created: start}
+
+	// a young watch is rated over its age
+	w.record(start.Add(2*time.Second), 20)
+	assert.Equal(t, 10.0, w.rate(start.Add(2*time.Second)))
+
+	// at the start of the next window, all previous events are counted
+	w.record(start.Add(10*time.Second), 0)
+	assert.Equal(t, 2.0, w.rate(start.Add(10*time.Second)))
+	// half way through, half of them are
+	w.record(start.Add(15*time.Second), 5)
+	assert.Equal(t, 1.5, w.rate(start.Add(15*time.Second)))
+
+	// the rate drops to zero once the watch stops receiving events
+	assert.Equal(t, 0.0, w.rate(start.Add(40*time.Second)))
+	assert.Equal(t, int64(25), w.sent)
+}
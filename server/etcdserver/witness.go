@@ -0,0 +1,132 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	"go.uber.org/zap"
+)
+
+// A witness is a voting member that stores no key-value data. It breaks ties
+// between two data members, so that a cluster of two data members and a
+// witness tolerates the loss of any one member. As a witness may hold
+// committed entries that the remaining data member misses, it campaigns like
+// any voter, but it only leads until a data member has caught up from its log
+// and then hands the leadership over.
+
+// isAppliedByWitness returns if a witness applies the given request. Witnesses
+// only keep the cluster-wide state (cluster version, member attributes,
+// downgrade info, alarms, auth) up to date; any other request, including the
+// ones added later, touches the key-value data and is skipped.
+func isAppliedByWitness(r *pb.InternalRaftRequest) bool {
+	switch {
+	case r.ClusterVersionSet != nil, r.ClusterMemberAttrSet != nil, r.DowngradeInfoSet != nil:
+		return true
+	case r.Alarm != nil:
+		return true
+	case r.Authenticate != nil, r.AuthEnable != nil, r.AuthDisable != nil, r.AuthStatus != nil:
+		return true
+	case r.AuthUserAdd != nil, r.AuthUserDelete != nil, r.AuthUserGet != nil, r.AuthUserChangePassword != nil,
+		r.AuthUserGrantRole != nil, r.AuthUserRevokeRole != nil, r.AuthUserList != nil:
+		return true
+	case r.AuthRoleAdd != nil, r.AuthRoleDelete != nil, r.AuthRoleGet != nil, r.AuthRoleGrantPermission != nil,
+		r.AuthRoleRevokePermission != nil, r.AuthRoleList != nil:
+		return true
+	}
+	return false
+}
+
+// isWitnessEmptyBucket returns if the given bucket is sent empty to witnesses.
+func isWitnessEmptyBucket(bucketName []byte) bool {
+	return bytes.Equal(bucketName, schema.Key.Name()) || bytes.Equal(bucketName, schema.Lease.Name())
+}
+
+// backendSnapshotFor returns the snapshot of the backend to send to the given
+// member. Witnesses get a snapshot without the key-value and lease data.
+func (s *EtcdServer) backendSnapshotFor(to types.ID) backend.Snapshot {
+	if !s.cluster.IsMemberWitness(to) {
+		return s.be.Snapshot()
+	}
+	snapshot, err := s.be.SnapshotWithout(isWitnessEmptyBucket)
+	if err != nil {
+		s.Logger().Warn(
+			"failed to create witness snapshot; sending full snapshot",
+			zap.String("remote-peer-id", to.String()),
+			zap.Error(err),
+		)
+		return s.be.Snapshot()
+	}
+	return snapshot
+}
+
+// leaderCandidateIDs returns the IDs of the voting members that can become
+// leader, that is, the voting members that are not witnesses.
+func (s *EtcdServer) leaderCandidateIDs() []types.ID {
+	var ids []types.ID
+	for _, m := range s.cluster.VotingMembers() {
+		if !m.IsWitness {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// handOverWitnessLeadership transfers the leadership of the local witness to a
+// data member. The transfer first catches the transferee up from the log of
+// the witness, so it is retried until a data member is reachable.
+func (s *EtcdServer) handOverWitnessLeadership() {
+	lg := s.Logger()
+	for {
+		err := s.TransferLeadership()
+		if err == nil {
+			return
+		}
+		lg.Warn(
+			"failed to hand over the leadership of witness",
+			zap.String("local-member-id", s.ID().String()),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(s.Cfg.ElectionTimeout()):
+		case <-s.stopping:
+			return
+		}
+		if !s.isLeader() {
+			return
+		}
+	}
+}
+
+// canSendSnapshotTo returns if the local member can send a snapshot to the
+// given member. A witness has no key-value data to send, so a data member
+// that lags behind its log cannot be caught up by it.
+func (s *EtcdServer) canSendSnapshotTo(to types.ID) bool {
+	if !s.IsWitness() || s.cluster.IsMemberWitness(to) {
+		return true
+	}
+	s.Logger().Warn(
+		"witness cannot send snapshot to data member",
+		zap.String("local-member-id", s.ID().String()),
+		zap.String("remote-peer-id", to.String()),
+	)
+	return false
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/membershippb"
+)
+
+func TestIsAppliedByWitness(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *pb.InternalRaftRequest
+		want bool
+	}{
+		{"cluster version", &pb.InternalRaftRequest{ClusterVersionSet: &membershippb.ClusterVersionSetRequest{}}, true},
+		{"member attributes", &pb.InternalRaftRequest{ClusterMemberAttrSet: &membershippb.ClusterMemberAttrSetRequest{}}, true},
+		{"downgrade info", &pb.InternalRaftRequest{DowngradeInfoSet: &membershippb.DowngradeInfoSetRequest{}}, true},
+		{"alarm", &pb.InternalRaftRequest{Alarm: &pb.AlarmRequest{}}, true},
+		{"auth enable", &pb.InternalRaftRequest{AuthEnable: &pb.AuthEnableRequest{}}, true},
+		{"user add", &pb.InternalRaftRequest{AuthUserAdd: &pb.AuthUserAddRequest{}}, true},
+		{"role grant", &pb.InternalRaftRequest{AuthRoleGrantPermission: &pb.AuthRoleGrantPermissionRequest{}}, true},
+		{"put", &pb.InternalRaftRequest{Put: &pb.PutRequest{}}, false},
+		{"txn", &pb.InternalRaftRequest{Txn: &pb.TxnRequest{}}, false},
+		{"lease grant", &pb.InternalRaftRequest{LeaseGrant: &pb.LeaseGrantRequest{}}, false},
+		{"lease expire", &pb.InternalRaftRequest{LeaseExpire: &pb.LeaseExpireRequest{}}, false},
+		{"namespace put", &pb.InternalRaftRequest{NamespacePut: &pb.NamespacePutRequest{}}, false},
+		{"index put", &pb.InternalRaftRequest{IndexPut: &pb.IndexPutRequest{}}, false},
+		{"cdc checkpoint", &pb.InternalRaftRequest{CdcCheckpoint: &pb.CDCCheckpointRequest{}}, false},
+		{"empty", &pb.InternalRaftRequest{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAppliedByWitness(tt.r); got != tt.want {
+				t.Errorf("isAppliedByWitness() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -32,7 +32,12 @@ type LeaseQueue []*LeaseWithTime
 
 func (pq LeaseQueue) Len() int { return len(pq) }
 
+// Less orders the leases by time, and then by ID so that leases expiring at the
+// same time are revoked in a deterministic order.
 func (pq LeaseQueue) Less(i, j int) bool {
+	if pq[i].time.Equal(pq[j].time) {
+		return pq[i].id < pq[j].id
+	}
 	return pq[i].time.Before(pq[j].time)
 }
 
@@ -17,6 +17,8 @@ package lease
 import (
 	"testing"
 	"time"
+
+	"github.com/jonboulle/clockwork"
 )
 
 func TestLeaseQueue(t *testing.T) {
@@ -25,6 +27,7 @@ func TestLeaseQueue(t *testing.T) {
 		leaseExpiredNotifier:      newLeaseExpiredNotifier(),
 		leaseMap:                  make(map[LeaseID]*Lease),
 		expiredLeaseRetryInterval: expiredRetryInterval,
+		clock:                     clockwork.NewRealClock(),
 	}
 	le.leaseExpiredNotifier.Init()
 
@@ -81,3 +84,20 @@ func TestLeaseQueue(t *testing.T) {
 	time.Sleep(expiredRetryInterval)
 	existExpiredEvent() // acquire after retry interval
 }
+
+// TestLeaseQueueTieOrder ensures leases expiring at the same time are expired
+// in the order of their IDs.
+func TestLeaseQueueTieOrder(t *testing.T) {
+	n := newLeaseExpiredNotifier()
+	n.Init()
+	exp := time.Now()
+	for _, id := range []LeaseID{3, 1, 4, 2} {
+		n.RegisterOrUpdate(&LeaseWithTime{id: id, time: exp})
+	}
+	for want := LeaseID(1); want <= 4; want++ {
+		if got := n.Poll().id; got != want {
+			t.Fatalf("expected lease ID %d, got %d", want, got)
+		}
+		n.Unregister()
+	}
+}
@@ -115,6 +115,7 @@ func (h *leaseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				ID:         lreq.LeaseTimeToLiveRequest.ID,
 				TTL:        int64(l.Remaining().Seconds()),
 				GrantedTTL: l.TTL(),
+				Labels:     l.Labels(),
 			},
 		}
 		if lreq.LeaseTimeToLiveRequest.Keys {
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/jonboulle/clockwork"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/server/v3/lease/leasepb"
 	"go.etcd.io/etcd/server/v3/storage/backend"
@@ -38,6 +39,10 @@ const NoLease = LeaseID(0)
 // MaxLeaseTTL is the maximum lease TTL value
 const MaxLeaseTTL = 9000000000
 
+// MaxLeaseLabelsBytes is the maximum total size of the keys and values of the
+// labels of a lease.
+const MaxLeaseLabelsBytes = 1024
+
 var v3_6 = semver.Version{Major: 3, Minor: 6}
 
 var (
@@ -58,10 +63,11 @@ var (
 	// the default interval to check if the expired lease is revoked
 	defaultExpiredleaseRetryInterval = 3 * time.Second
 
-	ErrNotPrimary       = errors.New("not a primary lessor")
-	ErrLeaseNotFound    = errors.New("lease not found")
-	ErrLeaseExists      = errors.New("lease already exists")
-	ErrLeaseTTLTooLarge = errors.New("too large lease TTL")
+	ErrNotPrimary          = errors.New("not a primary lessor")
+	ErrLeaseNotFound       = errors.New("lease not found")
+	ErrLeaseExists         = errors.New("lease already exists")
+	ErrLeaseTTLTooLarge    = errors.New("too large lease TTL")
+	ErrLeaseLabelsTooLarge = errors.New("too large lease labels")
 )
 
 // TxnDelete is a TxnWrite that only permits deletes. Defined here
@@ -91,11 +97,21 @@ type Lessor interface {
 
 	// Grant grants a lease that expires at least after TTL seconds.
 	Grant(id LeaseID, ttl int64) (*Lease, error)
+	// GrantWithLabels grants a lease like Grant, with the given labels
+	// attached to it.
+	GrantWithLabels(id LeaseID, ttl int64, labels map[string]string) (*Lease, error)
 	// Revoke revokes a lease with given ID. The item attached to the
 	// given lease will be removed. If the ID does not exist, an error
 	// will be returned.
 	Revoke(id LeaseID) error
 
+	// RevokeBatch revokes the given leases in order, deleting at most maxKeys
+	// of their attached keys in total, or all of them if maxKeys is zero. A
+	// lease with more keys than left to delete has only the first of them, in
+	// key order, deleted, and is not revoked. It returns the number of leases
+	// revoked or not found, from the first one, and the number of keys deleted.
+	RevokeBatch(ids []LeaseID, maxKeys int) (revoked int, deleted int)
+
 	// Checkpoint applies the remainingTTL of a lease. The remainingTTL is used in Promote to set
 	// the expiry of leases to less than the full TTL when possible.
 	Checkpoint(id LeaseID, remainingTTL int64) error
@@ -142,7 +158,6 @@ type Lessor interface {
 }
 
 // lessor implements Lessor interface.
-// TODO: use clockwork for testability.
 type lessor struct {
 	mu sync.RWMutex
 
@@ -185,8 +200,17 @@ type lessor struct {
 	expiredLeaseRetryInterval time.Duration
 	// whether lessor should always persist remaining TTL (always enabled in v3.6).
 	checkpointPersist bool
+	// fastRenew makes Renew return without waiting for the checkpointed
+	// remaining TTL of the lease to be cleared through raft. The clears are
+	// batched by the checkpoint loop instead.
+	fastRenew bool
+	// pendingCheckpointClears are the leases renewed by a fast renew whose
+	// checkpointed remaining TTL is yet to be cleared.
+	pendingCheckpointClears map[LeaseID]struct{}
 	// cluster is used to adapt lessor logic based on cluster version
 	cluster cluster
+	// clock is used to compute lease expiry and checkpoint times.
+	clock clockwork.Clock
 }
 
 type cluster interface {
@@ -199,6 +223,15 @@ type LessorConfig struct {
 	CheckpointInterval         time.Duration
 	ExpiredLeasesRetryInterval time.Duration
 	CheckpointPersist          bool
+	// FastRenew batches the raft entries clearing the checkpointed remaining
+	// TTL of renewed leases instead of proposing one per renewal. Until the
+	// batch is applied, a new leader may restore a renewed lease with the
+	// remaining TTL of its last checkpoint.
+	FastRenew bool
+	// Clock is used to expire leases, defaults to the real clock. Expired
+	// leases are still looked for every 500ms of real time, so advancing
+	// a fake clock past a lease expiry revokes the lease shortly after.
+	Clock clockwork.Clock
 }
 
 func NewLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorConfig) Lessor {
@@ -214,6 +247,10 @@ func newLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorCon
 	if expiredLeaseRetryInterval == 0 {
 		expiredLeaseRetryInterval = defaultExpiredleaseRetryInterval
 	}
+	clock := cfg.Clock
+	if clock == nil {
+		clock = clockwork.NewRealClock()
+	}
 	l := &lessor{
 		leaseMap:                  make(map[LeaseID]*Lease),
 		itemMap:                   make(map[LeaseItem]LeaseID),
@@ -224,12 +261,15 @@ func newLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorCon
 		checkpointInterval:        checkpointInterval,
 		expiredLeaseRetryInterval: expiredLeaseRetryInterval,
 		checkpointPersist:         cfg.CheckpointPersist,
+		fastRenew:                 cfg.FastRenew,
+		pendingCheckpointClears:   make(map[LeaseID]struct{}),
 		// expiredC is a small buffered chan to avoid unnecessary blocking.
 		expiredC: make(chan []*Lease, 16),
 		stopC:    make(chan struct{}),
 		doneC:    make(chan struct{}),
 		lg:       lg,
 		cluster:  cluster,
+		clock:    clock,
 	}
 	l.initAndRecover()
 
@@ -270,6 +310,10 @@ func (le *lessor) SetCheckpointer(cp Checkpointer) {
 }
 
 func (le *lessor) Grant(id LeaseID, ttl int64) (*Lease, error) {
+	return le.GrantWithLabels(id, ttl, nil)
+}
+
+func (le *lessor) GrantWithLabels(id LeaseID, ttl int64, labels map[string]string) (*Lease, error) {
 	if id == NoLease {
 		return nil, ErrLeaseNotFound
 	}
@@ -278,13 +322,19 @@ func (le *lessor) Grant(id LeaseID, ttl int64) (*Lease, error) {
 		return nil, ErrLeaseTTLTooLarge
 	}
 
+	if labelsSize(labels) > MaxLeaseLabelsBytes {
+		return nil, ErrLeaseLabelsTooLarge
+	}
+
 	// TODO: when lessor is under high load, it should give out lease
 	// with longer TTL to reduce renew load.
 	l := &Lease{
 		ID:      id,
 		ttl:     ttl,
+		labels:  labels,
 		itemSet: make(map[LeaseItem]struct{}),
 		revokec: make(chan struct{}),
+		clock:   le.clock,
 	}
 
 	le.mu.Lock()
@@ -359,17 +409,65 @@ func (le *lessor) Revoke(id LeaseID) error {
 	return nil
 }
 
+func (le *lessor) RevokeBatch(ids []LeaseID, maxKeys int) (revoked int, deleted int) {
+	for _, id := range ids {
+		l := le.Lookup(id)
+		if l == nil {
+			revoked++
+			continue
+		}
+		n := len(l.Keys())
+		if maxKeys > 0 && deleted+n > maxKeys {
+			deleted += le.deleteKeys(l, maxKeys-deleted)
+			return revoked, deleted
+		}
+		if err := le.Revoke(id); err != nil && err != ErrLeaseNotFound {
+			le.lg.Panic("failed to revoke lease", zap.Int64("leaseID", int64(id)), zap.Error(err))
+		}
+		revoked++
+		deleted += n
+	}
+	return revoked, deleted
+}
+
+// deleteKeys deletes the first n keys attached to the lease in key order, so
+// that the same keys are deleted among all members.
+func (le *lessor) deleteKeys(l *Lease, n int) int {
+	if le.rd == nil || n <= 0 {
+		return 0
+	}
+	keys := l.Keys()
+	sort.Strings(keys)
+	if n > len(keys) {
+		n = len(keys)
+	}
+	txn := le.rd()
+	for _, key := range keys[:n] {
+		txn.DeleteRange([]byte(key), nil)
+	}
+	txn.End()
+	return n
+}
+
 func (le *lessor) Checkpoint(id LeaseID, remainingTTL int64) error {
 	le.mu.Lock()
 	defer le.mu.Unlock()
 
 	if l, ok := le.leaseMap[id]; ok {
 		// when checkpointing, we only update the remainingTTL, Promote is responsible for applying this to lease expiry
+		cleared := remainingTTL == 0 && l.remainingTTL > 0
 		l.remainingTTL = remainingTTL
 		if le.shouldPersistCheckpoints() {
 			l.persistTo(le.b)
 		}
 		if le.isPrimary() {
+			// a clear proposed for a fast renew, e.g. by the previous
+			// leader, extends the lease promoted with the remaining TTL
+			// checkpointed before the renew.
+			if cleared && l.Remaining() < time.Duration(l.ttl)*time.Second {
+				l.refresh(0)
+				le.leaseExpiredNotifier.RegisterOrUpdate(&LeaseWithTime{id: l.ID, time: l.expiry})
+			}
 			// schedule the next checkpoint as needed
 			le.scheduleCheckpointIfNeeded(l)
 		}
@@ -426,11 +524,17 @@ func (le *lessor) Renew(id LeaseID) (int64, error) {
 	// Clear remaining TTL when we renew if it is set
 	// By applying a RAFT entry only when the remainingTTL is already set, we limit the number
 	// of RAFT entries written per lease to a max of 2 per checkpoint interval.
-	if clearRemainingTTL {
+	if clearRemainingTTL && !le.fastRenew {
 		le.cp(context.Background(), &pb.LeaseCheckpointRequest{Checkpoints: []*pb.LeaseCheckpoint{{ID: int64(l.ID), Remaining_TTL: 0}}})
 	}
 
 	le.mu.Lock()
+	if clearRemainingTTL && le.fastRenew {
+		// the lease is refreshed with its full ttl right away, while the
+		// checkpointed remaining TTL is cleared through raft later.
+		l.remainingTTL = 0
+		le.pendingCheckpointClears[l.ID] = struct{}{}
+	}
 	l.refresh(0)
 	item := &LeaseWithTime{id: l.ID, time: l.expiry}
 	le.leaseExpiredNotifier.RegisterOrUpdate(item)
@@ -532,6 +636,7 @@ func (le *lessor) Demote() {
 	}
 
 	le.clearScheduledLeasesCheckpoints()
+	le.flushPendingCheckpointClears()
 	le.clearLeaseExpiredNotifier()
 
 	if le.demotec != nil {
@@ -655,6 +760,17 @@ func (le *lessor) revokeExpiredLeases() {
 func (le *lessor) checkpointScheduledLeases() {
 	var cps []*pb.LeaseCheckpoint
 
+	le.mu.Lock()
+	if le.isPrimary() {
+		cps = le.findPendingCheckpointClears(maxLeaseCheckpointBatchSize)
+	}
+	le.mu.Unlock()
+	// Clears go first so that they do not override a due checkpoint of a
+	// lease renewed since.
+	if len(cps) != 0 {
+		le.cp(context.Background(), &pb.LeaseCheckpointRequest{Checkpoints: cps})
+	}
+
 	// rate limit
 	for i := 0; i < leaseCheckpointRate/2; i++ {
 		le.mu.Lock()
@@ -676,6 +792,32 @@ func (le *lessor) clearScheduledLeasesCheckpoints() {
 	le.leaseCheckpointHeap = make(LeaseQueue, 0)
 }
 
+// flushPendingCheckpointClears proposes the pending clears of the remaining
+// TTLs of the leases renewed by fast renews when the lessor is demoted, so
+// that the next leader does not expire them after the remaining TTL
+// checkpointed before the renews.
+func (le *lessor) flushPendingCheckpointClears() {
+	if le.cp == nil {
+		le.pendingCheckpointClears = make(map[LeaseID]struct{})
+		return
+	}
+	var batches [][]*pb.LeaseCheckpoint
+	for len(le.pendingCheckpointClears) != 0 {
+		if cps := le.findPendingCheckpointClears(maxLeaseCheckpointBatchSize); len(cps) != 0 {
+			batches = append(batches, cps)
+		}
+	}
+	if len(batches) == 0 {
+		return
+	}
+	cp := le.cp
+	go func() {
+		for _, cps := range batches {
+			cp(context.Background(), &pb.LeaseCheckpointRequest{Checkpoints: cps})
+		}
+	}()
+}
+
 func (le *lessor) clearLeaseExpiredNotifier() {
 	le.leaseExpiredNotifier = newLeaseExpiredNotifier()
 }
@@ -696,7 +838,7 @@ func (le *lessor) expireExists() (l *Lease, ok bool, next bool) {
 		le.leaseExpiredNotifier.Unregister() // O(log N)
 		return nil, false, true
 	}
-	now := time.Now()
+	now := le.clock.Now()
 	if now.Before(item.time) /* item.time: expiration time */ {
 		// Candidate expirations are caught up, reinsert this item
 		// and no need to revoke (nothing is expiry)
@@ -753,7 +895,7 @@ func (le *lessor) scheduleCheckpointIfNeeded(lease *Lease) {
 		}
 		heap.Push(&le.leaseCheckpointHeap, &LeaseWithTime{
 			id:   lease.ID,
-			time: time.Now().Add(le.checkpointInterval),
+			time: le.clock.Now().Add(le.checkpointInterval),
 		})
 	}
 }
@@ -763,7 +905,7 @@ func (le *lessor) findDueScheduledCheckpoints(checkpointLimit int) []*pb.LeaseCh
 		return nil
 	}
 
-	now := time.Now()
+	now := le.clock.Now()
 	cps := []*pb.LeaseCheckpoint{}
 	for le.leaseCheckpointHeap.Len() > 0 && len(cps) < checkpointLimit {
 		lt := le.leaseCheckpointHeap[0]
@@ -794,6 +936,26 @@ func (le *lessor) findDueScheduledCheckpoints(checkpointLimit int) []*pb.LeaseCh
 	return cps
 }
 
+// findPendingCheckpointClears removes up to limit pending clears and returns
+// the checkpoints clearing the remaining TTL of the leases which still have one.
+func (le *lessor) findPendingCheckpointClears(limit int) []*pb.LeaseCheckpoint {
+	if le.cp == nil {
+		return nil
+	}
+	var cps []*pb.LeaseCheckpoint
+	for id := range le.pendingCheckpointClears {
+		if len(cps) == limit {
+			break
+		}
+		delete(le.pendingCheckpointClears, id)
+		if _, ok := le.leaseMap[id]; !ok {
+			continue
+		}
+		cps = append(cps, &pb.LeaseCheckpoint{ID: int64(id), Remaining_TTL: 0})
+	}
+	return cps
+}
+
 func (le *lessor) initAndRecover() {
 	tx := le.b.BatchTx()
 
@@ -815,6 +977,8 @@ func (le *lessor) initAndRecover() {
 			expiry:       forever,
 			revokec:      make(chan struct{}),
 			remainingTTL: lpb.RemainingTTL,
+			labels:       lpb.Labels,
+			clock:        le.clock,
 		}
 	}
 	le.leaseExpiredNotifier.Init()
@@ -825,8 +989,9 @@ func (le *lessor) initAndRecover() {
 
 type Lease struct {
 	ID           LeaseID
-	ttl          int64 // time to live of the lease in seconds
-	remainingTTL int64 // remaining time to live in seconds, if zero valued it is considered unset and the full ttl should be used
+	ttl          int64             // time to live of the lease in seconds
+	remainingTTL int64             // remaining time to live in seconds, if zero valued it is considered unset and the full ttl should be used
+	labels       map[string]string // metadata attached to the lease when granted, never modified
 	// expiryMu protects concurrent accesses to expiry
 	expiryMu sync.RWMutex
 	// expiry is time when lease should expire. no expiration when expiry.IsZero() is true
@@ -836,6 +1001,8 @@ type Lease struct {
 	mu      sync.RWMutex
 	itemSet map[LeaseItem]struct{}
 	revokec chan struct{}
+
+	clock clockwork.Clock
 }
 
 func (l *Lease) expired() bool {
@@ -843,7 +1010,7 @@ func (l *Lease) expired() bool {
 }
 
 func (l *Lease) persistTo(b backend.Backend) {
-	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL}
+	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL, Labels: l.labels}
 	tx := b.BatchTx()
 	tx.LockInsideApply()
 	defer tx.Unlock()
@@ -855,6 +1022,12 @@ func (l *Lease) TTL() int64 {
 	return l.ttl
 }
 
+// Labels returns the labels attached to the lease when granted. The returned
+// map must not be modified.
+func (l *Lease) Labels() map[string]string {
+	return l.labels
+}
+
 // RemainingTTL returns the last checkpointed remaining TTL of the lease.
 func (l *Lease) getRemainingTTL() int64 {
 	if l.remainingTTL > 0 {
@@ -865,7 +1038,7 @@ func (l *Lease) getRemainingTTL() int64 {
 
 // refresh refreshes the expiry of the lease.
 func (l *Lease) refresh(extend time.Duration) {
-	newExpiry := time.Now().Add(extend + time.Duration(l.getRemainingTTL())*time.Second)
+	newExpiry := l.clock.Now().Add(extend + time.Duration(l.getRemainingTTL())*time.Second)
 	l.expiryMu.Lock()
 	defer l.expiryMu.Unlock()
 	l.expiry = newExpiry
@@ -896,13 +1069,20 @@ func (l *Lease) Remaining() time.Duration {
 	if l.expiry.IsZero() {
 		return time.Duration(math.MaxInt64)
 	}
-	return time.Until(l.expiry)
+	return l.expiry.Sub(l.clock.Now())
 }
 
 type LeaseItem struct {
 	Key string
 }
 
+func labelsSize(labels map[string]string) (n int) {
+	for k, v := range labels {
+		n += len(k) + len(v)
+	}
+	return n
+}
+
 func int64ToBytes(n int64) []byte {
 	bytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(bytes, uint64(n))
@@ -919,8 +1099,14 @@ func (fl *FakeLessor) SetCheckpointer(cp Checkpointer) {}
 
 func (fl *FakeLessor) Grant(id LeaseID, ttl int64) (*Lease, error) { return nil, nil }
 
+func (fl *FakeLessor) GrantWithLabels(id LeaseID, ttl int64, labels map[string]string) (*Lease, error) {
+	return nil, nil
+}
+
 func (fl *FakeLessor) Revoke(id LeaseID) error { return nil }
 
+func (fl *FakeLessor) RevokeBatch(ids []LeaseID, maxKeys int) (int, int) { return len(ids), 0 }
+
 func (fl *FakeLessor) Checkpoint(id LeaseID, remainingTTL int64) error { return nil }
 
 func (fl *FakeLessor) Attach(id LeaseID, items []LeaseItem) error { return nil }
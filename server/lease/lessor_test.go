@@ -21,11 +21,13 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/jonboulle/clockwork"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/server/v3/storage/backend"
@@ -99,7 +101,7 @@ func TestLessorGrant(t *testing.T) {
 	defer tx.Unlock()
 	lpb := schema.MustUnsafeGetLease(tx, int64(l.ID))
 	if lpb == nil {
-		t.Errorf("lpb = %d, want not nil", lpb)
+		t.Errorf("lpb = %v, want not nil", lpb)
 	}
 }
 
@@ -203,10 +205,67 @@ func TestLessorRevoke(t *testing.T) {
 	defer tx.Unlock()
 	lpb := schema.MustUnsafeGetLease(tx, int64(l.ID))
 	if lpb != nil {
-		t.Errorf("lpb = %d, want nil", lpb)
+		t.Errorf("lpb = %v, want nil", lpb)
 	}
 }
 
+// TestLessorRevokeBatch ensures RevokeBatch revokes leases in order without
+// deleting more than the maximum number of keys.
+func TestLessorRevokeBatch(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le.Stop()
+	var deleted []string
+	le.SetRangeDeleter(func() TxnDelete {
+		fd := newFakeDeleter(be)
+		return &recordingDeleter{fakeDeleter: fd, deleted: &deleted}
+	})
+
+	for id, keys := range map[LeaseID][]string{1: {"a", "b"}, 2: {"e", "d", "c"}} {
+		if _, err := le.Grant(id, 100); err != nil {
+			t.Fatal(err)
+		}
+		var items []LeaseItem
+		for _, k := range keys {
+			items = append(items, LeaseItem{Key: k})
+		}
+		if err := le.Attach(id, items); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// lease 3 is not found, it counts as revoked
+	revoked, n := le.RevokeBatch([]LeaseID{3, 1, 2}, 4)
+	if revoked != 2 || n != 4 {
+		t.Fatalf("expected 2 leases revoked and 4 keys deleted, got %d and %d", revoked, n)
+	}
+	if le.Lookup(1) != nil {
+		t.Errorf("got revoked lease 1")
+	}
+	if le.Lookup(2) == nil {
+		t.Errorf("expected lease 2 to be kept until all its keys are deleted")
+	}
+	sort.Strings(deleted[:2])
+	wdeleted := []string{"a_", "b_", "c_", "d_"}
+	if !reflect.DeepEqual(deleted, wdeleted) {
+		t.Errorf("deleted= %v, want %v", deleted, wdeleted)
+	}
+}
+
+type recordingDeleter struct {
+	*fakeDeleter
+	deleted *[]string
+}
+
+func (rd *recordingDeleter) DeleteRange(key, end []byte) (int64, int64) {
+	*rd.deleted = append(*rd.deleted, string(key)+"_"+string(end))
+	return 0, 0
+}
+
 // TestLessorRenew ensures Lessor can renew an existing lease.
 func TestLessorRenew(t *testing.T) {
 	lg := zap.NewNop()
@@ -285,6 +344,135 @@ func TestLessorRenewWithCheckpointer(t *testing.T) {
 	}
 }
 
+// TestLessorFastRenewWithCheckpointer ensures a fast renew does not wait for
+// the checkpointer, and that the remaining TTLs are cleared in a later batch.
+func TestLessorFastRenewWithCheckpointer(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer be.Close()
+	defer os.RemoveAll(dir)
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, FastRenew: true})
+	// The checkpointer blocks until the test receives the request, so
+	// that a renew waiting for it would never return.
+	cpc := make(chan *pb.LeaseCheckpointRequest)
+	le.SetCheckpointer(func(ctx context.Context, cp *pb.LeaseCheckpointRequest) {
+		select {
+		case cpc <- cp:
+		case <-le.stopC:
+		}
+	})
+	defer le.Stop()
+	le.Promote(0)
+
+	var ids []LeaseID
+	for i := 1; i <= 2; i++ {
+		l, err := le.Grant(LeaseID(i), minLeaseTTL)
+		if err != nil {
+			t.Fatalf("failed to grant lease (%v)", err)
+		}
+		// the remaining TTL checkpointed before the renew is shorter
+		// than the ttl
+		le.mu.Lock()
+		l.ttl = 10
+		l.remainingTTL = 3
+		le.mu.Unlock()
+		ids = append(ids, l.ID)
+	}
+	for _, id := range ids {
+		ttl, err := le.Renew(id)
+		if err != nil {
+			t.Fatalf("failed to renew lease (%v)", err)
+		}
+		if ttl != 10 {
+			t.Errorf("ttl = %d, want %d", ttl, 10)
+		}
+		// the lease expires after the ttl returned to the client
+		if remaining := le.Lookup(id).Remaining(); remaining < 9*time.Second {
+			t.Errorf("remaining = %v, want about %v", remaining, 10*time.Second)
+		}
+	}
+
+	cleared := make(map[LeaseID]bool)
+	for len(cleared) < len(ids) {
+		select {
+		case cp := <-cpc:
+			for _, c := range cp.GetCheckpoints() {
+				if c.GetRemaining_TTL() != 0 {
+					t.Fatalf("remainingTTL = %d, want %d", c.GetRemaining_TTL(), 0)
+				}
+				if cleared[LeaseID(c.GetID())] {
+					t.Fatalf("lease %x cleared twice", c.GetID())
+				}
+				cleared[LeaseID(c.GetID())] = true
+				le.Checkpoint(LeaseID(c.GetID()), 0)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for the remaining TTLs to be cleared, cleared %v", cleared)
+		}
+	}
+	le.mu.RLock()
+	defer le.mu.RUnlock()
+	for _, id := range ids {
+		if l := le.leaseMap[id]; l.remainingTTL != 0 {
+			t.Errorf("remainingTTL = %d, want %d", l.remainingTTL, 0)
+		}
+	}
+}
+
+// TestLessorFastRenewDemote ensures that the remaining TTLs cleared by fast
+// renews are still cleared through raft once the lessor is demoted, and that
+// the next primary lessor extends the leases it promoted with the remaining
+// TTL checkpointed before the renews.
+func TestLessorFastRenewDemote(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer be.Close()
+	defer os.RemoveAll(dir)
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, FastRenew: true, CheckpointInterval: time.Hour})
+	cpc := make(chan *pb.LeaseCheckpointRequest, 1)
+	le.SetCheckpointer(func(ctx context.Context, cp *pb.LeaseCheckpointRequest) { cpc <- cp })
+	defer le.Stop()
+	le.Promote(0)
+
+	l, err := le.Grant(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = le.Checkpoint(l.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = le.Renew(l.ID); err != nil {
+		t.Fatal(err)
+	}
+	le.Demote()
+	select {
+	case cp := <-cpc:
+		if cps := cp.GetCheckpoints(); len(cps) != 1 || cps[0].GetID() != int64(l.ID) || cps[0].GetRemaining_TTL() != 0 {
+			t.Fatalf("unexpected checkpoints %v", cps)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the pending clear to be flushed")
+	}
+
+	// the next primary promotes the lease with the checkpointed remaining
+	// TTL until the clear is applied.
+	if err = le.Checkpoint(l.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	le.Promote(0)
+	if remaining := l.Remaining(); remaining > 3*time.Second {
+		t.Fatalf("remaining = %v, want at most %v", remaining, 3*time.Second)
+	}
+	if err = le.Checkpoint(l.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if remaining := l.Remaining(); remaining < 9*time.Second {
+		t.Fatalf("remaining = %v, want about %v", remaining, 10*time.Second)
+	}
+}
+
 // TestLessorRenewExtendPileup ensures Lessor extends leases on promotion if too many
 // expire at the same time.
 func TestLessorRenewExtendPileup(t *testing.T) {
@@ -408,6 +596,32 @@ func TestLessorRecover(t *testing.T) {
 	}
 }
 
+func TestLessorGrantWithLabels(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le.Stop()
+	labels := map[string]string{"owner": "scheduler", "pod": "a"}
+	if _, err := le.GrantWithLabels(1, 10, labels); err != nil {
+		t.Fatal(err)
+	}
+	tooLarge := map[string]string{"owner": strings.Repeat("a", MaxLeaseLabelsBytes)}
+	if _, err := le.GrantWithLabels(2, 10, tooLarge); err != ErrLeaseLabelsTooLarge {
+		t.Fatalf("expected %v, got %v", ErrLeaseLabelsTooLarge, err)
+	}
+
+	// the labels are recovered from the backend
+	nle := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer nle.Stop()
+	nl := nle.Lookup(1)
+	if nl == nil || !reflect.DeepEqual(nl.Labels(), labels) {
+		t.Errorf("expected the labels %v to be recovered, got %+v", labels, nl)
+	}
+}
+
 func TestLessorExpire(t *testing.T) {
 	lg := zap.NewNop()
 	dir, be := NewTestBackend(t)
@@ -512,6 +726,48 @@ func TestLessorExpireAndDemote(t *testing.T) {
 	}
 }
 
+// TestLessorExpireWithFakeClock ensures leases expire according to the
+// configured clock rather than real time.
+func TestLessorExpireWithFakeClock(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	clock := clockwork.NewFakeClock()
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, Clock: clock})
+	defer le.Stop()
+
+	le.Promote(0)
+	l, err := le.Grant(1, 10)
+	if err != nil {
+		t.Fatalf("failed to create lease: %v", err)
+	}
+	if l.Remaining() != 10*time.Second {
+		t.Fatalf("remaining = %v, want %v", l.Remaining(), 10*time.Second)
+	}
+
+	clock.Advance(9 * time.Second)
+	if l.Remaining() != time.Second {
+		t.Fatalf("remaining = %v, want %v", l.Remaining(), time.Second)
+	}
+	select {
+	case <-le.ExpiredLeasesC():
+		t.Fatalf("lease expired before its TTL on the fake clock")
+	case <-time.After(time.Second):
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case el := <-le.ExpiredLeasesC():
+		if el[0].ID != l.ID {
+			t.Fatalf("expired id = %x, want %x", el[0].ID, l.ID)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("failed to receive expired lease")
+	}
+}
+
 func TestLessorMaxTTL(t *testing.T) {
 	lg := zap.NewNop()
 	dir, be := NewTestBackend(t)
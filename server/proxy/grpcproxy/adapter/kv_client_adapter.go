@@ -16,6 +16,7 @@ package adapter
 
 import (
 	"context"
+	"io"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 
@@ -32,6 +33,17 @@ func (s *kvs2kvc) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.C
 	return s.kvs.Range(ctx, in)
 }
 
+func (s *kvs2kvc) RangeStream(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (pb.KV_RangeStreamClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		if err := s.kvs.RangeStream(in, &rs2rcServerStream{ss}); err != nil {
+			return err
+		}
+		// signal the end of the stream like a gRPC server would
+		return io.EOF
+	})
+	return &rs2rcClientStream{cs}, nil
+}
+
 func (s *kvs2kvc) Put(ctx context.Context, in *pb.PutRequest, opts ...grpc.CallOption) (*pb.PutResponse, error) {
 	return s.kvs.Put(ctx, in)
 }
@@ -47,3 +59,21 @@ func (s *kvs2kvc) Txn(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallO
 func (s *kvs2kvc) Compact(ctx context.Context, in *pb.CompactionRequest, opts ...grpc.CallOption) (*pb.CompactionResponse, error) {
 	return s.kvs.Compact(ctx, in)
 }
+
+// rs2rcClientStream implements KV_RangeStreamClient
+type rs2rcClientStream struct{ chanClientStream }
+
+// rs2rcServerStream implements KV_RangeStreamServer
+type rs2rcServerStream struct{ chanServerStream }
+
+func (s *rs2rcClientStream) Recv() (*pb.RangeStreamResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.RangeStreamResponse), nil
+}
+
+func (s *rs2rcServerStream) Send(rr *pb.RangeStreamResponse) error {
+	return s.SendMsg(rr)
+}
@@ -16,6 +16,7 @@ package adapter
 
 import (
 	"context"
+	"io"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 
@@ -56,6 +57,84 @@ func (s *mts2mtc) Downgrade(ctx context.Context, r *pb.DowngradeRequest, opts ..
 	return s.mts.Downgrade(ctx, r)
 }
 
+func (s *mts2mtc) WatchConsumers(ctx context.Context, r *pb.WatchConsumersRequest, opts ...grpc.CallOption) (*pb.WatchConsumersResponse, error) {
+	return s.mts.WatchConsumers(ctx, r)
+}
+
+func (s *mts2mtc) ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest, opts ...grpc.CallOption) (*pb.ConfigAdviceResponse, error) {
+	return s.mts.ConfigAdvice(ctx, r)
+}
+
+func (s *mts2mtc) KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest, opts ...grpc.CallOption) (*pb.KeyHistogramResponse, error) {
+	return s.mts.KeyHistogram(ctx, r)
+}
+
+func (s *mts2mtc) ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest, opts ...grpc.CallOption) (*pb.ReadOnlyResponse, error) {
+	return s.mts.ReadOnly(ctx, r)
+}
+
+func (s *mts2mtc) NamespacePut(ctx context.Context, r *pb.NamespacePutRequest, opts ...grpc.CallOption) (*pb.NamespacePutResponse, error) {
+	return s.mts.NamespacePut(ctx, r)
+}
+
+func (s *mts2mtc) NamespaceDelete(ctx context.Context, r *pb.NamespaceDeleteRequest, opts ...grpc.CallOption) (*pb.NamespaceDeleteResponse, error) {
+	return s.mts.NamespaceDelete(ctx, r)
+}
+
+func (s *mts2mtc) NamespaceList(ctx context.Context, r *pb.NamespaceListRequest, opts ...grpc.CallOption) (*pb.NamespaceListResponse, error) {
+	return s.mts.NamespaceList(ctx, r)
+}
+
+func (s *mts2mtc) IndexPut(ctx context.Context, r *pb.IndexPutRequest, opts ...grpc.CallOption) (*pb.IndexPutResponse, error) {
+	return s.mts.IndexPut(ctx, r)
+}
+
+func (s *mts2mtc) IndexDelete(ctx context.Context, r *pb.IndexDeleteRequest, opts ...grpc.CallOption) (*pb.IndexDeleteResponse, error) {
+	return s.mts.IndexDelete(ctx, r)
+}
+
+func (s *mts2mtc) IndexList(ctx context.Context, r *pb.IndexListRequest, opts ...grpc.CallOption) (*pb.IndexListResponse, error) {
+	return s.mts.IndexList(ctx, r)
+}
+
+func (s *mts2mtc) IndexRange(ctx context.Context, r *pb.IndexRangeRequest, opts ...grpc.CallOption) (*pb.IndexRangeResponse, error) {
+	return s.mts.IndexRange(ctx, r)
+}
+
+func (s *mts2mtc) LogLevel(ctx context.Context, r *pb.LogLevelRequest, opts ...grpc.CallOption) (*pb.LogLevelResponse, error) {
+	return s.mts.LogLevel(ctx, r)
+}
+
+func (s *mts2mtc) SlowLog(ctx context.Context, r *pb.SlowLogRequest, opts ...grpc.CallOption) (*pb.SlowLogResponse, error) {
+	return s.mts.SlowLog(ctx, r)
+}
+
+func (s *mts2mtc) TopKeys(ctx context.Context, r *pb.TopKeysRequest, opts ...grpc.CallOption) (*pb.TopKeysResponse, error) {
+	return s.mts.TopKeys(ctx, r)
+}
+
+func (s *mts2mtc) Fragmentation(ctx context.Context, r *pb.FragmentationRequest, opts ...grpc.CallOption) (*pb.FragmentationResponse, error) {
+	return s.mts.Fragmentation(ctx, r)
+}
+
+func (s *mts2mtc) BulkImport(ctx context.Context, opts ...grpc.CallOption) (pb.Maintenance_BulkImportClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		return s.mts.BulkImport(&bi2bcServerStream{ss})
+	})
+	return &bi2bcClientStream{cs}, nil
+}
+
+func (s *mts2mtc) DefragmentStream(ctx context.Context, in *pb.DefragmentRequest, opts ...grpc.CallOption) (pb.Maintenance_DefragmentStreamClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		if err := s.mts.DefragmentStream(in, &ds2dcServerStream{ss}); err != nil {
+			return err
+		}
+		// signal the end of the stream like a gRPC server would
+		return io.EOF
+	})
+	return &ds2dcClientStream{cs}, nil
+}
+
 func (s *mts2mtc) Snapshot(ctx context.Context, in *pb.SnapshotRequest, opts ...grpc.CallOption) (pb.Maintenance_SnapshotClient, error) {
 	cs := newPipeStream(ctx, func(ss chanServerStream) error {
 		return s.mts.Snapshot(in, &ss2scServerStream{ss})
@@ -90,3 +169,89 @@ func (s *ss2scServerStream) Recv() (*pb.SnapshotRequest, error) {
 	}
 	return v.(*pb.SnapshotRequest), nil
 }
+
+// bi2bcClientStream implements Maintenance_BulkImportClient
+type bi2bcClientStream struct{ chanClientStream }
+
+// bi2bcServerStream implements Maintenance_BulkImportServer
+type bi2bcServerStream struct{ chanServerStream }
+
+func (s *bi2bcClientStream) Send(rr *pb.BulkImportRequest) error {
+	return s.SendMsg(rr)
+}
+func (s *bi2bcClientStream) CloseAndRecv() (*pb.BulkImportResponse, error) {
+	if err := s.CloseSend(); err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.BulkImportResponse), nil
+}
+
+func (s *bi2bcServerStream) SendAndClose(rr *pb.BulkImportResponse) error {
+	return s.SendMsg(rr)
+}
+func (s *bi2bcServerStream) Recv() (*pb.BulkImportRequest, error) {
+	select {
+	case v, ok := <-s.Stream.(*chanStream).recvc:
+		if !ok {
+			// the client closed its side of the stream
+			return nil, io.EOF
+		}
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		return v.(*pb.BulkImportRequest), nil
+	case <-s.Context().Done():
+		return nil, s.Context().Err()
+	}
+}
+
+// ds2dcClientStream implements Maintenance_DefragmentStreamClient
+type ds2dcClientStream struct{ chanClientStream }
+
+// ds2dcServerStream implements Maintenance_DefragmentStreamServer
+type ds2dcServerStream struct{ chanServerStream }
+
+func (s *ds2dcClientStream) Recv() (*pb.DefragmentStreamResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.DefragmentStreamResponse), nil
+}
+
+func (s *ds2dcServerStream) Send(rr *pb.DefragmentStreamResponse) error {
+	return s.SendMsg(rr)
+}
+
+func (s *mts2mtc) Backup(ctx context.Context, in *pb.BackupRequest, opts ...grpc.CallOption) (pb.Maintenance_BackupClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		if err := s.mts.Backup(in, &bk2bcServerStream{ss}); err != nil {
+			return err
+		}
+		// signal the end of the stream like a gRPC server would
+		return io.EOF
+	})
+	return &bk2bcClientStream{cs}, nil
+}
+
+// bk2bcClientStream implements Maintenance_BackupClient
+type bk2bcClientStream struct{ chanClientStream }
+
+// bk2bcServerStream implements Maintenance_BackupServer
+type bk2bcServerStream struct{ chanServerStream }
+
+func (s *bk2bcClientStream) Recv() (*pb.BackupResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.BackupResponse), nil
+}
+
+func (s *bk2bcServerStream) Send(rr *pb.BackupResponse) error {
+	return s.SendMsg(rr)
+}
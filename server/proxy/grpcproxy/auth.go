@@ -18,15 +18,22 @@ import (
 	"context"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3"
+
+	"google.golang.org/grpc/metadata"
 )
 
 type AuthProxy struct {
 	client *clientv3.Client
+
+	// statuses coalesces the AuthStatus requests of the clients sharing a
+	// token, which are frequently polled by large fleets.
+	statuses *callGroup
 }
 
 func NewAuthProxy(c *clientv3.Client) pb.AuthServer {
-	return &AuthProxy{client: c}
+	return &AuthProxy{client: c, statuses: newCallGroup(c.Ctx())}
 }
 
 func (ap *AuthProxy) AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error) {
@@ -40,8 +47,20 @@ func (ap *AuthProxy) AuthDisable(ctx context.Context, r *pb.AuthDisableRequest)
 }
 
 func (ap *AuthProxy) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error) {
-	conn := ap.client.ActiveConnection()
-	return pb.NewAuthClient(conn).AuthStatus(ctx, r)
+	token := getAuthTokenFromClient(ctx)
+	resp, err := ap.statuses.do(ctx, token, func(cctx context.Context) (interface{}, error) {
+		if token != "" {
+			// the request is shared, so forward the token of the callers
+			// rather than the context of the first one
+			cctx = metadata.NewIncomingContext(cctx, metadata.Pairs(rpctypes.TokenFieldNameGRPC, token))
+		}
+		conn := ap.client.ActiveConnection()
+		return pb.NewAuthClient(conn).AuthStatus(cctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthStatusResponse), nil
 }
 
 func (ap *AuthProxy) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
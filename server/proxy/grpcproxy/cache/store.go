@@ -18,7 +18,9 @@ package cache
 
 import (
 	"errors"
+	"math"
 	"sync"
+	"time"
 
 	"github.com/golang/groupcache/lru"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -36,10 +38,27 @@ type Cache interface {
 	Get(req *pb.RangeRequest) (*pb.RangeResponse, error)
 	Compact(revision int64)
 	Invalidate(key []byte, endkey []byte)
+	// Sync records that the changes up to the given revision invalidated
+	// the cache, so that the responses of ranges older than the revision
+	// are no longer added, as the changes since could be missed.
+	Sync(revision int64)
+	// Reset removes all the cached responses, and stops adding the responses
+	// of ranges until the next Sync.
+	Reset()
 	Size() int
 	Close()
 }
 
+// Config configures a Cache.
+type Config struct {
+	// MaxEntries is the maximum number of cached responses. It defaults to
+	// DefaultMaxEntries.
+	MaxEntries int
+	// TTL is the time after which a cached response expires. The responses
+	// do not expire if zero.
+	TTL time.Duration
+}
+
 // keyFunc returns the key of a request, which is used to look up its caching response in the cache.
 func keyFunc(req *pb.RangeRequest) string {
 	// TODO: use marshalTo to reduce allocation
@@ -51,8 +70,17 @@ func keyFunc(req *pb.RangeRequest) string {
 }
 
 func NewCache(maxCacheEntries int) Cache {
+	return NewCacheWithConfig(Config{MaxEntries: maxCacheEntries})
+}
+
+// NewCacheWithConfig returns a cache configured by cfg.
+func NewCacheWithConfig(cfg Config) Cache {
+	if cfg.MaxEntries == 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
 	return &cache{
-		lru:          lru.New(maxCacheEntries),
+		lru:          lru.New(cfg.MaxEntries),
+		ttl:          cfg.TTL,
 		cachedRanges: adt.NewIntervalTree(),
 		compactedRev: -1,
 	}
@@ -64,11 +92,20 @@ func (c *cache) Close() {}
 type cache struct {
 	mu  sync.RWMutex
 	lru *lru.Cache
+	ttl time.Duration
 
 	// a reverse index for cache invalidation
 	cachedRanges adt.IntervalTree
 
 	compactedRev int64
+	// syncedRev is the revision up to which the changes invalidated the cache
+	syncedRev int64
+}
+
+// entry is a cached response.
+type entry struct {
+	resp    *pb.RangeResponse
+	expires time.Time
 }
 
 // Add adds the response of a request to the cache if its revision is larger than the compacted revision of the cache.
@@ -78,8 +115,16 @@ func (c *cache) Add(req *pb.RangeRequest, resp *pb.RangeResponse) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if req.Revision == 0 && (resp.Header == nil || resp.Header.Revision < c.syncedRev) {
+		// the changes since the response may have invalidated it already
+		return
+	}
 	if req.Revision > c.compactedRev {
-		c.lru.Add(key, resp)
+		e := entry{resp: resp}
+		if c.ttl > 0 {
+			e.expires = time.Now().Add(c.ttl)
+		}
+		c.lru.Add(key, e)
 	}
 	// we do not need to invalidate a request with a revision specified.
 	// so we do not need to add it into the reverse index.
@@ -122,8 +167,12 @@ func (c *cache) Get(req *pb.RangeRequest) (*pb.RangeResponse, error) {
 		return nil, ErrCompacted
 	}
 
-	if resp, ok := c.lru.Get(key); ok {
-		return resp.(*pb.RangeResponse), nil
+	if v, ok := c.lru.Get(key); ok {
+		e := v.(entry)
+		if e.expires.IsZero() || time.Now().Before(e.expires) {
+			return e.resp, nil
+		}
+		c.lru.Remove(key)
 	}
 	return nil, errors.New("not exist")
 }
@@ -165,6 +214,22 @@ func (c *cache) Compact(revision int64) {
 	}
 }
 
+func (c *cache) Sync(revision int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.syncedRev = revision
+}
+
+func (c *cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Clear()
+	c.cachedRanges = adt.NewIntervalTree()
+	c.syncedRev = math.MaxInt64
+}
+
 func (c *cache) Size() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
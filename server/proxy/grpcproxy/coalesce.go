@@ -0,0 +1,87 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"sync"
+)
+
+// callGroup coalesces the concurrent calls with the same key into a single
+// upstream call, whose result is shared by all the callers. The upstream call
+// is canceled once every caller has given up on it.
+type callGroup struct {
+	ctx context.Context
+
+	mu    sync.Mutex
+	calls map[string]*groupCall
+}
+
+type groupCall struct {
+	donec   chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	resp interface{}
+	err  error
+}
+
+func newCallGroup(ctx context.Context) *callGroup {
+	return &callGroup{ctx: ctx, calls: make(map[string]*groupCall)}
+}
+
+// do calls f, unless a call with the same key is in flight, in which case it
+// waits for the result of that call instead.
+func (g *callGroup) do(ctx context.Context, key string, f func(context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		callsCoalescing.Inc()
+	} else {
+		cctx, cancel := context.WithCancel(g.ctx)
+		c = &groupCall{donec: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			c.resp, c.err = f(cctx)
+			g.mu.Lock()
+			g.forget(key, c)
+			g.mu.Unlock()
+			cancel()
+			close(c.donec)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.donec:
+		return c.resp, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		if c.waiters--; c.waiters == 0 {
+			g.forget(key, c)
+			c.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget lets the next call with the key start a new upstream call.
+func (g *callGroup) forget(key string, c *groupCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
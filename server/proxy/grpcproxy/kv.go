@@ -16,40 +16,103 @@ package grpcproxy
 
 import (
 	"context"
+	"io"
+	"sync/atomic"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/proxy/grpcproxy/cache"
 )
 
+// cacheResyncInterval is the wait before watching the changes again, after the
+// watch keeping the cache coherent failed.
+const cacheResyncInterval = time.Second
+
 type kvProxy struct {
 	kv    clientv3.KV
 	cache cache.Cache
+
+	hits   uint64
+	misses uint64
 }
 
 func NewKvProxy(c *clientv3.Client) (pb.KVServer, <-chan struct{}) {
+	return NewKvProxyWithCache(c.Ctx(), c, cache.Config{})
+}
+
+// NewKvProxyWithCache returns a KV proxy caching the responses of serializable
+// ranges as configured by cfg. The cache is kept coherent by a watch on the
+// keys, invalidating the cached ranges as their keys change, until ctx is
+// canceled.
+func NewKvProxyWithCache(ctx context.Context, c *clientv3.Client, cfg cache.Config) (pb.KVServer, <-chan struct{}) {
 	kv := &kvProxy{
 		kv:    c.KV,
-		cache: cache.NewCache(cache.DefaultMaxEntries),
+		cache: cache.NewCacheWithConfig(cfg),
 	}
+	// nothing is cached until the watch starts
+	kv.cache.Reset()
 	donec := make(chan struct{})
-	close(donec)
+	go func() {
+		defer close(donec)
+		kv.invalidate(ctx, c)
+	}()
 	return kv, donec
 }
 
+// invalidate watches the changes of all the keys, invalidating the cached
+// ranges changed.
+func (p *kvProxy) invalidate(ctx context.Context, c *clientv3.Client) {
+	for ctx.Err() == nil {
+		// the cached ranges are serializable, so are the changes watched
+		wctx, cancel := context.WithCancel(ctx)
+		wch := c.Watch(wctx, "\x00", clientv3.WithFromKey(), clientv3.WithCreatedNotify())
+		var err error
+		for wr := range wch {
+			if err = wr.Err(); err != nil {
+				break
+			}
+			// stop adding the ranges older than the changes before
+			// invalidating the ranges changed
+			p.cache.Sync(wr.Header.Revision)
+			for _, ev := range wr.Events {
+				p.cache.Invalidate(ev.Kv.Key, nil)
+				cacheInvalidations.Inc()
+			}
+			if len(wr.Events) != 0 {
+				cacheKeys.Set(float64(p.cache.Size()))
+			}
+		}
+		cancel()
+		// the changes are missed until the watch starts again
+		p.cache.Reset()
+		cacheKeys.Set(0)
+		if clientv3.IsConnCanceled(err) {
+			return
+		}
+		select {
+		case <-time.After(cacheResyncInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
 func (p *kvProxy) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
 	if r.Serializable {
 		resp, err := p.cache.Get(r)
 		switch err {
 		case nil:
 			cacheHits.Inc()
+			p.observeHit(true)
 			return resp, nil
 		case cache.ErrCompacted:
 			cacheHits.Inc()
+			p.observeHit(true)
 			return nil, err
 		}
 
 		cachedMisses.Inc()
+		p.observeHit(false)
 	}
 
 	resp, err := p.kv.Do(ctx, RangeRequestToOp(r))
@@ -67,11 +130,44 @@ func (p *kvProxy) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRespo
 	return gresp, nil
 }
 
+func (p *kvProxy) observeHit(hit bool) {
+	if hit {
+		atomic.AddUint64(&p.hits, 1)
+	} else {
+		atomic.AddUint64(&p.misses, 1)
+	}
+	hits, misses := atomic.LoadUint64(&p.hits), atomic.LoadUint64(&p.misses)
+	cacheHitRate.Set(float64(hits) / float64(hits+misses))
+}
+
+// RangeStream forwards the responses of the server without caching them, as
+// ranges large enough to be streamed would quickly evict the cache.
+func (p *kvProxy) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	it, err := p.kv.GetStream(stream.Context(), string(r.Key), RangeRequestToOpOptions(r)...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for {
+		resp, err := it.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err = stream.Send(&pb.RangeStreamResponse{RangeResponse: (*pb.RangeResponse)(resp)}); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *kvProxy) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
 	p.cache.Invalidate(r.Key, nil)
 	cacheKeys.Set(float64(p.cache.Size()))
 
-	resp, err := p.kv.Do(ctx, PutRequestToOp(r))
+	resp, err := p.kv.Do(withClientRequestID(ctx), PutRequestToOp(r))
 	return (*pb.PutResponse)(resp.Put()), err
 }
 
@@ -79,7 +175,7 @@ func (p *kvProxy) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*p
 	p.cache.Invalidate(r.Key, r.RangeEnd)
 	cacheKeys.Set(float64(p.cache.Size()))
 
-	resp, err := p.kv.Do(ctx, DelRequestToOp(r))
+	resp, err := p.kv.Do(withClientRequestID(ctx), DelRequestToOp(r))
 	return (*pb.DeleteRangeResponse)(resp.Del()), err
 }
 
@@ -101,7 +197,7 @@ func (p *kvProxy) txnToCache(reqs []*pb.RequestOp, resps []*pb.ResponseOp) {
 
 func (p *kvProxy) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
 	op := TxnRequestToOp(r)
-	opResp, err := p.kv.Do(ctx, op)
+	opResp, err := p.kv.Do(withClientRequestID(ctx), op)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +258,11 @@ func requestOpToOp(union *pb.RequestOp) clientv3.Op {
 }
 
 func RangeRequestToOp(r *pb.RangeRequest) clientv3.Op {
+	return clientv3.OpGet(string(r.Key), RangeRequestToOpOptions(r)...)
+}
+
+// RangeRequestToOpOptions returns the options of the Get described by r.
+func RangeRequestToOpOptions(r *pb.RangeRequest) []clientv3.OpOption {
 	opts := []clientv3.OpOption{}
 	if len(r.RangeEnd) != 0 {
 		opts = append(opts, clientv3.WithRange(string(r.RangeEnd)))
@@ -185,8 +286,7 @@ func RangeRequestToOp(r *pb.RangeRequest) clientv3.Op {
 	if r.Serializable {
 		opts = append(opts, clientv3.WithSerializable())
 	}
-
-	return clientv3.OpGet(string(r.Key), opts...)
+	return opts
 }
 
 func PutRequestToOp(r *pb.PutRequest) clientv3.Op {
@@ -201,6 +301,9 @@ func PutRequestToOp(r *pb.PutRequest) clientv3.Op {
 	if r.PrevKv {
 		opts = append(opts, clientv3.WithPrevKV())
 	}
+	if r.Ttl > 0 {
+		opts = append(opts, clientv3.WithTTL(r.Ttl))
+	}
 	return clientv3.OpPut(string(r.Key), string(r.Value), opts...)
 }
 
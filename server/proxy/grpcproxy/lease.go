@@ -17,6 +17,7 @@ package grpcproxy
 import (
 	"context"
 	"io"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,6 +38,13 @@ type leaseProxy struct {
 
 	lessor clientv3.Lease
 
+	// keepAlives shares the upstream keepalives of the leases among the
+	// LeaseKeepAlive streams.
+	keepAlives *leaseKeepAlives
+	// ttls coalesces the TimeToLive requests of the LeaseKeepAlive streams
+	// whose upstream keepalive stopped.
+	ttls *callGroup
+
 	ctx context.Context
 
 	leader *leader
@@ -53,6 +61,8 @@ func NewLeaseProxy(ctx context.Context, c *clientv3.Client) (pb.LeaseServer, <-c
 	lp := &leaseProxy{
 		leaseClient: pb.NewLeaseClient(c.ActiveConnection()),
 		lessor:      c.Lease,
+		keepAlives:  newLeaseKeepAlives(cctx, c.Lease),
+		ttls:        newCallGroup(cctx),
 		ctx:         cctx,
 		leader:      newLeader(cctx, c.Watcher),
 	}
@@ -110,6 +120,7 @@ func (lp *leaseProxy) LeaseTimeToLive(ctx context.Context, rr *pb.LeaseTimeToLiv
 		TTL:        r.TTL,
 		GrantedTTL: r.GrantedTTL,
 		Keys:       r.Keys,
+		Labels:     r.Labels,
 	}
 	return rp, err
 }
@@ -121,7 +132,7 @@ func (lp *leaseProxy) LeaseLeases(ctx context.Context, rr *pb.LeaseLeasesRequest
 	}
 	leases := make([]*pb.LeaseStatus, len(r.Leases))
 	for i := range r.Leases {
-		leases[i] = &pb.LeaseStatus{ID: int64(r.Leases[i].ID)}
+		leases[i] = &pb.LeaseStatus{ID: int64(r.Leases[i].ID), Labels: r.Leases[i].Labels}
 	}
 	rp := &pb.LeaseLeasesResponse{
 		Header: r.ResponseHeader,
@@ -145,6 +156,8 @@ func (lp *leaseProxy) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error
 	lps := leaseProxyStream{
 		stream:          stream,
 		lessor:          lp.lessor,
+		keepAlives:      lp.keepAlives,
+		ttls:            lp.ttls,
 		keepAliveLeases: make(map[int64]*atomicCounter),
 		respc:           make(chan *pb.LeaseKeepAliveResponse),
 		ctx:             ctx,
@@ -228,7 +241,9 @@ func (lp *leaseProxy) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error
 type leaseProxyStream struct {
 	stream pb.Lease_LeaseKeepAliveServer
 
-	lessor clientv3.Lease
+	lessor     clientv3.Lease
+	keepAlives *leaseKeepAlives
+	ttls       *callGroup
 	// wg tracks keepAliveLoop goroutines
 	wg sync.WaitGroup
 	// mu protects keepAliveLeases
@@ -272,10 +287,11 @@ func (lps *leaseProxyStream) recvLoop() error {
 func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCounter) error {
 	cctx, ccancel := context.WithCancel(lps.ctx)
 	defer ccancel()
-	respc, err := lps.lessor.KeepAlive(cctx, clientv3.LeaseID(leaseID))
+	respc, stop, err := lps.keepAlives.subscribe(leaseID)
 	if err != nil {
 		return err
 	}
+	defer stop()
 	// ticker expires when loop hasn't received keepalive within TTL
 	var ticker <-chan time.Time
 	for {
@@ -300,15 +316,10 @@ func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCou
 				if neededResps.get() == 0 {
 					return nil
 				}
-				ttlResp, err := lps.lessor.TimeToLive(cctx, clientv3.LeaseID(leaseID))
+				r, err := lps.timeToLive(cctx, leaseID)
 				if err != nil {
 					return err
 				}
-				r := &pb.LeaseKeepAliveResponse{
-					Header: ttlResp.ResponseHeader,
-					ID:     int64(ttlResp.ID),
-					TTL:    ttlResp.TTL,
-				}
 				for neededResps.get() > 0 {
 					select {
 					case lps.respc <- r:
@@ -333,6 +344,26 @@ func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCou
 	}
 }
 
+// timeToLive gets the remaining TTL of a lease, sharing the request with the
+// other streams keeping the lease alive.
+func (lps *leaseProxyStream) timeToLive(ctx context.Context, leaseID int64) (*pb.LeaseKeepAliveResponse, error) {
+	r, err := lps.ttls.do(ctx, strconv.FormatInt(leaseID, 16), func(ctx context.Context) (interface{}, error) {
+		ttlResp, err := lps.lessor.TimeToLive(ctx, clientv3.LeaseID(leaseID))
+		if err != nil {
+			return nil, err
+		}
+		return &pb.LeaseKeepAliveResponse{
+			Header: ttlResp.ResponseHeader,
+			ID:     int64(ttlResp.ID),
+			TTL:    ttlResp.TTL,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*pb.LeaseKeepAliveResponse), nil
+}
+
 func (lps *leaseProxyStream) replyToClient(r *pb.LeaseKeepAliveResponse, neededResps *atomicCounter) {
 	timer := time.After(500 * time.Millisecond)
 	for neededResps.get() > 0 {
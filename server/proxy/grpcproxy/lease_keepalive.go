@@ -0,0 +1,120 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"sync"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+// leaseKeepAlives coalesces the keepalives of the client streams of the
+// proxy, such that each lease is kept alive by a single upstream keepalive,
+// whatever the number of client streams keeping it alive.
+type leaseKeepAlives struct {
+	lessor clientv3.Lease
+	ctx    context.Context
+
+	// mu protects kas and the subscribers of each keepalive.
+	mu  sync.Mutex
+	kas map[int64]*leaseKeepAlive
+}
+
+// leaseKeepAlive fans out the responses of the upstream keepalive of a lease
+// to the client streams keeping the lease alive.
+type leaseKeepAlive struct {
+	cancel context.CancelFunc
+	subs   map[chan *clientv3.LeaseKeepAliveResponse]struct{}
+}
+
+func newLeaseKeepAlives(ctx context.Context, lessor clientv3.Lease) *leaseKeepAlives {
+	return &leaseKeepAlives{lessor: lessor, ctx: ctx, kas: make(map[int64]*leaseKeepAlive)}
+}
+
+// subscribe returns a channel receiving the latest keepalive response of the
+// lease, which is closed once the upstream keepalive stops, e.g. as the lease
+// expired. The upstream keepalive stops once every subscriber has called the
+// returned cancel function.
+func (lkas *leaseKeepAlives) subscribe(leaseID int64) (<-chan *clientv3.LeaseKeepAliveResponse, func(), error) {
+	lkas.mu.Lock()
+	defer lkas.mu.Unlock()
+
+	ka, ok := lkas.kas[leaseID]
+	if !ok {
+		ctx, cancel := context.WithCancel(lkas.ctx)
+		respc, err := lkas.lessor.KeepAlive(ctx, clientv3.LeaseID(leaseID))
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		ka = &leaseKeepAlive{cancel: cancel, subs: make(map[chan *clientv3.LeaseKeepAliveResponse]struct{})}
+		lkas.kas[leaseID] = ka
+		go lkas.fanout(leaseID, ka, respc)
+	}
+	// a slow client stream only keeps the latest response, so that it
+	// neither delays the other streams nor lags behind
+	ch := make(chan *clientv3.LeaseKeepAliveResponse, 1)
+	ka.subs[ch] = struct{}{}
+	leaseKeepAlivesCoalescing.Inc()
+	return ch, func() { lkas.unsubscribe(leaseID, ka, ch) }, nil
+}
+
+func (lkas *leaseKeepAlives) unsubscribe(leaseID int64, ka *leaseKeepAlive, ch chan *clientv3.LeaseKeepAliveResponse) {
+	lkas.mu.Lock()
+	defer lkas.mu.Unlock()
+	if _, ok := ka.subs[ch]; !ok {
+		// already closed by fanout
+		return
+	}
+	delete(ka.subs, ch)
+	leaseKeepAlivesCoalescing.Dec()
+	if len(ka.subs) == 0 {
+		lkas.forget(leaseID, ka)
+		ka.cancel()
+	}
+}
+
+func (lkas *leaseKeepAlives) fanout(leaseID int64, ka *leaseKeepAlive, respc <-chan *clientv3.LeaseKeepAliveResponse) {
+	for resp := range respc {
+		lkas.mu.Lock()
+		for ch := range ka.subs {
+			// fanout is the only sender, so the channel has room once drained
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- resp
+		}
+		lkas.mu.Unlock()
+	}
+
+	lkas.mu.Lock()
+	for ch := range ka.subs {
+		close(ch)
+		delete(ka.subs, ch)
+		leaseKeepAlivesCoalescing.Dec()
+	}
+	lkas.forget(leaseID, ka)
+	lkas.mu.Unlock()
+	ka.cancel()
+}
+
+// forget lets the next subscriber of the lease start a new upstream keepalive.
+func (lkas *leaseKeepAlives) forget(leaseID int64, ka *leaseKeepAlive) {
+	if lkas.kas[leaseID] == ka {
+		delete(lkas.kas, leaseID)
+	}
+}
@@ -37,6 +37,33 @@ func (mp *maintenanceProxy) Defragment(ctx context.Context, dr *pb.DefragmentReq
 	return pb.NewMaintenanceClient(conn).Defragment(ctx, dr)
 }
 
+func (mp *maintenanceProxy) DefragmentStream(dr *pb.DefragmentRequest, stream pb.Maintenance_DefragmentStreamServer) error {
+	conn := mp.client.ActiveConnection()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	dc, err := pb.NewMaintenanceClient(conn).DefragmentStream(ctx, dr)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rr, err := dc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		err = stream.Send(rr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (mp *maintenanceProxy) Snapshot(sr *pb.SnapshotRequest, stream pb.Maintenance_SnapshotServer) error {
 	conn := mp.client.ActiveConnection()
 	ctx, cancel := context.WithCancel(stream.Context())
@@ -64,6 +91,41 @@ func (mp *maintenanceProxy) Snapshot(sr *pb.SnapshotRequest, stream pb.Maintenan
 	}
 }
 
+func (mp *maintenanceProxy) BulkImport(stream pb.Maintenance_BulkImportServer) error {
+	conn := mp.client.ActiveConnection()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	bc, err := pb.NewMaintenanceClient(conn).BulkImport(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rr, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = bc.Send(rr); err != nil {
+			if err == io.EOF {
+				// the server failed, CloseAndRecv returns its error
+				break
+			}
+			return err
+		}
+	}
+	resp, err := bc.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
 func (mp *maintenanceProxy) Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error) {
 	conn := mp.client.ActiveConnection()
 	return pb.NewMaintenanceClient(conn).Hash(ctx, r)
@@ -93,3 +155,105 @@ func (mp *maintenanceProxy) Downgrade(ctx context.Context, r *pb.DowngradeReques
 	conn := mp.client.ActiveConnection()
 	return pb.NewMaintenanceClient(conn).Downgrade(ctx, r)
 }
+
+func (mp *maintenanceProxy) WatchConsumers(ctx context.Context, r *pb.WatchConsumersRequest) (*pb.WatchConsumersResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).WatchConsumers(ctx, r)
+}
+
+func (mp *maintenanceProxy) ConfigAdvice(ctx context.Context, r *pb.ConfigAdviceRequest) (*pb.ConfigAdviceResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).ConfigAdvice(ctx, r)
+}
+
+func (mp *maintenanceProxy) KeyHistogram(ctx context.Context, r *pb.KeyHistogramRequest) (*pb.KeyHistogramResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).KeyHistogram(ctx, r)
+}
+
+func (mp *maintenanceProxy) ReadOnly(ctx context.Context, r *pb.ReadOnlyRequest) (*pb.ReadOnlyResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).ReadOnly(ctx, r)
+}
+
+func (mp *maintenanceProxy) NamespacePut(ctx context.Context, r *pb.NamespacePutRequest) (*pb.NamespacePutResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).NamespacePut(ctx, r)
+}
+
+func (mp *maintenanceProxy) NamespaceDelete(ctx context.Context, r *pb.NamespaceDeleteRequest) (*pb.NamespaceDeleteResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).NamespaceDelete(ctx, r)
+}
+
+func (mp *maintenanceProxy) NamespaceList(ctx context.Context, r *pb.NamespaceListRequest) (*pb.NamespaceListResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).NamespaceList(ctx, r)
+}
+
+func (mp *maintenanceProxy) IndexPut(ctx context.Context, r *pb.IndexPutRequest) (*pb.IndexPutResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).IndexPut(ctx, r)
+}
+
+func (mp *maintenanceProxy) IndexDelete(ctx context.Context, r *pb.IndexDeleteRequest) (*pb.IndexDeleteResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).IndexDelete(ctx, r)
+}
+
+func (mp *maintenanceProxy) IndexList(ctx context.Context, r *pb.IndexListRequest) (*pb.IndexListResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).IndexList(ctx, r)
+}
+
+func (mp *maintenanceProxy) IndexRange(ctx context.Context, r *pb.IndexRangeRequest) (*pb.IndexRangeResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).IndexRange(ctx, r)
+}
+
+func (mp *maintenanceProxy) LogLevel(ctx context.Context, r *pb.LogLevelRequest) (*pb.LogLevelResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).LogLevel(ctx, r)
+}
+
+func (mp *maintenanceProxy) SlowLog(ctx context.Context, r *pb.SlowLogRequest) (*pb.SlowLogResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).SlowLog(ctx, r)
+}
+
+func (mp *maintenanceProxy) TopKeys(ctx context.Context, r *pb.TopKeysRequest) (*pb.TopKeysResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).TopKeys(ctx, r)
+}
+
+func (mp *maintenanceProxy) Fragmentation(ctx context.Context, r *pb.FragmentationRequest) (*pb.FragmentationResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).Fragmentation(ctx, r)
+}
+
+func (mp *maintenanceProxy) Backup(r *pb.BackupRequest, stream pb.Maintenance_BackupServer) error {
+	conn := mp.client.ActiveConnection()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	bc, err := pb.NewMaintenanceClient(conn).Backup(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rr, err := bc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		err = stream.Send(rr)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -40,6 +40,18 @@ var (
 		Name:      "events_coalescing_total",
 		Help:      "Total number of events coalescing",
 	})
+	leaseKeepAlivesCoalescing = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "lease_keepalives_coalescing_total",
+		Help:      "Total number of current lease keepalives of client streams coalescing",
+	})
+	callsCoalescing = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "calls_coalescing_total",
+		Help:      "Total number of requests coalescing with an in-flight request",
+	})
 	cacheKeys = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "etcd",
 		Subsystem: "grpc_proxy",
@@ -58,14 +70,30 @@ var (
 		Name:      "cache_misses_total",
 		Help:      "Total number of cache misses",
 	})
+	cacheHitRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "cache_hit_rate",
+		Help:      "Ratio of the serializable ranges served from the cache",
+	})
+	cacheInvalidations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "grpc_proxy",
+		Name:      "cache_invalidations_total",
+		Help:      "Total number of key changes invalidating the cache",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(watchersCoalescing)
 	prometheus.MustRegister(eventsCoalescing)
+	prometheus.MustRegister(leaseKeepAlivesCoalescing)
+	prometheus.MustRegister(callsCoalescing)
 	prometheus.MustRegister(cacheKeys)
 	prometheus.MustRegister(cacheHits)
 	prometheus.MustRegister(cachedMisses)
+	prometheus.MustRegister(cacheHitRate)
+	prometheus.MustRegister(cacheInvalidations)
 }
 
 // HandleMetrics performs a GET request against etcd endpoint and returns '/metrics'.
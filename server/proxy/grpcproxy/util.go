@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -42,6 +43,18 @@ func withClientAuthToken(ctx, ctxWithToken context.Context) context.Context {
 	return ctx
 }
 
+// withClientRequestID attaches the request ID of the client to the mutation
+// forwarded to the cluster, so that its retries are applied once.
+func withClientRequestID(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if ids := md.Get(rpctypes.MetadataRequestIDKey); len(ids) > 0 {
+			return clientv3.WithRequestID(ctx, ids[0])
+		}
+	}
+	return ctx
+}
+
 type proxyTokenCredential struct {
 	token string
 }
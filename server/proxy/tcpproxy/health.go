@@ -0,0 +1,96 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcpproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthChecker checks the health of the member serving an endpoint. It
+// returns the progress of the member, such as its raft index, for the proxy
+// to tell the members lagging behind the others, or 0 if unknown.
+type HealthChecker func(ctx context.Context, addr string) (progress uint64, err error)
+
+// NewGRPCHealthChecker returns a HealthChecker querying the gRPC health
+// service of the members, and their raft index through the maintenance
+// service. The checks use TLS if tlsConfig is not nil.
+func NewGRPCHealthChecker(tlsConfig *tls.Config) HealthChecker {
+	return func(ctx context.Context, addr string) (uint64, error) {
+		opts := []grpc.DialOption{grpc.WithBlock()}
+		if tlsConfig != nil {
+			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+		conn, err := grpc.DialContext(ctx, addr, opts...)
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+
+		hresp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return 0, err
+		}
+		if hresp.Status != healthpb.HealthCheckResponse_SERVING {
+			return 0, fmt.Errorf("member is %s", hresp.Status)
+		}
+		sresp, err := pb.NewMaintenanceClient(conn).Status(ctx, &pb.StatusRequest{})
+		if err != nil {
+			return 0, err
+		}
+		return sresp.RaftIndex, nil
+	}
+}
+
+// NewHTTPHealthChecker returns a HealthChecker querying the /health endpoint
+// of the members. The checks use TLS if tlsConfig is not nil.
+func NewHTTPHealthChecker(tlsConfig *tls.Config) HealthChecker {
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	cli := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return func(ctx context.Context, addr string) (uint64, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, addr, etcdhttp.PathHealth), nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		var h etcdhttp.Health
+		if err = json.NewDecoder(resp.Body).Decode(&h); err != nil {
+			return 0, err
+		}
+		if h.Health != "true" {
+			return 0, fmt.Errorf("member is unhealthy (%s)", h.Reason)
+		}
+		return 0, nil
+	}
+}
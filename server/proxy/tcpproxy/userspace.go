@@ -15,6 +15,7 @@
 package tcpproxy
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
@@ -51,6 +52,16 @@ func (r *remote) tryReactivate() error {
 	return nil
 }
 
+// setHealth activates the remote if healthy, and inactivates it otherwise.
+// It returns whether the remote was active before.
+func (r *remote) setHealth(err error) (wasActive bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wasActive = !r.inactive
+	r.inactive = err != nil
+	return wasActive
+}
+
 func (r *remote) isActive() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -63,6 +74,17 @@ type TCPProxy struct {
 	Endpoints       []*net.SRV
 	MonitorInterval time.Duration
 
+	// HealthCheck, if set, actively checks the health of all the endpoints
+	// every HealthCheckInterval, routing the new connections to the healthy
+	// ones only. It supersedes the reactivation of the endpoints failing to
+	// connect every MonitorInterval.
+	HealthCheck         HealthChecker
+	HealthCheckInterval time.Duration
+	// MaxLag is the progress reported by HealthCheck an endpoint may lag
+	// behind the most advanced endpoint before it is considered unhealthy.
+	// Zero disables the lag check.
+	MaxLag uint64
+
 	donec chan struct{}
 
 	mu        sync.Mutex // guards the following fields
@@ -91,6 +113,9 @@ func (tp *TCPProxy) Run() error {
 	if tp.MonitorInterval == 0 {
 		tp.MonitorInterval = 5 * time.Minute
 	}
+	if tp.HealthCheckInterval == 0 {
+		tp.HealthCheckInterval = 5 * time.Second
+	}
 	for _, srv := range tp.Endpoints {
 		addr := formatAddr(srv.Target, srv.Port)
 		tp.remotes = append(tp.remotes, &remote{srv: srv, addr: addr})
@@ -104,7 +129,11 @@ func (tp *TCPProxy) Run() error {
 		tp.Logger.Info("ready to proxy client requests", zap.Strings("endpoints", eps))
 	}
 
-	go tp.runMonitor()
+	if tp.HealthCheck != nil {
+		go tp.runHealthCheck()
+	} else {
+		go tp.runMonitor()
+	}
 	for {
 		in, err := tp.Listener.Accept()
 		if err != nil {
@@ -240,6 +269,58 @@ func (tp *TCPProxy) runMonitor() {
 	}
 }
 
+func (tp *TCPProxy) runHealthCheck() {
+	for {
+		tp.checkHealth()
+		select {
+		case <-time.After(tp.HealthCheckInterval):
+		case <-tp.donec:
+			return
+		}
+	}
+}
+
+// checkHealth checks the health of all the endpoints at once, so that the
+// lagging endpoints are told from the progress of the others.
+func (tp *TCPProxy) checkHealth() {
+	progress := make([]uint64, len(tp.remotes))
+	errs := make([]error, len(tp.remotes))
+	var wg sync.WaitGroup
+	for i, rem := range tp.remotes {
+		wg.Add(1)
+		go func(i int, r *remote) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), tp.HealthCheckInterval)
+			defer cancel()
+			progress[i], errs[i] = tp.HealthCheck(ctx, r.addr)
+		}(i, rem)
+	}
+	wg.Wait()
+
+	var maxProgress uint64
+	for i := range tp.remotes {
+		if errs[i] == nil && progress[i] > maxProgress {
+			maxProgress = progress[i]
+		}
+	}
+	for i, rem := range tp.remotes {
+		err := errs[i]
+		if err == nil && tp.MaxLag > 0 && maxProgress-progress[i] > tp.MaxLag {
+			err = fmt.Errorf("lagging %d behind the most advanced endpoint", maxProgress-progress[i])
+		}
+		wasActive := rem.setHealth(err)
+		if tp.Logger == nil {
+			continue
+		}
+		switch {
+		case wasActive && err != nil:
+			tp.Logger.Warn("deactivated unhealthy endpoint", zap.String("address", rem.addr), zap.Duration("interval", tp.HealthCheckInterval), zap.Error(err))
+		case !wasActive && err == nil:
+			tp.Logger.Info("activated", zap.String("address", rem.addr))
+		}
+	}
+}
+
 func (tp *TCPProxy) Stop() {
 	// graceful shutdown?
 	// shutdown current connections?
@@ -15,13 +15,17 @@
 package tcpproxy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestUserspaceProxy(t *testing.T) {
@@ -160,3 +164,82 @@ func TestFormatAddr(t *testing.T) {
 		}
 	}
 }
+
+func TestUserspaceProxyHealthCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	backends := []struct {
+		Payload  string
+		Progress uint64
+		Err      error
+	}{
+		{"hello proxy 1", 100, errors.New("unhealthy")},
+		{"hello proxy 2", 100, nil},
+		{"hello proxy 3", 10, nil},
+	}
+
+	var eps []*net.SRV
+	var front *url.URL
+	health := make(map[string]int)
+	for i, b := range backends {
+		backend := b
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, backend.Payload)
+		}))
+		defer ts.Close()
+
+		front, err = url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		health[front.Host] = i
+
+		var port uint16
+		fmt.Sscanf(front.Port(), "%d", &port)
+
+		eps = append(eps, &net.SRV{Target: front.Hostname(), Port: port})
+	}
+
+	var checks int64
+	p := TCPProxy{
+		Listener:  l,
+		Endpoints: eps,
+		HealthCheck: func(ctx context.Context, addr string) (uint64, error) {
+			atomic.AddInt64(&checks, 1)
+			b := backends[health[addr]]
+			return b.Progress, b.Err
+		},
+		HealthCheckInterval: 10 * time.Millisecond,
+		MaxLag:              50,
+	}
+	go p.Run()
+	defer p.Stop()
+
+	// the second round of checks starts after the first one is applied
+	for atomic.LoadInt64(&checks) <= int64(len(backends)) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	front.Host = l.Addr().String()
+	cli := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for i := 0; i < 10; i++ {
+		res, err := cli.Get(front.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, gerr := io.ReadAll(res.Body)
+		res.Body.Close()
+		if gerr != nil {
+			t.Fatal(gerr)
+		}
+
+		want := "hello proxy 2"
+		if string(got) != want {
+			t.Errorf("got = %s, want %s", got, want)
+		}
+	}
+}
@@ -28,7 +28,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func newBackend(cfg config.ServerConfig, hooks backend.Hooks) backend.Backend {
+func newBackend(cfg config.ServerConfig, hooks *BackendHooks) backend.Backend {
 	bcfg := backend.DefaultBackendConfig(cfg.Logger)
 	bcfg.Path = cfg.BackendPath()
 	bcfg.UnsafeNoFsync = cfg.UnsafeNoFsync
@@ -51,8 +51,19 @@ func newBackend(cfg config.ServerConfig, hooks backend.Hooks) backend.Backend {
 		bcfg.MmapSize = uint64(cfg.QuotaBackendBytes + cfg.QuotaBackendBytes/10)
 	}
 	bcfg.Mlock = cfg.ExperimentalMemoryMlock
+	if hooks == nil {
+		return backend.New(bcfg)
+	}
 	bcfg.Hooks = hooks
-	return backend.New(bcfg)
+	if hooks.keyring == nil {
+		return backend.New(bcfg)
+	}
+	bcfg.Cipher = schema.NewBackendCipher(hooks.keyring)
+	be := backend.New(bcfg)
+	if err := hooks.loadEncryptionKeys(be); err != nil {
+		cfg.Logger.Fatal("failed to load the backend encryption keys", zap.String("path", bcfg.Path), zap.Error(err))
+	}
+	return be
 }
 
 // OpenSnapshotBackend renames a snapshot db to the current etcd db and opens it.
@@ -68,7 +79,7 @@ func OpenSnapshotBackend(cfg config.ServerConfig, ss *snap.Snapshotter, snapshot
 }
 
 // OpenBackend returns a backend using the current etcd db.
-func OpenBackend(cfg config.ServerConfig, hooks backend.Hooks) backend.Backend {
+func OpenBackend(cfg config.ServerConfig, hooks *BackendHooks) backend.Backend {
 	fn := cfg.BackendPath()
 
 	now, beOpened := time.Now(), make(chan backend.Backend)
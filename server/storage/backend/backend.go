@@ -52,6 +52,9 @@ type Backend interface {
 	ConcurrentReadTx() ReadTx
 
 	Snapshot() Snapshot
+	// SnapshotWithout returns a snapshot of the backend in which the buckets
+	// selected by empty exist but hold no keys.
+	SnapshotWithout(empty func(bucketName []byte) bool) (Snapshot, error)
 	Hash(ignores func(bucketName, keyName []byte) bool) (uint32, error)
 	// Size returns the current size of the backend physically allocated.
 	// The backend can hold DB space that is not utilized at the moment,
@@ -65,6 +68,14 @@ type Backend interface {
 	// OpenReadTxN returns the number of currently open read transactions in the backend.
 	OpenReadTxN() int64
 	Defrag() error
+	// DefragWithProgress defragments the backend like Defrag, calling progress
+	// as the keys are copied to the defragmented file, and once done.
+	DefragWithProgress(progress func(DefragProgress)) error
+	// IsDefragActive reports whether the backend is being defragmented.
+	IsDefragActive() bool
+	// PageStats reports how the pages of the backend are used, walking the
+	// pages of all the buckets.
+	PageStats() (PageStats, error)
 	ForceCommit()
 	Close() error
 
@@ -99,6 +110,10 @@ type backend struct {
 	commits int64
 	// openReadTxN is the number of currently open read transactions in the backend
 	openReadTxN int64
+	// defragActive is 1 while the backend is being defragmented
+	defragActive int32
+	// defragMu serializes defragmentations.
+	defragMu sync.Mutex
 	// mlock prevents backend database file to be swapped
 	mlock bool
 
@@ -122,6 +137,9 @@ type backend struct {
 
 	hooks Hooks
 
+	// cipher encrypts the values written to the database file.
+	cipher valueCipher
+
 	// txPostLockInsideApplyHook is called each time right after locking the tx.
 	txPostLockInsideApplyHook func()
 
@@ -148,6 +166,8 @@ type BackendConfig struct {
 
 	// Hooks are getting executed during lifecycle of Backend's transactions.
 	Hooks Hooks
+	// Cipher, if set, encrypts the values of buckets in the database file.
+	Cipher ValueCipher
 }
 
 func DefaultBackendConfig(lg *zap.Logger) BackendConfig {
@@ -187,6 +207,7 @@ func newBackend(bcfg BackendConfig) *backend {
 
 	// In future, may want to make buffering optional for low-concurrency systems
 	// or dynamically swap between buffered/non-buffered depending on workload.
+	cipher := valueCipher{c: bcfg.Cipher, lg: bcfg.Logger}
 	b := &backend{
 		bopts: bopts,
 		db:    db,
@@ -204,6 +225,7 @@ func newBackend(bcfg BackendConfig) *backend {
 				buckets: make(map[BucketID]*bolt.Bucket),
 				txWg:    new(sync.WaitGroup),
 				txMu:    new(sync.RWMutex),
+				cipher:  cipher,
 			},
 		},
 		txReadBufferCache: txReadBufferCache{
@@ -216,6 +238,8 @@ func newBackend(bcfg BackendConfig) *backend {
 		donec: make(chan struct{}),
 
 		lg: bcfg.Logger,
+
+		cipher: cipher,
 	}
 
 	b.batchTx = newBatchTxBuffered(b)
@@ -317,6 +341,7 @@ func (b *backend) ConcurrentReadTx() ReadTx {
 			tx:      b.readTx.tx,
 			buckets: b.readTx.buckets,
 			txWg:    b.readTx.txWg,
+			cipher:  b.readTx.cipher,
 		},
 	}
 }
@@ -370,8 +395,67 @@ func (b *backend) Snapshot() Snapshot {
 	return &snapshot{tx, stopc, donec}
 }
 
+func (b *backend) SnapshotWithout(empty func(bucketName []byte) bool) (Snapshot, error) {
+	b.batchTx.Commit()
+
+	b.mu.RLock()
+	tx, err := b.db.Begin(false)
+	b.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Stage the snapshot in a temporary file, so it can be streamed like a
+	// regular snapshot. Snapshotter.cleanupSnapdir cleans up any of these that
+	// are left behind by a crash.
+	temp, err := os.CreateTemp(filepath.Dir(b.db.Path()), "db.tmp.*")
+	if err != nil {
+		return nil, err
+	}
+	temp.Close()
+	tmpdb, err := bolt.Open(temp.Name(), 0600, &bolt.Options{NoSync: true})
+	if err != nil {
+		os.Remove(temp.Name())
+		return nil, err
+	}
+	abort := func() {
+		tmpdb.Close()
+		os.Remove(tmpdb.Path())
+	}
+
+	err = tmpdb.Update(func(tmptx *bolt.Tx) error {
+		c := tx.Cursor()
+		for next, _ := c.First(); next != nil; next, _ = c.Next() {
+			tmpb, berr := tmptx.CreateBucketIfNotExists(next)
+			if berr != nil {
+				return berr
+			}
+			if empty(next) {
+				continue
+			}
+			tmpb.FillPercent = 0.9
+			if berr = tx.Bucket(next).ForEach(tmpb.Put); berr != nil {
+				return berr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		abort()
+		return nil, err
+	}
+	ttx, err := tmpdb.Begin(false)
+	if err != nil {
+		abort()
+		return nil, err
+	}
+	return &tempSnapshot{ttx}, nil
+}
+
 func (b *backend) Hash(ignores func(bucketName, keyName []byte) bool) (uint32, error) {
 	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	bcipher := b.cipher
 
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -386,7 +470,7 @@ func (b *backend) Hash(ignores func(bucketName, keyName []byte) bool) (uint32, e
 			b.ForEach(func(k, v []byte) error {
 				if ignores != nil && !ignores(next, k) {
 					h.Write(k)
-					h.Write(v)
+					h.Write(bcipher.decrypt(next, k, v))
 				}
 				return nil
 			})
@@ -441,31 +525,32 @@ func (b *backend) Commits() int64 {
 }
 
 func (b *backend) Defrag() error {
-	return b.defrag()
+	return b.defrag(nil)
 }
 
-func (b *backend) defrag() error {
-	now := time.Now()
-	isDefragActive.Set(1)
-	defer isDefragActive.Set(0)
-
-	// TODO: make this non-blocking?
-	// lock batchTx to ensure nobody is using previous tx, and then
-	// close previous ongoing tx.
-	b.batchTx.LockOutsideApply()
-	defer b.batchTx.Unlock()
-
-	// lock database after lock tx to avoid deadlock.
-	b.mu.Lock()
-	defer b.mu.Unlock()
+func (b *backend) DefragWithProgress(progress func(DefragProgress)) error {
+	return b.defrag(progress)
+}
 
-	// block concurrent read requests while resetting tx
-	b.readTx.Lock()
-	defer b.readTx.Unlock()
+func (b *backend) IsDefragActive() bool {
+	return atomic.LoadInt32(&b.defragActive) == 1
+}
 
-	b.batchTx.unsafeCommit(true)
+// defrag copies the backend to a new file while serving reads and writes,
+// tracking the keys written meanwhile, then copies the written keys again
+// until few enough are written to block the writes for copying the last ones
+// and switching to the new file.
+func (b *backend) defrag(progress func(DefragProgress)) error {
+	b.defragMu.Lock()
+	defer b.defragMu.Unlock()
 
-	b.batchTx.tx = nil
+	now := time.Now()
+	isDefragActive.Set(1)
+	atomic.StoreInt32(&b.defragActive, 1)
+	defer func() {
+		isDefragActive.Set(0)
+		atomic.StoreInt32(&b.defragActive, 0)
+	}()
 
 	// Create a temporary file to ensure we start with a clean slate.
 	// Snapshotter.cleanupSnapdir cleans up any of these that are found during startup.
@@ -488,6 +573,12 @@ func (b *backend) defrag() error {
 	if err != nil {
 		return err
 	}
+	abort := func() {
+		tmpdb.Close()
+		if rmErr := os.RemoveAll(tmpdb.Path()); rmErr != nil {
+			b.lg.Error("failed to remove db.tmp after defragmentation completed", zap.Error(rmErr))
+		}
+	}
 
 	dbp := b.db.Path()
 	size1, sizeInUse1 := b.Size(), b.SizeInUse()
@@ -501,16 +592,108 @@ func (b *backend) defrag() error {
 			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse1))),
 		)
 	}
+
+	var p DefragProgress
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	_, tx := b.trackDefragWrites(newDefragWrites())
+	p.TotalKeys = countKeys(tx)
+	report()
 	// gofail: var defragBeforeCopy struct{}
-	err = defragdb(b.db, tmpdb, defragLimit)
+	err = defragdb(tx, tmpdb, defragLimit, func(n int64) {
+		p.CopiedKeys += n
+		report()
+	})
+	tx.Rollback()
 	if err != nil {
-		tmpdb.Close()
-		if rmErr := os.RemoveAll(tmpdb.Path()); rmErr != nil {
-			b.lg.Error("failed to remove db.tmp after defragmentation completed", zap.Error(rmErr))
+		b.stopTrackingDefragWrites()
+		abort()
+		return err
+	}
+
+	for i := 0; i < defragMaxCatchUps; i++ {
+		var writes *defragWrites
+		writes, tx = b.trackDefragWrites(newDefragWrites())
+		var n int64
+		n, err = writes.copy(tx, tmpdb)
+		tx.Rollback()
+		if err != nil {
+			b.stopTrackingDefragWrites()
+			abort()
+			return err
 		}
+		p.RecopiedKeys += n
+		report()
+		if n < int64(defragCatchUpLimit) {
+			break
+		}
+	}
+
+	n, err := b.switchToDefragged(tmpdb, abort)
+	if err != nil {
 		return err
 	}
+	p.RecopiedKeys += n
 
+	took := time.Since(now)
+	defragSec.Observe(took.Seconds())
+
+	size2, sizeInUse2 := b.Size(), b.SizeInUse()
+	if b.lg != nil {
+		b.lg.Info(
+			"finished defragmenting directory",
+			zap.String("path", dbp),
+			zap.Int64("current-db-size-bytes-diff", size2-size1),
+			zap.Int64("current-db-size-bytes", size2),
+			zap.String("current-db-size", humanize.Bytes(uint64(size2))),
+			zap.Int64("current-db-size-in-use-bytes-diff", sizeInUse2-sizeInUse1),
+			zap.Int64("current-db-size-in-use-bytes", sizeInUse2),
+			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse2))),
+			zap.Int64("recopied-keys", p.RecopiedKeys),
+			zap.Duration("took", took),
+		)
+	}
+	report()
+	return nil
+}
+
+// switchToDefragged blocks reads and writes to copy the last keys written to
+// tmpdb, then replaces the backend file with it. It returns the number of keys
+// copied.
+func (b *backend) switchToDefragged(tmpdb *bolt.DB, abort func()) (int64, error) {
+	// lock batchTx to ensure nobody is using previous tx, and then
+	// close previous ongoing tx.
+	b.batchTx.LockOutsideApply()
+	defer b.batchTx.Unlock()
+
+	// lock database after lock tx to avoid deadlock.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// block concurrent read requests while resetting tx
+	b.readTx.Lock()
+	defer b.readTx.Unlock()
+
+	b.batchTx.unsafeCommit(true)
+
+	b.batchTx.tx = nil
+
+	writes := b.batchTx.defragWrites
+	b.batchTx.defragWrites = nil
+	tx := b.unsafeBegin(false)
+	n, err := writes.copy(tx, tmpdb)
+	tx.Rollback()
+	if err != nil {
+		abort()
+		b.batchTx.tx = b.unsafeBegin(true)
+		b.readTx.tx = b.unsafeBegin(false)
+		return 0, err
+	}
+
+	dbp, tdbp := b.db.Path(), tmpdb.Path()
 	err = b.db.Close()
 	if err != nil {
 		b.lg.Fatal("failed to close database", zap.Error(err))
@@ -538,28 +721,30 @@ func (b *backend) defrag() error {
 	db := b.readTx.tx.DB()
 	atomic.StoreInt64(&b.size, size)
 	atomic.StoreInt64(&b.sizeInUse, size-(int64(db.Stats().FreePageN)*int64(db.Info().PageSize)))
+	return n, nil
+}
 
-	took := time.Since(now)
-	defragSec.Observe(took.Seconds())
+// trackDefragWrites commits the pending writes and starts tracking the
+// following ones with writes. It returns the writes tracked until then, and a
+// read transaction of the committed ones.
+func (b *backend) trackDefragWrites(writes *defragWrites) (*defragWrites, *bolt.Tx) {
+	b.batchTx.LockOutsideApply()
+	defer b.batchTx.Unlock()
+	b.batchTx.commit(false)
+	prev := b.batchTx.defragWrites
+	b.batchTx.defragWrites = writes
+	return prev, b.begin(false)
+}
 
-	size2, sizeInUse2 := b.Size(), b.SizeInUse()
-	if b.lg != nil {
-		b.lg.Info(
-			"finished defragmenting directory",
-			zap.String("path", dbp),
-			zap.Int64("current-db-size-bytes-diff", size2-size1),
-			zap.Int64("current-db-size-bytes", size2),
-			zap.String("current-db-size", humanize.Bytes(uint64(size2))),
-			zap.Int64("current-db-size-in-use-bytes-diff", sizeInUse2-sizeInUse1),
-			zap.Int64("current-db-size-in-use-bytes", sizeInUse2),
-			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse2))),
-			zap.Duration("took", took),
-		)
-	}
-	return nil
+func (b *backend) stopTrackingDefragWrites() {
+	b.batchTx.LockOutsideApply()
+	b.batchTx.defragWrites = nil
+	b.batchTx.Unlock()
 }
 
-func defragdb(odb, tmpdb *bolt.DB, limit int) error {
+// defragdb copies the buckets of tx to tmpdb, committing every limit keys
+// and reporting their number to copied.
+func defragdb(tx *bolt.Tx, tmpdb *bolt.DB, limit int, copied func(n int64)) error {
 	// open a tx on tmpdb for writes
 	tmptx, err := tmpdb.Begin(true)
 	if err != nil {
@@ -571,13 +756,6 @@ func defragdb(odb, tmpdb *bolt.DB, limit int) error {
 		}
 	}()
 
-	// open a tx on old db for read
-	tx, err := odb.Begin(false)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
 	c := tx.Cursor()
 
 	count := 0
@@ -600,6 +778,7 @@ func defragdb(odb, tmpdb *bolt.DB, limit int) error {
 				if err != nil {
 					return err
 				}
+				copied(int64(limit))
 				tmptx, err = tmpdb.Begin(true)
 				if err != nil {
 					return err
@@ -607,7 +786,7 @@ func defragdb(odb, tmpdb *bolt.DB, limit int) error {
 				tmpb = tmptx.Bucket(next)
 				tmpb.FillPercent = 0.9 // for bucket2seq write in for each
 
-				count = 0
+				count = 1
 			}
 			return tmpb.Put(k, v)
 		}); err != nil {
@@ -615,7 +794,11 @@ func defragdb(odb, tmpdb *bolt.DB, limit int) error {
 		}
 	}
 
-	return tmptx.Commit()
+	if err = tmptx.Commit(); err != nil {
+		return err
+	}
+	copied(int64(count))
+	return nil
 }
 
 func (b *backend) begin(write bool) *bolt.Tx {
@@ -629,6 +812,9 @@ func (b *backend) begin(write bool) *bolt.Tx {
 	atomic.StoreInt64(&b.size, size)
 	atomic.StoreInt64(&b.sizeInUse, size-(int64(stats.FreePageN)*int64(db.Info().PageSize)))
 	atomic.StoreInt64(&b.openReadTxN, int64(stats.OpenTxN))
+	freePages.Set(float64(stats.FreePageN))
+	pendingPages.Set(float64(stats.PendingPageN))
+	freelistBytes.Set(float64(stats.FreelistInuse))
 
 	return tx
 }
@@ -656,3 +842,22 @@ func (s *snapshot) Close() error {
 	<-s.donec
 	return s.Tx.Rollback()
 }
+
+// tempSnapshot is a snapshot of a temporary database, which is removed once
+// the snapshot is closed.
+type tempSnapshot struct {
+	*bolt.Tx
+}
+
+func (s *tempSnapshot) Close() error {
+	db := s.Tx.DB()
+	path := db.Path()
+	err := s.Tx.Rollback()
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if rerr := os.Remove(path); err == nil {
+		err = rerr
+	}
+	return err
+}
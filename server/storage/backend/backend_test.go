@@ -15,8 +15,10 @@
 package backend_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -86,6 +88,55 @@ func TestBackendSnapshot(t *testing.T) {
 	newTx.Unlock()
 }
 
+func TestBackendSnapshotWithout(t *testing.T) {
+	b, _ := betesting.NewTmpBackend(t, time.Hour, 10000)
+	defer betesting.Close(t, b)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.UnsafePut(schema.Test, []byte("foo"), []byte("bar"))
+	tx.UnsafeCreateBucket(schema.Key)
+	tx.UnsafePut(schema.Key, []byte("foo"), []byte("bar"))
+	tx.Unlock()
+
+	f, err := os.CreateTemp(t.TempDir(), "etcd_backend_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := b.SnapshotWithout(func(bucketName []byte) bool {
+		return bytes.Equal(bucketName, schema.Key.Name())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snap.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, f.Close())
+	assert.NoError(t, snap.Close())
+
+	// the staging file is removed on close.
+	tmps, err := filepath.Glob(filepath.Join(filepath.Dir(backend.DbFromBackendForTest(b).Path()), "db.tmp.*"))
+	assert.NoError(t, err)
+	assert.Empty(t, tmps)
+
+	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+	bcfg.Path, bcfg.BatchInterval, bcfg.BatchLimit = f.Name(), time.Hour, 10000
+	nb := backend.New(bcfg)
+	defer betesting.Close(t, nb)
+
+	newTx := nb.BatchTx()
+	newTx.Lock()
+	defer newTx.Unlock()
+	if ks, _ := newTx.UnsafeRange(schema.Test, []byte("foo"), nil, 0); len(ks) != 1 {
+		t.Errorf("len(test kvs) = %d, want 1", len(ks))
+	}
+	if ks, _ := newTx.UnsafeRange(schema.Key, []byte("foo"), nil, 0); len(ks) != 0 {
+		t.Errorf("len(key kvs) = %d, want 0", len(ks))
+	}
+}
+
 func TestBackendBatchIntervalCommit(t *testing.T) {
 	// start backend with super short batch interval so
 	// we do not need to wait long before commit to happen.
@@ -193,7 +244,132 @@ func TestBackendDefrag(t *testing.T) {
 	b.ForceCommit()
 }
 
+func TestBackendDefragWithProgress(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+
+	limit := backend.DefragLimitForTest()
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	for i := 0; i < limit+100; i++ {
+		tx.UnsafePut(schema.Test, []byte(fmt.Sprintf("foo_%d", i)), []byte("bar"))
+	}
+	tx.Unlock()
+	b.ForceCommit()
+
+	var progress []backend.DefragProgress
+	err := b.DefragWithProgress(func(p backend.DefragProgress) {
+		progress = append(progress, p)
+		// write to the backend at each stage of the defragmentation
+		tx := b.BatchTx()
+		tx.Lock()
+		defer tx.Unlock()
+		switch len(progress) {
+		case 2: // while copying the keys
+			for i := 0; i < 2*backend.DefragCatchUpLimitForTest(); i++ {
+				tx.UnsafePut(schema.Test, []byte(fmt.Sprintf("new_%d", i)), []byte("bar"))
+			}
+			tx.UnsafeDelete(schema.Test, []byte("foo_0"))
+		case 3: // once the keys are copied
+			tx.UnsafePut(schema.Test, []byte("foo_1"), []byte("baz"))
+		case 4: // once the keys written meanwhile are copied again
+			tx.UnsafeDelete(schema.Test, []byte("new_0"))
+		case 5: // before switching to the defragmented file
+			tx.UnsafePut(schema.Test, []byte("foo_2"), []byte("baz"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wprogress := backend.DefragProgress{
+		TotalKeys:    int64(limit + 100),
+		CopiedKeys:   int64(limit + 100),
+		RecopiedKeys: int64(2*backend.DefragCatchUpLimitForTest() + 4),
+	}
+	if len(progress) != 6 || progress[5] != wprogress {
+		t.Fatalf("expected 6 progress reports ending with %+v, got %+v", wprogress, progress)
+	}
+
+	kvs := make(map[string]string)
+	rtx := b.ReadTx()
+	rtx.RLock()
+	rtx.UnsafeForEach(schema.Test, func(k, v []byte) error {
+		kvs[string(k)] = string(v)
+		return nil
+	})
+	rtx.RUnlock()
+	if len(kvs) != limit+100-1+2*backend.DefragCatchUpLimitForTest()-1 {
+		t.Errorf("expected %d keys after defrag, got %d", limit+100-1+2*backend.DefragCatchUpLimitForTest()-1, len(kvs))
+	}
+	for k, want := range map[string]string{"foo_0": "", "foo_1": "baz", "foo_2": "baz", "foo_3": "bar", "new_0": "", "new_1": "bar"} {
+		if kvs[k] != want {
+			t.Errorf("expected %q to be %q after defrag, got %q", k, want, kvs[k])
+		}
+	}
+}
+
 // TestBackendWriteback ensures writes are stored to the read txn on write txn unlock.
+func TestBackendPageStats(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	for i := 0; i < 10000; i++ {
+		tx.UnsafePut(schema.Test, []byte(fmt.Sprintf("foo_%05d", i)), bytes.Repeat([]byte("bar"), 100))
+	}
+	tx.Unlock()
+	b.ForceCommit()
+
+	tx = b.BatchTx()
+	tx.Lock()
+	for i := 0; i < 9000; i++ {
+		tx.UnsafeDelete(schema.Test, []byte(fmt.Sprintf("foo_%05d", i)))
+	}
+	tx.Unlock()
+	b.ForceCommit()
+
+	ps, err := b.PageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.Size != b.Size() {
+		t.Errorf("size = %d, want %d", ps.Size, b.Size())
+	}
+	if ps.FreePages+ps.PendingPages == 0 {
+		t.Errorf("free and pending pages = 0, want the pages of the deleted keys")
+	}
+	var test *backend.BucketPageStats
+	for i := range ps.Buckets {
+		if ps.Buckets[i].Name == string(schema.Test.Name()) {
+			test = &ps.Buckets[i]
+		}
+	}
+	if test == nil {
+		t.Fatalf("no page stats of bucket %q in %+v", schema.Test.Name(), ps.Buckets)
+	}
+	if test.Keys != 1000 {
+		t.Errorf("keys = %d, want 1000", test.Keys)
+	}
+	if r := test.FillRatio(); r <= 0 || r > 1 {
+		t.Errorf("fill ratio = %v, want in (0, 1]", r)
+	}
+
+	estimate := ps.DefragSize()
+	if estimate >= ps.Size {
+		t.Fatalf("defrag size = %d, want less than the size %d", estimate, ps.Size)
+	}
+	if err := b.Defrag(); err != nil {
+		t.Fatal(err)
+	}
+	if size := b.Size(); size > estimate*2 || size < estimate/2 {
+		t.Errorf("defragmented size = %d, estimated %d", size, estimate)
+	}
+}
+
 func TestBackendWriteback(t *testing.T) {
 	b, _ := betesting.NewDefaultTmpBackend(t)
 	defer betesting.Close(t, b)
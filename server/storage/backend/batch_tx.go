@@ -63,6 +63,8 @@ type batchTx struct {
 	backend *backend
 
 	pending int
+	// defragWrites tracks the keys written during an online defragmentation.
+	defragWrites *defragWrites
 }
 
 // Lock is supposed to be called only by the unit test.
@@ -121,6 +123,9 @@ func (t *batchTx) UnsafeCreateBucket(bucket Bucket) {
 			zap.Error(err),
 		)
 	}
+	if t.defragWrites != nil {
+		t.defragWrites.writeBucket(bucket.Name())
+	}
 	t.pending++
 }
 
@@ -133,6 +138,9 @@ func (t *batchTx) UnsafeDeleteBucket(bucket Bucket) {
 			zap.Error(err),
 		)
 	}
+	if t.defragWrites != nil {
+		t.defragWrites.writeBucket(bucket.Name())
+	}
 	t.pending++
 }
 
@@ -160,13 +168,16 @@ func (t *batchTx) unsafePut(bucketType Bucket, key []byte, value []byte, seq boo
 		// this can delay the page split and reduce space usage.
 		bucket.FillPercent = 0.9
 	}
-	if err := bucket.Put(key, value); err != nil {
+	if err := bucket.Put(key, t.backend.cipher.encrypt(bucketType, key, value)); err != nil {
 		t.backend.lg.Fatal(
 			"failed to write to a bucket",
 			zap.Stringer("bucket-name", bucketType),
 			zap.Error(err),
 		)
 	}
+	if t.defragWrites != nil {
+		t.defragWrites.writeKey(bucketType.Name(), key)
+	}
 	t.pending++
 }
 
@@ -180,7 +191,9 @@ func (t *batchTx) UnsafeRange(bucketType Bucket, key, endKey []byte, limit int64
 			zap.Stack("stack"),
 		)
 	}
-	return unsafeRange(bucket.Cursor(), key, endKey, limit)
+	keys, vals := unsafeRange(bucket.Cursor(), key, endKey, limit)
+	t.backend.cipher.decryptAll(bucketType, keys, vals)
+	return keys, vals
 }
 
 func unsafeRange(c *bolt.Cursor, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte) {
@@ -223,12 +236,15 @@ func (t *batchTx) UnsafeDelete(bucketType Bucket, key []byte) {
 			zap.Error(err),
 		)
 	}
+	if t.defragWrites != nil {
+		t.defragWrites.writeKey(bucketType.Name(), key)
+	}
 	t.pending++
 }
 
 // UnsafeForEach must be called holding the lock on the tx.
 func (t *batchTx) UnsafeForEach(bucket Bucket, visitor func(k, v []byte) error) error {
-	return unsafeForEach(t.tx, bucket, visitor)
+	return unsafeForEach(t.tx, bucket, t.backend.cipher.decryptVisitor(bucket, visitor))
 }
 
 func unsafeForEach(tx *bolt.Tx, bucket Bucket, visitor func(k, v []byte) error) error {
@@ -337,6 +353,10 @@ func (t *batchTxBuffered) commit(stop bool) {
 	t.backend.readTx.Lock()
 	t.unsafeCommit(stop)
 	t.backend.readTx.Unlock()
+
+	if h, ok := t.backend.hooks.(PostCommitHooks); ok {
+		h.OnPostCommitUnsafe(t)
+	}
 }
 
 func (t *batchTxBuffered) unsafeCommit(stop bool) {
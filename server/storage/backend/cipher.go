@@ -0,0 +1,85 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"go.uber.org/zap"
+)
+
+// ValueCipher encrypts the values of buckets when they are written to the
+// database file, and decrypts them when they are read from it. The values
+// buffered by the backend are kept unencrypted.
+type ValueCipher interface {
+	// Encrypts returns true if the values of the bucket are encrypted.
+	Encrypts(bucket []byte) bool
+	Encrypt(bucket, key, value []byte) ([]byte, error)
+	// Decrypt decrypts a value, which may have been written unencrypted.
+	Decrypt(bucket, key, value []byte) ([]byte, error)
+}
+
+// valueCipher wraps a ValueCipher, if any, failing on errors as the backend
+// does on database errors.
+type valueCipher struct {
+	c  ValueCipher
+	lg *zap.Logger
+}
+
+func (vc valueCipher) encrypt(bucket Bucket, key, value []byte) []byte {
+	if vc.c == nil || !vc.c.Encrypts(bucket.Name()) {
+		return value
+	}
+	v, err := vc.c.Encrypt(bucket.Name(), key, value)
+	if err != nil {
+		vc.lg.Fatal(
+			"failed to encrypt a value",
+			zap.Stringer("bucket-name", bucket),
+			zap.Error(err),
+		)
+	}
+	return v
+}
+
+func (vc valueCipher) decrypt(bucket, key, value []byte) []byte {
+	if vc.c == nil || !vc.c.Encrypts(bucket) {
+		return value
+	}
+	v, err := vc.c.Decrypt(bucket, key, value)
+	if err != nil {
+		vc.lg.Fatal(
+			"failed to decrypt a value",
+			zap.String("bucket-name", string(bucket)),
+			zap.Error(err),
+		)
+	}
+	return v
+}
+
+func (vc valueCipher) decryptAll(bucket Bucket, keys, vals [][]byte) {
+	if vc.c == nil || !vc.c.Encrypts(bucket.Name()) {
+		return
+	}
+	for i := range vals {
+		vals[i] = vc.decrypt(bucket.Name(), keys[i], vals[i])
+	}
+}
+
+func (vc valueCipher) decryptVisitor(bucket Bucket, visitor func(k, v []byte) error) func(k, v []byte) error {
+	if vc.c == nil || !vc.c.Encrypts(bucket.Name()) {
+		return visitor
+	}
+	return func(k, v []byte) error {
+		return visitor(k, vc.decrypt(bucket.Name(), k, v))
+	}
+}
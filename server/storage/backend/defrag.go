@@ -0,0 +1,158 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// defragCatchUpLimit is the number of keys written during the last round
+	// of an online defragmentation below which the writes are blocked to copy
+	// the remaining ones and switch to the defragmented file.
+	defragCatchUpLimit = 1000
+	// defragMaxCatchUps is the maximum number of rounds copying the keys
+	// written during an online defragmentation without blocking the writes.
+	defragMaxCatchUps = 8
+)
+
+// DefragProgress reports the progress of a defragmentation.
+type DefragProgress struct {
+	// TotalKeys is the number of keys in the backend when the defragmentation
+	// started.
+	TotalKeys int64
+	// CopiedKeys is the number of those keys copied to the defragmented file.
+	CopiedKeys int64
+	// RecopiedKeys is the number of keys written during the defragmentation,
+	// which are copied again once the others are.
+	RecopiedKeys int64
+}
+
+// defragWrites tracks the keys written to the backend while it is copied to a
+// defragmented file, so that they can be copied again.
+type defragWrites struct {
+	// buckets are the buckets created or deleted, which are copied entirely.
+	buckets map[string]struct{}
+	// keys are the keys put or deleted by bucket.
+	keys map[string]map[string]struct{}
+	n    int
+}
+
+func newDefragWrites() *defragWrites {
+	return &defragWrites{
+		buckets: make(map[string]struct{}),
+		keys:    make(map[string]map[string]struct{}),
+	}
+}
+
+func (w *defragWrites) writeBucket(bucket []byte) {
+	if _, ok := w.buckets[string(bucket)]; !ok {
+		w.buckets[string(bucket)] = struct{}{}
+		w.n++
+	}
+}
+
+func (w *defragWrites) writeKey(bucket, key []byte) {
+	keys, ok := w.keys[string(bucket)]
+	if !ok {
+		keys = make(map[string]struct{})
+		w.keys[string(bucket)] = keys
+	}
+	if _, ok = keys[string(key)]; !ok {
+		keys[string(key)] = struct{}{}
+		w.n++
+	}
+}
+
+// copy copies the written buckets and keys as found in tx to tmpdb, and
+// returns the number of keys copied.
+func (w *defragWrites) copy(tx *bolt.Tx, tmpdb *bolt.DB) (n int64, err error) {
+	if w.n == 0 {
+		return 0, nil
+	}
+	tmptx, err := tmpdb.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmptx.Rollback()
+		}
+	}()
+
+	for name := range w.buckets {
+		if err = tmptx.DeleteBucket([]byte(name)); err != nil && err != bolt.ErrBucketNotFound {
+			return 0, err
+		}
+		b := tx.Bucket([]byte(name))
+		if b == nil {
+			continue
+		}
+		tmpb, berr := tmptx.CreateBucket([]byte(name))
+		if berr != nil {
+			return 0, berr
+		}
+		if err = b.ForEach(func(k, v []byte) error {
+			n++
+			return tmpb.Put(k, v)
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	for name, keys := range w.keys {
+		if _, ok := w.buckets[name]; ok {
+			continue
+		}
+		b := tx.Bucket([]byte(name))
+		if b == nil {
+			continue
+		}
+		tmpb, berr := tmptx.CreateBucketIfNotExists([]byte(name))
+		if berr != nil {
+			return 0, berr
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		// writes in order fill the pages of the defragmented file as densely
+		// as the copied ones
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			n++
+			if v := b.Get([]byte(k)); v != nil {
+				err = tmpb.Put([]byte(k), v)
+			} else {
+				err = tmpb.Delete([]byte(k))
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, tmptx.Commit()
+}
+
+// countKeys returns the number of keys in the buckets of tx.
+func countKeys(tx *bolt.Tx) (n int64) {
+	tx.ForEach(func(_ []byte, b *bolt.Bucket) error {
+		n += int64(b.Stats().KeyN)
+		return nil
+	})
+	return n
+}
@@ -13,3 +13,7 @@ func DefragLimitForTest() int {
 func CommitsForTest(b Backend) int64 {
 	return b.(*backend).Commits()
 }
+
+func DefragCatchUpLimitForTest() int {
+	return defragCatchUpLimit
+}
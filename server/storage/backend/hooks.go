@@ -23,6 +23,15 @@ type Hooks interface {
 	OnPreCommitUnsafe(tx BatchTx)
 }
 
+// PostCommitHooks are Hooks which are also executed after the Commit of
+// transactions.
+type PostCommitHooks interface {
+	Hooks
+	// OnPostCommitUnsafe is executed after Commit of transactions.
+	// The given transaction is still locked.
+	OnPostCommitUnsafe(tx BatchTx)
+}
+
 type hooks struct {
 	onPreCommitUnsafe HookFunc
 }
@@ -90,6 +90,41 @@ var (
 		Name:      "defrag_inflight",
 		Help:      "Whether or not defrag is active on the member. 1 means active, 0 means not.",
 	})
+
+	freePages = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "backend_free_pages",
+		Help:      "The number of free pages of the backend, reused by the writes but only given back by defrag.",
+	})
+
+	pendingPages = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "backend_pending_pages",
+		Help:      "The number of backend pages freed by the transactions still read.",
+	})
+
+	freelistBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "backend_freelist_size_bytes",
+		Help:      "The size of the list of the free pages of the backend in bytes.",
+	})
+
+	bucketPages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "backend_bucket_pages",
+		Help:      "The number of pages of each backend bucket, as of the last page stats walk.",
+	}, []string{"bucket"})
+
+	bucketFillRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "backend_bucket_fill_ratio",
+		Help:      "The part of the pages of each backend bucket filled with its data, as of the last page stats walk.",
+	}, []string{"bucket"})
 )
 
 func init() {
@@ -100,4 +135,9 @@ func init() {
 	prometheus.MustRegister(defragSec)
 	prometheus.MustRegister(snapshotTransferSec)
 	prometheus.MustRegister(isDefragActive)
+	prometheus.MustRegister(freePages)
+	prometheus.MustRegister(pendingPages)
+	prometheus.MustRegister(freelistBytes)
+	prometheus.MustRegister(bucketPages)
+	prometheus.MustRegister(bucketFillRatio)
 }
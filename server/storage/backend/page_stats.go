@@ -0,0 +1,108 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// defragFillPercent is the fill percent of the buckets rewritten by defrag.
+const defragFillPercent = 0.9
+
+// PageStats describes how the pages of the backend file are used.
+type PageStats struct {
+	// PageSize is the size of a page in bytes.
+	PageSize int
+	// Size is the size of the backend file in bytes.
+	Size int64
+	// FreePages is the number of free pages, which the backend reuses for
+	// the new writes, but only defrag gives back to the file system.
+	FreePages int
+	// PendingPages is the number of pages freed by the transactions still
+	// read, to be free once they end.
+	PendingPages int
+	// FreelistBytes is the size of the list of the free pages.
+	FreelistBytes int
+	Buckets       []BucketPageStats
+}
+
+// BucketPageStats describes how the pages of a bucket are used.
+type BucketPageStats struct {
+	Name string
+	Keys int
+	// BranchPages and LeafPages are the number of pages of the bucket, their
+	// overflow pages included.
+	BranchPages int
+	LeafPages   int
+	// InuseBytes is the size of the data of the bucket, AllocBytes the size
+	// of its pages.
+	InuseBytes int
+	AllocBytes int
+}
+
+// FillRatio is the part of the pages of the bucket filled with its data.
+func (bs BucketPageStats) FillRatio() float64 {
+	if bs.AllocBytes == 0 {
+		return 0
+	}
+	return float64(bs.InuseBytes) / float64(bs.AllocBytes)
+}
+
+// DefragSize estimates the size of the backend file once defragmented, in
+// which the pages of the buckets are rewritten to be mostly filled, and the
+// free pages are dropped.
+func (ps PageStats) DefragSize() int64 {
+	// the two meta pages, the freelist and the root bucket
+	pages := int64(4)
+	for _, bs := range ps.Buckets {
+		pages += int64(float64(bs.InuseBytes)/(defragFillPercent*float64(ps.PageSize))) + 1
+	}
+	if size := pages * int64(ps.PageSize); size < ps.Size {
+		return size
+	}
+	return ps.Size
+}
+
+// PageStats walks the pages of the buckets of the backend to report how they
+// are used. It reads the whole backend.
+func (b *backend) PageStats() (PageStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var ps PageStats
+	err := b.db.View(func(tx *bolt.Tx) error {
+		stats := tx.DB().Stats()
+		ps.PageSize = tx.DB().Info().PageSize
+		ps.Size = tx.Size()
+		ps.FreePages = stats.FreePageN
+		ps.PendingPages = stats.PendingPageN
+		ps.FreelistBytes = stats.FreelistInuse
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			s := bucket.Stats()
+			bs := BucketPageStats{
+				Name:        string(name),
+				Keys:        s.KeyN,
+				BranchPages: s.BranchPageN + s.BranchOverflowN,
+				LeafPages:   s.LeafPageN + s.LeafOverflowN,
+				InuseBytes:  s.BranchInuse + s.LeafInuse,
+				AllocBytes:  s.BranchAlloc + s.LeafAlloc,
+			}
+			ps.Buckets = append(ps.Buckets, bs)
+			bucketPages.WithLabelValues(bs.Name).Set(float64(bs.BranchPages + bs.LeafPages))
+			bucketFillRatio.WithLabelValues(bs.Name).Set(bs.FillRatio())
+			return nil
+		})
+	})
+	return ps, err
+}
@@ -48,6 +48,8 @@ type baseReadTx struct {
 	buckets map[BucketID]*bolt.Bucket
 	// txWg protects tx from being rolled back at the end of a batch interval until all reads using this tx are done.
 	txWg *sync.WaitGroup
+	// cipher decrypts the values read from tx.
+	cipher valueCipher
 }
 
 func (baseReadTx *baseReadTx) UnsafeForEach(bucket Bucket, visitor func(k, v []byte) error) error {
@@ -66,7 +68,7 @@ func (baseReadTx *baseReadTx) UnsafeForEach(bucket Bucket, visitor func(k, v []b
 		return err
 	}
 	baseReadTx.txMu.Lock()
-	err := unsafeForEach(baseReadTx.tx, bucket, visitNoDup)
+	err := unsafeForEach(baseReadTx.tx, bucket, baseReadTx.cipher.decryptVisitor(bucket, visitNoDup))
 	baseReadTx.txMu.Unlock()
 	if err != nil {
 		return err
@@ -117,6 +119,7 @@ func (baseReadTx *baseReadTx) UnsafeRange(bucketType Bucket, key, endKey []byte,
 	baseReadTx.txMu.Unlock()
 
 	k2, v2 := unsafeRange(c, key, endKey, limit-int64(len(keys)))
+	baseReadTx.cipher.decryptAll(bucketType, k2, v2)
 	return append(k2, keys...), append(v2, vals...)
 }
 
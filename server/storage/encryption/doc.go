@@ -0,0 +1,23 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption implements the envelope encryption of the data etcd
+// stores on disk.
+//
+// The data is encrypted with data encryption keys (DEKs) kept in a Keyring.
+// The DEKs are only stored wrapped, i.e. encrypted, by the key encryption keys
+// (KEKs) of a KeyProvider, e.g. a KMS, next to the data they encrypt. Rotating
+// the KEKs only requires wrapping the DEKs again, not encrypting the data
+// again.
+package encryption
@@ -0,0 +1,263 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// keySize is the size of the AES-256 keys.
+const keySize = 32
+
+// header prefixes the encrypted data, followed by the id of the data
+// encryption key, the nonce and the ciphertext. Protocol buffers never start
+// with a zero byte, which tells the encrypted data apart from the data
+// written unencrypted.
+var header = []byte{0, 'e', 'n', 'c'}
+
+var ErrDataKeyNotFound = errors.New("encryption: data encryption key not found")
+
+// WrappedKey is a data encryption key wrapped by a key encryption key.
+type WrappedKey struct {
+	ID uint64 `json:"id"`
+	// KEKID is the id of the key encryption key wrapping Key.
+	KEKID string `json:"kek-id"`
+	Key   []byte `json:"key"`
+}
+
+func (k WrappedKey) Marshal() []byte {
+	b, _ := json.Marshal(k)
+	return b
+}
+
+func UnmarshalWrappedKey(b []byte) (k WrappedKey, err error) {
+	err = json.Unmarshal(b, &k)
+	return k, err
+}
+
+type dataKey struct {
+	wrapped WrappedKey
+	aead    cipher.AEAD
+}
+
+// Keyring encrypts data with the current one of its data encryption keys and
+// decrypts data encrypted with any of them.
+type Keyring struct {
+	p KeyProvider
+
+	mu      sync.RWMutex
+	keys    map[uint64]*dataKey
+	current *dataKey
+	// version changes with the wrapped keys.
+	version uint64
+}
+
+func NewKeyring(p KeyProvider) *Keyring {
+	return &Keyring{p: p, keys: make(map[uint64]*dataKey)}
+}
+
+// Add unwraps k and adds it to the keyring, if it was not added yet.
+func (kr *Keyring) Add(k WrappedKey) error {
+	kr.mu.RLock()
+	_, ok := kr.keys[k.ID]
+	kr.mu.RUnlock()
+	if ok {
+		return nil
+	}
+	dek, err := kr.p.Unwrap(k.KEKID, k.Key)
+	if err != nil {
+		return fmt.Errorf("encryption: cannot unwrap data encryption key %016x with key %q (%v)", k.ID, k.KEKID, err)
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return err
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok = kr.keys[k.ID]; !ok {
+		kr.keys[k.ID] = &dataKey{wrapped: k, aead: aead}
+		kr.version++
+	}
+	return nil
+}
+
+// SetCurrent makes the key id, which must have been added, the key
+// encrypting data.
+func (kr *Keyring) SetCurrent(id uint64) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	k, ok := kr.keys[id]
+	if !ok {
+		return ErrDataKeyNotFound
+	}
+	kr.current = k
+	return nil
+}
+
+// Rotate generates a new data encryption key, wrapped by the primary key
+// encryption key, to encrypt data from now on.
+func (kr *Keyring) Rotate() (WrappedKey, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return WrappedKey{}, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	wrapped, kekID, err := kr.p.Wrap(dek)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	var id [8]byte
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for {
+		if _, err = rand.Read(id[:]); err != nil {
+			return WrappedKey{}, err
+		}
+		if _, ok := kr.keys[binary.BigEndian.Uint64(id[:])]; !ok {
+			break
+		}
+	}
+	k := &dataKey{
+		wrapped: WrappedKey{ID: binary.BigEndian.Uint64(id[:]), KEKID: kekID, Key: wrapped},
+		aead:    aead,
+	}
+	kr.keys[k.wrapped.ID], kr.current = k, k
+	kr.version++
+	return k.wrapped, nil
+}
+
+// Rewrap wraps the keys not wrapped by the primary key encryption key again,
+// so that the other key encryption keys can be retired.
+func (kr *Keyring) Rewrap() error {
+	primary, err := kr.p.PrimaryKeyID()
+	if err != nil {
+		return err
+	}
+	kr.mu.RLock()
+	var stale []*dataKey
+	for _, k := range kr.keys {
+		if k.wrapped.KEKID != primary {
+			stale = append(stale, k)
+		}
+	}
+	kr.mu.RUnlock()
+	for _, k := range stale {
+		dek, err := kr.p.Unwrap(k.wrapped.KEKID, k.wrapped.Key)
+		if err != nil {
+			return fmt.Errorf("encryption: cannot unwrap data encryption key %016x with key %q (%v)", k.wrapped.ID, k.wrapped.KEKID, err)
+		}
+		wrapped, kekID, err := kr.p.Wrap(dek)
+		if err != nil {
+			return err
+		}
+		kr.mu.Lock()
+		k.wrapped = WrappedKey{ID: k.wrapped.ID, KEKID: kekID, Key: wrapped}
+		kr.version++
+		kr.mu.Unlock()
+	}
+	return nil
+}
+
+// NeedsRotation returns true if there is no current key, or if it is not
+// wrapped by the primary key encryption key.
+func (kr *Keyring) NeedsRotation() (bool, error) {
+	primary, err := kr.p.PrimaryKeyID()
+	if err != nil {
+		return false, err
+	}
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.current == nil || kr.current.wrapped.KEKID != primary, nil
+}
+
+// Current returns the key encrypting data, if any.
+func (kr *Keyring) Current() (WrappedKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	if kr.current == nil {
+		return WrappedKey{}, false
+	}
+	return kr.current.wrapped, true
+}
+
+// Keys returns the keys of the keyring, ordered by id, and its version.
+func (kr *Keyring) Keys() ([]WrappedKey, uint64) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	keys := make([]WrappedKey, 0, len(kr.keys))
+	for _, k := range kr.keys {
+		keys = append(keys, k.wrapped)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, kr.version
+}
+
+// Version changes whenever keys are added to the keyring or wrapped again.
+func (kr *Keyring) Version() uint64 {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.version
+}
+
+// Encrypt encrypts plaintext with the current key, authenticating aad with
+// it.
+func (kr *Keyring) Encrypt(plaintext, aad []byte) ([]byte, error) {
+	kr.mu.RLock()
+	k := kr.current
+	kr.mu.RUnlock()
+	if k == nil {
+		return nil, ErrDataKeyNotFound
+	}
+	b := make([]byte, len(header)+8, len(header)+8+k.aead.NonceSize()+len(plaintext)+k.aead.Overhead())
+	copy(b, header)
+	binary.BigEndian.PutUint64(b[len(header):], k.wrapped.ID)
+	nonce := b[len(b) : len(b)+k.aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	b = b[:len(b)+len(nonce)]
+	return k.aead.Seal(b, nonce, plaintext, aad), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt with the same aad.
+func (kr *Keyring) Decrypt(data, aad []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("encryption: data is not encrypted")
+	}
+	id := binary.BigEndian.Uint64(data[len(header):])
+	kr.mu.RLock()
+	k, ok := kr.keys[id]
+	kr.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %016x", ErrDataKeyNotFound, id)
+	}
+	return open(k.aead, data[len(header)+8:], aad)
+}
+
+// IsEncrypted returns true if data was encrypted by a Keyring.
+func IsEncrypted(data []byte) bool {
+	return len(data) >= len(header)+8 && bytes.Equal(data[:len(header)], header)
+}
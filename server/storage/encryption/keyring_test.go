@@ -0,0 +1,176 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFile writes a key file with the given key ids, the first being the
+// primary one, and bumps its modification time so that it is read again.
+func writeKeyFile(t *testing.T, path string, ids ...string) {
+	var b strings.Builder
+	b.WriteString("# key encryption keys\n")
+	for _, id := range ids {
+		key := bytes.Repeat([]byte(id[:1]), keySize)
+		fmt.Fprintf(&b, "%s:%s\n", id, base64.StdEncoding.EncodeToString(key))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0600))
+	mtime := time.Now().Add(time.Duration(len(ids)) * time.Second)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "a1")
+	p, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+
+	dek := bytes.Repeat([]byte{7}, keySize)
+	wrapped, kekID, err := p.Wrap(dek)
+	require.NoError(t, err)
+	assert.Equal(t, "a1", kekID)
+	assert.False(t, bytes.Contains(wrapped, dek))
+
+	writeKeyFile(t, path, "b2", "a1")
+	primary, err := p.PrimaryKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "b2", primary)
+	got, err := p.Unwrap("a1", wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dek, got)
+
+	_, err = p.Unwrap("b2", wrapped)
+	assert.Error(t, err, "a key wrapped by a1 must not unwrap with b2")
+
+	writeKeyFile(t, path, "b2")
+	_, err = p.Unwrap("a1", wrapped)
+	assert.True(t, errors.Is(err, ErrKeyEncryptionKeyNotFound), "unexpected error %v", err)
+}
+
+func TestFileKeyProviderInvalid(t *testing.T) {
+	tcs := map[string]string{
+		"no separator": "a1\n",
+		"bad encoding": "a1:!!!\n",
+		"short key":    "a1:" + base64.StdEncoding.EncodeToString([]byte("short")) + "\n",
+		"empty":        "# no keys\n",
+	}
+	for name, content := range tcs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+			_, err := NewFileKeyProvider(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyringEncrypt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "a1")
+	p, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+	kr := NewKeyring(p)
+
+	_, err = kr.Encrypt([]byte("secret"), nil)
+	assert.Equal(t, ErrDataKeyNotFound, err)
+
+	_, err = kr.Rotate()
+	require.NoError(t, err)
+	data, err := kr.Encrypt([]byte("secret"), []byte("aad"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(data))
+	assert.False(t, bytes.Contains(data, []byte("secret")))
+	assert.False(t, IsEncrypted([]byte("secret")))
+
+	got, err := kr.Decrypt(data, []byte("aad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), got)
+
+	_, err = kr.Decrypt(data, []byte("other"))
+	assert.Error(t, err, "data must not decrypt with another aad")
+
+	// the data encrypted with a previous key still decrypts after a rotation,
+	// including by a keyring loading the keys.
+	_, err = kr.Rotate()
+	require.NoError(t, err)
+	keys, _ := kr.Keys()
+	require.Len(t, keys, 2)
+	loaded := NewKeyring(p)
+	for _, k := range keys {
+		k, err = UnmarshalWrappedKey(k.Marshal())
+		require.NoError(t, err)
+		require.NoError(t, loaded.Add(k))
+	}
+	got, err = loaded.Decrypt(data, []byte("aad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), got)
+
+	_, err = NewKeyring(p).Decrypt(data, []byte("aad"))
+	assert.True(t, errors.Is(err, ErrDataKeyNotFound), "unexpected error %v", err)
+}
+
+func TestKeyringRewrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "a1")
+	p, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+	kr := NewKeyring(p)
+
+	rotate, err := kr.NeedsRotation()
+	require.NoError(t, err)
+	assert.True(t, rotate)
+	_, err = kr.Rotate()
+	require.NoError(t, err)
+	data, err := kr.Encrypt([]byte("secret"), nil)
+	require.NoError(t, err)
+	rotate, err = kr.NeedsRotation()
+	require.NoError(t, err)
+	assert.False(t, rotate)
+
+	writeKeyFile(t, path, "b2", "a1")
+	rotate, err = kr.NeedsRotation()
+	require.NoError(t, err)
+	assert.True(t, rotate)
+
+	version := kr.Version()
+	require.NoError(t, kr.Rewrap())
+	assert.NotEqual(t, version, kr.Version())
+	keys, _ := kr.Keys()
+	for _, k := range keys {
+		assert.Equal(t, "b2", k.KEKID)
+	}
+
+	// once rewrapped, the previous key encryption key can be retired.
+	writeKeyFile(t, path, "b2")
+	loaded := NewKeyring(p)
+	for _, k := range keys {
+		require.NoError(t, loaded.Add(k))
+	}
+	got, err := loaded.Decrypt(data, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), got)
+}
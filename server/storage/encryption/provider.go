@@ -0,0 +1,177 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeyProvider wraps data encryption keys with the key encryption keys it
+// manages.
+type KeyProvider interface {
+	// Wrap encrypts a data encryption key with the primary key encryption
+	// key and returns the id of that key.
+	Wrap(dek []byte) (wrapped []byte, kekID string, err error)
+	// Unwrap decrypts a data encryption key wrapped by the key encryption
+	// key kekID.
+	Unwrap(kekID string, wrapped []byte) ([]byte, error)
+	// PrimaryKeyID returns the id of the key encryption key Wrap uses.
+	PrimaryKeyID() (string, error)
+}
+
+var ErrKeyEncryptionKeyNotFound = errors.New("encryption: key encryption key not found")
+
+// fileKeyProvider wraps data encryption keys with AES-256-GCM using the keys
+// of a file.
+type fileKeyProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewFileKeyProvider returns a KeyProvider reading its key encryption keys
+// from the file at path, with one "<id>:<base64 encoded 32 bytes key>" line
+// per key. The first key is the primary one. The file is read again when it
+// is modified, so that a new primary key can be added online; the previous
+// keys must be kept until the data encryption keys are wrapped again.
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	p := &fileKeyProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *fileKeyProvider) reload() error {
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(p.modTime) {
+		return nil
+	}
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var primary string
+	keys := make(map[string]cipher.AEAD)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		i := bytes.IndexByte(line, ':')
+		if i <= 0 {
+			return fmt.Errorf("encryption: invalid key at line %d of %s, expected <id>:<base64 key>", n, p.path)
+		}
+		id := string(line[:i])
+		key, err := base64.StdEncoding.DecodeString(string(line[i+1:]))
+		if err != nil {
+			return fmt.Errorf("encryption: invalid key %q in %s (%v)", id, p.path, err)
+		}
+		if len(key) != keySize {
+			return fmt.Errorf("encryption: key %q in %s is %d bytes long, expected %d", id, p.path, len(key), keySize)
+		}
+		if _, ok := keys[id]; ok {
+			return fmt.Errorf("encryption: duplicate key %q in %s", id, p.path)
+		}
+		if keys[id], err = newAEAD(key); err != nil {
+			return err
+		}
+		if primary == "" {
+			primary = id
+		}
+	}
+	if err = s.Err(); err != nil {
+		return err
+	}
+	if primary == "" {
+		return fmt.Errorf("encryption: no key found in %s", p.path)
+	}
+	p.modTime, p.primary, p.keys = fi.ModTime(), primary, keys
+	return nil
+}
+
+func (p *fileKeyProvider) Wrap(dek []byte) ([]byte, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reload(); err != nil {
+		return nil, "", err
+	}
+	wrapped, err := seal(p.keys[p.primary], dek, []byte(p.primary))
+	return wrapped, p.primary, err
+}
+
+func (p *fileKeyProvider) Unwrap(kekID string, wrapped []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	aead, ok := p.keys[kekID]
+	if !ok {
+		return nil, ErrKeyEncryptionKeyNotFound
+	}
+	return open(aead, wrapped, []byte(kekID))
+}
+
+func (p *fileKeyProvider) PrimaryKeyID() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.reload(); err != nil {
+		return "", err
+	}
+	return p.primary, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce prepended to the result.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("encryption: ciphertext too short")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, ciphertext[:n], ciphertext[n:], aad)
+}
@@ -22,6 +22,7 @@ import (
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 )
 
@@ -35,6 +36,18 @@ type BackendHooks struct {
 	// not initialized `confState` is meaningless.
 	confStateDirty bool
 	confStateLock  sync.Mutex
+
+	// keyring, if set, encrypts the backend values. Its keys are written in
+	// the next submitted Backend transaction when they changed since
+	// keyringVersion.
+	keyring        *encryption.Keyring
+	keyringVersion uint64
+	keyringLock    sync.Mutex
+
+	// commitObserver, if set, is called before each commit of the Backend
+	// with the function to call once it is done.
+	commitObserver func() func()
+	commitDone     func()
 }
 
 func NewBackendHooks(lg *zap.Logger, indexer cindex.ConsistentIndexer) *BackendHooks {
@@ -42,6 +55,9 @@ func NewBackendHooks(lg *zap.Logger, indexer cindex.ConsistentIndexer) *BackendH
 }
 
 func (bh *BackendHooks) OnPreCommitUnsafe(tx backend.BatchTx) {
+	if bh.commitObserver != nil {
+		bh.commitDone = bh.commitObserver()
+	}
 	bh.indexer.UnsafeSave(tx)
 	bh.confStateLock.Lock()
 	defer bh.confStateLock.Unlock()
@@ -50,6 +66,29 @@ func (bh *BackendHooks) OnPreCommitUnsafe(tx backend.BatchTx) {
 		// save bh.confState
 		bh.confStateDirty = false
 	}
+	if bh.keyring != nil {
+		bh.keyringLock.Lock()
+		defer bh.keyringLock.Unlock()
+		if bh.keyring.Version() != bh.keyringVersion {
+			keys, version := bh.keyring.Keys()
+			schema.UnsafeSaveEncryptionKeys(tx, keys)
+			bh.keyringVersion = version
+		}
+	}
+}
+
+func (bh *BackendHooks) OnPostCommitUnsafe(tx backend.BatchTx) {
+	if bh.commitDone != nil {
+		bh.commitDone()
+		bh.commitDone = nil
+	}
+}
+
+// SetCommitObserver makes f observe the commits of the backends opened with
+// the hooks: f is called before each commit, and the function it returns
+// once the commit is done.
+func (bh *BackendHooks) SetCommitObserver(f func() func()) {
+	bh.commitObserver = f
 }
 
 func (bh *BackendHooks) SetConfState(confState *raftpb.ConfState) {
@@ -58,3 +97,47 @@ func (bh *BackendHooks) SetConfState(confState *raftpb.ConfState) {
 	bh.confState = *confState
 	bh.confStateDirty = true
 }
+
+// SetEncryptionKeyring makes the backends opened with the hooks encrypt their
+// values with kr.
+func (bh *BackendHooks) SetEncryptionKeyring(kr *encryption.Keyring) {
+	bh.keyring = kr
+}
+
+func (bh *BackendHooks) EncryptionKeyring() *encryption.Keyring {
+	return bh.keyring
+}
+
+// loadEncryptionKeys adds the keys of be to the keyring, and makes sure that
+// the keys of the keyring are written to be along with the first values they
+// encrypt.
+func (bh *BackendHooks) loadEncryptionKeys(be backend.Backend) error {
+	tx := be.ReadTx()
+	tx.RLock()
+	keys, err := schema.UnsafeReadEncryptionKeys(tx)
+	tx.RUnlock()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err = bh.keyring.Add(k); err != nil {
+			return err
+		}
+	}
+	rotate, err := bh.keyring.NeedsRotation()
+	if err != nil {
+		return err
+	}
+	if rotate {
+		if _, err = bh.keyring.Rotate(); err != nil {
+			return err
+		}
+		if err = bh.keyring.Rewrap(); err != nil {
+			return err
+		}
+	}
+	bh.keyringLock.Lock()
+	bh.keyringVersion = 0
+	bh.keyringLock.Unlock()
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"fmt"
+	"math"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// ForEachChange calls f with the events of the revisions greater than since,
+// and at most until unless until is zero, read from the key bucket of tx in
+// revision order, in batches of at most limit events. The events of a revision
+// may be split over batches. The transaction is only locked while a batch is
+// read, ErrCompacted is returned if the revisions left to read get compacted.
+func ForEachChange(tx backend.ReadTx, since, until int64, limit int64, f func(evs []mvccpb.Event) error) error {
+	if until == 0 {
+		until = math.MaxInt64 - 1
+	}
+	min, max := newRevBytes(), newRevBytes()
+	revToBytes(revision{main: since + 1}, min)
+	revToBytes(revision{main: until + 1}, max)
+	for {
+		tx.RLock()
+		if compacted, _ := UnsafeReadScheduledCompact(tx); compacted >= bytesToRev(min).main {
+			tx.RUnlock()
+			return ErrCompacted
+		}
+		revs, vals := tx.UnsafeRange(schema.Key, min, max, limit)
+		evs := make([]mvccpb.Event, len(revs))
+		var err error
+		for i := range revs {
+			var kv mvccpb.KeyValue
+			// unmarshal copies the values, which must not be used once unlocked
+			if err = kv.Unmarshal(vals[i]); err != nil {
+				break
+			}
+			evs[i] = mvccpb.Event{Type: mvccpb.PUT, Kv: &kv}
+			if isTombstone(revs[i]) {
+				evs[i].Type = mvccpb.DELETE
+				kv.ModRevision = bytesToRev(revs[i]).main
+			}
+		}
+		if len(revs) > 0 {
+			last := bytesToRev(revs[len(revs)-1])
+			revToBytes(revision{main: last.main, sub: last.sub + 1}, min)
+		}
+		tx.RUnlock()
+		if err != nil {
+			return err
+		}
+		if len(evs) == 0 {
+			return nil
+		}
+		if err = f(evs); err != nil {
+			return err
+		}
+		if limit <= 0 || int64(len(evs)) < limit {
+			return nil
+		}
+	}
+}
+
+// ChangeWriter writes the events read by ForEachChange into the key bucket of
+// a backend at their revisions, e.g. to layer the changes on a snapshot of the
+// store taken at an earlier revision. The backend must be restored by a store
+// to be used.
+type ChangeWriter struct {
+	tx backend.BatchTx
+	// last is the revision of the last event written.
+	last revision
+}
+
+func NewChangeWriter(tx backend.BatchTx) *ChangeWriter {
+	return &ChangeWriter{tx: tx}
+}
+
+// Write writes evs, which must follow the events written so far in
+// revision order.
+func (w *ChangeWriter) Write(evs []mvccpb.Event) error {
+	w.tx.LockOutsideApply()
+	defer w.tx.Unlock()
+	for _, ev := range evs {
+		if ev.Kv == nil {
+			return fmt.Errorf("mvcc: event without key-value")
+		}
+		rev := revision{main: ev.Kv.ModRevision}
+		switch {
+		case rev.main < w.last.main:
+			return fmt.Errorf("mvcc: event at revision %d follows revision %d", rev.main, w.last.main)
+		case rev.main == w.last.main:
+			rev.sub = w.last.sub + 1
+		}
+		w.last = rev
+
+		ibytes := newRevBytes()
+		revToBytes(rev, ibytes)
+		kv := ev.Kv
+		if ev.Type == mvccpb.DELETE {
+			ibytes = append(ibytes, markTombstone)
+			kv = &mvccpb.KeyValue{Key: ev.Kv.Key}
+		}
+		d, err := kv.Marshal()
+		if err != nil {
+			return err
+		}
+		w.tx.UnsafeSeqPut(schema.Key, ibytes, d)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestChangesLayeredOnSnapshot(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer s.Close()
+
+	s.Put([]byte("a"), []byte("a"), lease.NoLease)
+	s.Put([]byte("b"), []byte("b"), lease.NoLease)
+	txn := s.Write(traceutil.TODO())
+	txn.Put([]byte("c"), []byte("c"), lease.NoLease)
+	txn.DeleteRange([]byte("a"), nil)
+	txn.End()
+
+	// the base store is at revision 2, after the first put
+	bb, _ := betesting.NewDefaultTmpBackend(t)
+	base := NewStore(zaptest.NewLogger(t), bb, &lease.FakeLessor{}, StoreConfig{})
+	base.Put([]byte("a"), []byte("a"), lease.NoLease)
+	base.Close()
+
+	w := NewChangeWriter(bb.BatchTx())
+	var batches int
+	if err := ForEachChange(b.ReadTx(), 2, 0, 2, func(evs []mvccpb.Event) error {
+		batches++
+		return w.Write(evs)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if batches != 2 {
+		t.Errorf("expected the 3 changes in 2 batches, got %d", batches)
+	}
+	bb.ForceCommit()
+
+	layered := NewStore(zaptest.NewLogger(t), bb, &lease.FakeLessor{}, StoreConfig{})
+	defer layered.Close()
+	for _, rev := range []int64{2, 3, 4} {
+		want, err := s.Range(context.TODO(), []byte("a"), []byte("d"), RangeOptions{Rev: rev})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := layered.Range(context.TODO(), []byte("a"), []byte("d"), RangeOptions{Rev: rev})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.KVs, want.KVs) || got.Rev != want.Rev {
+			t.Errorf("at revision %d expected %+v, got %+v", rev, want, got)
+		}
+	}
+
+	// the changes since a compacted revision are not available
+	if _, err := s.Compact(traceutil.TODO(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := ForEachChange(b.ReadTx(), 2, 0, 0, func([]mvccpb.Event) error { return nil }); err != ErrCompacted {
+		t.Errorf("expected %v, got %v", ErrCompacted, err)
+	}
+}
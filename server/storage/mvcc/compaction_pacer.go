@@ -0,0 +1,103 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import "time"
+
+var (
+	// minCompactionBatchLimit is the smallest batch the pacer shrinks the
+	// compaction batches to.
+	minCompactionBatchLimit = 10
+	// maxCompactionSleepInterval is the longest pause the pacer waits between
+	// the compaction batches, unless the configured one is longer.
+	maxCompactionSleepInterval = time.Second
+)
+
+// compactionPacer adapts the number of revisions deleted in each compaction
+// batch and the pause between the batches to the latency of their commits.
+// A commit slower than the target halves the batch and doubles the pause,
+// so that the reads and writes blocked by the commits catch up; a commit
+// well under the target grows them back towards the configured ones. With
+// no target, the batches keep the configured size and pause.
+type compactionPacer struct {
+	target time.Duration
+
+	maxBatch    int
+	minBatch    int
+	minInterval time.Duration
+	maxInterval time.Duration
+
+	batch    int
+	interval time.Duration
+}
+
+func newCompactionPacer(cfg StoreConfig) *compactionPacer {
+	p := &compactionPacer{
+		target:      cfg.CompactionTargetCommitLatency,
+		maxBatch:    cfg.CompactionBatchLimit,
+		minBatch:    minCompactionBatchLimit,
+		minInterval: cfg.CompactionSleepInterval,
+		maxInterval: maxCompactionSleepInterval,
+		batch:       cfg.CompactionBatchLimit,
+		interval:    cfg.CompactionSleepInterval,
+	}
+	if p.minBatch > p.maxBatch {
+		p.minBatch = p.maxBatch
+	}
+	if p.maxInterval < p.minInterval {
+		p.maxInterval = p.minInterval
+	}
+	p.report()
+	return p
+}
+
+// observe adapts the pace of the next batches to the time the commit of the
+// last batch took.
+func (p *compactionPacer) observe(commit time.Duration) {
+	if p.target <= 0 {
+		return
+	}
+	switch {
+	case commit > p.target:
+		p.batch /= 2
+		if p.batch < p.minBatch {
+			p.batch = p.minBatch
+		}
+		p.interval *= 2
+		if p.interval < commit {
+			// give the requests at least the time they waited for the commit
+			p.interval = commit
+		}
+		if p.interval > p.maxInterval {
+			p.interval = p.maxInterval
+		}
+		dbCompactionPacedBatchesCounter.Inc()
+	case commit < p.target/2:
+		p.batch += p.batch/4 + 1
+		if p.batch > p.maxBatch {
+			p.batch = p.maxBatch
+		}
+		p.interval /= 2
+		if p.interval < p.minInterval {
+			p.interval = p.minInterval
+		}
+	}
+	p.report()
+}
+
+func (p *compactionPacer) report() {
+	dbCompactionBatchSize.Set(float64(p.batch))
+	dbCompactionSleepMs.Set(float64(p.interval / time.Millisecond))
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompactionPacer(t *testing.T) {
+	p := newCompactionPacer(StoreConfig{
+		CompactionBatchLimit:          1000,
+		CompactionSleepInterval:       10 * time.Millisecond,
+		CompactionTargetCommitLatency: 100 * time.Millisecond,
+	})
+
+	tests := []struct {
+		commit    time.Duration
+		wbatch    int
+		winterval time.Duration
+	}{
+		// commits within the target keep the configured pace
+		{80 * time.Millisecond, 1000, 10 * time.Millisecond},
+		{10 * time.Millisecond, 1000, 10 * time.Millisecond},
+		// slow commits shrink the batches and pause longer
+		{200 * time.Millisecond, 500, 200 * time.Millisecond},
+		{150 * time.Millisecond, 250, 400 * time.Millisecond},
+		{150 * time.Millisecond, 125, 800 * time.Millisecond},
+		{150 * time.Millisecond, 62, time.Second},
+		{150 * time.Millisecond, 31, time.Second},
+		{150 * time.Millisecond, 15, time.Second},
+		{150 * time.Millisecond, 10, time.Second},
+		{5 * time.Second, 10, time.Second},
+		// commits between half the target and the target keep the pace
+		{70 * time.Millisecond, 10, time.Second},
+		// fast commits grow the batches back
+		{10 * time.Millisecond, 13, 500 * time.Millisecond},
+		{10 * time.Millisecond, 17, 250 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		p.observe(tt.commit)
+		if p.batch != tt.wbatch || p.interval != tt.winterval {
+			t.Fatalf("#%d: batch, interval = %d, %v, want %d, %v", i, p.batch, p.interval, tt.wbatch, tt.winterval)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		p.observe(time.Millisecond)
+	}
+	if p.batch != 1000 || p.interval != 10*time.Millisecond {
+		t.Errorf("batch, interval = %d, %v, want the configured 1000, 10ms", p.batch, p.interval)
+	}
+}
+
+func TestCompactionPacerNoTarget(t *testing.T) {
+	p := newCompactionPacer(StoreConfig{CompactionBatchLimit: 1000, CompactionSleepInterval: 10 * time.Millisecond})
+	p.observe(5 * time.Second)
+	if p.batch != 1000 || p.interval != 10*time.Millisecond {
+		t.Errorf("batch, interval = %d, %v, want the configured 1000, 10ms", p.batch, p.interval)
+	}
+}
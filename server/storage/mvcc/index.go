@@ -17,6 +17,7 @@ package mvcc
 import (
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/btree"
 	"go.uber.org/zap"
@@ -30,7 +31,7 @@ type index interface {
 	Put(key []byte, rev revision)
 	Tombstone(key []byte, rev revision) error
 	RangeSince(key, end []byte, rev int64) []revision
-	Compact(rev int64) map[revision]struct{}
+	Compact(rev int64, retentions ...Retention) map[revision]struct{}
 	Keep(rev int64) map[revision]struct{}
 	Equal(b index) bool
 
@@ -209,29 +210,68 @@ func (ti *treeIndex) RangeSince(key, end []byte, rev int64) []revision {
 	return revs
 }
 
-func (ti *treeIndex) Compact(rev int64) map[revision]struct{} {
+// compactIndexBatchLimit bounds the number of keys compacted while the
+// index is locked. Non-const for testing.
+var compactIndexBatchLimit = 1000
+
+// compactIndexBatchHook is called between the batches of a compaction, while
+// the index is unlocked. Only set in tests.
+var compactIndexBatchHook func()
+
+// Compact compacts the index to rev, keeping the revisions retained by the
+// retentions, whose CompactRevision it raises to the lowest revision their
+// keys keep their history from.
+func (ti *treeIndex) Compact(rev int64, retentions ...Retention) map[revision]struct{} {
 	available := make(map[revision]struct{})
 	ti.lg.Info("compact tree index", zap.Int64("revision", rev))
 	ti.Lock()
 	clone := ti.tree.Clone()
 	ti.Unlock()
 
+	// Walk the clone in batches and only lock the index while compacting a
+	// batch, so that writers applying new entries are never blocked for
+	// longer than one batch, however large the index is.
+	batch := make([]*keyIndex, 0, compactIndexBatchLimit)
 	clone.Ascend(func(item btree.Item) bool {
-		keyi := item.(*keyIndex)
-		// Lock is needed here to prevent modification to the keyIndex while
-		// compaction is going on or revision added to empty before deletion
-		ti.Lock()
-		keyi.compact(ti.lg, rev, available)
+		batch = append(batch, item.(*keyIndex))
+		if len(batch) == compactIndexBatchLimit {
+			ti.compactBatch(rev, retentions, batch, available)
+			batch = batch[:0]
+			if compactIndexBatchHook != nil {
+				compactIndexBatchHook()
+			}
+		}
+		return true
+	})
+	if len(batch) > 0 {
+		ti.compactBatch(rev, retentions, batch, available)
+	}
+	return available
+}
+
+func (ti *treeIndex) compactBatch(rev int64, retentions []Retention, batch []*keyIndex, available map[revision]struct{}) {
+	start := time.Now()
+	// Lock is needed here to prevent modification to the keyIndex while
+	// compaction is going on or revision added to empty before deletion
+	ti.Lock()
+	defer func() {
+		ti.Unlock()
+		indexCompactionPauseMs.Observe(float64(time.Since(start) / time.Millisecond))
+	}()
+	for _, keyi := range batch {
+		crev := retainedCompactRev(keyi, rev, retentions)
+		raiseCompactRevs(keyi, crev, retentions)
+		keyi.compact(ti.lg, crev, available)
+		if crev < rev {
+			retainRevisions(keyi, rev, available)
+		}
 		if keyi.isEmpty() {
 			item := ti.tree.Delete(keyi)
 			if item == nil {
 				ti.lg.Panic("failed to delete during compaction")
 			}
 		}
-		ti.Unlock()
-		return true
-	})
-	return available
+	}
 }
 
 // Keep finds all revisions to be kept for a Compaction at the given rev.
@@ -15,7 +15,9 @@
 package mvcc
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/google/btree"
@@ -139,6 +141,45 @@ func TestIndexTombstone(t *testing.T) {
 	}
 }
 
+func TestIndexCompactRetentions(t *testing.T) {
+	ti := newTreeIndex(zaptest.NewLogger(t))
+	ti.Put([]byte("foo"), revision{main: 1})
+	ti.Put([]byte("foo1"), revision{main: 2})
+	ti.Put([]byte("foo"), revision{main: 3})
+	ti.Tombstone([]byte("foo1"), revision{main: 4})
+	ti.Put([]byte("foo"), revision{main: 5})
+	ti.Tombstone([]byte("foo"), revision{main: 6})
+	ti.Put([]byte("foo"), revision{main: 7})
+	ti.Put([]byte("fop"), revision{main: 8})
+	ti.Put([]byte("foo2"), revision{main: 9})
+	ti.Put([]byte("foo2"), revision{main: 10})
+	ti.Put([]byte("foo"), revision{main: 11})
+	ti.Put([]byte("fop"), revision{main: 12})
+	ti.Put([]byte("fop"), revision{main: 13})
+
+	am := ti.Compact(12,
+		Retention{Prefix: []byte("foo"), Versions: 2},
+		Retention{Prefix: []byte("fop"), Revision: 5},
+	)
+	// the current generation of foo and foo2 within 2 versions, and fop from
+	// revision 5, but not the deleted foo1
+	wam := map[revision]struct{}{
+		{main: 7}: {}, {main: 11}: {}, {main: 9}: {}, {main: 10}: {}, {main: 8}: {}, {main: 12}: {},
+	}
+	if !reflect.DeepEqual(am, wam) {
+		t.Errorf("available = %v, want %v", am, wam)
+	}
+	if rev, _, _, err := ti.Get([]byte("foo"), 8); err != nil || rev.main != 7 {
+		t.Errorf("get foo at 8 = %v, %v, want 7", rev, err)
+	}
+	if _, _, _, err := ti.Get([]byte("foo"), 6); err != ErrRevisionNotFound {
+		t.Errorf("get foo at 6 error = %v, want %v", err, ErrRevisionNotFound)
+	}
+	if _, _, _, err := ti.Get([]byte("foo1"), 3); err != ErrRevisionNotFound {
+		t.Errorf("get foo1 at 3 error = %v, want %v", err, ErrRevisionNotFound)
+	}
+}
+
 func TestIndexRangeSince(t *testing.T) {
 	allKeys := [][]byte{[]byte("foo"), []byte("foo1"), []byte("foo2"), []byte("foo2"), []byte("foo1"), []byte("foo")}
 	allRevs := []revision{{main: 1}, {main: 2}, {main: 3}, {main: 4}, {main: 5}, {main: 6}}
@@ -277,6 +318,105 @@ func TestIndexCompactAndKeep(t *testing.T) {
 	}
 }
 
+func TestIndexCompactBatches(t *testing.T) {
+	defer func(limit int) { compactIndexBatchLimit = limit }(compactIndexBatchLimit)
+	compactIndexBatchLimit = 3
+
+	ti := newTreeIndex(zaptest.NewLogger(t))
+	for i := 0; i < 10; i++ {
+		key := []byte(fmt.Sprintf("foo%d", i))
+		ti.Put(key, revision{main: int64(2*i + 1)})
+		if i%2 == 0 {
+			ti.Tombstone(key, revision{main: int64(2*i + 2)})
+		} else {
+			ti.Put(key, revision{main: int64(2*i + 2)})
+		}
+	}
+
+	// writers keep going while the index is compacted
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		for i := 0; i < 100; i++ {
+			ti.Put([]byte(fmt.Sprintf("bar%d", i)), revision{main: int64(100 + i)})
+		}
+	}()
+	am := ti.Compact(20)
+	<-donec
+
+	if keep := ti.Keep(20); !reflect.DeepEqual(am, keep) {
+		t.Errorf("compact keep %v != Keep keep %v", am, keep)
+	}
+	for i := 0; i < 10; i++ {
+		key := []byte(fmt.Sprintf("foo%d", i))
+		_, _, _, err := ti.Get(key, 30)
+		if i%2 == 0 && err != ErrRevisionNotFound {
+			t.Errorf("expected tombstoned %s to be compacted away, got %v", key, err)
+		}
+		if i%2 == 1 {
+			if err != nil {
+				t.Errorf("could not get %s: %v", key, err)
+			}
+			if _, ok := am[revision{main: int64(2*i + 2)}]; !ok {
+				t.Errorf("expected latest revision of %s to be kept", key)
+			}
+		}
+	}
+	if keys, _ := ti.Range([]byte("bar"), []byte("bas"), 300); len(keys) != 100 {
+		t.Errorf("expected 100 keys written during compaction, got %d", len(keys))
+	}
+}
+
+// TestIndexCompactDoesNotBlockPut ensures that a put is applied between the
+// batches of a compaction, rather than once the whole index is compacted.
+func TestIndexCompactDoesNotBlockPut(t *testing.T) {
+	defer func(limit int) { compactIndexBatchLimit = limit }(compactIndexBatchLimit)
+	compactIndexBatchLimit = 10
+
+	const keys = 100
+	ti := newTreeIndex(zaptest.NewLogger(t)).(*treeIndex)
+	for i := 0; i < keys; i++ {
+		key := []byte(fmt.Sprintf("foo%03d", i))
+		ti.Put(key, revision{main: int64(2*i + 1)})
+		ti.Tombstone(key, revision{main: int64(2*i + 2)})
+	}
+
+	// pause the compaction after its first batch
+	batched, resume := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	compactIndexBatchHook = func() {
+		once.Do(func() {
+			close(batched)
+			<-resume
+		})
+	}
+	defer func() { compactIndexBatchHook = nil }()
+
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		ti.Compact(2 * keys)
+	}()
+	<-batched
+
+	ti.RLock()
+	n := ti.tree.Len()
+	ti.RUnlock()
+	if n != keys-compactIndexBatchLimit {
+		t.Errorf("index has %d keys after the first batch, want %d", n, keys-compactIndexBatchLimit)
+	}
+	ti.Put([]byte("bar"), revision{main: 2*keys + 1})
+	close(resume)
+	<-donec
+
+	if _, _, _, err := ti.Get([]byte("bar"), 2*keys+1); err != nil {
+		t.Errorf("could not get the key put during compaction: %v", err)
+	}
+	if n := ti.tree.Len(); n != 1 {
+		t.Errorf("index has %d keys after compaction, want 1", n)
+	}
+}
+
 func restore(ti *treeIndex, key []byte, created, modified revision, ver int64) {
 	keyi := &keyIndex{key: key}
 
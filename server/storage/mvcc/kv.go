@@ -16,6 +16,7 @@ package mvcc
 
 import (
 	"context"
+	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
@@ -128,9 +129,20 @@ type KV interface {
 	// Compact frees all superseded keys with revisions less than rev.
 	Compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, error)
 
+	// LastCompaction returns the revision of the last finished compaction and how long it took.
+	LastCompaction() (rev int64, took time.Duration)
+
+	// CompactWithRetentions compacts like Compact, keeping the history of the
+	// keys retained by the retentions.
+	CompactWithRetentions(trace *traceutil.Trace, rev int64, retentions []Retention) (<-chan struct{}, error)
+
 	// Commit commits outstanding txns into the underlying backend.
 	Commit()
 
+	// Import creates an Importer writing keys at revision rev, which must be
+	// greater than the current revision, or at the next revision if rev is zero.
+	Import(rev int64) (Importer, error)
+
 	// Restore restores the KV store from a backend.
 	Restore(b backend.Backend) error
 	Close() error
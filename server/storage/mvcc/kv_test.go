@@ -601,6 +601,67 @@ func TestKVCompactReserveLastValue(t *testing.T) {
 	}
 }
 
+func TestKVCompactWithRetentions(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+
+	for i := 0; i < 3; i++ {
+		s.Put([]byte("/config/a"), []byte(fmt.Sprint(i)), lease.NoLease)
+		s.Put([]byte("/events/a"), []byte(fmt.Sprint(i)), lease.NoLease)
+	}
+	// /config/a is at 2, 4 and 6, /events/a at 3, 5 and 7, /jobs/a is put at 8
+	// and deleted at 9
+	s.Put([]byte("/jobs/a"), []byte("0"), lease.NoLease)
+	s.DeleteRange([]byte("/jobs/a"), nil)
+	s.Put([]byte("/events/a"), []byte("3"), lease.NoLease)
+	retentions := []Retention{{Prefix: []byte("/config/"), Versions: 2}, {Prefix: []byte("/jobs/"), Versions: 2}}
+	ch, err := s.CompactWithRetentions(traceutil.TODO(), 10, retentions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	// a plain compaction keeps the retentions
+	s.Put([]byte("/events/a"), []byte("4"), lease.NoLease)
+	if ch, err = s.Compact(traceutil.TODO(), 11); err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+
+	check := func(s *store) {
+		r, err := s.Range(context.TODO(), []byte("/config/"), []byte("/config0"), RangeOptions{Rev: 5})
+		if err != nil {
+			t.Fatalf("range of the retained keys error = %v", err)
+		}
+		if len(r.KVs) != 1 || string(r.KVs[0].Value) != "1" {
+			t.Errorf("range of the retained keys at 5 = %+v, want the value 1", r.KVs)
+		}
+		// the version before the last 2 ones is compacted
+		if _, err = s.Range(context.TODO(), []byte("/config/a"), nil, RangeOptions{Rev: 3}); err != ErrCompacted {
+			t.Errorf("range of the compacted version error = %v, want %v", err, ErrCompacted)
+		}
+		// the deleted generation is compacted
+		if _, err = s.Range(context.TODO(), []byte("/jobs/"), []byte("/jobs0"), RangeOptions{Rev: 8}); err != ErrCompacted {
+			t.Errorf("range of the deleted generation error = %v, want %v", err, ErrCompacted)
+		}
+		if r, err = s.Range(context.TODO(), []byte("/jobs/"), []byte("/jobs0"), RangeOptions{Rev: 9}); err != nil || len(r.KVs) != 0 {
+			t.Errorf("range after the deletion = %+v, %v, want none", r.KVs, err)
+		}
+		if _, err = s.Range(context.TODO(), []byte("/events/a"), nil, RangeOptions{Rev: 5}); err != ErrCompacted {
+			t.Errorf("range of the keys not retained error = %v, want %v", err, ErrCompacted)
+		}
+		if _, err = s.Range(context.TODO(), []byte("/"), []byte("0"), RangeOptions{Rev: 5}); err != ErrCompacted {
+			t.Errorf("range over the retained keys error = %v, want %v", err, ErrCompacted)
+		}
+	}
+	check(s)
+	s.Close()
+
+	// the retentions survive restarts
+	ns := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(ns, b, tmpPath)
+	check(ns)
+}
+
 func TestKVCompactBad(t *testing.T) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
 	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
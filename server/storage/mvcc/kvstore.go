@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"math"
+	"runtime"
 	"sync"
 	"time"
 
@@ -48,12 +49,26 @@ const (
 )
 
 var restoreChunkKeys = 10000 // non-const for testing
+// restoreWorkers is the number of goroutines decoding the revisions and
+// rebuilding the index on restore.
+var restoreWorkers = runtime.GOMAXPROCS(0) // non-const for testing
 var defaultCompactBatchLimit = 1000
 var minimumBatchInterval = 10 * time.Millisecond
 
 type StoreConfig struct {
 	CompactionBatchLimit    int
 	CompactionSleepInterval time.Duration
+	// CompactionTargetCommitLatency, if set, paces the compaction batches so
+	// that their commits take no longer than it, shrinking the batches and
+	// pausing longer between them when their commits are slower.
+	CompactionTargetCommitLatency time.Duration
+	// OnWrite, if set, is called with the events of each write txn of the
+	// watchable store, before the txn ends.
+	OnWrite func(evs []mvccpb.Event)
+	// OnWriteEnd, if set, is called with the revision of each write txn of
+	// the watchable store OnWrite is called for, once the txn ended and its
+	// writes are visible to the read txns.
+	OnWriteEnd func(rev int64)
 }
 
 type store struct {
@@ -78,8 +93,26 @@ type store struct {
 	currentRev int64
 	// compactMainRev is the main revision of the last compaction.
 	compactMainRev int64
+	// retentions are the histories of keys kept by the last compaction, kept
+	// by the next ones unless replaced.
+	retentions []Retention
+
+	// retainedMu protects retained and retainedRev.
+	retainedMu sync.RWMutex
+	// retained are the retentions of the last compaction of the index, at
+	// retainedRev, with the revisions their keys are readable from before
+	// compactMainRev. None are readable while the index is being compacted.
+	retained    []Retention
+	retainedRev int64
+	// lastCompactRev and lastCompactTook describe the last finished
+	// compaction, they are protected by revMu.
+	lastCompactRev  int64
+	lastCompactTook time.Duration
 
 	fifoSched schedule.Scheduler
+	// pacer paces the batches of the compactions, which are run one at a
+	// time by fifoSched.
+	pacer *compactionPacer
 
 	stopc chan struct{}
 
@@ -109,6 +142,7 @@ func NewStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg StoreConfi
 		compactMainRev: -1,
 
 		fifoSched: schedule.NewFIFOScheduler(),
+		pacer:     newCompactionPacer(cfg),
 
 		stopc: make(chan struct{}),
 
@@ -220,7 +254,7 @@ func (s *store) HashByRev(rev int64) (hash uint32, currentRev int64, compactRev
 	return hash, currentRev, compactRev, err
 }
 
-func (s *store) updateCompactRev(rev int64) (<-chan struct{}, error) {
+func (s *store) updateCompactRev(rev int64, retentions []Retention) (<-chan struct{}, error) {
 	s.revMu.Lock()
 	if rev <= s.compactMainRev {
 		ch := make(chan struct{})
@@ -236,7 +270,14 @@ func (s *store) updateCompactRev(rev int64) (<-chan struct{}, error) {
 
 	s.compactMainRev = rev
 
-	SetScheduledCompact(s.b.BatchTx(), rev)
+	tx := s.b.BatchTx()
+	tx.LockInsideApply()
+	UnsafeSetScheduledCompact(tx, rev)
+	if len(retentions) > 0 || len(s.retentions) > 0 {
+		UnsafeSetCompactRetentions(tx, retentions)
+	}
+	tx.Unlock()
+	s.retentions = retentions
 	// ensure that desired compaction is persisted
 	s.b.ForceCommit()
 
@@ -245,7 +286,7 @@ func (s *store) updateCompactRev(rev int64) (<-chan struct{}, error) {
 	return nil, nil
 }
 
-func (s *store) compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, error) {
+func (s *store) compact(trace *traceutil.Trace, rev int64, retentions []Retention) (<-chan struct{}, error) {
 	ch := make(chan struct{})
 	var j = func(ctx context.Context) {
 		if ctx.Err() != nil {
@@ -253,12 +294,26 @@ func (s *store) compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, err
 			return
 		}
 		start := time.Now()
-		keep := s.kvindex.Compact(rev)
-		indexCompactionPauseMs.Observe(float64(time.Since(start) / time.Millisecond))
-		if !s.scheduleCompaction(rev, keep) {
+		// the compactions run one at a time, after the previous one
+		s.retainedMu.RLock()
+		prev, prevRev := s.retained, s.retainedRev
+		s.retainedMu.RUnlock()
+		retained := withCompactRevs(retentions, prev, prevRev)
+		keep := s.kvindex.Compact(rev, retained...)
+		s.retainedMu.Lock()
+		s.retained, s.retainedRev = retained, rev
+		s.retainedMu.Unlock()
+		// the retentions are only saved if there are or were some
+		if len(retained) == 0 && len(prev) == 0 {
+			retained = nil
+		}
+		if !s.scheduleCompaction(rev, keep, retained) {
 			s.compactBarrier(context.TODO(), ch)
 			return
 		}
+		s.revMu.Lock()
+		s.lastCompactRev, s.lastCompactTook = rev, time.Since(start)
+		s.revMu.Unlock()
 		close(ch)
 	}
 
@@ -267,19 +322,26 @@ func (s *store) compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, err
 	return ch, nil
 }
 
-func (s *store) compactLockfree(rev int64) (<-chan struct{}, error) {
-	ch, err := s.updateCompactRev(rev)
+func (s *store) compactLockfree(rev int64, retentions []Retention) (<-chan struct{}, error) {
+	ch, err := s.updateCompactRev(rev, retentions)
 	if err != nil {
 		return ch, err
 	}
 
-	return s.compact(traceutil.TODO(), rev)
+	return s.compact(traceutil.TODO(), rev, retentions)
 }
 
 func (s *store) Compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, error) {
+	s.revMu.RLock()
+	retentions := s.retentions
+	s.revMu.RUnlock()
+	return s.CompactWithRetentions(trace, rev, retentions)
+}
+
+func (s *store) CompactWithRetentions(trace *traceutil.Trace, rev int64, retentions []Retention) (<-chan struct{}, error) {
 	s.mu.Lock()
 
-	ch, err := s.updateCompactRev(rev)
+	ch, err := s.updateCompactRev(rev, retentions)
 	trace.Step("check and update compact revision")
 	if err != nil {
 		s.mu.Unlock()
@@ -287,7 +349,21 @@ func (s *store) Compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, err
 	}
 	s.mu.Unlock()
 
-	return s.compact(trace, rev)
+	return s.compact(trace, rev, retentions)
+}
+
+// retainsRange tells if the keys of [key, end) keep their history at rev,
+// below the compaction revision.
+func (s *store) retainsRange(key, end []byte, rev int64) bool {
+	s.retainedMu.RLock()
+	defer s.retainedMu.RUnlock()
+	return s.retainedRev == s.compactMainRev && retainsRange(s.retained, key, end, rev)
+}
+
+func (s *store) LastCompaction() (rev int64, took time.Duration) {
+	s.revMu.RLock()
+	defer s.revMu.RUnlock()
+	return s.lastCompactRev, s.lastCompactTook
 }
 
 func (s *store) Commit() {
@@ -346,17 +422,36 @@ func (s *store) restore() error {
 		s.revMu.Unlock()
 	}
 	scheduledCompact, _ := UnsafeReadScheduledCompact(tx)
+	retentions, err := UnsafeReadCompactRetentions(tx)
+	if err != nil {
+		tx.Unlock()
+		return err
+	}
+	retained, err := UnsafeReadFinishedCompactRetentions(tx)
+	if err != nil {
+		tx.Unlock()
+		return err
+	}
+	s.revMu.Lock()
+	s.retentions = retentions
+	s.revMu.Unlock()
+	s.retainedMu.Lock()
+	s.retained, s.retainedRev = retained, finishedCompact
+	s.retainedMu.Unlock()
 	// index keys concurrently as they're loaded in from tx
 	keysGauge.Set(0)
-	rkvc, revc := restoreIntoIndex(s.lg, s.kvindex)
+	start := time.Now()
+	ir := newIndexRestorer(s.lg, s.kvindex, restoreWorkers)
+	currentRev := int64(1)
 	for {
 		keys, vals := tx.UnsafeRange(schema.Key, min, max, int64(restoreChunkKeys))
 		if len(keys) == 0 {
 			break
 		}
-		// rkvc blocks if the total pending keys exceeds the restore
-		// chunk size to keep keys from consuming too much memory.
-		restoreChunk(s.lg, rkvc, keys, vals, keyToLease)
+		// the restorer blocks if its workers are behind by a chunk, to keep
+		// keys from consuming too much memory.
+		ir.restoreChunk(keys, vals, keyToLease)
+		currentRev = bytesToRev(keys[len(keys)-1]).main
 		if len(keys) < restoreChunkKeys {
 			// partial set implies final set
 			break
@@ -366,11 +461,11 @@ func (s *store) restore() error {
 		newMin.sub++
 		revToBytes(newMin, min)
 	}
-	close(rkvc)
+	ir.wait()
 
 	{
 		s.revMu.Lock()
-		s.currentRev = <-revc
+		s.currentRev = currentRev
 
 		// keys in the range [compacted revision -N, compaction] might all be deleted due to compaction.
 		// the correct revision should be set to compaction revision in the case, not the largest revision
@@ -402,10 +497,15 @@ func (s *store) restore() error {
 
 	tx.Unlock()
 
-	s.lg.Info("kvstore restored", zap.Int64("current-rev", s.currentRev))
+	s.lg.Info(
+		"kvstore restored",
+		zap.Int64("current-rev", s.currentRev),
+		zap.Int("workers", len(ir.shards)),
+		zap.Duration("took", time.Since(start)),
+	)
 
 	if scheduledCompact != 0 {
-		if _, err := s.compactLockfree(scheduledCompact); err != nil {
+		if _, err := s.compactLockfree(scheduledCompact, retentions); err != nil {
 			s.lg.Warn("compaction encountered error", zap.Error(err))
 		}
 
@@ -424,17 +524,44 @@ type revKeyValue struct {
 	kstr string
 }
 
-func restoreIntoIndex(lg *zap.Logger, idx index) (chan<- revKeyValue, <-chan int64) {
-	rkvc, revc := make(chan revKeyValue, restoreChunkKeys), make(chan int64, 1)
-	go func() {
-		currentRev := int64(1)
-		defer func() { revc <- currentRev }()
-		// restore the tree index from streaming the unordered index.
-		kiCache := make(map[string]*keyIndex, restoreChunkKeys)
-		for rkv := range rkvc {
+// indexRestorer rebuilds the index from the revisions of the backend across
+// workers. The revisions of a chunk are decoded in parallel, then each key is
+// restored by the worker of its shard, which receives its revisions in order.
+type indexRestorer struct {
+	lg     *zap.Logger
+	idx    index
+	shards []chan []revKeyValue
+	wg     sync.WaitGroup
+}
+
+func newIndexRestorer(lg *zap.Logger, idx index, workers int) *indexRestorer {
+	if workers < 1 {
+		workers = 1
+	}
+	r := &indexRestorer{lg: lg, idx: idx, shards: make([]chan []revKeyValue, workers)}
+	for i := range r.shards {
+		c := make(chan []revKeyValue, 1)
+		r.shards[i] = c
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.restoreShard(c)
+		}()
+	}
+	return r
+}
+
+// restoreShard restores the tree index from the revisions of the keys of a
+// shard, streamed in revision order.
+func (r *indexRestorer) restoreShard(c <-chan []revKeyValue) {
+	cacheSize := restoreChunkKeys/len(r.shards) + 1
+	kiCache := make(map[string]*keyIndex, cacheSize)
+	for rkvs := range c {
+		for i := range rkvs {
+			rkv := &rkvs[i]
 			ki, ok := kiCache[rkv.kstr]
 			// purge kiCache if many keys but still missing in the cache
-			if !ok && len(kiCache) >= restoreChunkKeys {
+			if !ok && len(kiCache) >= cacheSize {
 				i := 10
 				for k := range kiCache {
 					delete(kiCache, k)
@@ -446,47 +573,90 @@ func restoreIntoIndex(lg *zap.Logger, idx index) (chan<- revKeyValue, <-chan int
 			// cache miss, fetch from tree index if there
 			if !ok {
 				ki = &keyIndex{key: rkv.kv.Key}
-				if idxKey := idx.KeyIndex(ki); idxKey != nil {
+				if idxKey := r.idx.KeyIndex(ki); idxKey != nil {
 					kiCache[rkv.kstr], ki = idxKey, idxKey
 					ok = true
 				}
 			}
 			rev := bytesToRev(rkv.key)
-			currentRev = rev.main
 			if ok {
 				if isTombstone(rkv.key) {
-					if err := ki.tombstone(lg, rev.main, rev.sub); err != nil {
-						lg.Warn("tombstone encountered error", zap.Error(err))
+					if err := ki.tombstone(r.lg, rev.main, rev.sub); err != nil {
+						r.lg.Warn("tombstone encountered error", zap.Error(err))
 					}
 					continue
 				}
-				ki.put(lg, rev.main, rev.sub)
+				ki.put(r.lg, rev.main, rev.sub)
 			} else if !isTombstone(rkv.key) {
-				ki.restore(lg, revision{rkv.kv.CreateRevision, 0}, rev, rkv.kv.Version)
-				idx.Insert(ki)
+				ki.restore(r.lg, revision{rkv.kv.CreateRevision, 0}, rev, rkv.kv.Version)
+				r.idx.Insert(ki)
 				kiCache[rkv.kstr] = ki
 			}
 		}
-	}()
-	return rkvc, revc
+	}
 }
 
-func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, keyToLease map[string]lease.LeaseID) {
-	for i, key := range keys {
-		rkv := revKeyValue{key: key}
-		if err := rkv.kv.Unmarshal(vals[i]); err != nil {
-			lg.Fatal("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
+// restoreChunk decodes the revisions of a chunk and hands them to the workers
+// of their keys. The leases of the keys are tracked in keyToLease.
+func (r *indexRestorer) restoreChunk(keys, vals [][]byte, keyToLease map[string]lease.LeaseID) {
+	rkvs := make([]revKeyValue, len(keys))
+	var wg sync.WaitGroup
+	n := (len(keys) + len(r.shards) - 1) / len(r.shards)
+	for start := 0; start < len(keys); start += n {
+		end := start + n
+		if end > len(keys) {
+			end = len(keys)
 		}
-		rkv.kstr = string(rkv.kv.Key)
-		if isTombstone(key) {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				rkvs[i].key = keys[i]
+				if err := rkvs[i].kv.Unmarshal(vals[i]); err != nil {
+					r.lg.Fatal("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
+				}
+				rkvs[i].kstr = string(rkvs[i].kv.Key)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	batches := make([][]revKeyValue, len(r.shards))
+	for i := range rkvs {
+		rkv := &rkvs[i]
+		if isTombstone(rkv.key) {
 			delete(keyToLease, rkv.kstr)
 		} else if lid := lease.LeaseID(rkv.kv.Lease); lid != lease.NoLease {
 			keyToLease[rkv.kstr] = lid
 		} else {
 			delete(keyToLease, rkv.kstr)
 		}
-		kvc <- rkv
+		shard := restoreShardOf(rkv.kstr, len(r.shards))
+		batches[shard] = append(batches[shard], *rkv)
+	}
+	for i, batch := range batches {
+		if len(batch) > 0 {
+			r.shards[i] <- batch
+		}
+	}
+}
+
+// wait waits for the workers to restore the revisions of all the chunks.
+func (r *indexRestorer) wait() {
+	for _, c := range r.shards {
+		close(c)
+	}
+	r.wg.Wait()
+}
+
+// restoreShardOf returns the shard of key among n, by its FNV-1a hash.
+func restoreShardOf(key string, n int) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
 	}
+	return int(h % uint32(n))
 }
 
 func (s *store) Close() error {
@@ -22,7 +22,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struct{}) bool {
+func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struct{}, retained []Retention) bool {
 	totalStart := time.Now()
 	defer func() { dbCompactionTotalMs.Observe(float64(time.Since(totalStart) / time.Millisecond)) }()
 	keyCompactions := 0
@@ -32,14 +32,12 @@ func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struc
 	end := make([]byte, 8)
 	binary.BigEndian.PutUint64(end, uint64(compactMainRev+1))
 
-	batchNum := s.cfg.CompactionBatchLimit
-	batchInterval := s.cfg.CompactionSleepInterval
-
 	last := make([]byte, 8+1+8)
 	for {
 		var rev revision
 
 		start := time.Now()
+		batchNum := s.pacer.batch
 
 		tx := s.b.BatchTx()
 		tx.LockOutsideApply()
@@ -54,6 +52,9 @@ func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struc
 
 		if len(keys) < batchNum {
 			UnsafeSetFinishedCompact(tx, compactMainRev)
+			if retained != nil {
+				UnsafeSetFinishedCompactRetentions(tx, retained)
+			}
 			tx.Unlock()
 			s.lg.Info(
 				"finished scheduled compaction",
@@ -67,11 +68,13 @@ func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struc
 		// update last
 		revToBytes(revision{main: rev.main, sub: rev.sub + 1}, last)
 		// Immediately commit the compaction deletes instead of letting them accumulate in the write buffer
+		commitStart := time.Now()
 		s.b.ForceCommit()
+		s.pacer.observe(time.Since(commitStart))
 		dbCompactionPauseMs.Observe(float64(time.Since(start) / time.Millisecond))
 
 		select {
-		case <-time.After(batchInterval):
+		case <-time.After(s.pacer.interval):
 		case <-s.stopc:
 			return false
 		}
@@ -0,0 +1,154 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStoreImport(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer func() { s.Close() }()
+
+	s.Put([]byte("a"), []byte("old"), lease.NoLease)
+	if _, err := s.Import(2); err != ErrImportRevision {
+		t.Fatalf("expected %v importing at the current revision, got %v", ErrImportRevision, err)
+	}
+
+	im, err := s.Import(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = im.Import([]mvccpb.KeyValue{{Key: []byte("a"), Value: []byte("new")}, {Key: []byte("b"), Value: []byte("b")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = im.Import([]mvccpb.KeyValue{{Key: []byte("b"), Value: []byte("b")}}); err != ErrImportKeyOrder {
+		t.Fatalf("expected %v importing unsorted keys, got %v", ErrImportKeyOrder, err)
+	}
+	if err = im.Import([]mvccpb.KeyValue{{Key: []byte("c"), Value: []byte("c")}}); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := s.Range(context.TODO(), []byte("b"), nil, RangeOptions{}); len(r.KVs) != 0 {
+		t.Errorf("expected imported keys to be hidden until the importer is closed, got %+v", r.KVs)
+	}
+	count, err := im.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 imported keys, got %d", count)
+	}
+
+	r, err := s.Range(context.TODO(), []byte("a"), []byte("d"), RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wkvs := []mvccpb.KeyValue{
+		{Key: []byte("a"), Value: []byte("new"), CreateRevision: 2, ModRevision: 10, Version: 2},
+		{Key: []byte("b"), Value: []byte("b"), CreateRevision: 10, ModRevision: 10, Version: 1},
+		{Key: []byte("c"), Value: []byte("c"), CreateRevision: 10, ModRevision: 10, Version: 1},
+	}
+	if r.Rev != 10 || !reflect.DeepEqual(r.KVs, wkvs) {
+		t.Errorf("expected %+v at revision 10, got %+v at revision %d", wkvs, r.KVs, r.Rev)
+	}
+	if rev := s.Put([]byte("a"), []byte("newer"), lease.NoLease); rev != 11 {
+		t.Errorf("expected put after the import at revision 11, got %d", rev)
+	}
+
+	// the imported keys are found in the backend after a restart
+	s.Close()
+	s = NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	if r, err = s.Range(context.TODO(), []byte("b"), nil, RangeOptions{}); err != nil || len(r.KVs) != 1 || r.Rev != 11 {
+		t.Errorf("expected imported key at revision 11 after restart, got %+v at revision %d (%v)", r.KVs, r.Rev, err)
+	}
+}
+
+func TestStoreImportAbort(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer s.Close()
+
+	s.Put([]byte("a"), []byte("old"), lease.NoLease)
+	im, err := s.Import(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = im.Import([]mvccpb.KeyValue{{Key: []byte("a"), Value: []byte("new")}, {Key: []byte("b"), Value: []byte("b")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = im.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.Range(context.TODO(), []byte("a"), []byte("c"), RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.KVs) != 1 || string(r.KVs[0].Value) != "old" || r.Rev != 2 {
+		t.Errorf("expected only the key put before the import at revision 2, got %+v at revision %d", r.KVs, r.Rev)
+	}
+	// the aborted revision is free to be written again
+	if rev := s.Put([]byte("a"), []byte("newer"), lease.NoLease); rev != 3 {
+		t.Errorf("expected put after the aborted import at revision 3, got %d", rev)
+	}
+}
+
+func TestWatchableStoreImport(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer s.Close()
+
+	w := s.NewWatchStream()
+	defer w.Close()
+	w.Watch(0, []byte("a"), []byte("z"), 0)
+
+	im, err := s.Import(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = im.Import([]mvccpb.KeyValue{{Key: []byte("a"), Value: []byte("a")}, {Key: []byte("b"), Value: []byte("b")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = im.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for len(keys) < 2 {
+		select {
+		case resp := <-w.Chan():
+			for _, ev := range resp.Events {
+				if ev.Type != mvccpb.PUT || ev.Kv.ModRevision != 5 {
+					t.Errorf("expected put event at revision 5, got %+v", ev)
+				}
+				keys = append(keys, string(ev.Kv.Key))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected events of the imported keys, got %v", keys)
+		}
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("expected events of keys a and b, got %v", keys)
+	}
+}
@@ -392,6 +392,8 @@ func TestStoreRestore(t *testing.T) {
 	}
 	b.tx.rangeRespc <- rangeResp{[][]byte{schema.FinishedCompactKeyName}, [][]byte{newTestRevBytes(revision{3, 0})}}
 	b.tx.rangeRespc <- rangeResp{[][]byte{schema.ScheduledCompactKeyName}, [][]byte{newTestRevBytes(revision{3, 0})}}
+	b.tx.rangeRespc <- rangeResp{nil, nil}
+	b.tx.rangeRespc <- rangeResp{nil, nil}
 
 	b.tx.rangeRespc <- rangeResp{[][]byte{putkey, delkey}, [][]byte{putkvb, delkvb}}
 	b.tx.rangeRespc <- rangeResp{nil, nil}
@@ -407,6 +409,8 @@ func TestStoreRestore(t *testing.T) {
 	wact := []testutil.Action{
 		{Name: "range", Params: []interface{}{schema.Meta, schema.FinishedCompactKeyName, []byte(nil), int64(0)}},
 		{Name: "range", Params: []interface{}{schema.Meta, schema.ScheduledCompactKeyName, []byte(nil), int64(0)}},
+		{Name: "range", Params: []interface{}{schema.Meta, schema.CompactRetentionsKeyName, []byte(nil), int64(0)}},
+		{Name: "range", Params: []interface{}{schema.Meta, schema.FinishedCompactRetentionsKeyName, []byte(nil), int64(0)}},
 		{Name: "range", Params: []interface{}{schema.Key, newTestRevBytes(revision{1, 0}), newTestRevBytes(revision{math.MaxInt64, math.MaxInt64}), int64(restoreChunkKeys)}},
 	}
 	if g := b.tx.Action(); !reflect.DeepEqual(g, wact) {
@@ -476,6 +480,40 @@ func TestRestoreDelete(t *testing.T) {
 	}
 }
 
+func TestRestoreWorkers(t *testing.T) {
+	oldChunk, oldWorkers := restoreChunkKeys, restoreWorkers
+	restoreChunkKeys = 7
+	defer func() { restoreChunkKeys, restoreWorkers = oldChunk, oldWorkers }()
+
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	for i := 0; i < 200; i++ {
+		k := []byte(fmt.Sprintf("foo-%d", mrand.Intn(30)))
+		if mrand.Intn(4) == 0 {
+			s.DeleteRange(k, nil)
+			continue
+		}
+		s.Put(k, []byte("bar"), lease.NoLease)
+	}
+	rev := s.Rev()
+	s.Close()
+
+	restoreWorkers = 1
+	s1 := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer s1.Close()
+	for _, workers := range []int{2, 4, 16} {
+		restoreWorkers = workers
+		s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+		if s.Rev() != rev {
+			t.Errorf("workers %d: rev = %d, want %d", workers, s.Rev(), rev)
+		}
+		if !s.kvindex.Equal(s1.kvindex) {
+			t.Errorf("workers %d: index differs from the one restored by a single worker", workers)
+		}
+		s.Close()
+	}
+}
+
 func TestRestoreContinueUnfinishedCompaction(t *testing.T) {
 	tests := []string{"recreate", "restore"}
 	for _, test := range tests {
@@ -848,7 +886,7 @@ func newTestKeyBytes(lg *zap.Logger, rev revision, tombstone bool) []byte {
 func newFakeStore(lg *zap.Logger) *store {
 	b := &fakeBackend{&fakeBatchTx{
 		Recorder:   &testutil.RecorderBuffered{},
-		rangeRespc: make(chan rangeResp, 5)}}
+		rangeRespc: make(chan rangeResp, 6)}}
 	fi := &fakeIndex{
 		Recorder:              &testutil.RecorderBuffered{},
 		indexGetRespc:         make(chan indexGetResp, 1),
@@ -864,6 +902,7 @@ func newFakeStore(lg *zap.Logger) *store {
 		currentRev:     0,
 		compactMainRev: -1,
 		fifoSched:      schedule.NewFIFOScheduler(),
+		pacer:          newCompactionPacer(StoreConfig{CompactionBatchLimit: 10000}),
 		stopc:          make(chan struct{}),
 		lg:             lg,
 	}
@@ -913,18 +952,22 @@ type fakeBackend struct {
 	tx *fakeBatchTx
 }
 
-func (b *fakeBackend) BatchTx() backend.BatchTx                                   { return b.tx }
-func (b *fakeBackend) ReadTx() backend.ReadTx                                     { return b.tx }
-func (b *fakeBackend) ConcurrentReadTx() backend.ReadTx                           { return b.tx }
-func (b *fakeBackend) Hash(func(bucketName, keyName []byte) bool) (uint32, error) { return 0, nil }
-func (b *fakeBackend) Size() int64                                                { return 0 }
-func (b *fakeBackend) SizeInUse() int64                                           { return 0 }
-func (b *fakeBackend) OpenReadTxN() int64                                         { return 0 }
-func (b *fakeBackend) Snapshot() backend.Snapshot                                 { return nil }
-func (b *fakeBackend) ForceCommit()                                               {}
-func (b *fakeBackend) Defrag() error                                              { return nil }
-func (b *fakeBackend) Close() error                                               { return nil }
-func (b *fakeBackend) SetTxPostLockInsideApplyHook(func())                        {}
+func (b *fakeBackend) BatchTx() backend.BatchTx                                    { return b.tx }
+func (b *fakeBackend) ReadTx() backend.ReadTx                                      { return b.tx }
+func (b *fakeBackend) ConcurrentReadTx() backend.ReadTx                            { return b.tx }
+func (b *fakeBackend) Hash(func(bucketName, keyName []byte) bool) (uint32, error)  { return 0, nil }
+func (b *fakeBackend) Size() int64                                                 { return 0 }
+func (b *fakeBackend) SizeInUse() int64                                            { return 0 }
+func (b *fakeBackend) OpenReadTxN() int64                                          { return 0 }
+func (b *fakeBackend) Snapshot() backend.Snapshot                                  { return nil }
+func (b *fakeBackend) ForceCommit()                                                {}
+func (b *fakeBackend) Defrag() error                                               { return nil }
+func (b *fakeBackend) DefragWithProgress(func(backend.DefragProgress)) error       { return nil }
+func (b *fakeBackend) IsDefragActive() bool                                        { return false }
+func (b *fakeBackend) PageStats() (backend.PageStats, error)                       { return backend.PageStats{}, nil }
+func (b *fakeBackend) SnapshotWithout(func([]byte) bool) (backend.Snapshot, error) { return nil, nil }
+func (b *fakeBackend) Close() error                                                { return nil }
+func (b *fakeBackend) SetTxPostLockInsideApplyHook(func())                         {}
 
 type indexGetResp struct {
 	rev     revision
@@ -985,7 +1028,7 @@ func (i *fakeIndex) RangeSince(key, end []byte, rev int64) []revision {
 	r := <-i.indexRangeEventsRespc
 	return r.revs
 }
-func (i *fakeIndex) Compact(rev int64) map[revision]struct{} {
+func (i *fakeIndex) Compact(rev int64, retentions ...Retention) map[revision]struct{} {
 	i.Recorder.Record(testutil.Action{Name: "compact", Params: []interface{}{rev}})
 	return <-i.indexCompactRespc
 }
@@ -153,6 +153,30 @@ var (
 			Help:      "Total number of db keys compacted.",
 		})
 
+	dbCompactionBatchSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_batch_size",
+			Help:      "The number of revisions the next db compaction batch deletes at most, as paced by the commit latency.",
+		})
+
+	dbCompactionSleepMs = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_sleep_duration_milliseconds",
+			Help:      "The pause between the db compaction batches, as paced by the commit latency.",
+		})
+
+	dbCompactionPacedBatchesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_paced_batches_total",
+			Help:      "Total number of db compaction batches whose commit exceeded the target latency, slowing down the compaction.",
+		})
+
 	dbTotalSize = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 		Namespace: "etcd",
 		Subsystem: "mvcc",
@@ -283,6 +307,9 @@ func init() {
 	prometheus.MustRegister(dbCompactionTotalMs)
 	prometheus.MustRegister(dbCompactionLast)
 	prometheus.MustRegister(dbCompactionKeysCounter)
+	prometheus.MustRegister(dbCompactionBatchSize)
+	prometheus.MustRegister(dbCompactionSleepMs)
+	prometheus.MustRegister(dbCompactionPacedBatchesCounter)
 	prometheus.MustRegister(dbTotalSize)
 	prometheus.MustRegister(dbTotalSizeInUse)
 	prometheus.MustRegister(dbOpenReadTxN)
@@ -0,0 +1,227 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// Retention keeps through a compaction the history of the current generation
+// of the keys of a prefix: the last Versions revisions of each key and its
+// revisions from Revision on. The revisions of the keys before the compaction
+// revision stay readable, for ranges within the prefix, from the lowest
+// revision all the keys of the prefix keep their history from.
+type Retention struct {
+	// Prefix is the prefix of the keys whose history is kept. An empty prefix
+	// matches all keys.
+	Prefix []byte `json:"prefix"`
+	// Versions is the number of the last versions of each key kept.
+	Versions int64 `json:"versions,omitempty"`
+	// Revision is the first revision of the keys kept, none if zero.
+	Revision int64 `json:"revision,omitempty"`
+	// CompactRevision is the lowest revision the keys of the prefix keep
+	// their history from, set by the compaction of the index.
+	CompactRevision int64 `json:"compactRevision,omitempty"`
+}
+
+// compactRev returns the revision to compact ki to, no higher than atRev,
+// for it to keep the revisions retained by r.
+func (r Retention) compactRev(ki *keyIndex, atRev int64) int64 {
+	g := ki.generations[len(ki.generations)-1]
+	if g.isEmpty() {
+		return atRev
+	}
+	keep := len(g.revs)
+	if r.Versions > 0 {
+		if keep -= int(r.Versions); keep < 0 {
+			keep = 0
+		}
+	}
+	if r.Revision > 0 {
+		for i := 0; i < keep; i++ {
+			if g.revs[i].main >= r.Revision {
+				keep = i
+				break
+			}
+		}
+	}
+	// compacting to a revision keeps the last revision up to it
+	if keep < len(g.revs) && g.revs[keep].main < atRev {
+		return g.revs[keep].main
+	}
+	return atRev
+}
+
+// retainedCompactRev returns the revision to compact ki to, no higher than
+// atRev, for it to keep the revisions retained by the retentions.
+func retainedCompactRev(ki *keyIndex, atRev int64, retentions []Retention) int64 {
+	rev := atRev
+	for _, r := range retentions {
+		if !bytes.HasPrefix(ki.key, r.Prefix) {
+			continue
+		}
+		if crev := r.compactRev(ki, atRev); crev < rev {
+			rev = crev
+		}
+	}
+	return rev
+}
+
+// compactedRev returns the lowest revision ki can still be read at once
+// compacted to crev, zero if the compaction removes none of its revisions.
+func compactedRev(ki *keyIndex, crev int64) int64 {
+	var kept int64
+	n := 0
+	for _, g := range ki.generations {
+		for _, r := range g.revs {
+			if r.main > crev {
+				break
+			}
+			kept = r.main
+			n++
+		}
+	}
+	// compacting to crev removes the revisions before the last one up to it
+	if n < 2 {
+		return 0
+	}
+	return kept
+}
+
+// raiseCompactRevs raises the CompactRevision of the retentions of ki to the
+// revision ki can still be read at once compacted to crev.
+func raiseCompactRevs(ki *keyIndex, crev int64, retentions []Retention) {
+	rev := compactedRev(ki, crev)
+	if rev == 0 {
+		return
+	}
+	for i := range retentions {
+		if bytes.HasPrefix(ki.key, retentions[i].Prefix) && retentions[i].CompactRevision < rev {
+			retentions[i].CompactRevision = rev
+		}
+	}
+}
+
+// withCompactRevs returns a copy of the retentions with the revisions their
+// keys keep their history from before being compacted, given the retentions
+// of the previous compaction at prevRev: those of the same or an enclosing
+// prefix, prevRev otherwise.
+func withCompactRevs(retentions, prev []Retention, prevRev int64) []Retention {
+	if prevRev < 0 {
+		prevRev = 0
+	}
+	rets := make([]Retention, len(retentions))
+	for i, r := range retentions {
+		r.CompactRevision = prevRev
+		for _, p := range prev {
+			if bytes.HasPrefix(r.Prefix, p.Prefix) && p.CompactRevision < r.CompactRevision {
+				r.CompactRevision = p.CompactRevision
+			}
+		}
+		rets[i] = r
+	}
+	return rets
+}
+
+// retainRevisions adds the revisions of ki up to rev to available, for the
+// compaction to keep those retained.
+func retainRevisions(ki *keyIndex, rev int64, available map[revision]struct{}) {
+	for _, g := range ki.generations {
+		for _, r := range g.revs {
+			if r.main <= rev {
+				available[r] = struct{}{}
+			}
+		}
+	}
+}
+
+// retainsRange tells if the keys of [key, end) all keep their history at rev
+// through one of the retentions. A nil end means the single key, an empty one
+// all the keys from key.
+func retainsRange(retentions []Retention, key, end []byte, rev int64) bool {
+	for _, r := range retentions {
+		if rev < r.CompactRevision {
+			continue
+		}
+		if len(r.Prefix) == 0 {
+			return true
+		}
+		if !bytes.HasPrefix(key, r.Prefix) {
+			continue
+		}
+		if end == nil {
+			return true
+		}
+		if pend := prefixEnd(r.Prefix); len(end) > 0 && (pend == nil || bytes.Compare(end, pend) <= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixEnd returns the end of the range of the keys with prefix, nil if they
+// are all the keys from prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+func UnsafeReadCompactRetentions(tx backend.ReadTx) ([]Retention, error) {
+	return unsafeReadRetentions(tx, schema.CompactRetentionsKeyName)
+}
+
+func UnsafeSetCompactRetentions(tx backend.BatchTx, retentions []Retention) {
+	unsafeSetRetentions(tx, schema.CompactRetentionsKeyName, retentions)
+}
+
+func UnsafeReadFinishedCompactRetentions(tx backend.ReadTx) ([]Retention, error) {
+	return unsafeReadRetentions(tx, schema.FinishedCompactRetentionsKeyName)
+}
+
+func UnsafeSetFinishedCompactRetentions(tx backend.BatchTx, retentions []Retention) {
+	unsafeSetRetentions(tx, schema.FinishedCompactRetentionsKeyName, retentions)
+}
+
+func unsafeReadRetentions(tx backend.ReadTx, key []byte) ([]Retention, error) {
+	_, vs := tx.UnsafeRange(schema.Meta, key, nil, 0)
+	if len(vs) == 0 {
+		return nil, nil
+	}
+	var retentions []Retention
+	err := json.Unmarshal(vs[0], &retentions)
+	return retentions, err
+}
+
+func unsafeSetRetentions(tx backend.BatchTx, key []byte, retentions []Retention) {
+	if len(retentions) == 0 {
+		tx.UnsafeDelete(schema.Meta, key)
+		return
+	}
+	b, err := json.Marshal(retentions)
+	if err != nil {
+		panic(err)
+	}
+	tx.UnsafePut(schema.Meta, key, b)
+}
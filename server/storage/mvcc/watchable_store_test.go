@@ -53,6 +53,40 @@ func TestWatch(t *testing.T) {
 	}
 }
 
+// TestWatchableStoreOnWrite ensures the OnWrite hook is called with the
+// events of each write txn.
+func TestWatchableStoreOnWrite(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	var got [][]mvccpb.Event
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{
+		OnWrite: func(evs []mvccpb.Event) { got = append(got, evs) },
+	})
+
+	defer func() {
+		b.Close()
+		s.Close()
+		os.Remove(tmpPath)
+	}()
+
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	txn := s.Write(traceutil.TODO())
+	txn.Put([]byte("foo1"), []byte("bar1"), lease.NoLease)
+	txn.DeleteRange([]byte("foo"), nil)
+	txn.End()
+	// no event without changes
+	s.DeleteRange([]byte("none"), nil)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(got))
+	}
+	if len(got[0]) != 1 || got[0][0].Type != mvccpb.PUT || string(got[0][0].Kv.Key) != "foo" {
+		t.Errorf("unexpected events of the first write %+v", got[0])
+	}
+	if len(got[1]) != 2 || got[1][0].Type != mvccpb.PUT || got[1][1].Type != mvccpb.DELETE {
+		t.Errorf("unexpected events of the second write %+v", got[1])
+	}
+}
+
 func TestNewWatcherCancel(t *testing.T) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
 	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
@@ -38,12 +38,20 @@ func (tw *watchableStoreTxnWrite) End() {
 		}
 	}
 
+	if onWrite := tw.s.store.cfg.OnWrite; onWrite != nil {
+		onWrite(evs)
+	}
+
 	// end write txn under watchable store lock so the updates are visible
 	// when asynchronous event posting checks the current store revision
 	tw.s.mu.Lock()
 	tw.s.notify(rev, evs)
 	tw.TxnWrite.End()
 	tw.s.mu.Unlock()
+
+	if onWriteEnd := tw.s.store.cfg.OnWriteEnd; onWriteEnd != nil {
+		onWriteEnd(rev)
+	}
 }
 
 type watchableStoreTxnWrite struct {
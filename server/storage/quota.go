@@ -15,6 +15,7 @@
 package storage
 
 import (
+	"strings"
 	"sync"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -32,6 +33,9 @@ const (
 	// MaxQuotaBytes is the maximum number of bytes suggested for a backend
 	// quota. A larger quota may lead to degraded performance.
 	MaxQuotaBytes = int64(8 * 1024 * 1024 * 1024) // 8GB
+	// DefaultQuotaExemptBytes is the number of bytes past the quota that
+	// writes to quota exempted prefixes may grow the backend by.
+	DefaultQuotaExemptBytes = int64(1024 * 1024) // 1MB
 )
 
 // Quota represents an arbitrary quota against arbitrary requests. Each request
@@ -44,6 +48,8 @@ type Quota interface {
 	Cost(req interface{}) int
 	// Remaining is the amount of charge left for the quota.
 	Remaining() int64
+	// Exempt reports whether the request only writes keys exempted from the quota.
+	Exempt(req interface{}) bool
 }
 
 type passthroughQuota struct{}
@@ -51,10 +57,17 @@ type passthroughQuota struct{}
 func (*passthroughQuota) Available(interface{}) bool { return true }
 func (*passthroughQuota) Cost(interface{}) int       { return 0 }
 func (*passthroughQuota) Remaining() int64           { return 1 }
+func (*passthroughQuota) Exempt(interface{}) bool    { return false }
 
 type BackendQuota struct {
 	be              backend.Backend
 	maxBackendBytes int64
+
+	// exemptPrefixes are key prefixes that may still be written once the
+	// quota is exhausted, as long as the backend stays within exemptBytes
+	// past the quota.
+	exemptPrefixes []string
+	exemptBytes    int64
 }
 
 const (
@@ -102,7 +115,7 @@ func NewBackendQuota(cfg config.ServerConfig, be backend.Backend, name string) Q
 			}
 		})
 		quotaBackendBytes.Set(float64(DefaultQuotaBytes))
-		return &BackendQuota{be, DefaultQuotaBytes}
+		return newBackendQuota(cfg, be, DefaultQuotaBytes)
 	}
 
 	quotaLogOnce.Do(func() {
@@ -123,7 +136,16 @@ func NewBackendQuota(cfg config.ServerConfig, be backend.Backend, name string) Q
 			zap.String("quota-size", humanize.Bytes(uint64(cfg.QuotaBackendBytes))),
 		)
 	})
-	return &BackendQuota{be, cfg.QuotaBackendBytes}
+	return newBackendQuota(cfg, be, cfg.QuotaBackendBytes)
+}
+
+func newBackendQuota(cfg config.ServerConfig, be backend.Backend, maxBackendBytes int64) *BackendQuota {
+	return &BackendQuota{
+		be:              be,
+		maxBackendBytes: maxBackendBytes,
+		exemptPrefixes:  cfg.QuotaExemptPrefixes,
+		exemptBytes:     cfg.QuotaExemptBytes,
+	}
 }
 
 func (b *BackendQuota) Available(v interface{}) bool {
@@ -133,7 +155,61 @@ func (b *BackendQuota) Available(v interface{}) bool {
 		return true
 	}
 	// TODO: maybe optimize Backend.Size()
-	return b.be.Size()+int64(cost) < b.maxBackendBytes
+	size := b.be.Size() + int64(cost)
+	if size < b.maxBackendBytes {
+		return true
+	}
+	return b.Exempt(v) && size < b.maxBackendBytes+b.exemptBytes
+}
+
+// Exempt reports whether every key written by the request is under one of
+// the quota exempted prefixes.
+func (b *BackendQuota) Exempt(v interface{}) bool {
+	if len(b.exemptPrefixes) == 0 {
+		return false
+	}
+	switch r := v.(type) {
+	case *pb.PutRequest:
+		return b.exemptKey(r.Key)
+	case *pb.TxnRequest:
+		puts, exempt := b.exemptTxn(r)
+		return exempt && puts > 0
+	default:
+		return false
+	}
+}
+
+// exemptTxn returns the number of puts of the txn, nested txns included, and
+// whether they all write under the exempted prefixes.
+func (b *BackendQuota) exemptTxn(r *pb.TxnRequest) (int, bool) {
+	puts := 0
+	for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, u := range ops {
+			switch op := u.Request.(type) {
+			case *pb.RequestOp_RequestPut:
+				if !b.exemptKey(op.RequestPut.Key) {
+					return puts, false
+				}
+				puts++
+			case *pb.RequestOp_RequestTxn:
+				n, exempt := b.exemptTxn(op.RequestTxn)
+				if !exempt {
+					return puts, false
+				}
+				puts += n
+			}
+		}
+	}
+	return puts, true
+}
+
+func (b *BackendQuota) exemptKey(key []byte) bool {
+	for _, prefix := range b.exemptPrefixes {
+		if strings.HasPrefix(string(key), prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 func (b *BackendQuota) Cost(v interface{}) int {
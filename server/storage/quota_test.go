@@ -0,0 +1,56 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestBackendQuotaExempt(t *testing.T) {
+	putOp := func(key string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte(key)}}}
+	}
+	txnOp := func(success ...*pb.RequestOp) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: success}}}
+	}
+	rangeOp := &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("abc")}}}
+
+	tests := []struct {
+		name string
+		req  interface{}
+		want bool
+	}{
+		{"exempt put", &pb.PutRequest{Key: []byte("/recovery/a")}, true},
+		{"put", &pb.PutRequest{Key: []byte("abc")}, false},
+		{"txn of exempt puts", &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/a"), rangeOp}, Failure: []*pb.RequestOp{putOp("/recovery/b")}}, true},
+		{"txn with a put", &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/a")}, Failure: []*pb.RequestOp{putOp("abc")}}, false},
+		{"txn without puts", &pb.TxnRequest{Success: []*pb.RequestOp{rangeOp}}, false},
+		{"nested txn of an exempt put", &pb.TxnRequest{Success: []*pb.RequestOp{txnOp(putOp("/recovery/a"))}}, true},
+		{"nested txn with a put", &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/a"), txnOp(putOp("/recovery/b"), txnOp(putOp("abc")))}}, false},
+		{"nested txn without puts", &pb.TxnRequest{Success: []*pb.RequestOp{txnOp(rangeOp)}}, false},
+		{"lease grant", &pb.LeaseGrantRequest{TTL: 10}, false},
+	}
+	q := &BackendQuota{exemptPrefixes: []string{"/recovery/"}}
+	for _, tt := range tests {
+		if got := q.Exempt(tt.req); got != tt.want {
+			t.Errorf("%s: expected exempt %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	if (&BackendQuota{}).Exempt(&pb.PutRequest{Key: []byte("/recovery/a")}) {
+		t.Error("expected no request to be exempt without exempted prefixes")
+	}
+}
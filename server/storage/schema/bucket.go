@@ -27,6 +27,11 @@ var (
 	leaseBucketName = []byte("lease")
 	alarmBucketName = []byte("alarm")
 
+	namespacesBucketName = []byte("namespaces")
+	indexesBucketName    = []byte("indexes")
+	cdcBucketName        = []byte("cdc")
+	requestIDsBucketName = []byte("request_ids")
+
 	clusterBucketName = []byte("cluster")
 
 	membersBucketName        = []byte("members")
@@ -36,6 +41,8 @@ var (
 	authUsersBucketName = []byte("authUsers")
 	authRolesBucketName = []byte("authRoles")
 
+	encryptionBucketName = []byte("encryption")
+
 	testBucketName = []byte("test")
 )
 
@@ -46,6 +53,11 @@ var (
 	Alarm   = backend.Bucket(bucket{id: 4, name: alarmBucketName, safeRangeBucket: false})
 	Cluster = backend.Bucket(bucket{id: 5, name: clusterBucketName, safeRangeBucket: false})
 
+	Namespaces = backend.Bucket(bucket{id: 6, name: namespacesBucketName, safeRangeBucket: false})
+	Indexes    = backend.Bucket(bucket{id: 7, name: indexesBucketName, safeRangeBucket: false})
+	CDC        = backend.Bucket(bucket{id: 8, name: cdcBucketName, safeRangeBucket: false})
+	RequestIDs = backend.Bucket(bucket{id: 9, name: requestIDsBucketName, safeRangeBucket: false})
+
 	Members        = backend.Bucket(bucket{id: 10, name: membersBucketName, safeRangeBucket: false})
 	MembersRemoved = backend.Bucket(bucket{id: 11, name: membersRemovedBucketName, safeRangeBucket: false})
 
@@ -53,6 +65,8 @@ var (
 	AuthUsers = backend.Bucket(bucket{id: 21, name: authUsersBucketName, safeRangeBucket: false})
 	AuthRoles = backend.Bucket(bucket{id: 22, name: authRolesBucketName, safeRangeBucket: false})
 
+	Encryption = backend.Bucket(bucket{id: 30, name: encryptionBucketName, safeRangeBucket: false})
+
 	Test = backend.Bucket(bucket{id: 100, name: testBucketName, safeRangeBucket: false})
 )
 
@@ -81,11 +95,20 @@ var (
 	ClusterDowngradeKeyName      = []byte("downgrade")
 	// Since v3.6
 	MetaStorageVersionName = []byte("storageVersion")
+	// CompactRetentionsKeyName holds the histories of keys kept by the last compaction.
+	CompactRetentionsKeyName = []byte("compactRetentions")
+	// FinishedCompactRetentionsKeyName holds the histories of keys kept by the
+	// last finished compaction, with the revisions they are kept from.
+	FinishedCompactRetentionsKeyName = []byte("finishedCompactRetentions")
 	// Before adding new meta key please update server/etcdserver/version
 )
 
 // DefaultIgnores defines buckets & keys to ignore in hash checking.
 func DefaultIgnores(bucket, key []byte) bool {
+	// the data encryption keys are generated by each member.
+	if bytes.Equal(bucket, Encryption.Name()) {
+		return true
+	}
 	// consistent index & term might be changed due to v2 internal sync, which
 	// is not controllable by the user.
 	// storage version might change after wal snapshot and is not controller by user.
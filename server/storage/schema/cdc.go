@@ -0,0 +1,53 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/binary"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+)
+
+// UnsafeReadCDCCheckpoint returns the revision of the last event published to
+// the change data capture sink, or 0 if none was.
+func UnsafeReadCDCCheckpoint(tx backend.ReadTx, sink string) int64 {
+	_, vs := tx.UnsafeRange(CDC, []byte(sink), nil, 0)
+	if len(vs) == 0 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(vs[0]))
+}
+
+// ReadCDCCheckpoint returns the revision of the last event published to the
+// change data capture sink, or 0 if none was.
+func ReadCDCCheckpoint(tx backend.ReadTx, sink string) int64 {
+	tx.RLock()
+	defer tx.RUnlock()
+	return UnsafeReadCDCCheckpoint(tx, sink)
+}
+
+// UnsafeUpdateCDCCheckpoint saves the revision of the last event published to
+// the change data capture sink, unless a later one is already saved. It
+// returns whether the checkpoint was saved.
+func UnsafeUpdateCDCCheckpoint(tx backend.BatchTx, sink string, rev int64) bool {
+	tx.UnsafeCreateBucket(CDC)
+	if rev <= UnsafeReadCDCCheckpoint(tx, sink) {
+		return false
+	}
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(rev))
+	tx.UnsafePut(CDC, []byte(sink), v)
+	return true
+}
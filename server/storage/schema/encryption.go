@@ -0,0 +1,91 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+)
+
+type backendCipher struct {
+	kr *encryption.Keyring
+}
+
+// NewBackendCipher returns a cipher encrypting the values of the key and the
+// user buckets with the keyring. Values written unencrypted, before
+// encryption was enabled, are still read.
+func NewBackendCipher(kr *encryption.Keyring) backend.ValueCipher {
+	return backendCipher{kr: kr}
+}
+
+func (c backendCipher) Encrypts(bucket []byte) bool {
+	return bytes.Equal(bucket, keyBucketName) || bytes.Equal(bucket, authUsersBucketName)
+}
+
+func (c backendCipher) Encrypt(bucket, key, value []byte) ([]byte, error) {
+	return c.kr.Encrypt(value, valueAAD(bucket, key))
+}
+
+func (c backendCipher) Decrypt(bucket, key, value []byte) ([]byte, error) {
+	if !encryption.IsEncrypted(value) {
+		return value, nil
+	}
+	return c.kr.Decrypt(value, valueAAD(bucket, key))
+}
+
+// valueAAD binds an encrypted value to its bucket and key.
+func valueAAD(bucket, key []byte) []byte {
+	aad := make([]byte, 0, len(bucket)+1+len(key))
+	aad = append(aad, bucket...)
+	aad = append(aad, 0)
+	return append(aad, key...)
+}
+
+// UnsafeSaveEncryptionKeys saves the wrapped data encryption keys.
+func UnsafeSaveEncryptionKeys(tx backend.BatchTx, keys []encryption.WrappedKey) {
+	tx.UnsafeCreateBucket(Encryption)
+	for _, k := range keys {
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, k.ID)
+		tx.UnsafePut(Encryption, id, k.Marshal())
+	}
+}
+
+// UnsafeReadEncryptionKeys reads the wrapped data encryption keys.
+func UnsafeReadEncryptionKeys(tx backend.ReadTx) (keys []encryption.WrappedKey, err error) {
+	err = tx.UnsafeForEach(Encryption, func(_, v []byte) error {
+		k, err := encryption.UnmarshalWrappedKey(v)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	return keys, err
+}
+
+// encryptionVersion is the minimal etcd version able to read a backend whose
+// values may be encrypted.
+var encryptionVersion = V3_6
+
+// unsafeHasEncryptionKeys returns if the backend holds data encryption keys,
+// that is if the encryption was enabled once and values may be encrypted.
+func unsafeHasEncryptionKeys(tx backend.ReadTx) (bool, error) {
+	keys, err := UnsafeReadEncryptionKeys(tx)
+	return len(keys) > 0, err
+}
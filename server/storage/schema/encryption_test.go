@@ -0,0 +1,93 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBackendCipher(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(keyFile, []byte("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600))
+	kp, err := encryption.NewFileKeyProvider(keyFile)
+	require.NoError(t, err)
+
+	// a value written before the encryption is enabled is still read
+	be, path := betesting.NewDefaultTmpBackend(t)
+	tx := be.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(Key)
+	tx.UnsafePut(Key, []byte("plain"), []byte("plaintext"))
+	tx.Unlock()
+	be.Close()
+
+	kr := encryption.NewKeyring(kp)
+	_, err = kr.Rotate()
+	require.NoError(t, err)
+	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
+	bcfg.Path, bcfg.Cipher = path, NewBackendCipher(kr)
+	be = backend.New(bcfg)
+	tx = be.BatchTx()
+	tx.Lock()
+	tx.UnsafePut(Key, []byte("secret"), []byte("secret-value"))
+	keys, _ := kr.Keys()
+	UnsafeSaveEncryptionKeys(tx, keys)
+	tx.Unlock()
+	be.ForceCommit()
+
+	rtx := be.ReadTx()
+	rtx.RLock()
+	_, vs := rtx.UnsafeRange(Key, []byte("secret"), nil, 0)
+	rtx.RUnlock()
+	assert.Equal(t, [][]byte{[]byte("secret-value")}, vs)
+	be.Close()
+
+	db, err := bolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(Key.Name())
+		assert.Equal(t, []byte("plaintext"), b.Get([]byte("plain")))
+		assert.True(t, encryption.IsEncrypted(b.Get([]byte("secret"))))
+		return nil
+	}))
+	require.NoError(t, db.Close())
+
+	// a keyring loading the saved keys decrypts the values
+	kr = encryption.NewKeyring(kp)
+	bcfg.Cipher = NewBackendCipher(kr)
+	be = backend.New(bcfg)
+	defer betesting.Close(t, be)
+	rtx = be.ReadTx()
+	rtx.RLock()
+	defer rtx.RUnlock()
+	keys, err = UnsafeReadEncryptionKeys(rtx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NoError(t, kr.Add(keys[0]))
+	_, vs = rtx.UnsafeRange(Key, []byte("plain"), []byte("zzz"), 0)
+	assert.Equal(t, [][]byte{[]byte("plaintext"), []byte("secret-value")}, vs)
+}
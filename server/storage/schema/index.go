@@ -0,0 +1,78 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.uber.org/zap"
+)
+
+type indexBackend struct {
+	lg *zap.Logger
+	be backend.Backend
+}
+
+func NewIndexBackend(lg *zap.Logger, be backend.Backend) *indexBackend {
+	return &indexBackend{
+		lg: lg,
+		be: be,
+	}
+}
+
+func (s *indexBackend) CreateIndexBucket() {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	tx.UnsafeCreateBucket(Indexes)
+}
+
+// MustPutIndex saves the definition of the index under its name.
+func (s *indexBackend) MustPutIndex(idx *etcdserverpb.Index) {
+	v, err := idx.Marshal()
+	if err != nil {
+		s.lg.Panic("failed to marshal index", zap.Error(err))
+	}
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(Indexes, []byte(idx.Name), v)
+}
+
+func (s *indexBackend) MustDeleteIndex(name string) {
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafeDelete(Indexes, []byte(name))
+}
+
+// GetAllIndexes returns the saved definitions of the indexes, sorted by name.
+// They are read with the batch tx, as the read tx does not see the deletes
+// until they are committed.
+func (s *indexBackend) GetAllIndexes() ([]*etcdserverpb.Index, error) {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	var idxs []*etcdserverpb.Index
+	err := tx.UnsafeForEach(Indexes, func(k, v []byte) error {
+		var idx etcdserverpb.Index
+		if err := idx.Unmarshal(v); err != nil {
+			return err
+		}
+		idxs = append(idxs, &idx)
+		return nil
+	})
+	return idxs, err
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.uber.org/zap"
+)
+
+type namespaceBackend struct {
+	lg *zap.Logger
+	be backend.Backend
+}
+
+func NewNamespaceBackend(lg *zap.Logger, be backend.Backend) *namespaceBackend {
+	return &namespaceBackend{
+		lg: lg,
+		be: be,
+	}
+}
+
+func (s *namespaceBackend) CreateNamespaceBucket() {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	tx.UnsafeCreateBucket(Namespaces)
+}
+
+// MustPutNamespace saves the namespace under its prefix.
+func (s *namespaceBackend) MustPutNamespace(ns *etcdserverpb.Namespace) {
+	v, err := ns.Marshal()
+	if err != nil {
+		s.lg.Panic("failed to marshal namespace", zap.Error(err))
+	}
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(Namespaces, ns.Prefix, v)
+}
+
+func (s *namespaceBackend) MustDeleteNamespace(prefix []byte) {
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafeDelete(Namespaces, prefix)
+}
+
+// GetAllNamespaces returns the saved namespaces. They are read with the batch
+// tx, as the read tx does not see the deletes until they are committed.
+func (s *namespaceBackend) GetAllNamespaces() ([]*etcdserverpb.Namespace, error) {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	var nss []*etcdserverpb.Namespace
+	err := tx.UnsafeForEach(Namespaces, func(k, v []byte) error {
+		var ns etcdserverpb.Namespace
+		if err := ns.Unmarshal(v); err != nil {
+			return err
+		}
+		nss = append(nss, &ns)
+		return nil
+	})
+	return nss, err
+}
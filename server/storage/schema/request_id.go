@@ -0,0 +1,95 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.uber.org/zap"
+)
+
+// RequestID is the response of a mutation applied with a request ID, saved
+// to answer the retries of the mutation.
+type RequestID struct {
+	// Key is the request ID qualified by the user of the mutation.
+	Key string
+	// Index is the raft index the mutation was applied at.
+	Index uint64
+	// Hash is the hash of the mutation, to tell a retry from another
+	// mutation with the same request ID.
+	Hash uint64
+	// Response is the marshaled response of the mutation.
+	Response []byte
+}
+
+type requestIDBackend struct {
+	lg *zap.Logger
+	be backend.Backend
+}
+
+func NewRequestIDBackend(lg *zap.Logger, be backend.Backend) *requestIDBackend {
+	return &requestIDBackend{
+		lg: lg,
+		be: be,
+	}
+}
+
+func (s *requestIDBackend) CreateRequestIDBucket() {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	tx.UnsafeCreateBucket(RequestIDs)
+}
+
+// MustPutRequestID saves the response of a mutation and deletes the evicted
+// request IDs in the same transaction.
+func (s *requestIDBackend) MustPutRequestID(r RequestID, evicted []string) {
+	v := make([]byte, 16+len(r.Response))
+	binary.BigEndian.PutUint64(v, r.Index)
+	binary.BigEndian.PutUint64(v[8:], r.Hash)
+	copy(v[16:], r.Response)
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(RequestIDs, []byte(r.Key), v)
+	for _, k := range evicted {
+		tx.UnsafeDelete(RequestIDs, []byte(k))
+	}
+}
+
+// GetAllRequestIDs returns the saved responses of the mutations, sorted by
+// key. They are read with the batch tx, as the read tx does not see the
+// deletes until they are committed.
+func (s *requestIDBackend) GetAllRequestIDs() ([]RequestID, error) {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	var rs []RequestID
+	err := tx.UnsafeForEach(RequestIDs, func(k, v []byte) error {
+		if len(v) < 16 {
+			return fmt.Errorf("request id %q has a truncated value", k)
+		}
+		rs = append(rs, RequestID{
+			Key:      string(k),
+			Index:    binary.BigEndian.Uint64(v),
+			Hash:     binary.BigEndian.Uint64(v[8:]),
+			Response: append([]byte{}, v[16:]...),
+		})
+		return nil
+	})
+	return rs, err
+}
@@ -80,6 +80,15 @@ func UnsafeMigrate(lg *zap.Logger, tx backend.BatchTx, w WALVersion, target semv
 		if minVersion != nil && target.LessThan(*minVersion) {
 			return fmt.Errorf("cannot downgrade storage, WAL contains newer entries")
 		}
+		if target.LessThan(encryptionVersion) {
+			encrypted, err := unsafeHasEncryptionKeys(tx)
+			if err != nil {
+				return fmt.Errorf("cannot read encryption keys: %v", err)
+			}
+			if encrypted {
+				return fmt.Errorf("cannot downgrade storage, backend contains encrypted values")
+			}
+		}
 	}
 	return plan.unsafeExecute(lg, tx)
 }
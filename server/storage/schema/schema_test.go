@@ -25,6 +25,7 @@ import (
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/wal"
 	waltesting "go.etcd.io/etcd/server/v3/storage/wal/testing"
 	"go.uber.org/zap"
@@ -194,6 +195,20 @@ func TestMigrate(t *testing.T) {
 			expectError:    true,
 			expectErrorMsg: "cannot downgrade storage, WAL contains newer entries",
 		},
+		{
+			name:    "Downgrading v3.6 to v3.5 fails if the backend values are encrypted",
+			version: V3_6,
+			overrideKeys: func(tx backend.BatchTx) {
+				MustUnsafeSaveConfStateToBackend(zap.NewNop(), tx, &raftpb.ConfState{})
+				UnsafeUpdateConsistentIndex(tx, 1, 1)
+				UnsafeSetStorageVersion(tx, &V3_6)
+				UnsafeSaveEncryptionKeys(tx, []encryption.WrappedKey{{ID: 1, KEKID: "k1", Key: []byte("wrapped")}})
+			},
+			targetVersion:  V3_5,
+			expectVersion:  &V3_6,
+			expectError:    true,
+			expectErrorMsg: "cannot downgrade storage, backend contains encrypted values",
+		},
 		{
 			name:           "Downgrading v3.5 to v3.4 is not supported as schema was introduced in v3.6",
 			version:        V3_5,
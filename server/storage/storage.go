@@ -123,11 +123,10 @@ func (st *storage) MinimalEtcdVersion() *semver.Version {
 	if err != nil {
 		panic(err)
 	}
-	_, _, ents, err := w.ReadAll()
+	wv, err := wal.ReadWALVersion(w)
 	if err != nil {
 		panic(err)
 	}
-	v := wal.MinimalEtcdVersion(ents)
 	st.w = w
-	return v
+	return wv.MinimalEtcdVersion()
 }
@@ -0,0 +1,74 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressedEntryBytes is the size of the data of the entries from which
+// it is compressed, the smaller ones barely shrinking.
+const minCompressedEntryBytes = 256
+
+var (
+	entryDecoderOnce sync.Once
+	entryDecoder     *zstd.Decoder
+	entryDecoderErr  error
+)
+
+// Compress compresses the data of the entries saved from now on. The entries
+// are read back whether compressed or not, so compression can be turned on
+// and off across restarts.
+func (w *WAL) Compress() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.compressor != nil {
+		return nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		return err
+	}
+	w.compressor = enc
+	return nil
+}
+
+// compressEntryData returns the compressed data, or nil if it is not worth
+// compressing.
+func (w *WAL) compressEntryData(data []byte) []byte {
+	if w.compressor == nil || len(data) < minCompressedEntryBytes {
+		return nil
+	}
+	compressed := w.compressor.EncodeAll(data, nil)
+	if len(compressed) >= len(data) {
+		return nil
+	}
+	walCompressionInBytes.Add(float64(len(data)))
+	walCompressionOutBytes.Add(float64(len(compressed)))
+	return compressed
+}
+
+// decompressEntryData decompresses the data of an entry saved compressed.
+func decompressEntryData(data []byte) ([]byte, error) {
+	entryDecoderOnce.Do(func() {
+		entryDecoder, entryDecoderErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	})
+	if entryDecoderErr != nil {
+		return nil, entryDecoderErr
+	}
+	return entryDecoder.DecodeAll(data, nil)
+}
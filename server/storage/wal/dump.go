@@ -0,0 +1,154 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"fmt"
+	"io"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+
+	"go.uber.org/zap"
+)
+
+// Record types as reported by Dump.
+const (
+	RecordMetadata = "metadata"
+	RecordEntry    = "entry"
+	RecordState    = "state"
+	RecordCRC      = "crc"
+	RecordSnapshot = "snapshot"
+	RecordKey      = "key"
+)
+
+// DumpedRecord is a record of the WAL as read by Dump.
+type DumpedRecord struct {
+	// File is the name of the WAL file holding the record.
+	File string
+	// Offset is the offset of the record in its file.
+	Offset int64
+	// Type is one of the Record* types.
+	Type string
+
+	// Entry is set for entry records. Its data is decompressed, but left
+	// as is if encrypted, Encrypted being set then.
+	Entry      *raftpb.Entry
+	Compressed bool
+	Encrypted  bool
+
+	// State is set for state records.
+	State *raftpb.HardState
+	// Snapshot is set for snapshot records.
+	Snapshot *walpb.Snapshot
+	// Metadata is set for metadata records.
+	Metadata []byte
+	// Crc is set for crc records.
+	Crc uint32
+}
+
+// Dump decodes the records of all the WAL files in walDir, in the order
+// they are laid out, and calls fn for each of them. Unlike ReadAll, it
+// reports the entries overwritten by later terms and the records preceding
+// the last snapshot, which makes it suited to debug the WAL of a member
+// offline. It does not lock the files.
+//
+// Dump stops at a torn record ending the last file without error, and at
+// any other corrupted record with an error giving its file and offset.
+// It stops as well at the first error returned by fn.
+func Dump(lg *zap.Logger, walDir string, fn func(DumpedRecord) error) error {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	names, err := readWALNames(lg, walDir)
+	if err != nil {
+		return err
+	}
+
+	// open wal files in read mode, so that there is no conflict
+	// when the same WAL is opened elsewhere in write mode
+	rs, _, closer, err := openWALFiles(lg, walDir, names, 0, false)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	decoder := newDecoder(rs...)
+	rec := &walpb.Record{}
+	// the decoder drops the readers of the files it is done with
+	fileOf := func() string { return names[len(names)-len(decoder.brs)] }
+	for {
+		file, off := fileOf(), decoder.lastOffset()
+		if err = decoder.decode(rec); err != nil {
+			break
+		}
+		if f := fileOf(); f != file {
+			file, off = f, 0
+		}
+
+		dr := DumpedRecord{File: file, Offset: off}
+		switch rec.Type {
+		case metadataType:
+			dr.Type, dr.Metadata = RecordMetadata, rec.Data
+		case entryType, compressedEntryType:
+			var e raftpb.Entry
+			if err = e.Unmarshal(rec.Data); err != nil {
+				return fmt.Errorf("wal: %s at offset %d: %w", file, off, err)
+			}
+			dr.Type, dr.Entry = RecordEntry, &e
+			dr.Compressed = rec.Type == compressedEntryType
+			dr.Encrypted = encryption.IsEncrypted(e.Data)
+			if dr.Compressed && !dr.Encrypted {
+				if e.Data, err = decompressEntryData(e.Data); err != nil {
+					return fmt.Errorf("wal: %s at offset %d: %w", file, off, err)
+				}
+			}
+		case stateType:
+			var s raftpb.HardState
+			if err = s.Unmarshal(rec.Data); err != nil {
+				return fmt.Errorf("wal: %s at offset %d: %w", file, off, err)
+			}
+			dr.Type, dr.State = RecordState, &s
+		case crcType:
+			crc := decoder.crc.Sum32()
+			// current crc of decoder must match the crc of the record.
+			// do no need to match 0 crc, since the decoder is a new one at this case.
+			if crc != 0 && rec.Validate(crc) != nil {
+				return fmt.Errorf("wal: %s at offset %d: %w", file, off, ErrCRCMismatch)
+			}
+			decoder.updateCRC(rec.Crc)
+			dr.Type, dr.Crc = RecordCRC, rec.Crc
+		case snapshotType:
+			var snap walpb.Snapshot
+			if err = snap.Unmarshal(rec.Data); err != nil {
+				return fmt.Errorf("wal: %s at offset %d: %w", file, off, err)
+			}
+			dr.Type, dr.Snapshot = RecordSnapshot, &snap
+		case keyType:
+			dr.Type = RecordKey
+		default:
+			return fmt.Errorf("wal: %s at offset %d: unexpected block type %d", file, off, rec.Type)
+		}
+		if err = fn(dr); err != nil {
+			return err
+		}
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return fmt.Errorf("wal: %s at offset %d: %w", fileOf(), decoder.lastOffset(), err)
+}
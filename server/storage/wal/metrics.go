@@ -34,9 +34,25 @@ var (
 		Name:      "wal_write_bytes_total",
 		Help:      "Total number of bytes written in WAL.",
 	})
+
+	walCompressionInBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "wal_compression_in_bytes_total",
+		Help:      "Total number of bytes of the data of the WAL entries compressed.",
+	})
+
+	walCompressionOutBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "disk",
+		Name:      "wal_compression_out_bytes_total",
+		Help:      "Total number of bytes the data of the WAL entries compressed to.",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(walFsyncSec)
 	prometheus.MustRegister(walWriteBytes)
+	prometheus.MustRegister(walCompressionInBytes)
+	prometheus.MustRegister(walCompressionOutBytes)
 }
@@ -35,16 +35,40 @@ func ReadWALVersion(w *WAL) (*walVersion, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &walVersion{entries: ents}, nil
+	return &walVersion{entries: ents, recordVersion: w.MinimalRecordVersion()}, nil
 }
 
 type walVersion struct {
-	entries []raftpb.Entry
+	entries       []raftpb.Entry
+	recordVersion *semver.Version
 }
 
 // MinimalEtcdVersion returns minimal etcd able to interpret entries from  WAL log,
+// and to read the records holding them.
 func (w *walVersion) MinimalEtcdVersion() *semver.Version {
-	return MinimalEtcdVersion(w.entries)
+	return maxVersion(MinimalEtcdVersion(w.entries), w.recordVersion)
+}
+
+// recordTypeVersions are the minimal etcd versions able to read the record
+// types added after the v3.5 WAL format. Older versions fail to read a WAL
+// holding them, whatever the entries.
+var recordTypeVersions = map[int64]semver.Version{
+	keyType:             {Major: 3, Minor: 6},
+	compressedEntryType: {Major: 3, Minor: 6},
+}
+
+// encryptedEntryVersion is the minimal etcd version able to read the entries
+// whose data is encrypted, whatever the type of their records.
+var encryptedEntryVersion = semver.Version{Major: 3, Minor: 6}
+
+// recordTypeVersion returns the minimal etcd version able to read the records
+// of the given type, nil if any version can.
+func recordTypeVersion(typ int64) *semver.Version {
+	ver, ok := recordTypeVersions[typ]
+	if !ok {
+		return nil
+	}
+	return &ver
 }
 
 // MinimalEtcdVersion returns minimal etcd able to interpret entries from  WAL log,
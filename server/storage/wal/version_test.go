@@ -15,7 +15,11 @@
 package wal
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/coreos/go-semver/semver"
@@ -25,6 +29,9 @@ import (
 	"go.etcd.io/etcd/api/v3/membershippb"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.uber.org/zap/zaptest"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -109,6 +116,59 @@ func TestEtcdVersionFromEntry(t *testing.T) {
 	}
 }
 
+func TestReadWALVersion(t *testing.T) {
+	raftReq := etcdserverpb.InternalRaftRequest{
+		Header: &etcdserverpb.RequestHeader{AuthRevision: 1},
+		Put:    &etcdserverpb.PutRequest{Key: []byte("foo"), Value: bytes.Repeat([]byte("compressible "), 100)},
+	}
+	ents := []raftpb.Entry{{Term: 1, Index: 1, Type: raftpb.EntryNormal, Data: pbutil.MustMarshal(&raftReq)}}
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600))
+	kp, err := encryption.NewFileKeyProvider(keyFile)
+	assert.NoError(t, err)
+
+	tcs := []struct {
+		name   string
+		setup  func(w *WAL) error
+		expect *semver.Version
+	}{
+		{
+			name:   "Plain entries imply the version of the entries",
+			setup:  func(w *WAL) error { return nil },
+			expect: &V3_1,
+		},
+		{
+			name:   "Compressed entries imply v3.6",
+			setup:  func(w *WAL) error { return w.Compress() },
+			expect: &V3_6,
+		},
+		{
+			name:   "Encrypted entries imply v3.6",
+			setup:  func(w *WAL) error { return w.Encrypt(kp) },
+			expect: &V3_6,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := t.TempDir()
+			w, err := Create(zaptest.NewLogger(t), p, nil)
+			assert.NoError(t, err)
+			assert.NoError(t, tc.setup(w))
+			assert.NoError(t, w.Save(raftpb.HardState{Term: 1, Commit: 1}, ents))
+			w.Close()
+
+			w, err = OpenForRead(zaptest.NewLogger(t), p, walpb.Snapshot{})
+			assert.NoError(t, err)
+			defer w.Close()
+			// the keys are needed to read an encrypted WAL
+			assert.NoError(t, w.Encrypt(kp))
+			wv, err := ReadWALVersion(w)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, wv.MinimalEtcdVersion())
+		})
+	}
+}
+
 func TestEtcdVersionFromMessage(t *testing.T) {
 	tcs := []struct {
 		name   string
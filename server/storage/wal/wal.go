@@ -16,6 +16,7 @@ package wal
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"hash/crc32"
@@ -26,10 +27,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
+	"github.com/klauspost/compress/zstd"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 
 	"go.uber.org/zap"
@@ -41,6 +45,12 @@ const (
 	stateType
 	crcType
 	snapshotType
+	// keyType records hold the data encryption key of the entries saved after
+	// them, wrapped by a key encryption key.
+	keyType
+	// compressedEntryType records hold an entry whose data is compressed,
+	// before being encrypted if the WAL is encrypted.
+	compressedEntryType
 
 	// warnSyncDuration is the amount of time allotted to an fsync before
 	// logging a warning
@@ -62,6 +72,7 @@ var (
 	ErrSliceOutOfRange              = errors.New("wal: slice bounds out of range")
 	ErrMaxWALEntrySizeLimitExceeded = errors.New("wal: max entry size limit exceeded")
 	ErrDecoderNotFound              = errors.New("wal: decoder not found")
+	ErrKeyProviderNotFound          = errors.New("wal: encrypted entries found, but no key provider set")
 	crcTable                        = crc32.MakeTable(crc32.Castagnoli)
 )
 
@@ -93,6 +104,16 @@ type WAL struct {
 
 	locks []*fileutil.LockedFile // the locked files the WAL holds (the name is increasing)
 	fp    *filePipeline
+
+	keyProvider encryption.KeyProvider
+	keyring     *encryption.Keyring // encrypts the data of the entries, if set
+
+	compressor  *zstd.Encoder // compresses the data of the entries, if set
+	segmentSize int64         // size from which the files are cut, SegmentSizeBytes if 0
+
+	// recordVersion is the minimal etcd version able to read the records
+	// read by ReadAll, nil if any version can.
+	recordVersion *semver.Version
 }
 
 // Create creates a WAL ready for appending records. The given metadata is
@@ -239,13 +260,99 @@ func (w *WAL) Reopen(lg *zap.Logger, snap walpb.Snapshot) (*WAL, error) {
 	if err != nil {
 		lg.Panic("failed to close WAL during reopen", zap.Error(err))
 	}
-	return Open(lg, w.dir, snap)
+	nw, err := Open(lg, w.dir, snap)
+	if err != nil {
+		return nil, err
+	}
+	if w.segmentSize != 0 {
+		if err = nw.SetSegmentSize(w.segmentSize); err != nil {
+			return nw, err
+		}
+	}
+	if w.compressor != nil {
+		if err = nw.Compress(); err != nil {
+			return nw, err
+		}
+	}
+	if w.keyProvider == nil {
+		return nw, nil
+	}
+	return nw, nw.Encrypt(w.keyProvider)
+}
+
+// Encrypt encrypts the data of the entries saved from now on, with a data
+// encryption key wrapped by p and generated again for each WAL file, and
+// decrypts the data of the entries read. It must be called before ReadAll to
+// read a WAL with encrypted entries.
+func (w *WAL) Encrypt(p encryption.KeyProvider) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keyProvider, w.keyring = p, encryption.NewKeyring(p)
+	if w.encoder == nil {
+		// the key is saved once all the records are read
+		return nil
+	}
+	return w.rotateKey()
+}
+
+// rotateKey saves a new data encryption key, which encrypts the entries saved
+// next. The current key, if any, is saved again if a new one cannot be
+// wrapped.
+func (w *WAL) rotateKey() error {
+	k, err := w.keyring.Rotate()
+	if err != nil {
+		var ok bool
+		if k, ok = w.keyring.Current(); !ok {
+			return err
+		}
+		w.lg.Warn("failed to rotate the WAL data encryption key; keeping the current one", zap.Error(err))
+	}
+	return w.encoder.encode(&walpb.Record{Type: keyType, Data: k.Marshal()})
+}
+
+// entryAAD binds the encrypted data of an entry to its index.
+func entryAAD(index uint64) []byte {
+	var aad [8]byte
+	binary.BigEndian.PutUint64(aad[:], index)
+	return aad[:]
+}
+
+// MinimalRecordVersion returns the minimal etcd version able to read the
+// records read by ReadAll, whatever the entries they hold, nil if any version
+// can.
+func (w *WAL) MinimalRecordVersion() *semver.Version {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.recordVersion
 }
 
 func (w *WAL) SetUnsafeNoFsync() {
 	w.unsafeNoSync = true
 }
 
+// SetSegmentSize sets the size from which the WAL files are cut instead of
+// SegmentSizeBytes. The files created from now on are preallocated to size.
+func (w *WAL) SetSegmentSize(size int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.segmentSize = size
+	if w.fp == nil {
+		return nil
+	}
+	if err := w.fp.Close(); err != nil {
+		return err
+	}
+	w.fp = newFilePipeline(w.lg, w.dir, w.segmentBytes())
+	return nil
+}
+
+func (w *WAL) segmentBytes() int64 {
+	if w.segmentSize > 0 {
+		return w.segmentSize
+	}
+	return SegmentSizeBytes
+}
+
 func (w *WAL) cleanupWAL(lg *zap.Logger) {
 	var err error
 	if err = w.Close(); err != nil {
@@ -446,12 +553,33 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 	decoder := w.decoder
 
 	var match bool
+	w.recordVersion = nil
 	for err = decoder.decode(rec); err == nil; err = decoder.decode(rec) {
+		w.recordVersion = maxVersion(w.recordVersion, recordTypeVersion(rec.Type))
 		switch rec.Type {
-		case entryType:
+		case entryType, compressedEntryType:
 			e := mustUnmarshalEntry(rec.Data)
+			if encryption.IsEncrypted(e.Data) {
+				w.recordVersion = maxVersion(w.recordVersion, &encryptedEntryVersion)
+			}
 			// 0 <= e.Index-w.start.Index - 1 < len(ents)
 			if e.Index > w.start.Index {
+				if encryption.IsEncrypted(e.Data) {
+					if w.keyring == nil {
+						state.Reset()
+						return nil, state, nil, ErrKeyProviderNotFound
+					}
+					if e.Data, err = w.keyring.Decrypt(e.Data, entryAAD(e.Index)); err != nil {
+						state.Reset()
+						return nil, state, nil, err
+					}
+				}
+				if rec.Type == compressedEntryType {
+					if e.Data, err = decompressEntryData(e.Data); err != nil {
+						state.Reset()
+						return nil, state, nil, err
+					}
+				}
 				// prevent "panic: runtime error: slice bounds out of range [:13038096702221461992] with capacity 0"
 				up := e.Index - w.start.Index - 1
 				if up > uint64(len(ents)) {
@@ -494,6 +622,22 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 				match = true
 			}
 
+		case keyType:
+			if w.keyring == nil {
+				state.Reset()
+				return nil, state, nil, ErrKeyProviderNotFound
+			}
+			var k encryption.WrappedKey
+			if k, err = encryption.UnmarshalWrappedKey(rec.Data); err == nil {
+				if err = w.keyring.Add(k); err == nil {
+					err = w.keyring.SetCurrent(k.ID)
+				}
+			}
+			if err != nil {
+				state.Reset()
+				return nil, state, nil, err
+			}
+
 		default:
 			state.Reset()
 			return nil, state, nil, fmt.Errorf("unexpected block type %d", rec.Type)
@@ -549,6 +693,11 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 		if err != nil {
 			return
 		}
+		if w.keyring != nil {
+			if kerr := w.rotateKey(); kerr != nil {
+				return nil, state, nil, kerr
+			}
+		}
 	}
 	w.decoder = nil
 
@@ -683,7 +832,8 @@ func Verify(lg *zap.Logger, walDir string, snap walpb.Snapshot) (*raftpb.HardSta
 			}
 		// We ignore all entry and state type records as these
 		// are not necessary for validating the WAL contents
-		case entryType:
+		case entryType, compressedEntryType:
+		case keyType:
 		case stateType:
 			pbutil.MustUnmarshal(&state, rec.Data)
 		default:
@@ -750,6 +900,12 @@ func (w *WAL) cut() error {
 		return err
 	}
 
+	if w.keyring != nil {
+		if err = w.rotateKey(); err != nil {
+			return err
+		}
+	}
+
 	// atomically move temp wal file to wal file
 	if err = w.sync(); err != nil {
 		return err
@@ -803,6 +959,7 @@ func (w *WAL) sync() error {
 	}
 
 	start := time.Now()
+	// gofail: var walBeforeSync struct{}
 	err := fileutil.Fdatasync(w.tail().File)
 
 	took := time.Since(start)
@@ -878,6 +1035,10 @@ func (w *WAL) Close() error {
 		w.fp.Close()
 		w.fp = nil
 	}
+	if w.compressor != nil {
+		w.compressor.Close()
+		w.compressor = nil
+	}
 
 	if w.tail() != nil {
 		if err := w.sync(); err != nil {
@@ -897,9 +1058,25 @@ func (w *WAL) Close() error {
 }
 
 func (w *WAL) saveEntry(e *raftpb.Entry) error {
+	typ := entryType
+	if data := w.compressEntryData(e.Data); data != nil {
+		ee := *e
+		ee.Data = data
+		e = &ee
+		typ = compressedEntryType
+	}
+	if w.keyring != nil && len(e.Data) > 0 {
+		data, err := w.keyring.Encrypt(e.Data, entryAAD(e.Index))
+		if err != nil {
+			return err
+		}
+		ee := *e
+		ee.Data = data
+		e = &ee
+	}
 	// TODO: add MustMarshalTo to reduce one allocation.
 	b := pbutil.MustMarshal(e)
-	rec := &walpb.Record{Type: entryType, Data: b}
+	rec := &walpb.Record{Type: typ, Data: b}
 	if err := w.encoder.encode(rec); err != nil {
 		return err
 	}
@@ -942,7 +1119,7 @@ func (w *WAL) Save(st raftpb.HardState, ents []raftpb.Entry) error {
 	if err != nil {
 		return err
 	}
-	if curOff < SegmentSizeBytes {
+	if curOff < w.segmentBytes() {
 		if mustSync {
 			return w.sync()
 		}
@@ -16,6 +16,7 @@ package wal
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"math"
@@ -30,6 +31,7 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/encryption"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 	"go.uber.org/zap/zaptest"
 )
@@ -1026,3 +1028,265 @@ func TestValidSnapshotEntriesAfterPurgeWal(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestEncrypt(t *testing.T) {
+	p := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(keyFile, []byte("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kp, err := encryption.NewFileKeyProvider(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Encrypt(kp); err != nil {
+		t.Fatal(err)
+	}
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: []byte("secret1")}, {Index: 2, Term: 1}}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: 1}, ents[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.cut(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: 2}, ents[1:]); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	names, err := fileutil.ReadDir(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		b, rerr := os.ReadFile(filepath.Join(p, name))
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if bytes.Contains(b, []byte("secret1")) {
+			t.Errorf("%s contains the entry data in plaintext", name)
+		}
+	}
+
+	w, err = Open(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = w.ReadAll(); err != ErrKeyProviderNotFound {
+		t.Errorf("err = %v, want %v", err, ErrKeyProviderNotFound)
+	}
+	w.Close()
+
+	w, err = Open(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err = w.Encrypt(kp); err != nil {
+		t.Fatal(err)
+	}
+	metadata, _, gents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(metadata, []byte("metadata")) {
+		t.Errorf("metadata = %s, want %s", metadata, "metadata")
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: 3}, []raftpb.Entry{{Index: 3, Term: 1, Data: []byte("secret3")}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	p := t.TempDir()
+	w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Compress(); err != nil {
+		t.Fatal(err)
+	}
+	large := bytes.Repeat([]byte("compressible "), 1000)
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: large}, {Index: 2, Term: 1, Data: []byte("small")}}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: 2}, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	names, err := fileutil.ReadDir(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		b, rerr := os.ReadFile(filepath.Join(p, name))
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if bytes.Contains(b, large) {
+			t.Errorf("%s contains the large entry data uncompressed", name)
+		}
+	}
+
+	// the compressed entries are read whether compression is on or not
+	w, err = OpenForRead(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, gents, err := w.ReadAll()
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+}
+
+func TestCompressEncrypted(t *testing.T) {
+	p := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(keyFile, []byte("k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kp, err := encryption.NewFileKeyProvider(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Create(zaptest.NewLogger(t), p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Compress(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Encrypt(kp); err != nil {
+		t.Fatal(err)
+	}
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: bytes.Repeat([]byte("secret "), 1000)}}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: 1}, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	w, err = Open(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err = w.Encrypt(kp); err != nil {
+		t.Fatal(err)
+	}
+	_, _, gents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+}
+
+func TestSetSegmentSize(t *testing.T) {
+	p := t.TempDir()
+	w, err := Create(zaptest.NewLogger(t), p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.SetSegmentSize(4 * 1024); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 1024)
+	for i := 1; i <= 10; i++ {
+		if err = w.Save(raftpb.HardState{Term: 1, Commit: uint64(i)}, []raftpb.Entry{{Index: uint64(i), Term: 1, Data: data}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	names, err := fileutil.ReadDir(p, fileutil.WithExt(".wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each file is cut once it holds more than 4KiB of entries
+	if len(names) < 3 {
+		t.Errorf("len(names) = %d, want the WAL cut in at least 3 files", len(names))
+	}
+
+	w, err = OpenForRead(zaptest.NewLogger(t), p, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, _, ents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ents) != 10 {
+		t.Errorf("len(ents) = %d, want 10", len(ents))
+	}
+}
+
+func TestDump(t *testing.T) {
+	p := t.TempDir()
+	w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.SetSegmentSize(256); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Compress(); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("compressible "), 200)
+	for i := 1; i <= 10; i++ {
+		if err = w.Save(raftpb.HardState{Term: 1, Commit: uint64(i)}, []raftpb.Entry{{Index: uint64(i), Term: 1, Data: data}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// entry 10 is overwritten by a later term, both being dumped
+	if err = w.Save(raftpb.HardState{Term: 2, Commit: 9}, []raftpb.Entry{{Index: 10, Term: 2, Data: []byte("small")}}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	var ents []raftpb.Entry
+	var files []string
+	types := make(map[string]int)
+	err = Dump(zaptest.NewLogger(t), p, func(r DumpedRecord) error {
+		types[r.Type]++
+		if len(files) == 0 || files[len(files)-1] != r.File {
+			if r.Offset != 0 {
+				t.Errorf("first record of %s at offset %d, want 0", r.File, r.Offset)
+			}
+			files = append(files, r.File)
+		}
+		if r.Type == RecordEntry {
+			if r.Entry.Index <= 9 && (!r.Compressed || !bytes.Equal(r.Entry.Data, data)) {
+				t.Errorf("entry %d compressed = %v, data decompressed = %v", r.Entry.Index, r.Compressed, bytes.Equal(r.Entry.Data, data))
+			}
+			ents = append(ents, *r.Entry)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ents) != 11 || ents[10].Term != 2 || string(ents[10].Data) != "small" {
+		t.Errorf("dumped %d entries, want 11 ending with the one of term 2", len(ents))
+	}
+	if len(files) < 2 {
+		t.Errorf("dumped records of %d files, want the WAL cut in several files", len(files))
+	}
+	if types[RecordMetadata] != len(files) || types[RecordCRC] != len(files) || types[RecordSnapshot] != 1 || types[RecordState] < 11 {
+		t.Errorf("types = %v", types)
+	}
+}
@@ -0,0 +1,517 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// IssueKind is the kind of an inconsistency found by CheckBackend.
+type IssueKind string
+
+const (
+	// IssuePage is a page of the backend file that is corrupted or truncated.
+	IssuePage IssueKind = "page"
+	// IssueConsistentIndex is a consistent index that is missing, or out of
+	// the range of the entries of the WAL.
+	IssueConsistentIndex IssueKind = "consistent-index"
+	// IssueRevision is a revision of the key bucket that cannot be decoded.
+	IssueRevision IssueKind = "revision"
+	// IssueCompaction is a compacted revision that cannot be decoded, or a
+	// finished compaction beyond the scheduled one.
+	IssueCompaction IssueKind = "compaction"
+	// IssueOrphanedLease is a key attached to a lease missing from the lease
+	// bucket.
+	IssueOrphanedLease IssueKind = "orphaned-lease"
+	// IssueDanglingRole is a user granted a role missing from the auth roles
+	// bucket.
+	IssueDanglingRole IssueKind = "dangling-role"
+	// IssueAuth is the auth enabled without a root user.
+	IssueAuth IssueKind = "auth"
+)
+
+// Issue is an inconsistency found in the backend by CheckBackend.
+type Issue struct {
+	Kind IssueKind
+	// Bucket and Key locate the issue when it is about a single key: the
+	// revision of the key bucket, or the name of the user.
+	Bucket string
+	Key    []byte
+	// Lease is the missing lease of an IssueOrphanedLease.
+	Lease int64
+	// Role is the missing role of an IssueDanglingRole.
+	Role   string
+	Detail string
+}
+
+// Repairable returns whether RepairBackend repairs the issue.
+func (i Issue) Repairable() bool {
+	switch i.Kind {
+	case IssuePage, IssueOrphanedLease, IssueDanglingRole:
+		return true
+	}
+	return false
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Kind, i.Detail)
+}
+
+// openTimeout is how long opening the backend waits for etcd to release
+// its lock on it.
+const openTimeout = 5 * time.Second
+
+// CheckBackend verifies the consistency between the consistent index and
+// the WAL, and between the MVCC, lease and auth buckets of the backend of
+// the given data directory, which must not be in use by etcd. It does not
+// modify the backend.
+//
+// The pages of the backend are checked first: the buckets are only checked
+// if no IssuePage is found, their content being unreliable otherwise.
+func CheckBackend(lg *zap.Logger, dataDir string) ([]Issue, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	path := datadir.ToBackendFileName(dataDir)
+	db, err := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true, Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backend %q, is it in use by etcd? (%w)", path, err)
+	}
+	defer db.Close()
+
+	var issues []Issue
+	var index, term uint64
+	err = db.View(func(tx *bolt.Tx) error {
+		// bbolt panics on the corrupted pages it reads, including in the
+		// goroutine of Check, which only runs once they are all readable.
+		if issues = checkPages(tx); len(issues) != 0 {
+			return nil
+		}
+		for err := range tx.Check() {
+			issues = append(issues, Issue{Kind: IssuePage, Detail: err.Error()})
+		}
+		if len(issues) != 0 {
+			return nil
+		}
+		var ok bool
+		if index, term, ok = readConsistentIndex(tx); !ok {
+			issues = append(issues, Issue{Kind: IssueConsistentIndex, Detail: "consistent index missing or malformed"})
+		}
+		issues = append(issues, checkKeys(tx)...)
+		issues = append(issues, checkAuth(tx)...)
+		return nil
+	})
+	if err != nil || hasPageIssue(issues) {
+		return issues, err
+	}
+
+	if wal.Exist(datadir.ToWalDir(dataDir)) {
+		cfg := Config{DataDir: dataDir, Logger: lg}
+		snapshot, hardstate, err := validateWal(cfg)
+		if err != nil {
+			return issues, err
+		}
+		if err = validateConsistentIndex(cfg, hardstate, snapshot, index, term); err != nil {
+			issues = append(issues, Issue{Kind: IssueConsistentIndex, Detail: err.Error()})
+		}
+	}
+	return issues, nil
+}
+
+// checkPages reads through the buckets, returning an IssuePage for the
+// ones holding a corrupted page.
+func checkPages(tx *bolt.Tx) []Issue {
+	names, err := bucketNames(tx)
+	if err != nil {
+		return []Issue{{Kind: IssuePage, Detail: err.Error()}}
+	}
+	var issues []Issue
+	for _, name := range names {
+		err := readPages(func() {
+			c := tx.Bucket(name).Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			}
+		})
+		if err != nil {
+			issues = append(issues, Issue{Kind: IssuePage, Detail: fmt.Sprintf("bucket %q: %v", name, err)})
+		}
+	}
+	return issues
+}
+
+func hasPageIssue(issues []Issue) bool {
+	return len(issues) != 0 && issues[0].Kind == IssuePage
+}
+
+func readConsistentIndex(tx *bolt.Tx) (index, term uint64, ok bool) {
+	b := tx.Bucket(schema.Meta.Name())
+	if b == nil {
+		return 0, 0, false
+	}
+	v := b.Get(schema.MetaConsistentIndexKeyName)
+	if len(v) != 8 {
+		return 0, 0, false
+	}
+	index = binary.BigEndian.Uint64(v)
+	// term is persisted since v3.5
+	if t := b.Get(schema.MetaTermKeyName); len(t) == 8 {
+		term = binary.BigEndian.Uint64(t)
+	}
+	return index, term, true
+}
+
+// The revisions of the key bucket are revBytesLen long, followed by
+// markTombstone for the deletions.
+const (
+	revBytesLen   = 8 + 1 + 8
+	markTombstone = 't'
+)
+
+func checkKeys(tx *bolt.Tx) []Issue {
+	kb := tx.Bucket(schema.Key.Name())
+	if kb == nil {
+		return nil
+	}
+	leases := make(map[int64]bool)
+	if lb := tx.Bucket(schema.Lease.Name()); lb != nil {
+		lb.ForEach(func(k, _ []byte) error {
+			if len(k) == 8 {
+				leases[int64(binary.BigEndian.Uint64(k))] = true
+			}
+			return nil
+		})
+	}
+
+	var issues []Issue
+	revIssue := func(k []byte, format string, args ...interface{}) {
+		issues = append(issues, Issue{Kind: IssueRevision, Bucket: schema.Key.String(), Key: append([]byte(nil), k...), Detail: fmt.Sprintf("revision %x: ", k) + fmt.Sprintf(format, args...)})
+	}
+	// leased holds the revision of the live keys attached to a lease.
+	leased := make(map[string][]byte)
+	kb.ForEach(func(k, v []byte) error {
+		tombstone := len(k) == revBytesLen+1 && k[revBytesLen] == markTombstone
+		if len(k) != revBytesLen && !tombstone {
+			revIssue(k, "malformed revision")
+			return nil
+		}
+		var kv mvccpb.KeyValue
+		if err := kv.Unmarshal(v); err != nil {
+			revIssue(k, "failed to unmarshal key-value: %v", err)
+			return nil
+		}
+		rev := int64(binary.BigEndian.Uint64(k))
+		if tombstone {
+			delete(leased, string(kv.Key))
+			return nil
+		}
+		if kv.ModRevision != rev {
+			revIssue(k, "mod revision %d of key %q does not match the revision %d", kv.ModRevision, kv.Key, rev)
+		}
+		if kv.Lease == 0 {
+			delete(leased, string(kv.Key))
+		} else {
+			leased[string(kv.Key)] = append([]byte(nil), k...)
+		}
+		return nil
+	})
+
+	keys := make([]string, 0, len(leased))
+	for key := range leased {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var kv mvccpb.KeyValue
+		kv.Unmarshal(kb.Get(leased[key]))
+		if !leases[kv.Lease] {
+			issues = append(issues, Issue{
+				Kind:   IssueOrphanedLease,
+				Bucket: schema.Key.String(),
+				Key:    leased[key],
+				Lease:  kv.Lease,
+				Detail: fmt.Sprintf("key %q attached to missing lease %016x", key, kv.Lease),
+			})
+		}
+	}
+
+	if mb := tx.Bucket(schema.Meta.Name()); mb != nil {
+		var compacted [2]int64
+		for i, name := range [][]byte{schema.ScheduledCompactKeyName, schema.FinishedCompactKeyName} {
+			v := mb.Get(name)
+			if v == nil {
+				continue
+			}
+			if len(v) != revBytesLen {
+				issues = append(issues, Issue{Kind: IssueCompaction, Bucket: schema.Meta.String(), Key: name, Detail: fmt.Sprintf("malformed %s revision", name)})
+				continue
+			}
+			compacted[i] = int64(binary.BigEndian.Uint64(v))
+		}
+		if scheduled, finished := compacted[0], compacted[1]; finished > scheduled {
+			issues = append(issues, Issue{
+				Kind:   IssueCompaction,
+				Detail: fmt.Sprintf("finished compaction at %d beyond the scheduled one at %d", finished, scheduled),
+			})
+		}
+	}
+	return issues
+}
+
+func checkAuth(tx *bolt.Tx) []Issue {
+	var issues []Issue
+	roles := make(map[string]bool)
+	if rb := tx.Bucket(schema.AuthRoles.Name()); rb != nil {
+		rb.ForEach(func(k, _ []byte) error {
+			roles[string(k)] = true
+			return nil
+		})
+	}
+	var root bool
+	if ub := tx.Bucket(schema.AuthUsers.Name()); ub != nil {
+		ub.ForEach(func(k, v []byte) error {
+			var u authpb.User
+			if err := u.Unmarshal(v); err != nil {
+				issues = append(issues, Issue{Kind: IssueAuth, Bucket: schema.AuthUsers.String(), Key: append([]byte(nil), k...), Detail: fmt.Sprintf("failed to unmarshal user: %v", err)})
+				return nil
+			}
+			for _, r := range u.Roles {
+				// the root role is not stored in the auth roles bucket
+				if r != "root" && !roles[r] {
+					issues = append(issues, Issue{
+						Kind:   IssueDanglingRole,
+						Bucket: schema.AuthUsers.String(),
+						Key:    append([]byte(nil), k...),
+						Role:   r,
+						Detail: fmt.Sprintf("user %q granted missing role %q", u.Name, r),
+					})
+				}
+			}
+			root = root || string(u.Name) == "root"
+			return nil
+		})
+	}
+	if ab := tx.Bucket(schema.Auth.Name()); ab != nil {
+		if v := ab.Get(schema.AuthEnabledKeyName); len(v) == 1 && v[0] == 1 && !root {
+			issues = append(issues, Issue{Kind: IssueAuth, Detail: "auth enabled without a root user"})
+		}
+	}
+	return issues
+}
+
+// RepairBackend repairs the repairable issues found by CheckBackend in the
+// backend of the given data directory, which must not be in use by etcd:
+//
+//   - on an IssuePage, the backend is rebuilt from the key-values that can
+//     still be read, the original file being kept with a ".corrupted" suffix.
+//     The key-values of a bucket from its first corrupted page are lost.
+//   - on an IssueOrphanedLease, the key is detached from the lease.
+//   - on an IssueDanglingRole, the role is revoked from the user.
+//
+// The issues are checked again after a rebuild, the other repairs being done
+// on the rebuilt backend. RepairBackend returns the issues left unrepaired.
+func RepairBackend(lg *zap.Logger, dataDir string, issues []Issue) ([]Issue, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	path := datadir.ToBackendFileName(dataDir)
+	if hasPageIssue(issues) {
+		if err := rebuildBackend(lg, path); err != nil {
+			return issues, err
+		}
+		var err error
+		if issues, err = CheckBackend(lg, dataDir); err != nil {
+			return issues, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return issues, fmt.Errorf("failed to open backend %q: %w", path, err)
+	}
+	defer db.Close()
+
+	var left []Issue
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, i := range issues {
+			var err error
+			switch i.Kind {
+			case IssueOrphanedLease:
+				err = detachLease(tx, i.Key)
+			case IssueDanglingRole:
+				err = revokeRole(tx, i.Key, i.Role)
+			default:
+				left = append(left, i)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			lg.Info("repaired backend issue", zap.String("issue", i.String()))
+		}
+		return nil
+	})
+	if err != nil {
+		return issues, err
+	}
+	return left, nil
+}
+
+func detachLease(tx *bolt.Tx, rev []byte) error {
+	kb := tx.Bucket(schema.Key.Name())
+	var kv mvccpb.KeyValue
+	if err := kv.Unmarshal(kb.Get(rev)); err != nil {
+		return err
+	}
+	kv.Lease = 0
+	v, err := kv.Marshal()
+	if err != nil {
+		return err
+	}
+	return kb.Put(rev, v)
+}
+
+func revokeRole(tx *bolt.Tx, name []byte, role string) error {
+	ub := tx.Bucket(schema.AuthUsers.Name())
+	var u authpb.User
+	if err := u.Unmarshal(ub.Get(name)); err != nil {
+		return err
+	}
+	roles := u.Roles[:0]
+	for _, r := range u.Roles {
+		if r != role {
+			roles = append(roles, r)
+		}
+	}
+	u.Roles = roles
+	v, err := u.Marshal()
+	if err != nil {
+		return err
+	}
+	return ub.Put(name, v)
+}
+
+// rebuildBackend copies the key-values that can still be read from the
+// backend at path into a new file replacing it.
+func rebuildBackend(lg *zap.Logger, path string) error {
+	src, err := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true, Timeout: openTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open backend %q: %w", path, err)
+	}
+	defer src.Close()
+
+	tmpPath := path + ".rebuild"
+	os.Remove(tmpPath)
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return err
+	}
+
+	err = src.View(func(stx *bolt.Tx) error {
+		return dst.Update(func(dtx *bolt.Tx) error {
+			names, err := bucketNames(stx)
+			if err != nil {
+				lg.Warn("lost buckets of corrupted backend pages", zap.Int("copied", len(names)), zap.Error(err))
+			}
+			for _, name := range names {
+				b, err := dtx.CreateBucketIfNotExists(name)
+				if err != nil {
+					return err
+				}
+				copied, err := copyBucket(stx.Bucket(name), b)
+				if err != nil {
+					lg.Warn("lost key-values of corrupted backend pages",
+						zap.String("bucket", string(name)),
+						zap.Int("copied", copied),
+						zap.Error(err),
+					)
+				}
+			}
+			return nil
+		})
+	})
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(path, path+".corrupted"); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// copyBucket copies the key-values of src to dst until reading src fails on
+// a corrupted page.
+func copyBucket(src, dst *bolt.Bucket) (copied int, err error) {
+	var putErr error
+	err = readPages(func() {
+		c := src.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v == nil {
+				// etcd has no nested buckets
+				continue
+			}
+			if putErr = dst.Put(append([]byte(nil), k...), append([]byte(nil), v...)); putErr != nil {
+				return
+			}
+			copied++
+		}
+	})
+	if putErr != nil {
+		return copied, putErr
+	}
+	return copied, err
+}
+
+// bucketNames returns the names of the buckets, up to the first corrupted
+// page.
+func bucketNames(tx *bolt.Tx) (names [][]byte, err error) {
+	err = readPages(func() {
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			return nil
+		})
+	})
+	return names, err
+}
+
+// readPages calls fn, turning into an error the panics of bbolt reading a
+// corrupted page, and the faults reading a truncated one.
+func readPages(fn func()) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	fn()
+	return nil
+}
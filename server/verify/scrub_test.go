@@ -0,0 +1,203 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap/zaptest"
+)
+
+func revBytes(main int64, tombstone bool) []byte {
+	b := make([]byte, revBytesLen, revBytesLen+1)
+	binary.BigEndian.PutUint64(b, uint64(main))
+	b[8] = '_'
+	if tombstone {
+		b = append(b, markTombstone)
+	}
+	return b
+}
+
+// createBackend creates the backend of a data directory holding the given
+// key-values, at revision 1 and on, and the given leases, roles and users.
+func createBackend(t *testing.T, kvs []mvccpb.KeyValue, leases []int64, roles []string, users []authpb.User) string {
+	dataDir := t.TempDir()
+	path := datadir.ToBackendFileName(dataDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bs := make(map[string]*bolt.Bucket)
+		for _, b := range []string{schema.Meta.String(), schema.Key.String(), schema.Lease.String(), schema.AuthRoles.String(), schema.AuthUsers.String()} {
+			if bs[b], err = tx.CreateBucket([]byte(b)); err != nil {
+				return err
+			}
+		}
+		ci := make([]byte, 8)
+		binary.BigEndian.PutUint64(ci, 10)
+		bs[schema.Meta.String()].Put(schema.MetaConsistentIndexKeyName, ci)
+		for i, kv := range kvs {
+			tombstone := kv.ModRevision == 0
+			if !tombstone {
+				kv.ModRevision = int64(i + 1)
+			}
+			v, _ := kv.Marshal()
+			bs[schema.Key.String()].Put(revBytes(int64(i+1), tombstone), v)
+		}
+		for _, l := range leases {
+			id := make([]byte, 8)
+			binary.BigEndian.PutUint64(id, uint64(l))
+			bs[schema.Lease.String()].Put(id, []byte{})
+		}
+		for _, r := range roles {
+			v, _ := (&authpb.Role{Name: []byte(r)}).Marshal()
+			bs[schema.AuthRoles.String()].Put([]byte(r), v)
+		}
+		for _, u := range users {
+			v, _ := u.Marshal()
+			bs[schema.AuthUsers.String()].Put(u.Name, v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dataDir
+}
+
+func TestCheckRepairBackend(t *testing.T) {
+	kvs := []mvccpb.KeyValue{
+		{Key: []byte("a"), Lease: 1, ModRevision: 1},
+		{Key: []byte("b"), Lease: 2, ModRevision: 1},
+		{Key: []byte("c"), Lease: 3, ModRevision: 1},
+		// c is deleted, its orphaned lease not mattering anymore
+		{Key: []byte("c")},
+		{Key: []byte("d"), Lease: 4, ModRevision: 1},
+		// d is detached from its orphaned lease
+		{Key: []byte("d"), ModRevision: 1},
+	}
+	users := []authpb.User{
+		{Name: []byte("root"), Roles: []string{"root"}},
+		{Name: []byte("u"), Roles: []string{"r1", "r2"}},
+	}
+	dataDir := createBackend(t, kvs, []int64{1}, []string{"r1"}, users)
+
+	issues, err := CheckBackend(zaptest.NewLogger(t), dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, fmt.Sprintf("%s %d %s", i.Kind, i.Lease, i.Role))
+	}
+	want := []string{"orphaned-lease 2 ", "dangling-role 0 r2"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("issues = %q, want %q", got, want)
+	}
+
+	left, err := RepairBackend(zaptest.NewLogger(t), dataDir, issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("left issues = %v, want none", left)
+	}
+	if issues, err = CheckBackend(zaptest.NewLogger(t), dataDir); err != nil || len(issues) != 0 {
+		t.Errorf("issues after repair = %v, %v, want none", issues, err)
+	}
+}
+
+func TestCheckRepairBackendCorruptedPage(t *testing.T) {
+	var kvs []mvccpb.KeyValue
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, mvccpb.KeyValue{Key: []byte(fmt.Sprintf("key-%04d", i)), Value: make([]byte, 64), ModRevision: 1})
+	}
+	dataDir := createBackend(t, kvs, nil, nil, nil)
+	path := datadir.ToBackendFileName(dataDir)
+
+	// corrupt the type of the last leaf page
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaf, pageSize int
+	db.View(func(tx *bolt.Tx) error {
+		pageSize = tx.DB().Info().PageSize
+		for id := 2; id < int(tx.Size())/pageSize; id++ {
+			if p, err := tx.Page(id); err == nil && p.Type == "leaf" && p.Count > 10 {
+				leaf = id
+			}
+		}
+		return nil
+	})
+	db.Close()
+	if leaf == 0 {
+		t.Fatal("no leaf page found")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the flags of the page follow its id, turned into the ones of a freelist
+	if _, err = f.WriteAt([]byte{0x10, 0x00}, int64(leaf*pageSize+8)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	issues, err := CheckBackend(zaptest.NewLogger(t), dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPageIssue(issues) {
+		t.Fatalf("issues = %v, want page issues", issues)
+	}
+
+	left, err := RepairBackend(zaptest.NewLogger(t), dataDir, issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("left issues = %v, want none", left)
+	}
+	if _, err = os.Stat(path + ".corrupted"); err != nil {
+		t.Errorf("corrupted backend not kept: %v", err)
+	}
+	db, err = bolt.Open(path, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(schema.Key.Name()).Stats().KeyN; n == 0 || n >= len(kvs) {
+			t.Errorf("rebuilt backend holds %d keys, want the keys of the uncorrupted pages", n)
+		}
+		return nil
+	})
+}
@@ -83,7 +83,8 @@ func Verify(cfg Config) error {
 	// TODO: Perform validation of consistency of membership between
 	// backend/members & WAL confstate (and maybe storev2 if still exists).
 
-	return validateConsistentIndex(cfg, hardstate, snapshot, be)
+	index, term := schema.ReadConsistentIndex(be.ReadTx())
+	return validateConsistentIndex(cfg, hardstate, snapshot, index, term)
 }
 
 // VerifyIfEnabled performs verification according to ETCD_VERIFY env settings.
@@ -106,8 +107,7 @@ func MustVerifyIfEnabled(cfg Config) {
 	}
 }
 
-func validateConsistentIndex(cfg Config, hardstate *raftpb.HardState, snapshot *walpb.Snapshot, be backend.Backend) error {
-	index, term := schema.ReadConsistentIndex(be.ReadTx())
+func validateConsistentIndex(cfg Config, hardstate *raftpb.HardState, snapshot *walpb.Snapshot, index, term uint64) error {
 	if cfg.ExactIndex && index != hardstate.Commit {
 		return fmt.Errorf("backend.ConsistentIndex (%v) expected == WAL.HardState.commit (%v)", index, hardstate.Commit)
 	}
@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestAuthTokens(t *testing.T) {
+	testRunner.BeforeTest(t)
+	const ttl = 2 * time.Second
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+		// refreshed tells whether using a token extends its lifetime.
+		refreshed bool
+	}{
+		{
+			name:      "Simple",
+			config:    config.ClusterConfig{ClusterSize: 1, AuthTokenType: config.SimpleToken, AuthTokenTTL: ttl},
+			refreshed: true,
+		},
+		{
+			name:   "JWT",
+			config: config.ClusterConfig{ClusterSize: 1, AuthTokenType: config.JWTToken, AuthTokenTTL: ttl},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+			gc := clus.Gateway()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				setupAuthUsers(t, cc)
+				require.NoError(t, cc.AuthEnable())
+
+				token := authenticate(t, gc, "barb", "rhubarb")
+				if tc.config.AuthTokenType == config.JWTToken {
+					assert.Len(t, strings.Split(token, "."), 3, "expected a JWT, got %q", token)
+				}
+				require.NoError(t, gatewayPut(gc, token))
+				assert.Error(t, gatewayPut(gc, "invalid-token"), "expected put with an invalid token to fail")
+
+				if tc.refreshed {
+					// each use pushes the expiry of the token back by ttl
+					for i := 0; i < 3; i++ {
+						time.Sleep(ttl / 2)
+						require.NoError(t, gatewayPut(gc, token), "expected token used within its ttl to stay valid")
+					}
+				}
+				// tokens expire with a resolution of a second
+				time.Sleep(ttl + 2*time.Second)
+				assert.Error(t, gatewayPut(gc, token), "expected put with an expired token to fail")
+
+				// authenticating again issues a fresh token
+				token = authenticate(t, gc, "barb", "rhubarb")
+				require.NoError(t, gatewayPut(gc, token))
+				// the gRPC client renews its token on its own
+				require.NoError(t, loginAndPut(clus, "barb", "rhubarb"))
+			})
+		})
+	}
+}
+
+func authenticate(t *testing.T, gc framework.GatewayClient, user, password string) string {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, gc.Post("/v3/auth/authenticate", "", &pb.AuthenticateRequest{Name: user, Password: password}, &resp))
+	require.NotEmpty(t, resp.Token)
+	return resp.Token
+}
+
+func gatewayPut(gc framework.GatewayClient, token string) error {
+	var resp gatewayPutResponse
+	return gc.Post("/v3/kv/put", token, &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}, &resp)
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+func TestChaos(t *testing.T) {
+	if testing.Short() {
+		t.Skip("injects faults for 10s, skipped in short mode")
+	}
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3})
+	defer clus.Close()
+
+	faults := []framework.Fault{framework.FaultStopMember}
+	if testRunner.Supports(framework.SupportsNetworkPartition) {
+		faults = append(faults, framework.FaultPartitionMember)
+	}
+	framework.RunChaos(t, clus, framework.ChaosConfig{
+		Duration: 10 * time.Second,
+		Faults:   faults,
+	})
+	require.NoError(t, clus.VerifyLinearizability())
+}
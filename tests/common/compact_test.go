@@ -15,11 +15,14 @@
 package common
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
@@ -41,7 +44,9 @@ func TestCompact(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3})
 			defer clus.Close()
 			testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
@@ -85,3 +90,58 @@ func TestCompact(t *testing.T) {
 		})
 	}
 }
+
+func TestCompactUnderLoad(t *testing.T) {
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3})
+	defer clus.Close()
+	cc := clus.Client()
+	workload := testutils.Workload{KeyCount: 20, Writes: 200, ValueSize: 4096, Payload: testutils.CompressiblePayload, Rate: 200, Concurrency: 4}
+
+	testutils.ExecuteWithTimeout(t, 60*time.Second, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- workload.Run(ctx, cc)
+		}()
+
+		compactions := 0
+		for done := false; !done; {
+			select {
+			case err := <-errc:
+				require.NoError(t, err)
+				done = true
+			case <-time.After(100 * time.Millisecond):
+			}
+			resp, err := cc.Get(workload.Key(0), config.GetOptions{})
+			require.NoError(t, err)
+			if _, err = cc.Compact(resp.Header.Revision, config.CompactOption{Physical: true}); err != nil && !strings.Contains(err.Error(), "required revision has been compacted") {
+				t.Fatalf("compaction at revision %d failed: %v", resp.Header.Revision, err)
+			}
+			compactions++
+		}
+		t.Logf("compacted %d times while writing", compactions)
+
+		resp, err := cc.Get("workload/", config.GetOptions{Prefix: true})
+		require.NoError(t, err)
+		assert.Len(t, resp.Kvs, workload.KeyCount)
+		// all members agree on the compacted store once they applied all writes
+		var hashes []*clientv3.HashKVResponse
+		require.Eventually(t, func() bool {
+			hashes, err = cc.HashKV(0)
+			if err != nil || len(hashes) != 3 {
+				return false
+			}
+			for _, h := range hashes {
+				if h.Header.Revision != resp.Header.Revision {
+					return false
+				}
+			}
+			return true
+		}, 10*time.Second, 100*time.Millisecond, "members did not reach revision %d", resp.Header.Revision)
+		for _, h := range hashes[1:] {
+			assert.Equal(t, hashes[0].Hash, h.Hash, "members disagree on the hash at revision %d", resp.Header.Revision)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestPeriodicCorruptCheck(t *testing.T) {
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3, CorruptCheckTime: time.Second})
+	defer clus.Close()
+	cc := clus.Client()
+
+	testutils.ExecuteWithTimeout(t, 60*time.Second, func() {
+		for i := 0; i < 10; i++ {
+			if err := cc.Put(fmt.Sprintf("foo%05d", i), fmt.Sprintf("v%05d", i), config.PutOptions{}); err != nil {
+				t.Fatalf("could not put key, err: %s", err)
+			}
+		}
+		// make sure the member has applied all the writes before corrupting it
+		m := clus.Members()[1]
+		if _, err := m.Client().Get("foo", config.GetOptions{Prefix: true}); err != nil {
+			t.Fatalf("could not get keys from member, err: %s", err)
+		}
+		m.Stop()
+		if err := m.CorruptBBolt(); err != nil {
+			t.Fatalf("could not corrupt backend, err: %s", err)
+		}
+		if err := m.Start(); err != nil {
+			t.Fatalf("could not restart member, err: %s", err)
+		}
+
+		for {
+			resp, err := cc.AlarmList()
+			if err != nil {
+				t.Fatalf("could not list alarms, err: %s", err)
+			}
+			for _, a := range resp.Alarms {
+				if a.Alarm == etcdserverpb.AlarmType_CORRUPT {
+					return
+				}
+			}
+			time.Sleep(time.Second)
+		}
+	})
+}
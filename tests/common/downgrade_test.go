@@ -0,0 +1,62 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestDowngradeValidateEnableCancel(t *testing.T) {
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3})
+	defer clus.Close()
+	cc := clus.Client()
+
+	currentVersion := semver.New(version.Version)
+	currentVersionStr := fmt.Sprintf("%d.%d", currentVersion.Major, currentVersion.Minor)
+	lastVersionStr := fmt.Sprintf("%d.%d", currentVersion.Major, currentVersion.Minor-1)
+	tooOldVersionStr := fmt.Sprintf("%d.%d", currentVersion.Major, currentVersion.Minor-2)
+
+	testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+		resp, err := cc.DowngradeValidate(lastVersionStr)
+		require.NoError(t, err)
+		assert.Equal(t, currentVersionStr, resp.Version)
+
+		_, err = cc.DowngradeValidate(tooOldVersionStr)
+		assert.Error(t, err, "expected downgrade by more than one minor version to be rejected")
+		_, err = cc.DowngradeValidate(currentVersionStr)
+		assert.Error(t, err, "expected downgrade to the current version to be rejected")
+
+		resp, err = cc.DowngradeEnable(lastVersionStr)
+		require.NoError(t, err)
+		assert.Equal(t, currentVersionStr, resp.Version)
+		_, err = cc.DowngradeValidate(lastVersionStr)
+		assert.Error(t, err, "expected validation to fail while a downgrade is in progress")
+		_, err = cc.DowngradeEnable(lastVersionStr)
+		assert.Error(t, err, "expected a second downgrade to be rejected")
+
+		_, err = cc.DowngradeCancel()
+		require.NoError(t, err)
+	})
+}
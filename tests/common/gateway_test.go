@@ -0,0 +1,271 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+// The gateway types mirror the JSON produced by the gRPC gateway. Bytes are
+// base64 strings and 64-bit integers are declared as strings, so decoding
+// fails if the gateway stops quoting them. Zero values are omitted by the
+// gateway and decode to "".
+type gatewayHeader struct {
+	ClusterID string `json:"cluster_id"`
+	MemberID  string `json:"member_id"`
+	Revision  string `json:"revision"`
+	RaftTerm  string `json:"raft_term"`
+}
+
+type gatewayKeyValue struct {
+	Key            string `json:"key"`
+	Value          string `json:"value"`
+	CreateRevision string `json:"create_revision"`
+	ModRevision    string `json:"mod_revision"`
+	Version        string `json:"version"`
+	Lease          string `json:"lease"`
+}
+
+type gatewayRangeResponse struct {
+	Header gatewayHeader     `json:"header"`
+	Kvs    []gatewayKeyValue `json:"kvs"`
+	Count  string            `json:"count"`
+}
+
+type gatewayPutResponse struct {
+	Header gatewayHeader    `json:"header"`
+	PrevKv *gatewayKeyValue `json:"prev_kv"`
+}
+
+type gatewayTxnResponse struct {
+	Header    gatewayHeader `json:"header"`
+	Succeeded bool          `json:"succeeded"`
+	Responses []struct {
+		ResponseRange *gatewayRangeResponse `json:"response_range"`
+		ResponsePut   *gatewayPutResponse   `json:"response_put"`
+	} `json:"responses"`
+}
+
+type gatewayPermission struct {
+	PermType string `json:"permType"`
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end"`
+}
+
+func TestGatewayKV(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.AutoTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+			gc := clus.Gateway()
+
+			testutils.ExecuteWithTimeout(t, 20*time.Second, func() {
+				require.NoError(t, cc.Put("foo", "bar", config.PutOptions{}))
+				getResp, err := cc.Get("foo", config.GetOptions{})
+				require.NoError(t, err)
+
+				var rangeResp gatewayRangeResponse
+				require.NoError(t, gc.Post("/v3/kv/range", "", &pb.RangeRequest{Key: []byte("foo")}, &rangeResp))
+				assert.Equal(t, toGatewayHeader(getResp.Header), rangeResp.Header)
+				assert.Equal(t, toGatewayKVs(getResp.Kvs), rangeResp.Kvs)
+				assert.Equal(t, "1", rangeResp.Count)
+
+				var putResp gatewayPutResponse
+				require.NoError(t, gc.Post("/v3/kv/put", "", &pb.PutRequest{Key: []byte("foo"), Value: []byte("baz"), PrevKv: true}, &putResp))
+				require.NotNil(t, putResp.PrevKv)
+				assert.Equal(t, toGatewayKV(getResp.Kvs[0]), *putResp.PrevKv)
+				getResp, err = cc.Get("foo", config.GetOptions{})
+				require.NoError(t, err)
+				require.Len(t, getResp.Kvs, 1)
+				assert.Equal(t, "baz", string(getResp.Kvs[0].Value))
+				assert.Equal(t, toGatewayHeader(getResp.Header), putResp.Header)
+
+				// Compare values are bytes and compare versions are 64-bit
+				// integers, both must be accepted in their JSON encoding.
+				var txnResp gatewayTxnResponse
+				require.NoError(t, gc.Post("/v3/kv/txn", "", map[string]interface{}{
+					"compare": []map[string]interface{}{
+						{"key": gatewayBytes("foo"), "target": "VALUE", "result": "EQUAL", "value": gatewayBytes("baz")},
+						{"key": gatewayBytes("foo"), "target": "VERSION", "result": "EQUAL", "version": "2"},
+					},
+					"success": []map[string]interface{}{
+						{"request_range": map[string]interface{}{"key": gatewayBytes("foo")}},
+					},
+				}, &txnResp))
+				grpcTxnResp, err := cc.Txn([]config.TxnCompare{config.Cmp().Value("foo").Equals("baz"), config.Cmp().Version("foo").Equals(2)}, []config.TxnOp{config.Op.Get("foo")}, nil, config.TxnOptions{Interactive: true})
+				require.NoError(t, err)
+				assert.Equal(t, grpcTxnResp.Succeeded, txnResp.Succeeded)
+				assert.Equal(t, toGatewayHeader(grpcTxnResp.Header), txnResp.Header)
+				require.Len(t, txnResp.Responses, 1)
+				require.NotNil(t, txnResp.Responses[0].ResponseRange)
+				assert.Equal(t, toGatewayKVs(grpcTxnResp.Responses[0].GetResponseRange().Kvs), txnResp.Responses[0].ResponseRange.Kvs)
+
+				txnResp = gatewayTxnResponse{}
+				require.NoError(t, gc.Post("/v3/kv/txn", "", map[string]interface{}{
+					"compare": []map[string]interface{}{
+						{"key": gatewayBytes("foo"), "target": "VERSION", "result": "GREATER", "version": "5"},
+					},
+					"failure": []map[string]interface{}{
+						{"request_put": map[string]interface{}{"key": gatewayBytes("txn"), "value": gatewayBytes("fail")}},
+					},
+				}, &txnResp))
+				assert.False(t, txnResp.Succeeded)
+				require.Len(t, txnResp.Responses, 1)
+				require.NotNil(t, txnResp.Responses[0].ResponsePut)
+				getResp, err = cc.Get("txn", config.GetOptions{})
+				require.NoError(t, err)
+				require.Len(t, getResp.Kvs, 1)
+				assert.Equal(t, "fail", string(getResp.Kvs[0].Value))
+				assert.Equal(t, txnResp.Header.Revision, gatewayInt(getResp.Kvs[0].ModRevision))
+			})
+		})
+	}
+}
+
+func TestGatewayAuth(t *testing.T) {
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 1})
+	defer clus.Close()
+	cc := clus.Client()
+	gc := clus.Gateway()
+
+	testutils.ExecuteWithTimeout(t, 20*time.Second, func() {
+		var resp struct{}
+		require.NoError(t, gc.Post("/v3/auth/user/add", "", &pb.AuthUserAddRequest{Name: "root", Password: "rootpass"}, &resp))
+		require.NoError(t, gc.Post("/v3/auth/user/grant", "", &pb.AuthUserGrantRoleRequest{User: "root", Role: "root"}, &resp))
+		require.NoError(t, gc.Post("/v3/auth/user/add", "", &pb.AuthUserAddRequest{Name: "user", Password: "userpass"}, &resp))
+		require.NoError(t, gc.Post("/v3/auth/role/add", "", &pb.AuthRoleAddRequest{Name: "role"}, &resp))
+		require.NoError(t, gc.Post("/v3/auth/role/grant", "", &pb.AuthRoleGrantPermissionRequest{
+			Name: "role",
+			Perm: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("foo"), RangeEnd: []byte("fop")},
+		}, &resp))
+		require.NoError(t, gc.Post("/v3/auth/user/grant", "", &pb.AuthUserGrantRoleRequest{User: "user", Role: "role"}, &resp))
+
+		userList, err := cc.UserList()
+		require.NoError(t, err)
+		var userListResp struct {
+			Users []string `json:"users"`
+		}
+		require.NoError(t, gc.Post("/v3/auth/user/list", "", &pb.AuthUserListRequest{}, &userListResp))
+		assert.Equal(t, userList.Users, userListResp.Users)
+
+		roleGet, err := cc.RoleGet("role")
+		require.NoError(t, err)
+		var roleGetResp struct {
+			Perm []gatewayPermission `json:"perm"`
+		}
+		require.NoError(t, gc.Post("/v3/auth/role/get", "", &pb.AuthRoleGetRequest{Role: "role"}, &roleGetResp))
+		var perms []gatewayPermission
+		for _, p := range roleGet.Perm {
+			perms = append(perms, gatewayPermission{
+				PermType: p.PermType.String(),
+				Key:      base64.StdEncoding.EncodeToString(p.Key),
+				RangeEnd: base64.StdEncoding.EncodeToString(p.RangeEnd),
+			})
+		}
+		assert.Equal(t, perms, roleGetResp.Perm)
+
+		require.NoError(t, gc.Post("/v3/auth/enable", "", &pb.AuthEnableRequest{}, &resp))
+		var authResp struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, gc.Post("/v3/auth/authenticate", "", &pb.AuthenticateRequest{Name: "user", Password: "userpass"}, &authResp))
+		require.NotEmpty(t, authResp.Token)
+
+		var putResp gatewayPutResponse
+		require.NoError(t, gc.Post("/v3/kv/put", authResp.Token, &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}, &putResp))
+		assert.Error(t, gc.Post("/v3/kv/put", authResp.Token, &pb.PutRequest{Key: []byte("bar"), Value: []byte("foo")}, &putResp), "expected put outside of the permitted range to fail")
+		assert.Error(t, gc.Post("/v3/kv/put", "", &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}, &putResp), "expected put without a token to fail")
+		assert.Error(t, cc.Put("foo", "bar", config.PutOptions{}), "expected gRPC put without credentials to fail")
+	})
+}
+
+func gatewayBytes(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func gatewayInt(i int64) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.FormatInt(i, 10)
+}
+
+func gatewayUint(i uint64) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.FormatUint(i, 10)
+}
+
+func toGatewayHeader(h *pb.ResponseHeader) gatewayHeader {
+	return gatewayHeader{
+		ClusterID: gatewayUint(h.ClusterId),
+		MemberID:  gatewayUint(h.MemberId),
+		Revision:  gatewayInt(h.Revision),
+		RaftTerm:  gatewayUint(h.RaftTerm),
+	}
+}
+
+func toGatewayKV(kv *mvccpb.KeyValue) gatewayKeyValue {
+	return gatewayKeyValue{
+		Key:            base64.StdEncoding.EncodeToString(kv.Key),
+		Value:          base64.StdEncoding.EncodeToString(kv.Value),
+		CreateRevision: gatewayInt(kv.CreateRevision),
+		ModRevision:    gatewayInt(kv.ModRevision),
+		Version:        gatewayInt(kv.Version),
+		Lease:          gatewayInt(kv.Lease),
+	}
+}
+
+func toGatewayKVs(kvs []*mvccpb.KeyValue) []gatewayKeyValue {
+	var gkvs []gatewayKeyValue
+	for _, kv := range kvs {
+		gkvs = append(gkvs, toGatewayKV(kv))
+	}
+	return gkvs
+}
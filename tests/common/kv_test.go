@@ -20,6 +20,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
@@ -50,12 +51,42 @@ func TestKVPut(t *testing.T) {
 			name:   "ClientAutoTLS",
 			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.AutoTLS},
 		},
+		{
+			name:   "IPv6",
+			config: config.ClusterConfig{ClusterSize: 3, IPMode: config.IPv6},
+		},
+		{
+			name:   "IPv6PeerAndClientTLS",
+			config: config.ClusterConfig{ClusterSize: 3, IPMode: config.IPv6, PeerTLS: config.ManualTLS, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "IPv6ClientAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 1, IPMode: config.IPv6, ClientTLS: config.AutoTLS},
+		},
+		{
+			name:   "DualStack",
+			config: config.ClusterConfig{ClusterSize: 3, IPMode: config.DualStack},
+		},
+		{
+			name:   "DualStackPeerAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 3, IPMode: config.DualStack, PeerTLS: config.AutoTLS},
+		},
+		{
+			name:   "UnixSockets",
+			config: config.ClusterConfig{ClusterSize: 3, UnixSockets: true},
+		},
+		{
+			name:   "UnixSocketsPeerAndClientTLS",
+			config: config.ClusterConfig{ClusterSize: 3, UnixSockets: true, PeerTLS: config.ManualTLS, ClientTLS: config.ManualTLS},
+		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
-			cc := clus.Client()
+			cc := framework.RecordHistory(t, clus)
 
 			testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
 				key, value := "foo", "bar"
@@ -109,10 +140,12 @@ func TestKVGet(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
-			cc := clus.Client()
+			cc := framework.RecordHistory(t, clus)
 
 			testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
 				var (
@@ -132,7 +165,8 @@ func TestKVGet(t *testing.T) {
 					end     string
 					options config.GetOptions
 
-					wkv []string
+					wkv    []string
+					wcount int64
 				}{
 					{begin: "a", wkv: wantKvs[:1]},
 					{begin: "a", options: config.GetOptions{Serializable: true}, wkv: wantKvs[:1]},
@@ -141,7 +175,7 @@ func TestKVGet(t *testing.T) {
 					{begin: "", options: config.GetOptions{FromKey: true}, wkv: wantKvs},
 					{begin: "a", options: config.GetOptions{End: "x"}, wkv: wantKvs},
 					{begin: "", options: config.GetOptions{Prefix: true, Revision: 4}, wkv: kvs[:3]},
-					{begin: "a", options: config.GetOptions{CountOnly: true}, wkv: nil},
+					{begin: "a", options: config.GetOptions{CountOnly: true}, wkv: nil, wcount: 1},
 					{begin: "foo", options: config.GetOptions{Prefix: true}, wkv: []string{"foo", "foo/abc"}},
 					{begin: "foo", options: config.GetOptions{FromKey: true}, wkv: []string{"foo", "foo/abc", "fop"}},
 					{begin: "", options: config.GetOptions{Prefix: true, Limit: 2}, wkv: wantKvs[:2]},
@@ -158,6 +192,9 @@ func TestKVGet(t *testing.T) {
 					}
 					kvs := testutils.KeysFromGetResponse(resp)
 					assert.Equal(t, tt.wkv, kvs)
+					if tt.options.CountOnly {
+						assert.Equal(t, tt.wcount, resp.Count)
+					}
 				}
 			})
 		})
@@ -192,10 +229,12 @@ func TestKVDelete(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
-			cc := clus.Client()
+			cc := framework.RecordHistory(t, clus)
 			testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
 				kvs := []string{"a", "b", "c", "c/abc", "d"}
 				tests := []struct {
@@ -286,7 +325,9 @@ func TestKVGetNoQuorum(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 3})
 			defer clus.Close()
 
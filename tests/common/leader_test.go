@@ -0,0 +1,109 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestMoveLeader(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 3},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 60*time.Second, func() {
+				require.NoError(t, cc.Put("foo", "bar", config.PutOptions{}))
+				lead, err := clus.Leader()
+				require.NoError(t, err)
+				statuses, err := cc.Status()
+				require.NoError(t, err)
+				var target uint64
+				for _, s := range statuses {
+					assert.Equal(t, lead, s.Leader)
+					if s.Header.MemberId != lead {
+						target = s.Header.MemberId
+					}
+				}
+				require.NotZero(t, target)
+
+				// Linearizable reads in flight during the transfer must not
+				// fail nor go back in time.
+				stopc, donec := make(chan struct{}), make(chan struct{})
+				var (
+					revs    []int64
+					readErr error
+				)
+				go func() {
+					defer close(donec)
+					for {
+						select {
+						case <-stopc:
+							return
+						default:
+						}
+						resp, err := cc.Get("foo", config.GetOptions{})
+						if err != nil {
+							readErr = err
+							return
+						}
+						revs = append(revs, resp.Header.Revision)
+					}
+				}()
+
+				require.NoError(t, clus.MoveLeader(target))
+				close(stopc)
+				<-donec
+				require.NoError(t, readErr)
+				require.NotEmpty(t, revs)
+				for i := 1; i < len(revs); i++ {
+					assert.GreaterOrEqual(t, revs[i], revs[i-1], "read went back in time")
+				}
+
+				lead, err = clus.Leader()
+				require.NoError(t, err)
+				assert.Equal(t, target, lead)
+				require.NoError(t, cc.Put("foo", "baz", config.PutOptions{}))
+				resp, err := cc.Get("foo", config.GetOptions{})
+				require.NoError(t, err)
+				require.Len(t, resp.Kvs, 1)
+				assert.Equal(t, "baz", string(resp.Kvs[0].Value))
+			})
+		})
+	}
+}
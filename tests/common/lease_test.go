@@ -53,7 +53,9 @@ func TestLeaseGrantTimeToLive(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -100,6 +102,7 @@ func TestLeaseGrantAndList(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		nestedCases := []struct {
 			name       string
 			leaseCount int
@@ -119,7 +122,9 @@ func TestLeaseGrantAndList(t *testing.T) {
 		}
 
 		for _, nc := range nestedCases {
+			nc := nc
 			t.Run(tc.name+"/"+nc.name, func(t *testing.T) {
+				t.Parallel()
 				t.Logf("Creating cluster...")
 				clus := testRunner.NewCluster(t, tc.config)
 				defer clus.Close()
@@ -190,7 +195,9 @@ func TestLeaseGrantTimeToLiveExpired(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -206,16 +213,18 @@ func TestLeaseGrantTimeToLiveExpired(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, int64(1), getResp.Count)
 
-				time.Sleep(3 * time.Second)
+				clus.AdvanceTime(3 * time.Second)
 
 				ttlResp, err := cc.TimeToLive(leaseResp.ID, config.LeaseOption{})
 				require.NoError(t, err)
 				require.Equal(t, int64(-1), ttlResp.TTL)
 
-				getResp, err = cc.Get("foo", config.GetOptions{})
-				require.NoError(t, err)
-				// Value should expire with the lease
-				require.Equal(t, int64(0), getResp.Count)
+				// Value should expire with the lease, once the expired lease is revoked
+				require.Eventually(t, func() bool {
+					getResp, err = cc.Get("foo", config.GetOptions{})
+					require.NoError(t, err)
+					return getResp.Count == 0
+				}, 5*time.Second, 100*time.Millisecond)
 			})
 		})
 	}
@@ -250,7 +259,9 @@ func TestLeaseGrantKeepAliveOnce(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -262,7 +273,7 @@ func TestLeaseGrantKeepAliveOnce(t *testing.T) {
 				_, err = cc.LeaseKeepAliveOnce(leaseResp.ID)
 				require.NoError(t, err)
 
-				time.Sleep(2 * time.Second) // Wait for the original lease to expire
+				clus.AdvanceTime(2 * time.Second) // Wait for the original lease to expire
 
 				ttlResp, err := cc.TimeToLive(leaseResp.ID, config.LeaseOption{})
 				require.NoError(t, err)
@@ -302,7 +313,9 @@ func TestLeaseGrantRevoke(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -0,0 +1,86 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestScaleTo(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 1, PeerTLS: config.ManualTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+
+			testutils.ExecuteWithTimeout(t, 90*time.Second, func() {
+				require.NoError(t, clus.Client().Put("foo", "bar", config.PutOptions{}))
+				for _, size := range []int{3, 4, 2, 1} {
+					require.NoError(t, clus.ScaleTo(size))
+					require.Len(t, clus.Members(), size)
+					assertVotingMembers(t, clus, size)
+				}
+				// the cluster keeps serving writes once scaled in
+				require.NoError(t, clus.Client().Put("foo", "baz", config.PutOptions{}))
+				resp, err := clus.Client().Get("foo", config.GetOptions{})
+				require.NoError(t, err)
+				require.Len(t, resp.Kvs, 1)
+				assert.Equal(t, "baz", string(resp.Kvs[0].Value))
+			})
+		})
+	}
+}
+
+// assertVotingMembers checks that the size members of clus are voting
+// members agreeing on the leader, and serve the data written before.
+func assertVotingMembers(t *testing.T, clus framework.Cluster, size int) {
+	var lead uint64
+	for _, m := range clus.Members() {
+		statuses, err := m.Client().Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		assert.False(t, statuses[0].IsLearner, "member %x is a learner", statuses[0].Header.MemberId)
+		if lead == 0 {
+			lead = statuses[0].Leader
+		}
+		assert.Equal(t, lead, statuses[0].Leader, "members disagree on the leader of %d members", size)
+		resp, err := m.Client().Get("foo", config.GetOptions{Serializable: true})
+		require.NoError(t, err)
+		require.Len(t, resp.Kvs, 1)
+		assert.Equal(t, "bar", string(resp.Kvs[0].Value))
+	}
+}
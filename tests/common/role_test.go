@@ -53,7 +53,9 @@ func TestRoleAdd_Simple(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
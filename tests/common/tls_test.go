@@ -0,0 +1,88 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+// fixturesDir is resolved before tests change the working directory.
+var fixturesDir = integration.MustAbsPath("../fixtures")
+
+func TestRotateCerts(t *testing.T) {
+	testRunner.BeforeTest(t)
+	framework.RequireCapabilities(t, testRunner, framework.SupportsCertRotation)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientAndPeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, ClientTLS: config.ManualTLS, PeerTLS: config.ManualTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				require.NoError(t, cc.Put("foo", "bar", config.PutOptions{}))
+
+				// A certificate restricted to client authentication cannot
+				// be served, so clients fail as soon as members load it.
+				err := clus.RotateCerts(config.CertBundle{
+					CertFile: filepath.Join(fixturesDir, "client-clientusage.crt"),
+					KeyFile:  filepath.Join(fixturesDir, "client-clientusage.key.insecure"),
+				})
+				require.NoError(t, err)
+				if tc.config.ClientTLS == config.ManualTLS {
+					_, err = cc.Get("foo", config.GetOptions{Timeout: time.Second})
+					require.Error(t, err, "members should serve the rotated certificate")
+				}
+
+				err = clus.RotateCerts(config.CertBundle{
+					CertFile: filepath.Join(fixturesDir, "server2.crt"),
+					KeyFile:  filepath.Join(fixturesDir, "server2.key.insecure"),
+				})
+				require.NoError(t, err)
+				require.NoError(t, cc.Put("foo", "baz", config.PutOptions{}))
+				resp, err := cc.Get("foo", config.GetOptions{})
+				require.NoError(t, err)
+				require.Len(t, resp.Kvs, 1)
+				require.Equal(t, "baz", string(resp.Kvs[0].Value))
+			})
+		})
+	}
+}
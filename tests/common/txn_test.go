@@ -16,20 +16,27 @@ package common
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	serverconfig "go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/tests/v3/framework"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
 
 type txnReq struct {
-	compare  []string
-	ifSucess []string
-	ifFail   []string
+	compare  []config.TxnCompare
+	ifSucess []config.TxnOp
+	ifFail   []config.TxnOp
 	results  []string
 }
 
@@ -61,25 +68,27 @@ func TestTxnSucc(t *testing.T) {
 	}
 	reqs := []txnReq{
 		{
-			compare:  []string{`value("key1") != "value2"`, `value("key2") != "value1"`},
-			ifSucess: []string{"get key1", "get key2"},
+			compare:  []config.TxnCompare{config.Cmp().Value("key1").NotEquals("value2"), config.Cmp().Value("key2").NotEquals("value1")},
+			ifSucess: []config.TxnOp{config.Op.Get("key1"), config.Op.Get("key2")},
 			results:  []string{"SUCCESS", "key1", "value1", "key2", "value2"},
 		},
 		{
-			compare:  []string{`version("key1") = "1"`, `version("key2") = "1"`},
-			ifSucess: []string{"get key1", "get key2", `put "key \"with\" space" "value \x23"`},
-			ifFail:   []string{`put key1 "fail"`, `put key2 "fail"`},
+			compare:  []config.TxnCompare{config.Cmp().Version("key1").Equals(1), config.Cmp().Version("key2").Equals(1)},
+			ifSucess: []config.TxnOp{config.Op.Get("key1"), config.Op.Get("key2"), config.Op.Put(`key "with" space`, "value \x23")},
+			ifFail:   []config.TxnOp{config.Op.Put("key1", "fail"), config.Op.Put("key2", "fail")},
 			results:  []string{"SUCCESS", "key1", "value1", "key2", "value2", "OK"},
 		},
 		{
-			compare:  []string{`version("key \"with\" space") = "1"`},
-			ifSucess: []string{`get "key \"with\" space"`},
+			compare:  []config.TxnCompare{config.Cmp().Version(`key "with" space`).Equals(1)},
+			ifSucess: []config.TxnOp{config.Op.Get(`key "with" space`)},
 			results:  []string{"SUCCESS", `key "with" space`, "value \x23"},
 		},
 	}
 	testRunner.BeforeTest(t)
 	for _, cfg := range tcs {
+		cfg := cfg
 		t.Run(cfg.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, cfg.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -132,21 +141,23 @@ func TestTxnFail(t *testing.T) {
 	}
 	reqs := []txnReq{
 		{
-			compare:  []string{`version("key") < "0"`},
-			ifSucess: []string{`put key "success"`},
-			ifFail:   []string{`put key "fail"`},
+			compare:  []config.TxnCompare{config.Cmp().Version("key").Less(0)},
+			ifSucess: []config.TxnOp{config.Op.Put("key", "success")},
+			ifFail:   []config.TxnOp{config.Op.Put("key", "fail")},
 			results:  []string{"FAILURE", "OK"},
 		},
 		{
-			compare:  []string{`value("key1") != "value1"`},
-			ifSucess: []string{`put key1 "success"`},
-			ifFail:   []string{`put key1 "fail"`},
+			compare:  []config.TxnCompare{config.Cmp().Value("key1").NotEquals("value1")},
+			ifSucess: []config.TxnOp{config.Op.Put("key1", "success")},
+			ifFail:   []config.TxnOp{config.Op.Put("key1", "fail")},
 			results:  []string{"FAILURE", "OK"},
 		},
 	}
 	testRunner.BeforeTest(t)
 	for _, cfg := range tcs {
+		cfg := cfg
 		t.Run(cfg.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, cfg.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -168,6 +179,183 @@ func TestTxnFail(t *testing.T) {
 	}
 }
 
+func TestTxnMaxOps(t *testing.T) {
+	testRunner.BeforeTest(t)
+	// max-txn-ops has no dedicated cluster config field, it is set through
+	// the passthrough of both runners.
+	clus := testRunner.NewCluster(t, config.ClusterConfig{
+		ClusterSize: 1,
+		ExtraArgs:   map[string]string{"max-txn-ops": "2"},
+		ServerConfigMutator: func(cfg *serverconfig.ServerConfig) {
+			cfg.MaxTxnOps = 2
+		},
+	})
+	defer clus.Close()
+	cc := clus.Client()
+	testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
+		_, err := cc.Txn(nil, []config.TxnOp{config.Op.Put("key1", "value1"), config.Op.Put("key2", "value2")}, nil, config.TxnOptions{Interactive: true})
+		if err != nil {
+			t.Fatalf("Txn with 2 operations returned error: %s", err)
+		}
+		_, err = cc.Txn(nil, []config.TxnOp{config.Op.Put("key1", "value1"), config.Op.Put("key2", "value2"), config.Op.Put("key3", "value3")}, nil, config.TxnOptions{Interactive: true})
+		if err == nil || !strings.Contains(err.Error(), rpctypes.ErrTooManyOps.Error()) {
+			t.Fatalf("expected Txn with 3 operations to fail with %q, got %v", rpctypes.ErrTooManyOps, err)
+		}
+		// nested transactions are limited to the operations left by the
+		// transactions they are nested in
+		_, err = cc.Txn(nil, []config.TxnOp{
+			config.Op.Txn(nil, []config.TxnOp{config.Op.Put("key1", "value1")}, nil),
+		}, nil, config.TxnOptions{Interactive: true})
+		if err != nil {
+			t.Fatalf("Txn with a nested transaction of 1 operation returned error: %s", err)
+		}
+		_, err = cc.Txn(nil, []config.TxnOp{
+			config.Op.Txn(nil, []config.TxnOp{config.Op.Put("key1", "value1"), config.Op.Put("key2", "value2")}, nil),
+		}, nil, config.TxnOptions{Interactive: true})
+		if err == nil || !strings.Contains(err.Error(), rpctypes.ErrTooManyOps.Error()) {
+			t.Fatalf("expected Txn with a nested transaction of 2 operations to fail with %q, got %v", rpctypes.ErrTooManyOps, err)
+		}
+	})
+}
+
+// casTxn is a compare-and-swap transaction that incremented two counters.
+type casTxn struct {
+	revision int64
+	keys     [2]string
+	// old are the values of the counters the transaction compared against.
+	old [2]int
+}
+
+func TestTxnConcurrentCAS(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+		{
+			name:   "PeerAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.AutoTLS},
+		},
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.AutoTLS},
+		},
+	}
+	const (
+		clients  = 4
+		attempts = 10
+	)
+	keys := []string{"counter0", "counter1", "counter2"}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			testutils.ExecuteWithTimeout(t, time.Minute, func() {
+				var (
+					wg        sync.WaitGroup
+					mu        sync.Mutex
+					committed []casTxn
+				)
+				for i := 0; i < clients; i++ {
+					cc := framework.RecordHistory(t, clus)
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						for j := 0; j < attempts; j++ {
+							// every client increments overlapping pairs of counters
+							txn := casTxn{keys: [2]string{keys[(i+j)%len(keys)], keys[(i+j+1)%len(keys)]}}
+							var (
+								compares []config.TxnCompare
+								puts     []config.TxnOp
+							)
+							for k, key := range txn.keys {
+								resp, err := cc.Get(key, config.GetOptions{})
+								if err != nil {
+									t.Errorf("could not get key %q: %s", key, err)
+									return
+								}
+								var modRevision int64
+								if len(resp.Kvs) != 0 {
+									modRevision = resp.Kvs[0].ModRevision
+									if txn.old[k], err = strconv.Atoi(string(resp.Kvs[0].Value)); err != nil {
+										t.Errorf("unexpected value of key %q: %s", key, err)
+										return
+									}
+								}
+								compares = append(compares, config.Cmp().ModRevision(key).Equals(modRevision))
+								puts = append(puts, config.Op.Put(key, strconv.Itoa(txn.old[k]+1)))
+							}
+							resp, err := cc.Txn(compares, puts, nil, config.TxnOptions{Interactive: true})
+							if err != nil {
+								t.Errorf("Txn returned error: %s", err)
+								return
+							}
+							if resp.Succeeded {
+								txn.revision = resp.Header.Revision
+								mu.Lock()
+								committed = append(committed, txn)
+								mu.Unlock()
+							}
+						}
+					}(i)
+				}
+				wg.Wait()
+				if t.Failed() {
+					return
+				}
+				if len(committed) == 0 {
+					t.Fatal("expected some transactions to succeed")
+				}
+
+				// Replaying the committed transactions in the order of their
+				// revisions must meet the values each of them compared
+				// against, and end in the state of the cluster.
+				sort.Slice(committed, func(i, j int) bool { return committed[i].revision < committed[j].revision })
+				want := map[string]int{}
+				for i, txn := range committed {
+					if i > 0 && txn.revision == committed[i-1].revision {
+						t.Fatalf("expected transactions to commit at distinct revisions, got two at %d", txn.revision)
+					}
+					for k, key := range txn.keys {
+						if want[key] != txn.old[k] {
+							t.Fatalf("transaction committed at revision %d incremented %q from %d, expected %d", txn.revision, key, txn.old[k], want[key])
+						}
+						want[key]++
+					}
+				}
+				cc := clus.Client()
+				for _, key := range keys {
+					resp, err := cc.Get(key, config.GetOptions{})
+					if err != nil {
+						t.Fatalf("could not get key %q: %s", key, err)
+					}
+					got := 0
+					if len(resp.Kvs) != 0 {
+						got, _ = strconv.Atoi(string(resp.Kvs[0].Value))
+					}
+					if got != want[key] {
+						t.Errorf("expected %q to be %d after replaying %d transactions, got %d", key, want[key], len(committed), got)
+					}
+				}
+			})
+		})
+	}
+}
+
 func getRespValues(r *clientv3.TxnResponse) []string {
 	ss := []string{}
 	if r.Succeeded {
@@ -191,6 +379,8 @@ func getRespValues(r *clientv3.TxnResponse) []string {
 			for _, kv := range r.Kvs {
 				ss = append(ss, string(kv.Key), string(kv.Value))
 			}
+		case *pb.ResponseOp_ResponseTxn:
+			ss = append(ss, getRespValues((*clientv3.TxnResponse)(v.ResponseTxn))...)
 		default:
 			ss = append(ss, fmt.Sprintf("\"Unknown\" : %q\n", fmt.Sprintf("%+v", v)))
 		}
@@ -15,10 +15,16 @@
 package common
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/auth"
+	"go.etcd.io/etcd/tests/v3/framework"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
@@ -51,6 +57,7 @@ func TestUserAdd_Simple(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		nestedCases := []struct {
 			name          string
 			username      string
@@ -87,7 +94,9 @@ func TestUserAdd_Simple(t *testing.T) {
 			},
 		}
 		for _, nc := range nestedCases {
+			nc := nc
 			t.Run(tc.name+"/"+nc.name, func(t *testing.T) {
+				t.Parallel()
 				clus := testRunner.NewCluster(t, tc.config)
 				defer clus.Close()
 				cc := clus.Client()
@@ -144,7 +153,9 @@ func TestUserAdd_DuplicateUserNotAllowed(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -196,7 +207,9 @@ func TestUserList(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -260,7 +273,9 @@ func TestUserDelete(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -335,7 +350,9 @@ func TestUserChangePassword(t *testing.T) {
 		},
 	}
 	for _, tc := range tcs {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 			clus := testRunner.NewCluster(t, tc.config)
 			defer clus.Close()
 			cc := clus.Client()
@@ -364,3 +381,260 @@ func TestUserChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestUserGrantRevokeRole(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+		{
+			name:   "PeerAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.AutoTLS},
+		},
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.AutoTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 10*time.Second, func() {
+				if _, err := cc.UserAdd("barb", "rhubarb", config.UserAddOptions{}); err != nil {
+					t.Fatalf("user creation should succeed, err: %v", err)
+				}
+				if _, err := cc.RoleAdd("role1"); err != nil {
+					t.Fatalf("role creation should succeed, err: %v", err)
+				}
+
+				_, err := cc.UserGrantRole("barb", "role1")
+				if err != nil {
+					t.Fatalf("granting a role should succeed, err: %v", err)
+				}
+				resp, err := cc.UserGet("barb")
+				if err != nil {
+					t.Fatalf("getting the user should succeed, err: %v", err)
+				}
+				assert.Equal(t, []string{"role1"}, resp.Roles)
+
+				_, err = cc.UserGrantRole("barb", "non-existent-role")
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrRoleNotFound.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrRoleNotFound, err)
+				}
+				_, err = cc.UserGrantRole("non-existent-user", "role1")
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrUserNotFound.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrUserNotFound, err)
+				}
+
+				_, err = cc.UserRevokeRole("barb", "role1")
+				if err != nil {
+					t.Fatalf("revoking a role should succeed, err: %v", err)
+				}
+				resp, err = cc.UserGet("barb")
+				if err != nil {
+					t.Fatalf("getting the user should succeed, err: %v", err)
+				}
+				assert.Empty(t, resp.Roles)
+
+				_, err = cc.UserRevokeRole("barb", "role1")
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrRoleNotGranted.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrRoleNotGranted, err)
+				}
+			})
+		})
+	}
+}
+
+func TestUserLogin(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+		{
+			name:   "PeerAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.AutoTLS},
+		},
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientAutoTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.AutoTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				setupAuthUsers(t, cc)
+				if err := cc.AuthEnable(); err != nil {
+					t.Fatalf("enabling auth should succeed, err: %v", err)
+				}
+
+				if err := loginAndPut(clus, "root", "rootpass"); err != nil {
+					t.Fatalf("root login should succeed, err: %v", err)
+				}
+				if err := loginAndPut(clus, "barb", "rhubarb"); err != nil {
+					t.Fatalf("login with the right password should succeed, err: %v", err)
+				}
+				err := loginAndPut(clus, "barb", "potato")
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrAuthFailed.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrAuthFailed, err)
+				}
+				// Users added with --no-password can only authenticate with a
+				// client certificate.
+				err = loginAndPut(clus, "cn-only", "any")
+				if err == nil || !strings.Contains(err.Error(), auth.ErrNoPasswordUser.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", auth.ErrNoPasswordUser, err)
+				}
+				err = cc.Put("foo", "bar", config.PutOptions{})
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrUserEmpty.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrUserEmpty, err)
+				}
+			})
+		})
+	}
+}
+
+// setupAuthUsers adds the root user, barb who can write "foo" and cn-only who
+// has no password.
+func setupAuthUsers(t *testing.T, cc framework.Client) {
+	if _, err := cc.UserAdd("root", "rootpass", config.UserAddOptions{}); err != nil {
+		t.Fatalf("user creation should succeed, err: %v", err)
+	}
+	if _, err := cc.UserGrantRole("root", "root"); err != nil {
+		t.Fatalf("granting a role should succeed, err: %v", err)
+	}
+	if _, err := cc.RoleAdd("foo-writer"); err != nil {
+		t.Fatalf("role creation should succeed, err: %v", err)
+	}
+	if _, err := cc.RoleGrantPermission("foo-writer", "foo", "", clientv3.PermissionType(clientv3.PermWrite)); err != nil {
+		t.Fatalf("granting a permission should succeed, err: %v", err)
+	}
+	if _, err := cc.UserAdd("barb", "rhubarb", config.UserAddOptions{}); err != nil {
+		t.Fatalf("user creation should succeed, err: %v", err)
+	}
+	if _, err := cc.UserGrantRole("barb", "foo-writer"); err != nil {
+		t.Fatalf("granting a role should succeed, err: %v", err)
+	}
+	if _, err := cc.UserAdd("cn-only", "", config.UserAddOptions{NoPassword: true}); err != nil {
+		t.Fatalf("user creation should succeed, err: %v", err)
+	}
+}
+
+func loginAndPut(clus framework.Cluster, user, password string) error {
+	c, err := clus.AuthClient(user, password)
+	if err != nil {
+		return err
+	}
+	return c.Put("foo", "bar", config.PutOptions{})
+}
+
+func TestUserCertLogin(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS, ClientCertAuthEnabled: true},
+		},
+		{
+			name:   "ClientAndPeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, ClientTLS: config.ManualTLS, PeerTLS: config.ManualTLS, ClientCertAuthEnabled: true},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			// The default client presents a certificate with the CommonName
+			// example.com, which is made a root user so that it keeps
+			// access once auth is enabled.
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				setupAuthUsers(t, cc)
+				for user, role := range map[string]string{"example.com": "root", "example2.com": "foo-writer"} {
+					if _, err := cc.UserAdd(user, "", config.UserAddOptions{NoPassword: true}); err != nil {
+						t.Fatalf("user creation should succeed, err: %v", err)
+					}
+					if _, err := cc.UserGrantRole(user, role); err != nil {
+						t.Fatalf("granting a role should succeed, err: %v", err)
+					}
+				}
+				if err := cc.AuthEnable(); err != nil {
+					t.Fatalf("enabling auth should succeed, err: %v", err)
+				}
+				if err := cc.Put("foo", "bar", config.PutOptions{}); err != nil {
+					t.Fatalf("root login by certificate should succeed, err: %v", err)
+				}
+
+				c2, err := clus.CertClient(config.CertBundle{
+					CertFile: filepath.Join(fixturesDir, "server2.crt"),
+					KeyFile:  filepath.Join(fixturesDir, "server2.key.insecure"),
+				})
+				if err != nil {
+					t.Fatalf("creating a client should succeed, err: %v", err)
+				}
+				if err = c2.Put("foo", "baz", config.PutOptions{}); err != nil {
+					t.Fatalf("login by certificate should succeed, err: %v", err)
+				}
+				err = c2.Put("bar", "baz", config.PutOptions{})
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrPermissionDenied.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrPermissionDenied, err)
+				}
+
+				// A certificate without a CommonName names no user, so the
+				// requests it authenticates are denied.
+				c3, err := clus.CertClient(config.CertBundle{
+					CertFile: filepath.Join(fixturesDir, "client-nocn.crt"),
+					KeyFile:  filepath.Join(fixturesDir, "client-nocn.key.insecure"),
+				})
+				if err != nil {
+					t.Fatalf("creating a client should succeed, err: %v", err)
+				}
+				err = c3.Put("foo", "baz", config.PutOptions{})
+				if err == nil || !strings.Contains(err.Error(), rpctypes.ErrPermissionDenied.Error()) {
+					t.Fatalf("want error (%v), but got (%v)", rpctypes.ErrPermissionDenied, err)
+				}
+			})
+		})
+	}
+}
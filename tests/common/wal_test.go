@@ -0,0 +1,45 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+func TestWALFsyncLatency(t *testing.T) {
+	testRunner.BeforeTest(t)
+	framework.RequireCapabilities(t, testRunner, framework.SupportsFailpoints)
+	latency := 200 * time.Millisecond
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 1, WALFsyncLatency: latency})
+	defer clus.Close()
+	cc := clus.Client()
+
+	puts := 5
+	start := time.Now()
+	for i := 0; i < puts; i++ {
+		if err := cc.Put("foo", "bar", config.PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a single member may apply a put while its entry is synced, but raft
+	// hands over the entry of the next put once the sync is done
+	if took, least := time.Since(start), time.Duration(puts-1)*latency; took < least {
+		t.Errorf("expected %d puts to take at least %v with slow WAL fsyncs, took %v", puts, least, took)
+	}
+}
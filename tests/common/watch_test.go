@@ -0,0 +1,251 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestWatch(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+		{
+			name:   "ClientTLS",
+			config: config.ClusterConfig{ClusterSize: 1, ClientTLS: config.ManualTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				tests := []struct {
+					name       string
+					opts       config.WatchOptions
+					wantEvents []string
+				}{
+					{
+						name:       "Prefix",
+						opts:       config.WatchOptions{Prefix: true},
+						wantEvents: []string{"PUT a=1", "PUT a=2", "DELETE a", "PUT b=3"},
+					},
+					{
+						name:       "RangeEnd",
+						opts:       config.WatchOptions{RangeEnd: "b"},
+						wantEvents: []string{"PUT a=1", "PUT a=2", "DELETE a"},
+					},
+					{
+						name:       "PrevKV",
+						opts:       config.WatchOptions{Prefix: true, PrevKV: true},
+						wantEvents: []string{"PUT a=1", "PUT a=2 (prev a=1)", "DELETE a (prev a=2)", "PUT b=3"},
+					},
+					{
+						name:       "FilterPut",
+						opts:       config.WatchOptions{Prefix: true, FilterPut: true},
+						wantEvents: []string{"DELETE a"},
+					},
+					{
+						name:       "FilterDelete",
+						opts:       config.WatchOptions{Prefix: true, FilterDelete: true},
+						wantEvents: []string{"PUT a=1", "PUT a=2", "PUT b=3"},
+					},
+					{
+						name:       "Fragment",
+						opts:       config.WatchOptions{Prefix: true, Fragment: true},
+						wantEvents: []string{"PUT a=1", "PUT a=2", "DELETE a", "PUT b=3"},
+					},
+				}
+				for _, tt := range tests {
+					prefix := "/" + tt.name + "/"
+					resp, err := cc.Get(prefix, config.GetOptions{})
+					if err != nil {
+						t.Fatalf("%s: could not get current revision, err: %s", tt.name, err)
+					}
+					startRev := resp.Header.Revision + 1
+					for _, op := range []struct{ key, value string }{{"a", "1"}, {"a", "2"}, {"a", ""}, {"b", "3"}} {
+						if op.value == "" {
+							_, err = cc.Delete(prefix+op.key, config.DeleteOptions{})
+						} else {
+							err = cc.Put(prefix+op.key, op.value, config.PutOptions{})
+						}
+						if err != nil {
+							t.Fatalf("%s: could not write key %q, err: %s", tt.name, prefix+op.key, err)
+						}
+					}
+
+					opts := tt.opts
+					opts.Revision = startRev
+					key := prefix
+					if opts.RangeEnd != "" {
+						key = prefix + "a"
+						opts.RangeEnd = prefix + opts.RangeEnd
+					}
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					wch, err := cc.Watch(ctx, key, opts)
+					if err != nil {
+						cancel()
+						t.Fatalf("%s: could not watch key %q, err: %s", tt.name, key, err)
+					}
+					events := collectWatchEvents(ctx, wch, prefix, len(tt.wantEvents))
+					cancel()
+					assert.Equal(t, tt.wantEvents, events, tt.name)
+				}
+			})
+		})
+	}
+}
+
+func TestWatchProgressNotify(t *testing.T) {
+	testRunner.BeforeTest(t)
+	clus := testRunner.NewCluster(t, config.ClusterConfig{ClusterSize: 1, WatchProgressNotifyInterval: 200 * time.Millisecond})
+	defer clus.Close()
+	cc := clus.Client()
+
+	testutils.ExecuteWithTimeout(t, 20*time.Second, func() {
+		if err := cc.Put("foo", "bar", config.PutOptions{}); err != nil {
+			t.Fatalf("could not put key, err: %s", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		wch, err := cc.Watch(ctx, "progress", config.WatchOptions{ProgressNotify: true})
+		if err != nil {
+			t.Fatalf("could not watch key, err: %s", err)
+		}
+		for resp := range wch {
+			if resp.IsProgressNotify() {
+				if resp.Header.Revision < 2 {
+					t.Errorf("expected progress notification at revision >= 2, got %d", resp.Header.Revision)
+				}
+				return
+			}
+		}
+		t.Fatalf("watch ended without a progress notification")
+	})
+}
+
+func TestWatchOrdering(t *testing.T) {
+	testRunner.BeforeTest(t)
+	tcs := []struct {
+		name   string
+		config config.ClusterConfig
+	}{
+		{
+			name:   "NoTLS",
+			config: config.ClusterConfig{ClusterSize: 1},
+		},
+		{
+			name:   "PeerTLS",
+			config: config.ClusterConfig{ClusterSize: 3, PeerTLS: config.ManualTLS},
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			clus := testRunner.NewCluster(t, tc.config)
+			defer clus.Close()
+			cc := clus.Client()
+
+			testutils.ExecuteWithTimeout(t, 30*time.Second, func() {
+				resp, err := cc.Get("/ordering/", config.GetOptions{})
+				if err != nil {
+					t.Fatalf("could not get current revision, err: %s", err)
+				}
+				startRev := resp.Header.Revision + 1
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+				defer cancel()
+				live, err := cc.Watch(ctx, "/ordering/", config.WatchOptions{Prefix: true, Revision: startRev})
+				if err != nil {
+					t.Fatalf("could not watch prefix, err: %s", err)
+				}
+
+				var puts []testutils.WatchedPut
+				for i := 0; i < 20; i++ {
+					key, value := fmt.Sprintf("/ordering/%d", i%3), fmt.Sprint(i)
+					// puts through transactions report their revision
+					txnResp, err := cc.Txn(nil, []config.TxnOp{config.Op.Put(key, value)}, nil, config.TxnOptions{Interactive: true})
+					if err != nil {
+						t.Fatalf("could not put key %q, err: %s", key, err)
+					}
+					puts = append(puts, testutils.WatchedPut{Key: key, Value: value, Revision: txnResp.Header.Revision})
+					if i%5 == 4 {
+						if _, err = cc.Delete(key, config.DeleteOptions{}); err != nil {
+							t.Fatalf("could not delete key %q, err: %s", key, err)
+						}
+					}
+				}
+				if err = testutils.ValidateWatch(ctx, live, puts); err != nil {
+					t.Errorf("watch started before the puts: %s", err)
+				}
+				historical, err := cc.Watch(ctx, "/ordering/", config.WatchOptions{Prefix: true, Revision: startRev})
+				if err != nil {
+					t.Fatalf("could not watch prefix, err: %s", err)
+				}
+				if err = testutils.ValidateWatch(ctx, historical, puts); err != nil {
+					t.Errorf("watch started after the puts: %s", err)
+				}
+			})
+		})
+	}
+}
+
+// collectWatchEvents reads n events from wch, formatted with prefix stripped from keys.
+func collectWatchEvents(ctx context.Context, wch clientv3.WatchChan, prefix string, n int) []string {
+	var events []string
+	for len(events) < n {
+		select {
+		case resp, ok := <-wch:
+			if !ok {
+				return events
+			}
+			for _, ev := range resp.Events {
+				s := fmt.Sprintf("%s %s", ev.Type, ev.Kv.Key[len(prefix):])
+				if ev.Type == clientv3.EventTypePut {
+					s += "=" + string(ev.Kv.Value)
+				}
+				if ev.PrevKv != nil {
+					s += fmt.Sprintf(" (prev %s=%s)", ev.PrevKv.Key[len(prefix):], ev.PrevKv.Value)
+				}
+				events = append(events, s)
+			}
+		case <-ctx.Done():
+			return events
+		}
+	}
+	return events
+}
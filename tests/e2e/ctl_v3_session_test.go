@@ -0,0 +1,95 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func newSessionTestCluster(t *testing.T) *e2e.EtcdctlV3 {
+	e2e.BeforeTest(t)
+	epc, err := e2e.NewEtcdProcessCluster(t, e2e.NewConfigNoTLS())
+	if err != nil {
+		t.Fatalf("could not start etcd process cluster (%v)", err)
+	}
+	t.Cleanup(func() { epc.Close() })
+	return e2e.NewEtcdctl(epc.Cfg, epc.EndpointsV3())
+}
+
+func TestCtlV3SessionWatch(t *testing.T) {
+	ctl := newSessionTestCluster(t)
+	require.NoError(t, ctl.Put("foo", "bar1", config.PutOptions{}))
+
+	// start from the first revision, so that the watch cannot miss the put
+	// above no matter when it is established
+	s, err := ctl.Session("watch", "foo", "--rev", "1")
+	require.NoError(t, err)
+	defer s.Stop()
+	_, err = s.Expect("bar1")
+	require.NoError(t, err)
+
+	for _, val := range []string{"bar2", "bar3"} {
+		require.NoError(t, ctl.Put("foo", val, config.PutOptions{}))
+		_, err = s.Expect(val)
+		require.NoError(t, err)
+	}
+}
+
+func TestCtlV3SessionLeaseKeepAlive(t *testing.T) {
+	ctl := newSessionTestCluster(t)
+	resp, err := ctl.Grant(3)
+	require.NoError(t, err)
+
+	s, err := ctl.Session("lease", "keep-alive", fmt.Sprintf("%x", resp.ID))
+	require.NoError(t, err)
+	defer s.Stop()
+	// each Expect matches a new line, so this waits for several renewals
+	for i := 0; i < 3; i++ {
+		_, err = s.Expect(fmt.Sprintf("lease %016x keepalived with TTL(3)", resp.ID))
+		require.NoError(t, err)
+	}
+}
+
+func TestCtlV3SessionTimeout(t *testing.T) {
+	ctl := newSessionTestCluster(t)
+	s, err := ctl.Session("watch", "foo")
+	require.NoError(t, err)
+	defer s.Stop()
+
+	s.SetTimeout(500 * time.Millisecond)
+	start := time.Now()
+	_, err = s.Expect("bar")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCtlV3SessionExit(t *testing.T) {
+	ctl := newSessionTestCluster(t)
+	s, err := ctl.Session("lease", "keep-alive", "1234")
+	require.NoError(t, err)
+	defer s.Stop()
+
+	// keep-alive of a missing lease exits, which fails Expect before the timeout
+	start := time.Now()
+	_, err = s.Expect("keepalived")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), e2e.DefaultCtlSessionTimeout)
+}
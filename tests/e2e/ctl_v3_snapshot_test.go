@@ -127,6 +127,52 @@ func snapshotStatusBeforeRestoreTest(cx ctlCtx) {
 	}
 }
 
+func TestCtlV3SnapshotFromFollower(t *testing.T) {
+	testCtl(t, snapshotFromFollowerTest, withQuorum())
+}
+
+func snapshotFromFollowerTest(cx ctlCtx) {
+	maintenanceInitKeys(cx)
+
+	fpath := filepath.Join(cx.t.TempDir(), "snapshot")
+	defer os.RemoveAll(fpath)
+
+	cmdArgs := append(cx.PrefixArgs(), "snapshot", "save", "--from-follower", fpath)
+	if err := e2e.SpawnWithExpectWithEnv(cmdArgs, cx.envMap, fmt.Sprintf("Snapshot saved at %s", fpath)); err != nil {
+		cx.t.Fatalf("snapshotFromFollowerTest snapshot save error (%v)", err)
+	}
+
+	st, err := getSnapshotStatus(cx, fpath)
+	if err != nil {
+		cx.t.Fatalf("snapshotFromFollowerTest getSnapshotStatus error (%v)", err)
+	}
+	if st.Revision != 4 {
+		cx.t.Fatalf("expected revision 4, got %d", st.Revision)
+	}
+}
+
+func TestCtlV3SnapshotCompression(t *testing.T) { testCtl(t, snapshotCompressionTest) }
+
+func snapshotCompressionTest(cx ctlCtx) {
+	maintenanceInitKeys(cx)
+
+	fpath := filepath.Join(cx.t.TempDir(), "snapshot")
+	defer os.RemoveAll(fpath)
+
+	cmdArgs := append(cx.PrefixArgs(), "snapshot", "save", "--compression=zstd", fpath)
+	if err := e2e.SpawnWithExpectWithEnv(cmdArgs, cx.envMap, fmt.Sprintf("Snapshot saved at %s", fpath)); err != nil {
+		cx.t.Fatalf("snapshotCompressionTest snapshot save error (%v)", err)
+	}
+
+	st, err := getSnapshotStatus(cx, fpath)
+	if err != nil {
+		cx.t.Fatalf("snapshotCompressionTest getSnapshotStatus error (%v)", err)
+	}
+	if st.Revision != 4 {
+		cx.t.Fatalf("expected revision 4, got %d", st.Revision)
+	}
+}
+
 func ctlV3SnapshotSave(cx ctlCtx, fpath string) error {
 	cmdArgs := append(cx.PrefixArgs(), "snapshot", "save", fpath)
 	return e2e.SpawnWithExpectWithEnv(cmdArgs, cx.envMap, fmt.Sprintf("Snapshot saved at %s", fpath))
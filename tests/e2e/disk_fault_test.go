@@ -0,0 +1,62 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func TestDiskFull(t *testing.T) {
+	e2e.BeforeTest(t)
+	cfg := e2e.NewConfigNoTLS()
+	cfg.ClusterSize = 1
+	// the WAL preallocates two 64MB segments, leaving little room for the
+	// backend
+	cfg.DiskSizeBytes = 160 * 1024 * 1024
+	epc, err := e2e.NewEtcdProcessCluster(t, cfg)
+	if err != nil {
+		t.Fatalf("could not start etcd process cluster (%v)", err)
+	}
+	defer epc.Close()
+
+	cli, err := clientv3.New(clientv3.Config{Endpoints: epc.EndpointsV3(), DialTimeout: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	val := strings.Repeat("a", 1024*1024)
+	for i := 0; ; i++ {
+		if i == 100 {
+			t.Fatal("expected the member to run out of disk")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = cli.Put(ctx, fmt.Sprintf("foo%d", i), val)
+		cancel()
+		if err != nil {
+			break
+		}
+	}
+	if _, err = epc.Procs[0].Logs().Expect("no space left on device"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+// authTokenOpts returns the --auth-token option of the members of a cluster
+// issuing tokens of the type cfg asks for, "simple" by default. JWT tokens
+// are signed with an ECDSA key generated in a temporary directory.
+func authTokenOpts(t testing.TB, cfg config.ClusterConfig) string {
+	switch cfg.AuthTokenType {
+	case config.SimpleToken:
+		return "simple"
+	case config.JWTToken:
+		dir := t.TempDir()
+		priv, pub := filepath.Join(dir, "jwt.key"), filepath.Join(dir, "jwt.pub")
+		if err := writeJWTKeys(priv, pub); err != nil {
+			t.Fatalf("could not generate JWT keys: %s", err)
+		}
+		opts := fmt.Sprintf("jwt,pub-key=%s,priv-key=%s,sign-method=ES256", pub, priv)
+		if cfg.AuthTokenTTL != 0 {
+			opts += ",ttl=" + cfg.AuthTokenTTL.String()
+		}
+		return opts
+	default:
+		t.Fatalf("AuthTokenType %q not supported", cfg.AuthTokenType)
+		return ""
+	}
+}
+
+// writeJWTKeys generates a P-256 key and writes it in PEM to priv, and its
+// public key to pub.
+func writeJWTKeys(priv, pub string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	privDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(priv, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(pub, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600)
+}
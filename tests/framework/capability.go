@@ -0,0 +1,45 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+)
+
+// Capability is a feature of the clusters created by a test runner that
+// shared tests may depend on.
+type Capability string
+
+const (
+	// SupportsFailpoints means gofail failpoints can be activated on members,
+	// e.g. with ClusterConfig.WALFsyncLatency. The e2e runner supports them
+	// if its etcd binary is built with failpoints enabled.
+	SupportsFailpoints Capability = "failpoints"
+	// SupportsNetworkPartition means members can be cut off from their peers.
+	SupportsNetworkPartition Capability = "network partition"
+	// SupportsCertRotation means the certificate files of members serving
+	// manual TLS can be replaced while they run.
+	SupportsCertRotation Capability = "certificate rotation"
+)
+
+// RequireCapabilities skips the test unless the runner supports all of the
+// given capabilities.
+func RequireCapabilities(t testing.TB, r testRunner, caps ...Capability) {
+	for _, c := range caps {
+		if !r.Supports(c) {
+			t.Skipf("%T does not support %s", r, c)
+		}
+	}
+}
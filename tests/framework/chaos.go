@@ -0,0 +1,194 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+// Fault is a failure injected into a member of the cluster by RunChaos.
+type Fault string
+
+const (
+	// FaultStopMember stops the member and starts it again once the fault
+	// is over.
+	FaultStopMember Fault = "stop member"
+	// FaultPartitionMember cuts the member off from its peers until the
+	// fault is over. It requires SupportsNetworkPartition.
+	FaultPartitionMember Fault = "partition member"
+)
+
+// ChaosConfig configures RunChaos.
+type ChaosConfig struct {
+	// Seed determines the schedule of faults and the traffic. A random seed
+	// is used if it is 0. The seed is logged, so that a failing run can be
+	// replayed.
+	Seed int64
+	// Duration is how long the traffic runs.
+	Duration time.Duration
+	// Faults are the faults to pick from, FaultStopMember if empty.
+	Faults []Fault
+	// MaxFaultInterval bounds the pause between two faults, 1s if 0.
+	MaxFaultInterval time.Duration
+	// MaxFaultDuration bounds how long a fault lasts, 2s if 0.
+	MaxFaultDuration time.Duration
+	// Clients is the number of concurrent clients sending traffic, 3 if 0.
+	Clients int
+	// Keys is the number of keys the traffic is spread over, 5 if 0.
+	Keys int
+}
+
+type faultEvent struct {
+	// after is the pause since the end of the previous fault.
+	after    time.Duration
+	fault    Fault
+	member   int
+	duration time.Duration
+}
+
+func (e faultEvent) String() string {
+	return fmt.Sprintf("%s %d for %s after %s", e.fault, e.member, e.duration, e.after)
+}
+
+// chaosSchedule draws the faults to inject until cfg.Duration elapsed.
+// Faults do not overlap, so that the cluster keeps a quorum.
+func chaosSchedule(rnd *rand.Rand, cfg ChaosConfig, members int) []faultEvent {
+	var (
+		events  []faultEvent
+		elapsed time.Duration
+	)
+	for {
+		e := faultEvent{
+			after:    time.Duration(rnd.Int63n(int64(cfg.MaxFaultInterval))),
+			fault:    cfg.Faults[rnd.Intn(len(cfg.Faults))],
+			member:   rnd.Intn(members),
+			duration: time.Duration(rnd.Int63n(int64(cfg.MaxFaultDuration))),
+		}
+		if elapsed += e.after + e.duration; elapsed > cfg.Duration {
+			return events
+		}
+		events = append(events, e)
+	}
+}
+
+// RunChaos sends traffic to the cluster for cfg.Duration while injecting
+// faults into its members, following a schedule drawn from cfg.Seed. All
+// faults are recovered from when it returns. Requests failing because of a
+// fault are expected; the recorded history is checked by
+// Cluster.VerifyLinearizability.
+func RunChaos(t testing.TB, clus Cluster, cfg ChaosConfig) {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if len(cfg.Faults) == 0 {
+		cfg.Faults = []Fault{FaultStopMember}
+	}
+	if cfg.MaxFaultInterval == 0 {
+		cfg.MaxFaultInterval = time.Second
+	}
+	if cfg.MaxFaultDuration == 0 {
+		cfg.MaxFaultDuration = 2 * time.Second
+	}
+	if cfg.Clients == 0 {
+		cfg.Clients = 3
+	}
+	if cfg.Keys == 0 {
+		cfg.Keys = 5
+	}
+	t.Logf("chaos seed: %d", cfg.Seed)
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	members := clus.Members()
+	schedule := chaosSchedule(rnd, cfg, len(members))
+	t.Logf("chaos schedule: %v", schedule)
+
+	cc := clus.Client()
+	h := clus.recorder()
+	stopc := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Clients; i++ {
+		wg.Add(1)
+		go func(c *recordingClient, rnd *rand.Rand) {
+			defer wg.Done()
+			sendTraffic(c, rnd, cfg.Keys, stopc)
+		}(h.newClient(cc), rand.New(rand.NewSource(rnd.Int63())))
+	}
+	defer func() {
+		close(stopc)
+		wg.Wait()
+		h.mu.Lock()
+		t.Logf("chaos traffic recorded %d requests", len(h.ops))
+		h.mu.Unlock()
+	}()
+
+	start := time.Now()
+	for _, e := range schedule {
+		time.Sleep(e.after)
+		t.Logf("injecting %s", e)
+		if err := injectFault(members[e.member], e.fault, e.duration); err != nil {
+			t.Fatalf("failed to inject %s: %v", e, err)
+		}
+	}
+	time.Sleep(cfg.Duration - time.Since(start))
+}
+
+func injectFault(m Member, f Fault, d time.Duration) error {
+	switch f {
+	case FaultStopMember:
+		m.Stop()
+		time.Sleep(d)
+		return m.Start()
+	case FaultPartitionMember:
+		if err := m.Partition(); err != nil {
+			return err
+		}
+		time.Sleep(d)
+		return m.Heal()
+	default:
+		return fmt.Errorf("unknown fault %q", f)
+	}
+}
+
+// sendTraffic puts, gets and deletes keys until stopc is closed, sometimes
+// in transactions.
+func sendTraffic(c *recordingClient, rnd *rand.Rand, keys int, stopc <-chan struct{}) {
+	for i := 0; ; i++ {
+		select {
+		case <-stopc:
+			return
+		default:
+		}
+		key := fmt.Sprintf("chaos-key-%d", rnd.Intn(keys))
+		value := fmt.Sprintf("%d-%d", c.id, i)
+		switch n := rnd.Intn(10); {
+		case n < 4:
+			c.Put(key, value, config.PutOptions{})
+		case n < 8:
+			c.Get(key, config.GetOptions{Timeout: 5 * time.Second})
+		case n < 9:
+			c.Delete(key, config.DeleteOptions{})
+		default:
+			c.Txn([]config.TxnCompare{config.Cmp().Version(key).Equals(0)},
+				[]config.TxnOp{config.Op.Put(key, value)},
+				[]config.TxnOp{config.Op.Get(key)},
+				config.TxnOptions{Interactive: true})
+		}
+	}
+}
@@ -63,3 +63,14 @@ type LeaseOption struct {
 type UserAddOptions struct {
 	NoPassword bool
 }
+
+type WatchOptions struct {
+	Prefix         bool
+	Revision       int64
+	RangeEnd       string
+	PrevKV         bool
+	ProgressNotify bool
+	FilterPut      bool
+	FilterDelete   bool
+	Fragment       bool
+}
@@ -14,6 +14,12 @@
 
 package config
 
+import (
+	"time"
+
+	serverconfig "go.etcd.io/etcd/server/v3/config"
+)
+
 type TLSConfig string
 
 const (
@@ -22,9 +28,71 @@ const (
 	ManualTLS TLSConfig = "manual-tls"
 )
 
+// CertBundle names the files of a TLS certificate, its private key and the
+// CA it is signed by.
+type CertBundle struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+type IPMode string
+
+const (
+	// IPv4 serves clients and peers on the IPv4 loopback address, or localhost.
+	IPv4 IPMode = ""
+	// IPv6 serves clients and peers on the IPv6 loopback address.
+	IPv6 IPMode = "ipv6"
+	// DualStack serves clients and peers on both loopback addresses,
+	// clients connect over IPv6.
+	DualStack IPMode = "dual-stack"
+)
+
+// AuthTokenType is the type of the tokens members issue to authenticated
+// users.
+type AuthTokenType string
+
+const (
+	// SimpleToken tokens are random strings the members keep track of.
+	SimpleToken AuthTokenType = ""
+	// JWTToken tokens are JSON web tokens signed with a key generated for
+	// the cluster.
+	JWTToken AuthTokenType = "jwt"
+)
+
 type ClusterConfig struct {
 	ClusterSize       int
 	PeerTLS           TLSConfig
 	ClientTLS         TLSConfig
+	IPMode            IPMode
 	QuotaBackendBytes int64
+
+	// ClientCertAuthEnabled makes members serving ClientTLS ManualTLS
+	// require client certificates, and authenticate requests without a
+	// token as the user named by the CommonName of the certificate.
+	ClientCertAuthEnabled bool
+
+	AuthTokenType AuthTokenType
+	// AuthTokenTTL is how long tokens stay valid, the default of the
+	// members if 0. Simple tokens expire AuthTokenTTL after their last use,
+	// rounded up to seconds, JWT tokens AuthTokenTTL after they were issued.
+	AuthTokenTTL time.Duration
+
+	// UnixSockets serves clients and peers on unix sockets instead of TCP.
+	UnixSockets bool
+
+	WatchProgressNotifyInterval time.Duration
+	// CorruptCheckTime is the interval of the leader's periodic hash check
+	// of the members' backends, zero disables it.
+	CorruptCheckTime time.Duration
+	// WALFsyncLatency, if set, delays every WAL fsync of the members. It
+	// requires SupportsFailpoints.
+	WALFsyncLatency time.Duration
+
+	// ExtraArgs are additional flags of the members of e2e clusters, keyed
+	// by flag name without the leading dashes.
+	ExtraArgs map[string]string
+	// ServerConfigMutator changes the server configuration of the members
+	// of integration clusters before they are launched.
+	ServerConfigMutator func(*serverconfig.ServerConfig)
 }
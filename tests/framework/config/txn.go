@@ -0,0 +1,131 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// CompareTarget is the attribute of a key compared by a transaction. The
+// values are the names etcdctl txn uses for them.
+type CompareTarget string
+
+const (
+	CompareValue          CompareTarget = "value"
+	CompareVersion        CompareTarget = "version"
+	CompareCreateRevision CompareTarget = "create"
+	CompareModRevision    CompareTarget = "mod"
+)
+
+// TxnCompare is a comparison of a transaction, built with Cmp, e.g.
+// Cmp().Value("key1").NotEquals("value2").
+type TxnCompare struct {
+	Target CompareTarget
+	Key    string
+	// Result is the comparison operator, one of "=", "!=", "<" and ">".
+	Result string
+	// Value is compared to the value of the key for CompareValue.
+	Value string
+	// Revision is compared to the version or revision of the key for the
+	// other targets.
+	Revision int64
+}
+
+// Cmp starts a comparison of a transaction.
+func Cmp() CmpBuilder { return CmpBuilder{} }
+
+// CmpBuilder selects the key and attribute a comparison is about.
+type CmpBuilder struct{}
+
+func (CmpBuilder) Value(key string) ValueCmp { return ValueCmp{key: key} }
+
+func (CmpBuilder) Version(key string) RevisionCmp {
+	return RevisionCmp{target: CompareVersion, key: key}
+}
+
+func (CmpBuilder) CreateRevision(key string) RevisionCmp {
+	return RevisionCmp{target: CompareCreateRevision, key: key}
+}
+
+func (CmpBuilder) ModRevision(key string) RevisionCmp {
+	return RevisionCmp{target: CompareModRevision, key: key}
+}
+
+// ValueCmp compares the value of a key.
+type ValueCmp struct{ key string }
+
+func (c ValueCmp) Equals(v string) TxnCompare    { return c.compare("=", v) }
+func (c ValueCmp) NotEquals(v string) TxnCompare { return c.compare("!=", v) }
+func (c ValueCmp) Less(v string) TxnCompare      { return c.compare("<", v) }
+func (c ValueCmp) Greater(v string) TxnCompare   { return c.compare(">", v) }
+
+func (c ValueCmp) compare(result, v string) TxnCompare {
+	return TxnCompare{Target: CompareValue, Key: c.key, Result: result, Value: v}
+}
+
+// RevisionCmp compares the version, create or mod revision of a key.
+type RevisionCmp struct {
+	target CompareTarget
+	key    string
+}
+
+func (c RevisionCmp) Equals(rev int64) TxnCompare    { return c.compare("=", rev) }
+func (c RevisionCmp) NotEquals(rev int64) TxnCompare { return c.compare("!=", rev) }
+func (c RevisionCmp) Less(rev int64) TxnCompare      { return c.compare("<", rev) }
+func (c RevisionCmp) Greater(rev int64) TxnCompare   { return c.compare(">", rev) }
+
+func (c RevisionCmp) compare(result string, rev int64) TxnCompare {
+	return TxnCompare{Target: c.target, Key: c.key, Result: result, Revision: rev}
+}
+
+// TxnOpType is the kind of a request of a transaction. The values are the
+// commands etcdctl txn uses for them.
+type TxnOpType string
+
+const (
+	TxnOpGet    TxnOpType = "get"
+	TxnOpPut    TxnOpType = "put"
+	TxnOpDelete TxnOpType = "del"
+	TxnOpTxn    TxnOpType = "txn"
+)
+
+// TxnOp is a request of a transaction, built with Op, e.g. Op.Get("key1").
+type TxnOp struct {
+	Type  TxnOpType
+	Key   string
+	Value string
+
+	// Compares, Success and Failure are the comparisons and branches of a
+	// nested transaction for TxnOpTxn.
+	Compares []TxnCompare
+	Success  []TxnOp
+	Failure  []TxnOp
+}
+
+// Op builds the requests of transactions.
+var Op OpBuilder
+
+type OpBuilder struct{}
+
+func (OpBuilder) Get(key string) TxnOp { return TxnOp{Type: TxnOpGet, Key: key} }
+
+func (OpBuilder) Put(key, value string) TxnOp {
+	return TxnOp{Type: TxnOpPut, Key: key, Value: value}
+}
+
+func (OpBuilder) Delete(key string) TxnOp { return TxnOp{Type: TxnOpDelete, Key: key} }
+
+// Txn nests a transaction in a branch of another one. The server evaluates
+// the comparisons of nested transactions against the state before the
+// outermost transaction.
+func (OpBuilder) Txn(compares []TxnCompare, success, failure []TxnOp) TxnOp {
+	return TxnOp{Type: TxnOpTxn, Compares: compares, Success: success, Failure: failure}
+}
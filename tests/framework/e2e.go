@@ -15,12 +15,19 @@
 package framework
 
 import (
+	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/e2e"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
 )
 
 type e2eRunner struct{}
@@ -35,14 +42,56 @@ func (e e2eRunner) TestMain(m *testing.M) {
 }
 
 func (e e2eRunner) BeforeTest(t testing.TB) {
+	trackResources(t)
 	e2e.BeforeTest(t)
 }
 
+func (e e2eRunner) Supports(c Capability) bool {
+	switch c {
+	case SupportsFailpoints:
+		return hasFailpoints()
+	case SupportsCertRotation:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	failpointsOnce sync.Once
+	failpoints     bool
+)
+
+// hasFailpoints reports whether e2e.BinPath is built with failpoints, the
+// binary is only run once.
+func hasFailpoints() bool {
+	failpointsOnce.Do(func() {
+		var err error
+		if failpoints, err = e2e.HasFailpoints(e2e.BinPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+	return failpoints
+}
+
 func (e e2eRunner) NewCluster(t testing.TB, cfg config.ClusterConfig) Cluster {
 	e2eConfig := e2e.EtcdProcessClusterConfig{
 		InitialToken:      "new",
 		ClusterSize:       cfg.ClusterSize,
+		BasePort:          e2e.ReservePorts(t, cfg.ClusterSize),
+		IPMode:            cfg.IPMode,
 		QuotaBackendBytes: cfg.QuotaBackendBytes,
+
+		WatchProgressNotifyInterval: cfg.WatchProgressNotifyInterval,
+		CorruptCheckTime:            cfg.CorruptCheckTime,
+		WALFsyncLatency:             cfg.WALFsyncLatency,
+		ExtraArgs:                   cfg.ExtraArgs,
+
+		ClientCertAuthEnabled: cfg.ClientCertAuthEnabled,
+		AuthTokenOpts:         authTokenOpts(t, cfg),
+	}
+	if cfg.AuthTokenType == config.SimpleToken {
+		e2eConfig.AuthTokenTTL = cfg.AuthTokenTTL
 	}
 	switch cfg.ClientTLS {
 	case config.NoTLS:
@@ -69,21 +118,50 @@ func (e e2eRunner) NewCluster(t testing.TB, cfg config.ClusterConfig) Cluster {
 	default:
 		t.Fatalf("PeerTLS config %q not supported", cfg.PeerTLS)
 	}
+	if cfg.UnixSockets {
+		if cfg.IPMode != config.IPv4 {
+			t.Fatalf("IPMode %q cannot be combined with unix sockets", cfg.IPMode)
+		}
+		if e2e.ThroughProxy {
+			t.Skip("the gRPC proxy does not serve unix sockets")
+		}
+		e2eConfig.BaseScheme = "unix"
+		e2eConfig.ClientUnixSocket = true
+	}
+	if cfg.IPMode == config.DualStack && (cfg.ClientTLS == config.ManualTLS || cfg.PeerTLS == config.ManualTLS) {
+		t.Fatalf("IPMode %q does not support manual TLS, no fixture certificate covers both loopback addresses", cfg.IPMode)
+	}
+	if cfg.ClientTLS == config.ManualTLS || cfg.PeerTLS == config.ManualTLS {
+		e2eConfig.CertDir = t.TempDir()
+	}
 	epc, err := e2e.NewEtcdProcessCluster(t, &e2eConfig)
 	if err != nil {
 		t.Fatalf("could not start etcd integrationCluster: %s", err)
 	}
-	return &e2eCluster{*epc}
+	return &e2eCluster{EtcdProcessCluster: *epc, t: t}
 }
 
 type e2eCluster struct {
 	e2e.EtcdProcessCluster
+	history
+	t testing.TB
 }
 
 func (c *e2eCluster) Client() Client {
 	return e2eClient{e2e.NewEtcdctl(c.Cfg, c.EndpointsV3())}
 }
 
+func (c *e2eCluster) AuthClient(user, password string) (Client, error) {
+	return e2eClient{e2e.NewEtcdctl(c.Cfg, c.EndpointsV3()).WithAuth(user, password)}, nil
+}
+
+func (c *e2eCluster) CertClient(bundle config.CertBundle) (Client, error) {
+	if c.Cfg.ClientTLS != e2e.ClientTLS || c.Cfg.IsClientAutoTLS {
+		return nil, fmt.Errorf("cluster does not serve manual client TLS")
+	}
+	return e2eClient{e2e.NewEtcdctl(c.Cfg, c.EndpointsV3()).WithCert(bundle)}, nil
+}
+
 func (c *e2eCluster) Members() (ms []Member) {
 	for _, proc := range c.EtcdProcessCluster.Procs {
 		ms = append(ms, e2eMember{EtcdProcess: proc, Cfg: c.Cfg})
@@ -91,6 +169,110 @@ func (c *e2eCluster) Members() (ms []Member) {
 	return ms
 }
 
+func (c *e2eCluster) AdvanceTime(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (c *e2eCluster) Leader() (uint64, error) {
+	return c.waitLeader(0)
+}
+
+func (c *e2eCluster) MoveLeader(targetID uint64) error {
+	if _, err := e2e.NewEtcdctl(c.Cfg, c.EndpointsV3()).MoveLeader(targetID); err != nil {
+		return err
+	}
+	_, err := c.waitLeader(targetID)
+	return err
+}
+
+// waitLeader polls the status of the members until they agree on a leader,
+// which must be want unless want is 0.
+func (c *e2eCluster) waitLeader(want uint64) (uint64, error) {
+	var err error
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(100 * time.Millisecond) {
+		var statuses []*clientv3.StatusResponse
+		if statuses, err = e2e.NewEtcdctl(c.Cfg, c.EndpointsV3()).Status(); err != nil {
+			continue
+		}
+		lead := statuses[0].Leader
+		for _, s := range statuses[1:] {
+			if s.Leader != lead {
+				lead = 0
+			}
+		}
+		switch {
+		case lead == 0:
+			err = fmt.Errorf("members do not agree on a leader")
+		case want != 0 && lead != want:
+			err = fmt.Errorf("leader is %x, expected %x", lead, want)
+		default:
+			return lead, nil
+		}
+	}
+	return 0, err
+}
+
+func (c *e2eCluster) ScaleTo(n int) error {
+	if n < 1 {
+		return fmt.Errorf("cannot scale to %d members", n)
+	}
+	for len(c.Procs) < n {
+		if err := c.AddMember(c.t); err != nil {
+			return err
+		}
+		if _, err := c.waitLeader(0); err != nil {
+			return err
+		}
+	}
+	for len(c.Procs) > n {
+		proc, err := c.follower()
+		if err != nil {
+			return err
+		}
+		if err = c.RemoveMember(proc); err != nil {
+			return err
+		}
+		if _, err = c.waitLeader(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// follower returns the last member which is not the leader.
+func (c *e2eCluster) follower() (e2e.EtcdProcess, error) {
+	if len(c.Procs) == 1 {
+		return c.Procs[0], nil
+	}
+	for i := len(c.Procs) - 1; i >= 0; i-- {
+		statuses, err := e2e.NewEtcdctl(c.Cfg, c.Procs[i].EndpointsV3()).Status()
+		if err != nil {
+			return nil, err
+		}
+		if statuses[0].Leader != statuses[0].Header.MemberId {
+			return c.Procs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("all members are leaders")
+}
+
+func (c *e2eCluster) Gateway() GatewayClient {
+	gc := gatewayClient{endpoint: c.Procs[0].Config().Acurl}
+	if c.Cfg.ClientTLS == e2e.ClientTLS {
+		if c.Cfg.IsClientAutoTLS {
+			gc.tlsInfo.InsecureSkipVerify = true
+		} else {
+			cert, key, ca := c.Cfg.CertFiles()
+			gc.tlsInfo = transport.TLSInfo{
+				CertFile:      cert,
+				KeyFile:       key,
+				TrustedCAFile: ca,
+			}
+		}
+	}
+	return gc
+}
+
 type e2eClient struct {
 	*e2e.EtcdctlV3
 }
@@ -111,3 +293,15 @@ func (m e2eMember) Start() error {
 func (m e2eMember) Stop() {
 	m.EtcdProcess.Stop()
 }
+
+func (m e2eMember) CorruptBBolt() error {
+	return testutils.CorruptBBolt(datadir.ToBackendFileName(m.Config().DataDirPath))
+}
+
+func (m e2eMember) Partition() error {
+	return fmt.Errorf("network partitions are not supported by e2e members")
+}
+
+func (m e2eMember) Heal() error {
+	return fmt.Errorf("network partitions are not supported by e2e members")
+}
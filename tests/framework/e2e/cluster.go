@@ -16,14 +16,20 @@ package e2e
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
@@ -144,7 +150,15 @@ type EtcdProcessClusterConfig struct {
 	ClusterSize int
 
 	BaseScheme string
-	BasePort   int
+	// BasePort is the first port used by the cluster, EtcdProcessBasePort
+	// if unset. Clusters started by parallel tests should use ReservePorts.
+	BasePort int
+	// IPMode selects the loopback addresses the members listen on and
+	// advertise, localhost if unset.
+	IPMode config.IPMode
+	// ClientUnixSocket serves clients on unix sockets instead of TCP. Set
+	// BaseScheme to "unix" to do the same for peers.
+	ClientUnixSocket bool
 
 	MetricsURLScheme string
 
@@ -159,6 +173,10 @@ type EtcdProcessClusterConfig struct {
 	NoCN                  bool
 
 	CipherSuites []string
+	// CertDir holds copies of the certificate files the members serve
+	// manual TLS with, made when the cluster is initialized, so that they
+	// can be rotated. The members use the shared fixtures if it is empty.
+	CertDir string
 
 	ForceNewCluster     bool
 	InitialToken        string
@@ -167,7 +185,9 @@ type EtcdProcessClusterConfig struct {
 	EnableV2            bool
 	InitialCorruptCheck bool
 	AuthTokenOpts       string
-	V2deprecation       string
+	// AuthTokenTTL is the TTL of simple tokens, rounded up to seconds.
+	AuthTokenTTL  time.Duration
+	V2deprecation string
 
 	RollingStart bool
 
@@ -176,6 +196,20 @@ type EtcdProcessClusterConfig struct {
 	DiscoveryEndpoints []string // v3 discovery
 	DiscoveryToken     string
 	LogLevel           string
+
+	WatchProgressNotifyInterval time.Duration
+	CorruptCheckTime            time.Duration
+	// ExtraArgs are passed as "--name=value" flags to every member, to
+	// set flags that have no dedicated field.
+	ExtraArgs map[string]string
+
+	// DiskSizeBytes, if set, runs the data dir of each member on its own
+	// loopback ext4 filesystem of this size, so that members can run out
+	// of disk. Tests are skipped unless run as root.
+	DiskSizeBytes int64
+	// WALFsyncLatency, if set, delays every WAL fsync of the members. It
+	// requires etcd built with failpoints, see SkipIfNoFailpoints.
+	WALFsyncLatency time.Duration
 }
 
 // NewEtcdProcessCluster launches a new cluster from etcd processes, returning
@@ -194,6 +228,11 @@ func NewEtcdProcessCluster(t testing.TB, cfg *EtcdProcessClusterConfig) (*EtcdPr
 func InitEtcdProcessCluster(t testing.TB, cfg *EtcdProcessClusterConfig) (*EtcdProcessCluster, error) {
 	SkipInShortMode(t)
 
+	if cfg.CertDir != "" {
+		if err := cfg.copyCerts(); err != nil {
+			return nil, fmt.Errorf("cannot copy certificates: %v", err)
+		}
+	}
 	etcdCfgs := cfg.EtcdServerProcessConfigs(t)
 	epc := &EtcdProcessCluster{
 		Cfg:   cfg,
@@ -230,10 +269,14 @@ func StartEtcdProcessCluster(epc *EtcdProcessCluster, cfg *EtcdProcessClusterCon
 }
 
 func (cfg *EtcdProcessClusterConfig) ClientScheme() string {
+	scheme := "http"
+	if cfg.ClientUnixSocket {
+		scheme = "unix"
+	}
 	if cfg.ClientTLS == ClientTLS {
-		return "https"
+		scheme += "s"
 	}
-	return "http"
+	return scheme
 }
 
 func (cfg *EtcdProcessClusterConfig) PeerScheme() string {
@@ -263,98 +306,13 @@ func (cfg *EtcdProcessClusterConfig) EtcdServerProcessConfigs(tb testing.TB) []*
 	etcdCfgs := make([]*EtcdServerProcessConfig, cfg.ClusterSize)
 	initialCluster := make([]string, cfg.ClusterSize)
 	for i := 0; i < cfg.ClusterSize; i++ {
-		var curls []string
-		var curl, curltls string
-		port := cfg.BasePort + 5*i
-		curlHost := fmt.Sprintf("localhost:%d", port)
-
-		switch cfg.ClientTLS {
-		case ClientNonTLS, ClientTLS:
-			curl = (&url.URL{Scheme: cfg.ClientScheme(), Host: curlHost}).String()
-			curls = []string{curl}
-		case ClientTLSAndNonTLS:
-			curl = (&url.URL{Scheme: "http", Host: curlHost}).String()
-			curltls = (&url.URL{Scheme: "https", Host: curlHost}).String()
-			curls = []string{curl, curltls}
-		}
-
-		purl := url.URL{Scheme: cfg.PeerScheme(), Host: fmt.Sprintf("localhost:%d", port+1)}
-		name := fmt.Sprintf("test-%d", i)
-		dataDirPath := cfg.DataDirPath
-		if cfg.DataDirPath == "" {
-			dataDirPath = tb.TempDir()
-		}
-		initialCluster[i] = fmt.Sprintf("%s=%s", name, purl.String())
-
-		args := []string{
-			"--name", name,
-			"--listen-client-urls", strings.Join(curls, ","),
-			"--advertise-client-urls", strings.Join(curls, ","),
-			"--listen-peer-urls", purl.String(),
-			"--initial-advertise-peer-urls", purl.String(),
-			"--initial-cluster-token", cfg.InitialToken,
-			"--data-dir", dataDirPath,
-			"--snapshot-count", fmt.Sprintf("%d", cfg.SnapshotCount),
-		}
-
-		if cfg.ForceNewCluster {
-			args = append(args, "--force-new-cluster")
-		}
-		if cfg.QuotaBackendBytes > 0 {
-			args = append(args,
-				"--quota-backend-bytes", fmt.Sprintf("%d", cfg.QuotaBackendBytes),
-			)
-		}
-		if cfg.NoStrictReconfig {
-			args = append(args, "--strict-reconfig-check=false")
-		}
-		if cfg.EnableV2 {
-			args = append(args, "--enable-v2")
-		}
-		if cfg.InitialCorruptCheck {
-			args = append(args, "--experimental-initial-corrupt-check")
-		}
-		var murl string
-		if cfg.MetricsURLScheme != "" {
-			murl = (&url.URL{
-				Scheme: cfg.MetricsURLScheme,
-				Host:   fmt.Sprintf("localhost:%d", port+2),
-			}).String()
-			args = append(args, "--listen-metrics-urls", murl)
-		}
-
-		args = append(args, cfg.TlsArgs()...)
-
-		if cfg.AuthTokenOpts != "" {
-			args = append(args, "--auth-token", cfg.AuthTokenOpts)
-		}
-
-		if cfg.V2deprecation != "" {
-			args = append(args, "--v2-deprecation", cfg.V2deprecation)
-		}
-
-		if cfg.Discovery != "" {
-			args = append(args, "--discovery", cfg.Discovery)
-		}
-
-		if cfg.LogLevel != "" {
-			args = append(args, "--log-level", cfg.LogLevel)
-		}
-
-		etcdCfgs[i] = &EtcdServerProcessConfig{
-			lg:           lg,
-			ExecPath:     cfg.ExecPath,
-			Args:         args,
-			EnvVars:      cfg.EnvVars,
-			TlsArgs:      cfg.TlsArgs(),
-			DataDirPath:  dataDirPath,
-			KeepDataDir:  cfg.KeepDataDir,
-			Name:         name,
-			Purl:         purl,
-			Acurl:        curl,
-			Murl:         murl,
-			InitialToken: cfg.InitialToken,
+		var purls []string
+		etcdCfgs[i], purls = cfg.etcdServerProcessConfig(tb, lg, fmt.Sprintf("test-%d", i), cfg.BasePort+5*i)
+		members := make([]string, len(purls))
+		for j, u := range purls {
+			members[j] = fmt.Sprintf("%s=%s", etcdCfgs[i].Name, u)
 		}
+		initialCluster[i] = strings.Join(members, ",")
 	}
 
 	if cfg.Discovery == "" && len(cfg.DiscoveryEndpoints) == 0 {
@@ -375,15 +333,217 @@ func (cfg *EtcdProcessClusterConfig) EtcdServerProcessConfigs(tb testing.TB) []*
 	return etcdCfgs
 }
 
+// etcdServerProcessConfig returns the configuration of the member name
+// listening on the ports from port on, without the initial cluster, and the
+// peer URLs of the member.
+func (cfg *EtcdProcessClusterConfig) etcdServerProcessConfig(tb testing.TB, lg *zap.Logger, name string, port int) (*EtcdServerProcessConfig, []string) {
+	hosts := cfg.hosts()
+	var curls []string
+	var curl string
+	for _, host := range hosts {
+		curlHost := net.JoinHostPort(host, strconv.Itoa(port))
+		switch cfg.ClientTLS {
+		case ClientNonTLS, ClientTLS:
+			curls = append(curls, (&url.URL{Scheme: cfg.ClientScheme(), Host: curlHost}).String())
+		case ClientTLSAndNonTLS:
+			scheme := cfg.ClientScheme()
+			curls = append(curls,
+				(&url.URL{Scheme: scheme, Host: curlHost}).String(),
+				(&url.URL{Scheme: scheme + "s", Host: curlHost}).String(),
+			)
+		}
+	}
+	curl = curls[0]
+
+	var purls []string
+	for _, host := range hosts {
+		purls = append(purls, (&url.URL{Scheme: cfg.PeerScheme(), Host: net.JoinHostPort(host, strconv.Itoa(port+1))}).String())
+	}
+	purl := url.URL{Scheme: cfg.PeerScheme(), Host: net.JoinHostPort(hosts[0], strconv.Itoa(port+1))}
+	dataDirPath := cfg.DataDirPath
+	if cfg.DataDirPath == "" {
+		dataDirPath = tb.TempDir()
+	}
+	if cfg.DiskSizeBytes > 0 {
+		// the data dir is removed and recreated by the members, so
+		// it cannot be the mount point itself
+		dataDirPath = filepath.Join(mountLoopFS(tb, cfg.DiskSizeBytes), "data")
+	}
+	args := []string{
+		"--name", name,
+		"--listen-client-urls", strings.Join(curls, ","),
+		"--advertise-client-urls", strings.Join(curls, ","),
+		"--listen-peer-urls", strings.Join(purls, ","),
+		"--initial-advertise-peer-urls", strings.Join(purls, ","),
+		"--initial-cluster-token", cfg.InitialToken,
+		"--data-dir", dataDirPath,
+		"--snapshot-count", fmt.Sprintf("%d", cfg.SnapshotCount),
+	}
+
+	if cfg.ForceNewCluster {
+		args = append(args, "--force-new-cluster")
+	}
+	if cfg.QuotaBackendBytes > 0 {
+		args = append(args,
+			"--quota-backend-bytes", fmt.Sprintf("%d", cfg.QuotaBackendBytes),
+		)
+	}
+	if cfg.NoStrictReconfig {
+		args = append(args, "--strict-reconfig-check=false")
+	}
+	if cfg.EnableV2 {
+		args = append(args, "--enable-v2")
+	}
+	if cfg.InitialCorruptCheck {
+		args = append(args, "--experimental-initial-corrupt-check")
+	}
+	var murl string
+	if cfg.MetricsURLScheme != "" {
+		murl = (&url.URL{
+			Scheme: cfg.MetricsURLScheme,
+			Host:   fmt.Sprintf("localhost:%d", port+2),
+		}).String()
+		args = append(args, "--listen-metrics-urls", murl)
+	}
+
+	args = append(args, cfg.TlsArgs()...)
+
+	if cfg.AuthTokenOpts != "" {
+		args = append(args, "--auth-token", cfg.AuthTokenOpts)
+	}
+	if cfg.AuthTokenTTL != 0 {
+		args = append(args, "--auth-token-ttl", fmt.Sprint(uint((cfg.AuthTokenTTL+time.Second-1)/time.Second)))
+	}
+
+	if cfg.V2deprecation != "" {
+		args = append(args, "--v2-deprecation", cfg.V2deprecation)
+	}
+
+	if cfg.Discovery != "" {
+		args = append(args, "--discovery", cfg.Discovery)
+	}
+
+	if cfg.LogLevel != "" {
+		args = append(args, "--log-level", cfg.LogLevel)
+	}
+
+	if cfg.WatchProgressNotifyInterval != 0 {
+		args = append(args, "--experimental-watch-progress-notify-interval", cfg.WatchProgressNotifyInterval.String())
+	}
+	if cfg.CorruptCheckTime != 0 {
+		args = append(args, "--experimental-corrupt-check-time", cfg.CorruptCheckTime.String())
+	}
+	args = append(args, cfg.extraArgs()...)
+
+	return &EtcdServerProcessConfig{
+		lg:           lg,
+		ExecPath:     cfg.ExecPath,
+		Args:         args,
+		EnvVars:      cfg.envVars(),
+		TlsArgs:      cfg.TlsArgs(),
+		DataDirPath:  dataDirPath,
+		KeepDataDir:  cfg.KeepDataDir,
+		Name:         name,
+		Purl:         purl,
+		Acurl:        curl,
+		Murl:         murl,
+		InitialToken: cfg.InitialToken,
+	}, purls
+}
+
+// envVars returns the environment variables of the members, EnvVars with
+// the failpoints injecting faults.
+func (cfg *EtcdProcessClusterConfig) envVars() map[string]string {
+	if cfg.WALFsyncLatency == 0 {
+		return cfg.EnvVars
+	}
+	env := make(map[string]string, len(cfg.EnvVars)+1)
+	for k, v := range cfg.EnvVars {
+		env[k] = v
+	}
+	env["GOFAIL_FAILPOINTS"] = fmt.Sprintf("walBeforeSync=sleep(%d)", cfg.WALFsyncLatency.Milliseconds())
+	return env
+}
+
+// hosts returns the addresses the members listen on, the first one is used
+// by clients.
+func (cfg *EtcdProcessClusterConfig) hosts() []string {
+	switch cfg.IPMode {
+	case config.IPv6:
+		return []string{"::1"}
+	case config.DualStack:
+		return []string{"::1", "127.0.0.1"}
+	default:
+		return []string{"localhost"}
+	}
+}
+
+// extraArgs returns ExtraArgs as flags sorted by name, so that members are
+// started with the same command line across runs.
+func (cfg *EtcdProcessClusterConfig) extraArgs() (args []string) {
+	names := make([]string, 0, len(cfg.ExtraArgs))
+	for name := range cfg.ExtraArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("--%s=%s", name, cfg.ExtraArgs[name]))
+	}
+	return args
+}
+
+// CertFiles returns the certificate, key and CA files the members serve
+// manual TLS with.
+func (cfg *EtcdProcessClusterConfig) CertFiles() (cert, key, ca string) {
+	cert, key, ca = CertPath, PrivateKeyPath, CaPath
+	if cfg.IPMode == config.IPv6 {
+		cert, key = CertPathIPv6, PrivateKeyPathIPv6
+	}
+	if cfg.CertDir == "" {
+		return cert, key, ca
+	}
+	return filepath.Join(cfg.CertDir, filepath.Base(cert)),
+		filepath.Join(cfg.CertDir, filepath.Base(key)),
+		filepath.Join(cfg.CertDir, filepath.Base(ca))
+}
+
+func (cfg *EtcdProcessClusterConfig) copyCerts() error {
+	shared := EtcdProcessClusterConfig{IPMode: cfg.IPMode}
+	srcCert, srcKey, srcCA := shared.CertFiles()
+	cert, key, ca := cfg.CertFiles()
+	return copyFiles(map[string]string{srcCert: cert, srcKey: key, srcCA: ca})
+}
+
+// copyFiles copies the source files to their destinations. Each destination
+// is replaced by a rename, so that readers never see a partial file.
+func copyFiles(dsts map[string]string) error {
+	for src, dst := range dsts {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		tmp := dst + ".tmp"
+		if err = os.WriteFile(tmp, b, 0600); err != nil {
+			return err
+		}
+		if err = os.Rename(tmp, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cfg *EtcdProcessClusterConfig) TlsArgs() (args []string) {
+	certPath, keyPath, caPath := cfg.CertFiles()
+
 	if cfg.ClientTLS != ClientNonTLS {
 		if cfg.IsClientAutoTLS {
 			args = append(args, "--auto-tls")
 		} else {
 			tlsClientArgs := []string{
-				"--cert-file", CertPath,
-				"--key-file", PrivateKeyPath,
-				"--trusted-ca-file", CaPath,
+				"--cert-file", certPath,
+				"--key-file", keyPath,
+				"--trusted-ca-file", caPath,
 			}
 			args = append(args, tlsClientArgs...)
 
@@ -398,9 +558,9 @@ func (cfg *EtcdProcessClusterConfig) TlsArgs() (args []string) {
 			args = append(args, "--peer-auto-tls")
 		} else {
 			tlsPeerArgs := []string{
-				"--peer-cert-file", CertPath,
-				"--peer-key-file", PrivateKeyPath,
-				"--peer-trusted-ca-file", CaPath,
+				"--peer-cert-file", certPath,
+				"--peer-key-file", keyPath,
+				"--peer-trusted-ca-file", caPath,
 			}
 			args = append(args, tlsPeerArgs...)
 		}
@@ -440,6 +600,25 @@ func (epc *EtcdProcessCluster) RollingStart() error {
 	return epc.rollingStart(func(ep EtcdProcess) error { return ep.Start() })
 }
 
+// RotateCerts replaces the certificate files of the members with the files
+// of bundle, an empty field keeps the current file. Members load their
+// certificate and key on every TLS handshake, so the new ones are served
+// without a restart, while a new CA is only trusted once members restart.
+// It requires CertDir, so that the shared fixtures are left untouched.
+func (epc *EtcdProcessCluster) RotateCerts(bundle config.CertBundle) error {
+	if epc.Cfg.CertDir == "" {
+		return fmt.Errorf("cluster serves the shared certificate fixtures, set CertDir to rotate them")
+	}
+	cert, key, ca := epc.Cfg.CertFiles()
+	dsts := make(map[string]string)
+	for src, dst := range map[string]string{bundle.CertFile: cert, bundle.KeyFile: key, bundle.CAFile: ca} {
+		if src != "" {
+			dsts[src] = dst
+		}
+	}
+	return copyFiles(dsts)
+}
+
 func (epc *EtcdProcessCluster) Restart() error {
 	return epc.start(func(ep EtcdProcess) error { return ep.Restart() })
 }
@@ -507,6 +686,106 @@ func (epc *EtcdProcessCluster) Close() error {
 	return err
 }
 
+// AddMember adds a member to the cluster as a learner, starts it and promotes
+// it to a voting member once it caught up with the leader.
+func (epc *EtcdProcessCluster) AddMember(tb testing.TB) error {
+	// the ports are unique among running clusters, unlike member indexes
+	// once members were removed
+	port := ReservePorts(tb, 1)
+	cfg, purls := epc.Cfg.etcdServerProcessConfig(tb, zaptest.NewLogger(tb), fmt.Sprintf("test-%d", port), port)
+	var resp *clientv3.MemberAddResponse
+	// members reject reconfigurations until they have been connected to
+	// their peers for a while
+	err := retryReconfiguration(func() (err error) {
+		resp, err = NewEtcdctl(epc.Cfg, epc.EndpointsV3()).MemberAddAsLearner(cfg.Name, purls)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot add member: %v", err)
+	}
+	var initialCluster []string
+	for _, m := range resp.Members {
+		name := m.Name
+		if m.ID == resp.Member.ID {
+			name = cfg.Name
+		}
+		for _, u := range m.PeerURLs {
+			initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", name, u))
+		}
+	}
+	cfg.InitialCluster = strings.Join(initialCluster, ",")
+	cfg.Args = append(cfg.Args, "--initial-cluster", cfg.InitialCluster, "--initial-cluster-state", "existing")
+	proc, err := NewEtcdProcess(cfg)
+	if err != nil {
+		return fmt.Errorf("cannot configure: %v", err)
+	}
+	epc.Procs = append(epc.Procs, proc)
+	if err = proc.Start(); err != nil {
+		return fmt.Errorf("cannot start member %s: %v", cfg.Name, err)
+	}
+
+	// a learner can only be promoted once it caught up with the leader
+	err = retryReconfiguration(func() error {
+		_, err := NewEtcdctl(epc.Cfg, epc.EndpointsV3()).MemberPromote(resp.Member.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot promote member %s: %v", cfg.Name, err)
+	}
+	return nil
+}
+
+// retryReconfiguration calls f until it succeeds or 10s passed, and returns
+// its last error.
+func retryReconfiguration(f func() error) error {
+	var err error
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(100 * time.Millisecond) {
+		if err = f(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// RemoveMember removes the member served by proc from the cluster and closes
+// proc.
+func (epc *EtcdProcessCluster) RemoveMember(proc EtcdProcess) error {
+	var (
+		rest      []EtcdProcess
+		endpoints []string
+	)
+	for _, p := range epc.Procs {
+		if p != proc {
+			rest = append(rest, p)
+			endpoints = append(endpoints, p.EndpointsV3()...)
+		}
+	}
+	if len(rest) == len(epc.Procs) {
+		return fmt.Errorf("member %s is not part of the cluster", proc.Config().Name)
+	}
+	ctl := NewEtcdctl(epc.Cfg, endpoints)
+	members, err := ctl.MemberList()
+	if err != nil {
+		return err
+	}
+	var id uint64
+	for _, m := range members.Members {
+		if m.Name == proc.Config().Name {
+			id = m.ID
+		}
+	}
+	if id == 0 {
+		return fmt.Errorf("member %s is not listed by the cluster", proc.Config().Name)
+	}
+	// the other members are asked, the removed one may stop before it
+	// answers
+	if _, err = ctl.MemberRemove(id); err != nil {
+		return err
+	}
+	epc.Procs = rest
+	return proc.Close()
+}
+
 func (epc *EtcdProcessCluster) WithStopSignal(sig os.Signal) (ret os.Signal) {
 	for _, p := range epc.Procs {
 		ret = p.WithStopSignal(sig)
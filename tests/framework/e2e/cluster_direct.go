@@ -17,6 +17,8 @@
 
 package e2e
 
+const ThroughProxy = false
+
 func NewEtcdProcess(cfg *EtcdServerProcessConfig) (EtcdProcess, error) {
 	return NewEtcdServerProcess(cfg)
 }
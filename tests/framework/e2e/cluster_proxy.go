@@ -30,6 +30,8 @@ import (
 	"go.uber.org/zap"
 )
 
+const ThroughProxy = true
+
 type proxyEtcdProcess struct {
 	etcdProc EtcdProcess
 	proxyV2  *proxyV2Proc
@@ -183,7 +185,7 @@ func proxyListenURL(cfg *EtcdServerProcessConfig, portOffset int) string {
 	}
 	host, port, _ := net.SplitHostPort(u.Host)
 	p, _ := strconv.ParseInt(port, 10, 16)
-	u.Host = fmt.Sprintf("%s:%d", host, int(p)+portOffset)
+	u.Host = net.JoinHostPort(host, strconv.Itoa(int(p)+portOffset))
 	return u.String()
 }
 
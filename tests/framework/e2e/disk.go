@@ -0,0 +1,78 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mountLoopFS mounts a new ext4 filesystem of the given size, backed by a
+// file, and returns its mount point. It is unmounted when the test ends.
+func mountLoopFS(tb testing.TB, size int64) string {
+	if os.Geteuid() != 0 {
+		tb.Skip("mounting loopback filesystems requires root")
+	}
+	img := filepath.Join(tb.TempDir(), "disk.img")
+	f, err := os.Create(img)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	err = f.Truncate(size)
+	f.Close()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	// the filesystem is small, drop the blocks reserved for root
+	if out, err := exec.Command("mkfs.ext4", "-q", "-F", "-m", "0", img).CombinedOutput(); err != nil {
+		tb.Fatalf("cannot create filesystem: %v (%s)", err, out)
+	}
+	mnt := tb.TempDir()
+	if out, err := exec.Command("mount", "-o", "loop", img, mnt).CombinedOutput(); err != nil {
+		tb.Fatalf("cannot mount filesystem: %v (%s)", err, out)
+	}
+	tb.Cleanup(func() {
+		if out, err := exec.Command("umount", mnt).CombinedOutput(); err != nil {
+			tb.Errorf("cannot unmount filesystem: %v (%s)", err, out)
+		}
+	})
+	return mnt
+}
+
+// SkipIfNoFailpoints skips the test unless the etcd binary at execPath was
+// built with gofail failpoints enabled, e.g. "FAILPOINTS=true make build".
+func SkipIfNoFailpoints(tb testing.TB, execPath string) {
+	ok, err := HasFailpoints(execPath)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !ok {
+		tb.Skipf("%s is not built with failpoints", execPath)
+	}
+}
+
+// HasFailpoints reports whether the etcd binary at execPath was built with
+// gofail failpoints enabled.
+func HasFailpoints(execPath string) (bool, error) {
+	out, err := exec.Command(execPath, "--version").CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("cannot get the version of %s: %v (%s)", execPath, err, out)
+	}
+	return strings.Contains(string(out), "FAILPOINTS"), nil
+}
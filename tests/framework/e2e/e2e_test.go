@@ -16,9 +16,14 @@ package e2e
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	etcdctlcmd "go.etcd.io/etcd/etcdctl/v3/ctlv3/command"
+	"go.etcd.io/etcd/tests/v3/framework/config"
 )
 
 func Test_AddTxnResponse(t *testing.T) {
@@ -37,3 +42,79 @@ func Test_AddTxnResponse(t *testing.T) {
 		t.Error("could not get original message after encoding")
 	}
 }
+
+func Test_AddNestedTxnResponse(t *testing.T) {
+	rangeResp := func(key string) *etcdserverpb.ResponseOp {
+		return &etcdserverpb.ResponseOp{Response: &etcdserverpb.ResponseOp_ResponseRange{ResponseRange: &etcdserverpb.RangeResponse{
+			Kvs:   []*mvccpb.KeyValue{{Key: []byte(key)}},
+			Count: 1,
+		}}}
+	}
+	putResp := &etcdserverpb.ResponseOp{Response: &etcdserverpb.ResponseOp_ResponsePut{ResponsePut: &etcdserverpb.PutResponse{}}}
+	want := clientv3.TxnResponse{
+		Succeeded: true,
+		Responses: []*etcdserverpb.ResponseOp{
+			rangeResp("key1"),
+			{Response: &etcdserverpb.ResponseOp_ResponseTxn{ResponseTxn: &etcdserverpb.TxnResponse{
+				Responses: []*etcdserverpb.ResponseOp{
+					{Response: &etcdserverpb.ResponseOp_ResponseTxn{ResponseTxn: &etcdserverpb.TxnResponse{
+						Succeeded: true,
+						Responses: []*etcdserverpb.ResponseOp{putResp},
+					}}},
+					rangeResp("key2"),
+				},
+			}}},
+			putResp,
+		},
+	}
+	jsonData, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp clientv3.TxnResponse
+	AddTxnResponse(&resp, string(jsonData))
+	if err = json.Unmarshal(jsonData, &resp); err != nil {
+		t.Fatalf("json Unmarshal failed. err: %s", err)
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %+v, got %+v", want, resp)
+	}
+}
+
+func TestTxnLines(t *testing.T) {
+	key := `key "with" space`
+	compares := txnCompareLines([]config.TxnCompare{
+		config.Cmp().Value(key).NotEquals("value \x23"),
+		config.Cmp().ModRevision(key).Greater(3),
+	})
+	wantCmps := []clientv3.Cmp{
+		clientv3.Compare(clientv3.Value(key), "!=", "value \x23"),
+		clientv3.Compare(clientv3.ModRevision(key), ">", 3),
+	}
+	for i, line := range compares {
+		cmp, err := etcdctlcmd.ParseCompare(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*cmp, wantCmps[i]) {
+			t.Errorf("expected %q to be parsed as %v, got %v", line, wantCmps[i], *cmp)
+		}
+	}
+
+	ops := txnOpLines([]config.TxnOp{config.Op.Put(key, "value \x23"), config.Op.Delete(key)})
+	wantArgs := [][]string{{"put", key, "value \x23"}, {"del", key}}
+	for i, line := range ops {
+		if args := etcdctlcmd.Argify(line); !reflect.DeepEqual(args, wantArgs[i]) {
+			t.Errorf("expected %q to be parsed as %q, got %q", line, wantArgs[i], args)
+		}
+	}
+
+	nested := txnOpLines([]config.TxnOp{
+		config.Op.Txn([]config.TxnCompare{config.Cmp().Version("key1").Equals(1)}, []config.TxnOp{config.Op.Get("key1")}, nil),
+		config.Op.Delete("key2"),
+	})
+	wantLines := []string{"txn", `version("key1") = "1"`, "", `get "key1"`, "", "", `del "key2"`}
+	if !reflect.DeepEqual(nested, wantLines) {
+		t.Errorf("expected nested transaction lines %q, got %q", wantLines, nested)
+	}
+}
@@ -143,6 +143,12 @@ func (ep *EtcdServerProcess) Stop() (err error) {
 			return err
 		}
 	}
+	if curl, perr := url.Parse(ep.cfg.Acurl); perr == nil && (curl.Scheme == "unix" || curl.Scheme == "unixs") {
+		err = os.Remove(curl.Host + curl.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	ep.cfg.lg.Info("stopped server.", zap.String("name", ep.cfg.Name))
 	return nil
 }
@@ -15,21 +15,29 @@
 package e2e
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.etcd.io/etcd/api/v3/authpb"
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/expect"
 	"go.etcd.io/etcd/tests/v3/framework/config"
 )
 
 type EtcdctlV3 struct {
 	cfg       *EtcdProcessClusterConfig
 	endpoints []string
+	// user is passed as --user, "name:password", if set.
+	user string
+	// cert overrides the certificate files of the cluster, if set.
+	cert config.CertBundle
 }
 
 func NewEtcdctl(cfg *EtcdProcessClusterConfig, endpoints []string) *EtcdctlV3 {
@@ -39,19 +47,48 @@ func NewEtcdctl(cfg *EtcdProcessClusterConfig, endpoints []string) *EtcdctlV3 {
 	}
 }
 
-func (ctl *EtcdctlV3) DowngradeEnable(version string) error {
-	return SpawnWithExpect(ctl.cmdArgs("downgrade", "enable", version), "Downgrade enable success")
+// WithAuth returns a copy of ctl which authenticates as user with password.
+func (ctl *EtcdctlV3) WithAuth(user, password string) *EtcdctlV3 {
+	c := *ctl
+	c.user = user + ":" + password
+	return &c
+}
+
+// WithCert returns a copy of ctl which presents the certificate and key of
+// bundle, and trusts its CA if set.
+func (ctl *EtcdctlV3) WithCert(bundle config.CertBundle) *EtcdctlV3 {
+	c := *ctl
+	c.cert = bundle
+	return &c
+}
+
+func (ctl *EtcdctlV3) DowngradeValidate(version string) (*clientv3.DowngradeResponse, error) {
+	var resp clientv3.DowngradeResponse
+	err := ctl.spawnJsonCmd(&resp, "downgrade", "validate", version)
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) DowngradeEnable(version string) (*clientv3.DowngradeResponse, error) {
+	var resp clientv3.DowngradeResponse
+	err := ctl.spawnJsonCmd(&resp, "downgrade", "enable", version)
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) DowngradeCancel() (*clientv3.DowngradeResponse, error) {
+	var resp clientv3.DowngradeResponse
+	err := ctl.spawnJsonCmd(&resp, "downgrade", "cancel")
+	return &resp, err
 }
 
 func (ctl *EtcdctlV3) Get(key string, o config.GetOptions) (*clientv3.GetResponse, error) {
-	args := ctl.cmdArgs()
+	var args []string
 	if o.Timeout != 0 {
 		args = append(args, fmt.Sprintf("--command-timeout=%s", o.Timeout))
 	}
 	if o.Serializable {
 		args = append(args, "--consistency", "s")
 	}
-	args = append(args, "get", key, "-w", "json")
+	args = append(args, "get", key)
 	if o.End != "" {
 		args = append(args, o.End)
 	}
@@ -69,8 +106,6 @@ func (ctl *EtcdctlV3) Get(key string, o config.GetOptions) (*clientv3.GetRespons
 	}
 	if o.CountOnly {
 		args = append(args, "-w", "fields", "--count-only")
-	} else {
-		args = append(args, "-w", "json")
 	}
 	switch o.SortBy {
 	case clientv3.SortByCreateRevision:
@@ -96,35 +131,35 @@ func (ctl *EtcdctlV3) Get(key string, o config.GetOptions) (*clientv3.GetRespons
 	default:
 		return nil, fmt.Errorf("bad sort order %v", o.Order)
 	}
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.GetResponse
 	if o.CountOnly {
-		_, err := cmd.Expect("Count")
+		// The count is only printed with the fields output.
+		cmd, err := SpawnCmd(ctl.cmdArgs(args...), nil)
+		if err != nil {
+			return nil, err
+		}
+		line, err := cmd.Expect("Count")
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Sscanf(strings.TrimSpace(line), `"Count" : %d`, &resp.Count)
 		return &resp, err
 	}
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, args...)
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) Put(key, value string, opts config.PutOptions) error {
-	args := ctl.cmdArgs()
-	args = append(args, "put", key, value)
+	args := []string{"put", key, value}
 	if opts.LeaseID != 0 {
 		args = append(args, "--lease", strconv.FormatInt(int64(opts.LeaseID), 16))
 	}
-	return SpawnWithExpect(args, "OK")
+	var resp clientv3.PutResponse
+	return ctl.spawnJsonCmd(&resp, args...)
 }
 
 func (ctl *EtcdctlV3) Delete(key string, o config.DeleteOptions) (*clientv3.DeleteResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "del", key, "-w", "json")
+	args := []string{"del", key}
 	if o.End != "" {
 		args = append(args, o.End)
 	}
@@ -134,69 +169,42 @@ func (ctl *EtcdctlV3) Delete(key string, o config.DeleteOptions) (*clientv3.Dele
 	if o.FromKey {
 		args = append(args, "--from-key")
 	}
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.DeleteResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, args...)
 	return &resp, err
 }
 
-func (ctl *EtcdctlV3) Txn(compares, ifSucess, ifFail []string, o config.TxnOptions) (*clientv3.TxnResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "txn")
+func (ctl *EtcdctlV3) Txn(compares []config.TxnCompare, ifSucess, ifFail []config.TxnOp, o config.TxnOptions) (*clientv3.TxnResponse, error) {
+	args := []string{"txn"}
 	if o.Interactive {
 		args = append(args, "--interactive")
 	}
 	args = append(args, "-w", "json", "--hex=true")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
-	_, err = cmd.Expect("compares:")
+	s, err := ctl.Session(args...)
 	if err != nil {
 		return nil, err
 	}
-	for _, cmp := range compares {
-		if err := cmd.Send(cmp + "\r"); err != nil {
-			return nil, err
-		}
-	}
-	if err := cmd.Send("\r"); err != nil {
-		return nil, err
-	}
-	_, err = cmd.Expect("success requests (get, put, del):")
-	if err != nil {
-		return nil, err
+	defer s.Stop()
+	steps := []struct {
+		prompt string
+		lines  []string
+	}{
+		{"compares:", txnCompareLines(compares)},
+		{"success requests (get, put, del):", txnOpLines(ifSucess)},
+		{"failure requests (get, put, del):", txnOpLines(ifFail)},
 	}
-	for _, req := range ifSucess {
-		if err = cmd.Send(req + "\r"); err != nil {
+	for _, step := range steps {
+		if _, err = s.Expect(step.prompt); err != nil {
 			return nil, err
 		}
-	}
-	if err = cmd.Send("\r"); err != nil {
-		return nil, err
-	}
-
-	_, err = cmd.Expect("failure requests (get, put, del):")
-	if err != nil {
-		return nil, err
-	}
-	for _, req := range ifFail {
-		if err = cmd.Send(req + "\r"); err != nil {
-			return nil, err
+		// an empty line ends each section
+		for _, line := range append(step.lines, "") {
+			if err = s.Send(line); err != nil {
+				return nil, err
+			}
 		}
 	}
-	if err = cmd.Send("\r"); err != nil {
-		return nil, err
-	}
-	var line string
-	line, err = cmd.Expect("header")
+	line, err := s.Expect("header")
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +214,43 @@ func (ctl *EtcdctlV3) Txn(compares, ifSucess, ifFail []string, o config.TxnOptio
 	return &resp, err
 }
 
+// txnCompareLines formats comparisons as etcdctl txn reads them.
+func txnCompareLines(compares []config.TxnCompare) []string {
+	var lines []string
+	for _, c := range compares {
+		v := c.Value
+		if c.Target != config.CompareValue {
+			v = strconv.FormatInt(c.Revision, 10)
+		}
+		lines = append(lines, fmt.Sprintf("%s(%q) %s %q", c.Target, c.Key, c.Result, v))
+	}
+	return lines
+}
+
+// txnOpLines formats requests as etcdctl txn reads them.
+func txnOpLines(ops []config.TxnOp) []string {
+	var lines []string
+	for _, op := range ops {
+		if op.Type == config.TxnOpTxn {
+			// the sections of a nested transaction end with an empty line
+			lines = append(lines, "txn")
+			lines = append(lines, txnCompareLines(op.Compares)...)
+			lines = append(lines, "")
+			lines = append(lines, txnOpLines(op.Success)...)
+			lines = append(lines, "")
+			lines = append(lines, txnOpLines(op.Failure)...)
+			lines = append(lines, "")
+			continue
+		}
+		line := fmt.Sprintf("%s %q", op.Type, op.Key)
+		if op.Type == config.TxnOpPut {
+			line += fmt.Sprintf(" %q", op.Value)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
 // AddTxnResponse looks for ResponseOp json tags and adds the objects for json decoding
 func AddTxnResponse(resp *clientv3.TxnResponse, jsonData string) {
 	if resp == nil {
@@ -214,73 +259,84 @@ func AddTxnResponse(resp *clientv3.TxnResponse, jsonData string) {
 	if resp.Responses == nil {
 		resp.Responses = []*etcdserverpb.ResponseOp{}
 	}
+	// nested holds the responses of the transactions the decoder is in and
+	// the json depth their objects end at.
+	type txnLevel struct {
+		responses *[]*etcdserverpb.ResponseOp
+		depth     int
+	}
+	nested := []txnLevel{{responses: &resp.Responses}}
+	depth := 0
 	jd := json.NewDecoder(strings.NewReader(jsonData))
 	for {
 		t, e := jd.Token()
 		if e == io.EOF {
 			break
 		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+			if len(nested) > 1 && depth < nested[len(nested)-1].depth {
+				nested = nested[:len(nested)-1]
+			}
+		}
+		responses := nested[len(nested)-1].responses
 		if t == "response_range" {
-			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+			*responses = append(*responses, &etcdserverpb.ResponseOp{
 				Response: &etcdserverpb.ResponseOp_ResponseRange{},
 			})
 		}
 		if t == "response_put" {
-			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+			*responses = append(*responses, &etcdserverpb.ResponseOp{
 				Response: &etcdserverpb.ResponseOp_ResponsePut{},
 			})
 		}
 		if t == "response_delete_range" {
-			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
+			*responses = append(*responses, &etcdserverpb.ResponseOp{
 				Response: &etcdserverpb.ResponseOp_ResponseDeleteRange{},
 			})
 		}
 		if t == "response_txn" {
-			resp.Responses = append(resp.Responses, &etcdserverpb.ResponseOp{
-				Response: &etcdserverpb.ResponseOp_ResponseTxn{},
+			txn := &etcdserverpb.TxnResponse{Responses: []*etcdserverpb.ResponseOp{}}
+			*responses = append(*responses, &etcdserverpb.ResponseOp{
+				Response: &etcdserverpb.ResponseOp_ResponseTxn{ResponseTxn: txn},
 			})
+			// the responses that follow belong to the nested transaction
+			// until its object, which opens next, ends
+			nested = append(nested, txnLevel{responses: &txn.Responses, depth: depth + 1})
 		}
 	}
 }
 func (ctl *EtcdctlV3) MemberList() (*clientv3.MemberListResponse, error) {
-	cmd, err := SpawnCmd(ctl.cmdArgs("member", "list", "-w", "json"), nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.MemberListResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "member", "list")
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) MemberAddAsLearner(name string, peerAddrs []string) (*clientv3.MemberAddResponse, error) {
-	cmd, err := SpawnCmd(ctl.cmdArgs("member", "add", name, "--learner", "--peer-urls", strings.Join(peerAddrs, ","), "-w", "json"), nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.MemberAddResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "member", "add", name, "--learner", "--peer-urls", strings.Join(peerAddrs, ","))
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) MemberRemove(id uint64) (*clientv3.MemberRemoveResponse, error) {
-	cmd, err := SpawnCmd(ctl.cmdArgs("member", "remove", fmt.Sprintf("%x", id), "-w", "json"), nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.MemberRemoveResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "member", "remove", fmt.Sprintf("%x", id))
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) MemberPromote(id uint64) (*clientv3.MemberPromoteResponse, error) {
+	var resp clientv3.MemberPromoteResponse
+	err := ctl.spawnJsonCmd(&resp, "member", "promote", fmt.Sprintf("%x", id))
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) MoveLeader(targetID uint64) (*clientv3.MoveLeaderResponse, error) {
+	var resp clientv3.MoveLeaderResponse
+	// The leader does not fill the header of the response.
+	err := ctl.spawnJsonCmdExpect(&resp, "{", "move-leader", fmt.Sprintf("%x", targetID))
 	return &resp, err
 }
 
@@ -303,10 +359,21 @@ func (ctl *EtcdctlV3) flags() map[string]string {
 			fmap["cert"] = RevokedCertPath
 			fmap["key"] = RevokedPrivateKeyPath
 		} else {
-			fmap["cacert"] = CaPath
-			fmap["cert"] = CertPath
-			fmap["key"] = PrivateKeyPath
+			cert, key, ca := ctl.cfg.CertFiles()
+			fmap["cacert"] = ca
+			fmap["cert"] = cert
+			fmap["key"] = key
+		}
+		if ctl.cert.CertFile != "" {
+			fmap["cert"] = ctl.cert.CertFile
+			fmap["key"] = ctl.cert.KeyFile
 		}
+		if ctl.cert.CAFile != "" {
+			fmap["cacert"] = ctl.cert.CAFile
+		}
+	}
+	if ctl.user != "" {
+		fmap["user"] = ctl.user
 	}
 	fmap["endpoints"] = strings.Join(ctl.endpoints, ",")
 	return fmap
@@ -327,102 +394,72 @@ func (ctl *EtcdctlV3) Compact(rev int64, o config.CompactOption) (*clientv3.Comp
 }
 
 func (ctl *EtcdctlV3) Status() ([]*clientv3.StatusResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "endpoint", "status", "-w", "json")
-	args = append(args, "--endpoints", strings.Join(ctl.endpoints, ","))
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var epStatus []*struct {
 		Endpoint string
 		Status   *clientv3.StatusResponse
 	}
-	line, err := cmd.Expect("header")
-	if err != nil {
+	if err := ctl.spawnJsonCmd(&epStatus, "endpoint", "status"); err != nil {
 		return nil, err
 	}
-	err = json.Unmarshal([]byte(line), &epStatus)
-	if err != nil {
-		return nil, err
-	}
-	resp := make([]*clientv3.StatusResponse, len(epStatus))
+	resp := make([]*clientv3.StatusResponse, 0, len(epStatus))
 	for _, e := range epStatus {
 		resp = append(resp, e.Status)
 	}
-	return resp, err
+	return resp, nil
 }
 
 func (ctl *EtcdctlV3) HashKV(rev int64) ([]*clientv3.HashKVResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "endpoint", "hashkv", "-w", "json")
-	args = append(args, "--endpoints", strings.Join(ctl.endpoints, ","))
-	args = append(args, "--rev", fmt.Sprint(rev))
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var epHashKVs []*struct {
 		Endpoint string
 		HashKV   *clientv3.HashKVResponse
 	}
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &epHashKVs)
-	if err != nil {
+	if err := ctl.spawnJsonCmd(&epHashKVs, "endpoint", "hashkv", "--rev", fmt.Sprint(rev)); err != nil {
 		return nil, err
 	}
-	resp := make([]*clientv3.HashKVResponse, len(epHashKVs))
+	resp := make([]*clientv3.HashKVResponse, 0, len(epHashKVs))
 	for _, e := range epHashKVs {
 		resp = append(resp, e.HashKV)
 	}
-	return resp, err
+	return resp, nil
 }
 
 func (ctl *EtcdctlV3) Health() error {
-	args := ctl.cmdArgs()
-	args = append(args, "endpoint", "health")
-	lines := make([]string, len(ctl.endpoints))
-	for i := range lines {
-		lines[i] = "is healthy"
+	cmd, err := SpawnCmd(ctl.cmdArgs("endpoint", "health", "-w", "json"), nil)
+	if err != nil {
+		return err
+	}
+	var epHealth []struct {
+		Endpoint string `json:"endpoint"`
+		Health   bool   `json:"health"`
+		Error    string `json:"error"`
+	}
+	if err = expectJSON(cmd, "endpoint", &epHealth); err != nil {
+		return err
+	}
+	if len(epHealth) != len(ctl.endpoints) {
+		return fmt.Errorf("expected health of %d endpoints, got %d", len(ctl.endpoints), len(epHealth))
+	}
+	for _, h := range epHealth {
+		if !h.Health {
+			return fmt.Errorf("endpoint %s is unhealthy: %s", h.Endpoint, h.Error)
+		}
 	}
-	return SpawnWithExpects(args, map[string]string{}, lines...)
+	return nil
 }
 
 func (ctl *EtcdctlV3) Grant(ttl int64) (*clientv3.LeaseGrantResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "lease", "grant", strconv.FormatInt(ttl, 10), "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.LeaseGrantResponse
-	line, err := cmd.Expect("ID")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmdExpect(&resp, "ID", "lease", "grant", strconv.FormatInt(ttl, 10))
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) TimeToLive(id clientv3.LeaseID, o config.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "lease", "timetolive", strconv.FormatInt(int64(id), 16), "-w", "json")
+	args := []string{"lease", "timetolive", strconv.FormatInt(int64(id), 16)}
 	if o.WithAttachedKeys {
 		args = append(args, "--keys")
 	}
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.LeaseTimeToLiveResponse
-	line, err := cmd.Expect("id")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmdExpect(&resp, "id", args...)
 	return &resp, err
 }
 
@@ -440,82 +477,38 @@ func (ctl *EtcdctlV3) Defragment(o config.DefragOption) error {
 }
 
 func (ctl *EtcdctlV3) LeaseList() (*clientv3.LeaseLeasesResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "lease", "list", "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.LeaseLeasesResponse
-	line, err := cmd.Expect("id")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmdExpect(&resp, "id", "lease", "list")
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) LeaseKeepAliveOnce(id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "lease", "keep-alive", strconv.FormatInt(int64(id), 16), "--once", "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.LeaseKeepAliveResponse
-	line, err := cmd.Expect("ID")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmdExpect(&resp, "ID", "lease", "keep-alive", strconv.FormatInt(int64(id), 16), "--once")
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) LeaseRevoke(id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "lease", "revoke", strconv.FormatInt(int64(id), 16), "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.LeaseRevokeResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "lease", "revoke", strconv.FormatInt(int64(id), 16))
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) AlarmList() (*clientv3.AlarmResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "alarm", "list", "-w", "json")
-	ep, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
+	// The response has no "alarms" field when no alarm is raised.
 	var resp clientv3.AlarmResponse
-	line, err := ep.Expect("alarm")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "alarm", "list")
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) AlarmDisarm(_ *clientv3.AlarmMember) (*clientv3.AlarmResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "alarm", "disarm", "-w", "json")
-	ep, err := SpawnCmd(args, nil)
+	cmd, err := SpawnCmd(ctl.cmdArgs("alarm", "disarm", "-w", "json"), nil)
 	if err != nil {
 		return nil, err
 	}
+	// Disarming all alarms aggregates the responses without a header.
 	var resp clientv3.AlarmResponse
-	line, err := ep.Expect("alarm")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err = expectJSON(cmd, "alarm", &resp)
 	return &resp, err
 }
 
@@ -549,43 +542,19 @@ func (ctl *EtcdctlV3) UserAdd(name, password string, opts config.UserAddOptions)
 	}
 
 	var resp clientv3.AuthUserAddResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err = expectJSON(cmd, "header", &resp)
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) UserList() (*clientv3.AuthUserListResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "user", "list", "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.AuthUserListResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "user", "list")
 	return &resp, err
 }
 
 func (ctl *EtcdctlV3) UserDelete(name string) (*clientv3.AuthUserDeleteResponse, error) {
-	args := ctl.cmdArgs()
-	args = append(args, "user", "delete", name, "-w", "json")
-	cmd, err := SpawnCmd(args, nil)
-	if err != nil {
-		return nil, err
-	}
 	var resp clientv3.AuthUserDeleteResponse
-	line, err := cmd.Expect("header")
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(line), &resp)
+	err := ctl.spawnJsonCmd(&resp, "user", "delete", name)
 	return &resp, err
 }
 
@@ -606,6 +575,33 @@ func (ctl *EtcdctlV3) UserChangePass(user, newPass string) error {
 	return err
 }
 
+func (ctl *EtcdctlV3) UserGet(name string) (*clientv3.AuthUserGetResponse, error) {
+	var resp clientv3.AuthUserGetResponse
+	err := ctl.spawnJsonCmd(&resp, "user", "get", name)
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) UserGrantRole(user, role string) (*clientv3.AuthUserGrantRoleResponse, error) {
+	var resp clientv3.AuthUserGrantRoleResponse
+	err := ctl.spawnJsonCmd(&resp, "user", "grant-role", user, role)
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) UserRevokeRole(user, role string) (*clientv3.AuthUserRevokeRoleResponse, error) {
+	var resp clientv3.AuthUserRevokeRoleResponse
+	err := ctl.spawnJsonCmd(&resp, "user", "revoke-role", user, role)
+	return &resp, err
+}
+
+func (ctl *EtcdctlV3) AuthEnable() error {
+	cmd, err := SpawnCmd(ctl.cmdArgs("auth", "enable"), nil)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.Expect("Authentication Enabled")
+	return err
+}
+
 func (ctl *EtcdctlV3) RoleAdd(name string) (*clientv3.AuthRoleAddResponse, error) {
 	var resp clientv3.AuthRoleAddResponse
 	err := ctl.spawnJsonCmd(&resp, "role", "add", name)
@@ -643,15 +639,121 @@ func (ctl *EtcdctlV3) RoleDelete(role string) (*clientv3.AuthRoleDeleteResponse,
 	return &resp, err
 }
 
+// Watch runs "etcdctl watch" until ctx is done, decoding the JSON output
+// into watch responses. The returned channel is closed when the watch ends,
+// including when etcdctl exits on its own, e.g. once it lost its connection.
+func (ctl *EtcdctlV3) Watch(ctx context.Context, key string, opts config.WatchOptions) (clientv3.WatchChan, error) {
+	args := ctl.cmdArgs()
+	args = append(args, "watch", key)
+	if opts.RangeEnd != "" {
+		args = append(args, opts.RangeEnd)
+	}
+	args = append(args, "-w", "json")
+	if opts.Prefix {
+		args = append(args, "--prefix")
+	}
+	if opts.Revision != 0 {
+		args = append(args, "--rev", fmt.Sprint(opts.Revision))
+	}
+	if opts.PrevKV {
+		args = append(args, "--prev-kv")
+	}
+	if opts.ProgressNotify {
+		args = append(args, "--progress-notify")
+	}
+	if opts.FilterPut {
+		args = append(args, "--filter-put")
+	}
+	if opts.FilterDelete {
+		args = append(args, "--filter-delete")
+	}
+	if opts.Fragment {
+		args = append(args, "--fragment")
+	}
+
+	proc, err := SpawnCmd(args, nil)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ch)
+		defer proc.Stop()
+		for {
+			// check for exit first, so that lines printed just before
+			// the process exited are still read below
+			exited := proc.Err() != nil
+			line := proc.ReadLine()
+			if line == "" {
+				if exited {
+					reportWatchExit(args, proc)
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+				continue
+			}
+			// skip everything except responses, e.g. "progress notify: 5"
+			if !strings.HasPrefix(line, "{") {
+				continue
+			}
+			var resp clientv3.WatchResponse
+			if err := json.Unmarshal([]byte(line), &resp); err != nil {
+				continue
+			}
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+				return
+			}
+			if resp.Canceled {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// reportWatchExit waits for the etcdctl watch proc, which exited before its
+// watch was canceled, and reports its exit error with its last lines.
+func reportWatchExit(args []string, proc *expect.ExpectProcess) {
+	err := proc.Wait()
+	lines := proc.Lines()
+	if len(lines) > expect.DEBUG_LINES_TAIL {
+		lines = lines[len(lines)-expect.DEBUG_LINES_TAIL:]
+	}
+	fmt.Fprintf(os.Stderr, "%v exited before its watch was canceled (%v), last lines:\n%s", args, err, strings.Join(lines, ""))
+}
+
+// spawnJsonCmd runs etcdctl with JSON output and decodes the response into
+// output, which should be the clientv3 response type of the command.
 func (ctl *EtcdctlV3) spawnJsonCmd(output interface{}, args ...string) error {
+	return ctl.spawnJsonCmdExpect(output, "header", args...)
+}
+
+// spawnJsonCmdExpect is like spawnJsonCmd for responses without a "header"
+// field, such as the lease responses which inline the header fields.
+func (ctl *EtcdctlV3) spawnJsonCmdExpect(output interface{}, substr string, args ...string) error {
 	args = append(args, "-w", "json")
 	cmd, err := SpawnCmd(append(ctl.cmdArgs(), args...), nil)
 	if err != nil {
 		return err
 	}
-	line, err := cmd.Expect("header")
+	return expectJSON(cmd, substr, output)
+}
+
+// expectJSON waits for the first output line of cmd containing substr and
+// decodes it into output.
+func expectJSON(cmd *expect.ExpectProcess, substr string, output interface{}) error {
+	line, err := cmd.Expect(substr)
 	if err != nil {
 		return err
 	}
+	if resp, ok := output.(*clientv3.TxnResponse); ok {
+		AddTxnResponse(resp, line)
+	}
 	return json.Unmarshal([]byte(line), output)
 }
@@ -0,0 +1,125 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/pkg/v3/expect"
+)
+
+// DefaultCtlSessionTimeout is how long a CtlSession waits for each expected
+// line unless changed with SetTimeout.
+const DefaultCtlSessionTimeout = 10 * time.Second
+
+// CtlSession keeps a single interactive etcdctl process, such as "watch",
+// "txn --interactive" or "lease keep-alive", alive across multiple Send and
+// Expect steps. Unlike ExpectProcess.Expect, each Expect only matches lines
+// printed after the line matched by the previous one, and gives up after the
+// session timeout instead of waiting for the process to exit.
+type CtlSession struct {
+	proc    *expect.ExpectProcess
+	args    []string
+	timeout time.Duration
+}
+
+// Session starts etcdctl with the given arguments, e.g. "watch", "foo", and
+// returns the session driving it. The caller must Close or Stop it.
+func (ctl *EtcdctlV3) Session(args ...string) (*CtlSession, error) {
+	args = ctl.cmdArgs(args...)
+	proc, err := SpawnCmd(args, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CtlSession{proc: proc, args: args, timeout: DefaultCtlSessionTimeout}, nil
+}
+
+// SetTimeout changes how long the following Expect calls and Close wait.
+func (s *CtlSession) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
+// Send writes line to the standard input of etcdctl, followed by a newline.
+func (s *CtlSession) Send(line string) error {
+	return s.proc.Send(line + "\r")
+}
+
+// Expect returns the next line containing substr.
+func (s *CtlSession) Expect(substr string) (string, error) {
+	return s.ExpectFunc(func(line string) bool { return strings.Contains(line, substr) })
+}
+
+// ExpectFunc returns the next line satisfying f. Lines not satisfying f are
+// skipped. It fails if etcdctl exits or no such line is printed within the
+// session timeout.
+func (s *CtlSession) ExpectFunc(f func(string) bool) (string, error) {
+	deadline := time.Now().Add(s.timeout)
+	for {
+		// check for exit first, so that lines printed just before
+		// the process exited are still read below
+		exited := s.proc.Err() != nil
+		if line := s.proc.ReadLine(); line != "" {
+			if f(line) {
+				return line, nil
+			}
+			continue
+		}
+		if exited {
+			return "", fmt.Errorf("%v exited before the expected line was printed (%v), last lines:\n%s", s.args, s.proc.Err(), s.lastLines())
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%v did not print the expected line within %v, last lines:\n%s", s.args, s.timeout, s.lastLines())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ExpectJSON decodes the next line containing substr into output, e.g. a
+// clientv3 response when etcdctl was started with "-w json".
+func (s *CtlSession) ExpectJSON(substr string, output interface{}) error {
+	line, err := s.Expect(substr)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(line), output)
+}
+
+// Signal sends sig to etcdctl, e.g. os.Interrupt to end a watch.
+func (s *CtlSession) Signal(sig os.Signal) error {
+	return s.proc.Signal(sig)
+}
+
+// Close waits for etcdctl to exit, and kills it once the session timeout
+// elapsed.
+func (s *CtlSession) Close() error {
+	return CloseWithTimeout(s.proc, s.timeout)
+}
+
+// Stop kills etcdctl and waits for it to exit.
+func (s *CtlSession) Stop() error {
+	return s.proc.Stop()
+}
+
+func (s *CtlSession) lastLines() string {
+	lines := s.proc.Lines()
+	if len(lines) > expect.DEBUG_LINES_TAIL {
+		lines = lines[len(lines)-expect.DEBUG_LINES_TAIL:]
+	}
+	return strings.Join(lines, "")
+}
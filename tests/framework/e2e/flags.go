@@ -36,6 +36,9 @@ var (
 	CertPath3       string
 	PrivateKeyPath3 string
 
+	CertPathIPv6       string
+	PrivateKeyPathIPv6 string
+
 	CrlPath               string
 	RevokedCertPath       string
 	RevokedPrivateKeyPath string
@@ -69,4 +72,7 @@ func InitFlags() {
 
 	CertPath3 = CertDir + "/server3.crt"
 	PrivateKeyPath3 = CertDir + "/server3.key.insecure"
+
+	CertPathIPv6 = CertDir + "/server-ipv6.crt"
+	PrivateKeyPathIPv6 = CertDir + "/server-ipv6.key.insecure"
 }
@@ -0,0 +1,150 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+)
+
+const (
+	// PortsPerMember is the number of consecutive ports used by each member
+	// of an e2e cluster: client, peer, metrics and the two proxy ports.
+	PortsPerMember = 5
+
+	// The dynamic range starts above the fixed ports used by legacy e2e tests
+	// (EtcdProcessBasePort and friends) and stays below 32768, since proxy
+	// ports are parsed as int16.
+	dynamicPortRangeStart = 22000
+	dynamicPortRangeEnd   = 32000
+)
+
+// DefaultPortAllocator is shared by all clusters started from this process.
+// Its lock directory is shared across processes, so e2e clusters started by
+// test binaries running in parallel never collide either.
+var DefaultPortAllocator = NewPortAllocator(filepath.Join(os.TempDir(), "etcd-e2e-ports"), dynamicPortRangeStart, dynamicPortRangeEnd)
+
+// PortAllocator hands out blocks of ports to e2e clusters. Every member slot
+// of PortsPerMember ports is guarded by a lock file in dir, which is released
+// when the reservation is released or the owning process exits.
+type PortAllocator struct {
+	mu    sync.Mutex
+	dir   string
+	start int
+	end   int
+	next  int
+}
+
+// NewPortAllocator creates an allocator handing out ports in [start, end).
+func NewPortAllocator(dir string, start, end int) *PortAllocator {
+	return &PortAllocator{dir: dir, start: start, end: end, next: start}
+}
+
+// PortReservation is a block of consecutive ports held for a cluster.
+type PortReservation struct {
+	// BasePort is the first port of the block, suitable for
+	// EtcdProcessClusterConfig.BasePort.
+	BasePort int
+	// Members is the number of member slots in the block.
+	Members int
+
+	locks []*fileutil.LockedFile
+}
+
+// Reserve reserves consecutive ports for a cluster of the given number of members.
+func (pa *PortAllocator) Reserve(members int) (*PortReservation, error) {
+	if members < 1 {
+		return nil, fmt.Errorf("invalid number of members %d", members)
+	}
+	size := members * PortsPerMember
+	if size > pa.end-pa.start {
+		return nil, fmt.Errorf("cannot reserve %d ports in range [%d, %d)", size, pa.start, pa.end)
+	}
+	if err := os.MkdirAll(pa.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	for attempted := 0; attempted < pa.end-pa.start; attempted += PortsPerMember {
+		base := pa.next
+		if base+size > pa.end {
+			base = pa.start
+		}
+		pa.next = base + PortsPerMember
+		if r, ok := pa.tryReserve(base, members); ok {
+			pa.next = base + size
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("no free ports for %d members in range [%d, %d)", members, pa.start, pa.end)
+}
+
+func (pa *PortAllocator) tryReserve(base, members int) (*PortReservation, bool) {
+	r := &PortReservation{BasePort: base, Members: members}
+	for i := 0; i < members; i++ {
+		slot := base + i*PortsPerMember
+		l, err := fileutil.TryLockFile(filepath.Join(pa.dir, fmt.Sprintf("%d.lock", slot)), os.O_WRONLY|os.O_CREATE, fileutil.PrivateFileMode)
+		if err != nil {
+			r.Release()
+			return nil, false
+		}
+		r.locks = append(r.locks, l)
+		// The lock only guards against other e2e clusters, make sure the
+		// ports are not used by anything else on the host either.
+		for port := slot; port < slot+PortsPerMember; port++ {
+			if !isPortFree(port) {
+				r.Release()
+				return nil, false
+			}
+		}
+	}
+	return r, true
+}
+
+func isPortFree(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// Release returns the ports to the allocator. It is safe to call more than once.
+func (r *PortReservation) Release() {
+	for _, l := range r.locks {
+		l.Close()
+	}
+	r.locks = nil
+}
+
+// ReservePorts reserves ports for a cluster of the given number of members
+// from DefaultPortAllocator and returns the base port. The ports are released
+// when the test and all its subtests complete.
+func ReservePorts(tb testing.TB, members int) int {
+	r, err := DefaultPortAllocator.Reserve(members)
+	if err != nil {
+		tb.Fatalf("could not reserve ports: %v", err)
+	}
+	tb.Cleanup(r.Release)
+	return r.BasePort
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"testing"
+)
+
+func TestPortAllocatorReserve(t *testing.T) {
+	dir := t.TempDir()
+	pa := NewPortAllocator(dir, 15000, 15000+4*PortsPerMember)
+	// A second allocator on the same directory simulates another test process.
+	other := NewPortAllocator(dir, 15000, 15000+4*PortsPerMember)
+
+	r1, err := pa.Reserve(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = other.Reserve(2); err == nil {
+		t.Fatal("expected reservation overlapping with a held reservation to fail")
+	}
+	r2, err := other.Reserve(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.BasePort < r1.BasePort+3*PortsPerMember && r1.BasePort < r2.BasePort+PortsPerMember {
+		t.Fatalf("reservations overlap: %d+%d and %d+%d", r1.BasePort, r1.Members, r2.BasePort, r2.Members)
+	}
+
+	r1.Release()
+	r1.Release()
+	r3, err := other.Reserve(3)
+	if err != nil {
+		t.Fatalf("expected released ports to be reusable, got %v", err)
+	}
+	r2.Release()
+	r3.Release()
+}
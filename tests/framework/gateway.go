@@ -0,0 +1,69 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+)
+
+type gatewayClient struct {
+	endpoint string
+	tlsInfo  transport.TLSInfo
+}
+
+func (c gatewayClient) Post(path, token string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	hreq, err := http.NewRequest(http.MethodPost, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		hreq.Header.Set("Authorization", token)
+	}
+	// A transport per request keeps no idle connections around, which the
+	// goroutine leak detection of the runners would report.
+	tr, err := transport.NewTransport(c.tlsInfo, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer tr.CloseIdleConnections()
+	hresp, err := (&http.Client{Transport: tr, Timeout: 10 * time.Second}).Do(hreq)
+	if err != nil {
+		return err
+	}
+	defer hresp.Body.Close()
+	data, err := io.ReadAll(hresp.Body)
+	if err != nil {
+		return err
+	}
+	if hresp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", path, hresp.Status, data)
+	}
+	if err = json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("%s: could not decode %q: %w", path, data, err)
+	}
+	return nil
+}
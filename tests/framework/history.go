@@ -0,0 +1,254 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+// linearizabilityTimeout bounds the time spent checking a history, the
+// problem is NP-hard.
+const linearizabilityTimeout = 5 * time.Minute
+
+// RecordHistory returns a client of the cluster which records the Put, Get,
+// Delete and Txn requests it sends, and checks at the end of the test that
+// all the requests recorded for the cluster are linearizable. Every write to
+// the keys the test reads must go through recording clients, and must not
+// attach leases, as the model does not expire keys.
+func RecordHistory(t testing.TB, clus Cluster) Client {
+	h := clus.recorder()
+	h.mu.Lock()
+	if !h.checked {
+		h.checked = true
+		t.Cleanup(func() {
+			if err := h.VerifyLinearizability(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	h.mu.Unlock()
+	return h.newClient(clus.Client())
+}
+
+// history records the requests sent to a cluster by recording clients.
+type history struct {
+	mu      sync.Mutex
+	ops     []porcupine.Operation
+	clients int
+	// unsupported lists the writes the model cannot check.
+	unsupported []string
+	checked     bool
+}
+
+func (h *history) recorder() *history { return h }
+
+func (h *history) newClient(c Client) *recordingClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients++
+	return &recordingClient{Client: c, h: h, id: h.clients - 1}
+}
+
+func (h *history) append(id int, req request, call time.Time, resp response, err error) {
+	op := porcupine.Operation{ClientId: id, Input: req, Call: call.UnixNano(), Output: resp, Return: time.Now().UnixNano()}
+	if err != nil {
+		if req.kind == rangeRequest {
+			return
+		}
+		// A failed write may be applied at any point after it was sent.
+		op.Output, op.Return = response{failed: true}, math.MaxInt64
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ops = append(h.ops, op)
+}
+
+func (h *history) appendUnsupported(desc string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsupported = append(h.unsupported, desc)
+}
+
+// VerifyLinearizability checks that the requests recorded so far are
+// linearizable. If they are not, the history is rendered to a temporary
+// HTML file named in the error.
+func (h *history) VerifyLinearizability() error {
+	h.mu.Lock()
+	ops := append([]porcupine.Operation(nil), h.ops...)
+	unsupported := h.unsupported
+	h.mu.Unlock()
+	if len(unsupported) != 0 {
+		return fmt.Errorf("history contains writes the model cannot check: %s", strings.Join(unsupported, ", "))
+	}
+
+	result, info := porcupine.CheckOperationsVerbose(etcdModel, ops, linearizabilityTimeout)
+	switch result {
+	case porcupine.Ok:
+		return nil
+	case porcupine.Unknown:
+		return fmt.Errorf("linearizability check of %d requests timed out", len(ops))
+	}
+	f, err := os.CreateTemp("", "etcd-history-*.html")
+	if err != nil {
+		return fmt.Errorf("history of %d requests is not linearizable", len(ops))
+	}
+	defer f.Close()
+	if err = porcupine.Visualize(etcdModel, info, f); err != nil {
+		return fmt.Errorf("history of %d requests is not linearizable (failed to visualize: %v)", len(ops), err)
+	}
+	return fmt.Errorf("history of %d requests is not linearizable, see %s", len(ops), f.Name())
+}
+
+// recordingClient records the requests it sends in the history of the
+// cluster. Reads the model cannot check, e.g. serializable or historical
+// ones, are sent without being recorded.
+type recordingClient struct {
+	Client
+	h  *history
+	id int
+}
+
+func (c *recordingClient) Put(key, value string, opts config.PutOptions) error {
+	if opts.LeaseID != 0 {
+		c.h.appendUnsupported(fmt.Sprintf("put(%q) with lease", key))
+		return c.Client.Put(key, value, opts)
+	}
+	call := time.Now()
+	err := c.Client.Put(key, value, opts)
+	c.h.append(c.id, request{kind: putRequest, key: key, value: value}, call, response{}, err)
+	return err
+}
+
+func (c *recordingClient) Get(key string, opts config.GetOptions) (*clientv3.GetResponse, error) {
+	if opts.Serializable || opts.Revision != 0 || opts.Limit != 0 || opts.CountOnly ||
+		opts.SortBy != clientv3.SortByKey || opts.Order != clientv3.SortNone {
+		return c.Client.Get(key, opts)
+	}
+	req := request{kind: rangeRequest, key: key, end: rangeEnd(key, opts.End, opts.Prefix, opts.FromKey)}
+	call := time.Now()
+	resp, err := c.Client.Get(key, opts)
+	var out response
+	if err == nil {
+		out = response{revision: resp.Header.Revision, kvs: toKeyValues(resp.Kvs)}
+	}
+	c.h.append(c.id, req, call, out, err)
+	return resp, err
+}
+
+func (c *recordingClient) Delete(key string, opts config.DeleteOptions) (*clientv3.DeleteResponse, error) {
+	req := request{kind: deleteRequest, key: key, end: rangeEnd(key, opts.End, opts.Prefix, opts.FromKey)}
+	call := time.Now()
+	resp, err := c.Client.Delete(key, opts)
+	var out response
+	if err == nil {
+		out = response{revision: resp.Header.Revision, deleted: resp.Deleted}
+	}
+	c.h.append(c.id, req, call, out, err)
+	return resp, err
+}
+
+func (c *recordingClient) Txn(compares []config.TxnCompare, ifSucess, ifFail []config.TxnOp, o config.TxnOptions) (*clientv3.TxnResponse, error) {
+	req := txnRequestOf(compares, ifSucess, ifFail)
+	call := time.Now()
+	resp, err := c.Client.Txn(compares, ifSucess, ifFail, o)
+	var out response
+	if err == nil {
+		out = txnResponseOf((*pb.TxnResponse)(resp))
+		out.revision = resp.Header.Revision
+	}
+	c.h.append(c.id, req, call, out, err)
+	return resp, err
+}
+
+func txnRequestOf(compares []config.TxnCompare, ifSucess, ifFail []config.TxnOp) request {
+	req := request{kind: txnRequest, onSuccess: txnOpRequests(ifSucess), onFailure: txnOpRequests(ifFail)}
+	for _, cmp := range compares {
+		req.compares = append(req.compares, toCmp(cmp))
+	}
+	return req
+}
+
+func txnOpRequests(ops []config.TxnOp) (reqs []request) {
+	for _, op := range ops {
+		switch op.Type {
+		case config.TxnOpPut:
+			reqs = append(reqs, request{kind: putRequest, key: op.Key, value: op.Value})
+		case config.TxnOpGet:
+			reqs = append(reqs, request{kind: rangeRequest, key: op.Key})
+		case config.TxnOpDelete:
+			reqs = append(reqs, request{kind: deleteRequest, key: op.Key})
+		case config.TxnOpTxn:
+			reqs = append(reqs, txnRequestOf(op.Compares, op.Success, op.Failure))
+		}
+	}
+	return reqs
+}
+
+// txnResponseOf converts the responses of a transaction, and of the
+// transactions nested in it, without the revision.
+func txnResponseOf(resp *pb.TxnResponse) response {
+	out := response{succeeded: resp.Succeeded}
+	for _, r := range resp.Responses {
+		var rr response
+		if rangeResp := r.GetResponseRange(); rangeResp != nil {
+			rr.kvs = toKeyValues(rangeResp.Kvs)
+		}
+		if deleteResp := r.GetResponseDeleteRange(); deleteResp != nil {
+			rr.deleted = deleteResp.Deleted
+		}
+		if txnResp := r.GetResponseTxn(); txnResp != nil {
+			rr = txnResponseOf(txnResp)
+		}
+		out.responses = append(out.responses, rr)
+	}
+	return out
+}
+
+func rangeEnd(key, end string, prefix, fromKey bool) string {
+	switch {
+	case prefix:
+		return clientv3.GetPrefixRangeEnd(key)
+	case fromKey:
+		return "\x00"
+	default:
+		return end
+	}
+}
+
+func toKeyValues(kvs []*mvccpb.KeyValue) []keyValue {
+	var out []keyValue
+	for _, kv := range kvs {
+		out = append(out, keyValue{
+			key:            string(kv.Key),
+			value:          string(kv.Value),
+			createRevision: kv.CreateRevision,
+			modRevision:    kv.ModRevision,
+			version:        kv.Version,
+		})
+	}
+	return out
+}
@@ -17,18 +17,20 @@ package framework
 import (
 	"context"
 	"fmt"
-	"strings"
 	"testing"
+	"time"
 
+	"github.com/jonboulle/clockwork"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
-	etcdctlcmd "go.etcd.io/etcd/etcdctl/v3/ctlv3/command"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
 
 	"go.etcd.io/etcd/tests/v3/framework/config"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
 	"go.uber.org/zap"
 )
 
@@ -39,39 +41,85 @@ func (e integrationRunner) TestMain(m *testing.M) {
 }
 
 func (e integrationRunner) BeforeTest(t testing.TB) {
+	trackResources(t)
 	integration.BeforeTest(t)
 }
 
+func (e integrationRunner) Supports(c Capability) bool {
+	switch c {
+	case SupportsNetworkPartition:
+		return true
+	default:
+		return false
+	}
+}
+
 func (e integrationRunner) NewCluster(t testing.TB, cfg config.ClusterConfig) Cluster {
 	var err error
 	var integrationCfg integration.ClusterConfig
 	integrationCfg.Size = cfg.ClusterSize
-	integrationCfg.ClientTLS, err = tlsInfo(t, cfg.ClientTLS)
+	// Members of an integration cluster are served on unix sockets unless
+	// they are asked to use TCP.
+	if cfg.UnixSockets && cfg.IPMode != config.IPv4 {
+		t.Fatalf("IPMode %q cannot be combined with unix sockets", cfg.IPMode)
+	}
+	switch cfg.IPMode {
+	case config.IPv4:
+	case config.IPv6:
+		integrationCfg.UseTCP = true
+		integrationCfg.UseIPv6 = true
+	default:
+		// Peers of an integration cluster talk over unix sockets, only the
+		// client listener can be bound on an IP address.
+		t.Skipf("IPMode %q is not supported by the integration runner", cfg.IPMode)
+	}
+	integrationCfg.ClientTLS, err = tlsInfo(t, cfg.ClientTLS, cfg.IPMode)
+	integrationCfg.ClientCertAuthEnabled = cfg.ClientCertAuthEnabled
+	integrationCfg.AuthToken = authTokenOpts(t, cfg)
+	if cfg.AuthTokenType == config.SimpleToken {
+		integrationCfg.AuthTokenTTL = uint((cfg.AuthTokenTTL + time.Second - 1) / time.Second)
+	}
 	integrationCfg.QuotaBackendBytes = cfg.QuotaBackendBytes
+	integrationCfg.WatchProgressNotifyInterval = cfg.WatchProgressNotifyInterval
+	integrationCfg.CorruptCheckTime = cfg.CorruptCheckTime
+	integrationCfg.ServerConfigMutator = cfg.ServerConfigMutator
+	if cfg.WALFsyncLatency != 0 {
+		t.Fatalf("WALFsyncLatency requires %s", SupportsFailpoints)
+	}
+	clock := clockwork.NewFakeClock()
+	integrationCfg.LeaseClock = clock
 	if err != nil {
 		t.Fatalf("ClientTLS: %s", err)
 	}
-	integrationCfg.PeerTLS, err = tlsInfo(t, cfg.PeerTLS)
+	integrationCfg.PeerTLS, err = tlsInfo(t, cfg.PeerTLS, config.IPv4)
 	if err != nil {
 		t.Fatalf("PeerTLS: %s", err)
 	}
 	return &integrationCluster{
 		Cluster: integration.NewCluster(t, &integrationCfg),
 		t:       t,
+		clock:   clock,
 	}
 }
 
-func tlsInfo(t testing.TB, cfg config.TLSConfig) (*transport.TLSInfo, error) {
+func tlsInfo(t testing.TB, cfg config.TLSConfig, ipMode config.IPMode) (*transport.TLSInfo, error) {
 	switch cfg {
 	case config.NoTLS:
 		return nil, nil
 	case config.AutoTLS:
-		tls, err := transport.SelfCert(zap.NewNop(), t.TempDir(), []string{"localhost"}, 1)
+		hosts := []string{"localhost"}
+		if ipMode == config.IPv6 {
+			hosts = append(hosts, "::1")
+		}
+		tls, err := transport.SelfCert(zap.NewNop(), t.TempDir(), hosts, 1)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate cert: %s", err)
 		}
 		return &tls, nil
 	case config.ManualTLS:
+		if ipMode == config.IPv6 {
+			return &integration.TestTLSInfoIPv6, nil
+		}
 		return &integration.TestTLSInfo, nil
 	default:
 		return nil, fmt.Errorf("config %q not supported", cfg)
@@ -80,18 +128,21 @@ func tlsInfo(t testing.TB, cfg config.TLSConfig) (*transport.TLSInfo, error) {
 
 type integrationCluster struct {
 	*integration.Cluster
-	t testing.TB
+	history
+	t     testing.TB
+	clock clockwork.FakeClock
 }
 
 func (c *integrationCluster) Members() (ms []Member) {
 	for _, m := range c.Cluster.Members {
-		ms = append(ms, integrationMember{m, c.t})
+		ms = append(ms, integrationMember{m, c.Cluster, c.t})
 	}
 	return ms
 }
 
 type integrationMember struct {
 	*integration.Member
+	c *integration.Cluster
 	t testing.TB
 }
 
@@ -107,6 +158,104 @@ func (m integrationMember) Stop() {
 	m.Member.Stop(m.t)
 }
 
+func (m integrationMember) CorruptBBolt() error {
+	return testutils.CorruptBBolt(datadir.ToBackendFileName(m.Member.DataDir))
+}
+
+func (m integrationMember) Partition() error {
+	m.Member.InjectPartition(m.t, m.peers()...)
+	return nil
+}
+
+func (m integrationMember) Heal() error {
+	m.Member.RecoverPartition(m.t, m.peers()...)
+	return nil
+}
+
+func (m integrationMember) peers() (peers []*integration.Member) {
+	for _, p := range m.c.Members {
+		if p != m.Member {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+func (c *integrationCluster) AdvanceTime(d time.Duration) {
+	c.clock.Advance(d)
+}
+
+func (c *integrationCluster) Leader() (uint64, error) {
+	lead := c.WaitLeader(c.t)
+	return uint64(c.Cluster.Members[lead].ID()), nil
+}
+
+func (c *integrationCluster) MoveLeader(targetID uint64) error {
+	lead := c.WaitLeader(c.t)
+	ctx, cancel := context.WithTimeout(context.Background(), integration.RequestTimeout)
+	defer cancel()
+	if _, err := c.Cluster.Members[lead].Client.MoveLeader(ctx, targetID); err != nil {
+		return err
+	}
+	if lead = c.WaitLeader(c.t); uint64(c.Cluster.Members[lead].ID()) != targetID {
+		return fmt.Errorf("leader is %s, expected %x", c.Cluster.Members[lead].ID(), targetID)
+	}
+	return nil
+}
+
+func (c *integrationCluster) ScaleTo(n int) error {
+	if n < 1 {
+		return fmt.Errorf("cannot scale to %d members", n)
+	}
+	for len(c.Cluster.Members) < n {
+		c.AddAndLaunchLearnerMember(c.t)
+		m := c.Cluster.Members[len(c.Cluster.Members)-1]
+		// a learner can only be promoted once it caught up with the leader
+		var err error
+		for start := time.Now(); ; time.Sleep(integration.TickDuration) {
+			ctx, cancel := context.WithTimeout(context.Background(), integration.RequestTimeout)
+			_, err = c.Cluster.Members[0].Client.MemberPromote(ctx, uint64(m.Server.ID()))
+			cancel()
+			if err == nil {
+				break
+			}
+			if time.Since(start) > 10*time.Second {
+				return fmt.Errorf("cannot promote member %s: %v", m.Name, err)
+			}
+		}
+		m.IsLearner = false
+		c.WaitLeader(c.t)
+	}
+	for len(c.Cluster.Members) > n {
+		lead := c.WaitLeader(c.t)
+		remove := len(c.Cluster.Members) - 1
+		if remove == lead {
+			remove--
+		}
+		id := uint64(c.Cluster.Members[remove].Server.ID())
+		if err := c.RemoveMember(c.t, c.Cluster.Members[lead].Client, id); err != nil {
+			return err
+		}
+		c.WaitLeader(c.t)
+	}
+	return nil
+}
+
+func (c *integrationCluster) Gateway() GatewayClient {
+	m := c.Cluster.Members[0]
+	gc := gatewayClient{endpoint: m.URL()}
+	if m.ClientTLSInfo != nil {
+		gc.tlsInfo = *m.ClientTLSInfo
+		// Self-signed certificates come without a CA to verify them.
+		gc.tlsInfo.InsecureSkipVerify = gc.tlsInfo.TrustedCAFile == ""
+	}
+	return gc
+}
+
+func (c *integrationCluster) RotateCerts(config.CertBundle) error {
+	return fmt.Errorf("certificate rotation is not supported by the integration runner")
+}
+
 func (c *integrationCluster) Close() error {
 	c.Terminate(c.t)
 	return nil
@@ -120,6 +269,24 @@ func (c *integrationCluster) Client() Client {
 	return integrationClient{cc}
 }
 
+func (c *integrationCluster) AuthClient(user, password string) (Client, error) {
+	cc, err := c.NewAuthClient(user, password)
+	if err != nil {
+		return nil, err
+	}
+	c.t.Cleanup(func() { cc.Close() })
+	return integrationClient{cc}, nil
+}
+
+func (c *integrationCluster) CertClient(bundle config.CertBundle) (Client, error) {
+	cc, err := c.NewCertClient(bundle.CertFile, bundle.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.t.Cleanup(func() { cc.Close() })
+	return integrationClient{cc}, nil
+}
+
 type integrationClient struct {
 	*clientv3.Client
 }
@@ -314,6 +481,23 @@ func (c integrationClient) UserChangePass(user, newPass string) error {
 	return err
 }
 
+func (c integrationClient) UserGet(name string) (*clientv3.AuthUserGetResponse, error) {
+	return c.Client.UserGet(context.Background(), name)
+}
+
+func (c integrationClient) UserGrantRole(user, role string) (*clientv3.AuthUserGrantRoleResponse, error) {
+	return c.Client.UserGrantRole(context.Background(), user, role)
+}
+
+func (c integrationClient) UserRevokeRole(user, role string) (*clientv3.AuthUserRevokeRoleResponse, error) {
+	return c.Client.UserRevokeRole(context.Background(), user, role)
+}
+
+func (c integrationClient) AuthEnable() error {
+	_, err := c.Client.AuthEnable(context.Background())
+	return err
+}
+
 func (c integrationClient) RoleAdd(name string) (*clientv3.AuthRoleAddResponse, error) {
 	return c.Client.RoleAdd(context.Background(), name)
 }
@@ -338,45 +522,93 @@ func (c integrationClient) RoleDelete(role string) (*clientv3.AuthRoleDeleteResp
 	return c.Client.RoleDelete(context.Background(), role)
 }
 
-func (c integrationClient) Txn(compares, ifSucess, ifFail []string, o config.TxnOptions) (*clientv3.TxnResponse, error) {
-	txn := c.Client.Txn(context.Background())
+func (c integrationClient) Txn(compares []config.TxnCompare, ifSucess, ifFail []config.TxnOp, o config.TxnOptions) (*clientv3.TxnResponse, error) {
 	cmps := []clientv3.Cmp{}
 	for _, c := range compares {
-		cmp, err := etcdctlcmd.ParseCompare(c)
-		if err != nil {
-			return nil, err
-		}
-		cmps = append(cmps, *cmp)
-	}
-	succOps, err := getOps(ifSucess)
-	if err != nil {
-		return nil, err
+		cmps = append(cmps, toCmp(c))
 	}
-	failOps, err := getOps(ifFail)
-	if err != nil {
-		return nil, err
-	}
-	txnrsp, err := txn.
+	return c.Client.Txn(context.Background()).
 		If(cmps...).
-		Then(succOps...).
-		Else(failOps...).
+		Then(toOps(ifSucess)...).
+		Else(toOps(ifFail)...).
 		Commit()
-	return txnrsp, err
 }
 
-func getOps(ss []string) ([]clientv3.Op, error) {
+func (c integrationClient) Watch(ctx context.Context, key string, opts config.WatchOptions) (clientv3.WatchChan, error) {
+	opOpts := []clientv3.OpOption{}
+	if opts.Prefix {
+		opOpts = append(opOpts, clientv3.WithPrefix())
+	}
+	if opts.Revision != 0 {
+		opOpts = append(opOpts, clientv3.WithRev(opts.Revision))
+	}
+	if opts.RangeEnd != "" {
+		opOpts = append(opOpts, clientv3.WithRange(opts.RangeEnd))
+	}
+	if opts.PrevKV {
+		opOpts = append(opOpts, clientv3.WithPrevKV())
+	}
+	if opts.ProgressNotify {
+		opOpts = append(opOpts, clientv3.WithProgressNotify())
+	}
+	if opts.FilterPut {
+		opOpts = append(opOpts, clientv3.WithFilterPut())
+	}
+	if opts.FilterDelete {
+		opOpts = append(opOpts, clientv3.WithFilterDelete())
+	}
+	if opts.Fragment {
+		opOpts = append(opOpts, clientv3.WithFragment())
+	}
+	return c.Client.Watch(ctx, key, opOpts...), nil
+}
+
+func (c integrationClient) DowngradeValidate(version string) (*clientv3.DowngradeResponse, error) {
+	return c.Client.Downgrade(context.Background(), clientv3.DowngradeValidate, version)
+}
+
+func (c integrationClient) DowngradeEnable(version string) (*clientv3.DowngradeResponse, error) {
+	return c.Client.Downgrade(context.Background(), clientv3.DowngradeEnable, version)
+}
+
+func (c integrationClient) DowngradeCancel() (*clientv3.DowngradeResponse, error) {
+	return c.Client.Downgrade(context.Background(), clientv3.DowngradeCancel, "")
+}
+
+func toCmp(c config.TxnCompare) clientv3.Cmp {
+	switch c.Target {
+	case config.CompareValue:
+		return clientv3.Compare(clientv3.Value(c.Key), c.Result, c.Value)
+	case config.CompareVersion:
+		return clientv3.Compare(clientv3.Version(c.Key), c.Result, c.Revision)
+	case config.CompareCreateRevision:
+		return clientv3.Compare(clientv3.CreateRevision(c.Key), c.Result, c.Revision)
+	case config.CompareModRevision:
+		return clientv3.Compare(clientv3.ModRevision(c.Key), c.Result, c.Revision)
+	default:
+		panic(fmt.Sprintf("unknown compare target %q", c.Target))
+	}
+}
+
+func toOps(txnOps []config.TxnOp) []clientv3.Op {
 	ops := []clientv3.Op{}
-	for _, s := range ss {
-		s = strings.TrimSpace(s)
-		args := etcdctlcmd.Argify(s)
-		switch args[0] {
-		case "get":
-			ops = append(ops, clientv3.OpGet(args[1]))
-		case "put":
-			ops = append(ops, clientv3.OpPut(args[1], args[2]))
-		case "del":
-			ops = append(ops, clientv3.OpDelete(args[1]))
+	for _, op := range txnOps {
+		switch op.Type {
+		case config.TxnOpGet:
+			ops = append(ops, clientv3.OpGet(op.Key))
+		case config.TxnOpPut:
+			ops = append(ops, clientv3.OpPut(op.Key, op.Value))
+		case config.TxnOpDelete:
+			ops = append(ops, clientv3.OpDelete(op.Key))
+		case config.TxnOpTxn:
+			var cmps []clientv3.Cmp
+			for _, c := range op.Compares {
+				cmps = append(cmps, toCmp(c))
+			}
+			ops = append(ops, clientv3.OpTxn(cmps, toOps(op.Success), toOps(op.Failure)))
+		default:
+			panic(fmt.Sprintf("unknown txn request type %q", op.Type))
 		}
 	}
-	return ops, nil
+	return ops
 }
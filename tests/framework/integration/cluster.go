@@ -34,6 +34,7 @@ import (
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/pkg/v3/tlsutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
@@ -59,6 +60,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jonboulle/clockwork"
 	"github.com/soheilhy/cmux"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
@@ -109,6 +111,13 @@ var (
 		ClientCertAuth: true,
 	}
 
+	TestTLSInfoIPv6 = transport.TLSInfo{
+		KeyFile:        MustAbsPath("../fixtures/server-ipv6.key.insecure"),
+		CertFile:       MustAbsPath("../fixtures/server-ipv6.crt"),
+		TrustedCAFile:  MustAbsPath("../fixtures/ca.crt"),
+		ClientCertAuth: true,
+	}
+
 	TestTLSInfoExpired = transport.TLSInfo{
 		KeyFile:        MustAbsPath("./fixtures-expired/server.key.insecure"),
 		CertFile:       MustAbsPath("./fixtures-expired/server.crt"),
@@ -135,10 +144,15 @@ type ClusterConfig struct {
 	Size      int
 	PeerTLS   *transport.TLSInfo
 	ClientTLS *transport.TLSInfo
+	// ClientCertAuthEnabled authenticates requests without a token as the
+	// user named by the CommonName of the client certificate.
+	ClientCertAuthEnabled bool
 
 	DiscoveryURL string
 
 	AuthToken string
+	// AuthTokenTTL is the TTL of simple tokens in seconds.
+	AuthTokenTTL uint
 
 	QuotaBackendBytes int64
 
@@ -156,6 +170,9 @@ type ClusterConfig struct {
 
 	// UseIP is true to use only IP for gRPC requests.
 	UseIP bool
+	// UseIPv6 is true to serve gRPC requests on the IPv6 loopback address.
+	// It only has effect together with UseTCP.
+	UseIPv6 bool
 	// UseBridge adds bridge between client and grpc server. Should be used in tests that
 	// want to manipulate connection or require connection not breaking despite server stop/restart.
 	UseBridge bool
@@ -165,11 +182,18 @@ type ClusterConfig struct {
 	EnableLeaseCheckpoint   bool
 	LeaseCheckpointInterval time.Duration
 	LeaseCheckpointPersist  bool
+	LeaseFastRenew          bool
+	// LeaseClock is shared by all members to expire leases, the real clock if nil.
+	LeaseClock clockwork.Clock
 
 	WatchProgressNotifyInterval time.Duration
 	ExperimentalMaxLearners     int
 	StrictReconfigCheck         bool
 	CorruptCheckTime            time.Duration
+	// ServerConfigMutator is applied to the server configuration of every
+	// member before it is launched, to set options that have no dedicated
+	// field.
+	ServerConfigMutator func(*config.ServerConfig)
 }
 
 type Cluster struct {
@@ -262,8 +286,10 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			Name:                        fmt.Sprintf("m%v", memberNumber),
 			MemberNumber:                memberNumber,
 			AuthToken:                   c.Cfg.AuthToken,
+			AuthTokenTTL:                c.Cfg.AuthTokenTTL,
 			PeerTLS:                     c.Cfg.PeerTLS,
 			ClientTLS:                   c.Cfg.ClientTLS,
+			ClientCertAuthEnabled:       c.Cfg.ClientCertAuthEnabled,
 			QuotaBackendBytes:           c.Cfg.QuotaBackendBytes,
 			MaxTxnOps:                   c.Cfg.MaxTxnOps,
 			MaxRequestBytes:             c.Cfg.MaxRequestBytes,
@@ -275,15 +301,19 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			ClientMaxCallSendMsgSize:    c.Cfg.ClientMaxCallSendMsgSize,
 			ClientMaxCallRecvMsgSize:    c.Cfg.ClientMaxCallRecvMsgSize,
 			UseIP:                       c.Cfg.UseIP,
+			UseIPv6:                     c.Cfg.UseIPv6,
 			UseBridge:                   c.Cfg.UseBridge,
 			UseTCP:                      c.Cfg.UseTCP,
 			EnableLeaseCheckpoint:       c.Cfg.EnableLeaseCheckpoint,
 			LeaseCheckpointInterval:     c.Cfg.LeaseCheckpointInterval,
 			LeaseCheckpointPersist:      c.Cfg.LeaseCheckpointPersist,
+			LeaseFastRenew:              c.Cfg.LeaseFastRenew,
+			LeaseClock:                  c.Cfg.LeaseClock,
 			WatchProgressNotifyInterval: c.Cfg.WatchProgressNotifyInterval,
 			ExperimentalMaxLearners:     c.Cfg.ExperimentalMaxLearners,
 			StrictReconfigCheck:         c.Cfg.StrictReconfigCheck,
 			CorruptCheckTime:            c.Cfg.CorruptCheckTime,
+			ServerConfigMutator:         c.Cfg.ServerConfigMutator,
 		})
 	m.DiscoveryURL = c.Cfg.DiscoveryURL
 	return m
@@ -567,10 +597,12 @@ type Member struct {
 	ClientMaxCallSendMsgSize int
 	ClientMaxCallRecvMsgSize int
 	UseIP                    bool
+	UseIPv6                  bool
 	UseBridge                bool
 	UseTCP                   bool
 
 	IsLearner bool
+	IsWitness bool
 	Closed    bool
 
 	GrpcServerRecorder *grpc_testing.GrpcRecorder
@@ -584,7 +616,9 @@ type MemberConfig struct {
 	MemberNumber                int
 	PeerTLS                     *transport.TLSInfo
 	ClientTLS                   *transport.TLSInfo
+	ClientCertAuthEnabled       bool
 	AuthToken                   string
+	AuthTokenTTL                uint
 	QuotaBackendBytes           int64
 	MaxTxnOps                   uint
 	MaxRequestBytes             uint
@@ -596,15 +630,19 @@ type MemberConfig struct {
 	ClientMaxCallSendMsgSize    int
 	ClientMaxCallRecvMsgSize    int
 	UseIP                       bool
+	UseIPv6                     bool
 	UseBridge                   bool
 	UseTCP                      bool
 	EnableLeaseCheckpoint       bool
 	LeaseCheckpointInterval     time.Duration
 	LeaseCheckpointPersist      bool
+	LeaseFastRenew              bool
+	LeaseClock                  clockwork.Clock
 	WatchProgressNotifyInterval time.Duration
 	ExperimentalMaxLearners     int
 	StrictReconfigCheck         bool
 	CorruptCheckTime            time.Duration
+	ServerConfigMutator         func(*config.ServerConfig)
 }
 
 // MustNewMember return an inited member with the given name. If peerTLS is
@@ -634,6 +672,7 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 		t.Fatal(err)
 	}
 	m.ClientTLSInfo = mcfg.ClientTLS
+	m.ClientCertAuthEnabled = mcfg.ClientCertAuthEnabled
 
 	m.Name = mcfg.Name
 
@@ -678,6 +717,7 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	if mcfg.AuthToken != "" {
 		m.AuthToken = mcfg.AuthToken
 	}
+	m.TokenTTL = mcfg.AuthTokenTTL
 
 	m.BcryptCost = uint(bcrypt.MinCost) // use min bcrypt cost to speedy up integration testing
 
@@ -698,11 +738,14 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	m.ClientMaxCallSendMsgSize = mcfg.ClientMaxCallSendMsgSize
 	m.ClientMaxCallRecvMsgSize = mcfg.ClientMaxCallRecvMsgSize
 	m.UseIP = mcfg.UseIP
+	m.UseIPv6 = mcfg.UseIPv6
 	m.UseBridge = mcfg.UseBridge
 	m.UseTCP = mcfg.UseTCP
 	m.EnableLeaseCheckpoint = mcfg.EnableLeaseCheckpoint
 	m.LeaseCheckpointInterval = mcfg.LeaseCheckpointInterval
 	m.LeaseCheckpointPersist = mcfg.LeaseCheckpointPersist
+	m.LeaseFastRenew = mcfg.LeaseFastRenew
+	m.LeaseClock = mcfg.LeaseClock
 
 	m.WatchProgressNotifyInterval = mcfg.WatchProgressNotifyInterval
 
@@ -718,8 +761,11 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	}
 	m.V2Deprecation = config.V2_DEPR_DEFAULT
 	m.GrpcServerRecorder = &grpc_testing.GrpcRecorder{}
-	m.Logger = memberLogger(t, mcfg.Name)
+	m.Logger, m.LogLevels = memberLogger(t, mcfg.Name)
 	m.StrictReconfigCheck = mcfg.StrictReconfigCheck
+	if mcfg.ServerConfigMutator != nil {
+		mcfg.ServerConfigMutator(&m.ServerConfig)
+	}
 	if err := m.listenGRPC(); err != nil {
 		t.Fatalf("listenGRPC FAILED: %v", err)
 	}
@@ -731,21 +777,25 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	return m
 }
 
-func memberLogger(t testutil.TB, name string) *zap.Logger {
+// memberLogger returns the logger of a member, and the log levels of its
+// modules.
+func memberLogger(t testutil.TB, name string) (*zap.Logger, *logutil.ModuleLevels) {
 	level := zapcore.InfoLevel
 	if os.Getenv("CLUSTER_DEBUG") != "" {
 		level = zapcore.DebugLevel
 	}
 
 	options := zaptest.WrapOptions(zap.Fields(zap.String("member", name)))
-	return zaptest.NewLogger(t, zaptest.Level(level), options).Named(name)
+	levels := logutil.NewModuleLevels(zap.NewAtomicLevelAt(level), etcdserver.LogModules...)
+	lg := zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel), options).Named(name)
+	return levels.Logger(lg, ""), levels
 }
 
 // listenGRPC starts a grpc server over a unix domain socket on the member
 func (m *Member) listenGRPC() error {
 	// prefix with localhost so cert has right domain
 	network, host, port := m.grpcAddr()
-	grpcAddr := host + ":" + port
+	grpcAddr := net.JoinHostPort(host, port)
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
@@ -784,7 +834,7 @@ func (m *Member) clientScheme() string {
 
 func (m *Member) addBridge() (*bridge, error) {
 	network, host, port := m.grpcAddr()
-	grpcAddr := host + ":" + port
+	grpcAddr := net.JoinHostPort(host, port)
 	bridgeAddr := grpcAddr + "0"
 	m.Logger.Info("LISTEN BRIDGE", zap.String("grpc-address", bridgeAddr), zap.String("member", m.Name))
 	bridgeListener, err := transport.NewUnixListener(bridgeAddr)
@@ -816,6 +866,9 @@ func (m *Member) grpcAddr() (network, host, port string) {
 	network = "unix"
 	if m.UseTCP {
 		network = "tcp"
+		if m.UseIPv6 {
+			host = "::1"
+		}
 	}
 	port = m.Name
 	if m.UseTCP {
@@ -900,7 +953,7 @@ func (m *Member) Clone(t testutil.TB) *Member {
 	mm.ElectionTicks = m.ElectionTicks
 	mm.PeerTLSInfo = m.PeerTLSInfo
 	mm.ClientTLSInfo = m.ClientTLSInfo
-	mm.Logger = memberLogger(t, mm.Name+"c")
+	mm.Logger, mm.LogLevels = memberLogger(t, mm.Name+"c")
 	return mm
 }
 
@@ -990,12 +1043,20 @@ func (m *Member) Launch() error {
 		}
 		m.ServerClosers = append(m.ServerClosers, closer)
 	}
+	var gwh *gateway
+	if m.GrpcURL != "" {
+		gwh = &gateway{m: m}
+		m.ServerClosers = append(m.ServerClosers, gwh.Close)
+	}
 	for _, ln := range m.ClientListeners {
 		handler := http.NewServeMux()
 		etcdhttp.HandleDebug(handler)
 		etcdhttp.HandleVersion(handler, m.Server)
 		etcdhttp.HandleMetrics(handler)
 		etcdhttp.HandleHealth(m.Logger, handler, m.Server)
+		if gwh != nil {
+			handler.Handle("/v3/", gwh)
+		}
 		hs := &httptest.Server{
 			Listener: ln,
 			Config: &http.Server{
@@ -1411,23 +1472,9 @@ func (c *Cluster) Endpoints() []string {
 
 func (c *Cluster) ClusterClient() (client *clientv3.Client, err error) {
 	if c.clusterClient == nil {
-		var endpoints []string
-		for _, m := range c.Members {
-			endpoints = append(endpoints, m.GrpcURL)
-		}
-		cfg := clientv3.Config{
-			Endpoints:          endpoints,
-			DialTimeout:        5 * time.Second,
-			DialOptions:        []grpc.DialOption{grpc.WithBlock()},
-			MaxCallSendMsgSize: c.Cfg.ClientMaxCallSendMsgSize,
-			MaxCallRecvMsgSize: c.Cfg.ClientMaxCallRecvMsgSize,
-		}
-		if c.Cfg.ClientTLS != nil {
-			tls, err := c.Cfg.ClientTLS.ClientConfig()
-			if err != nil {
-				return nil, err
-			}
-			cfg.TLS = tls
+		cfg, err := c.clusterClientConfig()
+		if err != nil {
+			return nil, err
 		}
 		c.clusterClient, err = newClientV3(cfg)
 		if err != nil {
@@ -1437,6 +1484,58 @@ func (c *Cluster) ClusterClient() (client *clientv3.Client, err error) {
 	return c.clusterClient, nil
 }
 
+// NewAuthClient creates a client of all the members which authenticates as
+// username with password. The caller is responsible for closing it.
+func (c *Cluster) NewAuthClient(username, password string) (*clientv3.Client, error) {
+	cfg, err := c.clusterClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Username, cfg.Password = username, password
+	return newClientV3(cfg)
+}
+
+// NewCertClient creates a client of all the members which presents the
+// given certificate. The caller is responsible for closing it.
+func (c *Cluster) NewCertClient(certFile, keyFile string) (*clientv3.Client, error) {
+	if c.Cfg.ClientTLS == nil {
+		return nil, fmt.Errorf("cluster does not serve client TLS")
+	}
+	cfg, err := c.clusterClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	info := *c.Cfg.ClientTLS
+	info.CertFile, info.KeyFile = certFile, keyFile
+	info.ClientCertFile, info.ClientKeyFile = "", ""
+	if cfg.TLS, err = info.ClientConfig(); err != nil {
+		return nil, err
+	}
+	return newClientV3(cfg)
+}
+
+func (c *Cluster) clusterClientConfig() (clientv3.Config, error) {
+	var endpoints []string
+	for _, m := range c.Members {
+		endpoints = append(endpoints, m.GrpcURL)
+	}
+	cfg := clientv3.Config{
+		Endpoints:          endpoints,
+		DialTimeout:        5 * time.Second,
+		DialOptions:        []grpc.DialOption{grpc.WithBlock()},
+		MaxCallSendMsgSize: c.Cfg.ClientMaxCallSendMsgSize,
+		MaxCallRecvMsgSize: c.Cfg.ClientMaxCallRecvMsgSize,
+	}
+	if c.Cfg.ClientTLS != nil {
+		tls, err := c.Cfg.ClientTLS.ClientConfig()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.TLS = tls
+	}
+	return cfg, nil
+}
+
 // NewClientV3 creates a new grpc client connection to the member
 func (c *Cluster) NewClientV3(memberIndex int) (*clientv3.Client, error) {
 	return NewClientV3(c.Members[memberIndex])
@@ -1544,6 +1643,37 @@ func (c *Cluster) AddAndLaunchLearnerMember(t testutil.TB) {
 	c.waitMembersMatch(t)
 }
 
+// AddAndLaunchWitnessMember creates a witness member, adds it to Cluster
+// via v3 MemberAdd API, and then launches the new member.
+func (c *Cluster) AddAndLaunchWitnessMember(t testutil.TB) {
+	m := c.mustNewMember(t)
+	m.IsWitness = true
+
+	scheme := SchemeFromTLSInfo(c.Cfg.PeerTLS)
+	peerURLs := []string{scheme + "://" + m.PeerListeners[0].Addr().String()}
+
+	cli := c.Client(0)
+	_, err := cli.MemberAddAsWitness(context.Background(), peerURLs)
+	if err != nil {
+		t.Fatalf("failed to add witness member %v", err)
+	}
+
+	m.InitialPeerURLsMap = types.URLsMap{}
+	for _, mm := range c.Members {
+		m.InitialPeerURLsMap[mm.Name] = mm.PeerURLs
+	}
+	m.InitialPeerURLsMap[m.Name] = m.PeerURLs
+	m.NewCluster = false
+
+	if err := m.Launch(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Members = append(c.Members, m)
+
+	c.waitMembersMatch(t)
+}
+
 // getMembers returns a list of members in Cluster, in format of etcdserverpb.Member
 func (c *Cluster) getMembers() []*pb.Member {
 	var mems []*pb.Member
@@ -1553,6 +1683,7 @@ func (c *Cluster) getMembers() []*pb.Member {
 			PeerURLs:   m.PeerURLs.StringSlice(),
 			ClientURLs: m.ClientURLs.StringSlice(),
 			IsLearner:  m.IsLearner,
+			IsWitness:  m.IsWitness,
 		}
 		mems = append(mems, mem)
 	}
@@ -1619,6 +1750,7 @@ func (p SortableProtoMemberSliceByPeerURLs) Swap(i, j int) { p[i], p[j] = p[j],
 func (c *Cluster) MustNewMember(t testutil.TB, resp *clientv3.MemberAddResponse) *Member {
 	m := c.mustNewMember(t)
 	m.IsLearner = resp.Member.IsLearner
+	m.IsWitness = resp.Member.IsWitness
 	m.NewCluster = false
 
 	m.InitialPeerURLsMap = types.URLsMap{}
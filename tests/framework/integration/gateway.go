@@ -0,0 +1,91 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	gw "github.com/grpc-ecosystem/grpc-gateway/runtime"
+	etcdservergw "go.etcd.io/etcd/api/v3/etcdserverpb/gw"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	v3electiongw "go.etcd.io/etcd/server/v3/etcdserver/api/v3election/v3electionpb/gw"
+	v3lockgw "go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb/gw"
+	"google.golang.org/grpc"
+)
+
+// gateway serves the HTTP/JSON gRPC gateway on the client listeners of a
+// member. The connection to the gRPC server of the member is established on
+// the first request, so that members which are never queried over HTTP do
+// not pay for it.
+type gateway struct {
+	m *Member
+
+	mu  sync.Mutex
+	cli *clientv3.Client
+	mux *gw.ServeMux
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mux, err := g.init()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	mux.ServeHTTP(w, r)
+}
+
+func (g *gateway) init() (*gw.ServeMux, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.mux != nil {
+		return g.mux, nil
+	}
+	cli, err := NewClientV3(g.m)
+	if err != nil {
+		return nil, err
+	}
+	mux := gw.NewServeMux()
+	handlers := []func(context.Context, *gw.ServeMux, *grpc.ClientConn) error{
+		etcdservergw.RegisterKVHandler,
+		etcdservergw.RegisterWatchHandler,
+		etcdservergw.RegisterLeaseHandler,
+		etcdservergw.RegisterClusterHandler,
+		etcdservergw.RegisterMaintenanceHandler,
+		etcdservergw.RegisterAuthHandler,
+		v3lockgw.RegisterLockHandler,
+		v3electiongw.RegisterElectionHandler,
+	}
+	for _, h := range handlers {
+		if err = h(context.Background(), mux, cli.ActiveConnection()); err != nil {
+			cli.Close()
+			return nil, err
+		}
+	}
+	g.cli, g.mux = cli, mux
+	return mux, nil
+}
+
+// Close closes the connection to the member, if any. The gateway reconnects
+// on the next request.
+func (g *gateway) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cli != nil {
+		g.cli.Close()
+		g.cli, g.mux = nil, nil
+	}
+}
@@ -15,7 +15,9 @@
 package framework
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/tests/v3/framework/config"
@@ -25,18 +27,61 @@ type testRunner interface {
 	TestMain(m *testing.M)
 	BeforeTest(testing.TB)
 	NewCluster(testing.TB, config.ClusterConfig) Cluster
+	// Supports reports whether clusters created by the runner provide the capability.
+	Supports(Capability) bool
 }
 
 type Cluster interface {
 	Members() []Member
 	Client() Client
+	// AuthClient returns a client of the cluster which authenticates as
+	// user with password. Depending on the runner, failing to authenticate
+	// is reported by AuthClient or by the first request of the client.
+	AuthClient(user, password string) (Client, error)
+	// CertClient returns a client of the cluster which presents the
+	// certificate and key of bundle. It requires ClientTLS ManualTLS.
+	CertClient(bundle config.CertBundle) (Client, error)
 	Close() error
+	// AdvanceTime lets d pass on the clock the cluster uses to expire leases.
+	// Runners with a controllable clock advance it, others wait in real time.
+	AdvanceTime(d time.Duration)
+	// Gateway returns a client of the HTTP/JSON gRPC gateway served by the
+	// members.
+	Gateway() GatewayClient
+	// Leader returns the ID of the leader once all members agree on it.
+	Leader() (uint64, error)
+	// MoveLeader transfers the leadership to the member with the given ID
+	// and waits for the transfer to complete.
+	MoveLeader(targetID uint64) error
+	// RotateCerts replaces the certificate files of the members serving
+	// manual TLS, empty fields of bundle keep the current file. Members
+	// serve the new certificate without a restart but only trust a new CA
+	// once restarted. It requires SupportsCertRotation.
+	RotateCerts(bundle config.CertBundle) error
+	// ScaleTo adds or removes members until the cluster has n members.
+	// Members are added as learners and promoted once they caught up with
+	// the leader, followers are removed before the leader, and the cluster
+	// is waited for to agree on a leader after each change.
+	ScaleTo(n int) error
+	// VerifyLinearizability checks that the requests recorded for the
+	// cluster, by RunChaos or clients returned by RecordHistory, are
+	// linearizable.
+	VerifyLinearizability() error
+
+	recorder() *history
 }
 
 type Member interface {
 	Client() Client
 	Start() error
 	Stop()
+	// CorruptBBolt silently corrupts the values stored in the backend of
+	// the member, which must be stopped.
+	CorruptBBolt() error
+	// Partition cuts the member off from the other members of the cluster
+	// until Heal is called. It requires SupportsNetworkPartition.
+	Partition() error
+	Heal() error
 }
 
 type Client interface {
@@ -60,6 +105,11 @@ type Client interface {
 	UserList() (*clientv3.AuthUserListResponse, error)
 	UserDelete(name string) (*clientv3.AuthUserDeleteResponse, error)
 	UserChangePass(user, newPass string) error
+	UserGet(name string) (*clientv3.AuthUserGetResponse, error)
+	UserGrantRole(user, role string) (*clientv3.AuthUserGrantRoleResponse, error)
+	UserRevokeRole(user, role string) (*clientv3.AuthUserRevokeRoleResponse, error)
+
+	AuthEnable() error
 
 	RoleAdd(name string) (*clientv3.AuthRoleAddResponse, error)
 	RoleGrantPermission(name string, key, rangeEnd string, permType clientv3.PermissionType) (*clientv3.AuthRoleGrantPermissionResponse, error)
@@ -68,5 +118,21 @@ type Client interface {
 	RoleRevokePermission(role string, key, rangeEnd string) (*clientv3.AuthRoleRevokePermissionResponse, error)
 	RoleDelete(role string) (*clientv3.AuthRoleDeleteResponse, error)
 
-	Txn(compares, ifSucess, ifFail []string, o config.TxnOptions) (*clientv3.TxnResponse, error)
+	Txn(compares []config.TxnCompare, ifSucess, ifFail []config.TxnOp, o config.TxnOptions) (*clientv3.TxnResponse, error)
+
+	DowngradeValidate(version string) (*clientv3.DowngradeResponse, error)
+	DowngradeEnable(version string) (*clientv3.DowngradeResponse, error)
+	DowngradeCancel() (*clientv3.DowngradeResponse, error)
+
+	Watch(ctx context.Context, key string, opts config.WatchOptions) (clientv3.WatchChan, error)
+}
+
+// GatewayClient talks to the cluster through the gRPC gateway. Requests and
+// responses are plain JSON, so tests observe the wire format of the gateway,
+// e.g. bytes encoded as base64 and 64-bit integers encoded as strings.
+type GatewayClient interface {
+	// Post sends req encoded as JSON to the gateway path, e.g. "/v3/kv/range",
+	// and decodes the JSON response into resp. A non-empty token is sent in
+	// the Authorization header.
+	Post(path, token string, req, resp interface{}) error
 }
@@ -0,0 +1,197 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakCheckTimeout bounds how long resources released asynchronously, e.g.
+// by goroutines still shutting down, are waited for once the test ends.
+var leakCheckTimeout = 5 * time.Second
+
+// ignoredGoroutines are the functions of goroutines that outlive tests by
+// design, e.g. started once per process by the libraries the tests use.
+var ignoredGoroutines = []string{
+	"testing.(*T).Run",
+	"testing.tRunner",
+	"testing.runTests",
+	"testing.(*M).",
+	"runtime.goexit",
+	"runtime.gc",
+	"os/signal.",
+	"go.opencensus.io/stats/view.(*worker).start",
+	"github.com/golang/glog.(*loggingT).flushDaemon",
+	"go.etcd.io/etcd/client/pkg/v3/logutil.(*MergeLogger).outputLoop",
+}
+
+// resources is a snapshot of the resources held by the test process.
+type resources struct {
+	// goroutines are the stacks of goroutines by ID
+	goroutines map[string]string
+	// fds are the targets of open file descriptors by number
+	fds map[string]string
+	// tempFiles are the entries of the temporary directory of the test
+	tempFiles []string
+}
+
+// trackResources snapshots the goroutines and file descriptors of the test
+// process and fails tb if, once all its other cleanups ran and its clusters
+// were closed, it holds any it did not hold before, or left files behind in
+// its temporary directory. The temporary directory of the test, as returned
+// by os.TempDir, is set by trackResources, so tests running in parallel with
+// tb must be its subtests.
+func trackResources(tb testing.TB) {
+	tmp, err := os.MkdirTemp("", "etcd-test-tmp")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	prevTmp, prevTmpSet := os.LookupEnv("TMPDIR")
+	os.Setenv("TMPDIR", tmp)
+	before := snapshotResources(tmp)
+
+	tb.Cleanup(func() {
+		defer func() {
+			if prevTmpSet {
+				os.Setenv("TMPDIR", prevTmp)
+			} else {
+				os.Unsetenv("TMPDIR")
+			}
+			os.RemoveAll(tmp)
+		}()
+		// leaks are expected when the test fails half way and would hide
+		// the actual failure
+		if tb.Failed() {
+			return
+		}
+		var leaks []string
+		deadline := time.Now().Add(leakCheckTimeout)
+		for {
+			http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+			leaks = before.leaked(snapshotResources(tmp))
+			if len(leaks) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if len(leaks) != 0 {
+			tb.Errorf("test leaked resources after its clusters were closed:\n%s", strings.Join(leaks, "\n"))
+		}
+	})
+}
+
+func snapshotResources(tmp string) resources {
+	return resources{
+		goroutines: goroutines(),
+		fds:        openFDs(),
+		tempFiles:  tempFiles(tmp),
+	}
+}
+
+// leaked describes the resources of after which are not in r.
+func (r resources) leaked(after resources) []string {
+	var leaks []string
+	for id, stack := range after.goroutines {
+		if _, ok := r.goroutines[id]; !ok {
+			leaks = append(leaks, fmt.Sprintf("goroutine %s:\n%s", id, stack))
+		}
+	}
+	for fd, target := range after.fds {
+		if r.fds[fd] != target {
+			leaks = append(leaks, fmt.Sprintf("file descriptor %s: %s", fd, target))
+		}
+	}
+	for _, f := range after.tempFiles {
+		leaks = append(leaks, fmt.Sprintf("temporary file: %s", f))
+	}
+	sort.Strings(leaks)
+	return leaks
+}
+
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+) \[`)
+
+// goroutines returns the stacks of the goroutines which are not ignored.
+func goroutines() map[string]string {
+	buf := make([]byte, 2<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	gs := make(map[string]string)
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		sl := strings.SplitN(g, "\n", 2)
+		m := goroutineHeader.FindStringSubmatch(sl[0])
+		if len(sl) != 2 || m == nil || isIgnoredGoroutine(sl[1]) {
+			continue
+		}
+		gs[m[1]] = strings.TrimSpace(sl[1])
+	}
+	return gs
+}
+
+func isIgnoredGoroutine(stack string) bool {
+	for _, f := range ignoredGoroutines {
+		if strings.Contains(stack, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// openFDs returns the targets of the open file descriptors of the process,
+// or nothing if they cannot be listed, e.g. on platforms without procfs.
+func openFDs() map[string]string {
+	dir := fmt.Sprintf("/proc/%d/fd", os.Getpid())
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	fds := make(map[string]string)
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		// the descriptor of the directory being read is closed already,
+		// anonymous inodes are the event loops of the runtime
+		if err != nil || target == dir || strings.HasPrefix(target, "anon_inode:") {
+			continue
+		}
+		fds[e.Name()] = target
+	}
+	return fds
+}
+
+func tempFiles(tmp string) []string {
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		files = append(files, filepath.Join(tmp, e.Name()))
+	}
+	return files
+}
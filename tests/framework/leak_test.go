@@ -0,0 +1,86 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cleanupTB runs its cleanups on demand and records its errors.
+type cleanupTB struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (c *cleanupTB) Cleanup(f func()) { c.cleanups = append(c.cleanups, f) }
+
+func (c *cleanupTB) Errorf(format string, args ...interface{}) {
+	c.errors = append(c.errors, fmt.Sprintf(format, args...))
+}
+
+func (c *cleanupTB) Failed() bool { return false }
+
+func (c *cleanupTB) runCleanups() {
+	for i := len(c.cleanups) - 1; i >= 0; i-- {
+		c.cleanups[i]()
+	}
+}
+
+func TestTrackResources(t *testing.T) {
+	defer func(d time.Duration) { leakCheckTimeout = d }(leakCheckTimeout)
+	leakCheckTimeout = 200 * time.Millisecond
+
+	tb := &cleanupTB{TB: t}
+	trackResources(tb)
+	stop := make(chan struct{})
+	go func() { <-stop }()
+	f, err := os.CreateTemp("", "leaked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb.runCleanups()
+	if len(tb.errors) != 1 {
+		t.Fatalf("expected the leaks to be reported, got %q", tb.errors)
+	}
+	for _, want := range []string{"goroutine", "file descriptor", "temporary file: " + f.Name()} {
+		if !strings.Contains(tb.errors[0], want) {
+			t.Errorf("expected the report to list the leaked %q, got:\n%s", want, tb.errors[0])
+		}
+	}
+
+	// released resources are not reported
+	close(stop)
+	f.Close()
+	tb = &cleanupTB{TB: t}
+	trackResources(tb)
+	stop = make(chan struct{})
+	go func() { <-stop }()
+	f, err = os.CreateTemp("", "released")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	close(stop)
+	tb.runCleanups()
+	if len(tb.errors) != 0 {
+		t.Errorf("expected no leaks, got %q", tb.errors)
+	}
+}
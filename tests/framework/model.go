@@ -0,0 +1,325 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type requestKind string
+
+const (
+	putRequest    requestKind = "put"
+	rangeRequest  requestKind = "get"
+	deleteRequest requestKind = "del"
+	txnRequest    requestKind = "txn"
+)
+
+// request is the input of an operation of the etcd model.
+type request struct {
+	kind requestKind
+	key  string
+	// end is the end of the range of a get or a delete. It is empty for a
+	// single key and "\x00" for all keys from key on.
+	end   string
+	value string
+
+	compares  []clientv3.Cmp
+	onSuccess []request
+	onFailure []request
+}
+
+func (r request) String() string {
+	switch r.kind {
+	case putRequest:
+		return fmt.Sprintf("put(%q, %q)", r.key, r.value)
+	case rangeRequest, deleteRequest:
+		if r.end == "" {
+			return fmt.Sprintf("%s(%q)", r.kind, r.key)
+		}
+		return fmt.Sprintf("%s(%q, %q)", r.kind, r.key, r.end)
+	default:
+		var cmps []string
+		for _, c := range r.compares {
+			cmps = append(cmps, fmt.Sprintf("%s(%q) %s %v", c.Target, c.Key, c.Result, c.TargetUnion))
+		}
+		return fmt.Sprintf("txn(if %v then %v else %v)", cmps, r.onSuccess, r.onFailure)
+	}
+}
+
+// keyValue is a key as stored by the model. Revisions are counted from the
+// first request of the history, see etcdState.
+type keyValue struct {
+	key            string
+	value          string
+	createRevision int64
+	modRevision    int64
+	version        int64
+}
+
+// response is the output of an operation of the etcd model.
+type response struct {
+	// failed means the request returned an error. A failed request may or
+	// may not have been applied.
+	failed bool
+	// revision is the revision in the response header, 0 if the response
+	// does not have one.
+	revision  int64
+	kvs       []keyValue
+	deleted   int64
+	succeeded bool
+	responses []response
+}
+
+// etcdState is a possible state of the keys written through the recorded
+// clients. The revision of the cluster when the history starts is not known,
+// so revisions are counted relative to it until a response reveals base.
+type etcdState struct {
+	base     int64
+	revision int64
+	kvs      map[string]keyValue
+}
+
+// etcdStates are all the states the cluster could be in. There can be more
+// than one as failed writes may or may not have been applied.
+type etcdStates []etcdState
+
+var etcdModel = porcupine.Model{
+	Init: func() interface{} {
+		return etcdStates{{kvs: map[string]keyValue{}}}
+	},
+	Step: func(st, in, out interface{}) (bool, interface{}) {
+		var next etcdStates
+		for _, s := range st.(etcdStates) {
+			for _, n := range s.step(in.(request), out.(response)) {
+				next = next.add(n)
+			}
+		}
+		return len(next) > 0, next
+	},
+	Equal: func(st1, st2 interface{}) bool {
+		s1, s2 := st1.(etcdStates), st2.(etcdStates)
+		if len(s1) != len(s2) {
+			return false
+		}
+		for _, s := range s1 {
+			if !s2.contains(s) {
+				return false
+			}
+		}
+		return true
+	},
+	DescribeOperation: func(in, out interface{}) string {
+		return fmt.Sprintf("%s -> %+v", in.(request), out.(response))
+	},
+	DescribeState: func(st interface{}) string {
+		return fmt.Sprintf("%+v", st.(etcdStates))
+	},
+}
+
+func (ss etcdStates) contains(s etcdState) bool {
+	for _, o := range ss {
+		if reflect.DeepEqual(o, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ss etcdStates) add(s etcdState) etcdStates {
+	if ss.contains(s) {
+		return ss
+	}
+	return append(ss, s)
+}
+
+// step returns the states s can move to by serving req with resp, none if
+// resp is not a valid response in s.
+func (s etcdState) step(req request, resp response) []etcdState {
+	if resp.failed {
+		next, _ := s.apply(req)
+		return []etcdState{s, next}
+	}
+	bases := []int64{s.base}
+	guess := s.base == 0 && resp.revision != 0
+	if guess {
+		// The first revision seen reveals the base, depending on whether
+		// the request created a revision or not.
+		bases = []int64{resp.revision - s.revision, resp.revision - s.revision - 1}
+	}
+	var states []etcdState
+	for _, base := range bases {
+		if guess && base < 1 {
+			continue
+		}
+		s.base = base
+		next, expected := s.apply(req)
+		if resp.revision != 0 && next.base+next.revision != resp.revision {
+			continue
+		}
+		if next.matches(expected, resp) {
+			states = append(states, next)
+		}
+	}
+	return states
+}
+
+// apply serves req and returns the next state and the expected response,
+// with relative revisions.
+func (s etcdState) apply(req request) (etcdState, response) {
+	next := etcdState{base: s.base, revision: s.revision, kvs: make(map[string]keyValue, len(s.kvs))}
+	for k, v := range s.kvs {
+		next.kvs[k] = v
+	}
+	resp, changed := s.applyOp(&next, req)
+	if changed {
+		next.revision++
+	}
+	return next, resp
+}
+
+// applyOp serves op on next and reports whether it changed a key. Revisions
+// and comparisons are based on s, the state before the request: all the
+// writes of a transaction share a revision, and the server evaluates the
+// comparisons of nested transactions before applying any of them.
+func (s etcdState) applyOp(next *etcdState, op request) (resp response, changed bool) {
+	switch op.kind {
+	case putRequest:
+		kv, ok := next.kvs[op.key]
+		if !ok {
+			kv = keyValue{key: op.key, createRevision: s.revision + 1}
+		}
+		kv.value, kv.modRevision, kv.version = op.value, s.revision+1, kv.version+1
+		next.kvs[op.key] = kv
+		changed = true
+	case rangeRequest:
+		resp.kvs = next.keysInRange(op.key, op.end)
+	case deleteRequest:
+		for _, kv := range next.keysInRange(op.key, op.end) {
+			delete(next.kvs, kv.key)
+			resp.deleted++
+			changed = true
+		}
+	case txnRequest:
+		resp.succeeded = s.compare(op.compares)
+		ops := op.onFailure
+		if resp.succeeded {
+			ops = op.onSuccess
+		}
+		for _, o := range ops {
+			r, c := s.applyOp(next, o)
+			resp.responses = append(resp.responses, r)
+			changed = changed || c
+		}
+	}
+	return resp, changed
+}
+
+func (s etcdState) keysInRange(key, end string) []keyValue {
+	var kvs []keyValue
+	for k, kv := range s.kvs {
+		if k == key || (end != "" && k > key && (end == "\x00" || k < end)) {
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+	return kvs
+}
+
+// compare evaluates the compares of a transaction the way the server does.
+func (s etcdState) compare(cmps []clientv3.Cmp) bool {
+	for _, c := range cmps {
+		kv, ok := s.kvs[string(c.Key)]
+		if !ok && c.Target == pb.Compare_VALUE {
+			return false
+		}
+		var r int
+		switch c.Target {
+		case pb.Compare_VALUE:
+			r = strings.Compare(kv.value, string(c.ValueBytes()))
+		case pb.Compare_VERSION:
+			r = compareInt64(kv.version, c.TargetUnion.(*pb.Compare_Version).Version)
+		case pb.Compare_CREATE:
+			r = compareInt64(s.absolute(kv.createRevision), c.TargetUnion.(*pb.Compare_CreateRevision).CreateRevision)
+		case pb.Compare_MOD:
+			r = compareInt64(s.absolute(kv.modRevision), c.TargetUnion.(*pb.Compare_ModRevision).ModRevision)
+		case pb.Compare_LEASE:
+			r = compareInt64(0, c.TargetUnion.(*pb.Compare_Lease).Lease)
+		}
+		var ok2 bool
+		switch c.Result {
+		case pb.Compare_EQUAL:
+			ok2 = r == 0
+		case pb.Compare_NOT_EQUAL:
+			ok2 = r != 0
+		case pb.Compare_GREATER:
+			ok2 = r > 0
+		case pb.Compare_LESS:
+			ok2 = r < 0
+		}
+		if !ok2 {
+			return false
+		}
+	}
+	return true
+}
+
+// absolute converts a relative revision of a key, 0 if the key does not
+// exist.
+func (s etcdState) absolute(rev int64) int64 {
+	if rev == 0 {
+		return 0
+	}
+	return s.base + rev
+}
+
+// matches reports whether resp is the expected response, including the
+// responses of transactions.
+func (s etcdState) matches(expected, resp response) bool {
+	if expected.deleted != resp.deleted || expected.succeeded != resp.succeeded ||
+		len(expected.kvs) != len(resp.kvs) || len(expected.responses) != len(resp.responses) {
+		return false
+	}
+	for i, kv := range expected.kvs {
+		kv.createRevision, kv.modRevision = s.absolute(kv.createRevision), s.absolute(kv.modRevision)
+		if kv != resp.kvs[i] {
+			return false
+		}
+	}
+	for i := range expected.responses {
+		if !s.matches(expected.responses[i], resp.responses[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEtcdModel(t *testing.T) {
+	put := func(key, value string) request { return request{kind: putRequest, key: key, value: value} }
+	get := func(key string) request { return request{kind: rangeRequest, key: key} }
+	kv := func(key, value string, create, mod, ver int64) keyValue {
+		return keyValue{key: key, value: value, createRevision: create, modRevision: mod, version: ver}
+	}
+	op := func(call, ret int64, req request, resp response) porcupine.Operation {
+		return porcupine.Operation{Input: req, Call: call, Output: resp, Return: ret}
+	}
+	failed := func(call int64, req request) porcupine.Operation {
+		return porcupine.Operation{Input: req, Call: call, Output: response{failed: true}, Return: math.MaxInt64}
+	}
+	tcs := []struct {
+		name string
+		ops  []porcupine.Operation
+		want bool
+	}{
+		{
+			name: "Sequential",
+			ops: []porcupine.Operation{
+				op(0, 1, get("a"), response{revision: 1}),
+				op(2, 3, put("a", "x"), response{}),
+				op(4, 5, get("a"), response{revision: 2, kvs: []keyValue{kv("a", "x", 2, 2, 1)}}),
+				op(6, 7, put("a", "y"), response{}),
+				op(8, 9, get("a"), response{revision: 3, kvs: []keyValue{kv("a", "y", 2, 3, 2)}}),
+			},
+			want: true,
+		},
+		{
+			name: "Revision unknown at start",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 3, get("a"), response{revision: 11, kvs: []keyValue{kv("a", "x", 11, 11, 1)}}),
+			},
+			want: true,
+		},
+		{
+			name: "Concurrent put",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 5, put("a", "y"), response{}),
+				op(3, 4, get("a"), response{revision: 2, kvs: []keyValue{kv("a", "x", 2, 2, 1)}}),
+			},
+			want: true,
+		},
+		{
+			name: "Stale read",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 3, put("a", "y"), response{}),
+				op(4, 5, get("a"), response{revision: 2, kvs: []keyValue{kv("a", "x", 2, 2, 1)}}),
+			},
+			want: false,
+		},
+		{
+			name: "Failed put applied",
+			ops: []porcupine.Operation{
+				op(0, 1, get("a"), response{revision: 1}),
+				failed(2, put("a", "x")),
+				op(4, 5, get("a"), response{revision: 2, kvs: []keyValue{kv("a", "x", 2, 2, 1)}}),
+			},
+			want: true,
+		},
+		{
+			name: "Failed put not applied",
+			ops: []porcupine.Operation{
+				op(0, 1, get("a"), response{revision: 1}),
+				failed(2, put("a", "x")),
+				op(4, 5, get("a"), response{revision: 1}),
+			},
+			want: true,
+		},
+		{
+			name: "Lost write",
+			ops: []porcupine.Operation{
+				op(0, 1, get("a"), response{revision: 1}),
+				op(2, 3, put("a", "x"), response{}),
+				op(4, 5, get("a"), response{revision: 1}),
+			},
+			want: false,
+		},
+		{
+			name: "Delete prefix",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a/1", "x"), response{}),
+				op(2, 3, put("a/2", "y"), response{}),
+				op(4, 5, request{kind: deleteRequest, key: "a/", end: clientv3.GetPrefixRangeEnd("a/")}, response{revision: 4, deleted: 2}),
+				op(6, 7, request{kind: rangeRequest, key: "a/", end: clientv3.GetPrefixRangeEnd("a/")}, response{revision: 4}),
+			},
+			want: true,
+		},
+		{
+			name: "Compare and swap",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 3, request{
+					kind:      txnRequest,
+					compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{put("a", "y")},
+				}, response{revision: 3, succeeded: true, responses: []response{{}}}),
+				op(4, 5, request{
+					kind:      txnRequest,
+					compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{put("a", "z")},
+					onFailure: []request{get("a")},
+				}, response{revision: 3, succeeded: false, responses: []response{{kvs: []keyValue{kv("a", "y", 2, 3, 2)}}}}),
+			},
+			want: true,
+		},
+		{
+			name: "Compare and swap succeeding twice",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 5, request{
+					kind:      txnRequest,
+					compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{put("a", "y")},
+				}, response{revision: 3, succeeded: true, responses: []response{{}}}),
+				op(3, 4, request{
+					kind:      txnRequest,
+					compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{put("a", "z")},
+				}, response{revision: 3, succeeded: true, responses: []response{{}}}),
+			},
+			want: false,
+		},
+		{
+			name: "Nested transaction compares before writes",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 3, request{
+					kind:     txnRequest,
+					compares: []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{put("b", "y"), {
+						kind:      txnRequest,
+						compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Version("b"), "=", 0)},
+						onSuccess: []request{put("a", "z")},
+						onFailure: []request{get("a")},
+					}},
+				}, response{revision: 3, succeeded: true, responses: []response{{}, {succeeded: true, responses: []response{{}}}}}),
+				op(4, 5, get("a"), response{revision: 3, kvs: []keyValue{kv("a", "z", 2, 3, 2)}}),
+			},
+			want: true,
+		},
+		{
+			name: "Nested transaction wrong branch",
+			ops: []porcupine.Operation{
+				op(0, 1, put("a", "x"), response{}),
+				op(2, 3, request{
+					kind:     txnRequest,
+					compares: []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "x")},
+					onSuccess: []request{{
+						kind:      txnRequest,
+						compares:  []clientv3.Cmp{clientv3.Compare(clientv3.Value("a"), "=", "y")},
+						onSuccess: []request{put("a", "z")},
+						onFailure: []request{get("a")},
+					}},
+				}, response{revision: 3, succeeded: true, responses: []response{{succeeded: true, responses: []response{{}}}}}),
+			},
+			want: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := porcupine.CheckOperations(etcdModel, tc.ops); got != tc.want {
+				t.Errorf("CheckOperations() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
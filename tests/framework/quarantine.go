@@ -0,0 +1,328 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// maxReportedLogLines bounds the log lines listed per attempt in the
+// divergence report.
+const maxReportedLogLines = 20
+
+// Quarantine marks a flaky shared test. A quarantined test is run up to
+// Attempts times, each attempt with its own clusters, and only fails if all
+// attempts fail. Whenever attempts end differently, what differed between
+// them is logged so flakes can be triaged from the test output.
+type Quarantine struct {
+	// Reason explains why the test is quarantined, e.g. an issue link.
+	Reason string
+	// Attempts is the maximum number of attempts, at least one.
+	Attempts int
+}
+
+// RunQuarantined runs f as a test quarantined by q. Clusters created with
+// the Attempt passed to f are closed before the next attempt starts. The
+// runner's BeforeTest should be called with t, once, before RunQuarantined.
+func RunQuarantined(t *testing.T, q Quarantine, f func(a *Attempt)) {
+	t.Helper()
+	t.Logf("test is quarantined: %s", q.Reason)
+	attempts := runAttempts(t, q.Attempts, f)
+	last := attempts[len(attempts)-1]
+	if last.Skipped() {
+		t.Skip(last.skipMsg)
+	}
+	if len(attempts) > 1 {
+		t.Log(divergenceReport(attempts))
+	}
+	if last.Failed() {
+		t.Errorf("all %d attempts of the quarantined test failed", len(attempts))
+	}
+}
+
+// runAttempts runs f until an attempt passes or is skipped, at most n times.
+func runAttempts(tb testing.TB, n int, f func(a *Attempt)) []*Attempt {
+	if n < 1 {
+		n = 1
+	}
+	var attempts []*Attempt
+	for i := 1; i <= n; i++ {
+		a := &Attempt{TB: tb, n: i}
+		a.run(f)
+		attempts = append(attempts, a)
+		if !a.Failed() || a.Skipped() {
+			break
+		}
+		if i < n {
+			tb.Logf("attempt %d of %d failed, retrying with new clusters", i, n)
+		}
+	}
+	return attempts
+}
+
+// Attempt is the testing.TB of a single attempt of a quarantined test.
+// Failing it ends the attempt instead of the test, cleanups registered with
+// it run once the attempt ends, and everything else is passed to the test.
+type Attempt struct {
+	testing.TB
+	n int
+
+	mu           sync.Mutex
+	failed       bool
+	skipped      bool
+	skipMsg      string
+	failures     []string
+	logs         []string
+	observations map[string]string
+	cleanups     []func()
+	took         time.Duration
+}
+
+// Observe records value under name, e.g. a response the test checks. The
+// divergence report lists the names observed with different values by
+// different attempts.
+func (a *Attempt) Observe(name string, value interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.observations == nil {
+		a.observations = make(map[string]string)
+	}
+	a.observations[name] = fmt.Sprintf("%+v", value)
+}
+
+func (a *Attempt) run(f func(a *Attempt)) {
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer a.runCleanups()
+		f(a)
+	}()
+	<-done
+	a.took = time.Since(start)
+}
+
+func (a *Attempt) runCleanups() {
+	for {
+		a.mu.Lock()
+		if len(a.cleanups) == 0 {
+			a.mu.Unlock()
+			return
+		}
+		cleanup := a.cleanups[len(a.cleanups)-1]
+		a.cleanups = a.cleanups[:len(a.cleanups)-1]
+		a.mu.Unlock()
+		cleanup()
+	}
+}
+
+func (a *Attempt) Cleanup(f func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cleanups = append(a.cleanups, f)
+}
+
+func (a *Attempt) Log(args ...interface{}) {
+	a.log(fmt.Sprintln(args...))
+}
+
+func (a *Attempt) Logf(format string, args ...interface{}) {
+	a.log(fmt.Sprintf(format, args...))
+}
+
+func (a *Attempt) log(msg string) {
+	msg = strings.TrimSuffix(msg, "\n")
+	a.mu.Lock()
+	a.logs = append(a.logs, msg)
+	a.mu.Unlock()
+	a.TB.Logf("attempt %d: %s", a.n, msg)
+}
+
+func (a *Attempt) Error(args ...interface{}) {
+	a.fail(fmt.Sprintln(args...))
+}
+
+func (a *Attempt) Errorf(format string, args ...interface{}) {
+	a.fail(fmt.Sprintf(format, args...))
+}
+
+func (a *Attempt) Fatal(args ...interface{}) {
+	a.fail(fmt.Sprintln(args...))
+	a.FailNow()
+}
+
+func (a *Attempt) Fatalf(format string, args ...interface{}) {
+	a.fail(fmt.Sprintf(format, args...))
+	a.FailNow()
+}
+
+func (a *Attempt) fail(msg string) {
+	msg = strings.TrimSuffix(msg, "\n")
+	a.mu.Lock()
+	a.failures = append(a.failures, msg)
+	a.mu.Unlock()
+	a.Fail()
+	a.TB.Logf("attempt %d failed: %s", a.n, msg)
+}
+
+func (a *Attempt) Fail() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failed = true
+}
+
+func (a *Attempt) FailNow() {
+	a.Fail()
+	runtime.Goexit()
+}
+
+func (a *Attempt) Failed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.failed
+}
+
+func (a *Attempt) Skip(args ...interface{}) {
+	a.skip(fmt.Sprintln(args...))
+}
+
+func (a *Attempt) Skipf(format string, args ...interface{}) {
+	a.skip(fmt.Sprintf(format, args...))
+}
+
+func (a *Attempt) SkipNow() {
+	a.skip("")
+}
+
+func (a *Attempt) skip(msg string) {
+	a.mu.Lock()
+	a.skipped = true
+	a.skipMsg = strings.TrimSuffix(msg, "\n")
+	a.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (a *Attempt) Skipped() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.skipped
+}
+
+// digits are masked when comparing logs, so that timestamps, ports and
+// revisions do not make every line differ between attempts.
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// divergenceReport describes how the attempts differ: their outcome, the
+// observations with different values, and the log lines that are not
+// printed by every attempt.
+func divergenceReport(attempts []*Attempt) string {
+	// members may still log while the report is built
+	logs := make([][]string, len(attempts))
+	observations := make([]map[string]string, len(attempts))
+	for i, a := range attempts {
+		a.mu.Lock()
+		logs[i] = append([]string(nil), a.logs...)
+		observations[i] = make(map[string]string, len(a.observations))
+		for name, v := range a.observations {
+			observations[i][name] = v
+		}
+		a.mu.Unlock()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "quarantined test ran %d attempts:\n", len(attempts))
+	for _, a := range attempts {
+		a.mu.Lock()
+		outcome := "passed"
+		if a.failed {
+			outcome = "failed"
+		}
+		fmt.Fprintf(&b, "  attempt %d %s after %v\n", a.n, outcome, a.took.Round(time.Millisecond))
+		for _, f := range a.failures {
+			fmt.Fprintf(&b, "    failure: %s\n", f)
+		}
+		a.mu.Unlock()
+	}
+
+	names := map[string]struct{}{}
+	for _, obs := range observations {
+		for name := range obs {
+			names[name] = struct{}{}
+		}
+	}
+	var diverged []string
+	for name := range names {
+		values := map[string]struct{}{}
+		for _, obs := range observations {
+			v, ok := obs[name]
+			if !ok {
+				v = "<not observed>"
+			}
+			values[v] = struct{}{}
+		}
+		if len(values) > 1 {
+			diverged = append(diverged, name)
+		}
+	}
+	sort.Strings(diverged)
+	for _, name := range diverged {
+		fmt.Fprintf(&b, "  observation %q differs:\n", name)
+		for i, a := range attempts {
+			v, ok := observations[i][name]
+			if !ok {
+				v = "<not observed>"
+			}
+			fmt.Fprintf(&b, "    attempt %d: %s\n", a.n, v)
+		}
+	}
+
+	seen := map[string]int{}
+	for i := range attempts {
+		lines := map[string]struct{}{}
+		for _, l := range logs[i] {
+			lines[digits.ReplaceAllString(l, "N")] = struct{}{}
+		}
+		for l := range lines {
+			seen[l]++
+		}
+	}
+	for i, a := range attempts {
+		var unique []string
+		for _, l := range logs[i] {
+			if seen[digits.ReplaceAllString(l, "N")] < len(attempts) {
+				unique = append(unique, l)
+			}
+		}
+		if len(unique) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  log lines of attempt %d missing from other attempts:\n", a.n)
+		for i, l := range unique {
+			if i == maxReportedLogLines {
+				fmt.Fprintf(&b, "    ... %d more\n", len(unique)-i)
+				break
+			}
+			fmt.Fprintf(&b, "    %s\n", l)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRunAttempts(t *testing.T) {
+	tcs := []struct {
+		name string
+		// failures lists whether each attempt fails
+		failures     []bool
+		wantAttempts int
+	}{
+		{name: "Pass", failures: []bool{false}, wantAttempts: 1},
+		{name: "Flaky", failures: []bool{true, true, false}, wantAttempts: 3},
+		{name: "Fail", failures: []bool{true, true, true, true}, wantAttempts: 3},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var cleanups, runs int
+			attempts := runAttempts(t, 3, func(a *Attempt) {
+				a.Cleanup(func() { cleanups++ })
+				fail := tc.failures[runs]
+				runs++
+				if fail {
+					a.Fatalf("attempt %d failed", runs)
+				}
+			})
+			if len(attempts) != tc.wantAttempts || runs != tc.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d (%d runs)", tc.wantAttempts, len(attempts), runs)
+			}
+			if cleanups != runs {
+				t.Errorf("expected cleanups of all %d attempts to run, %d ran", runs, cleanups)
+			}
+			if got, want := attempts[len(attempts)-1].Failed(), tc.failures[len(attempts)-1]; got != want {
+				t.Errorf("expected last attempt failed=%v, got %v", want, got)
+			}
+			if t.Failed() {
+				t.Fatal("failed attempts must not fail the test")
+			}
+		})
+	}
+}
+
+func TestRunAttemptsSkip(t *testing.T) {
+	attempts := runAttempts(t, 3, func(a *Attempt) {
+		a.Skip("not supported")
+	})
+	if len(attempts) != 1 || !attempts[0].Skipped() || attempts[0].skipMsg != "not supported" {
+		t.Fatalf("expected a single skipped attempt, got %d", len(attempts))
+	}
+}
+
+func TestDivergenceReport(t *testing.T) {
+	runs := 0
+	attempts := runAttempts(t, 2, func(a *Attempt) {
+		runs++
+		a.Logf("member started on port %d", 2379+runs)
+		a.Observe("revision", runs+1)
+		a.Observe("leader", "m0")
+		if runs == 1 {
+			a.Log("election timed out")
+			a.Error("unexpected revision")
+		}
+	})
+	report := divergenceReport(attempts)
+	for _, want := range []string{
+		"attempt 1 failed",
+		"attempt 2 passed",
+		"failure: unexpected revision",
+		fmt.Sprintf("observation %q differs", "revision"),
+		"attempt 1: 2",
+		"attempt 2: 3",
+		"log lines of attempt 1 missing from other attempts:\n    election timed out",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+	for _, unwanted := range []string{"leader", "member started"} {
+		if strings.Contains(report, unwanted) {
+			t.Errorf("expected report not to contain %q, got:\n%s", unwanted, report)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchedPut is a put a watch is expected to observe. Revision is the
+// revision the put was committed at, it is not checked if 0.
+type WatchedPut struct {
+	Key, Value string
+	Revision   int64
+}
+
+func (p WatchedPut) String() string {
+	if p.Revision == 0 {
+		return fmt.Sprintf("put %s=%s", p.Key, p.Value)
+	}
+	return fmt.Sprintf("put %s=%s at revision %d", p.Key, p.Value, p.Revision)
+}
+
+type eventID struct {
+	key      string
+	revision int64
+}
+
+// ValidateWatch consumes wch until it observed all the puts, which are the
+// puts to the watched keys from the start revision of the watch on, in the
+// order they were committed. It returns an error if the revisions of the
+// responses or the events go back, if an event is delivered twice, or if
+// the put events do not match the puts, i.e. a put is missing, out of order
+// or unexpected. Delete events are only checked for order and duplicates.
+// Once the puts are observed, the watch may keep sending events that are not
+// checked.
+func ValidateWatch(ctx context.Context, wch clientv3.WatchChan, puts []WatchedPut) error {
+	var (
+		headerRev, eventRev int64
+		seen                = make(map[eventID]struct{})
+		next                int
+	)
+	for next < len(puts) {
+		var (
+			resp clientv3.WatchResponse
+			ok   bool
+		)
+		select {
+		case resp, ok = <-wch:
+		case <-ctx.Done():
+			return fmt.Errorf("watch observed %d of %d puts, missing %s: %w", next, len(puts), puts[next], ctx.Err())
+		}
+		if !ok {
+			return fmt.Errorf("watch closed after observing %d of %d puts, missing %s", next, len(puts), puts[next])
+		}
+		if err := resp.Err(); err != nil {
+			return fmt.Errorf("watch failed after observing %d of %d puts: %w", next, len(puts), err)
+		}
+		if resp.Header.Revision < headerRev {
+			return fmt.Errorf("watch response revision went back from %d to %d", headerRev, resp.Header.Revision)
+		}
+		headerRev = resp.Header.Revision
+		for _, ev := range resp.Events {
+			id := eventID{key: string(ev.Kv.Key), revision: ev.Kv.ModRevision}
+			if ev.Kv.ModRevision < eventRev {
+				return fmt.Errorf("event %s %s at revision %d delivered after revision %d", ev.Type, ev.Kv.Key, ev.Kv.ModRevision, eventRev)
+			}
+			if _, ok := seen[id]; ok {
+				return fmt.Errorf("event %s %s at revision %d delivered twice", ev.Type, ev.Kv.Key, ev.Kv.ModRevision)
+			}
+			seen[id] = struct{}{}
+			eventRev = ev.Kv.ModRevision
+			if ev.Type != clientv3.EventTypePut || next == len(puts) {
+				continue
+			}
+			want := puts[next]
+			got := WatchedPut{Key: string(ev.Kv.Key), Value: string(ev.Kv.Value), Revision: ev.Kv.ModRevision}
+			if want.Revision == 0 {
+				got.Revision = 0
+			}
+			if got != want {
+				return fmt.Errorf("watch observed %s, expected %s after %d puts", got, want, next)
+			}
+			next++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestValidateWatch(t *testing.T) {
+	put := func(key, value string, rev int64) *clientv3.Event {
+		return &clientv3.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: rev}}
+	}
+	del := func(key string, rev int64) *clientv3.Event {
+		return &clientv3.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev}}
+	}
+	resp := func(rev int64, events ...*clientv3.Event) clientv3.WatchResponse {
+		return clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: rev}, Events: events}
+	}
+	puts := []WatchedPut{{Key: "a", Value: "1", Revision: 2}, {Key: "b", Value: "2", Revision: 3}, {Key: "a", Value: "3"}}
+	tcs := []struct {
+		name      string
+		responses []clientv3.WatchResponse
+		// wantErr is a substring of the expected error, no error if empty.
+		wantErr string
+	}{
+		{
+			name:      "AllPuts",
+			responses: []clientv3.WatchResponse{resp(2, put("a", "1", 2)), resp(5, put("b", "2", 3), del("b", 4), put("a", "3", 5))},
+		},
+		{
+			name:      "ProgressNotify",
+			responses: []clientv3.WatchResponse{resp(3, put("a", "1", 2), put("b", "2", 3)), resp(4), resp(5, put("a", "3", 5))},
+		},
+		{
+			name:      "Gap",
+			responses: []clientv3.WatchResponse{resp(2, put("a", "1", 2)), resp(5, put("a", "3", 5))},
+			wantErr:   "expected put b=2 at revision 3",
+		},
+		{
+			name:      "WrongRevision",
+			responses: []clientv3.WatchResponse{resp(2, put("a", "1", 4))},
+			wantErr:   "observed put a=1 at revision 4",
+		},
+		{
+			name:      "Duplicate",
+			responses: []clientv3.WatchResponse{resp(2, put("a", "1", 2)), resp(2, put("a", "1", 2))},
+			wantErr:   "delivered twice",
+		},
+		{
+			name:      "EventRevisionBack",
+			responses: []clientv3.WatchResponse{resp(4, put("a", "1", 2), del("c", 4)), resp(4, put("b", "2", 3))},
+			wantErr:   "delivered after revision 4",
+		},
+		{
+			name:      "HeaderRevisionBack",
+			responses: []clientv3.WatchResponse{resp(4, put("a", "1", 2)), resp(3)},
+			wantErr:   "went back from 4 to 3",
+		},
+		{
+			name:      "Closed",
+			responses: []clientv3.WatchResponse{resp(2, put("a", "1", 2))},
+			wantErr:   "closed after observing 1 of 3 puts",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			wch := make(chan clientv3.WatchResponse, len(tc.responses))
+			for _, r := range tc.responses {
+				wch <- r
+			}
+			close(wch)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := ValidateWatch(ctx, wch, puts)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("expected no error, got %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
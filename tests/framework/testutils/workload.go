@@ -0,0 +1,151 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"golang.org/x/time/rate"
+)
+
+type Payload int
+
+const (
+	// RandomPayload values are random letters and digits, which compress
+	// poorly.
+	RandomPayload Payload = iota
+	// CompressiblePayload values repeat a short random word, so that they
+	// compress well, like most data stored in etcd.
+	CompressiblePayload
+)
+
+// Putter is the part of a client a Workload writes through, e.g. a
+// framework.Client.
+type Putter interface {
+	Put(key, value string, opts config.PutOptions) error
+}
+
+// Workload describes a dataset written to a cluster. Keys and values are
+// derived from Seed, so the same Workload always writes the same data.
+type Workload struct {
+	// KeyPrefix is prepended to the keys, "workload/" if empty.
+	KeyPrefix string
+	// KeyCount is the number of distinct keys written.
+	KeyCount int
+	// Writes is the number of puts, keys are written round-robin so that
+	// Writes above KeyCount overwrite keys. It defaults to KeyCount.
+	Writes int
+	// ValueSize is the size of values in bytes.
+	ValueSize int
+	Payload   Payload
+	// Rate is the maximum number of puts per second, unlimited if zero.
+	Rate int
+	// Concurrency is the number of concurrent writers, 1 if zero.
+	Concurrency int
+	Seed        int64
+}
+
+// Key returns the key written by the i-th put.
+func (w Workload) Key(i int) string {
+	prefix := w.KeyPrefix
+	if prefix == "" {
+		prefix = "workload/"
+	}
+	return fmt.Sprintf("%s%08d", prefix, i%w.KeyCount)
+}
+
+// Value returns the value written by the i-th put.
+func (w Workload) Value(i int) string {
+	r := rand.New(rand.NewSource(w.Seed + int64(i)))
+	switch w.Payload {
+	case CompressiblePayload:
+		word := randomString(r, 8)
+		return strings.Repeat(word, w.ValueSize/len(word)+1)[:w.ValueSize]
+	default:
+		return randomString(r, w.ValueSize)
+	}
+}
+
+// Run writes the workload through c and returns the first error of a put,
+// or the error of ctx once it is done.
+func (w Workload) Run(ctx context.Context, c Putter) error {
+	if w.KeyCount <= 0 {
+		return fmt.Errorf("KeyCount must be positive, got %d", w.KeyCount)
+	}
+	writes := w.Writes
+	if writes == 0 {
+		writes = w.KeyCount
+	}
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if w.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(w.Rate), 1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	puts := make(chan int)
+	errc := make(chan error, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range puts {
+				if err := c.Put(w.Key(i), w.Value(i), config.PutOptions{}); err != nil {
+					errc <- fmt.Errorf("put #%d of %q failed: %w", i, w.Key(i), err)
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	var err error
+	for i := 0; i < writes && err == nil; i++ {
+		if err = limiter.Wait(ctx); err == nil {
+			select {
+			case puts <- i:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+	}
+	close(puts)
+	wg.Wait()
+	select {
+	case putErr := <-errc:
+		return putErr
+	default:
+		return err
+	}
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomString(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[r.Intn(len(letters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/tests/v3/framework/config"
+)
+
+type fakePutter struct {
+	mu   sync.Mutex
+	kvs  map[string]string
+	puts int
+	err  error
+}
+
+func (p *fakePutter) Put(key, value string, _ config.PutOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if p.kvs == nil {
+		p.kvs = map[string]string{}
+	}
+	p.kvs[key] = value
+	p.puts++
+	return nil
+}
+
+func TestWorkload(t *testing.T) {
+	tcs := []struct {
+		name     string
+		workload Workload
+		wantKeys int
+		wantPuts int
+		// compressible tells whether values gzip to less than half their size.
+		compressible bool
+	}{
+		{
+			name:     "Random",
+			workload: Workload{KeyCount: 10, ValueSize: 1024},
+			wantKeys: 10,
+			wantPuts: 10,
+		},
+		{
+			name:         "Compressible",
+			workload:     Workload{KeyCount: 10, ValueSize: 1024, Payload: CompressiblePayload},
+			wantKeys:     10,
+			wantPuts:     10,
+			compressible: true,
+		},
+		{
+			name:     "Overwrites",
+			workload: Workload{KeyCount: 5, Writes: 23, ValueSize: 10, Concurrency: 4},
+			wantKeys: 5,
+			wantPuts: 23,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakePutter{}
+			if err := tc.workload.Run(context.Background(), p); err != nil {
+				t.Fatal(err)
+			}
+			if len(p.kvs) != tc.wantKeys || p.puts != tc.wantPuts {
+				t.Fatalf("expected %d puts of %d keys, got %d puts of %d keys", tc.wantPuts, tc.wantKeys, p.puts, len(p.kvs))
+			}
+			for k, v := range p.kvs {
+				if len(v) != tc.workload.ValueSize {
+					t.Errorf("expected value of %q of %d bytes, got %d", k, tc.workload.ValueSize, len(v))
+				}
+			}
+			v := tc.workload.Value(0)
+			if v != tc.workload.Value(0) {
+				t.Errorf("expected values to be deterministic")
+			}
+			if compressible := gzipSize(t, v) < len(v)/2; compressible != tc.compressible {
+				t.Errorf("expected compressible %v, got %v", tc.compressible, compressible)
+			}
+		})
+	}
+}
+
+func TestWorkloadRate(t *testing.T) {
+	p := &fakePutter{}
+	start := time.Now()
+	if err := (Workload{KeyCount: 11, Rate: 50, Concurrency: 2}).Run(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	// the first put is immediate, the following 10 are 20ms apart
+	if took := time.Since(start); took < 180*time.Millisecond {
+		t.Errorf("expected 11 puts at 50/s to take at least 200ms, took %v", took)
+	}
+}
+
+func TestWorkloadError(t *testing.T) {
+	errPut := errors.New("put failed")
+	p := &fakePutter{err: errPut}
+	if err := (Workload{KeyCount: 100, Concurrency: 4}).Run(context.Background(), p); !errors.Is(err, errPut) {
+		t.Errorf("expected %v, got %v", errPut, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (Workload{KeyCount: 100}).Run(ctx, &fakePutter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func gzipSize(t *testing.T, v string) int {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(v)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Len()
+}
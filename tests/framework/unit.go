@@ -43,3 +43,7 @@ func (e unitRunner) NewCluster(t testing.TB, cfg config.ClusterConfig) Cluster {
 	testutil.SkipTestIfShortMode(t, "Cannot create clusters in --short tests")
 	return nil
 }
+
+func (e unitRunner) Supports(c Capability) bool {
+	return false
+}
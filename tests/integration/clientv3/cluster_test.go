@@ -23,7 +23,9 @@ import (
 	"testing"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/config"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 )
 
@@ -292,6 +294,166 @@ func TestMemberPromote(t *testing.T) {
 	}
 }
 
+// TestMemberAutoPromote ensures that the leader promotes a learner once it
+// catches up, and not before.
+func TestMemberAutoPromote(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.LearnerAutoPromote = true
+			cfg.LearnerAutoPromoteMaxLag = 100
+		},
+	})
+	defer clus.Terminate(t)
+
+	capi := clus.RandClient()
+	memberAddResp, err := capi.MemberAddAsLearner(context.Background(), []string{"http://127.0.0.1:1234"})
+	if err != nil {
+		t.Fatalf("failed to add member %v", err)
+	}
+	learnerID := memberAddResp.Member.ID
+
+	isLearner := func() bool {
+		resp, err := capi.MemberList(context.Background())
+		if err != nil {
+			t.Fatalf("failed to list member %v", err)
+		}
+		for _, m := range resp.Members {
+			if m.ID == learnerID {
+				return m.IsLearner
+			}
+		}
+		t.Fatalf("member %x not found", learnerID)
+		return false
+	}
+
+	// the learner is not started yet, so it must not be promoted.
+	time.Sleep(2 * time.Second)
+	if !isLearner() {
+		t.Fatalf("member %x promoted before it caught up", learnerID)
+	}
+
+	learnerMember := clus.MustNewMember(t, memberAddResp)
+	if err := learnerMember.Launch(); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for isLearner() {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("learner %x was not promoted", learnerID)
+		}
+	}
+}
+
+// TestMemberAddWitness ensures that a witness catches up from a snapshot without
+// the key-value data, does not serve it, and lets a cluster of two data members
+// survive the loss of one of them.
+func TestMemberAddWitness(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size:                   2,
+		SnapshotCount:          10,
+		SnapshotCatchUpEntries: 5,
+	})
+	defer clus.Terminate(t)
+
+	// compact the raft log, so that the witness is sent a snapshot.
+	for i := 0; i < 20; i++ {
+		if _, err := clus.Client(0).Put(context.Background(), fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clus.AddAndLaunchWitnessMember(t)
+	witness := clus.Members[2]
+	resp, err := clus.Client(0).MemberList(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list member %v", err)
+	}
+	for _, m := range resp.Members {
+		if m.IsWitness != (types.ID(m.ID) == witness.Server.ID()) {
+			t.Errorf("member %x: IsWitness = %v", m.ID, m.IsWitness)
+		}
+	}
+
+	// the cluster-wide state is applied by the witness.
+	timeout := time.After(10 * time.Second)
+	for witness.Server.AppliedIndex() < clus.Members[0].Server.AppliedIndex() {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("witness did not catch up")
+		}
+	}
+	if rev := witness.Server.KV().Rev(); rev != 1 {
+		t.Errorf("witness revision = %d, want 1", rev)
+	}
+	if _, err := witness.Client.Get(context.Background(), "foo0"); err != rpctypes.ErrNotSupportedForWitness {
+		t.Errorf("witness get error = %v, want %v", err, rpctypes.ErrNotSupportedForWitness)
+	}
+
+	// the witness keeps the quorum of the remaining data member.
+	lead := clus.WaitMembersForLeader(t, clus.Members[:2])
+	clus.Members[lead].Stop(t)
+	survivor := clus.Members[1-lead]
+	if got := clus.WaitMembersForLeader(t, []*integration2.Member{survivor, witness}); got != 0 {
+		t.Fatalf("leader = member %d, want the surviving data member", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := survivor.Client.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatalf("put with one data member down failed: %v", err)
+	}
+}
+
+// TestWitnessHandsOverLeadership ensures that a data member that lags behind
+// the witness is caught up by it and becomes leader when the other data member
+// is lost.
+func TestWitnessHandsOverLeadership(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 2})
+	defer clus.Terminate(t)
+
+	clus.AddAndLaunchWitnessMember(t)
+	witness := clus.Members[2]
+	lead := clus.WaitMembersForLeader(t, clus.Members[:2])
+	leader, lagging := clus.Members[lead], clus.Members[1-lead]
+
+	// commit an entry acknowledged by the witness only.
+	lagging.InjectPartition(t, leader, witness)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_, err := leader.Client.Put(ctx, "foo", "bar")
+	cancel()
+	if err != nil {
+		t.Fatalf("put with the lagging data member partitioned failed: %v", err)
+	}
+	leader.Stop(t)
+	lagging.RecoverPartition(t, witness)
+
+	if got := clus.WaitMembersForLeader(t, []*integration2.Member{lagging}); got != 0 {
+		t.Fatalf("leader = member %d, want the lagging data member", got)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := lagging.Client.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+		t.Errorf("kvs = %v, want the entry committed by the witness", resp.Kvs)
+	}
+	if witness.Server.Lead() != uint64(lagging.Server.ID()) {
+		t.Errorf("witness leader = %x, want %x", witness.Server.Lead(), lagging.Server.ID())
+	}
+}
+
 // TestMemberPromoteMemberNotLearner ensures that promoting a voting member fails.
 func TestMemberPromoteMemberNotLearner(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -111,3 +111,56 @@ func TestResumeElection(t *testing.T) {
 		t.Errorf("expected new leader to be 'candidate1' got %q", string(kv.Value))
 	}
 }
+
+func TestElectionObserver(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	o := concurrency.NewElectionObserver(cli, "/election-observer")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = o.Leader(ctx); err != concurrency.ErrElectionNoLeader {
+		t.Fatalf("expected %v, got %v", concurrency.ErrElectionNoLeader, err)
+	}
+	obsc := o.Observe(ctx)
+
+	s1, err := concurrency.NewSession(cli, concurrency.WithTTL(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = concurrency.NewElection(s1, "/election-observer").Campaign(ctx, "candidate1"); err != nil {
+		t.Fatal(err)
+	}
+	s2, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	electc := make(chan error, 1)
+	go func() { electc <- concurrency.NewElection(s2, "/election-observer").Campaign(ctx, "candidate2") }()
+
+	// the leadership moves on once the lease of the leader expires
+	s1.Orphan()
+	for _, want := range []string{"candidate1", "candidate2"} {
+		resp, ok := <-obsc
+		if !ok {
+			t.Fatal("Observe() channel closed prematurely")
+		}
+		if string(resp.Kvs[0].Value) != want {
+			t.Fatalf("expected leader %q, got %q", want, resp.Kvs[0].Value)
+		}
+	}
+	if err = <-electc; err != nil {
+		t.Fatal(err)
+	}
+	resp, err := o.Leader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Kvs[0].Value) != "candidate2" {
+		t.Fatalf("expected leader %q, got %q", "candidate2", resp.Kvs[0].Value)
+	}
+}
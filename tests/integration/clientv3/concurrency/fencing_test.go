@@ -0,0 +1,115 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"testing"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestMutexFencingToken(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s1, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s1.Close()
+	m1 := concurrency.NewMutex(s1, "/fencing-lock")
+	if err = m1.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	stale := m1.FencingToken()
+	if _, err = concurrency.FencedTxn(context.TODO(), cli, "/fencing-fence", stale, clientv3.OpPut("/fencing-data", "1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the session of m1 expires while it believes it holds the lock
+	s1.Orphan()
+	if _, err = cli.Revoke(context.TODO(), s1.Lease()); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	m2 := concurrency.NewMutex(s2, "/fencing-lock")
+	if err = m2.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if m2.FencingToken() <= stale {
+		t.Fatalf("expected a token greater than %v, got %v", stale, m2.FencingToken())
+	}
+	if _, err = concurrency.FencedTxn(context.TODO(), cli, "/fencing-fence", m2.FencingToken(), clientv3.OpPut("/fencing-data", "2")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the writes of the stale holder are rejected
+	if _, err = concurrency.FencedTxn(context.TODO(), cli, "/fencing-fence", stale, clientv3.OpPut("/fencing-data", "3")); err != concurrency.ErrFenced {
+		t.Fatalf("expected %v, got %v", concurrency.ErrFenced, err)
+	}
+	resp, err := cli.Txn(context.TODO()).If(stale.Guard("/fencing-fence")).Then(clientv3.OpPut("/fencing-data", "3")).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Succeeded {
+		t.Fatal("expected the guard of the stale token to fail")
+	}
+	gresp, err := cli.Get(context.TODO(), "/fencing-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gresp.Kvs[0].Value) != "2" {
+		t.Fatalf("expected the value of the current holder, got %q", gresp.Kvs[0].Value)
+	}
+}
+
+func TestElectionFencingToken(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var tokens []concurrency.FencingToken
+	for i := 0; i < 2; i++ {
+		s, err := concurrency.NewSession(cli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := concurrency.NewElection(s, "/fencing-election")
+		if err = e.Campaign(context.TODO(), "leader"); err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, e.FencingToken())
+		if err = e.Resign(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		s.Close()
+	}
+	if tokens[1] <= tokens[0] {
+		t.Fatalf("expected increasing tokens, got %v", tokens)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func newRWMutexes(t *testing.T, cli *clientv3.Client, pfx string, n int, opts ...concurrency.RWMutexOption) []*concurrency.RWMutex {
+	var rwms []*concurrency.RWMutex
+	for i := 0; i < n; i++ {
+		s, err := concurrency.NewSession(cli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		rwms = append(rwms, concurrency.NewRWMutex(s, pfx, opts...))
+	}
+	return rwms
+}
+
+func lockAsync(f func(context.Context) error) <-chan error {
+	errc := make(chan error, 1)
+	go func() { errc <- f(context.TODO()) }()
+	return errc
+}
+
+func expectLocked(t *testing.T, errc <-chan error) {
+	t.Helper()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the lock")
+	}
+}
+
+func expectWaiting(t *testing.T, errc <-chan error) {
+	t.Helper()
+	select {
+	case err := <-errc:
+		t.Fatalf("expected to wait for the lock, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestRWMutexReadersShareLock(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	rwms := newRWMutexes(t, cli, "/rwmutex-share", 3)
+	for _, rwm := range rwms[:2] {
+		if err = rwm.RLock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = rwms[2].TryLock(context.TODO()); err != concurrency.ErrLocked {
+		t.Fatalf("expected %v, got %v", concurrency.ErrLocked, err)
+	}
+	wlock := lockAsync(rwms[2].Lock)
+	expectWaiting(t, wlock)
+	for _, rwm := range rwms[:2] {
+		if err = rwm.RUnlock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectLocked(t, wlock)
+	if err = rwms[0].TryRLock(context.TODO()); err != concurrency.ErrLocked {
+		t.Fatalf("expected %v, got %v", concurrency.ErrLocked, err)
+	}
+}
+
+func TestRWMutexFairness(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// a reader holds the lock, a writer waits for it, then another reader
+	// asks for it.
+	tests := []struct {
+		fairness concurrency.RWMutexFairness
+		// readerFirst is set if the second reader gets the lock before the writer.
+		readerFirst bool
+	}{
+		{concurrency.RWMutexFIFO, false},
+		{concurrency.RWMutexReaderPreference, true},
+		{concurrency.RWMutexWriterPreference, false},
+	}
+	for i, tt := range tests {
+		rwms := newRWMutexes(t, cli, fmt.Sprintf("/rwmutex-fairness-%d", i), 3, concurrency.WithFairness(tt.fairness))
+		if err = rwms[0].RLock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		wlock := lockAsync(rwms[1].Lock)
+		expectWaiting(t, wlock)
+		rlock := lockAsync(rwms[2].RLock)
+		if tt.readerFirst {
+			expectLocked(t, rlock)
+			if err = rwms[0].RUnlock(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			expectWaiting(t, wlock)
+			if err = rwms[2].RUnlock(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			expectLocked(t, wlock)
+			continue
+		}
+		expectWaiting(t, rlock)
+		if err = rwms[0].RUnlock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		expectLocked(t, wlock)
+		expectWaiting(t, rlock)
+		if err = rwms[1].Unlock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		expectLocked(t, rlock)
+	}
+}
+
+func TestRWMutexSessionExpired(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s1, err := concurrency.NewSession(cli, concurrency.WithTTL(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwm1 := concurrency.NewRWMutex(s1, "/rwmutex-expired")
+	if err = rwm1.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	rwm2 := newRWMutexes(t, cli, "/rwmutex-expired", 1)[0]
+	rlock := lockAsync(rwm2.RLock)
+	expectWaiting(t, rlock)
+
+	// the lock of a crashed client is released once its session expires
+	s1.Orphan()
+	select {
+	case err = <-rlock:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the expired lock to be released")
+	}
+}
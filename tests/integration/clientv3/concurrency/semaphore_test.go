@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestSemaphore(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var sems []*concurrency.Semaphore
+	for i := 0; i < 4; i++ {
+		s, err := concurrency.NewSession(cli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		sems = append(sems, concurrency.NewSemaphore(s, "/semaphore", 2))
+	}
+	for _, sem := range sems[:2] {
+		if err = sem.Acquire(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = sems[2].TryAcquire(context.TODO()); err != concurrency.ErrNoPermit {
+		t.Fatalf("expected %v, got %v", concurrency.ErrNoPermit, err)
+	}
+
+	// the canceled waiter leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err = sems[3].Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	acquired := lockAsync(sems[2].Acquire)
+	expectWaiting(t, acquired)
+	if err = sems[0].Release(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	expectLocked(t, acquired)
+	if err = sems[3].TryAcquire(context.TODO()); err != concurrency.ErrNoPermit {
+		t.Fatalf("expected %v, got %v", concurrency.ErrNoPermit, err)
+	}
+}
+
+func TestSemaphoreSessionExpired(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s1, err := concurrency.NewSession(cli, concurrency.WithTTL(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = concurrency.NewSemaphore(s1, "/semaphore-expired", 1).Acquire(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	s2, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	acquired := lockAsync(concurrency.NewSemaphore(s2, "/semaphore-expired", 1).Acquire)
+	expectWaiting(t, acquired)
+
+	// the permit of a crashed client is reclaimed once its session expires
+	s1.Orphan()
+	select {
+	case err = <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the expired permit to be reclaimed")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
@@ -107,6 +108,66 @@ func TestKVPutWithLease(t *testing.T) {
 	}
 }
 
+// TestKVPutWithTTL ensures that keys put with a ttl expire without the
+// client granting a lease.
+func TestKVPutWithTTL(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	if _, err := kv.Put(ctx, "foo", "bar", clientv3.WithTTL(2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Txn(ctx).Then(clientv3.OpPut("baz", "qux", clientv3.WithTTL(2))).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Put(ctx, "neg", "bar", clientv3.WithTTL(-1)); err != rpctypes.ErrLeaseTTLNegative {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrLeaseTTLNegative, err)
+	}
+	if _, err := kv.Txn(ctx).Then(clientv3.OpPut("neg", "qux", clientv3.WithTTL(-1))).Commit(); err != rpctypes.ErrLeaseTTLNegative {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrLeaseTTLNegative, err)
+	}
+	resp, err := kv.Get(ctx, "", clientv3.WithFromKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(resp.Kvs))
+	}
+	for _, ev := range resp.Kvs {
+		if ev.Lease == 0 {
+			t.Fatalf("expected %q to be attached to a lease", ev.Key)
+		}
+		ttl, err := kv.TimeToLive(ctx, clientv3.LeaseID(ev.Lease))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ttl.TTL < 1 || ttl.TTL > 3 {
+			t.Errorf("expected lease of %q to expire in 2s, got %ds", ev.Key, ttl.TTL)
+		}
+	}
+
+	// revoking the lease of a key does not prevent putting new keys with a ttl
+	if _, err = kv.Revoke(ctx, clientv3.LeaseID(resp.Kvs[0].Lease)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Put(ctx, "foo", "bar", clientv3.WithTTL(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(4 * time.Second)
+	if resp, err = kv.Get(ctx, "", clientv3.WithFromKey()); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 0 {
+		t.Errorf("expected keys to expire, got %+v", resp.Kvs)
+	}
+}
+
 // TestKVPutWithIgnoreValue ensures that Put with WithIgnoreValue does not clobber the old value.
 func TestKVPutWithIgnoreValue(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -897,3 +958,307 @@ func TestBalancerSupportLearner(t *testing.T) {
 		t.Errorf("expect no error (balancer should retry when request to learner fails), got error: %v", err)
 	}
 }
+
+// TestKVWaitForRevision ensures serializable reads of a lagging member
+// observe a revision once the client waited for it.
+func TestKVWaitForRevision(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, UseBridge: true})
+	defer clus.Terminate(t)
+
+	lead := clus.WaitLeader(t)
+	stale := clus.Members[(lead+1)%3]
+	stale.InjectPartition(t, clus.Members[lead], clus.Members[(lead+2)%3])
+	presp, err := clus.Client(lead).Put(context.TODO(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := clus.Client((lead + 1) % 3)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	err = cli.WaitForRevision(ctx, presp.Header.Revision)
+	cancel()
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected the partitioned member not to reach revision %d, got %v", presp.Header.Revision, err)
+	}
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		stale.RecoverPartition(t, clus.Members[lead], clus.Members[(lead+2)%3])
+	}()
+	ctx, cancel = context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	if err = cli.WaitForRevision(ctx, presp.Header.Revision); err != nil {
+		t.Fatal(err)
+	}
+	gresp, err := cli.Get(ctx, "foo", clientv3.WithSerializable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 1 || string(gresp.Kvs[0].Value) != "bar" {
+		t.Fatalf("expected the serializable read to observe the put, got %v", gresp.Kvs)
+	}
+}
+
+func TestKVGetStream(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	// more keys than the server reads at once
+	const keys = 2500
+	for i := 0; i < keys; i += 100 {
+		var ops []clientv3.Op
+		for j := i; j < i+100; j++ {
+			ops = append(ops, clientv3.OpPut(fmt.Sprintf("key/%04d", j), strconv.Itoa(j)))
+		}
+		if _, err := kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// changes after the first revision of the stream are not observed
+	resp, err := kv.Put(ctx, "key/0000", "changed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := resp.Header.Revision - 1
+
+	tests := []struct {
+		name string
+		opts []clientv3.OpOption
+
+		wantKeys  []int
+		wantCount int64
+		wantMore  bool
+	}{
+		{
+			name:      "Prefix",
+			opts:      []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithRev(rev)},
+			wantKeys:  keyRange(0, keys),
+			wantCount: keys,
+		},
+		{
+			name:      "Limit",
+			opts:      []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithRev(rev), clientv3.WithLimit(1500)},
+			wantKeys:  keyRange(0, 1500),
+			wantCount: keys,
+			wantMore:  true,
+		},
+		{
+			name:      "Range",
+			opts:      []clientv3.OpOption{clientv3.WithRange("key/2000"), clientv3.WithRev(rev), clientv3.WithSerializable()},
+			wantKeys:  keyRange(0, 2000),
+			wantCount: 2000,
+		},
+		{
+			name:      "SortDescend",
+			opts:      []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithRev(rev), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(10)},
+			wantKeys:  reverse(keyRange(keys-10, keys)),
+			wantCount: keys,
+			wantMore:  true,
+		},
+		{
+			name:      "CountOnly",
+			opts:      []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithRev(rev), clientv3.WithCountOnly()},
+			wantCount: keys,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := kv.GetStream(ctx, "key/", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer it.Close()
+			var gotKeys []int
+			for it.Next() {
+				kv := it.KV()
+				if string(kv.Value) != strconv.Itoa(len(gotKeys)+tt.wantKeys[0]) && tt.name != "SortDescend" {
+					t.Fatalf("expected the value of %q at revision %d, got %q", kv.Key, rev, kv.Value)
+				}
+				i, _ := strconv.Atoi(strings.TrimPrefix(string(kv.Key), "key/"))
+				gotKeys = append(gotKeys, i)
+			}
+			if err = it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotKeys, tt.wantKeys) {
+				t.Errorf("expected %d keys from %v, got %d keys from %v", len(tt.wantKeys), tt.wantKeys[:1], len(gotKeys), gotKeys[:1])
+			}
+			if it.Count() != tt.wantCount || it.More() != tt.wantMore {
+				t.Errorf("expected count %d and more %v, got %d and %v", tt.wantCount, tt.wantMore, it.Count(), it.More())
+			}
+			if it.Header().Revision != rev+1 {
+				t.Errorf("expected current revision %d, got %d", rev+1, it.Header().Revision)
+			}
+		})
+	}
+
+	// the keys are read at the revision of the first response
+	it, err := kv.GetStream(ctx, "key/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Next() {
+		t.Fatalf("expected keys, got %v", it.Err())
+	}
+	if _, err = kv.Put(ctx, "key/2499", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	var last *mvccpb.KeyValue
+	for it.Next() {
+		last = it.KV()
+	}
+	if it.Err() != nil || string(last.Value) != "2499" {
+		t.Errorf("expected key/2499 to be read before the change, got %v, %v", last, it.Err())
+	}
+}
+
+// TestKVGetStreamLarge ensures that ranges too large for a single response are
+// received in several.
+func TestKVGetStreamLarge(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, ClientMaxCallRecvMsgSize: 4 * 1024 * 1024})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	// 6MiB, over the 4MiB the client receives
+	value := strings.Repeat("a", 256*1024)
+	for i := 0; i < 24; i++ {
+		if _, err := kv.Put(ctx, fmt.Sprintf("key/%02d", i), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := kv.Get(ctx, "key/", clientv3.WithPrefix()); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected the range to exceed the receive limit, got %v", err)
+	}
+
+	it, err := kv.GetStream(ctx, "key/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	keys, responses := 0, 0
+	for {
+		resp, err := it.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses++
+		keys += len(resp.Kvs)
+	}
+	if keys != 24 || responses < 4 {
+		t.Errorf("expected 24 keys in at least 4 responses, got %d keys in %d responses", keys, responses)
+	}
+}
+
+func keyRange(from, to int) (keys []int) {
+	for i := from; i < to; i++ {
+		keys = append(keys, i)
+	}
+	return keys
+}
+
+func reverse(keys []int) []int {
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+	return keys
+}
+
+// TestKVHedgedGet ensures that the linearizable reads of a client with
+// HedgeDelay are served by another member when theirs does not respond.
+func TestKVHedgedGet(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, UseBridge: true})
+	defer clus.Terminate(t)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:  []string{clus.Members[0].GRPCURL(), clus.Members[1].GRPCURL(), clus.Members[2].GRPCURL()},
+		HedgeDelay: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	clus.Members[0].Bridge().Blackhole()
+	defer clus.Members[0].Bridge().Unblackhole()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+		resp, err := cli.Get(ctx, "foo")
+		cancel()
+		if err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+			t.Fatalf("#%d: expected foo=bar, got %+v", i, resp.Kvs)
+		}
+		if resp.Header.MemberId == uint64(clus.Members[0].ID()) {
+			t.Fatalf("#%d: expected the response of another member than the blackholed one", i)
+		}
+	}
+}
+
+// TestKVCircuitBreaker ensures that the client with a CircuitBreaker stops
+// sending requests to a member that keeps timing out.
+func TestKVCircuitBreaker(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, UseBridge: true})
+	defer clus.Terminate(t)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:      []string{clus.Members[0].GRPCURL(), clus.Members[1].GRPCURL(), clus.Members[2].GRPCURL()},
+		CircuitBreaker: &clientv3.CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	clus.Members[0].Bridge().Blackhole()
+	defer clus.Members[0].Bridge().Unblackhole()
+
+	get := func() error {
+		ctx, cancel := context.WithTimeout(context.TODO(), 500*time.Millisecond)
+		defer cancel()
+		_, err := cli.Get(ctx, "foo")
+		return err
+	}
+	// the requests sent to the blackholed member time out, until it is ejected
+	failures := 0
+	for i := 0; i < 6; i++ {
+		if get() != nil {
+			failures++
+		}
+	}
+	if failures > 1 {
+		t.Fatalf("expected at most 1 request to time out before the member is ejected, got %d", failures)
+	}
+	for i := 0; i < 10; i++ {
+		if err = get(); err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -585,6 +586,42 @@ func TestLeaseTimeToLive(t *testing.T) {
 	}
 }
 
+// TestLeaseLabels ensures the labels attached to a lease when granted are
+// returned by TimeToLive, forwarded to the leader or not, and Leases.
+func TestLeaseLabels(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	labels := map[string]string{"owner": "scheduler", "instance": "a"}
+	resp, err := clus.RandClient().Grant(context.Background(), 10, clientv3.WithLabels(labels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clus.RandClient().Grant(context.Background(), 10, clientv3.WithLabels(map[string]string{"owner": strings.Repeat("a", 1024)})); err != rpctypes.ErrLeaseLabelsTooLarge {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrLeaseLabelsTooLarge, err)
+	}
+
+	for i := range clus.Members {
+		lresp, err := clus.Client(i).TimeToLive(context.Background(), resp.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lresp.Labels, labels) {
+			t.Errorf("expected labels %v through member %d, got %v", labels, i, lresp.Labels)
+		}
+	}
+
+	lsresp, err := clus.RandClient().Leases(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lsresp.Leases) != 1 || !reflect.DeepEqual(lsresp.Leases[0].Labels, labels) {
+		t.Errorf("expected the lease with labels %v, got %+v", labels, lsresp.Leases)
+	}
+}
+
 func TestLeaseTimeToLiveLeaseNotFound(t *testing.T) {
 	integration2.BeforeTest(t)
 
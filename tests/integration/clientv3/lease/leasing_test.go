@@ -28,6 +28,7 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
 	"go.etcd.io/etcd/client/v3/leasing"
+	"go.etcd.io/etcd/client/v3/namespace"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 )
 
@@ -2022,6 +2023,98 @@ func TestLeasingSessionExpireCancel(t *testing.T) {
 	}
 }
 
+// TestLeasingInvalidatePrefix checks that invalidating a prefix releases the
+// leases of its keys held by the other leasing clients.
+func TestLeasingInvalidatePrefix(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.Client(0)
+
+	lkv, closeLKV, err := leasing.NewKV(cli, "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+
+	lkv2, closeLKV2, err := leasing.NewKV(cli, "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV2()
+
+	for _, k := range []string{"abc/a", "abc/b", "xyz"} {
+		if _, err = cli.Put(context.TODO(), k, "1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = lkv.Get(context.TODO(), k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := cli.Get(context.TODO(), "foo/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 3 {
+		t.Fatalf("expected 3 leasing keys, got %d", resp.Count)
+	}
+
+	if err = leasing.InvalidatePrefix(context.TODO(), lkv2, "abc/"); err != nil {
+		t.Fatal(err)
+	}
+	if err = waitForLeasingExpire(cli, "foo/abc/"); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = cli.Get(context.TODO(), "foo/xyz"); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatalf("expected the lease of xyz to be kept")
+	}
+
+	// the key is not served from the cache once released
+	if _, err = cli.Put(context.TODO(), "abc/a", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = lkv.Get(context.TODO(), "abc/a"); err != nil {
+		t.Fatal(err)
+	}
+	if v := string(resp.Kvs[0].Value); v != "2" {
+		t.Fatalf("expected %q, got %q", "2", v)
+	}
+}
+
+// TestLeasingNamespaceInvalidatePrefix checks that invalidating a prefix of a
+// namespace KV invalidates the prefix in the namespace.
+func TestLeasingNamespaceInvalidatePrefix(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.Client(0)
+
+	lkv, closeLKV, err := leasing.NewKV(cli, "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+	nskv := namespace.NewKV(lkv, "ns/")
+
+	if _, err = nskv.Get(context.TODO(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.Get(context.TODO(), "foo/ns/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatalf("expected the lease of ns/abc to be acquired")
+	}
+
+	if err = leasing.InvalidatePrefix(context.TODO(), nskv, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = cli.Get(context.TODO(), "foo/ns/abc"); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 0 {
+		t.Fatalf("expected the lease of ns/abc to be released")
+	}
+}
+
 func waitForLeasingExpire(kv clientv3.KV, lkey string) error {
 	for {
 		time.Sleep(1 * time.Second)
@@ -17,20 +17,29 @@ package clientv3test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	bolt "go.etcd.io/bbolt"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"go.uber.org/zap/zaptest"
 	"google.golang.org/grpc"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
@@ -100,6 +109,63 @@ func TestMaintenanceMoveLeader(t *testing.T) {
 
 // TestMaintenanceSnapshotCancel ensures that context cancel
 // before snapshot reading returns corresponding context errors.
+func TestMaintenanceDefragmentWithProgress(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	for i := 0; i < 100; i++ {
+		if _, err := cli.Put(context.Background(), fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the member keeps serving writes while defragmenting
+	stopc, donec := make(chan struct{}), make(chan struct{})
+	written := 0
+	go func() {
+		defer close(donec)
+		for {
+			select {
+			case <-stopc:
+				return
+			default:
+			}
+			if _, err := cli.Put(context.Background(), fmt.Sprintf("new%d", written), "bar"); err != nil {
+				t.Error(err)
+				return
+			}
+			written++
+		}
+	}()
+
+	var progress []*clientv3.DefragmentStreamResponse
+	resp, err := cli.DefragmentWithProgress(context.Background(), clus.Members[0].GRPCURL(), func(p *clientv3.DefragmentStreamResponse) {
+		progress = append(progress, p)
+	})
+	close(stopc)
+	<-donec
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Done || resp.Header == nil || resp.TotalKeys == 0 || resp.CopiedKeys != resp.TotalKeys {
+		t.Errorf("expected the last response to report all keys copied, got %+v", resp)
+	}
+	if len(progress) == 0 || progress[0].CopiedKeys != 0 || progress[0].TotalKeys != resp.TotalKeys {
+		t.Errorf("expected progress reports starting with no keys copied, got %+v", progress)
+	}
+
+	gresp, err := cli.Get(context.Background(), "new", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gresp.Count != int64(written) {
+		t.Errorf("expected the %d keys written while defragmenting, got %d", written, gresp.Count)
+	}
+}
+
 func TestMaintenanceSnapshotCancel(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -269,6 +335,366 @@ func TestMaintenanceSnapshotWithVersionVersion(t *testing.T) {
 	}
 }
 
+func TestMaintenanceSnapshotWithCompression(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	// write more than one compressed chunk worth of data
+	val := strings.Repeat("a", 64*1024)
+	for i := 0; i < 40; i++ {
+		if _, err := clus.RandClient().Put(context.Background(), fmt.Sprintf("%d", i), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readSnapshot := func(compression string) []byte {
+		resp, err := clus.RandClient().SnapshotWithCompression(context.Background(), compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Snapshot.Close()
+		b, err := io.ReadAll(resp.Snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the trailing sha256 digest is computed over the uncompressed snapshot
+		db, digest := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+		if sum := sha256.Sum256(db); !bytes.Equal(sum[:], digest) {
+			t.Fatalf("snapshot with compression %q has a mismatching sha256 digest", compression)
+		}
+		return db
+	}
+	// the backend commits before each snapshot, which may change its pages,
+	// so the key-value pairs of the snapshots are compared instead of bytes
+	readKeyBucket := func(compression string, db []byte) map[string]string {
+		path := filepath.Join(t.TempDir(), "snapshot.db")
+		if err := os.WriteFile(path, db, 0600); err != nil {
+			t.Fatal(err)
+		}
+		bdb, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true})
+		if err != nil {
+			t.Fatalf("failed to open snapshot with compression %q: %v", compression, err)
+		}
+		defer bdb.Close()
+		kvs := make(map[string]string)
+		err = bdb.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte("key"))
+			if b == nil {
+				return fmt.Errorf("no key bucket")
+			}
+			return b.ForEach(func(k, v []byte) error {
+				kvs[string(k)] = string(v)
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatalf("failed to read snapshot with compression %q: %v", compression, err)
+		}
+		return kvs
+	}
+	want := readKeyBucket("", readSnapshot(""))
+	if len(want) < 40 {
+		t.Fatalf("expected at least 40 revisions in the uncompressed snapshot, got %d", len(want))
+	}
+	for _, compression := range []string{clientv3.SnapshotCompressionZstd, clientv3.SnapshotCompressionGzip, "unknown"} {
+		if got := readKeyBucket(compression, readSnapshot(compression)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("snapshot with compression %q has %d revisions not matching the %d of the uncompressed snapshot", compression, len(got), len(want))
+		}
+	}
+}
+
+func TestMaintenanceSnapshotResumable(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	// write more than the stream can buffer, even compressed, so that the
+	// stream breaks before the server sent the whole snapshot
+	val := make([]byte, 64*1024)
+	for i := 0; i < 512; i++ {
+		rand.Read(val)
+		if _, err := clus.Client(0).Put(context.Background(), fmt.Sprintf("%d", i), string(val)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := clus.Client(0).SnapshotResumable(ctx, clientv3.SnapshotCompressionGzip, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID == 0 {
+		t.Fatal("expected a resumable snapshot")
+	}
+	head := make([]byte, 100*1024)
+	if _, err = io.ReadFull(resp.Snapshot, head); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	resp.Snapshot.Close()
+
+	// the server keeps the snapshot once it notices the stream broke
+	var rresp *clientv3.SnapshotResponse
+	for i := 0; i < 50; i++ {
+		rresp, err = clus.Client(0).SnapshotResumable(context.Background(), clientv3.SnapshotCompressionGzip, resp.ID, int64(len(head)))
+		if err != rpctypes.ErrSnapshotNotFound {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rresp.Snapshot.Close()
+	tail, err := io.ReadAll(rresp.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := append(head, tail...)
+	db, digest := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	if sum := sha256.Sum256(db); !bytes.Equal(sum[:], digest) {
+		t.Fatal("resumed snapshot has a mismatching sha256 digest")
+	}
+
+	// a snapshot is not kept once it is sent
+	if _, err = clus.Client(0).SnapshotResumable(context.Background(), "", resp.ID, 0); err != rpctypes.ErrSnapshotNotFound {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrSnapshotNotFound, err)
+	}
+}
+
+func TestMaintenanceBackup(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.Client(0)
+	ctx := context.Background()
+
+	presp, err := cli.Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := presp.Header.Revision
+	if _, err = cli.Put(ctx, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	dresp, err := cli.Delete(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var evs []*mvccpb.Event
+	err = cli.Backup(ctx, since, func(resp *clientv3.BackupResponse) error {
+		if resp.SinceRevision != since {
+			t.Errorf("expected since revision %d, got %d", since, resp.SinceRevision)
+		}
+		if resp.Header.Revision != dresp.Header.Revision {
+			t.Errorf("expected revision %d, got %d", dresp.Header.Revision, resp.Header.Revision)
+		}
+		evs = append(evs, resp.Events...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 {
+		t.Fatalf("expected 2 events, got %v", evs)
+	}
+	if evs[0].Type != mvccpb.PUT || string(evs[0].Kv.Value) != "baz" || evs[0].Kv.ModRevision != since+1 {
+		t.Errorf("unexpected put event %v", evs[0])
+	}
+	// maintenance requests are not namespaced by the proxy
+	if evs[1].Type != mvccpb.DELETE || !strings.HasSuffix(string(evs[1].Kv.Key), "foo") || evs[1].Kv.ModRevision != dresp.Header.Revision {
+		t.Errorf("unexpected delete event %v", evs[1])
+	}
+
+	if _, err = cli.Compact(ctx, dresp.Header.Revision); err != nil {
+		t.Fatal(err)
+	}
+	err = cli.Backup(ctx, since, func(*clientv3.BackupResponse) error { return nil })
+	if err != rpctypes.ErrCompacted {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrCompacted, err)
+	}
+}
+
+func TestMaintenanceWatchConsumers(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the watched keys and adds its own watches")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	busy := cli.Watch(ctx, "/registry/pods/", clientv3.WithPrefix())
+	quiet := cli.Watch(ctx, "/registry/nodes/a")
+	receive := func(wch clientv3.WatchChan, n int) {
+		for n > 0 {
+			select {
+			case resp := <-wch:
+				n -= len(resp.Events)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %d events", n)
+			}
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err := cli.Put(context.Background(), fmt.Sprintf("/registry/pods/%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Put(context.Background(), "/registry/nodes/a", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	receive(busy, 20)
+	receive(quiet, 1)
+
+	resp, err := cli.WatchConsumers(context.Background(), clus.Members[0].GRPCURL(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Consumers) != 2 {
+		t.Fatalf("expected 2 watch consumers, got %+v", resp.Consumers)
+	}
+	top := resp.Consumers[0]
+	if string(top.Key) != "/registry/pods/" || string(top.RangeEnd) != "/registry/pods0" || top.EventsSent != 20 {
+		t.Errorf("expected the prefix watch to be the top consumer, got %+v", top)
+	}
+	if top.EventsPerSecond <= resp.Consumers[1].EventsPerSecond {
+		t.Errorf("expected the prefix watch to receive more events per second, got %+v", resp.Consumers)
+	}
+	if string(resp.Consumers[1].Key) != "/registry/nodes/a" || resp.Consumers[1].EventsSent != 1 {
+		t.Errorf("unexpected watch consumer %+v", resp.Consumers[1])
+	}
+
+	resp, err = cli.WatchConsumers(context.Background(), clus.Members[0].GRPCURL(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Consumers) != 1 {
+		t.Errorf("expected the limit to be applied, got %+v", resp.Consumers)
+	}
+}
+
+func TestMaintenanceConfigAdvice(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, SnapshotCount: 1000000})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cli.Put(context.Background(), fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// wait for the watch to be registered
+	<-cli.Watch(ctx, "foo", clientv3.WithCreatedNotify())
+
+	resp, err := cli.ConfigAdvice(context.Background(), clus.Members[0].GRPCURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Keys != 3 || resp.DbSize == 0 {
+		t.Errorf("expected the stats of the member with 3 keys, got %+v", resp)
+	}
+	// grpc-proxy adds its own watches
+	if resp.Watches != 1 && !(integration2.ThroughProxy && resp.Watches > 1) {
+		t.Errorf("expected 1 watch, got %d", resp.Watches)
+	}
+	var advised bool
+	for _, rec := range resp.Recommendations {
+		if rec.Parameter == "snapshot-count" {
+			advised = rec.CurrentValue == "1000000" && rec.RecommendedValue == "100000"
+		}
+	}
+	if !advised {
+		t.Errorf("expected the snapshot count to be lowered, got %+v", resp.Recommendations)
+	}
+}
+
+func TestMaintenanceBulkImport(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	if _, err := cli.Put(context.Background(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	batches := func(keys ...[]string) func() ([]*mvccpb.KeyValue, error) {
+		return func() ([]*mvccpb.KeyValue, error) {
+			if len(keys) == 0 {
+				return nil, io.EOF
+			}
+			var kvs []*mvccpb.KeyValue
+			for _, k := range keys[0] {
+				kvs = append(kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(k + "-imported")})
+			}
+			keys = keys[1:]
+			return kvs, nil
+		}
+	}
+
+	// unsorted keys fail the whole import
+	if _, err := cli.BulkImport(context.Background(), 0, batches([]string{"a", "b"}, []string{"b"})); err != rpctypes.ErrImportKeyOrder {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrImportKeyOrder, err)
+	}
+	if r, err := clus.Members[0].Server.KV().Range(context.Background(), []byte("a"), []byte("c"), mvcc.RangeOptions{}); err != nil || len(r.KVs) != 0 {
+		t.Fatalf("expected the failed import to be rolled back, got %+v (%v)", r.KVs, err)
+	}
+
+	resp, err := cli.BulkImport(context.Background(), 10, batches([]string{"a", "b"}, []string{"c", "foo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Revision != 10 || resp.Count != 4 {
+		t.Errorf("expected 4 keys imported at revision 10, got %+v", resp)
+	}
+	r, err := clus.Members[0].Server.KV().Range(context.Background(), []byte("a"), []byte("g"), mvcc.RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, kv := range r.KVs {
+		got = append(got, fmt.Sprintf("%s=%s@%d", kv.Key, kv.Value, kv.ModRevision))
+	}
+	if want := []string{"a=a-imported@10", "b=b-imported@10", "c=c-imported@10", "foo=foo-imported@10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected imported keys %v, got %v", want, got)
+	}
+
+	// writes are accepted again after the import
+	presp, err := cli.Put(context.Background(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presp.Header.Revision != 11 {
+		t.Errorf("expected put at revision 11 after the import, got %d", presp.Header.Revision)
+	}
+}
+
+func TestMaintenanceBulkImportNotSingleMember(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	next := func() ([]*mvccpb.KeyValue, error) { return nil, io.EOF }
+	if _, err := clus.RandClient().BulkImport(context.Background(), 0, next); err != rpctypes.ErrImportNotSingleMember {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrImportNotSingleMember, err)
+	}
+}
+
 func TestMaintenanceStatus(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -317,3 +743,601 @@ func TestMaintenanceStatus(t *testing.T) {
 		t.Fatal("no leader found")
 	}
 }
+
+func TestMaintenanceStatusCompaction(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ep := clus.Members[0].GRPCURL()
+	resp, err := cli.Status(context.TODO(), ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.LastCompactionRevision != 0 {
+		t.Fatalf("expected no compaction, got revision %d", resp.LastCompactionRevision)
+	}
+
+	var rev int64
+	for i := 0; i < 5; i++ {
+		presp, perr := cli.Put(context.TODO(), "foo", fmt.Sprintf("bar%d", i))
+		if perr != nil {
+			t.Fatal(perr)
+		}
+		rev = presp.Header.Revision
+	}
+	if _, err = cli.Compact(context.TODO(), rev, clientv3.WithCompactPhysical()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = cli.Status(context.TODO(), ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.LastCompactionRevision != rev {
+		t.Errorf("expected last compaction revision %d, got %d", rev, resp.LastCompactionRevision)
+	}
+	if resp.LastCompactionDurationMs < 0 {
+		t.Errorf("expected non-negative compaction duration, got %d", resp.LastCompactionDurationMs)
+	}
+	if resp.IsDefragmenting {
+		t.Error("expected no defragmentation in progress")
+	}
+	if resp.PendingProposals != 0 {
+		t.Errorf("expected no pending proposals, got %d", resp.PendingProposals)
+	}
+}
+
+func TestClusterStatus(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	lead := clus.WaitLeader(t)
+
+	eps := make([]string, 3)
+	for i := 0; i < 3; i++ {
+		eps[i] = clus.Members[i].GRPCURL()
+	}
+	// the dial timeout bounds the status request to the stopped member
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: eps, DialTimeout: time.Second, DialOptions: []grpc.DialOption{grpc.WithBlock()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	if _, err = cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := clientv3.ClusterStatus(context.TODO(), cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Endpoints) != 3 {
+		t.Fatalf("expected status of 3 endpoints, got %d", len(r.Endpoints))
+	}
+	for i, es := range r.Endpoints {
+		if es.Endpoint != eps[i] || es.Err != nil {
+			t.Errorf("#%d: expected status of %s, got %+v", i, eps[i], es)
+		}
+	}
+	if !r.LeaderAgreed() || r.Leader != uint64(clus.Members[lead].ID()) {
+		t.Errorf("expected leader %s, got %x", clus.Members[lead].ID(), r.Leader)
+	}
+	if r.MaxRevision < 2 || r.RevisionSpread() < 0 {
+		t.Errorf("unexpected revisions [%d, %d]", r.MinRevision, r.MaxRevision)
+	}
+	if r.MinDbSize <= 0 || r.MaxDbSize < r.MinDbSize {
+		t.Errorf("unexpected db sizes [%d, %d]", r.MinDbSize, r.MaxDbSize)
+	}
+	if len(r.Versions) != 1 || r.Versions[0] != version.Version {
+		t.Errorf("expected versions [%s], got %v", version.Version, r.Versions)
+	}
+
+	// an unreachable member is reported without failing the whole status
+	follower := (lead + 1) % 3
+	clus.Members[follower].Stop(t)
+	r, err = clientv3.ClusterStatus(context.TODO(), cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, es := range r.Endpoints {
+		if (es.Err != nil) != (i == follower) {
+			t.Errorf("#%d: unexpected status error %v", i, es.Err)
+		}
+	}
+	if !r.LeaderAgreed() {
+		t.Error("expected the remaining members to agree on the leader")
+	}
+}
+
+func TestMaintenanceKeyHistogram(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys, but not the maintenance requests")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+
+	kvs := map[string]string{"/a/x": "1", "/a/y": "22", "/b/x": "333", "/c": "4444"}
+	for k, v := range kvs {
+		if _, err := cli.Put(context.Background(), k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := cli.KeyHistogram(context.Background(), clus.Members[0].GRPCURL(), "/", "", 2, clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.KeyHistogramBucket{
+		{Prefix: []byte("/a/"), Count: 2, ValueBytes: 3},
+		{Prefix: []byte("/b/"), Count: 1, ValueBytes: 3},
+		{Prefix: []byte("/c"), Count: 1, ValueBytes: 4},
+	}
+	if !reflect.DeepEqual(resp.Buckets, want) {
+		t.Errorf("expected buckets %+v, got %+v", want, resp.Buckets)
+	}
+	if resp.Count != 4 || resp.ValueBytes != 10 {
+		t.Errorf("expected 4 keys of 10 bytes, got %d keys of %d bytes", resp.Count, resp.ValueBytes)
+	}
+
+	resp, err = cli.KeyHistogram(context.Background(), clus.Members[0].GRPCURL(), "", "", 2, clientv3.WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Buckets) != 1 || string(resp.Buckets[0].Prefix) != "/a/" || resp.Count != 4 {
+		t.Errorf("expected the bucket of /a/ out of 4 keys, got %+v", resp)
+	}
+}
+
+func TestMaintenanceReadOnly(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	if _, err := clus.Client(0).Put(context.Background(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := clus.Client(0).ReadOnly(context.Background(), clientv3.ReadOnlyEnable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.ReadOnly {
+		t.Fatal("expected the cluster in read-only mode")
+	}
+
+	cli := clus.Client(1)
+	if _, err = cli.Put(context.Background(), "foo", "baz"); err != rpctypes.ErrReadOnly {
+		t.Errorf("expected %v on put, got %v", rpctypes.ErrReadOnly, err)
+	}
+	if _, err = cli.Delete(context.Background(), "foo"); err != rpctypes.ErrReadOnly {
+		t.Errorf("expected %v on delete, got %v", rpctypes.ErrReadOnly, err)
+	}
+	if _, err = cli.Grant(context.Background(), 10); err != rpctypes.ErrReadOnly {
+		t.Errorf("expected %v on lease grant, got %v", rpctypes.ErrReadOnly, err)
+	}
+	if _, err = cli.UserAdd(context.Background(), "user", "pass"); err != rpctypes.ErrReadOnly {
+		t.Errorf("expected %v on user add, got %v", rpctypes.ErrReadOnly, err)
+	}
+	gresp, err := cli.Get(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 1 || string(gresp.Kvs[0].Value) != "bar" {
+		t.Errorf("expected foo=bar, got %+v", gresp.Kvs)
+	}
+	if _, err = cli.Txn(context.Background()).Then(clientv3.OpGet("foo")).Commit(); err != nil {
+		t.Errorf("expected a read-only txn to succeed, got %v", err)
+	}
+
+	// the mode survives restarts
+	clus.Members[2].Stop(t)
+	clus.Members[2].Restart(t)
+	clus.WaitMembersForLeader(t, clus.Members)
+	resp, err = clus.Client(2).ReadOnly(context.Background(), clientv3.ReadOnlyGet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.ReadOnly {
+		t.Fatal("expected the cluster in read-only mode after restart")
+	}
+
+	resp, err = clus.Client(2).ReadOnly(context.Background(), clientv3.ReadOnlyDisable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReadOnly {
+		t.Fatal("expected the cluster out of read-only mode")
+	}
+	if _, err = cli.Put(context.Background(), "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaintenanceNamespace(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys, but not the maintenance requests")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	cli := clus.Client(0)
+	ctx := context.Background()
+
+	if _, err := cli.Put(ctx, "/a/x", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.NamespacePut(ctx, clientv3.Namespace{Prefix: []byte("/a/"), QuotaKeys: 3, MaxLeaseTtl: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.NamespacePut(ctx, clientv3.Namespace{Prefix: []byte("/a/b/"), QuotaBytes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.NamespacePut(ctx, clientv3.Namespace{}); err != rpctypes.ErrNamespaceEmpty {
+		t.Errorf("expected %v, got %v", rpctypes.ErrNamespaceEmpty, err)
+	}
+
+	if _, err := cli.Put(ctx, "/a/b/x", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Put(ctx, "/a/b/x", "12345"); err != rpctypes.ErrNamespaceQuotaExceeded {
+		t.Errorf("expected %v over the byte quota, got %v", rpctypes.ErrNamespaceQuotaExceeded, err)
+	}
+	_, err := cli.Txn(ctx).Then(clientv3.OpPut("/a/y", "1"), clientv3.OpPut("/a/z", "1")).Commit()
+	if err != rpctypes.ErrNamespaceQuotaExceeded {
+		t.Errorf("expected %v over the key quota, got %v", rpctypes.ErrNamespaceQuotaExceeded, err)
+	}
+	if _, err = cli.Put(ctx, "/b", "no quota outside of the namespaces"); err != nil {
+		t.Fatal(err)
+	}
+	lresp, err := cli.Grant(ctx, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Put(ctx, "/a/y", "1", clientv3.WithLease(lresp.ID)); err != rpctypes.ErrNamespaceLeaseTTLTooLarge {
+		t.Errorf("expected %v, got %v", rpctypes.ErrNamespaceLeaseTTLTooLarge, err)
+	}
+
+	// all the members account for the keys alike, including after a restart
+	clus.Members[2].Stop(t)
+	clus.Members[2].Restart(t)
+	clus.WaitMembersForLeader(t, clus.Members)
+	want := []*pb.NamespaceUsage{
+		{Namespace: &pb.Namespace{Prefix: []byte("/a/"), QuotaKeys: 3, MaxLeaseTtl: 60}, UsedBytes: 15, UsedKeys: 2},
+		{Namespace: &pb.Namespace{Prefix: []byte("/a/b/"), QuotaBytes: 10}, UsedBytes: 10, UsedKeys: 1},
+	}
+	for i := range clus.Members {
+		resp, err := clus.Client(i).NamespaceList(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp.Namespaces, want) {
+			t.Errorf("expected namespaces %+v on member %d, got %+v", want, i, resp.Namespaces)
+		}
+	}
+
+	if _, err = cli.NamespaceDelete(ctx, "/a/b/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.NamespaceDelete(ctx, "/a/b/"); err != rpctypes.ErrNamespaceNotFound {
+		t.Errorf("expected %v, got %v", rpctypes.ErrNamespaceNotFound, err)
+	}
+	if _, err = cli.Put(ctx, "/a/b/x", "12345"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaintenanceIndex(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys, but not the maintenance requests")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	cli := clus.Client(0)
+	ctx := context.Background()
+
+	for key, val := range map[string]string{
+		"/pods/a":   `{"spec":{"node":"n1"}}`,
+		"/pods/b":   `{"spec":{"node":"n2"}}`,
+		"/nodes/n1": `{"spec":{"node":"n1"}}`,
+	} {
+		if _, err := cli.Put(ctx, key, val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.IndexPut(ctx, clientv3.Index{Name: "by-node", Prefix: []byte("/pods/"), Field: "spec.node"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.IndexPut(ctx, clientv3.Index{Name: "by-node", Field: "spec..node"}); err != rpctypes.ErrIndexFieldInvalid {
+		t.Errorf("expected %v, got %v", rpctypes.ErrIndexFieldInvalid, err)
+	}
+	if _, err := cli.IndexRange(ctx, "by-owner", "alice"); err != rpctypes.ErrIndexNotFound {
+		t.Errorf("expected %v, got %v", rpctypes.ErrIndexNotFound, err)
+	}
+
+	// the index is kept up to date by the writes, in the same txn
+	presp, err := cli.Put(ctx, "/pods/c", `{"spec":{"node":"n1"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.Txn(ctx).Then(
+		clientv3.OpPut("/pods/a", `{"spec":{"node":"n2"}}`),
+		clientv3.OpDelete("/pods/b"),
+	).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.IndexRange(ctx, "by-node", "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "/pods/c" || resp.Kvs[0].ModRevision != presp.Header.Revision {
+		t.Errorf("expected /pods/c at revision %d, got %+v", presp.Header.Revision, resp.Kvs)
+	}
+	if resp.Header.Revision != presp.Header.Revision+1 {
+		t.Errorf("expected the keys read at revision %d, got %d", presp.Header.Revision+1, resp.Header.Revision)
+	}
+	resp, err = cli.IndexRange(ctx, "by-node", "", clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "/pods/c" || resp.Kvs[0].Value != nil || !resp.More || resp.Count != 2 {
+		t.Errorf("expected the key /pods/c of 2 keys, got %+v", resp)
+	}
+
+	// all the members index the keys alike, including after a restart
+	clus.Members[2].Stop(t)
+	clus.Members[2].Restart(t)
+	clus.WaitMembersForLeader(t, clus.Members)
+	want := []*pb.IndexUsage{{Index: &pb.Index{Name: "by-node", Prefix: []byte("/pods/"), Field: "spec.node"}, IndexedKeys: 2, DistinctValues: 2}}
+	for i := range clus.Members {
+		lresp, err := clus.Client(i).IndexList(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lresp.Indexes, want) {
+			t.Errorf("expected indexes %+v on member %d, got %+v", want, i, lresp.Indexes)
+		}
+		resp, err = clus.Client(i).IndexRange(ctx, "by-node", "n2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "/pods/a" {
+			t.Errorf("expected /pods/a on member %d, got %+v", i, resp.Kvs)
+		}
+	}
+
+	if _, err = cli.IndexDelete(ctx, "by-node"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.IndexDelete(ctx, "by-node"); err != rpctypes.ErrIndexNotFound {
+		t.Errorf("expected %v, got %v", rpctypes.ErrIndexNotFound, err)
+	}
+}
+
+func TestMaintenanceLogLevel(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx := context.Background()
+	ep := clus.Members[0].GRPCURL()
+
+	resp, err := cli.LogLevel(ctx, ep, "warn", map[string]string{"raft": "debug", "mvcc": "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.ModuleLogLevel{
+		{Module: "auth", Level: "warn"},
+		{Module: "grpc", Level: "warn"},
+		{Module: "lessor", Level: "warn"},
+		{Module: "mvcc", Level: "error", Overridden: true},
+		{Module: "raft", Level: "debug", Overridden: true},
+	}
+	if resp.DefaultLevel != "warn" || !reflect.DeepEqual(resp.Modules, want) {
+		t.Errorf("expected default level warn and modules %+v, got %s and %+v", want, resp.DefaultLevel, resp.Modules)
+	}
+	if l, _, _ := clus.Members[0].LogLevels.Level("raft"); l.String() != "debug" {
+		t.Errorf("expected raft to log at debug, got %v", l)
+	}
+
+	if _, err = cli.LogLevel(ctx, ep, "", map[string]string{"raft": "verbose"}); err != rpctypes.ErrInvalidLogLevel {
+		t.Errorf("expected %v, got %v", rpctypes.ErrInvalidLogLevel, err)
+	}
+	if _, err = cli.LogLevel(ctx, ep, "", map[string]string{"wal": "debug"}); err != rpctypes.ErrUnknownLogModule {
+		t.Errorf("expected %v, got %v", rpctypes.ErrUnknownLogModule, err)
+	}
+
+	resp, err = cli.LogLevel(ctx, ep, "info", map[string]string{"raft": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range resp.Modules {
+		if m.Module == "raft" && (m.Level != "info" || m.Overridden) {
+			t.Errorf("expected raft to log at the default level again, got %+v", m)
+		}
+	}
+}
+
+func TestMaintenanceSlowLog(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			// every request is slow
+			cfg.SlowRequestThreshold = time.Nanosecond
+			cfg.SlowRequestLogSize = 2
+		},
+	})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx := context.Background()
+	ep := clus.Members[0].GRPCURL()
+
+	if _, err := cli.Put(ctx, strings.Repeat("k", 100), "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Get(ctx, "foo", clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cli.SlowLog(ctx, ep, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ThresholdSeconds != time.Nanosecond.Seconds() {
+		t.Errorf("expected threshold %v, got %v", time.Nanosecond.Seconds(), resp.ThresholdSeconds)
+	}
+	if len(resp.Requests) != 2 || resp.Total < 2 {
+		t.Fatalf("expected 2 slow requests of at least 2, got %d of %d", len(resp.Requests), resp.Total)
+	}
+	get, put := resp.Requests[0], resp.Requests[1]
+	// the proxy prefixes the keys with its namespace
+	if get.Method != "range" || !strings.HasSuffix(string(get.Key), "foo") || !strings.HasSuffix(string(get.RangeEnd), "fop") || get.ResponseBytes == 0 {
+		t.Errorf("unexpected range %+v", get)
+	}
+	if put.Method != "put" || len(put.Key) != 64 || put.RequestBytes == 0 || put.Error != "" {
+		t.Errorf("unexpected put %+v", put)
+	}
+	if put.TotalSeconds < put.QueueSeconds+put.FsyncSeconds+put.ApplySeconds {
+		t.Errorf("expected the breakdown of the put within its total time, got %+v", put)
+	}
+
+	resp, err = cli.SlowLog(ctx, ep, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Requests) != 1 || resp.Requests[0].Method != "range" {
+		t.Errorf("expected the last slow request only, got %+v", resp.Requests)
+	}
+}
+
+func TestMaintenanceSlowLogDisabled(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	resp, err := clus.RandClient().SlowLog(context.Background(), clus.Members[0].GRPCURL(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ThresholdSeconds != 0 || len(resp.Requests) != 0 {
+		t.Errorf("expected no slow log, got %+v", resp)
+	}
+}
+
+func TestMaintenanceTopKeys(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys and adds its own watches")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		if _, err := cli.Put(ctx, "/a/x", strings.Repeat("v", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for k, v := range map[string]string{"/b/x": "large value", "/b/y": "value", "/c": "v"} {
+		if _, err := cli.Put(ctx, k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range []string{"/a/", "/a/", "/b/x"} {
+		wch := cli.Watch(ctx, key, clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+		<-wch
+	}
+
+	resp, err := cli.TopKeys(ctx, clus.Members[0].GRPCURL(), "", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, k := range resp.LargestKeys {
+		keys = append(keys, fmt.Sprintf("%s:%d", k.Key, k.ValueBytes))
+	}
+	if want := []string{"/b/x:11", "/a/x:5"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected the largest keys %v, got %v", want, keys)
+	}
+	var prefixes []string
+	for _, p := range resp.Prefixes {
+		prefixes = append(prefixes, fmt.Sprintf("%s:%d", p.Prefix, p.Revisions))
+	}
+	if want := []string{"/a/:5", "/b/:2"}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("expected the prefixes %v, got %v", want, prefixes)
+	}
+	if len(resp.WatchedRanges) != 2 || string(resp.WatchedRanges[0].Key) != "/a/" || resp.WatchedRanges[0].Watchers != 2 {
+		t.Errorf("expected /a/ watched twice first, got %+v", resp.WatchedRanges)
+	}
+}
+
+func TestMaintenanceFragmentation(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	val := strings.Repeat("v", 1024)
+	for i := 0; i < 1000; i++ {
+		if _, err := cli.Put(ctx, fmt.Sprintf("foo%04d", i), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Delete(ctx, "foo", clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Compact(ctx, resp.Header.Revision, clientv3.WithCompactPhysical()); err != nil {
+		t.Fatal(err)
+	}
+
+	ep := clus.Members[0].GRPCURL()
+	fresp, err := cli.Fragmentation(ctx, ep, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresp.ReclaimableBytes <= 0 || !fresp.DefragRecommended {
+		t.Fatalf("expected defragmentation recommended after the compaction of the deleted keys, got %+v", fresp)
+	}
+	var buckets []string
+	for _, b := range fresp.Buckets {
+		buckets = append(buckets, string(b.Name))
+	}
+	if !strings.Contains(strings.Join(buckets, ","), "key") {
+		t.Errorf("expected the page stats of the key bucket, got the buckets %v", buckets)
+	}
+
+	if _, err = cli.Defragment(ctx, ep); err != nil {
+		t.Fatal(err)
+	}
+	fresp, err = cli.Fragmentation(ctx, ep, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresp.DefragRecommended {
+		t.Errorf("expected no defragmentation recommended once defragmented, got %+v", fresp)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/mirror"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 )
@@ -124,3 +125,126 @@ func TestMirrorSyncBase(t *testing.T) {
 		t.Errorf("unexpected kv count: %d", count)
 	}
 }
+
+func TestMirrorSyncBidirectional(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	// the members of both clusters are named m0, serve the destination over
+	// tcp so that their gRPC addresses differ
+	dclus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, UseTCP: true})
+	defer dclus.Terminate(t)
+
+	src, dst := clus.Client(0), dclus.Client(0)
+	for _, kv := range [][2]string{{"p/a", "1"}, {"p/shared", "src"}, {"p/skip/x", "x"}} {
+		if _, err := src.Put(context.TODO(), kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, kv := range [][2]string{{"q/b", "2"}, {"q/shared", "dst"}, {"q/owned", "dst"}} {
+		if _, err := dst.Put(context.TODO(), kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.Put(context.TODO(), "p/owned", "src"); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu sync.Mutex
+		cp mirror.Checkpoint
+	)
+	ctx, cancel := context.WithCancel(context.TODO())
+	donec := make(chan error, 1)
+	go func() {
+		donec <- mirror.SyncBidirectional(ctx, src, dst, mirror.BidirectionalConfig{
+			Prefix:          "p/",
+			DestPrefix:      "q/",
+			ExcludePrefixes: []string{"skip/"},
+			Policy:          mirror.PrefixOwnership(map[string]mirror.Side{"owned": mirror.Destination}, mirror.LastWriterWins),
+			OnCheckpoint: func(c mirror.Checkpoint) error {
+				mu.Lock()
+				defer mu.Unlock()
+				cp = c
+				return nil
+			},
+		})
+	}()
+
+	// the keys are merged, the source winning the tie on p/shared and the
+	// destination owning p/owned
+	waitMirrorValue(t, dst, "q/a", "1")
+	waitMirrorValue(t, src, "p/b", "2")
+	waitMirrorValue(t, dst, "q/shared", "src")
+	waitMirrorValue(t, src, "p/owned", "dst")
+
+	// the changes are mirrored both ways
+	if _, err := dst.Put(context.TODO(), "q/c", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.Delete(context.TODO(), "q/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Put(context.TODO(), "p/d", "4"); err != nil {
+		t.Fatal(err)
+	}
+	waitMirrorValue(t, src, "p/c", "3")
+	waitMirrorValue(t, src, "p/a", "")
+	waitMirrorValue(t, dst, "q/d", "4")
+
+	resp, err := dst.Get(context.TODO(), "q/skip/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 0 {
+		t.Errorf("excluded keys are mirrored")
+	}
+
+	// the mirrored changes are not mirrored back
+	time.Sleep(500 * time.Millisecond)
+	rev := func(c *clientv3.Client) int64 {
+		resp, err := c.Get(context.TODO(), "p/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Revision
+	}
+	srev, drev := rev(src), rev(dst)
+	time.Sleep(500 * time.Millisecond)
+	if rev(src) != srev || rev(dst) != drev {
+		t.Errorf("the mirror keeps writing")
+	}
+	mu.Lock()
+	if cp.SourceRev != srev || cp.DestRev != drev {
+		t.Errorf("checkpoint = %+v, want source revision %d and destination revision %d", cp, srev, drev)
+	}
+	mu.Unlock()
+
+	cancel()
+	if err := <-donec; err != context.Canceled {
+		t.Errorf("SyncBidirectional = %v, want %v", err, context.Canceled)
+	}
+}
+
+// waitMirrorValue waits for the key to have the value, or to be missing if
+// the value is empty.
+func waitMirrorValue(t *testing.T, c *clientv3.Client, key, want string) {
+	t.Helper()
+	var got string
+	for i := 0; i < 50; i++ {
+		resp, err := c.Get(context.TODO(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = ""
+		if len(resp.Kvs) != 0 {
+			got = string(resp.Kvs[0].Value)
+		}
+		if got == want {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("%s = %q, want %q", key, got, want)
+}
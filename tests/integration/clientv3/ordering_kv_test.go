@@ -93,6 +93,68 @@ func TestDetectKvOrderViolation(t *testing.T) {
 	}
 }
 
+func TestRerouteKvOrderViolation(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	// partition a follower so that the leader keeps serving writes
+	lead := clus.WaitLeader(t)
+	fresh, stale := clus.Members[lead], clus.Members[(lead+1)%3]
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{fresh.GRPCURL()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, cli.Close()) }()
+	cliAll, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints: []string{
+			clus.Members[0].GRPCURL(),
+			clus.Members[1].GRPCURL(),
+			clus.Members[2].GRPCURL(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { assert.NoError(t, cliAll.Close()) }()
+	ctx := context.TODO()
+
+	if _, err = clus.Client(lead).Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	// ensure that the stale member has the revision for "bar" before it is partitioned
+	if _, err = clus.Client((lead+1)%3).Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	stale.InjectPartition(t, clus.Members[lead], clus.Members[(lead+2)%3])
+	if _, err = clus.Client(lead).Put(ctx, "foo", "buzz"); err != nil {
+		t.Fatal(err)
+	}
+
+	orderingKv := ordering.NewKV(cli.KV,
+		func(op clientv3.Op, resp clientv3.OpResponse, prevRev int64) error {
+			return ordering.ErrNoGreaterRev
+		},
+		ordering.WithReroute(cliAll))
+	v, err := orderingKv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte("buzz"), v.Kvs[0].Value)
+
+	// the partitioned member serves the stale "bar", the ordering
+	// wrapper should reissue the read to one of the other members.
+	cli.SetEndpoints(stale.GRPCURL())
+	time.Sleep(2 * time.Second) // FIXME: Figure out how pause SetEndpoints sufficiently that this is not needed
+	v, err = orderingKv.Get(ctx, "foo", clientv3.WithSerializable())
+	if err != nil {
+		t.Fatalf("expected rerouted read, got %v", err)
+	}
+	assert.Equal(t, []byte("buzz"), v.Kvs[0].Value)
+	assert.NotEqual(t, uint64(stale.Server.ID()), v.Header.MemberId)
+}
+
 func TestDetectTxnOrderViolation(t *testing.T) {
 	var errOrderViolation = errors.New("DetectedOrderViolation")
 
@@ -29,6 +29,9 @@ import (
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/snapshot"
 	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"go.uber.org/zap/zaptest"
 )
@@ -70,6 +73,70 @@ func TestSaveSnapshotVersion(t *testing.T) {
 	}
 }
 
+// TestSaveSnapshotMinRevision ensures that the snapshot is only saved if its
+// revision is not behind the requested revision.
+func TestSaveSnapshotMinRevision(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	resp, err := clus.Client(0).Put(context.TODO(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := resp.Header.Revision
+	ccfg := clientv3.Config{Endpoints: []string{clus.Members[0].GRPCURL()}}
+	lg := zaptest.NewLogger(t)
+
+	dbPath := filepath.Join(t.TempDir(), "behind.db")
+	if _, err = snapshot.SaveWithVersion(context.TODO(), lg, ccfg, dbPath, snapshot.WithMinRevision(rev+1)); err == nil {
+		t.Fatalf("expected the snapshot at revision %d to fail for revision %d", rev, rev+1)
+	}
+	if _, err = os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshot file, got %v", err)
+	}
+
+	dbPath = filepath.Join(t.TempDir(), "snapshot.db")
+	if _, err = snapshot.SaveWithVersion(context.TODO(), lg, ccfg, dbPath, snapshot.WithMinRevision(rev)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSaveSnapshotFromFollower ensures that the snapshot saved from a follower
+// contains the writes acknowledged by the leader.
+func TestSaveSnapshotFromFollower(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	leader := clus.WaitLeader(t)
+	resp, err := clus.Client(leader).Put(context.TODO(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eps []string
+	for _, m := range clus.Members {
+		eps = append(eps, m.GRPCURL())
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "snapshot.db")
+	if _, err = snapshot.SaveFromFollowerWithVersion(context.TODO(), zaptest.NewLogger(t), clientv3.Config{Endpoints: eps}, dbPath); err != nil {
+		t.Fatal(err)
+	}
+	lg := zaptest.NewLogger(t)
+	be := backend.NewDefaultBackend(lg, dbPath)
+	defer be.Close()
+	kv := mvcc.NewStore(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer kv.Close()
+	rr, err := kv.Range(context.TODO(), []byte("foo"), nil, mvcc.RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.KVs) != 1 || rr.Rev < resp.Header.Revision {
+		t.Errorf("expected the snapshot to contain foo at revision %d, got %v at revision %d", resp.Header.Revision, rr.KVs, rr.Rev)
+	}
+}
+
 type kv struct {
 	k, v string
 }
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -918,6 +919,47 @@ func TestWatchWithFilter(t *testing.T) {
 	}
 }
 
+// TestWatchWithValueFilter checks that put events are filtered by value at
+// server side, and delete events are not.
+func TestWatchWithValueFilter(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	cluster := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+
+	client := cluster.RandClient()
+	ctx := context.Background()
+
+	wc := client.Watch(ctx, "job/", clientv3.WithPrefix(), clientv3.WithValuePrefix("failed:"), clientv3.WithValueSize("<", 16))
+
+	for _, kv := range [][2]string{
+		{"job/1", "succeeded"},
+		{"job/2", "failed: timeout"},
+		{"job/3", "failed: out of memory"},
+	} {
+		if _, err := client.Put(ctx, kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Delete(ctx, "job/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []*clientv3.Event
+	for len(events) < 2 {
+		resp := <-wc
+		if err := resp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, resp.Events...)
+	}
+	if len(events) != 2 ||
+		events[0].Type != clientv3.EventTypePut || string(events[0].Kv.Key) != "job/2" ||
+		events[1].Type != clientv3.EventTypeDelete || string(events[1].Kv.Key) != "job/1" {
+		t.Fatalf("expected put of job/2 and delete of job/1, got %+v", events)
+	}
+}
+
 // TestWatchWithCreatedNotification checks that WithCreatedNotify returns a
 // Created watch response.
 func TestWatchWithCreatedNotification(t *testing.T) {
@@ -1209,3 +1251,182 @@ func testWatchClose(t *testing.T, wctx *watchctx) {
 		t.Fatalf("read wch got %v; expected closed channel", wresp)
 	}
 }
+
+// TestWatchCacheResumeOnLeaderLoss ensures that the watches of the watch
+// cache resume without a gap on another member when theirs loses its leader.
+func TestWatchCacheResumeOnLeaderLoss(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys of the cluster clients")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	lead := clus.WaitLeader(t)
+	follower, other := (lead+1)%3, (lead+2)%3
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:      []string{clus.Members[follower].GRPCURL(), clus.Members[other].GRPCURL()},
+		WatchCacheSize: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	// pin the watch to the follower
+	cli.SetEndpoints(clus.Members[follower].GRPCURL())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wch := cli.Watch(ctx, "foo")
+
+	kv := clus.Client(lead)
+	var revs []int64
+	put := func(val string) {
+		resp, err := kv.Put(context.TODO(), "foo", val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revs = append(revs, resp.Header.Revision)
+	}
+	put("a")
+
+	clus.Members[follower].InjectPartition(t, clus.Members[lead], clus.Members[other])
+	defer clus.Members[follower].RecoverPartition(t, clus.Members[lead], clus.Members[other])
+	cli.SetEndpoints(clus.Members[follower].GRPCURL(), clus.Members[other].GRPCURL())
+	put("b")
+	put("c")
+
+	var got []int64
+	for len(got) < len(revs) {
+		select {
+		case wr := <-wch:
+			if err := wr.Err(); err != nil {
+				t.Fatalf("unexpected watch error %v", err)
+			}
+			for _, ev := range wr.Events {
+				got = append(got, ev.Kv.ModRevision)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected events at revisions %v, got %v", revs, got)
+		}
+	}
+	if !reflect.DeepEqual(got, revs) {
+		t.Fatalf("expected events at revisions %v, got %v", revs, got)
+	}
+}
+
+// TestWatchCacheCompacted ensures that the watches of the watch cache report
+// the compacted revisions, then carry on from the compaction revision.
+func TestWatchCacheCompacted(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:      []string{clus.Members[0].GRPCURL()},
+		WatchCacheSize: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var rev int64
+	for _, val := range []string{"a", "b", "c"} {
+		resp, err := cli.Put(context.TODO(), "foo", val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev = resp.Header.Revision
+	}
+	if _, err = cli.Compact(context.TODO(), rev); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wch := cli.Watch(ctx, "foo", clientv3.WithRev(1))
+	wr := <-wch
+	if wr.Err() != rpctypes.ErrCompacted || wr.CompactRevision != rev {
+		t.Fatalf("expected compaction at revision %d, got %+v", rev, wr)
+	}
+	wr = <-wch
+	if len(wr.Events) != 1 || string(wr.Events[0].Kv.Value) != "c" {
+		t.Fatalf("expected the event at the compaction revision, got %+v", wr)
+	}
+	if _, err = cli.Put(context.TODO(), "foo", "d"); err != nil {
+		t.Fatal(err)
+	}
+	wr = <-wch
+	if len(wr.Events) != 1 || string(wr.Events[0].Kv.Value) != "d" {
+		t.Fatalf("expected the event of the put, got %+v", wr)
+	}
+}
+
+// TestWatchReauthOnOldAuthRevision ensures a watch refused for an outdated
+// auth token is retried on a stream authenticated again, with the rotated
+// credentials.
+func TestWatchReauthOnOldAuthRevision(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	authSetupRoot(t, clus.Client(0).Auth)
+
+	var mu sync.Mutex
+	password := "123"
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints: []string{clus.Members[0].GRPCURL()},
+		Credentials: func(ctx context.Context) (string, string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return "root", password, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wcha := cli.Watch(ctx, "a", clientv3.WithCreatedNotify())
+	if resp := <-wcha; !resp.Created {
+		t.Fatalf("expected created response, got %+v", resp)
+	}
+
+	// changing the password bumps the auth revision, outdating the tokens
+	if _, err = cli.UserChangePassword(context.TODO(), "root", "456"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	password = "456"
+	mu.Unlock()
+
+	// the put is retried with a token issued for the new password
+	if _, err = cli.Put(context.TODO(), "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	// the watch is created on the stream of the outdated token first
+	wchb := cli.Watch(ctx, "b", clientv3.WithCreatedNotify())
+	if resp := <-wchb; !resp.Created || resp.Err() != nil {
+		t.Fatalf("expected created response, got %+v", resp)
+	}
+	if _, err = cli.Put(context.TODO(), "b", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, wch := range map[string]clientv3.WatchChan{"a": wcha, "b": wchb} {
+		select {
+		case resp := <-wch:
+			if len(resp.Events) != 1 || string(resp.Events[0].Kv.Key) != key {
+				t.Fatalf("expected a put of %q, got %+v", key, resp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the put of %q", key)
+		}
+	}
+}
@@ -21,7 +21,9 @@
 package embed_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"os"
@@ -210,3 +212,88 @@ func setupEmbedCfg(cfg *embed.Config, curls []url.URL, purls []url.URL) {
 	}
 	cfg.InitialCluster = cfg.InitialCluster[1:]
 }
+
+// TestEmbedEtcdEncryption ensures that the values and the WAL entries are not
+// written in plaintext when encryption at rest is enabled, and that they are
+// read back after a restart and a rotation of the key encryption key.
+func TestEmbedEtcdEncryption(t *testing.T) {
+	testutil.SkipTestIfShortMode(t, "Cannot start embedded cluster in --short tests")
+
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	writeKeys := func(keys ...string) {
+		var lines []string
+		for _, k := range keys {
+			lines = append(lines, k+":"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(k[:1]), 32)))
+		}
+		if err := os.WriteFile(keyFile, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKeys("a1")
+
+	urls := newEmbedURLs(false, 2)
+	dir := filepath.Join(t.TempDir(), "embed-etcd")
+	start := func() (*embed.Etcd, *clientv3.Client) {
+		cfg := embed.NewConfig()
+		setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
+		cfg.Dir = dir
+		cfg.ExperimentalEncryptionKeyFile = keyFile
+		cfg.ExperimentalEncryptionKeyRotationInterval = 100 * time.Millisecond
+		e, err := embed.StartEtcd(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-e.Server.ReadyNotify()
+		cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[0].String()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e, cli
+	}
+
+	e, cli := start()
+	for i := 0; i < 3; i++ {
+		if _, err := cli.Put(context.TODO(), fmt.Sprintf("foo%d", i), fmt.Sprintf("secret-value-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		// writes with the data encryption keys rotated in between
+		time.Sleep(150 * time.Millisecond)
+	}
+	cli.Close()
+	e.Close()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(b, []byte("secret-value")) {
+			t.Errorf("%s contains a value in plaintext", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous key encryption key is kept until the keys are wrapped again
+	writeKeys("b2", "a1")
+	e, cli = start()
+	defer e.Close()
+	defer cli.Close()
+	resp, err := cli.Get(context.TODO(), "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 3 {
+		t.Fatalf("len(kvs) = %d, want 3", len(resp.Kvs))
+	}
+	for i, kv := range resp.Kvs {
+		if want := fmt.Sprintf("secret-value-%d", i); string(kv.Value) != want {
+			t.Errorf("value = %q, want %q", kv.Value, want)
+		}
+	}
+}
@@ -52,6 +52,59 @@ func TestKVProxyRange(t *testing.T) {
 	client.Close()
 }
 
+func TestKVProxyCacheInvalidation(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kvts := newKVProxyServer([]string{clus.Members[0].GRPCURL()}, t)
+	defer kvts.close()
+
+	client, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:   []string{kvts.l.Addr().String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err = clus.Client(0).Put(context.TODO(), "foo", "1"); err != nil {
+		t.Fatal(err)
+	}
+	// cache the range once the proxy watches the changes
+	time.Sleep(time.Second)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(context.TODO(), "foo", clientv3.WithSerializable())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Kvs[0].Value) != "1" {
+			t.Fatalf("expected %q, got %q", "1", resp.Kvs[0].Value)
+		}
+	}
+
+	// a change not made through the proxy invalidates the cached range
+	if _, err = clus.Client(0).Put(context.TODO(), "foo", "2"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get(context.TODO(), "foo", clientv3.WithSerializable())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Kvs[0].Value) == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the cached range to be invalidated, got %q", resp.Kvs[0].Value)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 type kvproxyTestServer struct {
 	kp     pb.KVServer
 	c      *clientv3.Client
@@ -0,0 +1,182 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+	"google.golang.org/grpc"
+)
+
+// TestLeaseProxyKeepAliveCoalescing tests that the client streams keeping
+// the same lease alive through the proxy share its upstream keepalive.
+func TestLeaseProxyKeepAliveCoalescing(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lpts := newLeaseProxyServer([]string{clus.Members[0].GRPCURL()}, t)
+	defer lpts.close()
+
+	lresp, err := clus.Client(0).Grant(context.TODO(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cancels []context.CancelFunc
+	var respcs []<-chan *clientv3.LeaseKeepAliveResponse
+	for i := 0; i < 3; i++ {
+		client, err := integration2.NewClient(t, clientv3.Config{
+			Endpoints:   []string{lpts.l.Addr().String()},
+			DialTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		respc, err := client.KeepAlive(ctx, lresp.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancels, respcs = append(cancels, cancel), append(respcs, respc)
+	}
+	for i, respc := range respcs {
+		select {
+		case resp := <-respc:
+			if resp == nil || resp.ID != lresp.ID {
+				t.Fatalf("#%d: unexpected keepalive response %+v", i, resp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("#%d: timed out waiting for a keepalive response", i)
+		}
+	}
+
+	// the remaining streams keep the lease alive past its TTL
+	cancels[0]()
+	cancels[1]()
+	time.Sleep(3 * time.Second)
+	ttl, err := clus.Client(0).TimeToLive(context.TODO(), lresp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl.TTL <= 0 {
+		t.Fatalf("expected the lease to be kept alive, got TTL %d", ttl.TTL)
+	}
+
+	if _, err = clus.Client(0).Revoke(context.TODO(), lresp.ID); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-respcs[2]:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("expected the keepalive to stop once the lease is revoked")
+		}
+	}
+}
+
+// TestAuthProxyStatusCoalescing tests that concurrent AuthStatus requests
+// through the proxy all get the status.
+func TestAuthProxyStatusCoalescing(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lpts := newLeaseProxyServer([]string{clus.Members[0].GRPCURL()}, t)
+	defer lpts.close()
+
+	client, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:   []string{lpts.l.Addr().String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.AuthStatus(context.TODO())
+			if err == nil && resp.Enabled {
+				t.Errorf("expected auth to be disabled")
+			}
+			errc <- err
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type leaseProxyTestServer struct {
+	c      *clientv3.Client
+	server *grpc.Server
+	l      net.Listener
+}
+
+func (lpts *leaseProxyTestServer) close() {
+	lpts.server.Stop()
+	lpts.l.Close()
+	lpts.c.Close()
+}
+
+// newLeaseProxyServer serves the lease and auth proxies.
+func newLeaseProxyServer(endpoints []string, t *testing.T) *leaseProxyTestServer {
+	client, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lp, _ := grpcproxy.NewLeaseProxy(client.Ctx(), client)
+	lpts := &leaseProxyTestServer{c: client, server: grpc.NewServer()}
+	pb.RegisterLeaseServer(lpts.server, lp)
+	pb.RegisterAuthServer(lpts.server, grpcproxy.NewAuthProxy(client))
+
+	lpts.l, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go lpts.server.Serve(lpts.l)
+
+	return lpts
+}
@@ -20,12 +20,14 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/v3"
+	clientsnapshot "go.etcd.io/etcd/client/v3/snapshot"
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
 	"go.etcd.io/etcd/server/v3/embed"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
@@ -137,6 +139,108 @@ func TestSnapshotV3RestoreMulti(t *testing.T) {
 }
 
 // TestCorruptedBackupFileCheck tests if we can correctly identify a corrupted backup file.
+// TestSnapshotV3RestoreIncremental ensures that incremental backups are
+// layered on the snapshot they follow when restoring it.
+func TestSnapshotV3RestoreIncremental(t *testing.T) {
+	integration2.BeforeTest(t)
+	testutil.SkipTestIfShortMode(t,
+		"Snapshot creation tests are depending on embedded etcd server so are integration-level tests.")
+
+	urls := newEmbedURLs(t, 2)
+	cfg := integration2.NewEmbedConfig(t, "default")
+	cfg.ClusterState = "new"
+	cfg.LCUrls, cfg.ACUrls = urls[:1], urls[:1]
+	cfg.LPUrls, cfg.APUrls = urls[1:], urls[1:]
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, urls[1].String())
+	srv, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	select {
+	case <-srv.Server.ReadyNotify():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("failed to start embed.Etcd for creating snapshots")
+	}
+
+	ccfg := clientv3.Config{Endpoints: []string{cfg.ACUrls[0].String()}}
+	cli, err := integration2.NewClient(t, ccfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	put := func(k, v string) int64 {
+		resp, perr := cli.Put(context.Background(), k, v)
+		if perr != nil {
+			t.Fatal(perr)
+		}
+		return resp.Header.Revision
+	}
+
+	put("foo1", "bar1")
+	put("foo2", "bar2")
+	rev := put("foo3", "bar3")
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "snapshot.db")
+	if _, err = snapshot.NewV3(zaptest.NewLogger(t)).Save(context.Background(), ccfg, dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first incremental backup overlaps the snapshot, the second follows it
+	put("foo1", "baz1")
+	deltaPath1 := filepath.Join(dir, "1.delta")
+	rev1, err := clientsnapshot.SaveIncremental(context.Background(), zaptest.NewLogger(t), ccfg, rev-1, deltaPath1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put("foo4", "bar4")
+	if _, err = cli.Delete(context.Background(), "foo2"); err != nil {
+		t.Fatal(err)
+	}
+	deltaPath2 := filepath.Join(dir, "2.delta")
+	rev2, err := clientsnapshot.SaveIncremental(context.Background(), zaptest.NewLogger(t), ccfg, rev1, deltaPath2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cURLs, _, srvs := restoreCluster(t, 1, dbPath, deltaPath1, deltaPath2)
+	defer srvs[0].Close()
+	rcli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{cURLs[0].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rcli.Close()
+
+	gresp, err := rcli.Get(context.Background(), "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gresp.Header.Revision != rev2 {
+		t.Errorf("expected revision %d, got %d", rev2, gresp.Header.Revision)
+	}
+	var got []kv
+	for _, ekv := range gresp.Kvs {
+		got = append(got, kv{string(ekv.Key), string(ekv.Value)})
+	}
+	expected := []kv{{"foo1", "baz1"}, {"foo3", "bar3"}, {"foo4", "bar4"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// restoring an incremental backup that does not follow the snapshot fails
+	err = snapshot.NewV3(zaptest.NewLogger(t)).Restore(snapshot.RestoreConfig{
+		SnapshotPath:     dbPath,
+		Name:             "gap",
+		OutputDataDir:    filepath.Join(dir, "gap.etcd"),
+		PeerURLs:         []string{"http://127.0.0.1:2380"},
+		InitialCluster:   "gap=http://127.0.0.1:2380",
+		IncrementalPaths: []string{deltaPath2},
+	})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected a missing changes error, got %v", err)
+	}
+}
+
 func TestCorruptedBackupFileCheck(t *testing.T) {
 	dbPath := integration2.MustAbsPath("testdata/corrupted_backup.db")
 	integration2.BeforeTest(t)
@@ -218,7 +322,7 @@ func createSnapshotFile(t *testing.T, kvs []kv) string {
 
 const testClusterTkn = "tkn"
 
-func restoreCluster(t *testing.T, clusterN int, dbPath string) (
+func restoreCluster(t *testing.T, clusterN int, dbPath string, incrementalPaths ...string) (
 	cURLs []url.URL,
 	pURLs []url.URL,
 	srvs []*embed.Etcd) {
@@ -250,6 +354,7 @@ func restoreCluster(t *testing.T, clusterN int, dbPath string) (
 			PeerURLs:            []string{pURLs[i].String()},
 			InitialCluster:      ics,
 			InitialClusterToken: cfg.InitialClusterToken,
+			IncrementalPaths:    incrementalPaths,
 		}); err != nil {
 			t.Fatal(err)
 		}
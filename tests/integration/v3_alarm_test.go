@@ -137,6 +137,70 @@ func TestV3StorageQuotaApply(t *testing.T) {
 	}
 }
 
+// TestV3StorageQuotaExemptPrefixes tests that writes to quota exempted prefixes
+// go through once the quota is exhausted, up to the exempted number of bytes.
+func TestV3StorageQuotaExemptPrefixes(t *testing.T) {
+	integration.BeforeTest(t)
+	quotasize := int64(16 * os.Getpagesize())
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	clus.Members[0].QuotaBackendBytes = quotasize
+	clus.Members[0].QuotaExemptPrefixes = []string{"/recovery/"}
+	clus.Members[0].QuotaExemptBytes = quotasize
+	clus.Members[0].Stop(t)
+	clus.Members[0].Restart(t)
+	clus.WaitMembersForLeader(t, clus.Members)
+	kvc := integration.ToGRPC(clus.Client(0)).KV
+	waitForRestart(t, kvc)
+
+	// exhaust the quota to raise the alarm
+	bigbuf := make([]byte, quotasize)
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("abc"), Value: bigbuf}); err == nil || !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+	resp, err := clus.Members[0].Server.Alarm(context.TODO(), &pb.AlarmRequest{Action: pb.AlarmRequest_GET})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Alarms) != 1 || resp.Alarms[0].Alarm != pb.AlarmType_NOSPACE {
+		t.Fatalf("expected NOSPACE alarm, got %+v", resp.Alarms)
+	}
+
+	smallbuf := make([]byte, 1024)
+	if _, err = kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("/recovery/marker"), Value: smallbuf}); err != nil {
+		t.Fatalf("put to exempted prefix should succeed, got %v", err)
+	}
+	if _, err = kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("abc"), Value: smallbuf}); err == nil || !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+
+	putOp := func(key string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte(key), Value: smallbuf}}}
+	}
+	if _, err = kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/a"), putOp("/recovery/b")}}); err != nil {
+		t.Fatalf("txn writing exempted prefixes should succeed, got %v", err)
+	}
+	if _, err = kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/c")}, Failure: []*pb.RequestOp{putOp("abc")}}); err == nil || !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("txn got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+	txnOp := func(ops ...*pb.RequestOp) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: ops}}}
+	}
+	if _, err = kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{txnOp(putOp("/recovery/d"))}}); err != nil {
+		t.Fatalf("nested txn writing exempted prefixes should succeed, got %v", err)
+	}
+	if _, err = kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{putOp("/recovery/e"), txnOp(putOp("abc"))}}); err == nil || !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("txn got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+
+	// writes to exempted prefixes are capped as well
+	if _, err = kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("/recovery/big"), Value: make([]byte, 2*quotasize)}); err == nil || !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+}
+
 // TestV3AlarmDeactivate ensures that space alarms can be deactivated so puts go through.
 func TestV3AlarmDeactivate(t *testing.T) {
 	integration.BeforeTest(t)
@@ -172,6 +236,52 @@ func TestV3AlarmDeactivate(t *testing.T) {
 	}
 }
 
+// TestV3AlarmStacking ensures raising an alarm keeps the restrictions of alarms already raised.
+func TestV3AlarmStacking(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	kvc := integration.ToGRPC(clus.RandClient()).KV
+	mt := integration.ToGRPC(clus.RandClient()).Maintenance
+	auth := integration.ToGRPC(clus.RandClient()).Auth
+
+	alarm := func(action pb.AlarmRequest_AlarmAction, typ pb.AlarmType) {
+		if _, err := mt.Alarm(context.TODO(), &pb.AlarmRequest{MemberID: 123, Action: action, Alarm: typ}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key, val := []byte("abc"), make([]byte, 512)
+
+	alarm(pb.AlarmRequest_ACTIVATE, pb.AlarmType_READONLY)
+	alarm(pb.AlarmRequest_ACTIVATE, pb.AlarmType_NOSPACE)
+
+	// NOSPACE lets user changes through, READONLY must still reject them
+	if _, err := auth.UserAdd(context.TODO(), &pb.AuthUserAddRequest{Name: "user", Password: "pass"}); !eqErrGRPC(err, rpctypes.ErrGRPCReadOnly) {
+		t.Fatalf("user add got %v, expected %v", err, rpctypes.ErrGRPCReadOnly)
+	}
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: key, Value: val}); !eqErrGRPC(err, rpctypes.ErrGRPCReadOnly) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCReadOnly)
+	}
+
+	alarm(pb.AlarmRequest_DEACTIVATE, pb.AlarmType_READONLY)
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: key, Value: val}); !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+
+	// raising READONLY over NOSPACE keeps the space alarm after READONLY is lifted
+	alarm(pb.AlarmRequest_ACTIVATE, pb.AlarmType_READONLY)
+	alarm(pb.AlarmRequest_DEACTIVATE, pb.AlarmType_READONLY)
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: key, Value: val}); !eqErrGRPC(err, rpctypes.ErrGRPCNoSpace) {
+		t.Fatalf("put got %v, expected %v", err, rpctypes.ErrGRPCNoSpace)
+	}
+
+	alarm(pb.AlarmRequest_DEACTIVATE, pb.AlarmType_NOSPACE)
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: key, Value: val}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestV3CorruptAlarm(t *testing.T) {
 	integration.BeforeTest(t)
 	lg := zaptest.NewLogger(t)
@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestV3AuditLog ensures that the mutating and auth requests matching the
+// audit log filter are recorded with their user and result.
+func TestV3AuditLog(t *testing.T) {
+	integration.BeforeTest(t)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.AuditLogger = zap.New(core)
+			cfg.AuditLogFilter = config.AuditLogFilter{Prefixes: []string{"/audited/"}}
+		},
+	})
+	defer clus.Terminate(t)
+
+	ctx := context.Background()
+	cli := clus.Client(0)
+	if _, err := cli.Put(ctx, "/audited/a", "secret-value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Put(ctx, "/other", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Get(ctx, "/audited/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Compact(ctx, 1000); err == nil {
+		t.Fatal("expected compaction of a future revision to fail")
+	}
+
+	authSetupRoot(t, integration.ToGRPC(cli).Auth)
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: cli.Endpoints(), Username: "root", Password: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rootc.Close()
+	if _, err = rootc.Delete(ctx, "/", clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+
+	type record struct{ method, user, result string }
+	var got []record
+	for _, e := range logs.FilterMessage("audit").All() {
+		m := e.ContextMap()
+		if strings.Contains(strings.Join(fieldStrings(m), " "), "secret") {
+			t.Errorf("expected values and passwords not to be recorded, got %v", m)
+		}
+		got = append(got, record{method: m["method"].(string), user: m["user"].(string), result: m["result"].(string)})
+	}
+	want := []record{
+		{method: "Put", user: "", result: "success"},
+		{method: "Compact", user: "", result: "failure"},
+		{method: "UserAdd", user: "", result: "success"},
+		{method: "RoleAdd", user: "", result: "success"},
+		{method: "UserGrantRole", user: "", result: "success"},
+		{method: "AuthEnable", user: "", result: "success"},
+		{method: "Authenticate", user: "root", result: "success"},
+		{method: "DeleteRange", user: "root", result: "success"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected audit records %+v, got %+v", want, got)
+	}
+}
+
+func fieldStrings(m map[string]interface{}) (ss []string) {
+	for _, v := range m {
+		if s, ok := v.(string); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
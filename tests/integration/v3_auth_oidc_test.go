@@ -0,0 +1,114 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"google.golang.org/grpc/metadata"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3AuthOIDC ensures the users of OIDC ID tokens get the permissions of
+// the roles their groups are mapped to, on all the members, and not those of
+// the roles named like their unmapped groups.
+func TestV3AuthOIDC(t *testing.T) {
+	integration.BeforeTest(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err = os.WriteFile(jwksFile, jwks, 0600); err != nil {
+		t.Fatal(err)
+	}
+	rolesFile := filepath.Join(dir, "roles.json")
+	if err = os.WriteFile(rolesFile, []byte(`{"engineers": ["role1"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.AuthTokenOIDC = fmt.Sprintf("issuer=https://sso.example.com,audience=etcd,jwks-file=%s,group-roles=%s", jwksFile, rolesFile)
+		},
+	})
+	defer clus.Terminate(t)
+
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, []user{
+		{name: "user1", password: "user1-123", role: "role1", key: "k1", end: "k3"},
+	})
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	tk := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":    "https://sso.example.com",
+		"aud":    "etcd",
+		"sub":    "alice",
+		"groups": []string{"engineers", "root"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	tk.Header["kid"] = "k1"
+	token, err := tk.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range clus.Members {
+		ctx := metadata.AppendToOutgoingContext(context.TODO(), rpctypes.TokenFieldNameGRPC, token)
+		cli := clus.Client(i)
+		if _, err = cli.Put(ctx, "k1", fmt.Sprintf("v%d", i)); err != nil {
+			t.Fatalf("expected put through member %d to be permitted, got %v", i, err)
+		}
+		if _, err = cli.Get(ctx, "k2"); err != nil {
+			t.Fatalf("expected get through member %d to be permitted, got %v", i, err)
+		}
+		if _, err = cli.Put(ctx, "k3", "v"); err != rpctypes.ErrPermissionDenied {
+			t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+		}
+		if _, err = cli.MemberList(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = cli.UserList(ctx); err != rpctypes.ErrPermissionDenied {
+			t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+		}
+	}
+}
@@ -421,3 +421,99 @@ func TestV3AuthOldRevConcurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestV3AuthDelegatedAdmin ensures the users of a role with admin permission
+// on a prefix can manage the users and roles within the prefix.
+func TestV3AuthDelegatedAdmin(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	authc := integration.ToGRPC(clus.Client(0)).Auth
+	authSetupUsers(t, authc, []user{{name: "team-a-admin", password: "123", role: "team-a-admin"}})
+	perm := &authpb.Permission{PermType: authpb.ADMIN, Key: []byte("team-a/"), RangeEnd: []byte("team-a0")}
+	if _, err := authc.RoleGrantPermission(context.TODO(), &pb.AuthRoleGrantPermissionRequest{Name: "team-a-admin", Perm: perm}); err != nil {
+		t.Fatal(err)
+	}
+	authSetupRoot(t, authc)
+
+	adminc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "team-a-admin", Password: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adminc.Close()
+
+	ctx := context.TODO()
+	if _, err = adminc.UserAdd(ctx, "team-a/alice", "alice-123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminc.RoleAdd(ctx, "team-a/rw"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminc.RoleGrantPermission(ctx, "team-a/rw", "team-a/", "team-a0", clientv3.PermissionType(clientv3.PermReadWrite)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminc.UserGrantRole(ctx, "team-a/alice", "team-a/rw"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []func() error{
+		func() error { _, err := adminc.UserAdd(ctx, "bob", "bob-123"); return err },
+		func() error { _, err := adminc.UserChangePassword(ctx, "root", "456"); return err },
+		func() error { _, err := adminc.UserGrantRole(ctx, "team-a/alice", "root"); return err },
+		func() error {
+			_, err := adminc.RoleGrantPermission(ctx, "team-a/rw", "team-b/", "team-b0", clientv3.PermissionType(clientv3.PermRead))
+			return err
+		},
+		func() error { _, err := adminc.AuthDisable(ctx); return err },
+	} {
+		if err = f(); err != rpctypes.ErrPermissionDenied {
+			t.Errorf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+		}
+	}
+
+	ulresp, err := adminc.UserList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ulresp.Users) != 1 || ulresp.Users[0] != "team-a/alice" {
+		t.Errorf("expected only the users of team-a, got %v", ulresp.Users)
+	}
+
+	alicec, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "team-a/alice", Password: "alice-123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alicec.Close()
+	if _, err = alicec.Put(ctx, "team-a/k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = alicec.UserAdd(ctx, "team-a/carol", "carol-123"); err != rpctypes.ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+
+	// the indexes are managed like the users and roles, by prefix
+	if _, err = adminc.IndexPut(ctx, clientv3.Index{Name: "team-a-by-owner", Prefix: []byte("team-a/"), Field: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminc.IndexDelete(ctx, "team-a-by-owner"); err != nil {
+		t.Fatal(err)
+	}
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rootc.Close()
+	if _, err = rootc.IndexPut(ctx, clientv3.Index{Name: "team-b-by-owner", Prefix: []byte("team-b/"), Field: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = adminc.IndexPut(ctx, clientv3.Index{Name: "team-b-by-owner", Prefix: []byte("team-a/"), Field: "owner"}); err != rpctypes.ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+	if _, err = adminc.IndexDelete(ctx, "team-b-by-owner"); err != rpctypes.ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+	if _, err = adminc.IndexDelete(ctx, "no-such-index"); err != rpctypes.ErrIndexNotFound {
+		t.Errorf("expected %v, got %v", rpctypes.ErrIndexNotFound, err)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3cdc"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// fakeKafkaRESTProxy records the events produced to a topic through the
+// Kafka REST proxy API.
+type fakeKafkaRESTProxy struct {
+	*httptest.Server
+
+	mu  sync.Mutex
+	evs []v3cdc.Event
+}
+
+func newFakeKafkaRESTProxy(t *testing.T) *fakeKafkaRESTProxy {
+	p := &fakeKafkaRESTProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pr struct {
+			Records []struct {
+				Key   []byte `json:"key"`
+				Value []byte `json:"value"`
+			} `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+			t.Errorf("invalid produce request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		type offset struct {
+			Partition int32 `json:"partition"`
+			Offset    int64 `json:"offset"`
+		}
+		var resp struct {
+			Offsets []offset `json:"offsets"`
+		}
+		p.mu.Lock()
+		for _, rec := range pr.Records {
+			var ev v3cdc.Event
+			if err := json.Unmarshal(rec.Value, &ev); err != nil {
+				t.Errorf("invalid event: %v", err)
+			}
+			resp.Offsets = append(resp.Offsets, offset{Offset: int64(len(p.evs))})
+			p.evs = append(p.evs, ev)
+		}
+		p.mu.Unlock()
+		json.NewEncoder(w).Encode(resp)
+	}))
+	return p
+}
+
+func (p *fakeKafkaRESTProxy) events() []v3cdc.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]v3cdc.Event(nil), p.evs...)
+}
+
+// waitEvent waits for the event of key at rev to be published.
+func (p *fakeKafkaRESTProxy) waitEvent(t *testing.T, key string, rev int64) {
+	t.Helper()
+	for deadline := time.Now().Add(30 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		for _, ev := range p.events() {
+			if string(ev.Key) == key && ev.Revision == rev {
+				return
+			}
+		}
+	}
+	t.Fatalf("event of %s at revision %d not published", key, rev)
+}
+
+// TestV3CDCSink ensures that the events of the keys of the prefix of a sink
+// are published in order by the leader, and again by the next leader from the
+// checkpoint of the sink once the leader stops.
+func TestV3CDCSink(t *testing.T) {
+	integration.BeforeTest(t)
+
+	proxy := newFakeKafkaRESTProxy(t)
+	defer proxy.Close()
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.CDCSinks = []config.CDCSink{{Name: "test", URL: "kafka+" + proxy.URL + "/etcd-events", Prefix: "/cdc/", BatchSize: 2}}
+		},
+	})
+	defer clus.Terminate(t)
+
+	lead := clus.WaitLeader(t)
+	cli := clus.Client((lead + 1) % 3)
+	ctx := context.Background()
+
+	// the events of a new sink are published once the leader checkpointed it
+	var start int64
+	for deadline := time.Now().Add(30 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		resp, err := cli.Put(ctx, "/cdc/start", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evs := proxy.events(); len(evs) > 0 {
+			start = evs[0].Revision
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no event published up to revision %d", resp.Header.Revision)
+		}
+	}
+
+	put := func(key string) int64 {
+		t.Helper()
+		resp, err := cli.Put(ctx, key, "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Revision
+	}
+	revs := map[string]int64{"/cdc/a": put("/cdc/a"), "/other": put("/other")}
+	revs["/cdc/b"] = put("/cdc/b")
+	proxy.waitEvent(t, "/cdc/b", revs["/cdc/b"])
+
+	clus.Members[lead].Stop(t)
+	clus.WaitMembersForLeader(t, append(append([]*integration.Member{}, clus.Members[:lead]...), clus.Members[lead+1:]...))
+	resp, err := cli.Delete(ctx, "/cdc/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy.waitEvent(t, "/cdc/a", resp.Header.Revision)
+
+	// the events are published at least once, in revision order from the
+	// checkpoint of the leader
+	evs := proxy.events()
+	published := make(map[int64]v3cdc.Event)
+	for i, ev := range evs {
+		if i > 0 && ev.Revision <= evs[i-1].Revision {
+			if _, ok := published[ev.Revision]; !ok {
+				t.Errorf("event %d at revision %d published out of order, after revision %d", i, ev.Revision, evs[i-1].Revision)
+			}
+		}
+		if ev.Revision >= start {
+			published[ev.Revision] = ev
+		}
+	}
+	for key, rev := range revs {
+		ev, ok := published[rev]
+		if key == "/other" {
+			if ok {
+				t.Errorf("event of %s out of the prefix published", key)
+			}
+			continue
+		}
+		if !ok || string(ev.Key) != key || ev.Type != "PUT" {
+			t.Errorf("expected the PUT of %s at revision %d, got %+v", key, rev, ev)
+		}
+	}
+	if ev := published[resp.Header.Revision]; ev.Type != "DELETE" || ev.Version != 0 {
+		t.Errorf("expected the DELETE of /cdc/a, got %+v", ev)
+	}
+	if err = clus.Members[lead].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,10 +16,14 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/namespace"
+	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 )
 
@@ -78,3 +82,109 @@ func TestKVWithEmptyValue(t *testing.T) {
 	}
 	t.Logf("delete keys:%d", respDel.Deleted)
 }
+
+// TestKVCompactionRetentionPolicies ensures that the auto compactions keep the
+// versions of the keys retained by the retention policies.
+func TestKVCompactionRetentionPolicies(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.AutoCompactionMode = "periodic"
+			cfg.AutoCompactionRetention = time.Second
+			cfg.CompactionRetentionPolicies = []config.CompactionRetentionPolicy{{Prefix: "/config/", Versions: 2}}
+		},
+	})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.Background()
+
+	var configRevs []int64
+	for i := 0; i < 4; i++ {
+		resp, err := kv.Put(ctx, "/config/a", fmt.Sprint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		configRevs = append(configRevs, resp.Header.Revision)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := kv.Put(ctx, "/events/a", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the compactions go past the history of /config/a
+	lastRev := configRevs[3] + 10
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, err := kv.Get(ctx, "/events/a", clientv3.WithRev(lastRev-1))
+		if err == rpctypes.ErrCompacted {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the auto compaction")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// the last 2 versions of /config/a stay readable, not the previous ones
+	for i, rev := range configRevs[2:] {
+		resp, err := kv.Get(ctx, "/config/a", clientv3.WithRev(rev))
+		if err != nil {
+			t.Fatalf("expected the revision %d retained, got %v", rev, err)
+		}
+		if want := fmt.Sprint(i + 2); len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != want {
+			t.Errorf("expected %q at revision %d, got %+v", want, rev, resp.Kvs)
+		}
+	}
+	if _, err := kv.Get(ctx, "/config/", clientv3.WithPrefix(), clientv3.WithRev(configRevs[1])); err != rpctypes.ErrCompacted {
+		t.Errorf("expected the revision %d compacted, got %v", configRevs[1], err)
+	}
+}
+
+// TestKVRangeRateLimits ensures that requests on the keys of a prefix are
+// rejected once over its rate limits, and requests on other keys are not.
+func TestKVRangeRateLimits(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.RangeRateLimits = []config.RangeRateLimit{
+				{Prefix: "/limited/", ReadRequestsPerSecond: 0.01, WriteRequestsPerSecond: 0.01},
+			}
+		},
+	})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.Background()
+
+	if _, err := kv.Put(ctx, "/limited/a", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Put(ctx, "/limited/b", "v"); err != rpctypes.ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrRangeRateLimitExceeded, err)
+	}
+	if _, err := kv.Txn(ctx).Then(clientv3.OpPut("/other", "v"), clientv3.OpDelete("/limited/a")).Commit(); err != rpctypes.ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrRangeRateLimitExceeded, err)
+	}
+	if _, err := kv.Put(ctx, "/other", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a range over the prefix counts against its read limit
+	if _, err := kv.Get(ctx, "/", clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Get(ctx, "/limited/a"); err != rpctypes.ErrRangeRateLimitExceeded {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrRangeRateLimitExceeded, err)
+	}
+	if _, err := kv.Get(ctx, "/other"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 
 	"google.golang.org/grpc/codes"
@@ -33,6 +34,63 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// TestV3LeaseExpireBatched ensures expired leases attaching more keys than
+// a revocation batch allows get all their keys deleted.
+func TestV3LeaseExpireBatched(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.LeaseRevokeMaxKeys = 3
+			cfg.LeaseRevokeSpread = 10 * time.Millisecond
+		},
+	})
+	defer clus.Terminate(t)
+
+	ctx := context.Background()
+	kvc := integration.ToGRPC(clus.RandClient()).KV
+	lc := integration.ToGRPC(clus.RandClient()).Lease
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		lresp, err := lc.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, lresp.ID)
+		for j := 0; j < 5; j++ {
+			key := []byte(fmt.Sprintf("foo/%d/%d", i, j))
+			if _, err := kvc.Put(ctx, &pb.PutRequest{Key: key, Lease: lresp.ID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		rresp, err := kvc.Range(ctx, &pb.RangeRequest{Key: []byte("foo/"), RangeEnd: []byte("foo0"), CountOnly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rresp.Count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the keys of the expired leases to be deleted, %d left", rresp.Count)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, id := range ids {
+		resp, err := lc.LeaseTimeToLive(ctx, &pb.LeaseTimeToLiveRequest{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.TTL != -1 {
+			t.Errorf("expected lease %x to be revoked, got ttl %d", id, resp.TTL)
+		}
+	}
+}
+
 // TestV3LeasePrmote ensures the newly elected leader can promote itself
 // to the primary lessor, refresh the leases and start to manage leases.
 // TODO: use customized clock to make this test go faster?
@@ -423,6 +481,65 @@ func TestV3LeaseCheckpoint(t *testing.T) {
 	}
 }
 
+// TestV3LeaseFastRenewThroughFollower ensures a keepalive sent to a follower reaches the
+// leader's fast renew path and that the cleared checkpoint survives a leader change.
+func TestV3LeaseFastRenewThroughFollower(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                    3,
+		EnableLeaseCheckpoint:   true,
+		LeaseCheckpointInterval: time.Second,
+		LeaseFastRenew:          true,
+	})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaderID := clus.WaitLeader(t)
+	follower := integration.ToGRPC(clus.Client((leaderID + 1) % 3))
+	lresp, err := follower.Lease.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for a checkpoint to lower the remaining TTL below the granted TTL
+	time.Sleep(3 * time.Second)
+
+	lac, err := follower.Lease.LeaseKeepAlive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lac.Send(&pb.LeaseKeepAliveRequest{ID: lresp.ID}); err != nil {
+		t.Fatal(err)
+	}
+	kresp, err := lac.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kresp.TTL != 300 {
+		t.Fatalf("expected renewed ttl 300, got %d", kresp.TTL)
+	}
+	lac.CloseSend()
+
+	// the leader replicates the pending clear on its next checkpoint tick
+	time.Sleep(time.Second)
+
+	leader := clus.Members[leaderID]
+	leader.Stop(t)
+	time.Sleep(time.Duration(3*integration.ElectionTicks) * integration.TickDuration)
+	leader.Restart(t)
+	newLeaderID := clus.WaitLeader(t)
+
+	ttlresp, err := integration.ToGRPC(clus.Client(newLeaderID)).Lease.LeaseTimeToLive(ctx, &pb.LeaseTimeToLiveRequest{ID: lresp.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttlresp.TTL < 295 {
+		t.Errorf("expected lease ttl >= 295 after leader change, got %d", ttlresp.TTL)
+	}
+}
+
 // TestV3LeaseExists creates a lease on a random client and confirms it exists in the cluster.
 func TestV3LeaseExists(t *testing.T) {
 	integration.BeforeTest(t)
@@ -0,0 +1,181 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestV3RequestIDRetry ensures that a mutation retried with the same request
+// ID is applied once, across leader changes and restarts, and that the retries
+// get the response of the first attempt.
+func TestV3RequestIDRetry(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.RequestIDWindow = 100
+		},
+	})
+	defer clus.Terminate(t)
+
+	lead := clus.WaitLeader(t)
+	cli := clus.Client((lead + 1) % 3)
+	ctx := clientv3.WithRequestID(context.Background(), "put-foo")
+
+	first, err := cli.Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, err := cli.Txn(clientv3.WithRequestID(context.Background(), "txn-foo")).
+		If(clientv3.Compare(clientv3.Version("foo"), "=", 1)).
+		Then(clientv3.OpPut("foo", "baz"), clientv3.OpGet("foo")).
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !txn.Succeeded {
+		t.Fatal("expected the txn to succeed")
+	}
+
+	// the retries are answered by another leader.
+	clus.Members[lead].Stop(t)
+	clus.WaitMembersForLeader(t, append(append([]*integration.Member{}, clus.Members[:lead]...), clus.Members[lead+1:]...))
+
+	retry, err := cli.Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry.Header.Revision != first.Header.Revision {
+		t.Fatalf("expected the revision of the first attempt %d, got %d", first.Header.Revision, retry.Header.Revision)
+	}
+	txnRetry, err := cli.Txn(clientv3.WithRequestID(context.Background(), "txn-foo")).
+		If(clientv3.Compare(clientv3.Version("foo"), "=", 1)).
+		Then(clientv3.OpPut("foo", "baz"), clientv3.OpGet("foo")).
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !txnRetry.Succeeded || txnRetry.Header.Revision != txn.Header.Revision {
+		t.Fatalf("expected the response of the first attempt %v, got %v", txn, txnRetry)
+	}
+	if kvs := txnRetry.Responses[1].GetResponseRange().Kvs; len(kvs) != 1 || string(kvs[0].Value) != "baz" {
+		t.Fatalf("expected the range of the first attempt, got %v", kvs)
+	}
+
+	if _, err = cli.Put(ctx, "foo", "other"); err != rpctypes.ErrRequestIDConflict {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrRequestIDConflict, err)
+	}
+
+	// the restarted member remembers the request IDs.
+	if err = clus.Members[lead].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	clus.WaitLeader(t)
+	resp, err := clus.Client(lead).Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Revision != first.Header.Revision {
+		t.Fatalf("expected the revision of the first attempt %d, got %d", first.Header.Revision, resp.Header.Revision)
+	}
+
+	gresp, err := cli.Get(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv := gresp.Kvs[0]; kv.Version != 2 || string(kv.Value) != "baz" || gresp.Header.Revision != txn.Header.Revision {
+		t.Fatalf("expected foo to be written twice, got %v at revision %d", kv, gresp.Header.Revision)
+	}
+}
+
+// TestV3RequestIDRetryWithTTL ensures that a put with a ttl retried through
+// another member is applied once, although the members attach it to leases of
+// their own.
+func TestV3RequestIDRetryWithTTL(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 3,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.RequestIDWindow = 100
+		},
+	})
+	defer clus.Terminate(t)
+
+	ctx := clientv3.WithRequestID(context.Background(), "put-ttl")
+	first, err := clus.Client(0).Put(ctx, "foo", "bar", clientv3.WithTTL(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, err := clus.Client(1).Put(ctx, "foo", "bar", clientv3.WithTTL(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry.Header.Revision != first.Header.Revision {
+		t.Fatalf("expected the revision of the first attempt %d, got %d", first.Header.Revision, retry.Header.Revision)
+	}
+	if _, err = clus.Client(2).Put(ctx, "foo", "bar", clientv3.WithTTL(30)); err != rpctypes.ErrRequestIDConflict {
+		t.Fatalf("expected %v for another ttl, got %v", rpctypes.ErrRequestIDConflict, err)
+	}
+
+	gresp, err := clus.Client(1).Get(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv := gresp.Kvs[0]; kv.Version != 1 || kv.ModRevision != first.Header.Revision {
+		t.Fatalf("expected foo to be written once, got %v", kv)
+	}
+}
+
+// TestV3RequestIDWindow ensures that the request IDs beyond the window are
+// forgotten.
+func TestV3RequestIDWindow(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.RequestIDWindow = 2
+		},
+	})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	put := func(id string) int64 {
+		t.Helper()
+		resp, err := cli.Put(clientv3.WithRequestID(context.Background(), id), "foo", "bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Revision
+	}
+	first := put("a")
+	put("b")
+	if rev := put("a"); rev != first {
+		t.Fatalf("expected the revision of the first attempt %d, got %d", first, rev)
+	}
+	put("c")
+	if rev := put("a"); rev == first {
+		t.Fatalf("expected request id a to be forgotten, got the revision of the first attempt %d", rev)
+	}
+}
@@ -300,3 +300,62 @@ func TestSTMSerializableSnapshotPut(t *testing.T) {
 		t.Fatalf("bad version. got %+v, expected version 2", resp)
 	}
 }
+
+// TestSTMSnapshot tests that snapshot isolation reads from a consistent
+// revision and only conflicts on written keys, allowing write skew.
+func TestSTMSnapshot(t *testing.T) {
+	integration.BeforeTest(t)
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.Client(0)
+	for _, k := range []string{"x", "y"} {
+		_, err := cli.Put(context.TODO(), k, "0")
+		testutil.AssertNil(t, err)
+	}
+
+	for _, tt := range []struct {
+		iso       concurrency.Isolation
+		conflicts uint64
+	}{
+		{concurrency.Snapshot, 0},
+		{concurrency.SerializableSnapshot, 1},
+	} {
+		_, err := cli.Put(context.TODO(), "y", "0")
+		testutil.AssertNil(t, err)
+		var stats concurrency.STMCounters
+		attempts := 0
+		applyf := func(stm concurrency.STM) error {
+			attempts++
+			x := stm.Get("x")
+			if attempts == 1 {
+				// a concurrent transaction writes a key read, after the first read
+				_, err := cli.Put(context.TODO(), "y", fmt.Sprintf("%d", tt.iso))
+				testutil.AssertNil(t, err)
+			}
+			y := stm.Get("y")
+			if attempts == 1 && y != "0" {
+				t.Errorf("expected the read of the revision of the first read, got %q", y)
+			}
+			stm.Put("x", x+y)
+			if got := stm.Get("x"); got != x+y {
+				t.Errorf("expected to read the write %q, got %q", x+y, got)
+			}
+			return nil
+		}
+		_, err = concurrency.NewSTM(cli, applyf, concurrency.WithIsolation(tt.iso), concurrency.WithStats(&stats))
+		testutil.AssertNil(t, err)
+		if stats.Commits() != 1 || stats.Conflicts() != tt.conflicts || stats.Retries() != tt.conflicts || stats.Aborts() != 0 {
+			t.Fatalf("isolation %d: unexpected stats %+v", tt.iso, stats)
+		}
+	}
+
+	var stats concurrency.STMCounters
+	_, err := concurrency.NewSTM(cli, func(stm concurrency.STM) error {
+		return fmt.Errorf("aborted")
+	}, concurrency.WithIsolation(concurrency.Snapshot), concurrency.WithStats(&stats))
+	if err == nil || stats.Aborts() != 1 || stats.Commits() != 0 {
+		t.Fatalf("expected an aborted transaction, got %v with stats %+v", err, stats)
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 )
@@ -1290,3 +1291,89 @@ func TestV3WatchCloseCancelRace(t *testing.T) {
 		t.Fatalf("expected %s watch, got %s", expected, minWatches)
 	}
 }
+
+// TestV3WatchMaxEventsPerSecond ensures that a stream over the events per
+// second limit is throttled without delaying the events of other streams.
+func TestV3WatchMaxEventsPerSecond(t *testing.T) {
+	integration.BeforeTest(t)
+	if integration.ThroughProxy {
+		t.Skip("the proxy multiplexes the watches of its clients on shared streams")
+	}
+
+	const maxEventsPerSecond, events = 10, 30
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size: 1,
+		ServerConfigMutator: func(cfg *config.ServerConfig) {
+			cfg.WatchMaxEventsPerSecond = maxEventsPerSecond
+		},
+	})
+	defer clus.Terminate(t)
+
+	wAPI := integration.ToGRPC(clus.RandClient()).Watch
+	kvc := integration.ToGRPC(clus.RandClient()).KV
+	for i := 0; i < events; i++ {
+		if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte(fmt.Sprintf("greedy/%d", i)), Value: []byte("bar")}); err != nil {
+			t.Fatalf("couldn't put key (%v)", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	watch := func(req *pb.WatchCreateRequest) pb.Watch_WatchClient {
+		wStream, err := wAPI.Watch(ctx)
+		if err != nil {
+			t.Fatalf("wAPI.Watch error: %v", err)
+		}
+		if err = wStream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: req}}); err != nil {
+			t.Fatalf("wStream.Send error: %v", err)
+		}
+		if wresp, err := wStream.Recv(); err != nil || !wresp.Created {
+			t.Fatalf("expected watch to be created, got %v, %v", wresp, err)
+		}
+		return wStream
+	}
+	other := watch(&pb.WatchCreateRequest{Key: []byte("other")})
+	start := time.Now()
+	greedy := watch(&pb.WatchCreateRequest{Key: []byte("greedy/"), RangeEnd: []byte("greedy0"), StartRevision: 1})
+
+	greedyc := make(chan time.Duration, 1)
+	go func() {
+		received := 0
+		for received < events {
+			wresp, err := greedy.Recv()
+			if err != nil {
+				t.Errorf("wStream.Recv error: %v", err)
+				return
+			}
+			received += len(wresp.Events)
+		}
+		greedyc <- time.Since(start)
+	}()
+
+	if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("other"), Value: []byte("bar")}); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+	wresp, err := other.Recv()
+	if err != nil {
+		t.Fatalf("wStream.Recv error: %v", err)
+	}
+	if len(wresp.Events) != 1 {
+		t.Fatalf("expected the put of other, got %+v", wresp)
+	}
+	select {
+	case took := <-greedyc:
+		t.Fatalf("expected the greedy stream to be throttled while the other stream is served, it received %d events in %v", events, took)
+	default:
+	}
+
+	// the burst of the limit is sent at once, the rest at the limit
+	minDuration := time.Duration(events-maxEventsPerSecond) * time.Second / maxEventsPerSecond
+	select {
+	case took := <-greedyc:
+		if took < minDuration*3/4 {
+			t.Errorf("expected %d events to take at least %v at %d events per second, took %v", events, minDuration, maxEventsPerSecond, took)
+		}
+	case <-ctx.Done():
+		t.Fatalf("the greedy stream did not receive %d events", events)
+	}
+}